@@ -0,0 +1,460 @@
+// Package cli - MCP test client.
+// This file implements the client subcommand, which spawns a server instance, speaks MCP to
+// it over stdio and runs tools/list or tools/call, so a setup can be verified from the
+// command line without wiring up an AI assistant. Conversion calls exit with a status that
+// tells complete, partial and failed conversions apart, for scripted pipelines.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// clientProtocolVersion is the MCP protocol version the client requests.
+const clientProtocolVersion = "2024-11-05"
+
+// invalidParamsCode is the JSON-RPC error code of calls whose arguments do not match the
+// tool's inputSchema.
+const invalidParamsCode = -32602
+
+// Exit status of the client subcommand
+const (
+	exitOK      = 0 // The call succeeded; conversions converted every document completely
+	exitError   = 1 // Usage error, such as invalid tool arguments, server failure or failed call of a tool other than a conversion
+	exitPartial = 2 // Some documents or pages failed, or had warnings with --fail-on-warning
+	exitFailed  = 3 // The conversion failed, or no document of a batch was converted
+)
+
+// conversionTools are the tools whose failures are conversion failures, exiting with
+// exitFailed. get_job_result returns the result of an asynchronous batch conversion.
+var conversionTools = map[string]bool{
+	"convert_pdf_to_markdown":    true,
+	"convert_pdf_pages":          true,
+	"convert_pdfs_in_directory":  true,
+	"convert_images_to_markdown": true,
+	"split_pdf_by_sections":      true,
+	"get_job_result":             true,
+}
+
+// ClientCLI implements the client subcommand.
+type ClientCLI struct{}
+
+// clientOptions are the parsed arguments of the client subcommand.
+type clientOptions struct {
+	command  string                 // "list" or "call"
+	tool     string                 // Tool to call
+	toolArgs map[string]interface{} // Arguments of the tool call
+	server   []string               // Server command line; this executable when empty
+	envFile  string                 // Env file passed to the server
+	asJSON   bool                   // Print the raw JSON-RPC result
+	verbose  bool                   // Show the server log
+	quiet    bool                   // Print nothing but errors; the exit status tells the outcome
+	failWarn bool                   // Conversions with warnings exit with exitPartial
+}
+
+// Run executes the client with the provided arguments.
+//
+// Usage:
+//
+//	client list [options]
+//	client call <tool> [<json> | <key>=<value>...] [options]
+//
+// Options are -f <file> to configure the server from an env file, --server <command> to
+// spawn another server command, --json to print the raw result, -v to show the server log,
+// -q to print nothing but errors and --fail-on-warning to treat conversion warnings as
+// partial failures. Values of key=value arguments are read as JSON when they parse, such as
+// true or 12, and as strings otherwise.
+//
+// The exit status is 0 on success, 1 for usage errors, server failures and failed calls of
+// other tools, 2 when some documents or pages of a conversion failed and 3 when the
+// conversion failed as a whole.
+func (c *ClientCLI) Run(args []string) int {
+	opts, err := parseClientArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: pdf-md-mcp client list [-f <file>] [--server <command>] [--json] [-v]")
+		fmt.Fprintln(os.Stderr, "       pdf-md-mcp client call <tool> [<json> | <key>=<value>...] [-f <file>] [--server <command>] [--json] [-v] [-q] [--fail-on-warning]")
+		return exitError
+	}
+
+	client, err := startClient(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	code := client.run(opts)
+	if err := client.close(); err != nil && code == exitOK {
+		fmt.Fprintf(os.Stderr, "Error: server exited with %v\n", err)
+		code = exitError
+	}
+	if code != exitOK && client.serverFailed && !opts.verbose && client.log.Len() > 0 {
+		fmt.Fprintf(os.Stderr, "\nServer log:\n%s", client.log.String())
+	}
+	return code
+}
+
+// parseClientArgs extracts the command, tool arguments and options.
+func parseClientArgs(args []string) (clientOptions, error) {
+	var opts clientOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--json":
+			opts.asJSON = true
+		case args[i] == "-v" || args[i] == "--verbose":
+			opts.verbose = true
+		case args[i] == "-q" || args[i] == "--quiet":
+			opts.quiet = true
+		case args[i] == "--fail-on-warning":
+			opts.failWarn = true
+		case args[i] == "-f" && i+1 < len(args):
+			opts.envFile = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--file="):
+			opts.envFile = strings.TrimPrefix(args[i], "--file=")
+		case args[i] == "--server" && i+1 < len(args):
+			opts.server = strings.Fields(args[i+1])
+			i++
+		case strings.HasPrefix(args[i], "--server="):
+			opts.server = strings.Fields(strings.TrimPrefix(args[i], "--server="))
+		case strings.HasPrefix(args[i], "-"):
+			return opts, fmt.Errorf("unknown flag: %s", args[i])
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) == 0 {
+		return opts, fmt.Errorf("missing command (list or call)")
+	}
+
+	opts.command = positional[0]
+	switch opts.command {
+	case "list":
+		if len(positional) > 1 {
+			return opts, fmt.Errorf("unexpected argument: %s", positional[1])
+		}
+	case "call":
+		if len(positional) < 2 {
+			return opts, fmt.Errorf("missing tool name")
+		}
+		opts.tool = positional[1]
+		toolArgs, err := parseToolArgs(positional[2:])
+		if err != nil {
+			return opts, err
+		}
+		opts.toolArgs = toolArgs
+	default:
+		return opts, fmt.Errorf("unknown command: %s", opts.command)
+	}
+	return opts, nil
+}
+
+// parseToolArgs reads tool arguments given as one JSON object or as key=value pairs.
+func parseToolArgs(args []string) (map[string]interface{}, error) {
+	toolArgs := map[string]interface{}{}
+	if len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		if err := json.Unmarshal([]byte(args[0]), &toolArgs); err != nil {
+			return nil, fmt.Errorf("invalid JSON arguments: %v", err)
+		}
+		return toolArgs, nil
+	}
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("tool arguments must be <key>=<value> or a JSON object, got %q", arg)
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			toolArgs[key] = parsed
+		} else {
+			toolArgs[key] = value
+		}
+	}
+	return toolArgs, nil
+}
+
+// mcpClient is a client connection to a spawned server.
+type mcpClient struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	enc    *json.Encoder
+	log    bytes.Buffer // Server log, shown when the server fails
+	nextID int
+
+	serverFailed bool // Whether a request failed without an error response from the server
+}
+
+// rpcError is an error response of the server.
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func (e *rpcError) Error() string {
+	if e.Data != nil {
+		data, _ := json.Marshal(e.Data)
+		return fmt.Sprintf("%s (code %d, data %s)", e.Message, e.Code, data)
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// startClient spawns the server with the stdio transport.
+func startClient(opts clientOptions) (*mcpClient, error) {
+	server := opts.server
+	if len(server) == 0 {
+		self, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate the server executable: %v", err)
+		}
+		server = []string{self}
+	}
+
+	cmd := exec.Command(server[0], server[1:]...)
+	cmd.Env = os.Environ()
+	if opts.envFile != "" {
+		// The server loads pdf_md_mcp.env without overriding variables already set
+		env, err := godotenv.Read(opts.envFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", opts.envFile, err)
+		}
+		for key, value := range env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+	cmd.Env = append(cmd.Env, "MCP_TRANSPORT=stdio")
+
+	c := &mcpClient{cmd: cmd}
+	if opts.verbose {
+		cmd.Stderr = os.Stderr
+	} else {
+		cmd.Stderr = &c.log
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start server %s: %v", server[0], err)
+	}
+	c.stdin, c.stdout, c.enc = stdin, bufio.NewReader(stdout), json.NewEncoder(stdin)
+	return c, nil
+}
+
+// run initializes the session and executes the command, returning the exit status.
+func (c *mcpClient) run(opts clientOptions) int {
+	initParams := map[string]interface{}{
+		"protocolVersion": clientProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "pdf-md-mcp-client", "version": "1.0.0"},
+	}
+	if _, err := c.request("initialize", initParams); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: initialize failed: %v\n", err)
+		return exitError
+	}
+	if err := c.notify("notifications/initialized"); err != nil {
+		c.serverFailed = true
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	var result map[string]interface{}
+	var err error
+	if opts.command == "list" {
+		result, err = c.request("tools/list", map[string]interface{}{})
+	} else {
+		result, err = c.request("tools/call", map[string]interface{}{"name": opts.tool, "arguments": opts.toolArgs})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var serverErr *rpcError
+		// Invalid arguments are a usage error, not a failed conversion
+		if errors.As(err, &serverErr) && serverErr.Code != invalidParamsCode && conversionTools[opts.tool] {
+			return exitFailed
+		}
+		return exitError
+	}
+
+	isError, _ := result["isError"].(bool)
+	switch {
+	case opts.quiet && isError:
+		fmt.Fprint(os.Stderr, formatToolResult(result))
+	case opts.quiet:
+	case opts.asJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		fmt.Println(string(data))
+	case opts.command == "list":
+		fmt.Print(formatToolList(result))
+	default:
+		fmt.Print(formatToolResult(result))
+	}
+	switch {
+	case isError && conversionTools[opts.tool]:
+		return exitFailed
+	case isError:
+		return exitError
+	case opts.command == "call":
+		return conversionExitCode(result, opts.failWarn)
+	}
+	return exitOK
+}
+
+// conversionExitCode returns the exit status of a successful tool call from the conversion
+// summary in its structuredContent: exitFailed when no document of a batch was converted,
+// exitPartial when documents or pages failed, or had warnings and failWarn is set, and
+// exitOK otherwise, including for results that are not conversion summaries.
+func conversionExitCode(result map[string]interface{}, failWarn bool) int {
+	summary, _ := result["structuredContent"].(map[string]interface{})
+	count := func(key string) int {
+		n, _ := summary[key].(float64)
+		return int(n)
+	}
+	if _, batch := summary["file_count"]; batch {
+		switch {
+		case count("file_count") > 0 && count("success_count") == 0:
+			return exitFailed
+		case count("failure_count") > 0 || count("partial_count") > 0:
+			return exitPartial
+		case failWarn && count("warning_count") > 0:
+			return exitPartial
+		}
+		return exitOK
+	}
+	warnings, _ := summary["warnings"].([]interface{})
+	switch {
+	case summary["status"] == pdfconv.StatusPartial:
+		return exitPartial
+	case failWarn && len(warnings) > 0:
+		return exitPartial
+	}
+	return exitOK
+}
+
+// request sends a request and waits for its response, returning a *rpcError for an error
+// response. Requests from the server are answered as unsupported, since the client declares
+// no capabilities, and notifications are skipped.
+func (c *mcpClient) request(method string, params map[string]interface{}) (result map[string]interface{}, err error) {
+	defer func() {
+		var serverErr *rpcError
+		if err != nil && !errors.As(err, &serverErr) {
+			c.serverFailed = true
+		}
+	}()
+	c.nextID++
+	id := c.nextID
+	if err := c.enc.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %v", method, err)
+	}
+	for {
+		line, err := c.stdout.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, fmt.Errorf("server closed the connection before answering %s", method)
+			}
+			continue
+		}
+		var message struct {
+			ID     interface{}            `json:"id"`
+			Method string                 `json:"method"`
+			Result map[string]interface{} `json:"result"`
+			Error  *rpcError              `json:"error"`
+		}
+		if err := json.Unmarshal(line, &message); err != nil {
+			return nil, fmt.Errorf("invalid message from server: %v", err)
+		}
+		if message.Method != "" {
+			if message.ID != nil {
+				_ = c.enc.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": message.ID, "error": map[string]interface{}{"code": -32601, "message": "Method not found: " + message.Method}})
+			}
+			continue
+		}
+		if fmt.Sprint(message.ID) != fmt.Sprint(id) {
+			continue
+		}
+		if message.Error != nil {
+			return nil, message.Error
+		}
+		return message.Result, nil
+	}
+}
+
+// notify sends a notification.
+func (c *mcpClient) notify(method string) error {
+	if err := c.enc.Encode(map[string]interface{}{"jsonrpc": "2.0", "method": method}); err != nil {
+		return fmt.Errorf("failed to send %s: %v", method, err)
+	}
+	return nil
+}
+
+// close ends the session by closing the server's input and waits for it to exit.
+func (c *mcpClient) close() error {
+	_ = c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+// formatToolList renders a tools/list result as one line per tool.
+func formatToolList(result map[string]interface{}) string {
+	tools, _ := result["tools"].([]interface{})
+	type entry struct{ name, description string }
+	var entries []entry
+	width := 0
+	for _, item := range tools {
+		tool, _ := item.(map[string]interface{})
+		name, _ := tool["name"].(string)
+		description, _ := tool["description"].(string)
+		// The first sentence keeps the list readable
+		if i := strings.Index(description, ". "); i >= 0 {
+			description = description[:i+1]
+		}
+		entries = append(entries, entry{name, description})
+		width = max(width, len(name))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Tools (%d):\n", len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&out, "  %-*s  %s\n", width, e.name, e.description)
+	}
+	return out.String()
+}
+
+// formatToolResult renders the content of a tools/call result: text as is and other content
+// types as a placeholder line.
+func formatToolResult(result map[string]interface{}) string {
+	content, _ := result["content"].([]interface{})
+	var out strings.Builder
+	for _, item := range content {
+		block, _ := item.(map[string]interface{})
+		if text, ok := block["text"].(string); ok && block["type"] == "text" {
+			out.WriteString(text)
+			if !strings.HasSuffix(text, "\n") {
+				out.WriteString("\n")
+			}
+			continue
+		}
+		fmt.Fprintf(&out, "[%v content]\n", block["type"])
+	}
+	return out.String()
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+	"datasheet-to-md-mcp/mcp"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// testServerEnv makes the test binary run as an MCP server, for the client to spawn.
+const testServerEnv = "PDF_MD_MCP_TEST_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testServerEnv) == "1" {
+		os.Exit(runTestServer())
+	}
+	os.Exit(m.Run())
+}
+
+// runTestServer serves MCP over stdio like the pdf-md-mcp executable.
+func runTestServer() int {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return 2
+	}
+	log := logger.NewLogger("error")
+	converter, err := pdfconv.NewPDFConverter(cfg, log)
+	if err != nil {
+		return 2
+	}
+	if err := mcp.NewMCPHandler(converter, log).HandleStdio(); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func TestClientCLI(t *testing.T) {
+	t.Setenv(testServerEnv, "1")
+	t.Setenv("OUTPUT_BASE_DIR", t.TempDir())
+	run := func(args ...string) (int, string, string) {
+		var code int
+		var out string
+		errOut := captureStderr(func() {
+			out = captureStdout(func() {
+				code = (&ClientCLI{}).Run(append([]string{"--server", os.Args[0]}, args...))
+			})
+		})
+		return code, out, errOut
+	}
+
+	code, out, errOut := run("list")
+	if code != 0 || !strings.Contains(out, "Tools (") || !strings.Contains(out, "get_server_version") {
+		t.Errorf("expected the tool list, got %d:\n%s%s", code, out, errOut)
+	}
+
+	code, out, _ = run("list", "--json")
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); code != 0 || err != nil || result["tools"] == nil {
+		t.Errorf("expected the tools/list result as JSON, got %d %v:\n%s", code, err, out)
+	}
+
+	code, out, errOut = run("call", "get_server_version")
+	if code != 0 || !strings.Contains(out, "Server Version") {
+		t.Errorf("expected the server version, got %d:\n%s%s", code, out, errOut)
+	}
+
+	code, _, errOut = run("call", "no_such_tool", "x=1")
+	if code != 1 || !strings.Contains(errOut, "unexpected tool name: no_such_tool (code -32603)") {
+		t.Errorf("expected the tool error, got %d:\n%s", code, errOut)
+	}
+	if strings.Contains(errOut, "Server log:") {
+		t.Errorf("expected no server log for an error response, got:\n%s", errOut)
+	}
+
+	code, _, errOut = run("call", "get_server_version", "--server", "/nonexistent/server")
+	if code != 1 || !strings.Contains(errOut, "failed to start server") {
+		t.Errorf("expected a start failure, got %d:\n%s", code, errOut)
+	}
+
+	for _, args := range [][]string{{}, {"show"}, {"call"}, {"list", "extra"}, {"list", "--bogus"}, {"call", "tool", "novalue"}} {
+		if code, _, _ := run(args...); code != 1 {
+			t.Errorf("expected usage error for %v, got %d", args, code)
+		}
+	}
+}
+
+func TestClientCLI_ConversionExitCodes(t *testing.T) {
+	t.Setenv(testServerEnv, "1")
+	t.Setenv("OUTPUT_BASE_DIR", t.TempDir())
+	run := func(args ...string) (int, string, string) {
+		var code int
+		var out string
+		errOut := captureStderr(func() {
+			out = captureStdout(func() {
+				code = (&ClientCLI{}).Run(append([]string{"--server", os.Args[0]}, args...))
+			})
+		})
+		return code, out, errOut
+	}
+	inputDir := t.TempDir()
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	doc.AddPage()
+	doc.Cell(40, 10, "LM317 3-Terminal Adjustable Regulator")
+	if err := doc.OutputFileAndClose(filepath.Join(inputDir, "lm317.pdf")); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+
+	code, out, errOut := run("call", "convert_pdfs_in_directory", "input_dir="+inputDir)
+	if code != exitOK || !strings.Contains(out, "lm317.pdf") {
+		t.Errorf("expected a successful batch, got %d:\n%s%s", code, out, errOut)
+	}
+
+	brokenPath := filepath.Join(inputDir, "broken.pdf")
+	if err := os.WriteFile(brokenPath, []byte("not a pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, out, errOut = run("call", "convert_pdfs_in_directory", "input_dir="+inputDir, "-q")
+	if code != exitPartial || out != "" {
+		t.Errorf("expected a quiet partial failure, got %d:\n%s%s", code, out, errOut)
+	}
+
+	code, _, errOut = run("call", "convert_pdf_to_markdown", "pdf_path="+brokenPath, "--quiet")
+	if code != exitFailed || !strings.Contains(errOut, "Error: ") {
+		t.Errorf("expected a failed conversion, got %d:\n%s", code, errOut)
+	}
+
+	code, _, errOut = run("call", "convert_pdf_to_markdown", "pdf_path="+brokenPath, "dry_run=yes")
+	if code != exitError || !strings.Contains(errOut, "invalid parameter dry_run: expected boolean, got string (code -32602") {
+		t.Errorf("expected invalid arguments reported as a usage error, got %d:\n%s", code, errOut)
+	}
+
+	if err := os.Remove(filepath.Join(inputDir, "lm317.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	if code, out, errOut := run("call", "convert_pdfs_in_directory", "input_dir="+inputDir); code != exitFailed {
+		t.Errorf("expected a failed batch, got %d:\n%s%s", code, out, errOut)
+	}
+}
+
+func TestConversionExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  string
+		failWarn bool
+		want     int
+	}{
+		{"complete", `{"status": "complete"}`, false, exitOK},
+		{"partial", `{"status": "partial"}`, false, exitPartial},
+		{"warnings", `{"status": "complete", "warnings": ["low text coverage"]}`, false, exitOK},
+		{"warnings failing", `{"status": "complete", "warnings": ["low text coverage"]}`, true, exitPartial},
+		{"batch", `{"file_count": 2, "success_count": 2, "failure_count": 0, "partial_count": 0, "warning_count": 1}`, false, exitOK},
+		{"batch warnings failing", `{"file_count": 2, "success_count": 2, "failure_count": 0, "partial_count": 0, "warning_count": 1}`, true, exitPartial},
+		{"batch partial", `{"file_count": 2, "success_count": 2, "failure_count": 0, "partial_count": 1}`, false, exitPartial},
+		{"batch failures", `{"file_count": 2, "success_count": 1, "failure_count": 1, "partial_count": 0}`, false, exitPartial},
+		{"batch failed", `{"file_count": 2, "success_count": 0, "failure_count": 2, "partial_count": 0}`, false, exitFailed},
+		{"empty batch", `{"file_count": 0, "success_count": 0, "failure_count": 0, "partial_count": 0}`, false, exitOK},
+		{"other tool", `{"version": "1.0.0"}`, true, exitOK},
+	}
+	for _, tt := range tests {
+		var summary map[string]interface{}
+		if err := json.Unmarshal([]byte(tt.summary), &summary); err != nil {
+			t.Fatal(err)
+		}
+		if got := conversionExitCode(map[string]interface{}{"structuredContent": summary}, tt.failWarn); got != tt.want {
+			t.Errorf("%s: conversionExitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseToolArgs(t *testing.T) {
+	got, err := parseToolArgs([]string{"pdf_path=/data/a b.pdf", "dry_run=true", "max=3", "preset=fast"})
+	want := map[string]interface{}{"pdf_path": "/data/a b.pdf", "dry_run": true, "max": 3.0, "preset": "fast"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseToolArgs(key=value) = %v, %v; want %v", got, err, want)
+	}
+
+	got, err = parseToolArgs([]string{`{"pdf_path": "/data/a.pdf", "dry_run": true}`})
+	want = map[string]interface{}{"pdf_path": "/data/a.pdf", "dry_run": true}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseToolArgs(json) = %v, %v; want %v", got, err, want)
+	}
+
+	if _, err := parseToolArgs([]string{`{"pdf_path": `}); err == nil {
+		t.Error("expected an error for invalid JSON arguments")
+	}
+}
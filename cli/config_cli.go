@@ -31,6 +31,9 @@ type ConfigCLI struct{}
 //   - set [-f <file>] KEY VALUE        Update or add a setting in the config file
 //   - get [-f <file>] KEY              Print a specific setting value from the config file
 //   - list-keys                        Print available keys with descriptions and defaults
+//   - docs [-o <file>]                 Print the Markdown option reference of all keys
+//   - export [-f <file>] [--format <json|env>] [-o <file>]   Print the settings of the config file as JSON
+//   - import <file.json> [-f <file>] [--force]   Write the settings of a JSON object to the config file
 //   - help                             Show usage
 func (c *ConfigCLI) Run(args []string) int {
 	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
@@ -96,6 +99,33 @@ func (c *ConfigCLI) Run(args []string) int {
 	case "list-keys":
 		c.listKeys()
 		return 0
+	case "export":
+		file := parseOnlyFileFlag(args[1:])
+		format := parseFormatFlag(args[1:], "json")
+		if err := c.export(file, format, parseOutputFlag(args[1:])); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "import":
+		source, file, force, err := parseImportArgs(args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		count, err := c.importJSON(source, file, force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stdout, "Imported %d setting(s) into %s\n", count, file)
+		return 0
+	case "docs":
+		if err := c.docs(parseOutputFlag(args[1:])); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", sub)
 		c.printHelp()
@@ -114,6 +144,9 @@ Usage:
   pdf-md-mcp config set [-f <file>] KEY VALUE
   pdf-md-mcp config get [-f <file>] KEY
   pdf-md-mcp config list-keys
+  pdf-md-mcp config docs [-o <file>]
+  pdf-md-mcp config export [-f <file>] [--format json|env] [-o <file>]
+  pdf-md-mcp config import <file.json> [-f <file>] [--force]
 
 Description:
   Manage the environment-based configuration used by the PDF→Markdown MCP server.
@@ -127,6 +160,9 @@ Commands:
   set         Update a configuration value
   get         Read a single configuration value
   list-keys   Show all available configuration keys
+  docs        Print the option reference (descriptions, defaults, valid values) as Markdown
+  export      Print the settings of a config file as a JSON object (or normalized .env)
+  import      Write the settings of a JSON object to a config file, validating known keys
 
 Examples:
   # Create a new .env file using defaults (will not overwrite existing file)
@@ -152,34 +188,19 @@ Examples:
 
   # See available keys with descriptions
   pdf-md-mcp config list-keys
+
+  # Write the option reference as Markdown
+  pdf-md-mcp config docs -o CONFIGURATION.md
+
+  # Store the configuration as JSON, e.g. in a secrets manager, and convert it back
+  pdf-md-mcp config export -f .env --format json -o config.json
+  pdf-md-mcp config import config.json -f .env --force
 `)
 }
 
-// knownKeys defines supported configuration keys, their descriptions (from README), and default values.
-// This allows generating and validating config files without external libraries.
-var knownKeys = []struct {
-	Key         string
-	Description string
-	Default     string
-}{
-	{"PDF_INPUT_DIR", "Directory containing PDF files to process", ""},
-	{"OUTPUT_BASE_DIR", "Base output directory", "./output"},
-	{"MCP_SERVER_NAME", "Server identification name", "pdf-to-markdown-server"},
-	{"MCP_SERVER_VERSION", "Server version", "1.0.0"},
-	{"IMAGE_MAX_DPI", "Maximum image resolution (72-600)", "300"},
-	{"IMAGE_FORMAT", "Image output format (png/jpg)", "png"},
-	{"PRESERVE_ASPECT_RATIO", "Maintain image aspect ratios", "true"},
-	{"DETECT_DIAGRAMS", "Enable diagram detection and PlantUML generation", "false"},
-	{"DIAGRAM_CONFIDENCE", "Minimum confidence for diagram detection (0.0-1.0)", "0.7"},
-	{"PLANTUML_STYLE", "PlantUML diagram style (default/blueprint/modern)", "default"},
-	{"PLANTUML_COLOR_SCHEME", "PlantUML color scheme (mono/color/auto)", "auto"},
-	{"INCLUDE_TOC", "Generate table of contents", "true"},
-	{"BASE_HEADER_LEVEL", "Starting header level (1-6)", "1"},
-	{"EXTRACT_TABLES", "Enable table extraction", "true"},
-	{"EXTRACT_IMAGES", "Enable image extraction", "true"},
-	{"LOG_LEVEL", "Logging verbosity (debug/info/warn/error)", "info"},
-	{"MCP_TRANSPORT", "Transport method for MCP communication (stdio)", "stdio"},
-}
+// knownKeys defines supported configuration keys, their descriptions and default values.
+// It is the key registry of the config package, which also validates values.
+var knownKeys = config.Keys
 
 func defaultFilePath() string {
 	return ".env"
@@ -333,6 +354,101 @@ func (c *ConfigCLI) listKeys() {
 	}
 }
 
+// docs prints the Markdown option reference, or writes it to path when given.
+func (c *ConfigCLI) docs(path string) error {
+	content := config.KeyDocs()
+	if path == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	fmt.Fprintf(os.Stdout, "Option reference written to %s\n", path)
+	return nil
+}
+
+// export prints the settings of the config file as a JSON object of strings, or as a
+// normalized .env file, writing to out when given.
+func (c *ConfigCLI) export(path, format, out string) error {
+	if path == "" {
+		path = defaultFilePath()
+	}
+	envMap, err := godotenv.Read(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+
+	var content string
+	switch strings.ToLower(format) {
+	case "json":
+		b, err := json.MarshalIndent(envMap, "", "  ")
+		if err != nil {
+			return err
+		}
+		content = string(b) + "\n"
+	case "env":
+		content = formatEnvFile(envMap)
+	default:
+		return fmt.Errorf("unsupported export format: %s (use json or env)", format)
+	}
+
+	if out == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if err := os.WriteFile(out, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	return nil
+}
+
+// importJSON writes the settings of a JSON object to the env file at path. Values may be
+// strings, numbers or booleans; known keys are validated before anything is written. An
+// existing file is only replaced with force.
+func (c *ConfigCLI) importJSON(source, path string, force bool) (int, error) {
+	if path == "" {
+		path = defaultFilePath()
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return 0, fmt.Errorf("%s is not a JSON object of settings: %w", source, err)
+	}
+
+	envMap := make(map[string]string, len(settings))
+	for key, raw := range settings {
+		var value string
+		switch v := raw.(type) {
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+			value = ""
+		default:
+			return 0, fmt.Errorf("%s must be a string, number or boolean", key)
+		}
+		if err := validateValue(key, value); err != nil {
+			return 0, err
+		}
+		envMap[key] = value
+	}
+
+	if _, err := os.Stat(path); err == nil && !force {
+		return 0, fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return len(envMap), writeEnvFile(path, envMap)
+}
+
 // Helpers
 
 func parseFileFlag(args []string) (string, bool) {
@@ -386,12 +502,46 @@ func parseOnlyFileFlag(args []string) string {
 	return file
 }
 
+func parseImportArgs(args []string) (string, string, bool, error) {
+	source := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-f" {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(args[i], "-") && source == "" {
+			source = args[i]
+		}
+	}
+	if source == "" {
+		return "", "", false, errors.New("JSON file path required for import command")
+	}
+	file, force := parseFileFlag(args)
+	return source, file, force, nil
+}
+
+func parseOutputFlag(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-o" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(args[i], "--output=") {
+			return strings.TrimPrefix(args[i], "--output=")
+		}
+	}
+	return ""
+}
+
 func parseFormatFlag(args []string, def string) string {
 	format := def
 	for i := 0; i < len(args); i++ {
 		if strings.HasPrefix(args[i], "--format=") {
 			format = strings.TrimPrefix(args[i], "--format=")
 		}
+		if args[i] == "--format" && i+1 < len(args) {
+			format = args[i+1]
+			i++
+		}
 	}
 	return format
 }
@@ -426,64 +576,21 @@ func isKnownKey(key string) bool {
 }
 
 func validateValue(key, value string) error {
-	switch key {
-	case "IMAGE_MAX_DPI":
-		v, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("%s must be an integer: %v", key, err)
-		}
-		if v < 72 || v > 600 {
-			return fmt.Errorf("%s must be between 72 and 600", key)
-		}
-	case "IMAGE_FORMAT":
-		vv := strings.ToLower(value)
-		if vv != "png" && vv != "jpg" {
-			return fmt.Errorf("%s must be 'png' or 'jpg'", key)
-		}
-	case "DIAGRAM_CONFIDENCE":
-		f, err := strconv.ParseFloat(value, 64)
-		if err != nil || f < 0.0 || f > 1.0 {
-			return fmt.Errorf("%s must be a number between 0.0 and 1.0", key)
-		}
-	case "BASE_HEADER_LEVEL":
-		v, err := strconv.Atoi(value)
-		if err != nil || v < 1 || v > 6 {
-			return fmt.Errorf("%s must be an integer between 1 and 6", key)
-		}
-	case "LOG_LEVEL":
-		vv := strings.ToLower(value)
-		if !inSet(vv, []string{"debug", "info", "warn", "error"}) {
-			return fmt.Errorf("%s must be one of: debug, info, warn, error", key)
-		}
-	case "MCP_TRANSPORT":
-		vv := strings.ToLower(value)
-		if !inSet(vv, []string{"stdio"}) {
-			return fmt.Errorf("%s must be: stdio", key)
-		}
-	case "PLANTUML_STYLE":
-		vv := strings.ToLower(value)
-		if !inSet(vv, []string{"default", "blueprint", "modern"}) {
-			return fmt.Errorf("%s must be one of: default, blueprint, modern", key)
-		}
-	case "PLANTUML_COLOR_SCHEME":
-		vv := strings.ToLower(value)
-		if !inSet(vv, []string{"mono", "color", "auto"}) {
-			return fmt.Errorf("%s must be one of: mono, color, auto", key)
-		}
+	if spec, ok := config.LookupKey(key); ok {
+		return spec.Check(value)
 	}
 	return nil
 }
 
-func inSet(v string, set []string) bool {
-	for _, s := range set {
-		if s == v {
-			return true
-		}
+func writeEnvFile(path string, env map[string]string) error {
+	if err := os.WriteFile(path, []byte(formatEnvFile(env)), 0o644); err != nil {
+		return fmt.Errorf("failed to open config for writing: %w", err)
 	}
-	return false
+	return nil
 }
 
-func writeEnvFile(path string, env map[string]string) error {
+// formatEnvFile renders settings as an env file.
+func formatEnvFile(env map[string]string) string {
 	// Write keys in a stable order: known keys first, then any extras sorted
 	order := make([]string, 0, len(knownKeys))
 	for _, k := range knownKeys {
@@ -497,50 +604,122 @@ func writeEnvFile(path string, env map[string]string) error {
 	}
 	sort.Strings(extras)
 
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to open config for writing: %w", err)
-	}
-	defer f.Close()
-	w := bufio.NewWriter(f)
-	defer w.Flush()
-
+	var w strings.Builder
 	w.WriteString("# Generated by pdf-md-mcp config CLI\n")
 	w.WriteString("# Edit values as needed.\n\n")
 
 	for _, k := range order {
 		if v, ok := env[k]; ok {
-			w.WriteString(fmt.Sprintf("%s=%s\n", k, v))
+			w.WriteString(fmt.Sprintf("%s=%s\n", k, envValue(v)))
 		}
 	}
 	if len(extras) > 0 {
 		w.WriteString("\n# Additional settings\n")
 		for _, k := range extras {
-			w.WriteString(fmt.Sprintf("%s=%s\n", k, env[k]))
+			w.WriteString(fmt.Sprintf("%s=%s\n", k, envValue(env[k])))
 		}
 	}
-	return nil
+	return w.String()
+}
+
+// envValue quotes values that would not read back unchanged, such as values with '#', '$',
+// quotes or line breaks, which JSON imports may contain. Single quotes keep the value
+// literal; values with single quotes or line breaks are double-quoted and escaped.
+func envValue(v string) string {
+	if parsed, err := godotenv.Unmarshal("KEY=" + v); err == nil && parsed["KEY"] == v {
+		return v
+	}
+	if !strings.ContainsAny(v, "'\n\r") {
+		return "'" + v + "'"
+	}
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\r", "\\r", "$", "\\$")
+	return "\"" + r.Replace(v) + "\""
 }
 
 func (c *ConfigCLI) configToEnvPairs(cfg *config.Config) []string {
 	pairs := []string{
 		fmt.Sprintf("PDF_INPUT_DIR=%s", cfg.PDFInputDir),
+		fmt.Sprintf("FOLLOW_SYMLINKS=%t", cfg.FollowSymlinks),
+		fmt.Sprintf("INCLUDE_HIDDEN_DIRS=%t", cfg.IncludeHiddenDirs),
+		fmt.Sprintf("MAX_DISCOVERED_FILES=%d", cfg.MaxDiscoveredFiles),
 		fmt.Sprintf("OUTPUT_BASE_DIR=%s", cfg.OutputBaseDir),
+		fmt.Sprintf("DISK_SPACE_CHECK=%t", cfg.DiskSpaceCheck),
+		fmt.Sprintf("MAX_OUTPUT_AGE_DAYS=%d", cfg.MaxOutputAgeDays),
+		fmt.Sprintf("MAX_OUTPUT_TOTAL_GB=%g", cfg.MaxOutputTotalGB),
+		fmt.Sprintf("ESTIMATE_SAMPLE_PAGES=%d", cfg.EstimateSamplePages),
+		fmt.Sprintf("TMP_DIR=%s", cfg.TempDir),
+		fmt.Sprintf("INCREMENTAL_CONVERSION=%t", cfg.Incremental),
+		fmt.Sprintf("CHANGE_REPORT=%t", cfg.ChangeReport),
+		fmt.Sprintf("BATCH_SUMMARY=%t", cfg.BatchSummary),
+		fmt.Sprintf("BATCH_RESULTS=%t", cfg.BatchResults),
+		fmt.Sprintf("STRICT_MODE=%t", cfg.StrictMode),
 		fmt.Sprintf("MCP_SERVER_NAME=%s", cfg.ServerName),
 		fmt.Sprintf("MCP_SERVER_VERSION=%s", cfg.ServerVersion),
+		fmt.Sprintf("UPDATE_CHECK=%t", cfg.UpdateCheck),
+		fmt.Sprintf("UPDATE_CHECK_URL=%s", cfg.UpdateCheckURL),
+		fmt.Sprintf("LOCALE=%s", cfg.Locale),
+		fmt.Sprintf("RESTRICTED_MODE=%t", cfg.RestrictedMode),
+		fmt.Sprintf("CONVERSION_PRESET=%s", cfg.Preset),
 		fmt.Sprintf("IMAGE_MAX_DPI=%d", cfg.ImageMaxDPI),
 		fmt.Sprintf("IMAGE_FORMAT=%s", cfg.ImageFormat),
 		fmt.Sprintf("PRESERVE_ASPECT_RATIO=%t", cfg.PreserveAspectRatio),
+		fmt.Sprintf("IMAGE_PLACEMENT=%s", cfg.ImagePlacement),
+		fmt.Sprintf("OCR_LANGUAGE=%s", cfg.OCRLanguage),
+		fmt.Sprintf("TEXT_MIN_CONFIDENCE=%g", cfg.TextMinConfidence),
+		fmt.Sprintf("IMAGE_ALT_TEXT=%s", cfg.ImageAltText),
+		fmt.Sprintf("RENDERER=%s", cfg.Renderer),
+		fmt.Sprintf("THUMBNAIL_WIDTH=%d", cfg.ThumbnailWidth),
 		fmt.Sprintf("DETECT_DIAGRAMS=%t", cfg.DetectDiagrams),
+		fmt.Sprintf("DETECT_DIAGRAM_TYPES=%s", strings.Join(cfg.DiagramTypes, ",")),
 		fmt.Sprintf("DIAGRAM_CONFIDENCE=%g", cfg.DiagramConfidence),
+		fmt.Sprintf("DIAGRAM_MIN_SIZE=%d", cfg.DiagramMinSize),
+		fmt.Sprintf("DIAGRAM_MIN_EDGE_DENSITY=%g", cfg.DiagramMinEdgeDensity),
+		fmt.Sprintf("CURVE_DATA=%t", cfg.CurveData),
 		fmt.Sprintf("PLANTUML_STYLE=%s", cfg.PlantUMLStyle),
 		fmt.Sprintf("PLANTUML_COLOR_SCHEME=%s", cfg.PlantUMLColorScheme),
+		fmt.Sprintf("PLANTUML_TEMPLATE_DIR=%s", cfg.PlantUMLTemplateDir),
+		fmt.Sprintf("OUTPUT_FORMAT=%s", cfg.OutputFormat),
+		fmt.Sprintf("MARKDOWN_FLAVOR=%s", cfg.MarkdownFlavor),
 		fmt.Sprintf("INCLUDE_TOC=%t", cfg.IncludeTOC),
 		fmt.Sprintf("BASE_HEADER_LEVEL=%d", cfg.BaseHeaderLevel),
+		fmt.Sprintf("MAX_HEADER_DEPTH=%d", cfg.MaxHeaderDepth),
+		fmt.Sprintf("HEADER_OVERFLOW=%s", cfg.HeaderOverflow),
 		fmt.Sprintf("EXTRACT_TABLES=%t", cfg.ExtractTables),
+		fmt.Sprintf("TABLE_MIN_CONFIDENCE=%g", cfg.TableMinConfidence),
+		fmt.Sprintf("NORMALIZE_SPEC_TABLES=%t", cfg.NormalizeSpecTables),
+		fmt.Sprintf("BOLD_TYP_VALUES=%t", cfg.BoldTypValues),
+		fmt.Sprintf("VARIANT_TABLES=%t", cfg.VariantTables),
+		fmt.Sprintf("PACKAGE_DIMENSIONS=%t", cfg.PackageDimensions),
+		fmt.Sprintf("APPLICATION_BOM=%t", cfg.ApplicationBOM),
+		fmt.Sprintf("COMPLIANCE_TAGS=%t", cfg.ComplianceTags),
+		fmt.Sprintf("ERRATA_LINKS=%s", cfg.ErrataLinks),
+		fmt.Sprintf("MONOSPACE_CODE=%t", cfg.MonospaceCode),
+		fmt.Sprintf("PRESERVE_EMPHASIS=%t", cfg.PreserveEmphasis),
+		fmt.Sprintf("DETECT_CALLOUTS=%t", cfg.DetectCallouts),
+		fmt.Sprintf("JOIN_PAGE_BREAKS=%t", cfg.JoinPageBreaks),
+		fmt.Sprintf("NUMBER_LOCALE=%s", cfg.NumberLocale),
+		fmt.Sprintf("CONTENT_LANGUAGE_FILTER=%s", cfg.ContentLanguage),
 		fmt.Sprintf("EXTRACT_IMAGES=%t", cfg.ExtractImages),
+		fmt.Sprintf("ACCESSIBLE_OUTPUT=%t", cfg.AccessibleOutput),
+		fmt.Sprintf("MARKDOWN_LINT=%t", cfg.MarkdownLint),
+		fmt.Sprintf("MARKDOWN_LINT_RULES=%s", strings.Join(cfg.MarkdownLintRules, ",")),
+		fmt.Sprintf("MARKDOWN_LINE_LENGTH=%d", cfg.MarkdownLineLength),
+		fmt.Sprintf("HEADER_KEYWORD_LOCALES=%s", strings.Join(cfg.HeaderKeywordLocales, ",")),
+		fmt.Sprintf("HEADER_KEYWORDS=%s", strings.Join(cfg.HeaderKeywords, ",")),
+		fmt.Sprintf("HEADER_REGEXES=%s", strings.Join(cfg.HeaderRegexes, ";")),
+		fmt.Sprintf("HEADING_NORMALIZE=%t", cfg.HeadingNormalize),
+		fmt.Sprintf("SECTION_NUMBERING=%s", cfg.SectionNumbering),
+		fmt.Sprintf("CROSS_REFERENCE_LINKS=%t", cfg.CrossReferenceLinks),
 		fmt.Sprintf("LOG_LEVEL=%s", cfg.LogLevel),
 		fmt.Sprintf("MCP_TRANSPORT=%s", cfg.Transport),
+		fmt.Sprintf("MCP_HTTP_ADDR=%s", cfg.HTTPAddr),
+		fmt.Sprintf("MCP_ALLOWED_HOSTS=%s", strings.Join(cfg.AllowedHosts, ",")),
+		fmt.Sprintf("MCP_TRACE=%t", cfg.Trace),
+		fmt.Sprintf("MCP_TRACE_FILE=%s", cfg.TraceFile),
+		fmt.Sprintf("MAX_MESSAGE_SIZE_MB=%d", cfg.MaxMessageSizeMB),
+		fmt.Sprintf("MAX_CONCURRENT_TOOL_CALLS=%d", cfg.MaxToolCalls),
+		fmt.Sprintf("CONVERSION_TIMEOUT=%d", cfg.ToolTimeout),
+		fmt.Sprintf("MAX_QUEUED_JOBS=%d", cfg.MaxQueuedJobs),
 	}
 	return pairs
 }
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/joho/godotenv"
+
 	cfgpkg "datasheet-to-md-mcp/config"
 )
 
@@ -355,6 +357,99 @@ func TestValidateValue(t *testing.T) {
 	if err := validateValue("PLANTUML_COLOR_SCHEME", "vivid"); err == nil {
 		t.Errorf("expected error for invalid PLANTUML_COLOR_SCHEME")
 	}
+	if err := validateValue("OCR_LANGUAGE", "eng+deu"); err != nil {
+		t.Errorf("unexpected error for valid OCR_LANGUAGE: %v", err)
+	}
+	if err := validateValue("OCR_LANGUAGE", "eng deu"); err == nil {
+		t.Errorf("expected error for invalid OCR_LANGUAGE")
+	}
+	if err := validateValue("NUMBER_LOCALE", "de"); err != nil {
+		t.Errorf("unexpected error for valid NUMBER_LOCALE: %v", err)
+	}
+	if err := validateValue("NUMBER_LOCALE", "klingon"); err == nil {
+		t.Errorf("expected error for invalid NUMBER_LOCALE")
+	}
+	if err := validateValue("CONTENT_LANGUAGE_FILTER", "ZH"); err != nil {
+		t.Errorf("unexpected error for valid CONTENT_LANGUAGE_FILTER: %v", err)
+	}
+	if err := validateValue("CONTENT_LANGUAGE_FILTER", "chinese"); err == nil {
+		t.Errorf("expected error for invalid CONTENT_LANGUAGE_FILTER")
+	}
+	if err := validateValue("IMAGE_ALT_TEXT", "OCR"); err != nil {
+		t.Errorf("unexpected error for valid IMAGE_ALT_TEXT: %v", err)
+	}
+	if err := validateValue("IMAGE_ALT_TEXT", "describe"); err == nil {
+		t.Errorf("expected error for invalid IMAGE_ALT_TEXT")
+	}
+	if err := validateValue("MARKDOWN_LINT_RULES", "MD009,MD013"); err != nil {
+		t.Errorf("unexpected error for valid MARKDOWN_LINT_RULES: %v", err)
+	}
+	if err := validateValue("MARKDOWN_LINT_RULES", "MD009,MD033"); err == nil {
+		t.Errorf("expected error for unsupported MARKDOWN_LINT_RULES entry")
+	}
+	if err := validateValue("MARKDOWN_LINE_LENGTH", "-80"); err == nil {
+		t.Errorf("expected error for negative MARKDOWN_LINE_LENGTH")
+	}
+	if err := validateValue("IMAGE_PLACEMENT", "top"); err == nil {
+		t.Errorf("expected error for invalid IMAGE_PLACEMENT")
+	}
+	if err := validateValue("HEADER_KEYWORD_LOCALES", "en,klingon"); err == nil {
+		t.Errorf("expected error for unknown HEADER_KEYWORD_LOCALES entry")
+	}
+	if err := validateValue("HEADER_REGEXES", `^\d+;(`); err == nil {
+		t.Errorf("expected error for invalid HEADER_REGEXES entry")
+	}
+	if err := validateValue("HEADER_REGEXES", `^\d+\.\d+;^Table`); err != nil {
+		t.Errorf("unexpected error for valid HEADER_REGEXES: %v", err)
+	}
+	if err := validateValue("MAX_OUTPUT_AGE_DAYS", "-3"); err == nil {
+		t.Errorf("expected error for negative MAX_OUTPUT_AGE_DAYS")
+	}
+	if err := validateValue("MAX_DISCOVERED_FILES", "many"); err == nil {
+		t.Errorf("expected error for non-numeric MAX_DISCOVERED_FILES")
+	}
+	if err := validateValue("MAX_OUTPUT_TOTAL_GB", "0.5"); err != nil {
+		t.Errorf("unexpected error for valid MAX_OUTPUT_TOTAL_GB: %v", err)
+	}
+	if err := validateValue("ESTIMATE_SAMPLE_PAGES", "-1"); err == nil {
+		t.Errorf("expected error for negative ESTIMATE_SAMPLE_PAGES")
+	}
+	if err := validateValue("TEXT_MIN_CONFIDENCE", "2"); err == nil {
+		t.Errorf("expected error for out-of-range TEXT_MIN_CONFIDENCE")
+	}
+	if err := validateValue("TABLE_MIN_CONFIDENCE", "1.5"); err == nil {
+		t.Errorf("expected error for out-of-range TABLE_MIN_CONFIDENCE")
+	}
+	if err := validateValue("OUTPUT_FORMAT", "docx"); err == nil {
+		t.Errorf("expected error for invalid OUTPUT_FORMAT")
+	}
+	if err := validateValue("MARKDOWN_FLAVOR", "CommonMark"); err != nil {
+		t.Errorf("unexpected error for MARKDOWN_FLAVOR=CommonMark: %v", err)
+	}
+	if err := validateValue("MAX_HEADER_DEPTH", "1"); err == nil {
+		t.Errorf("expected error for MAX_HEADER_DEPTH out of range")
+	}
+	if err := validateValue("HEADER_OVERFLOW", "bold"); err != nil {
+		t.Errorf("unexpected error for HEADER_OVERFLOW=bold: %v", err)
+	}
+	if err := validateValue("SECTION_NUMBERING", "auto"); err == nil {
+		t.Errorf("expected error for invalid SECTION_NUMBERING")
+	}
+	if err := validateValue("SECTION_NUMBERING", "renumber"); err != nil {
+		t.Errorf("unexpected error for valid SECTION_NUMBERING: %v", err)
+	}
+	if err := validateValue("LOCALE", "zh"); err != nil {
+		t.Errorf("unexpected error for valid LOCALE: %v", err)
+	}
+	if err := validateValue("LOCALE", "de"); err == nil {
+		t.Errorf("expected error for unsupported LOCALE")
+	}
+	if err := validateValue("UPDATE_CHECK_URL", "releases.example.com"); err == nil {
+		t.Errorf("expected error for UPDATE_CHECK_URL without a scheme")
+	}
+	if err := validateValue("UPDATE_CHECK_URL", "https://releases.example.com/latest"); err != nil {
+		t.Errorf("unexpected error for valid UPDATE_CHECK_URL: %v", err)
+	}
 }
 
 func TestConfigToEnvPairs(t *testing.T) {
@@ -424,3 +519,88 @@ func TestConfigCLIShowInvalidValues(t *testing.T) {
 		t.Errorf("expected error from show with invalid IMAGE_FORMAT")
 	}
 }
+
+func TestConfigCLIDocs(t *testing.T) {
+	c := &ConfigCLI{}
+	out := captureStdout(func() {
+		if code := c.Run([]string{"docs"}); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+	})
+	for _, item := range knownKeys {
+		if !strings.Contains(out, "| `"+item.Key+"` |") {
+			t.Errorf("option reference missing key %s", item.Key)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "CONFIGURATION.md")
+	captureStdout(func() { c.Run([]string{"docs", "-o", path}) })
+	if data, err := os.ReadFile(path); err != nil || string(data) != out {
+		t.Errorf("expected the option reference written to %s: %v", path, err)
+	}
+}
+
+func TestConfigCLIExportImport(t *testing.T) {
+	c := &ConfigCLI{}
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "source.env")
+	if err := os.WriteFile(envPath, []byte("IMAGE_MAX_DPI=200\nHEADER_REGEXES=^\\d+\\.\\d+ ;^Table\nCUSTOM_SETTING=keep me\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonPath := filepath.Join(dir, "config.json")
+	if code := c.Run([]string{"export", "-f", envPath, "--format", "json", "-o", jsonPath}); code != 0 {
+		t.Fatalf("export failed with exit code %d", code)
+	}
+	var exported map[string]string
+	data, _ := os.ReadFile(jsonPath)
+	if err := json.Unmarshal(data, &exported); err != nil || exported["IMAGE_MAX_DPI"] != "200" || exported["CUSTOM_SETTING"] != "keep me" {
+		t.Fatalf("unexpected export %v: %s", err, data)
+	}
+
+	// Round trip back to an env file
+	target := filepath.Join(dir, "restored.env")
+	out := captureStdout(func() {
+		if code := c.Run([]string{"import", jsonPath, "-f", target}); code != 0 {
+			t.Errorf("import failed with exit code %d", code)
+		}
+	})
+	if !strings.Contains(out, "Imported 3 setting(s)") {
+		t.Errorf("unexpected import output: %s", out)
+	}
+	restored, err := godotenv.Read(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range exported {
+		if restored[key] != value {
+			t.Errorf("%s = %q after the round trip, want %q", key, restored[key], value)
+		}
+	}
+
+	// Typed JSON values are converted, invalid values and existing files are rejected
+	typed := filepath.Join(dir, "typed.json")
+	os.WriteFile(typed, []byte(`{"IMAGE_MAX_DPI": 150, "INCLUDE_TOC": false, "DIAGRAM_CONFIDENCE": 0.85}`), 0644)
+	if _, err := c.importJSON(typed, target, false); err == nil {
+		t.Errorf("expected an error importing over an existing file without --force")
+	}
+	if _, err := c.importJSON(typed, target, true); err != nil {
+		t.Fatalf("import with force failed: %v", err)
+	}
+	if restored, _ := godotenv.Read(target); restored["IMAGE_MAX_DPI"] != "150" || restored["INCLUDE_TOC"] != "false" || restored["DIAGRAM_CONFIDENCE"] != "0.85" {
+		t.Errorf("unexpected typed import: %v", restored)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"IMAGE_MAX_DPI": 9000}`), 0644)
+	if _, err := c.importJSON(invalid, filepath.Join(dir, "new.env"), false); err == nil {
+		t.Errorf("expected a validation error for IMAGE_MAX_DPI 9000")
+	}
+	nested := filepath.Join(dir, "nested.json")
+	os.WriteFile(nested, []byte(`{"LOG_LEVEL": {"value": "debug"}}`), 0644)
+	if _, err := c.importJSON(nested, filepath.Join(dir, "new.env"), false); err == nil {
+		t.Errorf("expected an error for a nested value")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.env")); err == nil {
+		t.Errorf("expected nothing written for rejected imports")
+	}
+}
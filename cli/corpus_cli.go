@@ -0,0 +1,284 @@
+// Package cli - Golden-file regression corpus.
+// This file implements the test-corpus subcommand, which converts a directory of fixture
+// documents and compares the generated Markdown against checked-in golden files.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// Golden corpus settings
+const (
+	GoldenSuffix      = ".golden.md" // Golden file name suffix, next to each fixture
+	MaxDiffLines      = 60           // Diff lines printed per failing fixture
+	maxDiffComparison = 4_000_000    // Largest line matrix compared exactly; larger changes are shown as a block
+)
+
+// CorpusCLI implements the test-corpus subcommand.
+type CorpusCLI struct{}
+
+// Run executes the corpus check with the provided arguments.
+//
+// Usage:
+//
+//	test-corpus <dir> [-f <file>] [--update] [--validate-output]
+//
+// Every supported document directly in <dir> is converted with the configuration from the
+// env file (pdf_md_mcp.env when -f is not given, falling back to the process environment)
+// and its README.md is compared with <name>.golden.md. --update rewrites the golden files.
+// --validate-output also checks the sidecar JSON files of every conversion against their
+// schemas, failing fixtures whose sidecars do not match.
+// The exit code is 1 when any fixture fails or has no golden file.
+func (c *CorpusCLI) Run(args []string) int {
+	dir, envFile, update, validate, err := parseCorpusArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: pdf-md-mcp test-corpus <dir> [-f <file>] [--update] [--validate-output]")
+		return 1
+	}
+
+	restore := snapshotEnv()
+	defer restore()
+	if envMap, err := godotenv.Read(envFile); err == nil {
+		for k, v := range envMap {
+			os.Setenv(k, v)
+		}
+	} else if envFile != "pdf_md_mcp.env" {
+		fmt.Fprintf(os.Stderr, "Error: failed to read config file '%s': %v\n", envFile, err)
+		return 1
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	// Corpus outputs are temporary; never prune the user's configured output directory
+	cfg.MaxOutputAgeDays, cfg.MaxOutputTotalGB = 0, 0
+	converter, err := pdfconv.NewPDFConverter(cfg, logger.NewLogger("error"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fixtures, err := findFixtures(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(fixtures) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no fixture documents found in %s\n", dir)
+		return 1
+	}
+
+	workDir, err := os.MkdirTemp(cfg.TempDir, "pdf-md-corpus-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(workDir)
+
+	passed, failed := 0, 0
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		golden := strings.TrimSuffix(fixture, filepath.Ext(fixture)) + GoldenSuffix
+		result, err := converter.ConvertDocument(fixture, workDir, pdfconv.ConversionOptions{})
+		if err != nil {
+			fmt.Printf("FAIL %s: conversion failed: %v\n", name, err)
+			failed++
+			continue
+		}
+		data, err := os.ReadFile(result.MarkdownFile)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		got := normalizeGolden(string(data))
+		if validate {
+			violations, _, err := pdfconv.ValidateOutput(result.OutputDir)
+			if err == nil && len(violations) > 0 {
+				err = fmt.Errorf("%d schema violation(s), first: %s", len(violations), violations[0])
+			}
+			if err != nil {
+				fmt.Printf("FAIL %s: invalid sidecar output: %v\n", name, err)
+				failed++
+				continue
+			}
+		}
+
+		if update {
+			if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+				fmt.Printf("FAIL %s: failed to write golden file: %v\n", name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("UPDATED %s\n", filepath.Base(golden))
+			passed++
+			continue
+		}
+
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			fmt.Printf("FAIL %s: no golden file %s (run with --update to create it)\n", name, filepath.Base(golden))
+			failed++
+			continue
+		}
+		if diff := lineDiff(normalizeGolden(string(want)), got); diff != "" {
+			fmt.Printf("FAIL %s: output differs from %s\n%s", name, filepath.Base(golden), diff)
+			failed++
+			continue
+		}
+		fmt.Printf("PASS %s\n", name)
+		passed++
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseCorpusArgs extracts the corpus directory, env file and the --update and
+// --validate-output flags.
+func parseCorpusArgs(args []string) (dir, envFile string, update, validate bool, err error) {
+	envFile = "pdf_md_mcp.env"
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--update":
+			update = true
+		case args[i] == "--validate-output":
+			validate = true
+		case args[i] == "-f" && i+1 < len(args):
+			envFile = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--file="):
+			envFile = strings.TrimPrefix(args[i], "--file=")
+		case strings.HasPrefix(args[i], "-"):
+			return "", "", false, false, fmt.Errorf("unknown flag: %s", args[i])
+		case dir == "":
+			dir = args[i]
+		default:
+			return "", "", false, false, fmt.Errorf("unexpected argument: %s", args[i])
+		}
+	}
+	if dir == "" {
+		return "", "", false, false, fmt.Errorf("corpus directory is required")
+	}
+	return dir, envFile, update, validate, nil
+}
+
+// findFixtures returns the supported documents directly in dir, sorted by name.
+func findFixtures(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus directory: %v", err)
+	}
+	var fixtures []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && pdfconv.IsSupportedDocument(entry.Name()) {
+			fixtures = append(fixtures, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(fixtures)
+	return fixtures, nil
+}
+
+// normalizeGolden removes differences that do not matter for comparison: line endings
+// and trailing whitespace.
+func normalizeGolden(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// lineDiff returns a unified-style line diff of want and got ("-" for golden lines, "+" for
+// generated lines), limited to MaxDiffLines lines, or "" when they are equal.
+func lineDiff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	// Trim the common prefix and suffix, then compare the changed middle
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var lines []string
+	if len(a)*len(b) > maxDiffComparison {
+		for _, line := range a {
+			lines = append(lines, "-"+line)
+		}
+		for _, line := range b {
+			lines = append(lines, "+"+line)
+		}
+	} else {
+		lines = lcsDiff(a, b)
+	}
+
+	var diff strings.Builder
+	diff.WriteString(fmt.Sprintf("@@ line %d @@\n", prefix+1))
+	for i, line := range lines {
+		if i == MaxDiffLines {
+			diff.WriteString(fmt.Sprintf("... %d more diff lines\n", len(lines)-MaxDiffLines))
+			break
+		}
+		diff.WriteString(line + "\n")
+	}
+	return diff.String()
+}
+
+// lcsDiff diffs two line slices through their longest common subsequence.
+func lcsDiff(a, b []string) []string {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			lines = append(lines, "+"+b[j])
+			j++
+		default:
+			lines = append(lines, "-"+a[i])
+			i++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestCorpusCLI_GoldenFiles(t *testing.T) {
+	dir := t.TempDir()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(40, 10, "Golden corpus fixture")
+	if err := pdf.OutputFileAndClose(filepath.Join(dir, "fixture.pdf")); err != nil {
+		t.Fatalf("failed to create fixture pdf: %v", err)
+	}
+	envFile := filepath.Join(dir, "corpus.env")
+	if err := os.WriteFile(envFile, []byte("PDF_INPUT_DIR="+dir+"\nEXTRACT_IMAGES=false\n"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	run := func(args ...string) (int, string) {
+		var code int
+		out := captureStdout(func() {
+			code = (&CorpusCLI{}).Run(append([]string{dir, "-f", envFile}, args...))
+		})
+		return code, out
+	}
+
+	// Without golden files every fixture fails
+	if code, out := run(); code != 1 || !strings.Contains(out, "no golden file fixture.golden.md") {
+		t.Fatalf("expected missing golden failure, got %d: %s", code, out)
+	}
+
+	if code, out := run("--update"); code != 0 || !strings.Contains(out, "UPDATED fixture.golden.md") {
+		t.Fatalf("expected update to succeed, got %d: %s", code, out)
+	}
+	golden := filepath.Join(dir, "fixture.golden.md")
+	data, err := os.ReadFile(golden)
+	if err != nil || !strings.Contains(string(data), "Golden corpus fixture") {
+		t.Fatalf("golden file not written correctly: %v %q", err, data)
+	}
+
+	if code, out := run(); code != 0 || !strings.Contains(out, "PASS fixture.pdf") {
+		t.Fatalf("expected pass against fresh golden, got %d: %s", code, out)
+	}
+
+	// Line endings and trailing whitespace are not differences
+	crlf := strings.ReplaceAll(string(data), "\n", "  \r\n")
+	if err := os.WriteFile(golden, []byte(crlf), 0644); err != nil {
+		t.Fatalf("failed to rewrite golden: %v", err)
+	}
+	if code, out := run(); code != 0 {
+		t.Fatalf("expected CRLF golden to pass, got %d: %s", code, out)
+	}
+
+	edited := strings.Replace(string(data), "Golden corpus fixture", "Edited fixture", 1)
+	if err := os.WriteFile(golden, []byte(edited), 0644); err != nil {
+		t.Fatalf("failed to edit golden: %v", err)
+	}
+	code, out := run()
+	if code != 1 || !strings.Contains(out, "FAIL fixture.pdf") ||
+		!strings.Contains(out, "-Edited fixture") || !strings.Contains(out, "+Golden corpus fixture") {
+		t.Fatalf("expected diff failure, got %d: %s", code, out)
+	}
+}
+
+func TestCorpusCLI_Usage(t *testing.T) {
+	var code int
+	errOut := captureStderr(func() { code = (&CorpusCLI{}).Run(nil) })
+	if code != 1 || !strings.Contains(errOut, "corpus directory is required") {
+		t.Fatalf("expected usage error, got %d: %s", code, errOut)
+	}
+	errOut = captureStderr(func() { code = (&CorpusCLI{}).Run([]string{t.TempDir(), "--bogus"}) })
+	if code != 1 || !strings.Contains(errOut, "unknown flag: --bogus") {
+		t.Fatalf("expected unknown flag error, got %d: %s", code, errOut)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	if diff := lineDiff("a\nb\n", "a\nb\n"); diff != "" {
+		t.Fatalf("expected no diff for equal input, got %q", diff)
+	}
+	diff := lineDiff("a\nb\nc\nd\n", "a\nc\nx\nd\n")
+	want := "@@ line 2 @@\n-b\n c\n+x\n"
+	if diff != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", diff, want)
+	}
+
+	var long []string
+	for i := 0; i < MaxDiffLines+10; i++ {
+		long = append(long, "line")
+	}
+	diff = lineDiff("", strings.Join(long, "\n"))
+	if !strings.Contains(diff, "... 11 more diff lines") {
+		t.Fatalf("expected truncated diff, got:\n%s", diff)
+	}
+}
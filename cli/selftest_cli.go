@@ -0,0 +1,193 @@
+// Package cli - Installation self-test.
+// This file implements the selftest subcommand, which generates a small synthetic datasheet
+// PDF, converts it end to end in a temporary directory with the configured settings and
+// reports each step as PASS or FAIL, so an installation can be verified in seconds without
+// any input documents.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// Self-test document content
+const (
+	selftestTitle  = "SELFTEST-42 Voltage Regulator" // Text of the first page
+	selftestMarker = "Selftest marker 7QX3"          // Text of the last page
+	selftestPages  = 2
+)
+
+// SelftestCLI implements the selftest subcommand.
+type SelftestCLI struct{}
+
+// Run executes the self-test with the provided arguments.
+//
+// Usage:
+//
+//	selftest [-f <file>] [-v]
+//
+// The configuration is read from the env file (pdf_md_mcp.env when -f is not given,
+// falling back to the process environment), with the output redirected to a temporary
+// directory that is removed afterwards. -v shows the conversion log. Optional external
+// tools are reported but do not fail the test. The exit code is 1 when any step fails.
+func (s *SelftestCLI) Run(args []string) int {
+	envFile, verbose, err := parseSelftestArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: pdf-md-mcp selftest [-f <file>] [-v]")
+		return 1
+	}
+
+	failed := 0
+	report := func(step, detail string, err error) bool {
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", step, err)
+			failed++
+			return false
+		}
+		fmt.Printf("PASS %s: %s\n", step, detail)
+		return true
+	}
+	summary := func() int {
+		if failed > 0 {
+			fmt.Printf("\nFAIL: %d step(s) failed\n", failed)
+			return 1
+		}
+		fmt.Println("\nPASS: the installation converts documents")
+		return 0
+	}
+
+	restore := snapshotEnv()
+	defer restore()
+	if envMap, err := godotenv.Read(envFile); err == nil {
+		for k, v := range envMap {
+			os.Setenv(k, v)
+		}
+	} else if envFile != "pdf_md_mcp.env" {
+		report("configuration", "", fmt.Errorf("failed to read config file '%s': %v", envFile, err))
+		return summary()
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		report("configuration", "", err)
+		return summary()
+	}
+	report("configuration", "server version "+cfg.ServerVersion, nil)
+
+	workDir, err := os.MkdirTemp(cfg.TempDir, "pdf-md-selftest-")
+	if !report("temporary directory", "created", err) {
+		return summary()
+	}
+	defer os.RemoveAll(workDir)
+	// Self-test outputs are temporary; never prune or read the user's configured directories
+	cfg.OutputBaseDir, cfg.PDFInputDir = filepath.Join(workDir, "output"), ""
+	cfg.MaxOutputAgeDays, cfg.MaxOutputTotalGB = 0, 0
+	cfg.Incremental, cfg.ChangeReport = false, false
+
+	level := "error"
+	if verbose {
+		level = "debug"
+	}
+	converter, err := pdfconv.NewPDFConverter(cfg, logger.NewLogger(level))
+	if !report("converter", "created", err) {
+		return summary()
+	}
+
+	pdfPath := filepath.Join(workDir, "selftest.pdf")
+	if !report("synthetic PDF", fmt.Sprintf("%d pages", selftestPages), writeSelftestPDF(pdfPath)) {
+		return summary()
+	}
+
+	started := time.Now()
+	result, err := converter.ConvertDocument(pdfPath, cfg.OutputBaseDir, pdfconv.ConversionOptions{})
+	if err == nil {
+		err = checkSelftestResult(result)
+	}
+	if err != nil {
+		report("conversion", "", err)
+		return summary()
+	}
+	report("conversion", fmt.Sprintf("%d pages, quality %.1f in %s", result.PageCount, result.Quality.Score, pdfconv.FormatDuration(time.Since(started))), nil)
+
+	violations, checked, err := pdfconv.ValidateOutput(result.OutputDir)
+	if err == nil && len(violations) > 0 {
+		err = fmt.Errorf("%d schema violation(s), first: %s", len(violations), violations[0])
+	}
+	report("sidecar schemas", fmt.Sprintf("%d file(s) valid", checked), err)
+
+	for _, capability := range converter.DetectCapabilities() {
+		switch {
+		case capability.Using != "":
+			fmt.Printf("INFO %s found (%s): %s enabled\n", capability.Name, capability.Using, capability.Features)
+		case capability.Available:
+			fmt.Printf("INFO %s found: %s enabled\n", capability.Name, capability.Features)
+		default:
+			fmt.Printf("INFO %s not found (missing %s): %s disabled\n", capability.Name, strings.Join(capability.Missing, ", "), capability.Features)
+		}
+	}
+	return summary()
+}
+
+// parseSelftestArgs extracts the env file and the -v flag.
+func parseSelftestArgs(args []string) (envFile string, verbose bool, err error) {
+	envFile = "pdf_md_mcp.env"
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-v" || args[i] == "--verbose":
+			verbose = true
+		case args[i] == "-f" && i+1 < len(args):
+			envFile = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--file="):
+			envFile = strings.TrimPrefix(args[i], "--file=")
+		case strings.HasPrefix(args[i], "-"):
+			return "", false, fmt.Errorf("unknown flag: %s", args[i])
+		default:
+			return "", false, fmt.Errorf("unexpected argument: %s", args[i])
+		}
+	}
+	return envFile, verbose, nil
+}
+
+// writeSelftestPDF writes the synthetic datasheet: a title page with a description and a
+// second page with the marker text, so text extraction across pages is exercised.
+func writeSelftestPDF(path string) error {
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "B", 16)
+	doc.AddPage()
+	doc.Cell(120, 10, selftestTitle)
+	doc.Ln(12)
+	doc.SetFont("Arial", "", 11)
+	doc.MultiCell(0, 6, "The SELFTEST-42 is a synthetic document generated by pdf-md-mcp selftest to verify that documents are converted.", "", "L", false)
+	doc.AddPage()
+	doc.Cell(120, 10, selftestMarker)
+	return doc.OutputFileAndClose(path)
+}
+
+// checkSelftestResult checks that the conversion of the synthetic datasheet kept its pages
+// and text.
+func checkSelftestResult(result *pdfconv.ConversionResult) error {
+	if result.PageCount != selftestPages {
+		return fmt.Errorf("expected %d pages, got %d", selftestPages, result.PageCount)
+	}
+	data, err := os.ReadFile(result.MarkdownFile)
+	if err != nil {
+		return fmt.Errorf("output not readable: %v", err)
+	}
+	for _, text := range []string{selftestTitle, selftestMarker} {
+		if !strings.Contains(string(data), text) {
+			return fmt.Errorf("text %q missing from %s", text, filepath.Base(result.MarkdownFile))
+		}
+	}
+	return nil
+}
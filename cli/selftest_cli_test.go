@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelftestCLI(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "selftest.env")
+	if err := os.WriteFile(envFile, []byte("TMP_DIR="+dir+"\nOUTPUT_BASE_DIR="+filepath.Join(dir, "untouched")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	var code int
+	out := captureStdout(func() { code = (&SelftestCLI{}).Run([]string{"-f", envFile}) })
+	if code != 0 || !strings.Contains(out, "PASS conversion: 2 pages") || !strings.Contains(out, "PASS sidecar schemas") || !strings.HasSuffix(out, "PASS: the installation converts documents\n") {
+		t.Fatalf("expected the self-test to pass, got %d:\n%s", code, out)
+	}
+	// Only the env file is left; the configured output directory is never written
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the temporary directory removed, got %d entries", len(entries))
+	}
+
+	if err := os.WriteFile(envFile, []byte("TMP_DIR="+dir+"\nBASE_HEADER_LEVEL=9\n"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	out = captureStdout(func() { code = (&SelftestCLI{}).Run([]string{"-f", envFile}) })
+	if code != 1 || !strings.Contains(out, "FAIL configuration: ") || !strings.Contains(out, "FAIL: 1 step(s) failed") {
+		t.Errorf("expected an invalid configuration to fail, got %d:\n%s", code, out)
+	}
+}
+
+func TestSelftestCLI_Usage(t *testing.T) {
+	var code int
+	errOut := captureStderr(func() { code = (&SelftestCLI{}).Run([]string{"--bogus"}) })
+	if code != 1 || !strings.Contains(errOut, "unknown flag: --bogus") {
+		t.Fatalf("expected unknown flag error, got %d: %s", code, errOut)
+	}
+}
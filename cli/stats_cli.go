@@ -0,0 +1,99 @@
+// Package cli - Library statistics.
+// This file implements the stats subcommand, which aggregates the conversion reports of all
+// outputs in an output base directory into totals for the converted library.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// StatsCLI implements the stats subcommand.
+type StatsCLI struct{}
+
+// Run executes the statistics with the provided arguments.
+//
+// Usage:
+//
+//	stats [<dir>] [-f <file>] [--json]
+//
+// The outputs directly in <dir> are aggregated: documents, pages, images, tables, diagrams,
+// quality scores and disk usage. Without <dir>, OUTPUT_BASE_DIR is read from the env file
+// (pdf_md_mcp.env when -f is not given) or the process environment. --json prints the
+// statistics as JSON.
+func (s *StatsCLI) Run(args []string) int {
+	dir, envFile, asJSON, err := parseStatsArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: pdf-md-mcp stats [<dir>] [-f <file>] [--json]")
+		return 1
+	}
+	if dir == "" {
+		if dir, err = outputBaseDir(envFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	stats, err := pdfconv.CollectLibraryStats(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	fmt.Print(stats.Summary())
+	return 0
+}
+
+// parseStatsArgs extracts the output directory, env file and the --json flag.
+func parseStatsArgs(args []string) (dir, envFile string, asJSON bool, err error) {
+	envFile = "pdf_md_mcp.env"
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--json":
+			asJSON = true
+		case args[i] == "-f" && i+1 < len(args):
+			envFile = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--file="):
+			envFile = strings.TrimPrefix(args[i], "--file=")
+		case strings.HasPrefix(args[i], "-"):
+			return "", "", false, fmt.Errorf("unknown flag: %s", args[i])
+		case dir == "":
+			dir = args[i]
+		default:
+			return "", "", false, fmt.Errorf("unexpected argument: %s", args[i])
+		}
+	}
+	return dir, envFile, asJSON, nil
+}
+
+// outputBaseDir returns OUTPUT_BASE_DIR from the env file, the process environment or the
+// default. A missing default env file is not an error.
+func outputBaseDir(envFile string) (string, error) {
+	envMap, err := godotenv.Read(envFile)
+	if err != nil && envFile != "pdf_md_mcp.env" {
+		return "", fmt.Errorf("failed to read config file '%s': %v", envFile, err)
+	}
+	if dir := envMap["OUTPUT_BASE_DIR"]; dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("OUTPUT_BASE_DIR"); dir != "" {
+		return dir, nil
+	}
+	return "./output", nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatsCLI(t *testing.T) {
+	dir := t.TempDir()
+	reports := map[string]string{
+		"MARKDOWN_a": `{"source": "a.pdf", "status": "complete", "page_count": 10, "image_count": 4, "table_count": 3, "diagram_count": 1, "quality": {"score": 90}}`,
+		"MARKDOWN_b": `{"source": "b.pdf", "status": "partial", "page_count": 6, "image_count": 0, "table_count": 1, "quality": {"score": 60}}`,
+		"MARKDOWN_c": ``,
+	}
+	for name, report := range reports {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if report != "" {
+			if err := os.WriteFile(filepath.Join(dir, name, "conversion_report.json"), []byte(report), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	run := func(args ...string) (int, string) {
+		var code int
+		out := captureStdout(func() {
+			code = (&StatsCLI{}).Run(args)
+		})
+		return code, out
+	}
+
+	code, out := run(dir)
+	for _, want := range []string{"Documents: 2 (1 partial)", "Outputs Without Report: 1", "Pages: 16", "Images: 4", "Tables: 4", "Diagrams: 1", "Quality: mean 75.0, min 60.0, max 90.0", "- MARKDOWN_b: 60.0/100, 6 page(s), partial"} {
+		if code != 0 || !strings.Contains(out, want) {
+			t.Errorf("expected %q in the statistics, got %d:\n%s", want, code, out)
+		}
+	}
+
+	code, out = run("--json", dir)
+	var stats struct {
+		Documents int   `json:"documents"`
+		Bytes     int64 `json:"bytes"`
+	}
+	if err := json.Unmarshal([]byte(out), &stats); code != 0 || err != nil || stats.Documents != 2 || stats.Bytes == 0 {
+		t.Errorf("unexpected JSON statistics %d %v: %s", code, err, out)
+	}
+
+	// Without a directory OUTPUT_BASE_DIR is read from the env file
+	envFile := filepath.Join(t.TempDir(), "stats.env")
+	if err := os.WriteFile(envFile, []byte("OUTPUT_BASE_DIR="+dir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := run("-f", envFile); code != 0 || !strings.Contains(out, "Library Statistics: "+dir) {
+		t.Errorf("expected the statistics of OUTPUT_BASE_DIR, got %d:\n%s", code, out)
+	}
+	if code, _ := run(filepath.Join(dir, "missing")); code != 1 {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
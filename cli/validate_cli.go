@@ -0,0 +1,99 @@
+// Package cli - Sidecar validation.
+// This file implements the validate-output subcommand, which checks the JSON files written
+// next to converted documents against their published schemas, and prints the schemas.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// ValidateCLI implements the validate-output subcommand.
+type ValidateCLI struct{}
+
+// Run executes the validation with the provided arguments.
+//
+// Usage:
+//
+//	validate-output <dir>
+//	validate-output --schema <file>
+//
+// Every sidecar file below <dir> (README.json, conversion_report.json and variants.json) is
+// checked against its JSON Schema and each violation is printed. --schema prints the schema
+// of a sidecar file instead. The exit code is 1 when any file does not match its schema.
+func (v *ValidateCLI) Run(args []string) int {
+	dir, schema, err := parseValidateArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: pdf-md-mcp validate-output <dir> | --schema <file>")
+		return 1
+	}
+
+	if schema != "" {
+		data, ok := pdfconv.SidecarSchema(schema)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: no schema for %s (known files: %s)\n", schema, strings.Join(sidecarFiles(), ", "))
+			return 1
+		}
+		fmt.Print(string(data))
+		return 0
+	}
+
+	violations, checked, err := pdfconv.ValidateOutput(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if checked == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no sidecar files found in %s\n", dir)
+		return 1
+	}
+	for _, violation := range violations {
+		fmt.Printf("INVALID %s\n", violation)
+	}
+	fmt.Printf("\n%d file(s) checked, %d violation(s)\n", checked, len(violations))
+	if len(violations) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseValidateArgs extracts the output directory or the --schema file name.
+func parseValidateArgs(args []string) (dir, schema string, err error) {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--schema" && i+1 < len(args):
+			schema = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--schema="):
+			schema = strings.TrimPrefix(args[i], "--schema=")
+		case strings.HasPrefix(args[i], "-"):
+			return "", "", fmt.Errorf("unknown flag: %s", args[i])
+		case dir == "":
+			dir = args[i]
+		default:
+			return "", "", fmt.Errorf("unexpected argument: %s", args[i])
+		}
+	}
+	if dir == "" && schema == "" {
+		return "", "", fmt.Errorf("output directory is required")
+	}
+	if dir != "" && schema != "" {
+		return "", "", fmt.Errorf("--schema does not take an output directory")
+	}
+	return dir, schema, nil
+}
+
+// sidecarFiles returns the names of the sidecar files with a schema, sorted.
+func sidecarFiles() []string {
+	var files []string
+	for file := range pdfconv.SidecarSchemas {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
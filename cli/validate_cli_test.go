@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCLI(t *testing.T) {
+	dir := t.TempDir()
+	section := filepath.Join(dir, "MARKDOWN_ds")
+	if err := os.MkdirAll(section, 0755); err != nil {
+		t.Fatal(err)
+	}
+	valid := `{"source": "ds.pdf", "variants": [{"part_number": "X1", "pages": [3]}]}`
+	if err := os.WriteFile(filepath.Join(section, "variants.json"), []byte(valid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (int, string) {
+		var code int
+		out := captureStdout(func() {
+			code = (&ValidateCLI{}).Run(args)
+		})
+		return code, out
+	}
+
+	if code, out := run(dir); code != 0 || !strings.Contains(out, "1 file(s) checked, 0 violation(s)") {
+		t.Fatalf("expected valid output to pass, got %d: %s", code, out)
+	}
+
+	invalid := `{"source": "ds.pdf", "variants": [{"part_number": "X1", "pages": [3], "grade": "consumer"}]}`
+	if err := os.WriteFile(filepath.Join(section, "variants.json"), []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := run(dir); code != 1 || !strings.Contains(out, "INVALID MARKDOWN_ds/variants.json /variants/0/grade") {
+		t.Fatalf("expected a grade violation, got %d: %s", code, out)
+	}
+
+	if code, out := run("--schema", "variants.json"); code != 0 || !strings.Contains(out, `"title": "variants.json"`) {
+		t.Errorf("expected the variants schema, got %d: %s", code, out)
+	}
+	if code, _ := run("--schema", "pinout.json"); code != 1 {
+		t.Errorf("expected an error for a file without a schema")
+	}
+	if code, _ := run(t.TempDir()); code != 1 {
+		t.Errorf("expected an error for a directory without sidecars")
+	}
+}
@@ -4,7 +4,10 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -14,35 +17,102 @@ import (
 // and MCP transport configuration.
 type Config struct {
 	// PDF Input/Output Settings
-	PDFInputDir   string // Directory containing PDF files to process
-	OutputBaseDir string // Base directory where MARKDOWN_<filename> subdirectories will be created
+	PDFInputDir         string  // Directory containing PDF files to process
+	FollowSymlinks      bool    // Whether directory discovery follows symbolic links
+	IncludeHiddenDirs   bool    // Whether directory discovery descends into hidden directories
+	MaxDiscoveredFiles  int     // Maximum number of PDF files discovered in a directory (0 = unlimited)
+	OutputBaseDir       string  // Base directory where MARKDOWN_<filename> subdirectories will be created
+	DiskSpaceCheck      bool    // Whether to verify free space in the output location before converting
+	MaxOutputAgeDays    int     // Remove conversion outputs older than this many days (0 = keep forever)
+	MaxOutputTotalGB    float64 // Remove the oldest conversion outputs beyond this total size (0 = unlimited)
+	EstimateSamplePages int     // Pages converted by a dry run to estimate conversion time and output size (0 = 5)
+	TempDir             string  // Directory for intermediate files of conversions (empty = system temporary directory)
+	Incremental         bool    // Whether re-conversions reuse the unchanged pages of the previous output
+	ChangeReport        bool    // Whether re-conversions write CHANGES.md comparing the output with the previous one
+	BatchSummary        bool    // Whether batch conversions write BATCH_SUMMARY.md to the output base directory
+	BatchResults        bool    // Whether batch conversions write results.json to the output base directory
+	StrictMode          bool    // Whether any page, image or table failure aborts the conversion instead of being a warning
 
 	// Server Settings
-	ServerName    string // Name of the MCP server for identification
-	ServerVersion string // Version of the MCP server
+	ServerName     string // Name of the MCP server for identification
+	ServerVersion  string // Version of the MCP server
+	UpdateCheck    bool   // Whether to check the release feed for a newer version at startup
+	UpdateCheckURL string // Release feed queried by the update check (GitHub latest release API)
+	Locale         string // Language of tool descriptions and result summaries (en, ja, zh)
+	RestrictedMode bool   // Whether to expose only single file conversion inside PDF_INPUT_DIR
 
 	// PDF Processing Settings
-	ImageMaxDPI         int    // Maximum DPI for extracted images (higher = better quality, larger files)
-	ImageFormat         string // Format for extracted images (png, jpg)
-	PreserveAspectRatio bool   // Whether to maintain original image aspect ratios
+	Preset              string  // Named bundle of conversion settings (fast, archival, rag-optimized, print-fidelity)
+	ImageMaxDPI         int     // Maximum DPI for extracted images (higher = better quality, larger files)
+	ImageFormat         string  // Format for extracted images (png, jpg)
+	PreserveAspectRatio bool    // Whether to maintain original image aspect ratios
+	ImagePlacement      string  // Where images are placed in the page Markdown (end, inline)
+	OCRLanguage         string  // Tesseract language(s) used to recognize text in page scans (e.g. eng, eng+deu)
+	TextMinConfidence   float64 // Pages whose text layer scores lower are OCRed or flagged as unreliable (0 = off)
+	ImageAltText        string  // Source of image alt text (off, ocr, caption)
+	Renderer            string  // Program that rasterizes pages (auto, pdftoppm, ghostscript, pdfium)
+	ThumbnailWidth      int     // Width in pixels of the first page thumbnail written with the output (0 = off)
 
 	// Diagram Detection and PlantUML Settings
-	DetectDiagrams      bool    // Whether to detect diagrams in PDFs and convert to PlantUML
-	DiagramConfidence   float64 // Minimum confidence threshold for diagram detection (0.0-1.0)
-	PlantUMLStyle       string  // PlantUML diagram style (default, blueprint, modern)
-	PlantUMLColorScheme string  // PlantUML color scheme (mono, color, auto)
+	DetectDiagrams        bool     // Whether to detect diagrams in PDFs and convert to PlantUML
+	DiagramTypes          []string // Diagram types written to the Markdown (empty = all); others are only listed in images.json
+	DiagramConfidence     float64  // Minimum confidence threshold for diagram detection (0.0-1.0)
+	DiagramMinSize        int      // Minimum width and height in pixels of images analyzed for diagrams (0 = all)
+	DiagramMinEdgeDensity float64  // Minimum fraction of edge pixels of images analyzed for diagrams (0 = all)
+	CurveData             bool     // Whether to extract the data points of characteristic curve graphs to CSV
+	PlantUMLStyle         string   // PlantUML diagram style (default, blueprint, modern)
+	PlantUMLColorScheme   string   // PlantUML color scheme (mono, color, auto)
+	PlantUMLTemplateDir   string   // Directory of per-type PlantUML templates replacing the built-in snippets (empty = built-in)
 
 	// Markdown Generation Settings
-	IncludeTOC      bool // Whether to generate a table of contents in the markdown
-	BaseHeaderLevel int  // Starting header level for sections (1-6, where 1 = #, 2 = ##, etc.)
-	ExtractTables   bool // Whether to attempt table extraction and conversion
-	ExtractImages   bool // Whether to extract and save images from the PDF
+	OutputFormat        string   // Document format written (markdown, asciidoc, html, json)
+	MarkdownFlavor      string   // Markdown flavor written (gfm, commonmark)
+	IncludeTOC          bool     // Whether to generate a table of contents in the markdown
+	BaseHeaderLevel     int      // Starting header level for sections (1-6, where 1 = #, 2 = ##, etc.)
+	MaxHeaderDepth      int      // Deepest Markdown heading level written (2-6, 0 = 6)
+	HeaderOverflow      string   // How headings deeper than MaxHeaderDepth are written (clamp, bold)
+	ExtractTables       bool     // Whether to attempt table extraction and conversion
+	TableMinConfidence  float64  // Tables reconstructed with lower confidence fall back to an image (0.0-1.0)
+	NormalizeSpecTables bool     // Whether to normalize numbers and units in min/typ/max tables
+	BoldTypValues       bool     // Whether to bold typical values in min/typ/max tables
+	VariantTables       bool     // Whether to join ordering information tables into a variant comparison and variants.json
+	PackageDimensions   bool     // Whether to export package drawing and mechanical dimension table data to package.json
+	ApplicationBOM      bool     // Whether to collect the components of typical application circuits into bom.csv
+	ComplianceTags      bool     // Whether to tag compliance and qualification standards in front matter and compliance.json
+	ErrataLinks         string   // How errata documents are related to their datasheets in batch conversions (off, link, inject)
+	MonospaceCode       bool     // Whether text set in monospace fonts is written as code spans and blocks
+	PreserveEmphasis    bool     // Whether text set in bold or italic fonts keeps its emphasis
+	DetectCallouts      bool     // Whether colored warning and note boxes are written as GFM alerts
+	JoinPageBreaks      bool     // Whether running headers and footers are removed and sentences joined across pages
+	NumberLocale        string   // Locale whose number and date formats are normalized (off, de, fr, en, ...)
+	ContentLanguage     string   // Language whose text is kept in multilingual documents (off, en, zh, ...)
+	ExtractImages       bool     // Whether to extract and save images from the PDF
+	AccessibleOutput    bool     // Whether to enforce alt text, heading hierarchy, table headers and a language declaration
+	MarkdownLint        bool     // Whether to normalize the generated Markdown with markdownlint-style rules
+	MarkdownLintRules   []string // Rules applied by the lint pass (markdownlint IDs such as MD009, MD013)
+	MarkdownLineLength  int      // Line length MD013 wraps paragraphs at (0 = 80)
+
+	// Header Detection Settings
+	HeaderKeywordLocales []string // Built-in header keyword sets to use (en, de, fr, ja, zh)
+	HeaderKeywords       []string // Additional keywords that mark a line as a section header
+	HeaderRegexes        []string // Regular expressions that mark a line as a section header
+	HeadingNormalize     bool     // Whether to title-case ALL-CAPS headings and clean up colons and numbering
+	SectionNumbering     string   // How numbered section headings are handled (preserve, renumber, off)
+	CrossReferenceLinks  bool     // Whether "see Figure 12" style references are linked to their anchors
 
 	// Logging Configuration
 	LogLevel string // Logging verbosity level (debug, info, warn, error)
 
 	// MCP Transport Settings
-	Transport string // Transport method for MCP communication (stdio only)
+	Transport        string   // Transport methods for MCP communication (stdio, http, or both comma-separated)
+	HTTPAddr         string   // Address the http transport listens on
+	AllowedHosts     []string // Host names besides loopback and IP addresses the http transport answers to
+	Trace            bool     // Whether JSON-RPC messages are written to TraceFile
+	TraceFile        string   // File traced messages are appended to
+	MaxMessageSizeMB int      // Largest client message accepted in MB (0 = unlimited)
+	MaxToolCalls     int      // Tool calls each session runs at the same time (1-64, 0 = 1)
+	ToolTimeout      int      // Seconds a tool call may run before it is stopped (0 = no limit)
+	MaxQueuedJobs    int      // Asynchronous jobs that may wait in the job queue (0 = unlimited)
 }
 
 // LoadConfig creates a new Config instance by reading values from environment variables.
@@ -51,22 +121,87 @@ type Config struct {
 //
 // Environment variables read:
 //   - PDF_INPUT_DIR: Directory containing PDF files to process
+//   - FOLLOW_SYMLINKS: Follow symbolic links when discovering PDF files
+//   - INCLUDE_HIDDEN_DIRS: Search hidden directories when discovering PDF files
+//   - MAX_DISCOVERED_FILES: Limit on PDF files discovered in a directory
 //   - OUTPUT_BASE_DIR: Base output directory
+//   - DISK_SPACE_CHECK: Verify free space before converting
+//   - MAX_OUTPUT_AGE_DAYS: Retention age for conversion outputs
+//   - MAX_OUTPUT_TOTAL_GB: Retention size limit for conversion outputs
+//   - ESTIMATE_SAMPLE_PAGES: Pages sampled by dry-run estimates
+//   - TMP_DIR: Directory for intermediate files
+//   - INCREMENTAL_CONVERSION: Reuse unchanged pages when re-converting
+//   - CHANGE_REPORT: Write CHANGES.md when re-converting
+//   - BATCH_SUMMARY: Write BATCH_SUMMARY.md after batch conversions
+//   - BATCH_RESULTS: Write results.json after batch conversions
+//   - STRICT_MODE: Fail conversions with failed pages, images or tables
 //   - MCP_SERVER_NAME: Server identification name
 //   - MCP_SERVER_VERSION: Server version
+//   - UPDATE_CHECK: Startup check for a newer release
+//   - UPDATE_CHECK_URL: Release feed used by the update check
+//   - LOCALE: Language of tool descriptions and result summaries
+//   - RESTRICTED_MODE: Limit tools to single file conversion inside the input directory
+//   - CONVERSION_PRESET: Named bundle of conversion settings, overridden by variables set explicitly
 //   - IMAGE_MAX_DPI: Maximum image resolution
 //   - IMAGE_FORMAT: Image output format
 //   - PRESERVE_ASPECT_RATIO: Maintain image aspect ratios
+//   - IMAGE_PLACEMENT: Image placement strategy within each page
+//   - OCR_LANGUAGE: Tesseract language for page scan OCR
+//   - TEXT_MIN_CONFIDENCE: Minimum plausibility of extracted page text
+//   - IMAGE_ALT_TEXT: Source of image alt text
+//   - RENDERER: Program used to rasterize pages
+//   - THUMBNAIL_WIDTH: Width of the first page thumbnail
 //   - DETECT_DIAGRAMS: Enable diagram detection
+//   - DETECT_DIAGRAM_TYPES: Comma-separated diagram types written to the Markdown
 //   - DIAGRAM_CONFIDENCE: Minimum confidence for diagram detection
+//   - DIAGRAM_MIN_SIZE: Minimum image width and height analyzed for diagrams
+//   - DIAGRAM_MIN_EDGE_DENSITY: Minimum edge density of images analyzed for diagrams
+//   - CURVE_DATA: Extract characteristic curve data points to CSV
 //   - PLANTUML_STYLE: PlantUML diagram style
 //   - PLANTUML_COLOR_SCHEME: PlantUML color scheme
+//   - PLANTUML_TEMPLATE_DIR: Directory of per-type PlantUML templates
+//   - OUTPUT_FORMAT: Document format written
+//   - MARKDOWN_FLAVOR: Markdown flavor written
 //   - INCLUDE_TOC: Generate table of contents
 //   - BASE_HEADER_LEVEL: Starting header level for sections
+//   - MAX_HEADER_DEPTH: Deepest heading level written
+//   - HEADER_OVERFLOW: Handling of headings deeper than MAX_HEADER_DEPTH
 //   - EXTRACT_TABLES: Enable table extraction
+//   - TABLE_MIN_CONFIDENCE: Minimum confidence for reconstructed tables
+//   - NORMALIZE_SPEC_TABLES: Normalize min/typ/max table values and units
+//   - BOLD_TYP_VALUES: Bold typical values in min/typ/max tables
+//   - VARIANT_TABLES: Build a part variant comparison from ordering information tables
+//   - PACKAGE_DIMENSIONS: Export package dimensions to package.json
+//   - APPLICATION_BOM: Collect application circuit components into bom.csv
+//   - COMPLIANCE_TAGS: Tag compliance and qualification standards
+//   - ERRATA_LINKS: Link errata documents to their datasheets in batch conversions
+//   - MONOSPACE_CODE: Write text set in monospace fonts as code
+//   - PRESERVE_EMPHASIS: Keep bold and italic emphasis of the source fonts
+//   - DETECT_CALLOUTS: Write warning, caution and note boxes as GFM alerts
+//   - JOIN_PAGE_BREAKS: Remove running headers and footers and join sentences split by page breaks
+//   - NUMBER_LOCALE: Locale of number and date formats to normalize
+//   - CONTENT_LANGUAGE_FILTER: Keep only the text of this language in multilingual documents
 //   - EXTRACT_IMAGES: Enable image extraction
+//   - ACCESSIBLE_OUTPUT: Enforce accessibility requirements in the Markdown
+//   - MARKDOWN_LINT: Normalize the generated Markdown
+//   - MARKDOWN_LINT_RULES: Comma-separated markdownlint rules to apply
+//   - MARKDOWN_LINE_LENGTH: Line length for MD013 wrapping
+//   - HEADER_KEYWORD_LOCALES: Comma-separated built-in header keyword sets
+//   - HEADER_KEYWORDS: Comma-separated additional header keywords
+//   - HEADER_REGEXES: Semicolon-separated header regular expressions
+//   - HEADING_NORMALIZE: Normalize detected heading text
+//   - SECTION_NUMBERING: Section number handling for headings
+//   - CROSS_REFERENCE_LINKS: Link in-text section, figure and table references
 //   - LOG_LEVEL: Logging verbosity
-//   - MCP_TRANSPORT: Transport method
+//   - MCP_TRANSPORT: Transport methods, comma-separated to serve several
+//   - MCP_HTTP_ADDR: Listen address of the http transport
+//   - MCP_ALLOWED_HOSTS: Host names the http transport answers to
+//   - MCP_TRACE: Trace JSON-RPC messages
+//   - MCP_TRACE_FILE: File traced messages are appended to
+//   - MAX_MESSAGE_SIZE_MB: Largest client message accepted
+//   - MAX_CONCURRENT_TOOL_CALLS: Tool calls run at the same time per session
+//   - MAX_QUEUED_JOBS: Asynchronous jobs that may wait in the job queue
+//   - CONVERSION_TIMEOUT: Seconds a tool call may run
 //
 // Returns:
 //   - *Config: Populated configuration struct
@@ -74,23 +209,93 @@ type Config struct {
 func LoadConfig() (*Config, error) {
 	config := &Config{
 		// Set default values first
-		PDFInputDir:         getEnvWithDefault("PDF_INPUT_DIR", ""),
-		OutputBaseDir:       getEnvWithDefault("OUTPUT_BASE_DIR", "./output"),
-		ServerName:          getEnvWithDefault("MCP_SERVER_NAME", "pdf-to-markdown-server"),
-		ServerVersion:       getEnvWithDefault("MCP_SERVER_VERSION", "1.0.0"),
-		ImageMaxDPI:         getEnvIntWithDefault("IMAGE_MAX_DPI", 300),
-		ImageFormat:         getEnvWithDefault("IMAGE_FORMAT", "png"),
-		PreserveAspectRatio: getEnvBoolWithDefault("PRESERVE_ASPECT_RATIO", true),
-		DetectDiagrams:      getEnvBoolWithDefault("DETECT_DIAGRAMS", false),
-		DiagramConfidence:   getEnvFloat64WithDefault("DIAGRAM_CONFIDENCE", 0.7),
-		PlantUMLStyle:       getEnvWithDefault("PLANTUML_STYLE", "default"),
-		PlantUMLColorScheme: getEnvWithDefault("PLANTUML_COLOR_SCHEME", "auto"),
-		IncludeTOC:          getEnvBoolWithDefault("INCLUDE_TOC", true),
-		BaseHeaderLevel:     getEnvIntWithDefault("BASE_HEADER_LEVEL", 1),
-		ExtractTables:       getEnvBoolWithDefault("EXTRACT_TABLES", true),
-		ExtractImages:       getEnvBoolWithDefault("EXTRACT_IMAGES", true),
-		LogLevel:            getEnvWithDefault("LOG_LEVEL", "info"),
-		Transport:           getEnvWithDefault("MCP_TRANSPORT", "stdio"),
+		PDFInputDir:           getEnvWithDefault("PDF_INPUT_DIR", ""),
+		FollowSymlinks:        getEnvBoolWithDefault("FOLLOW_SYMLINKS", false),
+		IncludeHiddenDirs:     getEnvBoolWithDefault("INCLUDE_HIDDEN_DIRS", false),
+		MaxDiscoveredFiles:    getEnvIntWithDefault("MAX_DISCOVERED_FILES", 10000),
+		OutputBaseDir:         getEnvWithDefault("OUTPUT_BASE_DIR", "./output"),
+		DiskSpaceCheck:        getEnvBoolWithDefault("DISK_SPACE_CHECK", true),
+		MaxOutputAgeDays:      getEnvIntWithDefault("MAX_OUTPUT_AGE_DAYS", 0),
+		MaxOutputTotalGB:      getEnvFloat64WithDefault("MAX_OUTPUT_TOTAL_GB", 0),
+		EstimateSamplePages:   getEnvIntWithDefault("ESTIMATE_SAMPLE_PAGES", 5),
+		TempDir:               getEnvWithDefault("TMP_DIR", ""),
+		Incremental:           getEnvBoolWithDefault("INCREMENTAL_CONVERSION", false),
+		ChangeReport:          getEnvBoolWithDefault("CHANGE_REPORT", false),
+		BatchSummary:          getEnvBoolWithDefault("BATCH_SUMMARY", false),
+		BatchResults:          getEnvBoolWithDefault("BATCH_RESULTS", false),
+		StrictMode:            getEnvBoolWithDefault("STRICT_MODE", false),
+		ServerName:            getEnvWithDefault("MCP_SERVER_NAME", "pdf-to-markdown-server"),
+		ServerVersion:         getEnvWithDefault("MCP_SERVER_VERSION", "1.0.0"),
+		UpdateCheck:           getEnvBoolWithDefault("UPDATE_CHECK", false),
+		UpdateCheckURL:        getEnvWithDefault("UPDATE_CHECK_URL", DefaultUpdateCheckURL),
+		Locale:                strings.ToLower(getEnvWithDefault("LOCALE", "en")),
+		RestrictedMode:        getEnvBoolWithDefault("RESTRICTED_MODE", false),
+		Preset:                strings.ToLower(getEnvWithDefault("CONVERSION_PRESET", "")),
+		ImageMaxDPI:           getEnvIntWithDefault("IMAGE_MAX_DPI", 300),
+		ImageFormat:           getEnvWithDefault("IMAGE_FORMAT", "png"),
+		PreserveAspectRatio:   getEnvBoolWithDefault("PRESERVE_ASPECT_RATIO", true),
+		ImagePlacement:        getEnvWithDefault("IMAGE_PLACEMENT", "end"),
+		OCRLanguage:           getEnvWithDefault("OCR_LANGUAGE", "eng"),
+		TextMinConfidence:     getEnvFloat64WithDefault("TEXT_MIN_CONFIDENCE", 0.5),
+		ImageAltText:          strings.ToLower(getEnvWithDefault("IMAGE_ALT_TEXT", "off")),
+		Renderer:              strings.ToLower(getEnvWithDefault("RENDERER", "auto")),
+		ThumbnailWidth:        getEnvIntWithDefault("THUMBNAIL_WIDTH", 0),
+		DetectDiagrams:        getEnvBoolWithDefault("DETECT_DIAGRAMS", false),
+		DiagramTypes:          getEnvListWithDefault("DETECT_DIAGRAM_TYPES", ",", nil),
+		DiagramConfidence:     getEnvFloat64WithDefault("DIAGRAM_CONFIDENCE", 0.7),
+		DiagramMinSize:        getEnvIntWithDefault("DIAGRAM_MIN_SIZE", 100),
+		DiagramMinEdgeDensity: getEnvFloat64WithDefault("DIAGRAM_MIN_EDGE_DENSITY", 0.01),
+		CurveData:             getEnvBoolWithDefault("CURVE_DATA", false),
+		PlantUMLStyle:         getEnvWithDefault("PLANTUML_STYLE", "default"),
+		PlantUMLColorScheme:   getEnvWithDefault("PLANTUML_COLOR_SCHEME", "auto"),
+		PlantUMLTemplateDir:   getEnvWithDefault("PLANTUML_TEMPLATE_DIR", ""),
+		OutputFormat:          strings.ToLower(getEnvWithDefault("OUTPUT_FORMAT", "markdown")),
+		MarkdownFlavor:        strings.ToLower(getEnvWithDefault("MARKDOWN_FLAVOR", "gfm")),
+		IncludeTOC:            getEnvBoolWithDefault("INCLUDE_TOC", true),
+		BaseHeaderLevel:       getEnvIntWithDefault("BASE_HEADER_LEVEL", 1),
+		MaxHeaderDepth:        getEnvIntWithDefault("MAX_HEADER_DEPTH", 6),
+		HeaderOverflow:        strings.ToLower(getEnvWithDefault("HEADER_OVERFLOW", "clamp")),
+		ExtractTables:         getEnvBoolWithDefault("EXTRACT_TABLES", true),
+		TableMinConfidence:    getEnvFloat64WithDefault("TABLE_MIN_CONFIDENCE", 0.5),
+		NormalizeSpecTables:   getEnvBoolWithDefault("NORMALIZE_SPEC_TABLES", true),
+		BoldTypValues:         getEnvBoolWithDefault("BOLD_TYP_VALUES", false),
+		VariantTables:         getEnvBoolWithDefault("VARIANT_TABLES", false),
+		PackageDimensions:     getEnvBoolWithDefault("PACKAGE_DIMENSIONS", false),
+		ApplicationBOM:        getEnvBoolWithDefault("APPLICATION_BOM", false),
+		ComplianceTags:        getEnvBoolWithDefault("COMPLIANCE_TAGS", false),
+		ErrataLinks:           strings.ToLower(getEnvWithDefault("ERRATA_LINKS", "off")),
+		MonospaceCode:         getEnvBoolWithDefault("MONOSPACE_CODE", true),
+		PreserveEmphasis:      getEnvBoolWithDefault("PRESERVE_EMPHASIS", true),
+		DetectCallouts:        getEnvBoolWithDefault("DETECT_CALLOUTS", true),
+		JoinPageBreaks:        getEnvBoolWithDefault("JOIN_PAGE_BREAKS", true),
+		NumberLocale:          strings.ToLower(getEnvWithDefault("NUMBER_LOCALE", "off")),
+		ContentLanguage:       strings.ToLower(getEnvWithDefault("CONTENT_LANGUAGE_FILTER", "off")),
+		ExtractImages:         getEnvBoolWithDefault("EXTRACT_IMAGES", true),
+		AccessibleOutput:      getEnvBoolWithDefault("ACCESSIBLE_OUTPUT", false),
+		MarkdownLint:          getEnvBoolWithDefault("MARKDOWN_LINT", false),
+		MarkdownLintRules:     getEnvListWithDefault("MARKDOWN_LINT_RULES", ",", DefaultMarkdownLintRules),
+		MarkdownLineLength:    getEnvIntWithDefault("MARKDOWN_LINE_LENGTH", 80),
+		HeaderKeywordLocales:  getEnvListWithDefault("HEADER_KEYWORD_LOCALES", ",", []string{"en"}),
+		HeaderKeywords:        getEnvListWithDefault("HEADER_KEYWORDS", ",", nil),
+		HeaderRegexes:         getEnvListWithDefault("HEADER_REGEXES", ";", nil),
+		HeadingNormalize:      getEnvBoolWithDefault("HEADING_NORMALIZE", false),
+		SectionNumbering:      getEnvWithDefault("SECTION_NUMBERING", "preserve"),
+		CrossReferenceLinks:   getEnvBoolWithDefault("CROSS_REFERENCE_LINKS", true),
+		LogLevel:              getEnvWithDefault("LOG_LEVEL", "info"),
+		Transport:             getEnvWithDefault("MCP_TRANSPORT", "stdio"),
+		HTTPAddr:              getEnvWithDefault("MCP_HTTP_ADDR", DefaultHTTPAddr),
+		AllowedHosts:          getEnvListWithDefault("MCP_ALLOWED_HOSTS", ",", nil),
+		Trace:                 getEnvBoolWithDefault("MCP_TRACE", false),
+		TraceFile:             getEnvWithDefault("MCP_TRACE_FILE", "mcp_trace.log"),
+		MaxMessageSizeMB:      getEnvIntWithDefault("MAX_MESSAGE_SIZE_MB", 64),
+		MaxToolCalls:          getEnvIntWithDefault("MAX_CONCURRENT_TOOL_CALLS", 4),
+		ToolTimeout:           getEnvIntWithDefault("CONVERSION_TIMEOUT", 0),
+		MaxQueuedJobs:         getEnvIntWithDefault("MAX_QUEUED_JOBS", 20),
+	}
+
+	// Apply the preset to the settings not set explicitly
+	if err := config.ApplyPreset(config.Preset, isSet); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: CONVERSION_PRESET: %v", err)
 	}
 
 	// Validate configuration values
@@ -101,20 +306,151 @@ func LoadConfig() (*Config, error) {
 	return config, nil
 }
 
+// OutputFormats are the accepted OUTPUT_FORMAT values.
+var OutputFormats = []string{"markdown", "asciidoc", "html", "json"}
+
+// Transports are the accepted MCP_TRANSPORT values.
+var Transports = []string{"stdio", "http"}
+
+// TransportList returns the transports of MCP_TRANSPORT, which may list several separated by
+// commas, such as "stdio,http", in the order given.
+func (c *Config) TransportList() []string {
+	var transports []string
+	for _, transport := range strings.Split(c.Transport, ",") {
+		if transport = strings.ToLower(strings.TrimSpace(transport)); transport != "" {
+			transports = append(transports, transport)
+		}
+	}
+	return transports
+}
+
+// HasTransport reports whether MCP_TRANSPORT includes the named transport.
+func (c *Config) HasTransport(name string) bool {
+	return contains(c.TransportList(), name)
+}
+
+// IsListenAddress reports whether addr is a host:port address to listen on, such as
+// 127.0.0.1:8080 or :8080.
+func IsListenAddress(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
+
+// Renderers are the accepted RENDERER values besides "auto", in the order "auto" tries them.
+var Renderers = []string{"pdftoppm", "ghostscript", "pdfium"}
+
+// MarkdownFlavors are the accepted MARKDOWN_FLAVOR values.
+var MarkdownFlavors = []string{"gfm", "commonmark"}
+
+// NumberLocales are the accepted NUMBER_LOCALE values besides "off".
+var NumberLocales = []string{"cs", "da", "de", "en", "en-gb", "es", "fi", "fr", "it", "ja", "nb", "nl", "pl", "pt", "ru", "sv", "zh"}
+
+// languageCodePattern matches an ISO 639 language code such as "en" or "zh".
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// IsLanguageCode reports whether code is a lowercase ISO 639 language code.
+func IsLanguageCode(code string) bool {
+	return languageCodePattern.MatchString(code)
+}
+
+// DefaultUpdateCheckURL is the release feed queried by UPDATE_CHECK.
+const DefaultUpdateCheckURL = "https://api.github.com/repos/monamaret/datasheet-to-md-mcp/releases/latest"
+
+// DefaultHTTPAddr is the address the http transport listens on, reachable only from the
+// local machine.
+const DefaultHTTPAddr = "127.0.0.1:8080"
+
+// DiagramTypes are the diagram types DETECT_DIAGRAM_TYPES can select.
+var DiagramTypes = []string{"flowchart", "block", "circuit", "network", "plot", "unknown"}
+
+// Locales are the supported LOCALE values.
+var Locales = []string{"en", "ja", "zh"}
+
+// MarkdownLintRules are the markdownlint rules the lint pass can apply.
+var MarkdownLintRules = []string{"MD009", "MD012", "MD013", "MD019", "MD022", "MD031", "MD040", "MD047"}
+
+// DefaultMarkdownLintRules are applied when MARKDOWN_LINT_RULES is not set. Line wrapping
+// (MD013) is left out because it changes every long paragraph.
+var DefaultMarkdownLintRules = []string{"MD009", "MD012", "MD019", "MD022", "MD031", "MD040", "MD047"}
+
+// ocrLanguagePattern matches Tesseract language codes such as "eng", "chi_sim" or "eng+deu".
+var ocrLanguagePattern = regexp.MustCompile(`^[A-Za-z_]+(\+[A-Za-z_]+)*$`)
+
+// IsOCRLanguage reports whether language is Tesseract language codes joined by "+", as
+// accepted by OCR_LANGUAGE.
+func IsOCRLanguage(language string) bool {
+	return ocrLanguagePattern.MatchString(language)
+}
+
 // Validate checks that all configuration values are valid and within acceptable ranges.
 // It ensures that critical settings like paths exist and numeric values are within bounds.
 //
 // Validation rules:
+//   - MaxDiscoveredFiles, MaxOutputAgeDays, MaxOutputTotalGB and EstimateSamplePages must not be negative
+//   - Preset, when set, must be one of Presets
 //   - ImageMaxDPI must be between 72 and 600 DPI
 //   - ImageFormat must be "png" or "jpg"
+//   - ImagePlacement, when set, must be "end" or "inline"
+//   - OCRLanguage, when set, must be Tesseract language codes joined by "+"
+//   - TextMinConfidence must be between 0.0 and 1.0
+//   - ImageAltText, when set, must be "off", "ocr" or "caption"
+//   - Renderer, when set, must be "auto" or one of Renderers
+//   - ThumbnailWidth must be 0 or between 16 and 2048
 //   - DiagramConfidence must be between 0.0 and 1.0
+//   - DiagramMinSize must not be negative and DiagramMinEdgeDensity must be between 0.0 and 1.0
+//   - DiagramTypes must be known diagram types
+//   - PlantUMLTemplateDir, when set, must be an existing directory
+//   - OutputFormat and MarkdownFlavor, when set, must be one of OutputFormats and MarkdownFlavors
 //   - BaseHeaderLevel must be between 1 and 6
+//   - MaxHeaderDepth, when set, must be between 2 and 6
+//   - HeaderOverflow, when set, must be "clamp" or "bold"
+//   - TableMinConfidence must be between 0.0 and 1.0
+//   - ErrataLinks, when set, must be "off", "link" or "inject"
+//   - NumberLocale, when set, must be "off" or one of NumberLocales
+//   - ContentLanguage, when set, must be "off" or a language code
+//   - MarkdownLintRules must be known rules and MarkdownLineLength must not be negative
+//   - HeaderKeywordLocales must be known locales and HeaderRegexes must compile
+//   - SectionNumbering, when set, must be "preserve", "renumber" or "off"
+//   - UpdateCheckURL, when set, must be an http or https URL
+//   - Locale, when set, must be one of Locales
 //   - LogLevel must be one of: debug, info, warn, error
-//   - Transport must be one of: stdio
+//   - Transport must be one of: stdio, http, or a comma-separated list of them without repeats
+//   - HTTPAddr must be a host:port address when Transport includes http
+//   - TraceFile must be set when Trace is enabled
+//   - MaxMessageSizeMB must not be negative
+//   - MaxToolCalls, when set, must be between 1 and 64
+//   - MaxQueuedJobs must not be negative
+//   - PDFInputDir and OutputBaseDir must be set when RestrictedMode is enabled
+//   - ToolTimeout must not be negative
 //
 // Returns:
 //   - error: Validation error describing the first invalid setting found, or nil if valid
 func (c *Config) Validate() error {
+	// Validate discovery limit
+	if c.MaxDiscoveredFiles < 0 {
+		return fmt.Errorf("MAX_DISCOVERED_FILES must not be negative, got %d", c.MaxDiscoveredFiles)
+	}
+
+	// Validate retention policy
+	if c.MaxOutputAgeDays < 0 {
+		return fmt.Errorf("MAX_OUTPUT_AGE_DAYS must not be negative, got %d", c.MaxOutputAgeDays)
+	}
+	if c.MaxOutputTotalGB < 0 {
+		return fmt.Errorf("MAX_OUTPUT_TOTAL_GB must not be negative, got %f", c.MaxOutputTotalGB)
+	}
+	if c.EstimateSamplePages < 0 {
+		return fmt.Errorf("ESTIMATE_SAMPLE_PAGES must not be negative, got %d", c.EstimateSamplePages)
+	}
+
+	// Validate conversion preset (empty means no preset)
+	if c.Preset != "" && !contains(Presets, c.Preset) {
+		return fmt.Errorf("CONVERSION_PRESET must be one of %v, got '%s'", Presets, c.Preset)
+	}
+
 	// Validate image DPI range
 	if c.ImageMaxDPI < 72 || c.ImageMaxDPI > 600 {
 		return fmt.Errorf("IMAGE_MAX_DPI must be between 72 and 600, got %d", c.ImageMaxDPI)
@@ -125,16 +461,129 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("IMAGE_FORMAT must be 'png' or 'jpg', got '%s'", c.ImageFormat)
 	}
 
+	// Validate image placement strategy (empty means the default "end")
+	validPlacements := []string{"end", "inline"}
+	if c.ImagePlacement != "" && !contains(validPlacements, c.ImagePlacement) {
+		return fmt.Errorf("IMAGE_PLACEMENT must be one of %v, got '%s'", validPlacements, c.ImagePlacement)
+	}
+
+	// Validate OCR language (empty means the default "eng")
+	if c.OCRLanguage != "" && !IsOCRLanguage(c.OCRLanguage) {
+		return fmt.Errorf("OCR_LANGUAGE must be Tesseract language codes joined by '+', got '%s'", c.OCRLanguage)
+	}
+	if c.TextMinConfidence < 0.0 || c.TextMinConfidence > 1.0 {
+		return fmt.Errorf("TEXT_MIN_CONFIDENCE must be between 0.0 and 1.0, got %f", c.TextMinConfidence)
+	}
+
+	// Validate image alt text source (empty means the default "off")
+	validAltText := []string{"off", "ocr", "caption"}
+	if c.ImageAltText != "" && !contains(validAltText, c.ImageAltText) {
+		return fmt.Errorf("IMAGE_ALT_TEXT must be one of %v, got '%s'", validAltText, c.ImageAltText)
+	}
+
+	// Validate page renderer (empty means the default "auto")
+	if c.Renderer != "" && c.Renderer != "auto" && !contains(Renderers, c.Renderer) {
+		return fmt.Errorf("RENDERER must be 'auto' or one of %v, got '%s'", Renderers, c.Renderer)
+	}
+
+	// Validate thumbnail width
+	if c.ThumbnailWidth != 0 && (c.ThumbnailWidth < 16 || c.ThumbnailWidth > 2048) {
+		return fmt.Errorf("THUMBNAIL_WIDTH must be 0 or between 16 and 2048, got %d", c.ThumbnailWidth)
+	}
+
 	// Validate diagram confidence range
 	if c.DiagramConfidence < 0.0 || c.DiagramConfidence > 1.0 {
 		return fmt.Errorf("DIAGRAM_CONFIDENCE must be between 0.0 and 1.0, got %f", c.DiagramConfidence)
 	}
+	if c.DiagramMinSize < 0 {
+		return fmt.Errorf("DIAGRAM_MIN_SIZE must not be negative, got %d", c.DiagramMinSize)
+	}
+	if c.DiagramMinEdgeDensity < 0.0 || c.DiagramMinEdgeDensity > 1.0 {
+		return fmt.Errorf("DIAGRAM_MIN_EDGE_DENSITY must be between 0.0 and 1.0, got %f", c.DiagramMinEdgeDensity)
+	}
+
+	// Validate table confidence range
+	if c.TableMinConfidence < 0.0 || c.TableMinConfidence > 1.0 {
+		return fmt.Errorf("TABLE_MIN_CONFIDENCE must be between 0.0 and 1.0, got %f", c.TableMinConfidence)
+	}
+
+	// Validate output format and Markdown flavor (empty means the defaults)
+	if c.OutputFormat != "" && !contains(OutputFormats, c.OutputFormat) {
+		return fmt.Errorf("OUTPUT_FORMAT must be one of %v, got '%s'", OutputFormats, c.OutputFormat)
+	}
+	if c.MarkdownFlavor != "" && !contains(MarkdownFlavors, c.MarkdownFlavor) {
+		return fmt.Errorf("MARKDOWN_FLAVOR must be one of %v, got '%s'", MarkdownFlavors, c.MarkdownFlavor)
+	}
+
+	// Validate number locale (empty means the default "off")
+	if c.NumberLocale != "" && c.NumberLocale != "off" && !contains(NumberLocales, c.NumberLocale) {
+		return fmt.Errorf("NUMBER_LOCALE must be 'off' or one of %v, got '%s'", NumberLocales, c.NumberLocale)
+	}
+
+	// Validate content language filter (empty means the default "off")
+	if c.ContentLanguage != "" && c.ContentLanguage != "off" && !IsLanguageCode(c.ContentLanguage) {
+		return fmt.Errorf("CONTENT_LANGUAGE_FILTER must be 'off' or a language code such as en or zh, got '%s'", c.ContentLanguage)
+	}
 
 	// Validate header level range
 	if c.BaseHeaderLevel < 1 || c.BaseHeaderLevel > 6 {
 		return fmt.Errorf("BASE_HEADER_LEVEL must be between 1 and 6, got %d", c.BaseHeaderLevel)
 	}
 
+	// Validate heading depth limit (0 means the default 6)
+	if c.MaxHeaderDepth != 0 && (c.MaxHeaderDepth < 2 || c.MaxHeaderDepth > 6) {
+		return fmt.Errorf("MAX_HEADER_DEPTH must be between 2 and 6, got %d", c.MaxHeaderDepth)
+	}
+	validOverflow := []string{"clamp", "bold"}
+	if c.HeaderOverflow != "" && !contains(validOverflow, c.HeaderOverflow) {
+		return fmt.Errorf("HEADER_OVERFLOW must be one of %v, got '%s'", validOverflow, c.HeaderOverflow)
+	}
+	validErrataLinks := []string{"off", "link", "inject"}
+	if c.ErrataLinks != "" && !contains(validErrataLinks, c.ErrataLinks) {
+		return fmt.Errorf("ERRATA_LINKS must be one of %v, got '%s'", validErrataLinks, c.ErrataLinks)
+	}
+
+	// Validate Markdown lint settings
+	for _, rule := range c.MarkdownLintRules {
+		if !contains(MarkdownLintRules, rule) {
+			return fmt.Errorf("MARKDOWN_LINT_RULES entries must be one of %v, got '%s'", MarkdownLintRules, rule)
+		}
+	}
+	if c.MarkdownLineLength < 0 {
+		return fmt.Errorf("MARKDOWN_LINE_LENGTH must not be negative, got %d", c.MarkdownLineLength)
+	}
+
+	// Validate header detection settings
+	validHeaderLocales := []string{"en", "de", "fr", "ja", "zh"}
+	for _, locale := range c.HeaderKeywordLocales {
+		if !contains(validHeaderLocales, locale) {
+			return fmt.Errorf("HEADER_KEYWORD_LOCALES entries must be one of %v, got '%s'", validHeaderLocales, locale)
+		}
+	}
+	for _, expr := range c.HeaderRegexes {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("HEADER_REGEXES contains an invalid regular expression '%s': %v", expr, err)
+		}
+	}
+
+	// Validate section numbering mode (empty means the default "preserve")
+	validNumbering := []string{"preserve", "renumber", "off"}
+	if c.SectionNumbering != "" && !contains(validNumbering, c.SectionNumbering) {
+		return fmt.Errorf("SECTION_NUMBERING must be one of %v, got '%s'", validNumbering, c.SectionNumbering)
+	}
+
+	// Validate the release feed URL
+	if c.UpdateCheckURL != "" {
+		if u, err := url.Parse(c.UpdateCheckURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("UPDATE_CHECK_URL must be an http or https URL, got '%s'", c.UpdateCheckURL)
+		}
+	}
+
+	// Validate the message locale (empty means English)
+	if c.Locale != "" && !contains(Locales, c.Locale) {
+		return fmt.Errorf("LOCALE must be one of %v, got '%s'", Locales, c.Locale)
+	}
+
 	// Validate log level
 	validLogLevels := []string{"debug", "info", "warn", "error"}
 	if !contains(validLogLevels, c.LogLevel) {
@@ -142,9 +591,43 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate transport method
-	validTransports := []string{"stdio"}
-	if !contains(validTransports, c.Transport) {
-		return fmt.Errorf("MCP_TRANSPORT must be one of %v, got '%s'", validTransports, c.Transport)
+	transports := c.TransportList()
+	if len(transports) == 0 {
+		return fmt.Errorf("MCP_TRANSPORT must be one of %v, got '%s'", Transports, c.Transport)
+	}
+	for i, transport := range transports {
+		if !contains(Transports, transport) {
+			return fmt.Errorf("MCP_TRANSPORT must be one of %v, or several of them separated by commas, got '%s'", Transports, c.Transport)
+		}
+		if contains(transports[:i], transport) {
+			return fmt.Errorf("MCP_TRANSPORT lists %s more than once", transport)
+		}
+	}
+	if c.HasTransport("http") && !IsListenAddress(c.HTTPAddr) {
+		return fmt.Errorf("MCP_HTTP_ADDR must be a host:port address such as 127.0.0.1:8080, got '%s'", c.HTTPAddr)
+	}
+	if c.Trace && c.TraceFile == "" {
+		return fmt.Errorf("MCP_TRACE_FILE must be set when MCP_TRACE is enabled")
+	}
+	if c.MaxMessageSizeMB < 0 {
+		return fmt.Errorf("MAX_MESSAGE_SIZE_MB must not be negative, got %d", c.MaxMessageSizeMB)
+	}
+	if c.MaxToolCalls < 0 || c.MaxToolCalls > 64 {
+		return fmt.Errorf("MAX_CONCURRENT_TOOL_CALLS must be between 1 and 64, got %d", c.MaxToolCalls)
+	}
+	if c.ToolTimeout < 0 {
+		return fmt.Errorf("CONVERSION_TIMEOUT must not be negative, got %d", c.ToolTimeout)
+	}
+	if c.MaxQueuedJobs < 0 {
+		return fmt.Errorf("MAX_QUEUED_JOBS must not be negative, got %d", c.MaxQueuedJobs)
+	}
+
+	// Validate restricted mode, whose sandbox is the input and output directories
+	if c.RestrictedMode && c.PDFInputDir == "" {
+		return fmt.Errorf("RESTRICTED_MODE requires PDF_INPUT_DIR to be set")
+	}
+	if c.RestrictedMode && c.OutputBaseDir == "" {
+		return fmt.Errorf("RESTRICTED_MODE requires OUTPUT_BASE_DIR to be set")
 	}
 
 	// Validate PlantUML style
@@ -159,6 +642,20 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("PLANTUML_COLOR_SCHEME must be one of %v, got '%s'", validColorSchemes, c.PlantUMLColorScheme)
 	}
 
+	// Validate diagram types
+	for _, diagramType := range c.DiagramTypes {
+		if !contains(DiagramTypes, strings.ToLower(diagramType)) {
+			return fmt.Errorf("DETECT_DIAGRAM_TYPES entries must be one of %v, got '%s'", DiagramTypes, diagramType)
+		}
+	}
+
+	// Validate PlantUML template directory
+	if c.PlantUMLTemplateDir != "" {
+		if info, err := os.Stat(c.PlantUMLTemplateDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("PLANTUML_TEMPLATE_DIR must be an existing directory, got '%s'", c.PlantUMLTemplateDir)
+		}
+	}
+
 	return nil
 }
 
@@ -237,6 +734,29 @@ func getEnvBoolWithDefault(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvListWithDefault retrieves an environment variable as a list of values split on sep,
+// or returns a default if the variable is not set. Entries are trimmed and empty entries dropped.
+//
+// Parameters:
+//   - key: Environment variable name to look up
+//   - sep: Separator between list entries
+//   - defaultValue: List to return if environment variable is not set or empty
+//
+// Returns:
+//   - []string: Parsed list or default value
+func getEnvListWithDefault(key, sep string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultValue
+}
+
 // contains checks if a string slice contains a specific string value.
 // This is a utility function used for validating configuration values against allowed lists.
 //
@@ -268,94 +788,16 @@ func ConfigExample() string {
 	lines = append(lines, "# See README.md for detailed descriptions of each setting.")
 	lines = append(lines, "")
 
-	// Configuration sections with descriptions
-	configSections := []struct {
-		Title string
-		Keys  []struct {
-			Key         string
-			Description string
-			Default     string
-		}
-	}{
-		{
-			Title: "PDF Input/Output Settings",
-			Keys: []struct {
-				Key         string
-				Description string
-				Default     string
-			}{
-				{"PDF_INPUT_DIR", "Directory containing PDF files to process", ""},
-				{"OUTPUT_BASE_DIR", "Base output directory", "./output"},
-			},
-		},
-		{
-			Title: "Server Settings",
-			Keys: []struct {
-				Key         string
-				Description string
-				Default     string
-			}{
-				{"MCP_SERVER_NAME", "Server identification name", "pdf-to-markdown-server"},
-				{"MCP_SERVER_VERSION", "Server version", "1.0.0"},
-			},
-		},
-		{
-			Title: "Image Processing Settings",
-			Keys: []struct {
-				Key         string
-				Description string
-				Default     string
-			}{
-				{"IMAGE_MAX_DPI", "Maximum image resolution (72-600)", "300"},
-				{"IMAGE_FORMAT", "Image output format (png/jpg)", "png"},
-				{"PRESERVE_ASPECT_RATIO", "Maintain image aspect ratios", "true"},
-			},
-		},
-		{
-			Title: "Diagram Detection and PlantUML Settings",
-			Keys: []struct {
-				Key         string
-				Description string
-				Default     string
-			}{
-				{"DETECT_DIAGRAMS", "Enable diagram detection and PlantUML generation", "false"},
-				{"DIAGRAM_CONFIDENCE", "Minimum confidence for diagram detection (0.0-1.0)", "0.7"},
-				{"PLANTUML_STYLE", "PlantUML diagram style (default/blueprint/modern)", "default"},
-				{"PLANTUML_COLOR_SCHEME", "PlantUML color scheme (mono/color/auto)", "auto"},
-			},
-		},
-		{
-			Title: "Markdown Generation Settings",
-			Keys: []struct {
-				Key         string
-				Description string
-				Default     string
-			}{
-				{"INCLUDE_TOC", "Generate table of contents", "true"},
-				{"BASE_HEADER_LEVEL", "Starting header level (1-6)", "1"},
-				{"EXTRACT_TABLES", "Enable table extraction", "true"},
-				{"EXTRACT_IMAGES", "Enable image extraction", "true"},
-			},
-		},
-		{
-			Title: "Logging and Transport Settings",
-			Keys: []struct {
-				Key         string
-				Description string
-				Default     string
-			}{
-				{"LOG_LEVEL", "Logging verbosity (debug/info/warn/error)", "info"},
-				{"MCP_TRANSPORT", "Transport method for MCP communication (stdio)", "stdio"},
-			},
-		},
-	}
-
-	for i, section := range configSections {
+	// Configuration sections with descriptions, in registry order
+	for i, section := range KeySections() {
 		if i > 0 {
 			lines = append(lines, "")
 		}
-		lines = append(lines, fmt.Sprintf("# %s", section.Title))
-		for _, key := range section.Keys {
+		lines = append(lines, fmt.Sprintf("# %s", section))
+		for _, key := range Keys {
+			if key.Section != section {
+				continue
+			}
 			lines = append(lines, fmt.Sprintf("# %s", key.Description))
 			lines = append(lines, fmt.Sprintf("%s=%s", key.Key, key.Default))
 			lines = append(lines, "")
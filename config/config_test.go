@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -10,10 +11,13 @@ func TestLoadConfig(t *testing.T) {
 	// Save original environment
 	originalEnv := map[string]string{}
 	envVars := []string{
-		"PDF_INPUT_DIR", "OUTPUT_BASE_DIR", "MCP_SERVER_NAME", "MCP_SERVER_VERSION",
-		"IMAGE_MAX_DPI", "IMAGE_FORMAT", "PRESERVE_ASPECT_RATIO", "DETECT_DIAGRAMS",
-		"DIAGRAM_CONFIDENCE", "PLANTUML_STYLE", "PLANTUML_COLOR_SCHEME", "INCLUDE_TOC",
+		"PDF_INPUT_DIR", "OUTPUT_BASE_DIR", "MCP_SERVER_NAME", "MCP_SERVER_VERSION", "UPDATE_CHECK", "UPDATE_CHECK_URL", "LOCALE", "RESTRICTED_MODE",
+		"IMAGE_MAX_DPI", "IMAGE_FORMAT", "PRESERVE_ASPECT_RATIO", "IMAGE_PLACEMENT", "DETECT_DIAGRAMS",
+		"DETECT_DIAGRAM_TYPES", "DIAGRAM_CONFIDENCE", "DIAGRAM_MIN_SIZE", "DIAGRAM_MIN_EDGE_DENSITY", "CURVE_DATA", "PLANTUML_STYLE", "PLANTUML_COLOR_SCHEME", "PLANTUML_TEMPLATE_DIR", "INCLUDE_TOC",
 		"BASE_HEADER_LEVEL", "EXTRACT_TABLES", "EXTRACT_IMAGES", "LOG_LEVEL", "MCP_TRANSPORT",
+		"HEADER_KEYWORD_LOCALES", "HEADER_KEYWORDS", "HEADER_REGEXES", "HEADING_NORMALIZE", "SECTION_NUMBERING", "OUTPUT_FORMAT", "MARKDOWN_FLAVOR", "MAX_HEADER_DEPTH", "HEADER_OVERFLOW", "CROSS_REFERENCE_LINKS", "TABLE_MIN_CONFIDENCE", "NORMALIZE_SPEC_TABLES", "BOLD_TYP_VALUES", "VARIANT_TABLES", "PACKAGE_DIMENSIONS", "APPLICATION_BOM", "COMPLIANCE_TAGS", "ERRATA_LINKS", "MONOSPACE_CODE", "PRESERVE_EMPHASIS", "DETECT_CALLOUTS", "JOIN_PAGE_BREAKS", "DISK_SPACE_CHECK", "MAX_OUTPUT_AGE_DAYS", "MAX_OUTPUT_TOTAL_GB", "ESTIMATE_SAMPLE_PAGES", "TMP_DIR", "INCREMENTAL_CONVERSION", "CHANGE_REPORT", "BATCH_SUMMARY", "BATCH_RESULTS", "STRICT_MODE",
+		"FOLLOW_SYMLINKS", "INCLUDE_HIDDEN_DIRS", "MAX_DISCOVERED_FILES", "OCR_LANGUAGE", "TEXT_MIN_CONFIDENCE", "NUMBER_LOCALE", "CONTENT_LANGUAGE_FILTER",
+		"IMAGE_ALT_TEXT", "ACCESSIBLE_OUTPUT", "MARKDOWN_LINT", "MARKDOWN_LINT_RULES", "MARKDOWN_LINE_LENGTH", "CONVERSION_PRESET", "RENDERER", "THUMBNAIL_WIDTH", "MAX_MESSAGE_SIZE_MB", "MAX_CONCURRENT_TOOL_CALLS", "CONVERSION_TIMEOUT", "MAX_QUEUED_JOBS", "MCP_HTTP_ADDR", "MCP_ALLOWED_HOSTS", "MCP_TRACE", "MCP_TRACE_FILE",
 	}
 
 	for _, key := range envVars {
@@ -45,6 +49,18 @@ func TestLoadConfig(t *testing.T) {
 		if cfg.ServerVersion != "1.0.0" {
 			t.Errorf("ServerVersion '1.0.0', got '%s'", cfg.ServerVersion)
 		}
+		if cfg.Locale != "en" {
+			t.Errorf("Locale 'en', got '%s'", cfg.Locale)
+		}
+		if cfg.UpdateCheck || cfg.UpdateCheckURL != DefaultUpdateCheckURL {
+			t.Errorf("UpdateCheck false with the default feed, got %t '%s'", cfg.UpdateCheck, cfg.UpdateCheckURL)
+		}
+		if cfg.RestrictedMode {
+			t.Errorf("RestrictedMode false, got %t", cfg.RestrictedMode)
+		}
+		if cfg.Preset != "" {
+			t.Errorf("no Preset, got '%s'", cfg.Preset)
+		}
 		if cfg.ImageMaxDPI != 300 {
 			t.Errorf("ImageMaxDPI 300, got %d", cfg.ImageMaxDPI)
 		}
@@ -54,36 +70,156 @@ func TestLoadConfig(t *testing.T) {
 		if !cfg.PreserveAspectRatio {
 			t.Error("PreserveAspectRatio true")
 		}
+		if cfg.OCRLanguage != "eng" {
+			t.Errorf("OCRLanguage 'eng', got '%s'", cfg.OCRLanguage)
+		}
+		if cfg.TextMinConfidence != 0.5 {
+			t.Errorf("TextMinConfidence 0.5, got %f", cfg.TextMinConfidence)
+		}
+		if cfg.ImagePlacement != "end" {
+			t.Errorf("ImagePlacement 'end', got '%s'", cfg.ImagePlacement)
+		}
 		if cfg.DetectDiagrams {
 			t.Error("DetectDiagrams false")
 		}
 		if cfg.DiagramConfidence != 0.7 {
 			t.Errorf("DiagramConfidence 0.7, got %f", cfg.DiagramConfidence)
 		}
+		if cfg.CurveData {
+			t.Error("CurveData false")
+		}
+		if cfg.TableMinConfidence != 0.5 {
+			t.Errorf("TableMinConfidence 0.5, got %f", cfg.TableMinConfidence)
+		}
+		if !cfg.DiskSpaceCheck || cfg.MaxOutputAgeDays != 0 || cfg.MaxOutputTotalGB != 0 {
+			t.Errorf("DiskSpaceCheck true and retention disabled, got %t %d %f", cfg.DiskSpaceCheck, cfg.MaxOutputAgeDays, cfg.MaxOutputTotalGB)
+		}
+		if cfg.EstimateSamplePages != 5 {
+			t.Errorf("EstimateSamplePages 5, got %d", cfg.EstimateSamplePages)
+		}
+		if cfg.TempDir != "" || cfg.Incremental || cfg.ChangeReport || cfg.BatchSummary || cfg.BatchResults {
+			t.Errorf("TempDir empty, Incremental, ChangeReport, BatchSummary and BatchResults false, got '%s' %t %t %t %t", cfg.TempDir, cfg.Incremental, cfg.ChangeReport, cfg.BatchSummary, cfg.BatchResults)
+		}
+		if cfg.StrictMode {
+			t.Error("StrictMode false")
+		}
+		if cfg.FollowSymlinks || cfg.IncludeHiddenDirs || cfg.MaxDiscoveredFiles != 10000 {
+			t.Errorf("symlinks and hidden dirs skipped with a 10000 file limit, got %t %t %d", cfg.FollowSymlinks, cfg.IncludeHiddenDirs, cfg.MaxDiscoveredFiles)
+		}
+		if !cfg.NormalizeSpecTables || cfg.BoldTypValues {
+			t.Errorf("NormalizeSpecTables true and BoldTypValues false, got %t %t", cfg.NormalizeSpecTables, cfg.BoldTypValues)
+		}
+		if cfg.VariantTables {
+			t.Error("VariantTables false")
+		}
+		if cfg.PackageDimensions {
+			t.Error("PackageDimensions false")
+		}
+		if cfg.ApplicationBOM {
+			t.Error("ApplicationBOM false")
+		}
+		if cfg.ComplianceTags {
+			t.Error("ComplianceTags false")
+		}
+		if cfg.ErrataLinks != "off" {
+			t.Errorf("ErrataLinks off, got %s", cfg.ErrataLinks)
+		}
+		if !cfg.MonospaceCode || !cfg.PreserveEmphasis || !cfg.DetectCallouts || !cfg.JoinPageBreaks {
+			t.Error("MonospaceCode, PreserveEmphasis, DetectCallouts and JoinPageBreaks true")
+		}
+		if cfg.NumberLocale != "off" {
+			t.Errorf("NumberLocale 'off', got '%s'", cfg.NumberLocale)
+		}
+		if cfg.ContentLanguage != "off" {
+			t.Errorf("ContentLanguage 'off', got '%s'", cfg.ContentLanguage)
+		}
+		if cfg.ImageAltText != "off" {
+			t.Errorf("ImageAltText 'off', got '%s'", cfg.ImageAltText)
+		}
+		if cfg.Renderer != "auto" || cfg.ThumbnailWidth != 0 {
+			t.Errorf("Renderer 'auto' without thumbnails, got '%s' %d", cfg.Renderer, cfg.ThumbnailWidth)
+		}
+		if cfg.AccessibleOutput {
+			t.Error("AccessibleOutput false")
+		}
+		if cfg.MarkdownLint || strings.Join(cfg.MarkdownLintRules, ",") != "MD009,MD012,MD019,MD022,MD031,MD040,MD047" || cfg.MarkdownLineLength != 80 {
+			t.Errorf("MarkdownLint off with default rules and line length 80, got %t %v %d", cfg.MarkdownLint, cfg.MarkdownLintRules, cfg.MarkdownLineLength)
+		}
 		if cfg.PlantUMLStyle != "default" {
 			t.Errorf("PlantUMLStyle 'default', got '%s'", cfg.PlantUMLStyle)
 		}
 		if cfg.PlantUMLColorScheme != "auto" {
 			t.Errorf("PlantUMLColorScheme 'auto', got '%s'", cfg.PlantUMLColorScheme)
 		}
+		if cfg.DiagramMinSize != 100 || cfg.DiagramMinEdgeDensity != 0.01 {
+			t.Errorf("DiagramMinSize 100 and DiagramMinEdgeDensity 0.01, got %d %f", cfg.DiagramMinSize, cfg.DiagramMinEdgeDensity)
+		}
+		if cfg.DiagramTypes != nil {
+			t.Errorf("DiagramTypes empty, got %v", cfg.DiagramTypes)
+		}
+		if cfg.PlantUMLTemplateDir != "" {
+			t.Errorf("PlantUMLTemplateDir empty, got '%s'", cfg.PlantUMLTemplateDir)
+		}
 		if !cfg.IncludeTOC {
 			t.Error("IncludeTOC true")
 		}
 		if cfg.BaseHeaderLevel != 1 {
 			t.Errorf("BaseHeaderLevel 1, got %d", cfg.BaseHeaderLevel)
 		}
+		if cfg.OutputFormat != "markdown" || cfg.MarkdownFlavor != "gfm" {
+			t.Errorf("OutputFormat markdown and MarkdownFlavor gfm, got %s %s", cfg.OutputFormat, cfg.MarkdownFlavor)
+		}
+		if cfg.MaxHeaderDepth != 6 || cfg.HeaderOverflow != "clamp" {
+			t.Errorf("MaxHeaderDepth 6 and HeaderOverflow clamp, got %d %s", cfg.MaxHeaderDepth, cfg.HeaderOverflow)
+		}
 		if !cfg.ExtractTables {
 			t.Error("ExtractTables true")
 		}
 		if !cfg.ExtractImages {
 			t.Error("ExtractImages true")
 		}
+		if len(cfg.HeaderKeywordLocales) != 1 || cfg.HeaderKeywordLocales[0] != "en" {
+			t.Errorf("HeaderKeywordLocales [en], got %v", cfg.HeaderKeywordLocales)
+		}
+		if len(cfg.HeaderKeywords) != 0 || len(cfg.HeaderRegexes) != 0 {
+			t.Errorf("HeaderKeywords and HeaderRegexes empty, got %v %v", cfg.HeaderKeywords, cfg.HeaderRegexes)
+		}
+		if cfg.HeadingNormalize {
+			t.Errorf("HeadingNormalize default false, got true")
+		}
+		if cfg.SectionNumbering != "preserve" {
+			t.Errorf("SectionNumbering default preserve, got %s", cfg.SectionNumbering)
+		}
+		if !cfg.CrossReferenceLinks {
+			t.Errorf("CrossReferenceLinks default true, got false")
+		}
 		if cfg.LogLevel != "info" {
 			t.Errorf("LogLevel 'info', got '%s'", cfg.LogLevel)
 		}
 		if cfg.Transport != "stdio" {
 			t.Errorf("Transport 'stdio', got '%s'", cfg.Transport)
 		}
+		if cfg.MaxMessageSizeMB != 64 {
+			t.Errorf("MaxMessageSizeMB 64, got %d", cfg.MaxMessageSizeMB)
+		}
+		if cfg.MaxToolCalls != 4 {
+			t.Errorf("MaxToolCalls 4, got %d", cfg.MaxToolCalls)
+		}
+		if cfg.ToolTimeout != 0 {
+			t.Errorf("ToolTimeout 0, got %d", cfg.ToolTimeout)
+		}
+		if cfg.MaxQueuedJobs != 20 {
+			t.Errorf("MaxQueuedJobs 20, got %d", cfg.MaxQueuedJobs)
+		}
+		if cfg.HTTPAddr != "127.0.0.1:8080" {
+			t.Errorf("HTTPAddr '127.0.0.1:8080', got '%s'", cfg.HTTPAddr)
+		}
+		if len(cfg.AllowedHosts) != 0 {
+			t.Errorf("no AllowedHosts, got %v", cfg.AllowedHosts)
+		}
+		if cfg.Trace || cfg.TraceFile != "mcp_trace.log" {
+			t.Errorf("tracing off to mcp_trace.log, got %t %s", cfg.Trace, cfg.TraceFile)
+		}
 	})
 
 	t.Run("custom configuration", func(t *testing.T) {
@@ -91,18 +227,80 @@ func TestLoadConfig(t *testing.T) {
 		os.Setenv("OUTPUT_BASE_DIR", "/custom/output")
 		os.Setenv("MCP_SERVER_NAME", "custom-server")
 		os.Setenv("MCP_SERVER_VERSION", "2.0.0")
+		os.Setenv("UPDATE_CHECK", "true")
+		os.Setenv("LOCALE", "JA")
+		os.Setenv("RESTRICTED_MODE", "true")
+		os.Setenv("UPDATE_CHECK_URL", "https://mirror.example.com/releases/latest")
 		os.Setenv("IMAGE_MAX_DPI", "600")
 		os.Setenv("IMAGE_FORMAT", "jpg")
 		os.Setenv("PRESERVE_ASPECT_RATIO", "false")
 		os.Setenv("DETECT_DIAGRAMS", "true")
 		os.Setenv("DIAGRAM_CONFIDENCE", "0.8")
+		os.Setenv("DETECT_DIAGRAM_TYPES", "flowchart, Block")
+		os.Setenv("DIAGRAM_MIN_SIZE", "0")
+		os.Setenv("DIAGRAM_MIN_EDGE_DENSITY", "0.05")
+		os.Setenv("CURVE_DATA", "true")
 		os.Setenv("PLANTUML_STYLE", "blueprint")
 		os.Setenv("PLANTUML_COLOR_SCHEME", "mono")
+		templateDir := t.TempDir()
+		os.Setenv("PLANTUML_TEMPLATE_DIR", templateDir)
 		os.Setenv("INCLUDE_TOC", "false")
 		os.Setenv("BASE_HEADER_LEVEL", "2")
+		os.Setenv("MAX_HEADER_DEPTH", "4")
+		os.Setenv("OUTPUT_FORMAT", "AsciiDoc")
+		os.Setenv("MARKDOWN_FLAVOR", "commonmark")
+		os.Setenv("HEADER_OVERFLOW", "Bold")
 		os.Setenv("EXTRACT_TABLES", "false")
 		os.Setenv("EXTRACT_IMAGES", "false")
 		os.Setenv("LOG_LEVEL", "debug")
+		os.Setenv("HEADER_KEYWORD_LOCALES", "en, ja")
+		os.Setenv("HEADER_KEYWORDS", "PINOUT,,REGISTER MAP")
+		os.Setenv("HEADER_REGEXES", `^\d+\.\d+;^Table \d{1,3}`)
+		os.Setenv("HEADING_NORMALIZE", "true")
+		os.Setenv("SECTION_NUMBERING", "renumber")
+		os.Setenv("CROSS_REFERENCE_LINKS", "false")
+		os.Setenv("TABLE_MIN_CONFIDENCE", "0.8")
+		os.Setenv("BOLD_TYP_VALUES", "true")
+		os.Setenv("VARIANT_TABLES", "true")
+		os.Setenv("PACKAGE_DIMENSIONS", "true")
+		os.Setenv("APPLICATION_BOM", "true")
+		os.Setenv("COMPLIANCE_TAGS", "true")
+		os.Setenv("ERRATA_LINKS", "Inject")
+		os.Setenv("MONOSPACE_CODE", "false")
+		os.Setenv("PRESERVE_EMPHASIS", "false")
+		os.Setenv("DETECT_CALLOUTS", "false")
+		os.Setenv("JOIN_PAGE_BREAKS", "false")
+		os.Setenv("NUMBER_LOCALE", "DE")
+		os.Setenv("CONTENT_LANGUAGE_FILTER", "ZH")
+		os.Setenv("IMAGE_ALT_TEXT", "Caption")
+		os.Setenv("RENDERER", "Ghostscript")
+		os.Setenv("THUMBNAIL_WIDTH", "256")
+		os.Setenv("ACCESSIBLE_OUTPUT", "true")
+		os.Setenv("MARKDOWN_LINT", "true")
+		os.Setenv("MARKDOWN_LINT_RULES", "MD013, MD047")
+		os.Setenv("MARKDOWN_LINE_LENGTH", "120")
+		os.Setenv("MAX_OUTPUT_AGE_DAYS", "30")
+		os.Setenv("MAX_OUTPUT_TOTAL_GB", "2.5")
+		os.Setenv("ESTIMATE_SAMPLE_PAGES", "12")
+		os.Setenv("TMP_DIR", "/custom/tmp")
+		os.Setenv("INCREMENTAL_CONVERSION", "true")
+		os.Setenv("CHANGE_REPORT", "true")
+		os.Setenv("BATCH_SUMMARY", "true")
+		os.Setenv("BATCH_RESULTS", "true")
+		os.Setenv("STRICT_MODE", "true")
+		os.Setenv("FOLLOW_SYMLINKS", "true")
+		os.Setenv("OCR_LANGUAGE", "eng+deu")
+		os.Setenv("TEXT_MIN_CONFIDENCE", "0")
+		os.Setenv("MAX_DISCOVERED_FILES", "0")
+		os.Setenv("MAX_MESSAGE_SIZE_MB", "256")
+		os.Setenv("MAX_CONCURRENT_TOOL_CALLS", "2")
+		os.Setenv("CONVERSION_TIMEOUT", "300")
+		os.Setenv("MAX_QUEUED_JOBS", "5")
+		os.Setenv("MCP_TRANSPORT", "http")
+		os.Setenv("MCP_HTTP_ADDR", ":9000")
+		os.Setenv("MCP_ALLOWED_HOSTS", "mcp.example, proxy.local")
+		os.Setenv("MCP_TRACE", "true")
+		os.Setenv("MCP_TRACE_FILE", "/custom/trace.log")
 
 		cfg, err := LoadConfig()
 		if err != nil {
@@ -120,6 +318,15 @@ func TestLoadConfig(t *testing.T) {
 		if cfg.ServerVersion != "2.0.0" {
 			t.Errorf("ServerVersion '2.0.0', got '%s'", cfg.ServerVersion)
 		}
+		if cfg.Locale != "ja" {
+			t.Errorf("Locale 'ja', got '%s'", cfg.Locale)
+		}
+		if !cfg.UpdateCheck || cfg.UpdateCheckURL != "https://mirror.example.com/releases/latest" {
+			t.Errorf("UpdateCheck true with a custom feed, got %t '%s'", cfg.UpdateCheck, cfg.UpdateCheckURL)
+		}
+		if !cfg.RestrictedMode {
+			t.Errorf("RestrictedMode true, got %t", cfg.RestrictedMode)
+		}
 		if cfg.ImageMaxDPI != 600 {
 			t.Errorf("ImageMaxDPI 600, got %d", cfg.ImageMaxDPI)
 		}
@@ -135,18 +342,36 @@ func TestLoadConfig(t *testing.T) {
 		if cfg.DiagramConfidence != 0.8 {
 			t.Errorf("DiagramConfidence 0.8, got %f", cfg.DiagramConfidence)
 		}
+		if !cfg.CurveData {
+			t.Error("CurveData true")
+		}
 		if cfg.PlantUMLStyle != "blueprint" {
 			t.Errorf("PlantUMLStyle 'blueprint', got '%s'", cfg.PlantUMLStyle)
 		}
 		if cfg.PlantUMLColorScheme != "mono" {
 			t.Errorf("PlantUMLColorScheme 'mono', got '%s'", cfg.PlantUMLColorScheme)
 		}
+		if cfg.DiagramMinSize != 0 || cfg.DiagramMinEdgeDensity != 0.05 {
+			t.Errorf("DiagramMinSize 0 and DiagramMinEdgeDensity 0.05, got %d %f", cfg.DiagramMinSize, cfg.DiagramMinEdgeDensity)
+		}
+		if strings.Join(cfg.DiagramTypes, "|") != "flowchart|Block" {
+			t.Errorf("DiagramTypes [flowchart Block], got %v", cfg.DiagramTypes)
+		}
+		if cfg.PlantUMLTemplateDir != templateDir {
+			t.Errorf("PlantUMLTemplateDir '%s', got '%s'", templateDir, cfg.PlantUMLTemplateDir)
+		}
 		if cfg.IncludeTOC {
 			t.Error("IncludeTOC false")
 		}
 		if cfg.BaseHeaderLevel != 2 {
 			t.Errorf("BaseHeaderLevel 2, got %d", cfg.BaseHeaderLevel)
 		}
+		if cfg.OutputFormat != "asciidoc" || cfg.MarkdownFlavor != "commonmark" {
+			t.Errorf("OutputFormat asciidoc and MarkdownFlavor commonmark, got %s %s", cfg.OutputFormat, cfg.MarkdownFlavor)
+		}
+		if cfg.MaxHeaderDepth != 4 || cfg.HeaderOverflow != "bold" {
+			t.Errorf("MaxHeaderDepth 4 and HeaderOverflow bold, got %d %s", cfg.MaxHeaderDepth, cfg.HeaderOverflow)
+		}
 		if cfg.ExtractTables {
 			t.Error("ExtractTables false")
 		}
@@ -156,6 +381,132 @@ func TestLoadConfig(t *testing.T) {
 		if cfg.LogLevel != "debug" {
 			t.Errorf("LogLevel 'debug', got '%s'", cfg.LogLevel)
 		}
+		if strings.Join(cfg.HeaderKeywordLocales, "|") != "en|ja" {
+			t.Errorf("HeaderKeywordLocales [en ja], got %v", cfg.HeaderKeywordLocales)
+		}
+		if strings.Join(cfg.HeaderKeywords, "|") != "PINOUT|REGISTER MAP" {
+			t.Errorf("HeaderKeywords [PINOUT REGISTER MAP], got %v", cfg.HeaderKeywords)
+		}
+		if len(cfg.HeaderRegexes) != 2 || cfg.HeaderRegexes[1] != `^Table \d{1,3}` {
+			t.Errorf("HeaderRegexes not split on semicolons, got %v", cfg.HeaderRegexes)
+		}
+		if !cfg.HeadingNormalize {
+			t.Errorf("HeadingNormalize expected true")
+		}
+		if cfg.SectionNumbering != "renumber" {
+			t.Errorf("SectionNumbering expected renumber, got %s", cfg.SectionNumbering)
+		}
+		if cfg.CrossReferenceLinks {
+			t.Errorf("CrossReferenceLinks expected false")
+		}
+		if cfg.TableMinConfidence != 0.8 {
+			t.Errorf("TableMinConfidence 0.8, got %f", cfg.TableMinConfidence)
+		}
+		if !cfg.BoldTypValues {
+			t.Error("BoldTypValues true")
+		}
+		if !cfg.VariantTables {
+			t.Error("VariantTables true")
+		}
+		if !cfg.PackageDimensions {
+			t.Error("PackageDimensions true")
+		}
+		if !cfg.ApplicationBOM {
+			t.Error("ApplicationBOM true")
+		}
+		if !cfg.ComplianceTags {
+			t.Error("ComplianceTags true")
+		}
+		if cfg.ErrataLinks != "inject" {
+			t.Errorf("ErrataLinks inject, got %s", cfg.ErrataLinks)
+		}
+		if cfg.MonospaceCode || cfg.PreserveEmphasis || cfg.DetectCallouts || cfg.JoinPageBreaks {
+			t.Error("MonospaceCode, PreserveEmphasis, DetectCallouts and JoinPageBreaks false")
+		}
+		if cfg.NumberLocale != "de" {
+			t.Errorf("NumberLocale 'de', got '%s'", cfg.NumberLocale)
+		}
+		if cfg.ContentLanguage != "zh" {
+			t.Errorf("ContentLanguage 'zh', got '%s'", cfg.ContentLanguage)
+		}
+		if cfg.ImageAltText != "caption" {
+			t.Errorf("ImageAltText 'caption', got '%s'", cfg.ImageAltText)
+		}
+		if cfg.Renderer != "ghostscript" || cfg.ThumbnailWidth != 256 {
+			t.Errorf("Renderer 'ghostscript' with 256 pixel thumbnails, got '%s' %d", cfg.Renderer, cfg.ThumbnailWidth)
+		}
+		if !cfg.AccessibleOutput {
+			t.Error("AccessibleOutput true")
+		}
+		if !cfg.MarkdownLint || strings.Join(cfg.MarkdownLintRules, ",") != "MD013,MD047" || cfg.MarkdownLineLength != 120 {
+			t.Errorf("MarkdownLint on with MD013,MD047 at 120, got %t %v %d", cfg.MarkdownLint, cfg.MarkdownLintRules, cfg.MarkdownLineLength)
+		}
+		if cfg.EstimateSamplePages != 12 {
+			t.Errorf("EstimateSamplePages 12, got %d", cfg.EstimateSamplePages)
+		}
+		if cfg.TempDir != "/custom/tmp" || !cfg.Incremental || !cfg.ChangeReport || !cfg.BatchSummary || !cfg.BatchResults {
+			t.Errorf("TempDir '/custom/tmp', Incremental, ChangeReport, BatchSummary and BatchResults true, got '%s' %t %t %t %t", cfg.TempDir, cfg.Incremental, cfg.ChangeReport, cfg.BatchSummary, cfg.BatchResults)
+		}
+		if !cfg.StrictMode {
+			t.Error("StrictMode true")
+		}
+		if cfg.MaxOutputAgeDays != 30 || cfg.MaxOutputTotalGB != 2.5 {
+			t.Errorf("retention 30 days / 2.5 GB, got %d / %f", cfg.MaxOutputAgeDays, cfg.MaxOutputTotalGB)
+		}
+		if cfg.OCRLanguage != "eng+deu" {
+			t.Errorf("OCRLanguage 'eng+deu', got '%s'", cfg.OCRLanguage)
+		}
+		if cfg.TextMinConfidence != 0 {
+			t.Errorf("TextMinConfidence 0, got %f", cfg.TextMinConfidence)
+		}
+		if !cfg.FollowSymlinks || cfg.MaxDiscoveredFiles != 0 {
+			t.Errorf("FollowSymlinks true and no discovery limit, got %t %d", cfg.FollowSymlinks, cfg.MaxDiscoveredFiles)
+		}
+		if cfg.MaxMessageSizeMB != 256 {
+			t.Errorf("MaxMessageSizeMB 256, got %d", cfg.MaxMessageSizeMB)
+		}
+		if cfg.MaxToolCalls != 2 {
+			t.Errorf("MaxToolCalls 2, got %d", cfg.MaxToolCalls)
+		}
+		if cfg.ToolTimeout != 300 {
+			t.Errorf("ToolTimeout 300, got %d", cfg.ToolTimeout)
+		}
+		if cfg.MaxQueuedJobs != 5 {
+			t.Errorf("MaxQueuedJobs 5, got %d", cfg.MaxQueuedJobs)
+		}
+		if cfg.Transport != "http" || cfg.HTTPAddr != ":9000" {
+			t.Errorf("http transport on :9000, got %s on %s", cfg.Transport, cfg.HTTPAddr)
+		}
+		if !reflect.DeepEqual(cfg.AllowedHosts, []string{"mcp.example", "proxy.local"}) {
+			t.Errorf("AllowedHosts [mcp.example proxy.local], got %v", cfg.AllowedHosts)
+		}
+		if !cfg.Trace || cfg.TraceFile != "/custom/trace.log" {
+			t.Errorf("tracing to /custom/trace.log, got %t %s", cfg.Trace, cfg.TraceFile)
+		}
+	})
+
+	t.Run("conversion preset", func(t *testing.T) {
+		for _, key := range envVars {
+			os.Unsetenv(key)
+		}
+		os.Setenv("CONVERSION_PRESET", "Fast")
+		os.Setenv("IMAGE_FORMAT", "png")
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if cfg.Preset != "fast" || cfg.ImageMaxDPI != 150 || cfg.DetectDiagrams || cfg.IncludeTOC {
+			t.Errorf("fast preset applied, got %s %d %t %t", cfg.Preset, cfg.ImageMaxDPI, cfg.DetectDiagrams, cfg.IncludeTOC)
+		}
+		if cfg.ImageFormat != "png" {
+			t.Errorf("IMAGE_FORMAT set explicitly takes precedence over the preset, got '%s'", cfg.ImageFormat)
+		}
+
+		os.Setenv("CONVERSION_PRESET", "slow")
+		if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "unknown conversion preset") {
+			t.Errorf("expected an unknown preset error, got %v", err)
+		}
 	})
 }
 
@@ -167,17 +518,59 @@ func TestConfigValidate(t *testing.T) {
 		errorMsg    string
 	}{
 		{"valid configuration", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, false, ""},
+		{"invalid Preset", Config{Preset: "quick", ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "CONVERSION_PRESET must be one of"},
 		{"invalid ImageMaxDPI - too low", Config{ImageMaxDPI: 50, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "IMAGE_MAX_DPI must be between 72 and 600"},
 		{"invalid ImageMaxDPI - too high", Config{ImageMaxDPI: 800, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "IMAGE_MAX_DPI must be between 72 and 600"},
 		{"invalid ImageFormat", Config{ImageMaxDPI: 300, ImageFormat: "gif", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "IMAGE_FORMAT must be 'png' or 'jpg'"},
+		{"invalid ImagePlacement", Config{ImageMaxDPI: 300, ImageFormat: "png", ImagePlacement: "middle", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "IMAGE_PLACEMENT must be one of"},
+		{"invalid OCRLanguage", Config{ImageMaxDPI: 300, ImageFormat: "png", OCRLanguage: "eng;rm -rf", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "OCR_LANGUAGE must be Tesseract language codes"},
+		{"invalid HeaderKeywordLocales", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, HeaderKeywordLocales: []string{"xx"}, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "HEADER_KEYWORD_LOCALES entries must be one of"},
+		{"invalid HeaderRegexes", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, HeaderRegexes: []string{"(unclosed"}, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "HEADER_REGEXES contains an invalid regular expression"},
+		{"invalid MaxOutputAgeDays", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, MaxOutputAgeDays: -1, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MAX_OUTPUT_AGE_DAYS must not be negative"},
+		{"invalid EstimateSamplePages", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, EstimateSamplePages: -1, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "ESTIMATE_SAMPLE_PAGES must not be negative"},
+		{"invalid MaxDiscoveredFiles", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, MaxDiscoveredFiles: -5, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MAX_DISCOVERED_FILES must not be negative"},
+		{"invalid HTTPAddr", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "http", HTTPAddr: "localhost", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MCP_HTTP_ADDR must be a host:port address"},
+		{"missing TraceFile", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", Trace: true, PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MCP_TRACE_FILE must be set"},
+		{"invalid MaxMessageSizeMB", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", MaxMessageSizeMB: -1, PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MAX_MESSAGE_SIZE_MB must not be negative"},
+		{"invalid MaxToolCalls", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", MaxToolCalls: 65, PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MAX_CONCURRENT_TOOL_CALLS must be between 1 and 64"},
+		{"invalid ToolTimeout", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", ToolTimeout: -1, PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "CONVERSION_TIMEOUT must not be negative"},
+		{"RestrictedMode without PDFInputDir", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", RestrictedMode: true, OutputBaseDir: "/out", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "RESTRICTED_MODE requires PDF_INPUT_DIR"},
+		{"RestrictedMode without OutputBaseDir", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", RestrictedMode: true, PDFInputDir: "/in", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "RESTRICTED_MODE requires OUTPUT_BASE_DIR"},
+		{"valid RestrictedMode", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", RestrictedMode: true, PDFInputDir: "/in", OutputBaseDir: "/out", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, false, ""},
+		{"invalid MaxQueuedJobs", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", MaxQueuedJobs: -1, PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MAX_QUEUED_JOBS must not be negative"},
+		{"invalid TextMinConfidence", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, TextMinConfidence: -0.1, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "TEXT_MIN_CONFIDENCE must be between 0.0 and 1.0"},
+		{"invalid TableMinConfidence", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, TableMinConfidence: 1.5, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "TABLE_MIN_CONFIDENCE must be between 0.0 and 1.0"},
+		{"invalid ImageAltText", Config{ImageMaxDPI: 300, ImageFormat: "png", ImageAltText: "model", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "IMAGE_ALT_TEXT must be one of"},
+		{"invalid Renderer", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, Renderer: "mupdf", BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "RENDERER must be 'auto' or one of"},
+		{"invalid ThumbnailWidth", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, ThumbnailWidth: 8, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "THUMBNAIL_WIDTH must be 0 or between 16 and 2048"},
+		{"invalid NumberLocale", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, NumberLocale: "xx", BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "NUMBER_LOCALE must be 'off' or one of"},
+		{"invalid ContentLanguage", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, ContentLanguage: "chinese", BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "CONTENT_LANGUAGE_FILTER must be 'off' or a language code"},
+		{"invalid MarkdownLintRules", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, MarkdownLintRules: []string{"MD001"}, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MARKDOWN_LINT_RULES entries must be one of"},
+		{"invalid MarkdownLineLength", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, MarkdownLineLength: -1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MARKDOWN_LINE_LENGTH must not be negative"},
+		{"invalid SectionNumbering", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, SectionNumbering: "auto", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "SECTION_NUMBERING must be one of"},
 		{"invalid DiagramConfidence - too low", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: -0.1, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "DIAGRAM_CONFIDENCE must be between 0.0 and 1.0"},
 		{"invalid DiagramConfidence - too high", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 1.1, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "DIAGRAM_CONFIDENCE must be between 0.0 and 1.0"},
 		{"invalid BaseHeaderLevel - too low", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 0, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "BASE_HEADER_LEVEL must be between 1 and 6"},
+		{"invalid OutputFormat", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, OutputFormat: "pdf", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "OUTPUT_FORMAT must be one of"},
+		{"invalid MarkdownFlavor", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, MarkdownFlavor: "mdx", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MARKDOWN_FLAVOR must be one of"},
+		{"invalid MaxHeaderDepth", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, MaxHeaderDepth: 7, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MAX_HEADER_DEPTH must be between 2 and 6"},
+		{"invalid HeaderOverflow", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, HeaderOverflow: "drop", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "HEADER_OVERFLOW must be one of"},
+		{"invalid ErrataLinks", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, ErrataLinks: "merge", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "ERRATA_LINKS must be one of"},
 		{"invalid BaseHeaderLevel - too high", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 7, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "BASE_HEADER_LEVEL must be between 1 and 6"},
+		{"invalid UpdateCheckURL", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, UpdateCheckURL: "ftp://example.com/feed", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "UPDATE_CHECK_URL must be an http or https URL"},
+		{"invalid Locale", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, Locale: "fr", LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "LOCALE must be one of"},
 		{"invalid LogLevel", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "invalid", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "LOG_LEVEL must be one of"},
 		{"invalid Transport", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "tcp", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MCP_TRANSPORT must be one of"},
+		{"valid multiple Transports", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio, http", HTTPAddr: ":8080", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, false, ""},
+		{"invalid Transport in list", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio,tcp", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MCP_TRANSPORT must be one of"},
+		{"repeated Transport", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio,stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MCP_TRANSPORT lists stdio more than once"},
+		{"invalid HTTPAddr with multiple Transports", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio,http", HTTPAddr: "localhost", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "MCP_HTTP_ADDR must be a host:port address"},
 		{"invalid PlantUMLStyle", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "invalid", PlantUMLColorScheme: "auto"}, true, "PLANTUML_STYLE must be one of"},
 		{"invalid PlantUMLColorScheme", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "invalid"}, true, "PLANTUML_COLOR_SCHEME must be one of"},
+		{"invalid DiagramMinSize", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, DiagramMinSize: -1, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "DIAGRAM_MIN_SIZE must not be negative"},
+		{"invalid DiagramMinEdgeDensity", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, DiagramMinEdgeDensity: 1.5, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}, true, "DIAGRAM_MIN_EDGE_DENSITY must be between 0.0 and 1.0"},
+		{"invalid DiagramTypes", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto", DiagramTypes: []string{"flowchart", "schematic"}}, true, "DETECT_DIAGRAM_TYPES entries must be one of"},
+		{"missing PlantUMLTemplateDir", Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto", PlantUMLTemplateDir: "/nonexistent/templates"}, true, "PLANTUML_TEMPLATE_DIR must be an existing directory"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -195,3 +588,84 @@ func TestConfigValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestTransportList(t *testing.T) {
+	cfg := Config{Transport: " STDIO, http ,"}
+	if got := cfg.TransportList(); !reflect.DeepEqual(got, []string{"stdio", "http"}) {
+		t.Errorf("TransportList() = %v, want [stdio http]", got)
+	}
+	if !cfg.HasTransport("http") || !cfg.HasTransport("stdio") {
+		t.Error("expected both transports reported")
+	}
+	if cfg := (Config{Transport: "stdio"}); cfg.HasTransport("http") {
+		t.Error("expected http not reported for stdio alone")
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	for _, name := range Presets {
+		cfg := Config{ImageMaxDPI: 300, ImageFormat: "png", DiagramConfidence: 0.7, BaseHeaderLevel: 1, LogLevel: "info", Transport: "stdio", PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}
+		if err := cfg.ApplyPreset(name, nil); err != nil {
+			t.Fatalf("ApplyPreset(%s) error = %v", name, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("preset %s yields an invalid configuration: %v", name, err)
+		}
+	}
+
+	cfg := Config{ImageMaxDPI: 300}
+	if err := cfg.ApplyPreset("archival", func(env string) bool { return env == "IMAGE_MAX_DPI" }); err != nil || cfg.ImageMaxDPI != 300 || !cfg.AccessibleOutput {
+		t.Errorf("expected kept settings left alone, got %v %d %t", err, cfg.ImageMaxDPI, cfg.AccessibleOutput)
+	}
+	if err := cfg.ApplyPreset("quick", nil); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	example := ConfigExample()
+	seen := map[string]bool{}
+	for _, spec := range Keys {
+		if seen[spec.Key] {
+			t.Errorf("duplicate key %s", spec.Key)
+		}
+		seen[spec.Key] = true
+		if !strings.Contains(example, "\n"+spec.Key+"="+spec.Default+"\n") {
+			t.Errorf("expected %s with its default in the example configuration", spec.Key)
+		}
+		if spec.Default != "" {
+			if err := spec.Check(spec.Default); err != nil {
+				t.Errorf("default of %s is invalid: %v", spec.Key, err)
+			}
+		}
+	}
+
+	spec, ok := LookupKey("THUMBNAIL_WIDTH")
+	if !ok || spec.Check("0") != nil || spec.Check("256") != nil {
+		t.Errorf("expected 0 and 256 valid for THUMBNAIL_WIDTH")
+	}
+	if err := spec.Check("8"); err == nil || err.Error() != "THUMBNAIL_WIDTH must be 0 or an integer between 16 and 2048" {
+		t.Errorf("unexpected error for THUMBNAIL_WIDTH 8: %v", err)
+	}
+	if spec, _ := LookupKey("INCLUDE_TOC"); spec.Check("yes") != nil || spec.Check("maybe") == nil {
+		t.Errorf("expected yes valid and maybe invalid for INCLUDE_TOC")
+	}
+	if spec, _ := LookupKey("HEADER_REGEXES"); spec.Check("(") == nil || !strings.Contains(spec.Check("(").Error(), "invalid regular expression") {
+		t.Errorf("expected the regular expression error for HEADER_REGEXES")
+	}
+	if _, ok := LookupKey("NOT_A_KEY"); ok {
+		t.Errorf("expected no registry entry for NOT_A_KEY")
+	}
+
+	docs := KeyDocs()
+	for _, want := range []string{
+		"## Markdown Generation Settings",
+		"| `IMAGE_MAX_DPI` | Maximum image resolution (72-600) | `300` | an integer between 72 and 600 |  |",
+		"| `OUTPUT_FORMAT` |",
+		"one of: markdown, asciidoc, html, json | `output_format` |",
+	} {
+		if !strings.Contains(docs, want) {
+			t.Errorf("expected %q in the option reference", want)
+		}
+	}
+}
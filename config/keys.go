@@ -0,0 +1,324 @@
+// Package config - Key registry.
+// This file lists every configuration key with its section, description, default, valid
+// values and the tool arguments that override it for one call. The example configuration,
+// the config CLI's key list and value checks and the generated option reference are all
+// derived from this registry, so the documentation cannot drift from the validation.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// KeySpec describes a configuration key.
+type KeySpec struct {
+	Key         string
+	Section     string // Section of the example configuration and the option reference
+	Description string
+	Default     string
+	ToolArgs    []string // Tool arguments that override the setting for one call
+	rule        *valueRule
+}
+
+// valueRule is the set of valid values of a key: a phrase describing them, used in the
+// option reference and in error messages, and the check.
+type valueRule struct {
+	valid string
+	check func(value string) error
+}
+
+// errInvalidValue is returned by checks whose error message is the valid values phrase.
+var errInvalidValue = errors.New("invalid value")
+
+// Keys is the registry of configuration keys, in the order of the example configuration.
+var Keys = []KeySpec{
+	{Key: "PDF_INPUT_DIR", Section: "PDF Input/Output Settings", Description: "Directory containing PDF files to process", Default: "", ToolArgs: []string{"input_dir"}},
+	{Key: "FOLLOW_SYMLINKS", Section: "PDF Input/Output Settings", Description: "Follow symbolic links when searching for PDF files", Default: "false", rule: boolean},
+	{Key: "INCLUDE_HIDDEN_DIRS", Section: "PDF Input/Output Settings", Description: "Search hidden directories for PDF files", Default: "false", rule: boolean},
+	{Key: "MAX_DISCOVERED_FILES", Section: "PDF Input/Output Settings", Description: "Maximum number of PDF files discovered in a directory (0 = unlimited)", Default: "10000", rule: nonNegativeInt},
+	{Key: "OUTPUT_BASE_DIR", Section: "PDF Input/Output Settings", Description: "Base output directory", Default: "./output", ToolArgs: []string{"output_dir"}},
+	{Key: "DISK_SPACE_CHECK", Section: "PDF Input/Output Settings", Description: "Verify free space in the output location before converting", Default: "true", rule: boolean},
+	{Key: "MAX_OUTPUT_AGE_DAYS", Section: "PDF Input/Output Settings", Description: "Remove conversion outputs older than this many days (0 = keep forever)", Default: "0", rule: nonNegativeInt},
+	{Key: "MAX_OUTPUT_TOTAL_GB", Section: "PDF Input/Output Settings", Description: "Remove the oldest conversion outputs beyond this total size in GB (0 = unlimited)", Default: "0", rule: nonNegativeNumber},
+	{Key: "ESTIMATE_SAMPLE_PAGES", Section: "PDF Input/Output Settings", Description: "Pages a dry run converts to estimate conversion time and output size", Default: "5", rule: nonNegativeInt, ToolArgs: []string{"dry_run"}},
+	{Key: "TMP_DIR", Section: "PDF Input/Output Settings", Description: "Directory for intermediate files, cleaned up after each conversion (empty = system temporary directory)", Default: ""},
+	{Key: "INCREMENTAL_CONVERSION", Section: "PDF Input/Output Settings", Description: "Re-extract only the pages that changed since the previous output of a document", Default: "false", rule: boolean},
+	{Key: "CHANGE_REPORT", Section: "PDF Input/Output Settings", Description: "Write CHANGES.md listing changed sections and spec values when a document is converted again", Default: "false", rule: boolean},
+	{Key: "BATCH_SUMMARY", Section: "PDF Input/Output Settings", Description: "Write BATCH_SUMMARY.md to the output directory of a batch conversion, listing every document with links, statistics, quality scores and errors", Default: "false", rule: boolean},
+	{Key: "BATCH_RESULTS", Section: "PDF Input/Output Settings", Description: "Write results.json to the output directory of a batch conversion, with the batch counts, the conversion report of every document and the errors, matching results.schema.json", Default: "false", rule: boolean},
+	{Key: "STRICT_MODE", Section: "PDF Input/Output Settings", Description: "Fail the conversion when any page, image or table cannot be converted, instead of reporting warnings", Default: "false", rule: boolean},
+	{Key: "MCP_SERVER_NAME", Section: "Server Settings", Description: "Server identification name", Default: "pdf-to-markdown-server"},
+	{Key: "MCP_SERVER_VERSION", Section: "Server Settings", Description: "Server version", Default: "1.0.0"},
+	{Key: "UPDATE_CHECK", Section: "Server Settings", Description: "Check the project's release feed for a newer version at startup (opt-in)", Default: "false", rule: boolean},
+	{Key: "UPDATE_CHECK_URL", Section: "Server Settings", Description: "Release feed queried by the update check", Default: DefaultUpdateCheckURL, rule: httpURL},
+	{Key: "LOCALE", Section: "Server Settings", Description: "Language of tool descriptions and result summaries (en/ja/zh)", Default: "en", rule: oneOf(Locales...)},
+	{Key: "RESTRICTED_MODE", Section: "Server Settings", Description: "Expose only single file conversion inside PDF_INPUT_DIR, for untrusted clients", Default: "false", rule: boolean},
+	{Key: "CONVERSION_PRESET", Section: "Image Processing Settings", Description: "Named bundle of conversion settings (fast/archival/rag-optimized/print-fidelity); variables set explicitly take precedence", Default: "", rule: optional("", oneOf(Presets...)), ToolArgs: []string{"preset"}},
+	{Key: "IMAGE_MAX_DPI", Section: "Image Processing Settings", Description: "Maximum image resolution (72-600)", Default: "300", rule: intRange(72, 600)},
+	{Key: "IMAGE_FORMAT", Section: "Image Processing Settings", Description: "Image output format (png/jpg)", Default: "png", rule: oneOf("png", "jpg")},
+	{Key: "PRESERVE_ASPECT_RATIO", Section: "Image Processing Settings", Description: "Maintain image aspect ratios", Default: "true", rule: boolean},
+	{Key: "IMAGE_PLACEMENT", Section: "Image Processing Settings", Description: "Image placement in page text (end/inline)", Default: "end", rule: oneOf("end", "inline")},
+	{Key: "OCR_LANGUAGE", Section: "Image Processing Settings", Description: "Tesseract language(s) for page scan OCR, e.g. eng+deu", Default: "eng", rule: ocrLanguages},
+	{Key: "TEXT_MIN_CONFIDENCE", Section: "Image Processing Settings", Description: "Pages whose text layer looks garbled below this score are OCRed or flagged as unreliable (0.0-1.0, 0 = off)", Default: "0.5", rule: fraction},
+	{Key: "IMAGE_ALT_TEXT", Section: "Image Processing Settings", Description: "Image alt text source: off, ocr (text in the figure) or caption (model caption via MCP sampling, falling back to ocr)", Default: "off", rule: oneOf("off", "ocr", "caption")},
+	{Key: "RENDERER", Section: "Image Processing Settings", Description: "Program that rasterizes pages for table images, OCR and thumbnails: auto (first found), pdftoppm, ghostscript or pdfium", Default: "auto", rule: oneOf(append([]string{"auto"}, Renderers...)...)},
+	{Key: "THUMBNAIL_WIDTH", Section: "Image Processing Settings", Description: "Write thumbnail.png of the first page this many pixels wide (16-2048, 0 = off)", Default: "0", rule: optional("0", intRange(16, 2048))},
+	{Key: "DETECT_DIAGRAMS", Section: "Diagram Detection and PlantUML Settings", Description: "Enable diagram detection and PlantUML generation", Default: "false", rule: boolean},
+	{Key: "DETECT_DIAGRAM_TYPES", Section: "Diagram Detection and PlantUML Settings", Description: "Diagram types written to the Markdown, comma-separated (flowchart/block/circuit/network/plot/unknown); other detected types are only listed in images.json (empty = all)", Default: "", rule: listOf(",", DiagramTypes...)},
+	{Key: "DIAGRAM_CONFIDENCE", Section: "Diagram Detection and PlantUML Settings", Description: "Minimum confidence for diagram detection (0.0-1.0)", Default: "0.7", rule: fraction},
+	{Key: "DIAGRAM_MIN_SIZE", Section: "Diagram Detection and PlantUML Settings", Description: "Minimum width and height in pixels of images analyzed for diagrams; smaller images such as logos are skipped (0 = all)", Default: "100", rule: nonNegativeInt},
+	{Key: "DIAGRAM_MIN_EDGE_DENSITY", Section: "Diagram Detection and PlantUML Settings", Description: "Minimum fraction of edge pixels of images analyzed for diagrams; flat images are skipped (0.0-1.0, 0 = all)", Default: "0.01", rule: fraction},
+	{Key: "CURVE_DATA", Section: "Diagram Detection and PlantUML Settings", Description: "Extract the data points of characteristic curve graphs (derating, thermal and other curves) to CSV files with a cropped graph image and curves.json", Default: "false", rule: boolean},
+	{Key: "PLANTUML_STYLE", Section: "Diagram Detection and PlantUML Settings", Description: "PlantUML diagram style (default/blueprint/modern)", Default: "default", rule: oneOf("default", "blueprint", "modern")},
+	{Key: "PLANTUML_COLOR_SCHEME", Section: "Diagram Detection and PlantUML Settings", Description: "PlantUML color scheme (mono/color/auto)", Default: "auto", rule: oneOf("mono", "color", "auto")},
+	{Key: "PLANTUML_TEMPLATE_DIR", Section: "Diagram Detection and PlantUML Settings", Description: "Directory of PlantUML templates named after the diagram type (flowchart.puml, block.puml, circuit.puml, network.puml, generic.puml) used instead of the built-in snippets (empty = built-in)", Default: ""},
+	{Key: "OUTPUT_FORMAT", Section: "Markdown Generation Settings", Description: "Document format written: markdown (README.md), asciidoc (README.adoc), html (README.html) or json (README.json)", Default: "markdown", rule: oneOf(OutputFormats...), ToolArgs: []string{"output_format"}},
+	{Key: "MARKDOWN_FLAVOR", Section: "Markdown Generation Settings", Description: "Markdown flavor: gfm (pipe tables) or commonmark (tables as HTML)", Default: "gfm", rule: oneOf(MarkdownFlavors...), ToolArgs: []string{"markdown_flavor"}},
+	{Key: "INCLUDE_TOC", Section: "Markdown Generation Settings", Description: "Generate table of contents", Default: "true", rule: boolean},
+	{Key: "BASE_HEADER_LEVEL", Section: "Markdown Generation Settings", Description: "Starting header level (1-6)", Default: "1", rule: intRange(1, 6)},
+	{Key: "MAX_HEADER_DEPTH", Section: "Markdown Generation Settings", Description: "Deepest heading level written (2-6); deeper headings are handled per HEADER_OVERFLOW", Default: "6", rule: intRange(2, 6)},
+	{Key: "HEADER_OVERFLOW", Section: "Markdown Generation Settings", Description: "Headings deeper than MAX_HEADER_DEPTH: clamp (written at MAX_HEADER_DEPTH) or bold (written as bold paragraphs)", Default: "clamp", rule: oneOf("clamp", "bold")},
+	{Key: "EXTRACT_TABLES", Section: "Markdown Generation Settings", Description: "Enable table extraction", Default: "true", rule: boolean},
+	{Key: "TABLE_MIN_CONFIDENCE", Section: "Markdown Generation Settings", Description: "Minimum table reconstruction confidence; lower-confidence tables are embedded as images (0.0-1.0)", Default: "0.5", rule: fraction},
+	{Key: "NORMALIZE_SPEC_TABLES", Section: "Markdown Generation Settings", Description: "Normalize minus signs, number spacing and units in min/typ/max tables", Default: "true", rule: boolean},
+	{Key: "BOLD_TYP_VALUES", Section: "Markdown Generation Settings", Description: "Bold the typical values in min/typ/max tables", Default: "false", rule: boolean},
+	{Key: "VARIANT_TABLES", Section: "Markdown Generation Settings", Description: "Join ordering information tables across pages into a part variant comparison table and variants.json", Default: "false", rule: boolean},
+	{Key: "PACKAGE_DIMENSIONS", Section: "Markdown Generation Settings", Description: "Export the dimensions of package drawings and mechanical dimension tables (body size, pitch, pad recommendations) to package.json", Default: "false", rule: boolean},
+	{Key: "APPLICATION_BOM", Section: "Markdown Generation Settings", Description: "Collect the component designators and values of typical application circuits into a bill of materials table and bom.csv", Default: "false", rule: boolean},
+	{Key: "COMPLIANCE_TAGS", Section: "Markdown Generation Settings", Description: "Tag the document with the compliance and qualification standards it states (RoHS, REACH, AEC-Q100, UL, ...) in front matter and compliance.json", Default: "false", rule: boolean},
+	{Key: "ERRATA_LINKS", Section: "Markdown Generation Settings", Description: "Errata documents converted in the same batch as their datasheet: off, link (cross-link the two documents) or inject (also add errata notes to the affected sections of the datasheet)", Default: "off", rule: oneOf("off", "link", "inject")},
+	{Key: "MONOSPACE_CODE", Section: "Markdown Generation Settings", Description: "Write text set in monospace fonts such as Courier as code spans, and consecutive lines of it as code blocks", Default: "true", rule: boolean},
+	{Key: "PRESERVE_EMPHASIS", Section: "Markdown Generation Settings", Description: "Write text set in bold or italic fonts within a line, such as parameter names, as bold or italic", Default: "true", rule: boolean},
+	{Key: "DETECT_CALLOUTS", Section: "Markdown Generation Settings", Description: "Write warning, caution and note boxes, found by their colored background or icon, as GFM alerts (> [!WARNING])", Default: "true", rule: boolean},
+	{Key: "JOIN_PAGE_BREAKS", Section: "Markdown Generation Settings", Description: "Remove running page headers and footers and join sentences split across page breaks", Default: "true", rule: boolean},
+	{Key: "NUMBER_LOCALE", Section: "Markdown Generation Settings", Description: "Normalize numbers and dates written in this locale (e.g. de: 1.234,5 -> 1234.5, 15.03.2024 -> 2024-03-15), or off", Default: "off", rule: oneOf(append([]string{"off"}, NumberLocales...)...)},
+	{Key: "CONTENT_LANGUAGE_FILTER", Section: "Markdown Generation Settings", Description: "In multilingual documents, keep only the text of this language (e.g. en or zh), or off to keep all languages tagged by language", Default: "off", rule: languageFilter},
+	{Key: "EXTRACT_IMAGES", Section: "Markdown Generation Settings", Description: "Enable image extraction", Default: "true", rule: boolean},
+	{Key: "ACCESSIBLE_OUTPUT", Section: "Markdown Generation Settings", Description: "Enforce alt text on every image, heading levels without skips, table header cells and a language declaration in front matter", Default: "false", rule: boolean},
+	{Key: "MARKDOWN_LINT", Section: "Markdown Generation Settings", Description: "Normalize the generated Markdown with markdownlint-style rules so it passes doc CI checks", Default: "false", rule: boolean},
+	{Key: "MARKDOWN_LINT_RULES", Section: "Markdown Generation Settings", Description: "Lint rules to apply, comma-separated (MD009/MD012/MD013/MD019/MD022/MD031/MD040/MD047)", Default: "MD009,MD012,MD019,MD022,MD031,MD040,MD047", rule: listOf(",", MarkdownLintRules...)},
+	{Key: "MARKDOWN_LINE_LENGTH", Section: "Markdown Generation Settings", Description: "Line length MD013 wraps paragraphs at", Default: "80", rule: nonNegativeInt},
+	{Key: "HEADER_KEYWORD_LOCALES", Section: "Header Detection Settings", Description: "Built-in header keyword sets, comma-separated (en/de/fr/ja/zh)", Default: "en", rule: listOf(",", "en", "de", "fr", "ja", "zh")},
+	{Key: "HEADER_KEYWORDS", Section: "Header Detection Settings", Description: "Additional header keywords, comma-separated", Default: ""},
+	{Key: "HEADER_REGEXES", Section: "Header Detection Settings", Description: "Header regular expressions, semicolon-separated", Default: "", rule: regexList},
+	{Key: "HEADING_NORMALIZE", Section: "Header Detection Settings", Description: "Title-case ALL-CAPS headings and strip trailing colons and duplicate numbering", Default: "false", rule: boolean},
+	{Key: "SECTION_NUMBERING", Section: "Header Detection Settings", Description: "Section numbers: preserve (anchor numbered headings), renumber (also number unnumbered headings) or off", Default: "preserve", rule: oneOf("preserve", "renumber", "off")},
+	{Key: "CROSS_REFERENCE_LINKS", Section: "Header Detection Settings", Description: "Link \"see Figure 12\", \"Table 5\" and \"Section 4.2\" references to their anchors", Default: "true", rule: boolean},
+	{Key: "LOG_LEVEL", Section: "Logging and Transport Settings", Description: "Logging verbosity (debug/info/warn/error)", Default: "info", rule: oneOf("debug", "info", "warn", "error")},
+	{Key: "MCP_TRANSPORT", Section: "Logging and Transport Settings", Description: "Transport method for MCP communication: stdio (spawned by the client) or http (JSON-RPC over POST with Server-Sent Events); stdio,http serves both from one process", Default: "stdio", rule: listOf(",", Transports...)},
+	{Key: "MCP_HTTP_ADDR", Section: "Logging and Transport Settings", Description: "Address the http transport listens on", Default: DefaultHTTPAddr, rule: listenAddress},
+	{Key: "MCP_ALLOWED_HOSTS", Section: "Logging and Transport Settings", Description: "Comma-separated host names the http transport answers to besides localhost and IP addresses, e.g. when it is reached through a proxy; requests for other hosts are rejected to prevent DNS rebinding", Default: ""},
+	{Key: "MCP_TRACE", Section: "Logging and Transport Settings", Description: "Write every JSON-RPC message received and sent to MCP_TRACE_FILE, with secrets redacted and long values truncated", Default: "false", rule: boolean},
+	{Key: "MCP_TRACE_FILE", Section: "Logging and Transport Settings", Description: "File MCP_TRACE appends messages to", Default: "mcp_trace.log"},
+	{Key: "MAX_MESSAGE_SIZE_MB", Section: "Logging and Transport Settings", Description: "Largest client message accepted in MB, e.g. tool calls carrying base64 PDFs (0 = unlimited)", Default: "64", rule: nonNegativeInt},
+	{Key: "MAX_CONCURRENT_TOOL_CALLS", Section: "Logging and Transport Settings", Description: "Tool calls each session runs at the same time (1-64); other requests are answered while they run", Default: "4", rule: intRange(1, 64)},
+	{Key: "CONVERSION_TIMEOUT", Section: "Logging and Transport Settings", Description: "Seconds a tool call may run before it is stopped with a timeout error and its partial output removed (0 = no limit)", Default: "0", rule: nonNegativeInt},
+	{Key: "MAX_QUEUED_JOBS", Section: "Logging and Transport Settings", Description: "Asynchronous jobs that may wait in the job queue; further submissions fail with a queue_full error until a job starts (0 = unlimited)", Default: "20", rule: nonNegativeInt},
+}
+
+// Valid returns the phrase describing the valid values of the key, "" when any value is
+// accepted.
+func (k KeySpec) Valid() string {
+	if k.rule == nil {
+		return ""
+	}
+	return k.rule.valid
+}
+
+// Check reports whether value is valid for the key.
+func (k KeySpec) Check(value string) error {
+	if k.rule == nil {
+		return nil
+	}
+	if err := k.rule.check(value); err != nil {
+		if err == errInvalidValue {
+			return fmt.Errorf("%s must be %s", k.Key, k.rule.valid)
+		}
+		return fmt.Errorf("%s %v", k.Key, err)
+	}
+	return nil
+}
+
+// LookupKey returns the registry entry of a configuration key.
+func LookupKey(key string) (KeySpec, bool) {
+	for _, spec := range Keys {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	return KeySpec{}, false
+}
+
+// KeySections returns the section titles of the registry in order.
+func KeySections() []string {
+	var sections []string
+	for _, spec := range Keys {
+		if len(sections) == 0 || sections[len(sections)-1] != spec.Section {
+			sections = append(sections, spec.Section)
+		}
+	}
+	return sections
+}
+
+// KeyDocs renders the registry as a Markdown option reference: one table per section
+// listing each key's description, default, valid values and overriding tool arguments.
+func KeyDocs() string {
+	var out strings.Builder
+	out.WriteString("# Configuration Reference\n\n")
+	out.WriteString("Generated by `pdf-md-mcp config docs` from the key registry that validates `config set`.\n")
+	for _, section := range KeySections() {
+		fmt.Fprintf(&out, "\n## %s\n\n", section)
+		out.WriteString("| Key | Description | Default | Valid values | Tool arguments |\n")
+		out.WriteString("|-----|-------------|---------|--------------|----------------|\n")
+		for _, spec := range Keys {
+			if spec.Section != section {
+				continue
+			}
+			fmt.Fprintf(&out, "| `%s` | %s | %s | %s | %s |\n", spec.Key, docCell(spec.Description), codeCell(spec.Default), docCell(spec.Valid()), codeCell(strings.Join(spec.ToolArgs, ", ")))
+		}
+	}
+	return out.String()
+}
+
+// docCell escapes the pipes of a Markdown table cell.
+func docCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// codeCell writes a non-empty table cell as a code span.
+func codeCell(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + docCell(text) + "`"
+}
+
+// Value rules
+
+// boolean accepts the values getEnvBoolWithDefault understands.
+var boolean = &valueRule{valid: "true or false", check: func(value string) error {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes", "on", "false", "0", "no", "off":
+		return nil
+	}
+	return errInvalidValue
+}}
+
+// nonNegativeInt accepts integers from 0.
+var nonNegativeInt = &valueRule{valid: "a non-negative integer", check: func(value string) error {
+	if v, err := strconv.Atoi(value); err != nil || v < 0 {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// nonNegativeNumber accepts numbers from 0.
+var nonNegativeNumber = &valueRule{valid: "a non-negative number", check: func(value string) error {
+	if f, err := strconv.ParseFloat(value, 64); err != nil || f < 0 {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// fraction accepts numbers between 0.0 and 1.0.
+var fraction = &valueRule{valid: "a number between 0.0 and 1.0", check: func(value string) error {
+	if f, err := strconv.ParseFloat(value, 64); err != nil || f < 0 || f > 1 {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// ocrLanguages accepts Tesseract language codes joined by '+'.
+var ocrLanguages = &valueRule{valid: "Tesseract language codes joined by '+', e.g. eng+deu", check: func(value string) error {
+	if !regexp.MustCompile(`^[A-Za-z_]+(\+[A-Za-z_]+)*$`).MatchString(value) {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// languageFilter accepts off or a language code.
+var languageFilter = &valueRule{valid: "off or a language code such as en or zh", check: func(value string) error {
+	if value = strings.ToLower(value); value != "off" && !IsLanguageCode(value) {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// regexList accepts regular expressions separated by semicolons.
+var regexList = &valueRule{valid: "regular expressions separated by ';'", check: func(value string) error {
+	for _, expr := range strings.Split(value, ";") {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("contains an invalid regular expression %q: %v", expr, err)
+		}
+	}
+	return nil
+}}
+
+// httpURL accepts http and https URLs.
+var httpURL = &valueRule{valid: "an http or https URL", check: func(value string) error {
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// listenAddress accepts host:port addresses.
+var listenAddress = &valueRule{valid: "a host:port address such as 127.0.0.1:8080 or :8080", check: func(value string) error {
+	if !IsListenAddress(value) {
+		return errInvalidValue
+	}
+	return nil
+}}
+
+// oneOf accepts one of values, ignoring case.
+func oneOf(values ...string) *valueRule {
+	return &valueRule{valid: "one of: " + strings.Join(values, ", "), check: func(value string) error {
+		if !contains(values, strings.ToLower(value)) {
+			return errInvalidValue
+		}
+		return nil
+	}}
+}
+
+// listOf accepts values separated by sep, each one of values.
+func listOf(sep string, values ...string) *valueRule {
+	return &valueRule{valid: fmt.Sprintf("entries separated by '%s', each one of: %s", sep, strings.Join(values, ", ")), check: func(value string) error {
+		for _, entry := range strings.Split(value, sep) {
+			if entry = strings.TrimSpace(entry); entry != "" && !contains(values, entry) && !contains(values, strings.ToLower(entry)) {
+				return errInvalidValue
+			}
+		}
+		return nil
+	}}
+}
+
+// intRange accepts integers between lo and hi.
+func intRange(lo, hi int) *valueRule {
+	return &valueRule{valid: fmt.Sprintf("an integer between %d and %d", lo, hi), check: func(value string) error {
+		if v, err := strconv.Atoi(value); err != nil || v < lo || v > hi {
+			return errInvalidValue
+		}
+		return nil
+	}}
+}
+
+// optional accepts the value off besides the values of rule.
+func optional(off string, rule *valueRule) *valueRule {
+	valid := off + " or " + rule.valid
+	if off == "" {
+		off, valid = "", "empty or "+rule.valid
+	}
+	return &valueRule{valid: valid, check: func(value string) error {
+		if value == off {
+			return nil
+		}
+		return rule.check(value)
+	}}
+}
@@ -0,0 +1,102 @@
+// Package config - Conversion presets.
+// This file defines named bundles of OCR, image, diagram and output settings, selected with
+// CONVERSION_PRESET or per tool call, so casual users can pick a purpose instead of tuning
+// the individual settings.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Presets are the accepted CONVERSION_PRESET values besides "" (no preset).
+var Presets = []string{"fast", "archival", "rag-optimized", "print-fidelity"}
+
+// presetSetting is one setting of a preset.
+type presetSetting struct {
+	env   string        // Variable the setting replaces
+	apply func(*Config) // Sets the value
+}
+
+// presets maps preset names to their settings.
+var presets = map[string][]presetSetting{
+	// Quickest conversion: small images, no OCR, diagram detection, alt text or lint pass
+	"fast": {
+		{"IMAGE_MAX_DPI", func(c *Config) { c.ImageMaxDPI = 150 }},
+		{"IMAGE_FORMAT", func(c *Config) { c.ImageFormat = "jpg" }},
+		{"TEXT_MIN_CONFIDENCE", func(c *Config) { c.TextMinConfidence = 0 }},
+		{"IMAGE_ALT_TEXT", func(c *Config) { c.ImageAltText = "off" }},
+		{"DETECT_DIAGRAMS", func(c *Config) { c.DetectDiagrams = false }},
+		{"INCLUDE_TOC", func(c *Config) { c.IncludeTOC = false }},
+		{"MARKDOWN_LINT", func(c *Config) { c.MarkdownLint = false }},
+	},
+	// Complete, self-describing copy for long-term storage
+	"archival": {
+		{"IMAGE_MAX_DPI", func(c *Config) { c.ImageMaxDPI = 600 }},
+		{"IMAGE_FORMAT", func(c *Config) { c.ImageFormat = "png" }},
+		{"PRESERVE_ASPECT_RATIO", func(c *Config) { c.PreserveAspectRatio = true }},
+		{"EXTRACT_IMAGES", func(c *Config) { c.ExtractImages = true }},
+		{"TEXT_MIN_CONFIDENCE", func(c *Config) { c.TextMinConfidence = 0.6 }},
+		{"IMAGE_ALT_TEXT", func(c *Config) { c.ImageAltText = "ocr" }},
+		{"DETECT_DIAGRAMS", func(c *Config) { c.DetectDiagrams = true }},
+		{"INCLUDE_TOC", func(c *Config) { c.IncludeTOC = true }},
+		{"ACCESSIBLE_OUTPUT", func(c *Config) { c.AccessibleOutput = true }},
+		{"VARIANT_TABLES", func(c *Config) { c.VariantTables = true }},
+	},
+	// Clean, self-contained text chunks for retrieval: normalized headings and tables,
+	// diagrams as PlantUML text and alt text for every image
+	"rag-optimized": {
+		{"IMAGE_MAX_DPI", func(c *Config) { c.ImageMaxDPI = 150 }},
+		{"TEXT_MIN_CONFIDENCE", func(c *Config) { c.TextMinConfidence = 0.5 }},
+		{"IMAGE_ALT_TEXT", func(c *Config) { c.ImageAltText = "ocr" }},
+		{"DETECT_DIAGRAMS", func(c *Config) { c.DetectDiagrams = true }},
+		{"INCLUDE_TOC", func(c *Config) { c.IncludeTOC = false }},
+		{"HEADING_NORMALIZE", func(c *Config) { c.HeadingNormalize = true }},
+		{"NORMALIZE_SPEC_TABLES", func(c *Config) { c.NormalizeSpecTables = true }},
+		{"BOLD_TYP_VALUES", func(c *Config) { c.BoldTypValues = false }},
+		{"CROSS_REFERENCE_LINKS", func(c *Config) { c.CrossReferenceLinks = true }},
+		{"VARIANT_TABLES", func(c *Config) { c.VariantTables = true }},
+		{"MARKDOWN_LINT", func(c *Config) { c.MarkdownLint = true }},
+		{"OUTPUT_FORMAT", func(c *Config) { c.OutputFormat = "markdown" }},
+	},
+	// Output that looks like the printed page: high resolution images in place and tables
+	// that cannot be rebuilt reliably kept as page images
+	"print-fidelity": {
+		{"IMAGE_MAX_DPI", func(c *Config) { c.ImageMaxDPI = 600 }},
+		{"IMAGE_FORMAT", func(c *Config) { c.ImageFormat = "png" }},
+		{"PRESERVE_ASPECT_RATIO", func(c *Config) { c.PreserveAspectRatio = true }},
+		{"IMAGE_PLACEMENT", func(c *Config) { c.ImagePlacement = "inline" }},
+		{"TABLE_MIN_CONFIDENCE", func(c *Config) { c.TableMinConfidence = 0.8 }},
+		{"NORMALIZE_SPEC_TABLES", func(c *Config) { c.NormalizeSpecTables = false }},
+		{"HEADING_NORMALIZE", func(c *Config) { c.HeadingNormalize = false }},
+		{"DETECT_DIAGRAMS", func(c *Config) { c.DetectDiagrams = false }},
+		{"INCLUDE_TOC", func(c *Config) { c.IncludeTOC = true }},
+		{"MARKDOWN_LINT", func(c *Config) { c.MarkdownLint = false }},
+	},
+}
+
+// ApplyPreset sets the settings of a preset. Settings whose variable keep reports true are
+// left alone, so variables set explicitly take precedence over CONVERSION_PRESET; a nil
+// keep applies every setting. An empty name applies nothing.
+func (c *Config) ApplyPreset(name string, keep func(env string) bool) error {
+	if name == "" {
+		return nil
+	}
+	settings, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("unknown conversion preset %q (available: %s)", name, strings.Join(Presets, ", "))
+	}
+	for _, setting := range settings {
+		if keep == nil || !keep(setting.env) {
+			setting.apply(c)
+		}
+	}
+	return nil
+}
+
+// isSet reports whether an environment variable is set.
+func isSet(env string) bool {
+	_, ok := os.LookupEnv(env)
+	return ok
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +17,17 @@ import (
 type Logger struct {
 	level  LogLevel    // Current minimum log level to output
 	logger *log.Logger // Underlying Go standard library logger
+
+	mu    sync.RWMutex
+	sinks []Sink // Additional receivers of every message, whatever the level
+}
+
+// Sink receives log messages in addition to the standard error output, such as an MCP
+// client that has log messages streamed to it. A sink gets messages of every level and
+// filters them itself, since its threshold can be lower than the logger's. Log may be
+// called from several goroutines at once and must not log itself.
+type Sink interface {
+	Log(level LogLevel, message string)
 }
 
 // LogLevel represents the severity level of log messages.
@@ -116,22 +128,54 @@ func parseLogLevel(levelStr string) LogLevel {
 //   - format: Printf-style format string for the message
 //   - args: Arguments to substitute into the format string
 func (l *Logger) log(level LogLevel, format string, args ...interface{}) {
+	l.mu.RLock()
+	sinks := l.sinks
+	l.mu.RUnlock()
+
 	// Only log if the message level is at or above our configured minimum level
+	if level < l.level && len(sinks) == 0 {
+		return
+	}
+
+	// Create the full log message with timestamp, level, and formatted content
+	message := fmt.Sprintf(format, args...)
+	for _, sink := range sinks {
+		sink.Log(level, message)
+	}
 	if level < l.level {
 		return
 	}
 
 	// Format the timestamp in ISO 8601 format for consistency
 	timestamp := time.Now().Format("2006-01-02T15:04:05.000Z07:00")
-
-	// Create the full log message with timestamp, level, and formatted content
-	message := fmt.Sprintf(format, args...)
 	fullMessage := fmt.Sprintf("%s [%s] %s", timestamp, level.String(), message)
 
 	// Output the formatted message
 	l.logger.Println(fullMessage)
 }
 
+// AddSink adds a receiver of all subsequent log messages.
+func (l *Logger) AddSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Copy on write, so log can use the slice without holding the lock
+	l.sinks = append(l.sinks[:len(l.sinks):len(l.sinks)], sink)
+}
+
+// RemoveSink removes a receiver added with AddSink. Removing a sink that was not added has
+// no effect.
+func (l *Logger) RemoveSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sinks := make([]Sink, 0, len(l.sinks))
+	for _, s := range l.sinks {
+		if s != sink {
+			sinks = append(sinks, s)
+		}
+	}
+	l.sinks = sinks
+}
+
 // Debug logs a debug-level message.
 func (l *Logger) Debug(format string, args ...interface{}) {
 	l.log(LogDebug, format, args...)
@@ -226,3 +226,37 @@ func TestLogger_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+// recordingSink collects the messages it receives.
+type recordingSink struct {
+	messages []string
+}
+
+func (s *recordingSink) Log(level LogLevel, message string) {
+	s.messages = append(s.messages, level.String()+" "+message)
+}
+
+func TestLogger_Sinks(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("warn")
+	logger.logger = log.New(&buf, "", 0)
+	first, second := &recordingSink{}, &recordingSink{}
+	logger.AddSink(first)
+	logger.AddSink(second)
+
+	logger.Debug("page %d parsed", 3)
+	logger.Warn("table on page %d falls back to an image", 4)
+	if len(first.messages) != 2 || first.messages[0] != "DEBUG page 3 parsed" || first.messages[1] != "WARN table on page 4 falls back to an image" {
+		t.Errorf("expected sinks to receive messages of every level, got %v", first.messages)
+	}
+	if strings.Contains(buf.String(), "page 3 parsed") || !strings.Contains(buf.String(), "falls back") {
+		t.Errorf("expected the logger's own level to apply to its output, got: %s", buf.String())
+	}
+
+	logger.RemoveSink(first)
+	logger.RemoveSink(&recordingSink{})
+	logger.Error("conversion failed")
+	if len(first.messages) != 2 || len(second.messages) != 3 {
+		t.Errorf("expected only the remaining sink to receive messages, got %v and %v", first.messages, second.messages)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
 
 	"github.com/joho/godotenv"
 
@@ -30,6 +34,26 @@ func main() {
 		code := (&cli.ConfigCLI{}).Run(os.Args[2:])
 		os.Exit(code)
 	}
+	// The 'test-corpus' subcommand checks conversions against golden Markdown files
+	if len(os.Args) > 1 && os.Args[1] == "test-corpus" {
+		os.Exit((&cli.CorpusCLI{}).Run(os.Args[2:]))
+	}
+	// The 'validate-output' subcommand checks sidecar JSON files against their schemas
+	if len(os.Args) > 1 && os.Args[1] == "validate-output" {
+		os.Exit((&cli.ValidateCLI{}).Run(os.Args[2:]))
+	}
+	// The 'stats' subcommand aggregates the conversion reports of an output directory
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		os.Exit((&cli.StatsCLI{}).Run(os.Args[2:]))
+	}
+	// The 'client' subcommand spawns a server and runs tools/list or tools/call against it
+	if len(os.Args) > 1 && os.Args[1] == "client" {
+		os.Exit((&cli.ClientCLI{}).Run(os.Args[2:]))
+	}
+	// The 'selftest' subcommand converts a generated PDF to verify the installation
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit((&cli.SelftestCLI{}).Run(os.Args[2:]))
+	}
 
 	// Load environment variables from pdf_md_mcp.env file if it exists
 	if err := godotenv.Load("pdf_md_mcp.env"); err != nil {
@@ -52,6 +76,15 @@ func main() {
 		logr.Fatal("Failed to create PDF converter: %v", err)
 	}
 
+	// Probe the optional external tools so missing ones disable their features up front
+	converter.DetectCapabilities()
+
+	// Remove temporary files left behind by server processes that crashed or were killed
+	if removed := converter.SweepTempFiles(); removed > 0 {
+		logr.Info("Removed %d orphaned temporary file(s)", removed)
+	}
+	go cleanupOnSignal(converter, logr)
+
 	// Create the main MCP server instance
 	server := &MCPServer{
 		config:    cfg,
@@ -62,33 +95,81 @@ func main() {
 	logr.Info("Starting PDF to Markdown MCP server v%s", cfg.ServerVersion)
 
 	// Start the MCP protocol handler based on the configured transport
-	if err := server.Start(); err != nil {
+	err = server.Start()
+	converter.CleanupTempFiles()
+	if err != nil {
 		logr.Fatal("Server failed to start: %v", err)
 	}
 }
 
-// Start initializes and starts the MCP server based on the configured transport method.
-// It handles stdio transport only, processing incoming MCP messages.
+// cleanupOnSignal removes the temporary files of running conversions and exits when the
+// server is interrupted or terminated.
+func cleanupOnSignal(converter *pdfconv.PDFConverter, logr *logger.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	removed := converter.CleanupTempFiles()
+	logr.Info("Received %v, removed %d temporary file(s)", sig, removed)
+	os.Exit(1)
+}
+
+// Start initializes and starts the MCP server on the configured transports. Several
+// transports run concurrently on one MCP handler, so they share the converter, its caches,
+// the statistics and the job queue. Start returns when every transport has stopped, or with
+// the first transport error.
 func (s *MCPServer) Start() error {
-	s.logger.Info("MCP Server starting with transport: %s", s.config.Transport)
+	transports := s.config.TransportList()
+	s.logger.Info("MCP Server starting with transport: %s", strings.Join(transports, ", "))
+	if s.config.RestrictedMode {
+		s.logger.Info("Restricted mode: only single file conversion inside %s is available", s.config.PDFInputDir)
+	}
+	for _, transport := range transports {
+		if !slices.Contains(config.Transports, transport) {
+			return fmt.Errorf("unsupported transport type: %s (supported: %v)", transport, config.Transports)
+		}
+	}
+
+	// Create the MCP message handler shared by all transports
+	handler := mcp.NewMCPHandler(s.converter, s.logger)
 
-	switch s.config.Transport {
-	case "stdio":
-		return s.startStdioTransport()
-	default:
-		return fmt.Errorf("unsupported transport type: %s (only stdio is supported)", s.config.Transport)
+	// Check for a newer release in the background when UPDATE_CHECK is enabled
+	go handler.CheckForUpdates()
+
+	stopped := make(chan error, len(transports))
+	for _, transport := range transports {
+		go func() {
+			switch transport {
+			case "stdio":
+				stopped <- s.startStdioTransport(handler, len(transports) > 1)
+			case "http":
+				stopped <- s.startHTTPTransport(handler)
+			}
+		}()
 	}
+	for range transports {
+		if err := <-stopped; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// startStdioTransport starts the MCP server using standard input/output communication.
-// This is the most common transport method for MCP servers, allowing them to be
-// integrated with AI coding assistants and other tools that spawn subprocess servers.
-func (s *MCPServer) startStdioTransport() error {
+// startStdioTransport serves MCP over standard input/output until stdin is closed. This is
+// the most common transport method for MCP servers, allowing them to be integrated with AI
+// coding assistants and other tools that spawn subprocess servers. When other transports
+// run alongside, they keep serving after the stdio client has gone.
+func (s *MCPServer) startStdioTransport(handler *mcp.MCPHandler, shared bool) error {
 	s.logger.Info("Starting STDIO transport")
+	err := handler.HandleStdio()
+	if err == nil && shared {
+		s.logger.Info("STDIO client disconnected; other transports keep running")
+	}
+	return err
+}
 
-	// Create MCP message handler
-	handler := mcp.NewMCPHandler(s.converter, s.logger)
-
-	// Process messages from stdin and write responses to stdout
-	return handler.HandleStdio()
+// startHTTPTransport starts the MCP server as an HTTP service with Server-Sent Events, for
+// deployments where clients connect remotely instead of spawning the server.
+func (s *MCPServer) startHTTPTransport(handler *mcp.MCPHandler) error {
+	s.logger.Info("Starting HTTP transport on %s", s.config.HTTPAddr)
+	return handler.HandleHTTP(s.config.HTTPAddr)
 }
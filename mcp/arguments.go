@@ -0,0 +1,169 @@
+// Package mcp - Tool argument validation.
+// This file checks the arguments of a tools/call request against the inputSchema the tool
+// declares in tools/list before the tool runs, so a missing or mistyped argument fails with
+// an invalid params error (-32602) naming every offending argument, instead of being
+// ignored or failing halfway through a conversion.
+package mcp
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// invalidParamsCode is the JSON-RPC error code of requests with invalid params.
+const invalidParamsCode = -32602
+
+// argumentError reports tool call arguments that do not match the tool's inputSchema.
+type argumentError struct {
+	tool   string
+	fields []fieldError
+}
+
+// fieldError describes one argument that does not match the schema. Field is the argument
+// name, with the path below it for nested values, such as "pages[2]".
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *argumentError) Error() string {
+	messages := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// data returns the error data of the invalid params response: the tool and the offending
+// arguments, so clients can point at the fields to fix.
+func (e *argumentError) data() map[string]interface{} {
+	return map[string]interface{}{"tool": e.tool, "errors": e.fields}
+}
+
+// validateArguments checks the arguments of a call to toolName against the tool's
+// inputSchema. Unknown tools have no schema and are left to handleToolsCall.
+func (h *MCPHandler) validateArguments(toolName string, arguments map[string]interface{}) error {
+	for _, tool := range h.toolDefinitions() {
+		if tool["name"] != toolName {
+			continue
+		}
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		if fields := checkObject(schema, arguments, ""); len(fields) > 0 {
+			return &argumentError{tool: toolName, fields: fields}
+		}
+		return nil
+	}
+	return nil
+}
+
+// checkObject checks an object value against an object schema: required properties must be
+// present and declared properties must match their schemas. A null property counts as
+// omitted, and properties the schema does not declare are allowed.
+func checkObject(schema, value map[string]interface{}, path string) []fieldError {
+	var fields []fieldError
+	required, _ := schema["required"].([]string)
+	for _, name := range required {
+		if value[name] == nil {
+			fields = append(fields, fieldError{Field: path + name, Message: "missing required parameter: " + path + name})
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value[name] == nil {
+			continue
+		}
+		property, _ := properties[name].(map[string]interface{})
+		fields = append(fields, checkValue(property, value[name], path+name)...)
+	}
+	return fields
+}
+
+// checkValue checks a value against the type, enum, minimum and maximum of its schema, and
+// the properties or items of objects and arrays. Enumerated strings match ignoring case, as
+// the tools accept them, and the empty string leaves such an argument at its default.
+func checkValue(schema map[string]interface{}, value interface{}, field string) []fieldError {
+	invalid := func(format string, args ...interface{}) []fieldError {
+		return []fieldError{{Field: field, Message: fmt.Sprintf("invalid parameter %s: ", field) + fmt.Sprintf(format, args...)}}
+	}
+	kind, _ := schema["type"].(string)
+	if kind != "" && !isJSONType(value, kind) {
+		return invalid("expected %s, got %s", kind, jsonType(value))
+	}
+
+	switch value := value.(type) {
+	case string:
+		enum, _ := schema["enum"].([]string)
+		if len(enum) > 0 && value != "" && !slices.ContainsFunc(enum, func(allowed string) bool { return strings.EqualFold(allowed, value) }) {
+			return invalid("must be one of %s, got '%s'", strings.Join(enum, ", "), value)
+		}
+	case float64:
+		if minimum, ok := schemaNumber(schema["minimum"]); ok && value < minimum {
+			return invalid("must be at least %g, got %g", minimum, value)
+		}
+		if maximum, ok := schemaNumber(schema["maximum"]); ok && value > maximum {
+			return invalid("must be at most %g, got %g", maximum, value)
+		}
+	case map[string]interface{}:
+		return checkObject(schema, value, field+".")
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		var fields []fieldError
+		for i, item := range value {
+			fields = append(fields, checkValue(items, item, fmt.Sprintf("%s[%d]", field, i))...)
+		}
+		return fields
+	}
+	return nil
+}
+
+// isJSONType reports whether a decoded JSON value has the JSON Schema type kind.
+func isJSONType(value interface{}, kind string) bool {
+	switch kind {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+	return jsonType(value) == kind
+}
+
+// jsonType returns the JSON Schema type name of a decoded JSON value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaNumber returns a numeric schema keyword such as minimum.
+func schemaNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
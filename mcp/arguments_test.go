@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckObject(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pdf_path": map[string]interface{}{"type": "string"},
+			"format":   map[string]interface{}{"type": "string", "enum": []string{"markdown", "html"}},
+			"limit":    map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 50},
+			"async":    map[string]interface{}{"type": "boolean"},
+			"pages":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+			"range":    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"first": map[string]interface{}{"type": "integer"}}, "required": []string{"first"}},
+		},
+		"required": []string{"pdf_path"},
+	}
+	tests := []struct {
+		name      string
+		arguments string
+		want      []fieldError
+	}{
+		{"valid", `{"pdf_path": "a.pdf", "format": "HTML", "limit": 5, "async": true, "pages": [1, 2], "range": {"first": 1}, "extra": 1}`, nil},
+		{"null and empty optional arguments", `{"pdf_path": "a.pdf", "format": "", "limit": null}`, nil},
+		{"missing required", `{"limit": 5}`, []fieldError{{"pdf_path", "missing required parameter: pdf_path"}}},
+		{"null required", `{"pdf_path": null}`, []fieldError{{"pdf_path", "missing required parameter: pdf_path"}}},
+		{"wrong types", `{"pdf_path": 3, "async": "yes"}`, []fieldError{
+			{"async", "invalid parameter async: expected boolean, got string"},
+			{"pdf_path", "invalid parameter pdf_path: expected string, got number"},
+		}},
+		{"fractional integer", `{"pdf_path": "a.pdf", "limit": 2.5}`, []fieldError{{"limit", "invalid parameter limit: expected integer, got number"}}},
+		{"below minimum", `{"pdf_path": "a.pdf", "limit": 0}`, []fieldError{{"limit", "invalid parameter limit: must be at least 1, got 0"}}},
+		{"above maximum", `{"pdf_path": "a.pdf", "limit": 51}`, []fieldError{{"limit", "invalid parameter limit: must be at most 50, got 51"}}},
+		{"not in enum", `{"pdf_path": "a.pdf", "format": "pdf"}`, []fieldError{{"format", "invalid parameter format: must be one of markdown, html, got 'pdf'"}}},
+		{"array item", `{"pdf_path": "a.pdf", "pages": [1, "2"]}`, []fieldError{{"pages[1]", "invalid parameter pages[1]: expected integer, got string"}}},
+		{"nested object", `{"pdf_path": "a.pdf", "range": {}}`, []fieldError{{"range.first", "missing required parameter: range.first"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arguments map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.arguments), &arguments); err != nil {
+				t.Fatal(err)
+			}
+			if got := checkObject(schema, arguments, ""); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkObject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallTool_InvalidArguments(t *testing.T) {
+	h := newConformanceHandler(t)
+	message := &MCPMessage{JSONRPC: "2.0", ID: 1.0, Method: "tools/call", Params: map[string]interface{}{
+		"name": "find_datasheet", "arguments": map[string]interface{}{"limit": "ten"},
+	}}
+	response := h.callTool(context.Background(), message)
+	if response.Error == nil || response.Error.Code != -32602 {
+		t.Fatalf("expected an invalid params error, got %+v", response)
+	}
+	want := map[string]interface{}{"tool": "find_datasheet", "errors": []fieldError{
+		{"query", "missing required parameter: query"},
+		{"limit", "invalid parameter limit: expected integer, got string"},
+	}}
+	if data, _ := response.Error.Data.(map[string]interface{}); !reflect.DeepEqual(data, want) {
+		t.Errorf("expected field errors in the error data, got %v", response.Error.Data)
+	}
+
+	// Unknown tools have no schema to check and fail as before
+	message.Params = map[string]interface{}{"name": "no_such_tool", "arguments": map[string]interface{}{}}
+	if response := h.callTool(context.Background(), message); response.Error == nil || response.Error.Code != -32603 {
+		t.Errorf("expected an unknown tool error, got %+v", response)
+	}
+}
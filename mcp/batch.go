@@ -0,0 +1,139 @@
+// Package mcp - Batch conversion summaries.
+// This file builds the per-file status table of batch and portfolio conversions and the
+// matching machine-readable summary returned as structuredContent for dashboards.
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// Per-file batch conversion status values
+const (
+	BatchStatusOK      = "ok"      // Converted without warnings
+	BatchStatusWarning = "warning" // Converted, but the output needs review
+	BatchStatusPartial = "partial" // Converted without some pages that failed
+	BatchStatusFailed  = "failed"  // Not converted
+)
+
+// batchStatusBadges are the badges shown for each status in the text table.
+var batchStatusBadges = map[string]string{
+	BatchStatusOK:      "✅ ok",
+	BatchStatusWarning: "⚠️ warning",
+	BatchStatusPartial: "🟧 partial",
+	BatchStatusFailed:  "❌ failed",
+}
+
+// BatchSummary is the structuredContent of batch and portfolio conversion results.
+type BatchSummary struct {
+	Input        string               `json:"input"`
+	OutputDir    string               `json:"output_dir"`
+	Portfolio    bool                 `json:"portfolio"`
+	FileCount    int                  `json:"file_count"`
+	SuccessCount int                  `json:"success_count"`
+	FailureCount int                  `json:"failure_count"`
+	WarningCount int                  `json:"warning_count"` // Files converted with warnings
+	PartialCount int                  `json:"partial_count"` // Files converted without some failed pages
+	PageCount    int                  `json:"page_count"`
+	ImageCount   int                  `json:"image_count"`
+	DurationMS   int64                `json:"duration_ms"` // Sum of the per-file conversion times
+	Files        []BatchFileSummary   `json:"files"`
+	ErrataLinks  []pdfconv.ErrataLink `json:"errata_links,omitempty"` // Errata documents linked to their datasheets
+	SummaryFile  string               `json:"summary_file,omitempty"` // BATCH_SUMMARY.md written with BATCH_SUMMARY
+	ResultsFile  string               `json:"results_file,omitempty"` // results.json written with BATCH_RESULTS
+}
+
+// BatchFileSummary is the status of one file of a batch conversion.
+type BatchFileSummary struct {
+	File        string                `json:"file"`
+	Status      string                `json:"status"`
+	OutputDir   string                `json:"output_dir,omitempty"`
+	Quality     *float64              `json:"quality,omitempty"` // Quality score from 0 to 100, absent for failures
+	PageCount   int                   `json:"page_count"`
+	ImageCount  int                   `json:"image_count"`
+	DurationMS  int64                 `json:"duration_ms"`
+	Timings     *pdfconv.PhaseTimings `json:"timings,omitempty"` // Per-phase times in milliseconds
+	Warnings    []string              `json:"warnings,omitempty"`
+	FailedPages []pdfconv.PageFailure `json:"failed_pages,omitempty"` // Pages missing from a partial conversion
+	Error       string                `json:"error,omitempty"`
+	ErrorCode   string                `json:"error_code,omitempty"` // Failure class from pdfconv.ErrorCode
+}
+
+// summarizeBatch builds the batch summary. Files are listed failures first, then by
+// quality score, lowest first, so the conversions needing attention come first.
+func summarizeBatch(result *pdfconv.BatchConversionResult) BatchSummary {
+	summary := BatchSummary{
+		Input:        result.InputDir,
+		OutputDir:    result.OutputBaseDir,
+		Portfolio:    result.Portfolio,
+		FileCount:    result.FileCount,
+		SuccessCount: result.SuccessCount,
+		FailureCount: result.FailureCount,
+		PageCount:    result.TotalPageCount,
+		ImageCount:   result.TotalImageCount,
+		ErrataLinks:  result.ErrataLinks,
+		SummaryFile:  result.SummaryFile,
+		ResultsFile:  result.ResultsFile,
+		Files:        make([]BatchFileSummary, 0, len(result.Results)+len(result.Errors)),
+	}
+	for _, e := range result.Errors {
+		summary.Files = append(summary.Files, BatchFileSummary{File: filepath.Base(e.PDFPath), Status: BatchStatusFailed, Error: e.Error, ErrorCode: e.Code})
+	}
+
+	ranked := append([]pdfconv.ConversionResult(nil), result.Results...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Quality.Score < ranked[j].Quality.Score })
+	for _, r := range ranked {
+		score := r.Quality.Score
+		file := BatchFileSummary{
+			File:        filepath.Base(r.Source),
+			Status:      BatchStatusOK,
+			OutputDir:   r.OutputDir,
+			Quality:     &score,
+			PageCount:   r.PageCount,
+			ImageCount:  r.ImageCount,
+			DurationMS:  r.Duration.Milliseconds(),
+			Timings:     &r.Timings,
+			Warnings:    r.Warnings(),
+			FailedPages: r.FailedPages,
+		}
+		if r.Source == "" {
+			file.File = filepath.Base(r.OutputDir)
+		}
+		switch {
+		case r.Status == pdfconv.StatusPartial:
+			file.Status = BatchStatusPartial
+			summary.PartialCount++
+		case len(file.Warnings) > 0:
+			file.Status = BatchStatusWarning
+			summary.WarningCount++
+		}
+		summary.DurationMS += file.DurationMS
+		summary.Files = append(summary.Files, file)
+	}
+	return summary
+}
+
+// formatBatchTable renders the per-file status lines as a compact Markdown table.
+func (h *MCPHandler) formatBatchTable(files []BatchFileSummary) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var table strings.Builder
+	table.WriteString(h.text(msgBatchTableHeader))
+	for _, f := range files {
+		quality, pages, duration, warnings := "-", "-", "-", "-"
+		if f.Status != BatchStatusFailed {
+			quality = fmt.Sprintf("%.1f", *f.Quality)
+			pages = fmt.Sprintf("%d", f.PageCount)
+			duration = pdfconv.FormatDuration(time.Duration(f.DurationMS) * time.Millisecond)
+			warnings = fmt.Sprintf("%d", len(f.Warnings))
+		}
+		fmt.Fprintf(&table, "| %s | %s | %s | %s | %s | %s |\n", batchStatusBadges[f.Status], strings.ReplaceAll(f.File, "|", `\|`), quality, pages, duration, warnings)
+	}
+	return table.String()
+}
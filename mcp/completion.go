@@ -0,0 +1,123 @@
+// Package mcp - Argument completion.
+// This file implements the completion/complete method, which suggests values for the path,
+// format and preset arguments of the tools while the user types them in clients that support
+// argument autocompletion.
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// maxCompletions is the largest number of values returned by one completion request, the
+// limit set by the MCP specification.
+const maxCompletions = 100
+
+// handleComplete returns completions for the argument in a completion/complete request.
+// Arguments are completed by name, whichever tool they belong to; arguments without
+// completions return an empty list.
+func (h *MCPHandler) handleComplete(params map[string]interface{}) (map[string]interface{}, error) {
+	argument, _ := params["argument"].(map[string]interface{})
+	name, _ := argument["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("missing argument name")
+	}
+	value, _ := argument["value"].(string)
+
+	cfg := h.converter.Config()
+	var values []string
+	switch name {
+	case "pdf_path":
+		values = h.completePath(cfg.PDFInputDir, value, true)
+	case "input_dir":
+		if !h.restricted() {
+			values = h.completePath(cfg.PDFInputDir, value, false)
+		}
+	case "output_dir", "markdown_path":
+		values = h.completePath(cfg.OutputBaseDir, value, false)
+	case "output_format":
+		values = completeWord(config.OutputFormats, value)
+	case "markdown_flavor":
+		values = completeWord(config.MarkdownFlavors, value)
+	case "preset":
+		values = completeWord(config.Presets, value)
+	}
+
+	total := len(values)
+	if total > maxCompletions {
+		values = values[:maxCompletions]
+	}
+	if values == nil {
+		values = []string{}
+	}
+	return map[string]interface{}{
+		"completion": map[string]interface{}{"values": values, "total": total, "hasMore": total > maxCompletions},
+	}, nil
+}
+
+// completePath returns the directories, and with files the supported documents, whose path
+// starts with value. An empty value lists base, and in restricted mode relative values are
+// completed inside base, the same way tool calls resolve them. Hidden entries are only
+// offered once the typed name starts with a dot.
+func (h *MCPHandler) completePath(base, value string, files bool) []string {
+	if value == "" && base != "" && !h.restricted() {
+		value = strings.TrimSuffix(base, string(filepath.Separator)) + string(filepath.Separator)
+	}
+	dir, prefix := filepath.Split(value)
+	lookup := dir
+	if lookup == "" {
+		lookup = "."
+	}
+	if h.restricted() {
+		resolved, err := h.sandboxPath(base, lookup, "path")
+		if err != nil {
+			return nil
+		}
+		lookup = resolved
+	}
+	entries, err := os.ReadDir(lookup)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			info, err := os.Stat(filepath.Join(lookup, name))
+			if err != nil {
+				continue
+			}
+			isDir = info.IsDir()
+		}
+		switch {
+		case isDir:
+			values = append(values, dir+name+string(filepath.Separator))
+		case files && pdfconv.IsSupportedDocument(name):
+			values = append(values, dir+name)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// completeWord returns the words starting with value, ignoring case.
+func completeWord(words []string, value string) []string {
+	var values []string
+	for _, word := range words {
+		if strings.HasPrefix(word, strings.ToLower(value)) {
+			values = append(values, word)
+		}
+	}
+	return values
+}
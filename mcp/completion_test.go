@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+)
+
+func TestHandleComplete(t *testing.T) {
+	h := newConformanceHandler(t)
+	input, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"sub", ".hidden", "many"} {
+		if err := os.Mkdir(filepath.Join(input, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := []string{"a.pdf", "b.xps", "notes.txt", filepath.Join("sub", "c.pdf")}
+	for i := 0; i < maxCompletions+20; i++ {
+		files = append(files, filepath.Join("many", fmt.Sprintf("%03d.pdf", i)))
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(input, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := h.converter.Config()
+	cfg.PDFInputDir = input
+	sep := string(filepath.Separator)
+	complete := func(name, value string) ([]string, int, bool) {
+		t.Helper()
+		result, err := h.handleComplete(map[string]interface{}{"ref": map[string]interface{}{"type": "ref/tool"}, "argument": map[string]interface{}{"name": name, "value": value}})
+		if err != nil {
+			t.Fatalf("handleComplete(%s, %q) error = %v", name, value, err)
+		}
+		completion := result["completion"].(map[string]interface{})
+		return completion["values"].([]string), completion["total"].(int), completion["hasMore"].(bool)
+	}
+
+	tests := []struct {
+		name       string
+		argument   string
+		value      string
+		restricted bool
+		want       []string
+	}{
+		{"documents and directories of PDF_INPUT_DIR", "pdf_path", "", false, []string{input + sep + "a.pdf", input + sep + "b.xps", input + sep + "many" + sep, input + sep + "sub" + sep}},
+		{"typed prefix", "pdf_path", input + sep + "a", false, []string{input + sep + "a.pdf"}},
+		{"hidden entries once a dot is typed", "pdf_path", input + sep + ".", false, []string{input + sep + ".hidden" + sep}},
+		{"directories only", "input_dir", input + sep + "s", false, []string{input + sep + "sub" + sep}},
+		{"missing directory", "pdf_path", input + sep + "missing" + sep, false, []string{}},
+		{"relative to PDF_INPUT_DIR in restricted mode", "pdf_path", "", true, []string{"a.pdf", "b.xps", "many" + sep, "sub" + sep}},
+		{"subdirectory in restricted mode", "pdf_path", "sub" + sep, true, []string{"sub" + sep + "c.pdf"}},
+		{"escape in restricted mode", "pdf_path", ".." + sep, true, []string{}},
+		{"absolute path outside in restricted mode", "pdf_path", filepath.Dir(input) + sep, true, []string{}},
+		{"no input_dir in restricted mode", "input_dir", "", true, []string{}},
+		{"output format", "output_format", "M", false, []string{"markdown"}},
+		{"markdown flavor", "markdown_flavor", "", false, config.MarkdownFlavors},
+		{"preset", "preset", "r", false, []string{"rag-optimized"}},
+		{"argument without completions", "pages", "1", false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.RestrictedMode = tt.restricted
+			defer func() { cfg.RestrictedMode = false }()
+			values, total, hasMore := complete(tt.argument, tt.value)
+			if !reflect.DeepEqual(values, tt.want) || total != len(tt.want) || hasMore {
+				t.Errorf("got %v (total %d, hasMore %t), want %v", values, total, hasMore, tt.want)
+			}
+		})
+	}
+
+	// Long lists are cut at the limit of the specification
+	values, total, hasMore := complete("pdf_path", input+sep+"many"+sep)
+	if len(values) != maxCompletions || total != maxCompletions+20 || !hasMore {
+		t.Errorf("expected %d of %d values with more, got %d of %d (hasMore %t)", maxCompletions, maxCompletions+20, len(values), total, hasMore)
+	}
+
+	if _, err := h.handleComplete(map[string]interface{}{"argument": map[string]interface{}{"value": "a"}}); err == nil {
+		t.Error("expected a request without an argument name rejected")
+	}
+}
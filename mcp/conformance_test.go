@@ -0,0 +1,410 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// conformanceConn is a client connection to the server over one transport.
+type conformanceConn interface {
+	// exchange sends one raw message and returns the messages the server answered it with.
+	exchange(t *testing.T, message string) []map[string]interface{}
+}
+
+// conformanceTransports are the transports the conformance sequence runs over.
+var conformanceTransports = []struct {
+	name    string
+	connect func(t *testing.T, h *MCPHandler) conformanceConn
+}{
+	{"stdio", connectStdio},
+	{"streamable-http-json", func(t *testing.T, h *MCPHandler) conformanceConn { return connectStreamable(t, h, "application/json") }},
+	{"streamable-http-sse", func(t *testing.T, h *MCPHandler) conformanceConn {
+		return connectStreamable(t, h, "application/json, text/event-stream")
+	}},
+}
+
+// conformanceStep is a message of the conformance sequence and the check of its answers.
+type conformanceStep struct {
+	name    string
+	message string
+	check   func(t *testing.T, responses []map[string]interface{})
+}
+
+// conformanceSteps drive a session from initialize through tools, prompts, cancellation and
+// ping, with malformed messages in between that must not disturb the session.
+var conformanceSteps = []conformanceStep{
+	{"initialize", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"conformance","version":"1.0"}}}`,
+		expectResult(1.0, func(t *testing.T, result map[string]interface{}) {
+			if result["protocolVersion"] != protocolVersion {
+				t.Errorf("expected protocolVersion %q, got %v", protocolVersion, result["protocolVersion"])
+			}
+			capabilities, _ := result["capabilities"].(map[string]interface{})
+			if _, ok := capabilities["tools"].(map[string]interface{}); !ok {
+				t.Errorf("expected the tools capability, got %v", result["capabilities"])
+			}
+			serverInfo, _ := result["serverInfo"].(map[string]interface{})
+			if name, _ := serverInfo["name"].(string); name == "" {
+				t.Errorf("expected serverInfo with a name, got %v", result["serverInfo"])
+			}
+		})},
+	{"initialized notification", `{"jsonrpc":"2.0","method":"notifications/initialized"}`, expectNone},
+	{"ping", `{"jsonrpc":"2.0","id":"ping-1","method":"ping"}`,
+		expectResult("ping-1", func(t *testing.T, result map[string]interface{}) {
+			if len(result) != 0 {
+				t.Errorf("expected an empty ping result, got %v", result)
+			}
+		})},
+	{"tools/list", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		expectResult(2.0, func(t *testing.T, result map[string]interface{}) {
+			tools, _ := result["tools"].([]interface{})
+			if len(tools) == 0 {
+				t.Fatalf("expected tools, got %v", result)
+			}
+			names := make(map[string]bool)
+			for _, item := range tools {
+				tool, _ := item.(map[string]interface{})
+				name, _ := tool["name"].(string)
+				schema, _ := tool["inputSchema"].(map[string]interface{})
+				if name == "" || names[name] || schema["type"] != "object" {
+					t.Errorf("expected a uniquely named tool with an object input schema, got %v", tool)
+				}
+				names[name] = true
+			}
+		})},
+	{"tools/call", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_server_version","arguments":{}}}`,
+		expectResult(3.0, func(t *testing.T, result map[string]interface{}) {
+			content, _ := result["content"].([]interface{})
+			if len(content) == 0 {
+				t.Fatalf("expected tool content, got %v", result)
+			}
+			for _, item := range content {
+				block, _ := item.(map[string]interface{})
+				if text, _ := block["text"].(string); block["type"] != "text" || text == "" {
+					t.Errorf("expected a text content block, got %v", block)
+				}
+			}
+		})},
+	{"tools/call of an unknown tool", `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"no_such_tool","arguments":{}}}`, expectToolError(4.0)},
+	{"tools/call without a name", `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"arguments":{}}}`, expectToolError(5.0)},
+	{"tools/call with invalid arguments", `{"jsonrpc":"2.0","id":"tools-invalid","method":"tools/call","params":{"name":"get_job_status","arguments":{"job_id":5}}}`, expectError("tools-invalid", -32602)},
+	{"prompts/list", `{"jsonrpc":"2.0","id":"prompts-1","method":"prompts/list"}`,
+		expectResult("prompts-1", func(t *testing.T, result map[string]interface{}) {
+			prompts, _ := result["prompts"].([]interface{})
+			if len(prompts) == 0 {
+				t.Fatalf("expected prompts, got %v", result)
+			}
+			for _, item := range prompts {
+				prompt, _ := item.(map[string]interface{})
+				arguments, _ := prompt["arguments"].([]interface{})
+				if name, _ := prompt["name"].(string); name == "" || len(arguments) == 0 {
+					t.Errorf("expected a named prompt with arguments, got %v", prompt)
+				}
+			}
+		})},
+	{"prompts/get of an unknown prompt", `{"jsonrpc":"2.0","id":"prompts-2","method":"prompts/get","params":{"name":"no_such_prompt"}}`, expectError("prompts-2", -32602)},
+	{"prompts/get without arguments", `{"jsonrpc":"2.0","id":"prompts-3","method":"prompts/get","params":{"name":"summarize_datasheet"}}`, expectError("prompts-3", -32602)},
+	{"logging/setLevel with an invalid level", `{"jsonrpc":"2.0","id":"logging-1","method":"logging/setLevel","params":{"level":"verbose"}}`, expectError("logging-1", -32602)},
+	{"logging/setLevel", `{"jsonrpc":"2.0","id":"logging-2","method":"logging/setLevel","params":{"level":"emergency"}}`, expectResult("logging-2", nil)},
+	{"cancel of an answered request", `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":3,"reason":"user abort"}}`, expectNone},
+	{"cancel of an unknown request", `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"never-sent"}}`, expectNone},
+	{"unknown method", `{"jsonrpc":"2.0","id":6,"method":"no/such/method"}`, expectError(6.0, -32601)},
+	{"unknown notification", `{"jsonrpc":"2.0","method":"notifications/no_such_notification"}`, expectNone},
+	{"unsolicited response", `{"jsonrpc":"2.0","id":"server-99","result":{}}`, expectNone},
+	{"wrong jsonrpc version", `{"jsonrpc":"1.0","id":7,"method":"ping"}`, expectError(7.0, -32600)},
+	{"missing method", `{"jsonrpc":"2.0","id":8}`, expectError(8.0, -32600)},
+	{"null id", `{"jsonrpc":"2.0","id":null,"method":"ping"}`, expectError(nil, -32600)},
+	{"object id", `{"jsonrpc":"2.0","id":{"a":1},"method":"ping"}`, expectError(nil, -32600)},
+	{"array params", `{"jsonrpc":"2.0","id":9,"method":"tools/list","params":[1]}`, expectError(nil, -32600)},
+	{"invalid JSON", `{"jsonrpc":"2.0","id":10,"method":`, expectError(nil, -32700)},
+	{"JSON that is not an object", `42`, expectError(nil, -32600)},
+	{"ping after malformed messages", `{"jsonrpc":"2.0","id":11,"method":"ping"}`, expectResult(11.0, nil)},
+}
+
+func TestConformance(t *testing.T) {
+	for _, transport := range conformanceTransports {
+		t.Run(transport.name, func(t *testing.T) {
+			conn := transport.connect(t, newConformanceHandler(t))
+			for _, step := range conformanceSteps {
+				responses := conn.exchange(t, step.message)
+				for _, response := range responses {
+					_, hasResult := response["result"]
+					_, hasError := response["error"]
+					_, hasID := response["id"]
+					if response["jsonrpc"] != "2.0" || hasResult == hasError || !hasID {
+						t.Errorf("%s: expected a JSON-RPC 2.0 response with an id and either result or error, got %v", step.name, response)
+					}
+				}
+				t.Run(step.name, func(t *testing.T) { step.check(t, responses) })
+			}
+		})
+	}
+}
+
+// TestConformance_SharedHandler serves stdio and Streamable HTTP from one handler, as
+// MCP_TRANSPORT=stdio,http does, and checks that session defaults and jobs stay with the
+// session that set or submitted them.
+func TestConformance_SharedHandler(t *testing.T) {
+	inputDir := filepath.Dir(createFigurePDF(t))
+	outputDir := t.TempDir()
+	h := newConformanceHandler(t)
+	stdio := connectStdio(t, h)
+	streamable := connectStreamable(t, h, "application/json")
+	other := &streamableConn{url: streamable.(*streamableConn).url, accept: "application/json"}
+	initialize := conformanceSteps[0]
+	for _, conn := range []conformanceConn{stdio, streamable, other} {
+		initialize.check(t, conn.exchange(t, initialize.message))
+	}
+
+	// Session defaults apply to their own session only
+	expectResult(19.0, func(t *testing.T, result map[string]interface{}) {
+		defaults, _ := result["structuredContent"].(map[string]interface{})
+		if defaults["output_dir"] != outputDir || defaults["preset"] != "fast" {
+			t.Errorf("expected the session defaults set, got %v", defaults)
+		}
+	})(t, streamable.exchange(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":19,"method":"tools/call","params":{"name":"set_session_defaults","arguments":{"output_dir":%q,"preset":"fast"}}}`, outputDir)))
+	expectResult(19.0, func(t *testing.T, result map[string]interface{}) {
+		if defaults, _ := result["structuredContent"].(map[string]interface{}); len(defaults) != 0 {
+			t.Errorf("expected no defaults in another session, got %v", defaults)
+		}
+	})(t, other.exchange(t, `{"jsonrpc":"2.0","id":19,"method":"tools/call","params":{"name":"set_session_defaults","arguments":{}}}`))
+
+	var jobID string
+	expectResult(20.0, func(t *testing.T, result map[string]interface{}) {
+		job, _ := result["structuredContent"].(map[string]interface{})
+		jobID, _ = job["job_id"].(string)
+	})(t, streamable.exchange(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":20,"method":"tools/call","params":{"name":"convert_pdfs_in_directory","arguments":{"input_dir":%q,"async":true}}}`, inputDir)))
+	if jobID == "" {
+		t.Fatal("expected a job id from the HTTP transport")
+	}
+
+	// Only the submitting session sees the job, over any transport
+	status := fmt.Sprintf(`{"jsonrpc":"2.0","id":21,"method":"tools/call","params":{"name":"get_job_status","arguments":{"job_id":%q}}}`, jobID)
+	expectToolError(21.0)(t, stdio.exchange(t, status))
+	expectToolError(21.0)(t, other.exchange(t, status))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var state string
+		expectResult(21.0, func(t *testing.T, result map[string]interface{}) {
+			job, _ := result["structuredContent"].(map[string]interface{})
+			state, _ = job["status"].(string)
+		})(t, streamable.exchange(t, status))
+		if state == JobCompleted {
+			break
+		}
+		if state == JobFailed || time.Now().After(deadline) {
+			t.Fatalf("expected the job completed, got status %q", state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if entries, err := os.ReadDir(outputDir); err != nil || len(entries) == 0 {
+		t.Errorf("expected the job to write to the session's default output directory, got %v, %v", entries, err)
+	}
+	expectToolError(22.0)(t, other.exchange(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":22,"method":"tools/call","params":{"name":"get_job_result","arguments":{"job_id":%q}}}`, jobID)))
+}
+
+// newConformanceHandler returns a handler with the default configuration, writing output
+// to a temporary directory.
+func newConformanceHandler(t *testing.T) *MCPHandler {
+	t.Setenv("OUTPUT_BASE_DIR", t.TempDir())
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	log := logger.NewLogger("fatal")
+	converter, err := pdfconv.NewPDFConverter(cfg, log)
+	if err != nil {
+		t.Fatalf("failed to create converter: %v", err)
+	}
+	return NewMCPHandler(converter, log)
+}
+
+// expectNone checks that a notification or response was not answered.
+func expectNone(t *testing.T, responses []map[string]interface{}) {
+	if len(responses) != 0 {
+		t.Errorf("expected no response, got %v", responses)
+	}
+}
+
+// expectResult checks for a single result with the request's id, checked further by check
+// when not nil.
+func expectResult(id interface{}, check func(t *testing.T, result map[string]interface{})) func(*testing.T, []map[string]interface{}) {
+	return func(t *testing.T, responses []map[string]interface{}) {
+		response := singleResponse(t, responses, id)
+		result, ok := response["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected a result, got %v", response)
+		}
+		if check != nil {
+			check(t, result)
+		}
+	}
+}
+
+// expectError checks for a single error response with the given id and code.
+func expectError(id interface{}, code int) func(*testing.T, []map[string]interface{}) {
+	return func(t *testing.T, responses []map[string]interface{}) {
+		response := singleResponse(t, responses, id)
+		rpcError, _ := response["error"].(map[string]interface{})
+		if message, _ := rpcError["message"].(string); rpcError["code"] != float64(code) || message == "" {
+			t.Errorf("expected error %d with a message, got %v", code, response)
+		}
+	}
+}
+
+// expectToolError checks that a failed tool call is reported, either as a JSON-RPC error or
+// as a result with isError set.
+func expectToolError(id interface{}) func(*testing.T, []map[string]interface{}) {
+	return func(t *testing.T, responses []map[string]interface{}) {
+		response := singleResponse(t, responses, id)
+		result, _ := response["result"].(map[string]interface{})
+		if response["error"] == nil && result["isError"] != true {
+			t.Errorf("expected a tool error, got %v", response)
+		}
+	}
+}
+
+// singleResponse returns the only response, failing unless it carries id.
+func singleResponse(t *testing.T, responses []map[string]interface{}, id interface{}) map[string]interface{} {
+	t.Helper()
+	if len(responses) != 1 {
+		t.Fatalf("expected one response, got %v", responses)
+	}
+	if !reflect.DeepEqual(responses[0]["id"], id) {
+		t.Fatalf("expected id %#v, got %#v", id, responses[0]["id"])
+	}
+	return responses[0]
+}
+
+// conformanceSync is the ping sent after each stdio message: everything the server sends
+// before answering it answers the message.
+const conformanceSync = `{"jsonrpc":"2.0","id":"conformance-sync","method":"ping"}`
+
+// stdioConn talks to a handler over the stdio transport's message loop.
+type stdioConn struct {
+	in  io.Writer
+	out *bufio.Reader
+}
+
+func connectStdio(t *testing.T, h *MCPHandler) conformanceConn {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- h.serve(inReader, outWriter)
+		outWriter.Close()
+	}()
+	t.Cleanup(func() {
+		inWriter.Close()
+		if err := <-done; err != nil {
+			t.Errorf("serve failed: %v", err)
+		}
+	})
+	return &stdioConn{in: inWriter, out: bufio.NewReader(outReader)}
+}
+
+func (c *stdioConn) exchange(t *testing.T, message string) []map[string]interface{} {
+	t.Helper()
+	// The server may answer before reading the sync message, so write without blocking reads
+	go func() { _, _ = io.WriteString(c.in, message+"\n"+conformanceSync+"\n") }()
+
+	var responses []map[string]interface{}
+	for {
+		line, err := c.out.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(line, &response); err != nil {
+			t.Fatalf("server sent invalid JSON %q: %v", line, err)
+		}
+		if response["id"] == "conformance-sync" {
+			return responses
+		}
+		responses = append(responses, response)
+	}
+}
+
+// streamableConn talks to a handler over the Streamable HTTP transport.
+type streamableConn struct {
+	url     string
+	accept  string
+	session string
+}
+
+func connectStreamable(t *testing.T, h *MCPHandler, accept string) conformanceConn {
+	server := httptest.NewServer(h.httpHandler())
+	t.Cleanup(server.Close)
+	return &streamableConn{url: server.URL + streamableEndpointPath, accept: accept}
+}
+
+func (c *streamableConn) exchange(t *testing.T, message string) []map[string]interface{} {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPost, c.url, strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", c.accept)
+	if c.session != "" {
+		request.Header.Set(sessionIDHeader, c.session)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer response.Body.Close()
+	if id := response.Header.Get(sessionIDHeader); id != "" {
+		c.session = id
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	switch {
+	case response.StatusCode == http.StatusAccepted:
+		return nil
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		var responses []map[string]interface{}
+		for _, line := range strings.Split(string(body), "\n") {
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				responses = append(responses, decodeResponses(t, []byte(data))...)
+			}
+		}
+		return responses
+	case strings.HasPrefix(contentType, "application/json"):
+		return decodeResponses(t, body)
+	}
+	t.Fatalf("unexpected %s response %q", response.Status, body)
+	return nil
+}
+
+// decodeResponses decodes a JSON response object or batch of them.
+func decodeResponses(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+	var responses []map[string]interface{}
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] != '[' {
+		data = []byte("[" + string(data) + "]")
+	}
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatalf("server sent invalid JSON %q: %v", data, err)
+	}
+	return responses
+}
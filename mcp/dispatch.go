@@ -0,0 +1,207 @@
+// Package mcp - Message dispatch.
+// This file reads client messages on a goroutine of their own, so responses to the server's
+// requests, such as sampling/createMessage, reach the tool call waiting for them whatever
+// the session is doing. Tool calls run on a pool of MAX_CONCURRENT_TOOL_CALLS workers and
+// are answered as they finish, so a long conversion does not hold up tools/list, ping or
+// other tool calls, and notifications/cancelled stops a running call.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// inbox holds the client messages read by the reader goroutine until the session takes
+// them. It never blocks the reader, which must keep reading responses to the server's
+// requests while the session waits for them.
+type inbox struct {
+	mu       sync.Mutex
+	messages []MCPMessage
+	err      error         // Error that ended reading, io.EOF when the client closed the stream
+	wake     chan struct{} // Signaled when a message is added or reading ended
+}
+
+// newInbox returns an empty inbox.
+func newInbox() *inbox {
+	return &inbox{wake: make(chan struct{}, 1)}
+}
+
+// push adds a message.
+func (b *inbox) push(message MCPMessage) {
+	b.mu.Lock()
+	b.messages = append(b.messages, message)
+	b.mu.Unlock()
+	b.signal()
+}
+
+// close ends the inbox once its messages have been taken; next then returns err.
+func (b *inbox) close(err error) {
+	b.mu.Lock()
+	b.err = err
+	b.mu.Unlock()
+	b.signal()
+}
+
+// signal wakes a waiting next.
+func (b *inbox) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next waits for the next message, in arrival order.
+func (b *inbox) next() (MCPMessage, error) {
+	for {
+		b.mu.Lock()
+		if len(b.messages) > 0 {
+			message := b.messages[0]
+			b.messages = b.messages[1:]
+			b.mu.Unlock()
+			return message, nil
+		}
+		err := b.err
+		b.mu.Unlock()
+		if err != nil {
+			return MCPMessage{}, err
+		}
+		<-b.wake
+	}
+}
+
+// readMessages reads client messages into messages until the stream ends. Responses to the
+// server's requests go to the request waiting for them, and messages that cannot be
+// parsed are answered right away.
+func (h *MCPHandler) readMessages(messages *inbox) {
+	defer h.disconnect()
+	for {
+		line, err := h.in.next()
+		var tooLarge *messageTooLargeError
+		if errors.As(err, &tooLarge) {
+			h.logger.Error("Rejected client message: %v", err)
+			_ = h.send(MCPMessage{JSONRPC: "2.0", Error: &MCPError{Code: -32600, Message: "Message too large", Data: err.Error()}})
+			continue
+		}
+		if err != nil {
+			messages.close(err)
+			return
+		}
+
+		h.logger.Debug("Received message: %s", line)
+
+		var message MCPMessage
+		if err := json.Unmarshal(line, &message); err != nil {
+			h.logger.Error("Failed to parse message: %v", err)
+			errorResponse := MCPMessage{JSONRPC: "2.0", Error: &MCPError{Code: -32700, Message: "Parse error", Data: err.Error()}}
+			if json.Valid(line) {
+				// Valid JSON that is not a message object, such as an array, is an invalid request
+				errorResponse.Error = &MCPError{Code: -32600, Message: "Invalid Request", Data: "message must be a JSON-RPC request object with object params"}
+			}
+			_ = h.send(errorResponse)
+			continue
+		}
+		if message.Method == "" && (message.Result != nil || message.Error != nil) && h.deliver(message) {
+			continue
+		}
+		messages.push(message)
+	}
+}
+
+// deliver passes a response to the request to the client waiting for it. It reports false
+// when no request waits for the response.
+func (h *MCPHandler) deliver(message MCPMessage) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	response, ok := h.awaiting[requestKey(message.ID)]
+	if ok {
+		select {
+		case response <- message:
+		default: // A duplicate response
+		}
+	}
+	return ok
+}
+
+// disconnect marks the client connection closed, failing the requests waiting for the
+// client.
+func (h *MCPHandler) disconnect() {
+	close(h.disconnected)
+}
+
+// toolCallPool runs the tool calls of a session on at most size goroutines at a time.
+type toolCallPool struct {
+	h       *MCPHandler
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+// toolCallKey is the context key of the request ID of the tool call a context belongs to.
+type toolCallKey struct{}
+
+// newToolCallPool returns a pool running size tool calls at a time.
+func (h *MCPHandler) newToolCallPool(size int) *toolCallPool {
+	return &toolCallPool{h: h, slots: make(chan struct{}, size)}
+}
+
+// start runs a tool call once a worker is free and sends its response. The response to a
+// call cancelled by the client is not sent.
+func (p *toolCallPool) start(message MCPMessage) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), toolCallKey{}, message.ID))
+	key := requestKey(message.ID)
+	if message.hasID {
+		p.h.mu.Lock()
+		p.h.running[key] = cancel
+		p.h.mu.Unlock()
+	}
+
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		defer cancel()
+		p.slots <- struct{}{}
+		defer func() { <-p.slots }()
+
+		var response MCPMessage
+		if ctx.Err() == nil {
+			response = p.h.callTool(ctx, &message)
+		}
+		if message.hasID {
+			p.h.mu.Lock()
+			delete(p.h.running, key)
+			p.h.mu.Unlock()
+		}
+		if ctx.Err() != nil {
+			p.h.logger.Info("Tool call %v cancelled", message.ID)
+			return
+		}
+		if !message.hasID {
+			return
+		}
+		if err := p.h.send(response); err != nil {
+			p.h.logger.Error("Failed to send response: %v", err)
+		}
+	}()
+}
+
+// wait waits until the started tool calls have been answered.
+func (p *toolCallPool) wait() {
+	p.running.Wait()
+}
+
+// cancelRequest stops the tool call with the request ID named by a notifications/cancelled.
+// Requests that already have been answered, or that are not tool calls, are left alone.
+func (h *MCPHandler) cancelRequest(params map[string]interface{}) {
+	id := params["requestId"]
+	h.mu.Lock()
+	cancel, ok := h.running[requestKey(id)]
+	h.mu.Unlock()
+	if !ok {
+		h.logger.Debug("Ignoring cancellation of request %v, which is not running", id)
+		return
+	}
+	reason, _ := params["reason"].(string)
+	h.logger.Info("Cancelling tool call %v: %s", id, reason)
+	cancel()
+}
@@ -0,0 +1,695 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// createFigurePDF writes a single-page PDF drawing one uncompressed grayscale image, whose
+// caption is requested from the client.
+func createFigurePDF(t *testing.T) string {
+	t.Helper()
+	pixels := bytes.Repeat([]byte{0xff}, 16*16)
+	for i := 0; i < 16; i++ {
+		pixels[i*16+i] = 0
+	}
+	content := "q 100 0 0 100 50 600 cm /Fig Do Q"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /XObject << /Fig 4 0 R >> >> /Contents 5 0 R >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 16 /Height 16 /ColorSpace /DeviceGray /BitsPerComponent 8 /Length %d >>\nstream\n%s\nendstream", len(pixels), pixels),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+	var data bytes.Buffer
+	data.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = data.Len()
+		fmt.Fprintf(&data, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := data.Len()
+	fmt.Fprintf(&data, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&data, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&data, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	pdfPath := filepath.Join(t.TempDir(), "figure.pdf")
+	if err := os.WriteFile(pdfPath, data.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+	return pdfPath
+}
+
+func TestServe_ConcurrentToolCalls(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageAltText: "caption", ImageFormat: "png", ImageMaxDPI: 300, OutputBaseDir: t.TempDir()}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+	h.toolCalls = 2
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- h.serve(inReader, outWriter)
+		outWriter.Close()
+	}()
+	out := bufio.NewReader(outReader)
+	write := func(message string) {
+		t.Helper()
+		if _, err := io.WriteString(inWriter, message+"\n"); err != nil {
+			t.Fatalf("failed to write message: %v", err)
+		}
+	}
+	read := func() map[string]interface{} {
+		t.Helper()
+		line, err := out.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+		var message map[string]interface{}
+		if err := json.Unmarshal(line, &message); err != nil {
+			t.Fatalf("server sent invalid JSON %q: %v", line, err)
+		}
+		return message
+	}
+	call := func(id string) string {
+		arguments, _ := json.Marshal(map[string]interface{}{"pdf_path": createFigurePDF(t)})
+		return `{"jsonrpc":"2.0","id":"` + id + `","method":"tools/call","params":{"name":"convert_pdf_to_markdown","arguments":` + string(arguments) + `}}`
+	}
+
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"sampling":{}}}}`)
+	read()
+
+	// The conversion waits for its caption while other requests are answered
+	write(call("convert"))
+	sampling := read()
+	if sampling["method"] != "sampling/createMessage" {
+		t.Fatalf("expected a sampling request, got %v", sampling)
+	}
+	write(`{"jsonrpc":"2.0","id":"ping","method":"ping"}`)
+	if response := read(); response["id"] != "ping" {
+		t.Fatalf("expected the ping answered during the conversion, got %v", response)
+	}
+	samplingID, _ := json.Marshal(sampling["id"])
+	write(`{"jsonrpc":"2.0","id":` + string(samplingID) + `,"result":{"role":"assistant","model":"test","content":{"type":"text","text":"Diagonal line."}}}`)
+	if response := read(); response["id"] != "convert" || response["result"] == nil {
+		t.Fatalf("expected the conversion result, got %v", response)
+	}
+
+	// A cancelled call withdraws its caption request and is not answered
+	write(call("cancel"))
+	sampling = read()
+	write(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"cancel"}}`)
+	if notification := read(); notification["method"] != "notifications/cancelled" {
+		t.Fatalf("expected the sampling request cancelled, got %v", notification)
+	}
+	inWriter.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+	if rest, _ := io.ReadAll(out); len(rest) > 0 {
+		t.Errorf("expected no response to the cancelled call, got %s", rest)
+	}
+}
+
+func TestHandleInitialize_ConcurrentToolCalls(t *testing.T) {
+	h := newConformanceHandler(t)
+	h.converter.Config().ImageAltText = "caption"
+
+	// Tool calls on workers read the client capabilities while a new initialize records them;
+	// go test -race reports unguarded access
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			h.imageCaptioner(context.Background())
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		h.handleInitialize(map[string]interface{}{"capabilities": map[string]interface{}{"sampling": map[string]interface{}{}}})
+	}
+	<-done
+	if h.imageCaptioner(context.Background()) == nil {
+		t.Error("expected captions requested from a client declaring sampling")
+	}
+}
+
+func TestCallTool_Timeout(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := &config.Config{BaseHeaderLevel: 1, ImageFormat: "png", ImageMaxDPI: 300, OutputBaseDir: outputDir, ToolTimeout: 30}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+
+	// A deadline that has passed stands in for a conversion running past CONVERSION_TIMEOUT
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	message := MCPMessage{JSONRPC: "2.0", ID: "slow", Method: "tools/call", Params: map[string]interface{}{
+		"name": "convert_pdf_to_markdown", "arguments": map[string]interface{}{"pdf_path": createFigurePDF(t)},
+	}}
+	response := h.callTool(ctx, &message)
+	if response.Error == nil {
+		t.Fatalf("expected a timeout error, got %+v", response.Result)
+	}
+	data, _ := response.Error.Data.(map[string]interface{})
+	if data["code"] != "timeout" || data["timeout_seconds"] != 30 || response.Error.Message != "Tool call timed out after 30s (CONVERSION_TIMEOUT)" {
+		t.Errorf("unexpected timeout error: %+v", response.Error)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("expected no output left behind, got %v", entries)
+	}
+}
+
+func TestHandleToolsCall_StructuredConversionResult(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageFormat: "png", ImageMaxDPI: 300, OutputBaseDir: t.TempDir()}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+
+	result, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "convert_pdf_to_markdown", "arguments": map[string]interface{}{"pdf_path": createFigurePDF(t)},
+	})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	summary, ok := result["structuredContent"].(ConversionSummary)
+	if !ok {
+		t.Fatalf("expected a conversion summary as structuredContent, got %T", result["structuredContent"])
+	}
+	if summary.MarkdownFile == "" || filepath.Dir(summary.MarkdownFile) != summary.OutputDir || len(summary.Pages) != 1 || summary.Pages[0].Images != 1 {
+		t.Errorf("unexpected conversion summary: %+v", summary)
+	}
+	if len(summary.Images) != 1 || summary.Images[0].Page != 1 || summary.Images[0].Width == 0 {
+		t.Fatalf("expected the image file of page 1, got %+v", summary.Images)
+	}
+	if _, err := os.Stat(filepath.Join(summary.OutputDir, summary.Images[0].File)); err != nil {
+		t.Errorf("listed image file is missing: %v", err)
+	}
+
+	content, _ := result["content"].([]map[string]interface{})
+	if len(content) != 2 {
+		t.Fatalf("expected a text and a JSON content block, got %v", content)
+	}
+	var decoded ConversionSummary
+	if err := json.Unmarshal([]byte(content[1]["text"].(string)), &decoded); err != nil {
+		t.Fatalf("invalid JSON content block: %v", err)
+	}
+	if decoded.OutputDir != summary.OutputDir || len(decoded.Images) != 1 {
+		t.Errorf("expected the JSON block to match structuredContent, got %+v", decoded)
+	}
+}
+
+func TestFormatSplitConversionResult_PartialSection(t *testing.T) {
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(&config.Config{}, log)
+	h := NewMCPHandler(converter, log)
+
+	text := h.formatSplitConversionResult(&pdfconv.SplitConversionResult{
+		OutputDir: "/out/MARKDOWN_manual", IndexFile: "/out/MARKDOWN_manual/README.md", PageCount: 4,
+		Sections: []pdfconv.SectionResult{
+			{Title: "Chapter 1", StartPage: 1, EndPage: 2, Result: pdfconv.ConversionResult{Status: pdfconv.StatusComplete, OutputDir: "/out/MARKDOWN_manual/SECTION_01_chapter-1"}},
+			{Title: "Chapter 3", StartPage: 3, EndPage: 4, Result: pdfconv.ConversionResult{Status: pdfconv.StatusPartial, OutputDir: "/out/MARKDOWN_manual/SECTION_02_chapter-3",
+				FailedPages: []pdfconv.PageFailure{{Page: 3, Reason: "text extraction failed"}}}},
+		},
+	})
+	for _, want := range []string{"SECTION_01_chapter-1, quality 0.0/100, 0ms\n", "SECTION_02_chapter-3, quality 0.0/100, 0ms, partial (1 page(s) failed)\n   - Page 3: text extraction failed\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestHandleToolsCall_ConvertPDFPages(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ImageFormat: "png", ImageMaxDPI: 300, OutputBaseDir: t.TempDir()}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+	pdfPath := createFigurePDF(t)
+
+	result, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "convert_pdf_pages", "arguments": map[string]interface{}{"pdf_path": pdfPath, "pages": "1-"},
+	})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	if summary, _ := result["structuredContent"].(ConversionSummary); summary.PageCount != 1 {
+		t.Errorf("expected page 1 converted, got %+v", result["structuredContent"])
+	}
+
+	for pages, want := range map[string]string{"": "invalid pages", "3-1": "invalid pages", "2-4": "matches no page"} {
+		_, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+			"name": "convert_pdf_pages", "arguments": map[string]interface{}{"pdf_path": pdfPath, "pages": pages},
+		})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("pages %q: expected an error containing %q, got %v", pages, want, err)
+		}
+	}
+	if _, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "convert_pdf_pages", "arguments": map[string]interface{}{"pdf_path": pdfPath},
+	}); err == nil || err.Error() != "missing required parameter: pages" {
+		t.Errorf("expected a missing pages error, got %v", err)
+	}
+}
+
+func TestHandleToolsCall_ExtractPDFMetadata(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, OutputBaseDir: t.TempDir()}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+
+	result, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "extract_pdf_metadata", "arguments": map[string]interface{}{"pdf_path": createFigurePDF(t)},
+	})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	metadata, ok := result["structuredContent"].(*pdfconv.PDFMetadata)
+	if !ok {
+		t.Fatalf("expected the metadata as structuredContent, got %T", result["structuredContent"])
+	}
+	if metadata.PageCount != 1 || metadata.Version != "1.7" || metadata.Encrypted {
+		t.Errorf("unexpected metadata: %+v", metadata)
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	if len(content) != 2 || !strings.Contains(content[0]["text"].(string), "Title: -\n") || !strings.Contains(content[0]["text"].(string), "PDF Version: 1.7\n") {
+		t.Errorf("unexpected text result: %v", content)
+	}
+	if entries, _ := os.ReadDir(cfg.OutputBaseDir); len(entries) != 0 {
+		t.Errorf("expected nothing written, got %d entries", len(entries))
+	}
+}
+
+func TestHandleToolsCall_ListPDFs(t *testing.T) {
+	pdfPath := createFigurePDF(t)
+	inputDir := filepath.Dir(pdfPath)
+	cfg := &config.Config{BaseHeaderLevel: 1, PDFInputDir: inputDir, OutputBaseDir: t.TempDir()}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+
+	result, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "list_pdfs", "arguments": map[string]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	list, ok := result["structuredContent"].(DocumentList)
+	if !ok {
+		t.Fatalf("expected the document list as structuredContent, got %T", result["structuredContent"])
+	}
+	if list.InputDir != inputDir || len(list.Documents) != 1 || list.Documents[0].Path != pdfPath || list.Documents[0].PageCount != 1 {
+		t.Errorf("unexpected document list: %+v", list)
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	if len(content) != 2 || !strings.Contains(content[0]["text"].(string), "| figure.pdf | 1 | ") {
+		t.Errorf("unexpected text result: %v", content)
+	}
+
+	// Restricted mode keeps the listing inside PDF_INPUT_DIR
+	cfg.RestrictedMode = true
+	if _, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "list_pdfs", "arguments": map[string]interface{}{"input_dir": t.TempDir()},
+	}); err == nil || !strings.Contains(err.Error(), "restricted mode") {
+		t.Errorf("expected a directory outside PDF_INPUT_DIR rejected, got %v", err)
+	}
+}
+
+func TestHandleToolsCall_PreviewPDFText(t *testing.T) {
+	pdfPath := createFigurePDF(t)
+	outputDir := t.TempDir()
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, PDFInputDir: filepath.Dir(pdfPath), OutputBaseDir: outputDir}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+
+	result, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "preview_pdf_text", "arguments": map[string]interface{}{"pdf_path": pdfPath, "pages": "1"},
+	})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	preview, ok := result["structuredContent"].(*pdfconv.Preview)
+	if !ok {
+		t.Fatalf("expected the preview as structuredContent, got %T", result["structuredContent"])
+	}
+	if preview.Source != pdfPath || preview.PageCount != 1 || preview.Previewed != 1 || strings.Contains(preview.Markdown, "](images/") {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("expected no output written, got %d entries", len(entries))
+	}
+
+	if _, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "preview_pdf_text", "arguments": map[string]interface{}{"pdf_path": pdfPath, "pages": "x"},
+	}); err == nil || !strings.Contains(err.Error(), "invalid pages") {
+		t.Errorf("expected an invalid page selection rejected, got %v", err)
+	}
+}
+
+func TestHandleToolsCall_AsyncBatchJob(t *testing.T) {
+	inputDir := filepath.Dir(createFigurePDF(t))
+	cfg := &config.Config{BaseHeaderLevel: 1, OutputBaseDir: t.TempDir()}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+	call := func(name string, arguments map[string]interface{}) (map[string]interface{}, error) {
+		return h.handleToolsCall(context.Background(), map[string]interface{}{"name": name, "arguments": arguments})
+	}
+
+	result, err := call("convert_pdfs_in_directory", map[string]interface{}{"input_dir": inputDir, "async": true})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	queued, ok := result["structuredContent"].(JobStatus)
+	if !ok || !strings.HasPrefix(queued.ID, "job-") || queued.Tool != "convert_pdfs_in_directory" || queued.Input != inputDir {
+		t.Fatalf("expected a queued job, got %+v", result["structuredContent"])
+	}
+
+	// Another session does not see the job
+	other := h.newSession("other")
+	for _, tool := range []string{"get_job_status", "get_job_result"} {
+		if _, err := other.handleToolsCall(context.Background(), map[string]interface{}{"name": tool, "arguments": map[string]interface{}{"job_id": queued.ID}}); err == nil || !strings.Contains(err.Error(), "unknown job") {
+			t.Errorf("expected %s of another session to fail with an unknown job error, got %v", tool, err)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	var status JobStatus
+	for {
+		result, err := call("get_job_status", map[string]interface{}{"job_id": queued.ID})
+		if err != nil {
+			t.Fatalf("get_job_status error = %v", err)
+		}
+		status = result["structuredContent"].(JobStatus)
+		if status.Status == JobCompleted || status.Status == JobFailed || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status.Status != JobCompleted || status.Started == nil || status.Finished == nil || status.Error != "" {
+		t.Fatalf("expected the job completed, got %+v", status)
+	}
+
+	result, err = call("get_job_result", map[string]interface{}{"job_id": queued.ID})
+	if err != nil {
+		t.Fatalf("get_job_result error = %v", err)
+	}
+	summary, ok := result["structuredContent"].(BatchSummary)
+	if !ok || summary.FileCount != 1 || summary.SuccessCount != 1 {
+		t.Errorf("expected the batch summary as the job result, got %+v", result["structuredContent"])
+	}
+
+	if _, err := call("get_job_status", map[string]interface{}{"job_id": "job-missing"}); err == nil || !strings.Contains(err.Error(), "unknown job") {
+		t.Errorf("expected an unknown job error, got %v", err)
+	}
+}
+
+func TestHandleToolsCall_SessionDefaults(t *testing.T) {
+	h := newConformanceHandler(t)
+	outputDir := t.TempDir()
+	set := func(arguments map[string]interface{}) SessionDefaults {
+		t.Helper()
+		result, err := h.handleToolsCall(context.Background(), map[string]interface{}{"name": "set_session_defaults", "arguments": arguments})
+		if err != nil {
+			t.Fatalf("set_session_defaults error = %v", err)
+		}
+		return result["structuredContent"].(SessionDefaults)
+	}
+
+	if got := set(map[string]interface{}{"output_dir": outputDir, "preset": "fast"}); got != (SessionDefaults{OutputDir: outputDir, Preset: "fast"}) {
+		t.Errorf("expected the defaults set, got %+v", got)
+	}
+	arguments := map[string]interface{}{"pdf_path": "a.pdf"}
+	h.applySessionDefaults("convert_pdf_to_markdown", arguments)
+	if arguments["output_dir"] != outputDir || arguments["preset"] != "fast" {
+		t.Errorf("expected the session defaults filled in, got %v", arguments)
+	}
+	arguments = map[string]interface{}{"output_dir": "/explicit", "preset": ""}
+	h.applySessionDefaults("convert_pdf_to_markdown", arguments)
+	if arguments["output_dir"] != "/explicit" || arguments["preset"] != "" {
+		t.Errorf("expected the arguments of the call kept, got %v", arguments)
+	}
+	arguments = map[string]interface{}{}
+	h.applySessionDefaults("list_pdfs", arguments)
+	if len(arguments) != 0 {
+		t.Errorf("expected no defaults for arguments the tool does not declare, got %v", arguments)
+	}
+
+	if got := set(map[string]interface{}{"preset": ""}); got != (SessionDefaults{OutputDir: outputDir}) {
+		t.Errorf("expected the preset cleared, got %+v", got)
+	}
+	if got := set(map[string]interface{}{"reset": true}); got != (SessionDefaults{}) {
+		t.Errorf("expected the defaults reset, got %+v", got)
+	}
+}
+
+func TestToolAnnotations(t *testing.T) {
+	h := newConformanceHandler(t)
+	h.converter.Config().MaxOutputAgeDays = 30
+	tests := []struct {
+		tool string
+		want map[string]interface{}
+	}{
+		{"preview_pdf_text", map[string]interface{}{"readOnlyHint": true, "openWorldHint": false}},
+		{"convert_pdf_to_markdown", map[string]interface{}{"readOnlyHint": false, "openWorldHint": false, "destructiveHint": true, "idempotentHint": true}},
+		{"set_session_defaults", map[string]interface{}{"readOnlyHint": false, "openWorldHint": false, "destructiveHint": false, "idempotentHint": true}},
+	}
+	for _, tt := range tests {
+		if got := h.toolAnnotations(tt.tool); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.tool, tt.want, got)
+		}
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	q := newJobQueue(logger.NewLogger("error"), 0)
+	running, release := make(chan struct{}), make(chan struct{})
+	first, _ := q.submit("", "convert_pdfs_in_directory", "a", "", func(ctx context.Context) (map[string]interface{}, error) {
+		close(running)
+		<-release
+		return map[string]interface{}{"content": "a"}, nil
+	})
+	second, _ := q.submit("", "convert_pdfs_in_directory", "b", "", func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, pdfconv.ErrEncrypted
+	})
+
+	// Jobs run one at a time in submission order
+	<-running
+	if status, _ := q.status("", second.ID); status.Status != JobQueued || status.Position != 1 {
+		t.Errorf("expected the second job first in the queue, got %+v", status)
+	}
+	if _, err := q.result("", first.ID); err == nil || !strings.Contains(err.Error(), "poll get_job_status") {
+		t.Errorf("expected the result of an unfinished job rejected, got %v", err)
+	}
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for status, _ := q.status("", second.ID); status.Status != JobFailed && time.Now().Before(deadline); status, _ = q.status("", second.ID) {
+		time.Sleep(time.Millisecond)
+	}
+	if result, err := q.result("", first.ID); err != nil || result["content"] != "a" {
+		t.Errorf("expected the first job result, got %v, %v", result, err)
+	}
+	if _, err := q.result("", second.ID); !errors.Is(err, pdfconv.ErrEncrypted) {
+		t.Errorf("expected the error of the failed job, got %v", err)
+	}
+}
+
+func TestJobQueue_PrioritiesAndLimit(t *testing.T) {
+	q := newJobQueue(logger.NewLogger("error"), 3)
+	blockers := map[string][2]chan struct{}{
+		"blocker": {make(chan struct{}), make(chan struct{})},
+		"urgent":  {make(chan struct{}), make(chan struct{})},
+	}
+	var mu sync.Mutex
+	var order []string
+	submit := func(input, priority string) (JobStatus, error) {
+		return q.submit("", "convert_pdfs_in_directory", input, priority, func(ctx context.Context) (map[string]interface{}, error) {
+			if blocker, ok := blockers[input]; ok {
+				close(blocker[0])
+				<-blocker[1]
+			}
+			mu.Lock()
+			order = append(order, input)
+			mu.Unlock()
+			return map[string]interface{}{}, nil
+		})
+	}
+	wait := func(job JobStatus, status string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for current, _ := q.status("", job.ID); current.Status != status; current, _ = q.status("", job.ID) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected job %s %s, got %+v", job.Input, status, current)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// A high priority job does not wait for a running batch; it runs on its own lane
+	if _, err := submit("blocker", PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+	<-blockers["blocker"][0]
+	if _, err := submit("urgent", PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	<-blockers["urgent"][0]
+
+	// With both lanes busy, the jobs after them wait in the queue by priority
+	batch, _ := submit("batch", PriorityNormal)
+	background, _ := submit("background", PriorityLow)
+	single, err := submit("single", PriorityHigh)
+	if err != nil || single.Position != 1 || single.Priority != PriorityHigh {
+		t.Fatalf("expected the high priority job first in the queue, got %+v, %v", single, err)
+	}
+	if status, _ := q.status("", batch.ID); status.Position != 2 {
+		t.Errorf("expected the normal priority job second, got %+v", status)
+	}
+	if status, _ := q.status("", background.ID); status.Position != 3 || status.Priority != PriorityLow {
+		t.Errorf("expected the low priority job last, got %+v", status)
+	}
+
+	// The queue holds three waiting jobs; a fourth is turned away whatever its priority
+	if _, err := submit("overflow", PriorityHigh); !errors.Is(err, errQueueFull) || !strings.Contains(err.Error(), "MAX_QUEUED_JOBS") {
+		t.Errorf("expected a queue full error, got %v", err)
+	}
+	if _, err := submit("invalid", "urgent"); err == nil || errors.Is(err, errQueueFull) {
+		t.Errorf("expected an invalid priority rejected, got %v", err)
+	}
+
+	// The high priority lane runs the waiting high priority job but leaves the batch queued
+	close(blockers["urgent"][1])
+	wait(single, JobCompleted)
+	if status, _ := q.status("", batch.ID); status.Status != JobQueued || status.Position != 1 {
+		t.Errorf("expected the normal priority job left for the other lane, got %+v", status)
+	}
+
+	close(blockers["blocker"][1])
+	wait(background, JobCompleted)
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"urgent", "single", "blocker", "batch", "background"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected jobs run in the order %v, got %v", want, order)
+	}
+}
+
+func TestJobQueue_Sessions(t *testing.T) {
+	q := newJobQueue(logger.NewLogger("error"), 0)
+	running := make(chan struct{})
+	var mu sync.Mutex
+	var ran []string
+	submit := func(session, input string) JobStatus {
+		status, err := q.submit(session, "convert_pdfs_in_directory", input, "", func(ctx context.Context) (map[string]interface{}, error) {
+			if input == "blocker" {
+				close(running)
+				<-ctx.Done() // Runs until its session ends
+				input += " cancelled"
+			}
+			mu.Lock()
+			ran = append(ran, input)
+			mu.Unlock()
+			return map[string]interface{}{"content": input}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	blocker := submit("a", "blocker")
+	<-running
+	dropped := submit("a", "dropped")
+	kept := submit("b", "kept")
+	if _, ok := q.status("b", dropped.ID); ok {
+		t.Error("expected the job of session a hidden from session b")
+	}
+	if _, err := q.result("b", dropped.ID); err == nil || !strings.Contains(err.Error(), "unknown job") {
+		t.Errorf("expected an unknown job error for another session's job, got %v", err)
+	}
+
+	// Session a ends: its queued job is dropped and its running job cancelled and forgotten
+	q.forget("a")
+	deadline := time.Now().Add(5 * time.Second)
+	for status, _ := q.status("b", kept.ID); status.Status != JobCompleted && time.Now().Before(deadline); status, _ = q.status("b", kept.ID) {
+		time.Sleep(time.Millisecond)
+	}
+	if result, err := q.result("b", kept.ID); err != nil || result["content"] != "kept" {
+		t.Errorf("expected the result of session b's job, got %v, %v", result, err)
+	}
+	for _, id := range []string{blocker.ID, dropped.ID} {
+		if _, ok := q.status("a", id); ok {
+			t.Errorf("expected job %s of the ended session forgotten", id)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"blocker cancelled", "kept"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("expected jobs %v run, got %v", want, ran)
+	}
+}
+
+func TestHandleToolsCall_AsyncDocumentJob(t *testing.T) {
+	pdfPath := createFigurePDF(t)
+	cfg := &config.Config{BaseHeaderLevel: 1, OutputBaseDir: t.TempDir(), MaxQueuedJobs: 1}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	h := NewMCPHandler(converter, log)
+
+	// Hold the worker with a job of its own, so the submissions below wait in the queue
+	running, release := make(chan struct{}), make(chan struct{})
+	if _, err := h.jobs.submit("", "test", "blocker", PriorityLow, func(ctx context.Context) (map[string]interface{}, error) {
+		close(running)
+		<-release
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-running
+
+	result, err := h.handleToolsCall(context.Background(), map[string]interface{}{
+		"name": "convert_pdf_to_markdown", "arguments": map[string]interface{}{"pdf_path": pdfPath, "async": true},
+	})
+	if err != nil {
+		t.Fatalf("handleToolsCall() error = %v", err)
+	}
+	queued, _ := result["structuredContent"].(JobStatus)
+	if queued.Priority != PriorityHigh || queued.Position != 1 || queued.Input != pdfPath {
+		t.Errorf("expected a queued high priority document job, got %+v", result["structuredContent"])
+	}
+
+	response := h.callTool(context.Background(), &MCPMessage{JSONRPC: "2.0", ID: 1.0, Method: "tools/call", Params: map[string]interface{}{
+		"name": "convert_pdfs_in_directory", "arguments": map[string]interface{}{"input_dir": filepath.Dir(pdfPath), "async": true, "priority": "low"},
+	}})
+	if response.Error == nil || !reflect.DeepEqual(response.Error.Data, map[string]interface{}{"code": "queue_full"}) {
+		t.Errorf("expected a queue_full error when the queue is full, got %+v", response.Error)
+	}
+
+	// The document job converts once the worker is free
+	close(release)
+	deadline := time.Now().Add(10 * time.Second)
+	for status, _ := h.jobs.status("", queued.ID); status.Status != JobCompleted && status.Status != JobFailed && time.Now().Before(deadline); status, _ = h.jobs.status("", queued.ID) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := h.jobs.result("", queued.ID); err != nil {
+		t.Errorf("expected the document converted by the job, got %v", err)
+	}
+}
@@ -4,12 +4,19 @@
 package mcp
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"datasheet-to-md-mcp/config"
 	"datasheet-to-md-mcp/logger"
 	"datasheet-to-md-mcp/pdfconv"
 )
@@ -19,6 +26,33 @@ import (
 type MCPHandler struct {
 	converter *pdfconv.PDFConverter // PDF conversion engine for processing tool calls
 	logger    *logger.Logger        // Logger for tracking MCP operations
+	stats     *serverStats          // Execution statistics reported by get_server_stats
+	updates   *updateStatus         // Result of the opt-in startup update check
+	jobs      *jobQueue             // Asynchronous jobs; the queue is shared, each job belongs to its session
+	session   string                // MCP session ID of a network session, "" for stdio
+
+	in           *messageReader // Client messages, read by the reader goroutine of serve
+	out          *json.Encoder  // Messages to the client, written through send
+	outMu        sync.Mutex     // Serializes writes to out, which log notifications make from any goroutine
+	toolCalls    int            // Tool calls run at the same time on workers; 0 runs them in turn with other messages
+	disconnected chan struct{}  // Closed when the client closed the connection
+	trace        *tracer        // Trace file of MCP_TRACE, nil when tracing is off
+	clientLog    *clientLog     // Log sink streaming to the client, nil until it sets a level
+
+	// relate tells the transport the tool call a request to the client is made for; nil when
+	// the transport sends all messages the same way
+	relate func(request, call interface{})
+
+	// Guarded by mu, since tool calls running on workers share them
+	mu             sync.Mutex
+	requestID      int                           // Last ID used for a request sent to the client
+	awaiting       map[string]chan MCPMessage    // Responses awaited by requests sent to the client, by request key
+	running        map[string]context.CancelFunc // Cancels the tool calls running on workers, by request key
+	clientSampling bool                          // Whether the client declared the sampling capability
+	clientRoots    bool                          // Whether the client declared the roots capability
+	roots          []string                      // Directories of the client's roots; nil when the client provided none
+	rootsPending   bool                          // Whether the client declared roots but has not listed them yet
+	defaults       SessionDefaults               // Defaults the client set with set_session_defaults
 }
 
 // MCPMessage represents a generic MCP protocol message that can be either a request or response.
@@ -30,6 +64,51 @@ type MCPMessage struct {
 	Params  map[string]interface{} `json:"params,omitempty"`
 	Result  map[string]interface{} `json:"result,omitempty"`
 	Error   *MCPError              `json:"error,omitempty"`
+
+	hasID bool // Whether a received message carried an ID; requests without one are notifications
+}
+
+// UnmarshalJSON decodes a message, recording whether it carried an ID.
+func (m *MCPMessage) UnmarshalJSON(data []byte) error {
+	type plain MCPMessage
+	var wire struct {
+		plain
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*m = MCPMessage(wire.plain)
+	m.hasID = wire.ID != nil
+	if m.hasID {
+		return json.Unmarshal(wire.ID, &m.ID)
+	}
+	return nil
+}
+
+// MarshalJSON encodes a message. Responses always carry an ID, null when the request's ID
+// could not be read, and a result unless they report an error.
+func (m MCPMessage) MarshalJSON() ([]byte, error) {
+	type plain MCPMessage
+	if m.Method != "" {
+		return json.Marshal(plain(m))
+	}
+	if m.Error != nil {
+		return json.Marshal(struct {
+			plain
+			ID interface{} `json:"id"`
+		}{plain(m), m.ID})
+	}
+	// An empty result, such as that of ping, would be dropped by omitempty
+	result := m.Result
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	return json.Marshal(struct {
+		plain
+		ID     interface{}            `json:"id"`
+		Result map[string]interface{} `json:"result"`
+	}{plain(m), m.ID, result})
 }
 
 // MCPError represents an error response in the MCP protocol
@@ -41,53 +120,129 @@ type MCPError struct {
 
 // NewMCPHandler creates a new MCP message handler with the specified converter and logger.
 func NewMCPHandler(converter *pdfconv.PDFConverter, logger *logger.Logger) *MCPHandler {
-	return &MCPHandler{
+	h := &MCPHandler{
 		converter: converter,
 		logger:    logger,
+		stats:     newServerStats(),
+		updates:   &updateStatus{},
+		jobs:      newJobQueue(logger, converter.Config().MaxQueuedJobs),
+	}
+	if cfg := converter.Config(); cfg.Trace {
+		trace, err := newTracer(cfg.TraceFile)
+		if err != nil {
+			logger.Warn("MCP tracing disabled: %v", err)
+		} else {
+			h.trace = trace
+			logger.Info("Tracing MCP messages to %s", cfg.TraceFile)
+		}
+	}
+	return h
+}
+
+// newSession returns a handler for the client connection of session id. It shares the
+// converter, statistics, update check result and job queue, while the connection state,
+// session defaults and jobs are its own. Like over stdio, its tool calls run on
+// MAX_CONCURRENT_TOOL_CALLS workers.
+func (h *MCPHandler) newSession(id string) *MCPHandler {
+	return &MCPHandler{
+		converter: h.converter,
+		logger:    h.logger,
+		stats:     h.stats,
+		updates:   h.updates,
+		jobs:      h.jobs,
+		trace:     h.trace,
+		session:   id,
+		toolCalls: max(h.converter.Config().MaxToolCalls, 1),
 	}
 }
 
-// HandleStdio processes MCP messages using standard input/output communication.
+// HandleStdio processes MCP messages using standard input/output communication. Tool calls
+// run on MAX_CONCURRENT_TOOL_CALLS workers and their responses are sent as they finish,
+// interleaved with the responses to other requests.
 func (h *MCPHandler) HandleStdio() error {
 	h.logger.Debug("Starting STDIO message handling")
+	h.toolCalls = max(h.converter.Config().MaxToolCalls, 1)
 
-	scanner := bufio.NewScanner(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
+	if err := h.serve(os.Stdin, os.Stdout); err != nil {
+		return fmt.Errorf("error reading from stdin: %v", err)
+	}
+	return nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
+// serve reads client messages from in, one per line, and writes the responses to out
+// until in is closed. Tool calls still running then are answered before serve returns.
+func (h *MCPHandler) serve(in io.Reader, out io.Writer) error {
+	h.in = newMessageReader(in, h.converter.Config().MaxMessageSizeMB)
+	h.in.trace = h.trace
+	if h.trace != nil {
+		out = tracingWriter{w: out, trace: h.trace}
+	}
+	h.out = json.NewEncoder(out)
+	h.disconnected = make(chan struct{})
+	h.mu.Lock()
+	h.awaiting = map[string]chan MCPMessage{}
+	h.running = map[string]context.CancelFunc{}
+	h.mu.Unlock()
+	defer h.stopClientLog()
 
-		h.logger.Debug("Received message: %s", line)
+	messages := newInbox()
+	go h.readMessages(messages)
+	var workers *toolCallPool
+	if h.toolCalls > 0 {
+		workers = h.newToolCallPool(h.toolCalls)
+		defer workers.wait()
+	}
 
-		var message MCPMessage
-		if err := json.Unmarshal([]byte(line), &message); err != nil {
-			h.logger.Error("Failed to parse message: %v", err)
-			errorResponse := MCPMessage{JSONRPC: "2.0", Error: &MCPError{Code: -32700, Message: "Parse error", Data: err.Error()}}
-			_ = encoder.Encode(errorResponse)
-			continue
+	for {
+		message, err := messages.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
 		}
 
-		response := h.processMessage(&message)
+		if workers != nil && message.Method == "tools/call" && validateRequest(&message) == nil {
+			workers.start(message)
+			continue
+		}
+		response, ok := h.processMessage(&message)
+		if !ok {
+			continue
+		}
 
-		if err := encoder.Encode(response); err != nil {
+		if err := h.send(response); err != nil {
 			h.logger.Error("Failed to send response: %v", err)
 		}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading from stdin: %v", err)
+// send writes a message to the client. It is safe for concurrent use, so log notifications
+// can be sent while a tool call runs on another goroutine.
+func (h *MCPHandler) send(message MCPMessage) error {
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+	if h.out == nil {
+		return fmt.Errorf("no client connection")
 	}
-
-	return nil
+	return h.out.Encode(message)
 }
 
-// processMessage handles the core MCP message processing logic.
-func (h *MCPHandler) processMessage(message *MCPMessage) MCPMessage {
-	if message.JSONRPC == "" {
-		message.JSONRPC = "2.0"
+// processMessage handles the core MCP message processing logic. It reports false when the
+// message must not be answered: notifications and responses to the server's own requests.
+func (h *MCPHandler) processMessage(message *MCPMessage) (MCPMessage, bool) {
+	// Late responses to requests the server no longer waits for need no reply
+	if message.Method == "" && (message.Result != nil || message.Error != nil) {
+		return MCPMessage{}, false
+	}
+
+	if err := validateRequest(message); err != nil {
+		h.logger.Warn("Invalid request: %v", err)
+		response := MCPMessage{JSONRPC: "2.0", Error: &MCPError{Code: -32600, Message: "Invalid Request", Data: err.Error()}}
+		if validID(message.ID) {
+			response.ID = message.ID
+		}
+		return response, true
 	}
 
 	response := MCPMessage{JSONRPC: "2.0", ID: message.ID}
@@ -102,172 +257,1273 @@ func (h *MCPHandler) processMessage(message *MCPMessage) MCPMessage {
 		h.logger.Debug("Sent tools list")
 
 	case "tools/call":
-		result, err := h.handleToolsCall(message.Params)
+		response = h.callTool(context.Background(), message)
+
+	case "prompts/list":
+		response.Result = h.handlePromptsList()
+		h.logger.Debug("Sent prompts list")
+
+	case "prompts/get":
+		result, err := h.handlePromptsGet(message.Params)
+		if err != nil {
+			response.Error = &MCPError{Code: -32602, Message: err.Error()}
+			h.logger.Warn("Prompt request failed: %v", err)
+		} else {
+			response.Result = result
+		}
+
+	case "completion/complete":
+		result, err := h.handleComplete(message.Params)
 		if err != nil {
-			response.Error = &MCPError{Code: -32603, Message: err.Error()}
-			h.logger.Error("Tool call failed: %v", err)
+			response.Error = &MCPError{Code: -32602, Message: err.Error()}
 		} else {
 			response.Result = result
-			h.logger.Info("Tool call completed successfully")
 		}
 
-	case "notifications/initialized":
-		return MCPMessage{}
+	case "logging/setLevel":
+		result, err := h.handleSetLevel(message.Params)
+		if err != nil {
+			response.Error = &MCPError{Code: -32602, Message: err.Error()}
+		} else {
+			response.Result = result
+		}
+
+	case "ping":
+		response.Result = map[string]interface{}{}
+
+	case "notifications/initialized", "notifications/roots/list_changed":
+		h.mu.Lock()
+		clientRoots := h.clientRoots
+		h.mu.Unlock()
+		if clientRoots {
+			h.loadRoots()
+		}
+
+	case "notifications/cancelled":
+		// Only tool calls running on workers can still be cancelled; other requests are
+		// answered before the next message is read
+		h.cancelRequest(message.Params)
 
 	default:
+		if !message.hasID {
+			h.logger.Debug("Ignoring notification: %s", message.Method)
+			break
+		}
 		response.Error = &MCPError{Code: -32601, Message: fmt.Sprintf("Method not found: %s", message.Method)}
 		h.logger.Warn("Unknown method requested: %s", message.Method)
 	}
 
+	// Notifications are never answered, not even with an error
+	if !message.hasID {
+		return MCPMessage{}, false
+	}
+	return response, true
+}
+
+// callTool runs a tool call request and returns its response. ctx cancels conversions, and
+// a call running longer than CONVERSION_TIMEOUT is stopped with a timeout error. Conversions
+// stage their output, so a stopped call leaves no partial output directory behind. Arguments
+// that do not match the tool's inputSchema are answered with an invalid params error.
+func (h *MCPHandler) callTool(ctx context.Context, message *MCPMessage) MCPMessage {
+	response := MCPMessage{JSONRPC: "2.0", ID: message.ID}
+	timeout := time.Duration(h.converter.Config().ToolTimeout) * time.Second
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result, err := h.handleToolsCall(ctx, message.Params)
+	var invalid *argumentError
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		response.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("Tool call timed out after %s (CONVERSION_TIMEOUT)", timeout), Data: map[string]interface{}{"code": "timeout", "timeout_seconds": int(timeout.Seconds()), "detail": err.Error()}}
+		h.logger.Error("Tool call timed out after %s: %v", timeout, err)
+	case errors.As(err, &invalid):
+		response.Error = &MCPError{Code: invalidParamsCode, Message: err.Error(), Data: invalid.data()}
+		h.logger.Warn("Tool call rejected: %v", err)
+	case err != nil:
+		response.Error = &MCPError{Code: -32603, Message: err.Error(), Data: toolErrorData(err)}
+		h.logger.Error("Tool call failed: %v", err)
+	default:
+		response.Result = result
+		h.logger.Info("Tool call completed successfully")
+	}
 	return response
 }
 
+// validateRequest checks that a request or notification is a JSON-RPC 2.0 request object.
+func validateRequest(message *MCPMessage) error {
+	switch {
+	case message.JSONRPC != "2.0":
+		return fmt.Errorf("jsonrpc must be \"2.0\", got %q", message.JSONRPC)
+	case message.Method == "":
+		return fmt.Errorf("method is missing")
+	case message.hasID && message.ID == nil:
+		return fmt.Errorf("id must not be null")
+	case message.hasID && !validID(message.ID):
+		return fmt.Errorf("id must be a string or a number")
+	}
+	return nil
+}
+
+// validID reports whether id is a string or number, the IDs MCP allows.
+func validID(id interface{}) bool {
+	switch id.(type) {
+	case string, float64:
+		return true
+	}
+	return false
+}
+
+// toolErrorData returns the error data of a failed tool call: the failure class of err from
+// pdfconv.ErrorCode, or queue_full for a job the queue rejected, so clients can branch on
+// the cause, or nil for unclassified errors.
+func toolErrorData(err error) interface{} {
+	if errors.Is(err, errQueueFull) {
+		return map[string]interface{}{"code": "queue_full"}
+	}
+	code := pdfconv.ErrorCode(err)
+	if code == "" {
+		return nil
+	}
+	return map[string]interface{}{"code": code}
+}
+
 // handleInitialize processes the MCP initialize request and returns server capabilities.
 func (h *MCPHandler) handleInitialize(params map[string]interface{}) map[string]interface{} {
+	if capabilities, ok := params["capabilities"].(map[string]interface{}); ok {
+		h.mu.Lock()
+		h.clientSampling = capabilities["sampling"] != nil
+		h.clientRoots = capabilities["roots"] != nil
+		h.rootsPending = h.clientRoots
+		h.mu.Unlock()
+	}
+	capabilities := map[string]interface{}{"tools": map[string]interface{}{}, "prompts": map[string]interface{}{}, "completions": map[string]interface{}{}}
+	if !h.restricted() {
+		capabilities["logging"] = map[string]interface{}{}
+	}
 	return map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
-		"serverInfo":      map[string]interface{}{"name": "pdf-to-markdown-server", "version": "1.0.0"},
+		"protocolVersion": protocolVersion,
+		"capabilities":    capabilities,
+		"serverInfo":      map[string]interface{}{"name": h.converter.Config().ServerName, "version": h.converter.Config().ServerVersion},
+	}
+}
+
+// Tool parameters selecting the output format, shared by the conversion tools.
+var (
+	outputFormatParameter   = map[string]interface{}{"type": "string", "enum": config.OutputFormats, "description": "Document format to write, overriding OUTPUT_FORMAT for this call (optional)"}
+	markdownFlavorParameter = map[string]interface{}{"type": "string", "enum": config.MarkdownFlavors, "description": "Markdown flavor to write, overriding MARKDOWN_FLAVOR for this call (optional)"}
+	expectedSHA256Parameter = map[string]interface{}{"type": "string", "description": "Expected SHA-256 of pdf_path as a hex digest; the call fails without converting when the file does not match (optional)"}
+	priorityParameter       = map[string]interface{}{"type": "string", "enum": JobPriorities, "description": "Queue priority of an async job: high jobs run before normal and low ones and do not wait for a running job (optional, default high for single documents and normal for directories)"}
+	presetParameter         = map[string]interface{}{"type": "string", "enum": config.Presets, "description": "Named bundle of conversion settings for this call, replacing the configured settings it covers (optional)"}
+)
+
+// toolCapabilities maps tools to the optional external tool they cannot work without.
+var toolCapabilities = map[string]string{
+	"convert_images_to_markdown": pdfconv.CapabilityOCR,
+}
+
+// toolHint describes how a tool affects its environment, for the MCP tool annotations.
+type toolHint struct {
+	readOnly    bool // The tool does not modify anything
+	idempotent  bool // Repeating a call with the same arguments has no further effect
+	sessionOnly bool // The tool only modifies the state of the session, never files
+}
+
+// toolHints maps tools to their behavior. Conversions write output directories and repeat
+// calls only replace the output of the same document.
+var toolHints = map[string]toolHint{
+	"convert_pdf_to_markdown":    {idempotent: true},
+	"convert_pdf_pages":          {idempotent: true},
+	"convert_pdfs_in_directory":  {idempotent: true},
+	"convert_images_to_markdown": {idempotent: true},
+	"split_pdf_by_sections":      {idempotent: true},
+	"find_datasheet":             {readOnly: true},
+	"extract_pdf_metadata":       {readOnly: true},
+	"list_pdfs":                  {readOnly: true},
+	"preview_pdf_text":           {readOnly: true},
+	"get_server_version":         {readOnly: true},
+	"get_server_stats":           {readOnly: true},
+	"get_library_stats":          {readOnly: true},
+	"get_job_status":             {readOnly: true},
+	"get_job_result":             {readOnly: true},
+	"set_session_defaults":       {idempotent: true, sessionOnly: true},
+}
+
+// toolAnnotations returns the MCP annotations of a tool, so clients can apply confirmation
+// policies without knowing the tools. No tool reaches services outside the local machine.
+// Tools writing files are destructive when the output retention policy is enabled, since a
+// conversion may then remove older outputs.
+func (h *MCPHandler) toolAnnotations(name string) map[string]interface{} {
+	hint := toolHints[name]
+	cfg := h.converter.Config()
+	annotations := map[string]interface{}{
+		"readOnlyHint":  hint.readOnly,
+		"openWorldHint": false,
 	}
+	if !hint.readOnly {
+		annotations["destructiveHint"] = !hint.sessionOnly && (cfg.MaxOutputAgeDays > 0 || cfg.MaxOutputTotalGB > 0)
+		annotations["idempotentHint"] = hint.idempotent
+	}
+	return annotations
 }
 
-// handleToolsList returns the list of available tools.
+// handleToolsList returns the list of available tools. Tools whose optional external tool
+// was not found at startup, and tools disabled by RESTRICTED_MODE, are left out.
 func (h *MCPHandler) handleToolsList() map[string]interface{} {
-	return map[string]interface{}{
-		"tools": []map[string]interface{}{
-			{
-				"name":        "convert_pdf_to_markdown",
-				"description": "Convert a single PDF file to Markdown format with extracted images",
-				"inputSchema": map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"pdf_path":   map[string]interface{}{"type": "string", "description": "Path to the input PDF file"},
-						"output_dir": map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
-					},
-					"required": []string{"pdf_path"},
+	tools := h.toolDefinitions()
+	available := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		if !h.toolAllowed(tool["name"].(string)) {
+			continue
+		}
+		if capability, ok := toolCapabilities[tool["name"].(string)]; ok && !h.converter.HasCapability(capability) {
+			continue
+		}
+		tool["annotations"] = h.toolAnnotations(tool["name"].(string))
+		available = append(available, tool)
+	}
+	return map[string]interface{}{"tools": available}
+}
+
+// toolProperties returns the parameters a tool declares in its inputSchema, nil for an
+// unknown tool.
+func (h *MCPHandler) toolProperties(toolName string) map[string]interface{} {
+	for _, tool := range h.toolDefinitions() {
+		if tool["name"] == toolName {
+			schema, _ := tool["inputSchema"].(map[string]interface{})
+			properties, _ := schema["properties"].(map[string]interface{})
+			return properties
+		}
+	}
+	return nil
+}
+
+// toolDefinitions returns the name, description and inputSchema of every tool, whether or
+// not it is available in this configuration.
+func (h *MCPHandler) toolDefinitions() []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"name":        "convert_pdf_to_markdown",
+			"description": h.text(msgToolConvertPDF),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pdf_path":        map[string]interface{}{"type": "string", "description": "Path to the input PDF, XPS, OXPS or DjVu file"},
+					"output_dir":      map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
+					"verbatim":        map[string]interface{}{"type": "boolean", "description": "Preserve original line breaks and spacing of every page inside fenced blocks (optional)"},
+					"verbatim_pages":  map[string]interface{}{"type": "string", "description": "Pages to preserve verbatim, e.g. \"3,7-9\" (optional)"},
+					"dry_run":         map[string]interface{}{"type": "boolean", "description": "Only estimate conversion time and output size from a sample of the first pages, without writing output (optional)"},
+					"async":           map[string]interface{}{"type": "boolean", "description": "Queue the conversion as a job and return its job ID right away; poll get_job_status and fetch the result with get_job_result (optional)"},
+					"priority":        priorityParameter,
+					"expected_sha256": expectedSHA256Parameter,
+					"output_format":   outputFormatParameter,
+					"markdown_flavor": markdownFlavorParameter,
+					"preset":          presetParameter,
+				},
+				"required": []string{"pdf_path"},
+			},
+		},
+		{
+			"name":        "convert_pdf_pages",
+			"description": h.text(msgToolConvertPages),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pdf_path":        map[string]interface{}{"type": "string", "description": "Path to the input PDF, XPS, OXPS or DjVu file"},
+					"pages":           map[string]interface{}{"type": "string", "description": "Pages to convert, e.g. \"1-10,15,20-\": N, N-M, N- (to the last page) or -M (from the first page)"},
+					"output_dir":      map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
+					"expected_sha256": expectedSHA256Parameter,
+					"output_format":   outputFormatParameter,
+					"markdown_flavor": markdownFlavorParameter,
+					"preset":          presetParameter,
+				},
+				"required": []string{"pdf_path", "pages"},
+			},
+		},
+		{
+			"name":        "convert_pdfs_in_directory",
+			"description": h.text(msgToolConvertDirectory),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"input_dir":       map[string]interface{}{"type": "string", "description": "Directory path containing PDF files to process"},
+					"output_dir":      map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
+					"dry_run":         map[string]interface{}{"type": "boolean", "description": "Only estimate conversion time and output size of every file, without writing output (optional)"},
+					"async":           map[string]interface{}{"type": "boolean", "description": "Queue the conversion as a job and return its job ID right away; poll get_job_status and fetch the result with get_job_result (optional)"},
+					"priority":        priorityParameter,
+					"output_format":   outputFormatParameter,
+					"markdown_flavor": markdownFlavorParameter,
+					"preset":          presetParameter,
 				},
+				"required": []string{"input_dir"},
 			},
-			{
-				"name":        "convert_pdfs_in_directory",
-				"description": "Convert all PDF files in a directory to Markdown format with extracted images",
-				"inputSchema": map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"input_dir":  map[string]interface{}{"type": "string", "description": "Directory path containing PDF files to process"},
-						"output_dir": map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
-					},
-					"required": []string{"input_dir"},
+		},
+		{
+			"name":        "convert_images_to_markdown",
+			"description": h.text(msgToolConvertImages),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"input_dir":       map[string]interface{}{"type": "string", "description": "Directory containing the page scans, one image per page"},
+					"output_dir":      map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
+					"output_format":   outputFormatParameter,
+					"markdown_flavor": markdownFlavorParameter,
+					"preset":          presetParameter,
+				},
+				"required": []string{"input_dir"},
+			},
+		},
+		{
+			"name":        "split_pdf_by_sections",
+			"description": h.text(msgToolSplitPDF),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pdf_path":        map[string]interface{}{"type": "string", "description": "Path to the input PDF file"},
+					"output_dir":      map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
+					"expected_sha256": expectedSHA256Parameter,
+					"preset":          presetParameter,
+				},
+				"required": []string{"pdf_path"},
+			},
+		},
+		{
+			"name":        "find_datasheet",
+			"description": h.text(msgToolFindDatasheet),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query":     map[string]interface{}{"type": "string", "description": "Part number or keywords, e.g. \"LM317\" or \"STM32F4 reference manual\""},
+					"input_dir": map[string]interface{}{"type": "string", "description": "Directory to search (optional, uses PDF_INPUT_DIR if not provided)"},
+					"limit":     map[string]interface{}{"type": "integer", "minimum": 1, "description": "Maximum number of candidates (optional, default 10)"},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			"name":        "extract_pdf_metadata",
+			"description": h.text(msgToolExtractMetadata),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pdf_path": map[string]interface{}{"type": "string", "description": "Path to the input PDF file"},
+				},
+				"required": []string{"pdf_path"},
+			},
+		},
+		{
+			"name":        "list_pdfs",
+			"description": h.text(msgToolListPDFs),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"input_dir": map[string]interface{}{"type": "string", "description": "Directory to list (optional, uses PDF_INPUT_DIR if not provided)"},
+				},
+			},
+		},
+		{
+			"name":        "preview_pdf_text",
+			"description": h.text(msgToolPreviewText),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pdf_path":  map[string]interface{}{"type": "string", "description": "Path to the input PDF file"},
+					"pages":     map[string]interface{}{"type": "string", "description": "Pages to preview, such as \"1-10,15,20-\" (optional, default all pages)"},
+					"max_chars": map[string]interface{}{"type": "integer", "minimum": 1, "description": fmt.Sprintf("Maximum length of the returned Markdown (optional, default %d)", pdfconv.DefaultPreviewMaxChars)},
+				},
+				"required": []string{"pdf_path"},
+			},
+		},
+		{
+			"name":        "get_job_status",
+			"description": h.text(msgToolJobStatus),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"job_id": map[string]interface{}{"type": "string", "description": "Job ID returned by an asynchronous tool call"},
+				},
+				"required": []string{"job_id"},
+			},
+		},
+		{
+			"name":        "get_job_result",
+			"description": h.text(msgToolJobResult),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"job_id": map[string]interface{}{"type": "string", "description": "Job ID returned by an asynchronous tool call"},
+				},
+				"required": []string{"job_id"},
+			},
+		},
+		{
+			"name":        "set_session_defaults",
+			"description": h.text(msgToolSessionDefaults),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"output_dir": map[string]interface{}{"type": "string", "description": "Base output directory of this session's calls that pass none (optional, empty to use the config default)"},
+					"preset":     map[string]interface{}{"type": "string", "enum": config.Presets, "description": "Preset of this session's conversions that pass none (optional, empty for the configured settings)"},
+					"reset":      map[string]interface{}{"type": "boolean", "description": "Clear the session defaults before applying the other arguments (optional)"},
+				},
+			},
+		},
+		{
+			"name":        "get_server_version",
+			"description": h.text(msgToolServerVersion),
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "get_server_stats",
+			"description": h.text(msgToolServerStats),
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "get_library_stats",
+			"description": h.text(msgToolLibraryStats),
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"output_dir": map[string]interface{}{"type": "string", "description": "Base output directory (optional, uses config default if not provided)"},
 				},
 			},
 		},
 	}
 }
 
-// handleToolsCall executes a tool call request.
-func (h *MCPHandler) handleToolsCall(params map[string]interface{}) (map[string]interface{}, error) {
+// handleToolsCall executes a tool call request. ctx cancels the conversion of the call.
+func (h *MCPHandler) handleToolsCall(ctx context.Context, params map[string]interface{}) (result map[string]interface{}, err error) {
 	toolName, ok := params["name"].(string)
 	if !ok {
 		return nil, fmt.Errorf("missing tool name")
 	}
 	arguments, ok := params["arguments"].(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("missing tool arguments")
+		if params["arguments"] != nil {
+			return nil, fmt.Errorf("missing tool arguments")
+		}
+		arguments = map[string]interface{}{} // tools without parameters may omit arguments
+	}
+
+	start := time.Now()
+	h.stats.begin()
+	defer func() { h.stats.end(toolName, time.Since(start), err) }()
+
+	if !h.toolAllowed(toolName) {
+		return nil, fmt.Errorf("tool %s is disabled in restricted mode", toolName)
+	}
+	if capability, ok := toolCapabilities[toolName]; ok {
+		if err := h.converter.RequireCapability(capability); err != nil {
+			return nil, fmt.Errorf("tool %s is not available: %v", toolName, err)
+		}
+	}
+	if err := h.validateArguments(toolName, arguments); err != nil {
+		return nil, err
+	}
+	h.applySessionDefaults(toolName, arguments)
+	if err := h.applyRoots(toolName, arguments); err != nil {
+		return nil, err
 	}
 
 	switch toolName {
 	case "convert_pdf_to_markdown":
-		pdfPath, ok := arguments["pdf_path"].(string)
-		if !ok {
-			return nil, fmt.Errorf("missing required parameter: pdf_path")
+		pdfPath, _ := arguments["pdf_path"].(string)
+		outputDir := h.converter.Config().OutputBaseDir
+		if providedDir, exists := arguments["output_dir"].(string); exists {
+			outputDir = providedDir
+		}
+		if pdfPath, err = h.sandboxPath(h.converter.Config().PDFInputDir, pdfPath, "pdf_path"); err != nil {
+			return nil, err
+		}
+		if outputDir, err = h.sandboxPath(h.converter.Config().OutputBaseDir, outputDir, "output_dir"); err != nil {
+			return nil, err
+		}
+		if err := verifyChecksum(arguments, pdfPath); err != nil {
+			return nil, err
+		}
+		conv, err := h.presetConverter(arguments)
+		if err != nil {
+			return nil, err
+		}
+		opts := pdfconv.ConversionOptions{Context: ctx, Captioner: h.imageCaptioner(ctx)}
+		if err := outputOptions(arguments, &opts); err != nil {
+			return nil, err
+		}
+		if verbatim, exists := arguments["verbatim"].(bool); exists {
+			opts.Verbatim = verbatim
+		}
+		if expr, exists := arguments["verbatim_pages"].(string); exists && expr != "" {
+			pages, err := pdfconv.ParsePageSelection(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid verbatim_pages: %v", err)
+			}
+			opts.VerbatimPages = pages
+		}
+		if dryRun, _ := arguments["dry_run"].(bool); dryRun {
+			h.logger.Info("Estimating PDF conversion: %s", pdfPath)
+			estimate, err := conv.EstimateConversion(pdfPath)
+			if err != nil {
+				return nil, fmt.Errorf("estimate failed: %w", err)
+			}
+			return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.formatConversionEstimate(estimate)}}}, nil
+		}
+		convert := func(ctx context.Context) (map[string]interface{}, error) {
+			start := time.Now()
+			opts.Context = ctx
+			if conv.IsPortfolio(pdfPath) {
+				h.logger.Info("Executing PDF portfolio conversion: %s -> %s", pdfPath, outputDir)
+				batchResult, err := conv.ConvertPortfolio(pdfPath, outputDir, opts)
+				if err != nil {
+					return nil, fmt.Errorf("conversion failed: %w", err)
+				}
+				h.stats.recordConversion(batchResult.SuccessCount, batchResult.TotalPageCount, batchResult.TotalImageCount, time.Since(start))
+				return h.batchToolResult(batchResult), nil
+			}
+			h.logger.Info("Executing single PDF conversion: %s -> %s", pdfPath, outputDir)
+			convResult, err := conv.ConvertDocument(pdfPath, outputDir, opts)
+			if err != nil {
+				return nil, fmt.Errorf("conversion failed: %w", err)
+			}
+			h.stats.recordConversion(1, convResult.PageCount, convResult.ImageCount, time.Since(start))
+			return h.conversionToolResult(convResult), nil
+		}
+		if async, _ := arguments["async"].(bool); async {
+			if h.restricted() {
+				return nil, fmt.Errorf("async is disabled in restricted mode, which offers no job tools")
+			}
+			// The client may be gone when the job runs, so images are not captioned through it
+			opts.Captioner = nil
+			status, err := h.jobs.submit(h.session, toolName, pdfPath, jobPriority(arguments, PriorityHigh), h.jobTimeout(convert))
+			if err != nil {
+				return nil, err
+			}
+			return structuredToolResult(h.textf(msgDocumentJobQueued, pdfPath, status.ID, status.Position), status), nil
+		}
+		return convert(ctx)
+
+	case "convert_pdf_pages":
+		pdfPath, _ := arguments["pdf_path"].(string)
+		expr, _ := arguments["pages"].(string)
+		pages, err := pdfconv.ParsePageSelection(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pages: %v", err)
 		}
 		outputDir := h.converter.Config().OutputBaseDir
 		if providedDir, exists := arguments["output_dir"].(string); exists {
 			outputDir = providedDir
 		}
-		h.logger.Info("Executing single PDF conversion: %s -> %s", pdfPath, outputDir)
-		result, err := h.converter.ConvertPDF(pdfPath, outputDir)
+		if pdfPath, err = h.sandboxPath(h.converter.Config().PDFInputDir, pdfPath, "pdf_path"); err != nil {
+			return nil, err
+		}
+		if outputDir, err = h.sandboxPath(h.converter.Config().OutputBaseDir, outputDir, "output_dir"); err != nil {
+			return nil, err
+		}
+		if err := verifyChecksum(arguments, pdfPath); err != nil {
+			return nil, err
+		}
+		conv, err := h.presetConverter(arguments)
 		if err != nil {
-			return nil, fmt.Errorf("conversion failed: %v", err)
+			return nil, err
+		}
+		opts := pdfconv.ConversionOptions{Pages: pages, Context: ctx, Captioner: h.imageCaptioner(ctx)}
+		if err := outputOptions(arguments, &opts); err != nil {
+			return nil, err
 		}
-		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.formatConversionResult(result)}}}, nil
+		h.logger.Info("Executing PDF page conversion: %s (pages %s) -> %s", pdfPath, pages, outputDir)
+		convResult, err := conv.ConvertDocument(pdfPath, outputDir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("conversion failed: %w", err)
+		}
+		h.stats.recordConversion(1, convResult.PageCount, convResult.ImageCount, time.Since(start))
+		return h.conversionToolResult(convResult), nil
 
 	case "convert_pdfs_in_directory":
-		inputDir, ok := arguments["input_dir"].(string)
+		inputDir, _ := arguments["input_dir"].(string)
+		outputDir := h.converter.Config().OutputBaseDir
+		if providedDir, exists := arguments["output_dir"].(string); exists {
+			outputDir = providedDir
+		}
+		if inputDir, err = h.sandboxPath(h.converter.Config().PDFInputDir, inputDir, "input_dir"); err != nil {
+			return nil, err
+		}
+		if outputDir, err = h.sandboxPath(h.converter.Config().OutputBaseDir, outputDir, "output_dir"); err != nil {
+			return nil, err
+		}
+		conv, err := h.presetConverter(arguments)
+		if err != nil {
+			return nil, err
+		}
+		if dryRun, _ := arguments["dry_run"].(bool); dryRun {
+			h.logger.Info("Estimating batch PDF conversion: %s", inputDir)
+			estimate, err := conv.EstimateDirectory(inputDir)
+			if err != nil {
+				return nil, fmt.Errorf("estimate failed: %w", err)
+			}
+			return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.formatBatchEstimate(estimate)}}}, nil
+		}
+		opts := pdfconv.ConversionOptions{}
+		if err := outputOptions(arguments, &opts); err != nil {
+			return nil, err
+		}
+		convert := func(ctx context.Context) (map[string]interface{}, error) {
+			start := time.Now()
+			opts.Context = ctx
+			h.logger.Info("Executing batch PDF conversion: %s -> %s", inputDir, outputDir)
+			batchResult, err := conv.ConvertPDFsInDirectoryWithOptions(inputDir, outputDir, opts)
+			if err != nil {
+				return nil, fmt.Errorf("batch conversion failed: %w", err)
+			}
+			h.stats.recordConversion(batchResult.SuccessCount, batchResult.TotalPageCount, batchResult.TotalImageCount, time.Since(start))
+			return h.batchToolResult(batchResult), nil
+		}
+		if async, _ := arguments["async"].(bool); async {
+			status, err := h.jobs.submit(h.session, toolName, inputDir, jobPriority(arguments, PriorityNormal), h.jobTimeout(convert))
+			if err != nil {
+				return nil, err
+			}
+			return structuredToolResult(h.textf(msgJobQueued, inputDir, status.ID, status.Position), status), nil
+		}
+		return convert(ctx)
+
+	case "convert_images_to_markdown":
+		inputDir, _ := arguments["input_dir"].(string)
+		outputDir := h.converter.Config().OutputBaseDir
+		if providedDir, exists := arguments["output_dir"].(string); exists {
+			outputDir = providedDir
+		}
+		if inputDir, err = h.sandboxPath(h.converter.Config().PDFInputDir, inputDir, "input_dir"); err != nil {
+			return nil, err
+		}
+		if outputDir, err = h.sandboxPath(h.converter.Config().OutputBaseDir, outputDir, "output_dir"); err != nil {
+			return nil, err
+		}
+		conv, err := h.presetConverter(arguments)
+		if err != nil {
+			return nil, err
+		}
+		opts := pdfconv.ConversionOptions{Context: ctx, Captioner: h.imageCaptioner(ctx)}
+		if err := outputOptions(arguments, &opts); err != nil {
+			return nil, err
+		}
+		h.logger.Info("Executing scanned image conversion: %s -> %s", inputDir, outputDir)
+		convResult, err := conv.ConvertImages(inputDir, outputDir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("conversion failed: %w", err)
+		}
+		h.stats.recordConversion(1, convResult.PageCount, convResult.ImageCount, time.Since(start))
+		return h.conversionToolResult(convResult), nil
+
+	case "split_pdf_by_sections":
+		pdfPath, _ := arguments["pdf_path"].(string)
+		outputDir := h.converter.Config().OutputBaseDir
+		if providedDir, exists := arguments["output_dir"].(string); exists {
+			outputDir = providedDir
+		}
+		if pdfPath, err = h.sandboxPath(h.converter.Config().PDFInputDir, pdfPath, "pdf_path"); err != nil {
+			return nil, err
+		}
+		if outputDir, err = h.sandboxPath(h.converter.Config().OutputBaseDir, outputDir, "output_dir"); err != nil {
+			return nil, err
+		}
+		if err := verifyChecksum(arguments, pdfPath); err != nil {
+			return nil, err
+		}
+		conv, err := h.presetConverter(arguments)
+		if err != nil {
+			return nil, err
+		}
+		h.logger.Info("Executing section split: %s -> %s", pdfPath, outputDir)
+		splitResult, err := conv.SplitPDFBySectionsWithOptions(pdfPath, outputDir, pdfconv.ConversionOptions{Context: ctx})
+		if err != nil {
+			return nil, fmt.Errorf("section split failed: %w", err)
+		}
+		splitImages := 0
+		for _, s := range splitResult.Sections {
+			splitImages += s.Result.ImageCount
+		}
+		h.stats.recordConversion(1, splitResult.PageCount, splitImages, time.Since(start))
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.formatSplitConversionResult(splitResult)}}}, nil
+
+	case "find_datasheet":
+		query, _ := arguments["query"].(string)
+		inputDir := h.converter.Config().PDFInputDir
+		if providedDir, exists := arguments["input_dir"].(string); exists {
+			inputDir = providedDir
+		}
+		if inputDir == "" {
+			return nil, fmt.Errorf("missing parameter: input_dir (PDF_INPUT_DIR is not set)")
+		}
+		if inputDir, err = h.sandboxPath(h.converter.Config().PDFInputDir, inputDir, "input_dir"); err != nil {
+			return nil, err
+		}
+		limit := 0
+		if n, exists := arguments["limit"].(float64); exists {
+			limit = int(n)
+		}
+		matches, err := h.converter.FindDocuments(inputDir, query, limit)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failed: %v", err)
+		}
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.formatDocumentMatches(query, inputDir, matches)}}}, nil
+
+	case "extract_pdf_metadata":
+		pdfPath, _ := arguments["pdf_path"].(string)
+		if pdfPath, err = h.sandboxPath(h.converter.Config().PDFInputDir, pdfPath, "pdf_path"); err != nil {
+			return nil, err
+		}
+		metadata, err := h.converter.Inspect(pdfPath)
+		if err != nil {
+			return nil, fmt.Errorf("metadata extraction failed: %v", err)
+		}
+		return structuredToolResult(h.formatMetadata(metadata), metadata), nil
+
+	case "list_pdfs":
+		inputDir := h.converter.Config().PDFInputDir
+		if providedDir, exists := arguments["input_dir"].(string); exists {
+			inputDir = providedDir
+		}
+		if inputDir == "" {
+			return nil, fmt.Errorf("missing parameter: input_dir (PDF_INPUT_DIR is not set)")
+		}
+		if inputDir, err = h.sandboxPath(h.converter.Config().PDFInputDir, inputDir, "input_dir"); err != nil {
+			return nil, err
+		}
+		documents, err := h.converter.ListDocuments(inputDir)
+		if err != nil {
+			return nil, fmt.Errorf("listing failed: %v", err)
+		}
+		return structuredToolResult(h.formatDocumentList(inputDir, documents), DocumentList{InputDir: inputDir, Documents: documents}), nil
+
+	case "preview_pdf_text":
+		pdfPath, _ := arguments["pdf_path"].(string)
+		var pages pdfconv.PageSelection
+		if expr, exists := arguments["pages"].(string); exists {
+			if pages, err = pdfconv.ParsePageSelection(expr); err != nil {
+				return nil, fmt.Errorf("invalid pages: %v", err)
+			}
+		}
+		maxChars := 0
+		if n, exists := arguments["max_chars"].(float64); exists {
+			maxChars = int(n)
+		}
+		if pdfPath, err = h.sandboxPath(h.converter.Config().PDFInputDir, pdfPath, "pdf_path"); err != nil {
+			return nil, err
+		}
+		preview, err := h.converter.PreviewPDF(ctx, pdfPath, pages, maxChars)
+		if err != nil {
+			return nil, fmt.Errorf("preview failed: %w", err)
+		}
+		text := preview.Markdown
+		if preview.Truncated {
+			text += h.textf(msgPreviewTruncated, len([]rune(preview.Markdown)))
+		}
+		return structuredToolResult(text, preview), nil
+
+	case "get_job_status":
+		jobID, _ := arguments["job_id"].(string)
+		status, ok := h.jobs.status(h.session, jobID)
 		if !ok {
-			return nil, fmt.Errorf("missing required parameter: input_dir")
+			return nil, fmt.Errorf("unknown job: %s", jobID)
 		}
+		return structuredToolResult(h.formatJobStatus(status), status), nil
+
+	case "get_job_result":
+		jobID, _ := arguments["job_id"].(string)
+		return h.jobs.result(h.session, jobID)
+
+	case "set_session_defaults":
+		return h.setSessionDefaults(arguments)
+
+	case "get_server_version":
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.versionReport()}}}, nil
+
+	case "get_server_stats":
+		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.stats.report() + h.formatCapabilities()}}}, nil
+
+	case "get_library_stats":
 		outputDir := h.converter.Config().OutputBaseDir
 		if providedDir, exists := arguments["output_dir"].(string); exists {
 			outputDir = providedDir
 		}
-		h.logger.Info("Executing batch PDF conversion: %s -> %s", inputDir, outputDir)
-		batchResult, err := h.converter.ConvertPDFsInDirectory(inputDir, outputDir)
+		if outputDir, err = h.sandboxPath(h.converter.Config().OutputBaseDir, outputDir, "output_dir"); err != nil {
+			return nil, err
+		}
+		stats, err := pdfconv.CollectLibraryStats(outputDir)
 		if err != nil {
-			return nil, fmt.Errorf("batch conversion failed: %v", err)
+			return nil, fmt.Errorf("library statistics failed: %v", err)
 		}
-		return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": h.formatBatchConversionResult(batchResult)}}}, nil
+		return structuredToolResult(stats.Summary(), stats), nil
 	}
 
 	return nil, fmt.Errorf("unexpected tool name: %s", toolName)
 }
 
-// formatConversionResult creates a formatted text description of the conversion results.
-func (h *MCPHandler) formatConversionResult(result *pdfconv.ConversionResult) string {
-	return fmt.Sprintf(`PDF Conversion Completed Successfully
+// jobPriority returns the priority argument of an asynchronous tool call, fallback when the
+// call has none.
+func jobPriority(arguments map[string]interface{}, fallback string) string {
+	if priority, _ := arguments["priority"].(string); priority != "" {
+		return strings.ToLower(priority)
+	}
+	return fallback
+}
+
+// presetConverter returns the converter for a conversion tool call, with the settings of
+// the preset argument applied when one was passed.
+func (h *MCPHandler) presetConverter(arguments map[string]interface{}) (*pdfconv.PDFConverter, error) {
+	preset, _ := arguments["preset"].(string)
+	conv, err := h.converter.WithPreset(preset)
+	if err != nil {
+		return nil, fmt.Errorf("invalid preset: %v", err)
+	}
+	return conv, nil
+}
+
+// outputOptions applies the output_format and markdown_flavor arguments of a conversion
+// tool call to opts.
+func outputOptions(arguments map[string]interface{}, opts *pdfconv.ConversionOptions) error {
+	if format, exists := arguments["output_format"].(string); exists && format != "" {
+		opts.OutputFormat = strings.ToLower(format)
+		if !slices.Contains(config.OutputFormats, opts.OutputFormat) {
+			return fmt.Errorf("invalid output_format '%s': must be one of %s", format, strings.Join(config.OutputFormats, ", "))
+		}
+	}
+	if flavor, exists := arguments["markdown_flavor"].(string); exists && flavor != "" {
+		opts.MarkdownFlavor = strings.ToLower(flavor)
+		if !slices.Contains(config.MarkdownFlavors, opts.MarkdownFlavor) {
+			return fmt.Errorf("invalid markdown_flavor '%s': must be one of %s", flavor, strings.Join(config.MarkdownFlavors, ", "))
+		}
+	}
+	return nil
+}
+
+// verifyChecksum checks pdfPath against the expected_sha256 argument of a tool call, when
+// one was passed.
+func verifyChecksum(arguments map[string]interface{}, pdfPath string) error {
+	expected, exists := arguments["expected_sha256"].(string)
+	if !exists || expected == "" {
+		return nil
+	}
+	return pdfconv.VerifyChecksum(pdfPath, expected)
+}
+
+// formatCapabilities lists the optional external tools found at startup and the features
+// they enable, for the server statistics.
+func (h *MCPHandler) formatCapabilities() string {
+	capabilities := h.converter.Capabilities()
+	if len(capabilities) == 0 {
+		return ""
+	}
+	var out strings.Builder
+	out.WriteString("\nOptional Tools:\n")
+	for _, c := range capabilities {
+		status := "found"
+		if c.Using != "" {
+			status = "found " + c.Using
+		}
+		if !c.Available {
+			status = "missing " + strings.Join(c.Missing, ", ")
+		}
+		fmt.Fprintf(&out, "- %s: %s (%s)\n", c.Name, status, c.Features)
+	}
+	return out.String()
+}
+
+// formatDocumentMatches lists the candidates of a find_datasheet lookup, best first.
+func (h *MCPHandler) formatDocumentMatches(query, inputDir string, matches []pdfconv.DocumentMatch) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("No documents in %s match %q.\n", inputDir, query)
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "Documents matching %q in %s:\n\n", query, inputDir)
+	for i, m := range matches {
+		fmt.Fprintf(&out, "%d. %s (confidence %.2f, matched in %s)\n", i+1, m.Path, m.Confidence, m.MatchedIn)
+		if m.Snippet != "" {
+			fmt.Fprintf(&out, "   %s\n", m.Snippet)
+		}
+	}
+	return out.String()
+}
 
-Output Directory: %s
-Markdown File: %s
-Pages Processed: %d
-Images Extracted: %d
+// formatDocumentList creates a table of the documents of a list_pdfs listing, with paths
+// relative to the listed directory.
+func (h *MCPHandler) formatDocumentList(inputDir string, documents []pdfconv.DocumentFile) string {
+	if len(documents) == 0 {
+		return h.textf(msgDocumentListEmpty, inputDir)
+	}
+	var total int64
+	for _, d := range documents {
+		total += d.Size
+	}
+	var out strings.Builder
+	out.WriteString(h.textf(msgDocumentList, inputDir, len(documents), pdfconv.FormatBytes(total)))
+	for _, d := range documents {
+		name := d.Path
+		if rel, err := filepath.Rel(inputDir, d.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		pages := "-"
+		switch {
+		case d.PasswordRequired:
+			pages = h.text(msgDocumentListLocked)
+		case d.PageCount > 0:
+			pages = fmt.Sprint(d.PageCount)
+		}
+		fmt.Fprintf(&out, "| %s | %s | %s | %s |\n", strings.ReplaceAll(name, "|", `\|`), pages, pdfconv.FormatBytes(d.Size), d.Modified.Format("2006-01-02 15:04"))
+	}
+	return out.String()
+}
 
-The PDF has been converted to Markdown format with all text content preserved and structured with appropriate headers. %s`,
+// formatJobStatus creates a formatted text description of the status of a job. Times that
+// have not been reached yet are shown as "-".
+func (h *MCPHandler) formatJobStatus(status JobStatus) string {
+	moment := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	state := status.Status
+	if status.Position > 0 {
+		state = h.textf(msgJobQueuePosition, state, status.Position, status.Priority)
+	}
+	text := h.textf(msgJobStatus, status.ID, status.Tool, status.Input, state, status.Created.Format(time.RFC3339), moment(status.Started), moment(status.Finished))
+	if status.Error != "" {
+		text += h.textf(msgJobError, status.Error)
+	}
+	return text
+}
+
+// formatMetadata creates a formatted text description of the document information of a
+// PDF. Missing entries are shown as "-".
+func (h *MCPHandler) formatMetadata(metadata *pdfconv.PDFMetadata) string {
+	value := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	pages := "-"
+	encryption := h.text(msgMetadataUnencrypted)
+	switch {
+	case metadata.PasswordRequired:
+		encryption = h.text(msgMetadataLocked)
+	case metadata.Encrypted:
+		encryption = h.text(msgMetadataEncrypted)
+	}
+	if !metadata.PasswordRequired {
+		pages = fmt.Sprintf("%d", metadata.PageCount)
+	}
+	return h.textf(msgMetadataResult,
+		metadata.Path,
+		value(metadata.Title),
+		value(metadata.Author),
+		value(metadata.Subject),
+		value(metadata.Keywords),
+		value(metadata.Creator),
+		value(metadata.Producer),
+		value(metadata.CreationDate),
+		value(metadata.ModDate),
+		pages,
+		value(metadata.Version),
+		pdfconv.FormatBytes(metadata.FileSize),
+		encryption,
+	) + h.getRepairNote(metadata.Repaired)
+}
+
+// formatConversionResult creates a formatted text description of the conversion results.
+func (h *MCPHandler) formatConversionResult(result *pdfconv.ConversionResult) string {
+	title := h.text(msgConversionTitle)
+	if result.Status == pdfconv.StatusPartial {
+		title = h.textf(msgPartialTitle, len(result.FailedPages), result.PageCount)
+	}
+	return h.textf(msgConversionResult,
+		title,
 		result.OutputDir,
 		filepath.Base(result.MarkdownFile),
+		pdfconv.ReportFileName,
 		result.PageCount,
 		result.ImageCount,
+		result.Quality.Summary(),
+		pdfconv.FormatDuration(result.Duration), result.Throughput(), result.Timings,
+		h.getFailedPagesNote(result.FailedPages),
 		h.getImageExtractionNote(result.ImageCount),
+		h.getRepairNote(result.Repaired),
+		h.getRedactionNote(result.Quality),
+		h.getBrokenLinkNote(result.BrokenLinks),
+	) + h.getVariantNote(result.Variants) + h.getPackageNote(result.Packages) + h.getCurveNote(result.Graphs) + h.getBOMNote(result.BOM) + h.getComplianceNote(result.Compliance)
+}
+
+// formatConversionEstimate creates a formatted text description of a dry-run estimate.
+func (h *MCPHandler) formatConversionEstimate(estimate *pdfconv.ConversionEstimate) string {
+	if !estimate.Sampled() {
+		return h.textf(msgEstimateSizeOnly,
+			estimate.Source,
+			pdfconv.FormatBytes(estimate.OutputBytes),
+		)
+	}
+	return h.textf(msgEstimate,
+		estimate.Source,
+		estimate.PageCount,
+		estimate.ImageCount,
+		pdfconv.FormatDuration(estimate.Duration),
+		pdfconv.FormatBytes(estimate.OutputBytes),
+		estimate.SampledPages,
 	)
 }
 
-// formatBatchConversionResult creates a formatted text description of the batch conversion results.
-func (h *MCPHandler) formatBatchConversionResult(result *pdfconv.BatchConversionResult) string {
+// formatBatchEstimate creates a formatted text description of a directory dry run, with
+// one line per file.
+func (h *MCPHandler) formatBatchEstimate(estimate *pdfconv.BatchEstimate) string {
+	var table strings.Builder
+	if len(estimate.Estimates) > 0 {
+		table.WriteString(h.text(msgEstimateTableHeader))
+		for _, e := range estimate.Estimates {
+			pages, duration := "-", "-"
+			if e.Sampled() {
+				pages = fmt.Sprintf("%d", e.PageCount)
+				duration = pdfconv.FormatDuration(e.Duration)
+			}
+			fmt.Fprintf(&table, "| %s | %s | %d | %s | %s |\n", strings.ReplaceAll(filepath.Base(e.Source), "|", `\|`), pages, e.ImageCount, duration, pdfconv.FormatBytes(e.OutputBytes))
+		}
+	}
+	var errorDetails string
+	if len(estimate.Errors) > 0 {
+		errorDetails = h.text(msgEstimateErrors)
+		for _, err := range estimate.Errors {
+			errorDetails += fmt.Sprintf("- %s: %s\n", filepath.Base(err.PDFPath), err.Error)
+		}
+	}
+
+	return h.textf(msgBatchEstimate,
+		estimate.InputDir,
+		len(estimate.Estimates)+len(estimate.Errors),
+		estimate.TotalPageCount,
+		estimate.TotalImageCount,
+		pdfconv.FormatDuration(estimate.Duration),
+		pdfconv.FormatBytes(estimate.OutputBytes),
+		table.String(),
+		errorDetails,
+	)
+}
+
+// formatBatchConversionResult creates a formatted text description of the batch conversion
+// results, with one status line per file.
+func (h *MCPHandler) formatBatchConversionResult(result *pdfconv.BatchConversionResult, summary BatchSummary) string {
 	var errorDetails string
 	if result.FailureCount > 0 {
-		errorDetails = fmt.Sprintf("\n\nErrors occurred during processing:\n")
+		errorDetails = h.text(msgBatchErrors)
 		for _, err := range result.Errors {
 			errorDetails += fmt.Sprintf("- %s: %s\n", filepath.Base(err.PDFPath), err.Error)
 		}
 	}
 
-	return fmt.Sprintf(`Batch PDF Conversion Completed
+	// Spell out the warnings of the files that have any
+	var warnings string
+	for _, f := range summary.Files {
+		if len(f.Warnings) > 0 {
+			warnings += fmt.Sprintf("- %s: %s\n", f.File, strings.Join(f.Warnings, ", "))
+		}
+	}
+	if warnings != "" {
+		warnings = h.text(msgBatchWarnings) + warnings + "\n"
+	}
 
-Input Directory: %s
-Output Directory: %s
-PDF Files Found: %d
-Successfully Converted: %d
-Failed Conversions: %d
-Total Pages Processed: %d
-Total Images Extracted: %d
+	title, inputLabel, countLabel := h.text(msgBatchTitle), h.text(msgInputDirectory), h.text(msgFilesFound)
+	if result.Portfolio {
+		title, inputLabel, countLabel = h.text(msgPortfolioTitle), h.text(msgPortfolio), h.text(msgDocumentsFound)
+	}
 
-%s%s`,
-		result.InputDir,
+	return h.textf(msgBatchResult,
+		title,
+		inputLabel, result.InputDir,
 		result.OutputBaseDir,
-		result.FileCount,
+		countLabel, result.FileCount,
 		result.SuccessCount,
 		result.FailureCount,
 		result.TotalPageCount,
 		result.TotalImageCount,
+		h.formatBatchTable(summary.Files),
+		warnings,
 		h.getImageExtractionNote(result.TotalImageCount),
 		errorDetails,
+	) + h.getErrataNote(result.ErrataLinks) + h.getBatchSummaryNote(result.SummaryFile, result.ResultsFile)
+}
+
+// batchToolResult returns the tool result of a batch or portfolio conversion: the text
+// summary plus the same per-file data as structuredContent.
+func (h *MCPHandler) batchToolResult(result *pdfconv.BatchConversionResult) map[string]interface{} {
+	summary := summarizeBatch(result)
+	return structuredToolResult(h.formatBatchConversionResult(result, summary), summary)
+}
+
+// formatSplitConversionResult creates a formatted text description of a per-section split.
+func (h *MCPHandler) formatSplitConversionResult(result *pdfconv.SplitConversionResult) string {
+	var sections string
+	totalImages := 0
+	brokenLinks := result.BrokenLinks
+	for i, s := range result.Sections {
+		status := ""
+		if s.Result.Status == pdfconv.StatusPartial {
+			status = h.textf(msgSplitSectionPartial, len(s.Result.FailedPages))
+		}
+		sections += h.textf(msgSplitSection, i+1, s.Title, s.StartPage, s.EndPage, filepath.Base(s.Result.OutputDir), s.Result.Quality.Score, pdfconv.FormatDuration(s.Result.Duration), status)
+		for _, failure := range s.Result.FailedPages {
+			sections += "   " + h.textf(msgFailedPageLine, failure.Page, failure.Reason) + "\n"
+		}
+		totalImages += s.Result.ImageCount
+		brokenLinks = append(brokenLinks, s.Result.BrokenLinks...)
+	}
+
+	return h.textf(msgSplitResult,
+		result.OutputDir,
+		filepath.Base(result.IndexFile),
+		result.PageCount,
+		len(result.Sections),
+		pdfconv.FormatDuration(result.Duration),
+		sections,
+		h.getImageExtractionNote(totalImages),
+		h.getRepairNote(len(result.Sections) > 0 && result.Sections[0].Result.Repaired),
+		h.getBrokenLinkNote(brokenLinks),
 	)
 }
 
 // getImageExtractionNote returns an appropriate note about image extraction based on the count.
 func (h *MCPHandler) getImageExtractionNote(imageCount int) string {
 	if imageCount == 0 {
-		return "No images were found in the PDF or image extraction was disabled."
+		return h.text(msgImagesNone)
 	} else if imageCount == 1 {
-		return "One image was extracted and saved as a PNG file."
+		return h.text(msgImagesOne)
+	}
+	return h.textf(msgImagesMany, imageCount)
+}
+
+// getRedactionNote returns a note listing the pages where the source document hides content.
+func (h *MCPHandler) getRedactionNote(quality pdfconv.QualityReport) string {
+	if len(quality.Redactions) == 0 {
+		return ""
+	}
+	var lines []string
+	for _, r := range quality.Redactions {
+		line := h.textf(msgRedactionLine, r.Page, r.Count, r.Kind, r.Area*100)
+		if r.Section != "" {
+			line += h.textf(msgRedactionSection, r.Section)
+		}
+		lines = append(lines, line)
+	}
+	return h.text(msgRedactionNote) + strings.Join(lines, "\n")
+}
+
+// getBrokenLinkNote returns a note listing links in the output whose targets do not resolve.
+func (h *MCPHandler) getBrokenLinkNote(broken []pdfconv.BrokenLink) string {
+	if len(broken) == 0 {
+		return ""
+	}
+	lines := make([]string, len(broken))
+	for i, link := range broken {
+		lines[i] = "- " + link.String()
+	}
+	return h.text(msgBrokenLinkNote) + strings.Join(lines, "\n")
+}
+
+// getFailedPagesNote returns a note listing the pages missing from a partial conversion.
+func (h *MCPHandler) getFailedPagesNote(failures []pdfconv.PageFailure) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	lines := make([]string, len(failures))
+	for i, failure := range failures {
+		lines[i] = h.textf(msgFailedPageLine, failure.Page, failure.Reason)
+	}
+	return h.text(msgFailedPagesNote) + strings.Join(lines, "\n") + "\n\n"
+}
+
+// getVariantNote returns a note for conversions that found part variants in ordering tables.
+func (h *MCPHandler) getVariantNote(variants []pdfconv.PartVariant) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	return h.textf(msgVariantNote, len(variants), pdfconv.VariantsFileName)
+}
+
+// getPackageNote returns a note for conversions that exported package dimensions.
+func (h *MCPHandler) getPackageNote(packages []pdfconv.PackageDimensions) string {
+	if len(packages) == 0 {
+		return ""
+	}
+	return h.textf(msgPackageNote, len(packages), pdfconv.PackageFileName)
+}
+
+// getCurveNote returns a note for conversions that digitized characteristic curve graphs.
+func (h *MCPHandler) getCurveNote(graphs []pdfconv.CurveGraph) string {
+	if len(graphs) == 0 {
+		return ""
+	}
+	curves := 0
+	for _, graph := range graphs {
+		curves += len(graph.Curves)
+	}
+	return h.textf(msgCurveNote, curves, len(graphs), pdfconv.CurvesFileName)
+}
+
+// getBOMNote returns a note for conversions that collected a bill of materials from
+// typical application circuits.
+func (h *MCPHandler) getBOMNote(items []pdfconv.BOMItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+	components := 0
+	for _, item := range items {
+		components += len(item.Designators)
+	}
+	return h.textf(msgBOMNote, components, pdfconv.BOMFileName)
+}
+
+// getComplianceNote returns a note listing the compliance and qualification standards a
+// converted document states.
+func (h *MCPHandler) getComplianceNote(summary *pdfconv.ComplianceSummary) string {
+	if summary == nil {
+		return ""
+	}
+	return h.textf(msgComplianceNote, strings.Join(summary.Tags, ", "), pdfconv.ComplianceFileName)
+}
+
+// getErrataNote returns a note for batch conversions that linked errata documents to their
+// datasheets.
+func (h *MCPHandler) getErrataNote(links []pdfconv.ErrataLink) string {
+	if len(links) == 0 {
+		return ""
+	}
+	notes := 0
+	for _, link := range links {
+		notes += link.Notes
+	}
+	return h.textf(msgErrataNote, len(links), notes)
+}
+
+// getBatchSummaryNote returns notes pointing to the BATCH_SUMMARY.md and results.json of a
+// batch, if any.
+func (h *MCPHandler) getBatchSummaryNote(summaryPath, resultsPath string) string {
+	note := ""
+	if summaryPath != "" {
+		note += h.textf(msgBatchSummaryNote, summaryPath)
+	}
+	if resultsPath != "" {
+		note += h.textf(msgBatchResultsNote, resultsPath)
+	}
+	return note
+}
+
+// getRepairNote returns a note for conversions of malformed PDFs that had to be repaired.
+func (h *MCPHandler) getRepairNote(repaired bool) string {
+	if !repaired {
+		return ""
 	}
-	return fmt.Sprintf("All %d images were extracted and saved as PNG files.", imageCount)
+	return h.text(msgRepairNote)
 }
@@ -0,0 +1,338 @@
+// Package mcp - HTTP transport.
+// This file serves MCP over HTTP with Server-Sent Events (the HTTP+SSE transport of the
+// 2024-11-05 protocol), so the server can run remotely instead of being spawned by the
+// client. A client opens an event stream with GET /sse, receives an "endpoint" event with
+// the URL of its session, and POSTs its JSON-RPC messages there. Responses and the server's
+// own requests, such as sampling/createMessage, are sent back as "message" events.
+package mcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP transport settings
+const (
+	sseEndpointPath     = "/sse"           // Opens a session's event stream
+	messageEndpointPath = "/message"       // Receives a session's client messages
+	sessionQueueSize    = 16               // Client messages queued while the session is busy
+	sseKeepAlive        = 30 * time.Second // Comment sent on idle streams to keep proxies from closing them
+)
+
+// messageQueue feeds the client messages POSTed to a session to its handler.
+type messageQueue struct {
+	messages chan []byte   // Client messages, one line each
+	done     chan struct{} // Closed when the session ends
+	buf      []byte        // Rest of the message being read
+}
+
+// newMessageQueue returns an empty queue.
+func newMessageQueue() *messageQueue {
+	return &messageQueue{messages: make(chan []byte, sessionQueueSize), done: make(chan struct{})}
+}
+
+// Read returns the queued messages, and io.EOF once the session has ended.
+func (q *messageQueue) Read(p []byte) (int, error) {
+	if len(q.buf) == 0 {
+		select {
+		case q.buf = <-q.messages:
+		case <-q.done:
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, q.buf)
+	q.buf = q.buf[n:]
+	return n, nil
+}
+
+// httpSession is the connection state of one event stream.
+type httpSession struct {
+	*messageQueue
+	events *sseWriter // Event stream to the client
+}
+
+// sseWriter writes each JSON message of the handler as a "message" event.
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	closed  bool
+}
+
+// Write sends p, one JSON message as written by json.Encoder, as a message event.
+func (e *sseWriter) Write(p []byte) (int, error) {
+	if err := e.event("message", bytes.TrimRight(p, "\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// event sends an event with a single line of data.
+func (e *sseWriter) event(name string, data []byte) error {
+	return e.send(fmt.Sprintf("event: %s\ndata: %s\n\n", name, data))
+}
+
+// send writes raw event stream text and flushes it to the client.
+func (e *sseWriter) send(text string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return fmt.Errorf("event stream closed")
+	}
+	if _, err := io.WriteString(e.w, text); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	return nil
+}
+
+// close stops writes to the response, which must not be used after its handler returned.
+func (e *sseWriter) close() {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+}
+
+// httpTransport routes HTTP requests to the sessions of the server.
+type httpTransport struct {
+	base       *MCPHandler // Handler whose converter, statistics and update status sessions share
+	mu         sync.Mutex
+	sessions   map[string]*httpSession       // HTTP+SSE sessions by ID
+	streamable map[string]*streamableSession // Streamable HTTP sessions by ID
+}
+
+// HandleHTTP serves MCP over HTTP on addr until the listener fails: the Streamable HTTP
+// transport on /mcp and the older HTTP+SSE transport on /sse and /message. Each session is
+// independent, as if the client had spawned its own server process, while conversion
+// statistics are shared.
+func (h *MCPHandler) HandleHTTP(addr string) error {
+	h.logger.Info("Listening for MCP clients on http://%s%s (Streamable HTTP) and http://%s%s (HTTP+SSE)", addr, streamableEndpointPath, addr, sseEndpointPath)
+	server := &http.Server{Addr: addr, Handler: h.httpHandler(), ReadHeaderTimeout: 10 * time.Second}
+	return server.ListenAndServe()
+}
+
+// httpHandler returns the handler of the HTTP transport's endpoints and starts ending idle
+// Streamable HTTP sessions.
+func (h *MCPHandler) httpHandler() http.Handler {
+	t := newHTTPTransport(h)
+	go t.reapStreamable()
+	return t.handler()
+}
+
+// newHTTPTransport returns a transport without sessions.
+func newHTTPTransport(h *MCPHandler) *httpTransport {
+	return &httpTransport{base: h, sessions: make(map[string]*httpSession), streamable: make(map[string]*streamableSession)}
+}
+
+// handler returns the handler of the transport's endpoints.
+func (t *httpTransport) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(streamableEndpointPath, t.handleStreamable)
+	mux.HandleFunc(sseEndpointPath, t.handleEvents)
+	mux.HandleFunc(messageEndpointPath, t.handleMessage)
+	return t.checkOrigin(mux)
+}
+
+// handleEvents opens a session: it announces the session's message endpoint and then runs a
+// handler for the session, streaming its messages until the client disconnects.
+func (t *httpTransport) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	session := &httpSession{messageQueue: newMessageQueue(), events: &sseWriter{w: w, flusher: flusher}}
+	t.mu.Lock()
+	t.sessions[id] = session
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.sessions, id)
+		t.mu.Unlock()
+		close(session.done)
+		session.events.close()
+		t.base.jobs.forget(id)
+		t.base.logger.Info("HTTP session %s closed", id)
+	}()
+
+	if err := session.events.event("endpoint", []byte(messageEndpointPath+"?sessionId="+id)); err != nil {
+		return
+	}
+	t.base.logger.Info("HTTP session %s opened from %s", id, r.RemoteAddr)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- t.base.newSession(id).serve(session, session.events)
+	}()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case err := <-served:
+			if err != nil {
+				t.base.logger.Error("HTTP session %s failed: %v", id, err)
+			}
+			return
+		case <-keepAlive.C:
+			if err := session.events.send(": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleMessage queues a POSTed client message for its session. The response is sent on the
+// session's event stream; the POST is answered with 202 Accepted.
+func (t *httpTransport) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t.mu.Lock()
+	session, ok := t.sessions[r.URL.Query().Get("sessionId")]
+	t.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	data, ok := t.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	// Sessions read one message per line
+	var line bytes.Buffer
+	if err := json.Compact(&line, data); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	line.WriteByte('\n')
+
+	select {
+	case session.messages <- line.Bytes():
+		w.WriteHeader(http.StatusAccepted)
+	case <-session.done:
+		http.Error(w, "session closed", http.StatusGone)
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "session busy", http.StatusServiceUnavailable)
+	}
+}
+
+// readBody reads the body of a POST up to MAX_MESSAGE_SIZE_MB. On failure the error has
+// been answered and ok is false.
+func (t *httpTransport) readBody(w http.ResponseWriter, r *http.Request) (data []byte, ok bool) {
+	body := r.Body
+	if limit := t.base.converter.Config().MaxMessageSizeMB << 20; limit > 0 {
+		body = http.MaxBytesReader(w, body, int64(limit))
+	}
+	data, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		err = &messageTooLargeError{limit: int(tooLarge.Limit)}
+		t.base.logger.Error("Rejected client message: %v", err)
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read message: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	return data, true
+}
+
+// checkOrigin rejects requests that could come from a web page of another site. Through
+// DNS rebinding such a page reaches a server on localhost under the attacker's own host
+// name, so the Host header must name loopback, an IP address or one of MCP_ALLOWED_HOSTS.
+// Browser requests must also carry an Origin on loopback, on one of MCP_ALLOWED_HOSTS or
+// on the host the request was sent to; requests without an Origin header, such as those
+// of MCP clients that are not browsers, are let through.
+func (t *httpTransport) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := t.base.converter.Config().AllowedHosts
+		if !knownHost(hostName(r.Host), allowed, true) {
+			http.Error(w, "host not allowed", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Host != r.Host && !knownHost(u.Hostname(), allowed, false)) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hostName returns the host of a Host header without its port.
+func hostName(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return strings.Trim(host, "[]")
+}
+
+// knownHost reports whether host is loopback or one of allowed, or with anyIP any IP
+// address, which DNS rebinding cannot produce.
+func knownHost(host string, allowed []string, anyIP bool) bool {
+	if isLoopbackHost(host) || (anyIP && net.ParseIP(host) != nil) {
+		return true
+	}
+	for _, name := range allowed {
+		if strings.EqualFold(host, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackHost reports whether host names the local machine.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newSessionID returns a random session ID that is hard to guess.
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to create session ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is an event received on an HTTP+SSE event stream.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// sseConn talks to a handler over the HTTP+SSE transport: messages are POSTed to the
+// session's endpoint and answered on its event stream.
+type sseConn struct {
+	endpoint string
+	events   chan sseEvent
+	cancel   context.CancelFunc // Closes the event stream
+}
+
+func connectSSE(t *testing.T, h *MCPHandler) *sseConn {
+	server := httptest.NewServer(h.httpHandler())
+	t.Cleanup(server.Close)
+	return openSSE(t, server.URL)
+}
+
+// openSSE opens an event stream on the server at baseURL and waits for its endpoint event.
+func openSSE(t *testing.T, baseURL string) *sseConn {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+sseEndpointPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", sseEndpointPath, err)
+	}
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s %s", response.Status, response.Header.Get("Content-Type"))
+	}
+	t.Cleanup(cancel)
+
+	conn := &sseConn{events: make(chan sseEvent), cancel: cancel}
+	go readEvents(ctx, response.Body, conn.events)
+
+	endpoint := conn.next(t)
+	if endpoint.name != "endpoint" || !strings.HasPrefix(endpoint.data, messageEndpointPath+"?sessionId=") {
+		t.Fatalf("expected the endpoint event first, got %+v", endpoint)
+	}
+	conn.endpoint = baseURL + endpoint.data
+	return conn
+}
+
+// readEvents passes the events of an event stream on until the stream ends or ctx is done,
+// and then closes events.
+func readEvents(ctx context.Context, body io.ReadCloser, events chan<- sseEvent) {
+	defer body.Close()
+	defer close(events)
+	reader := bufio.NewReader(body)
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch line = strings.TrimSuffix(line, "\n"); {
+		case line == "" && event.name != "":
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+			event = sseEvent{}
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// next returns the next event of the stream.
+func (c *sseConn) next(t *testing.T) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-c.events:
+		if !ok {
+			t.Fatal("event stream closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return sseEvent{}
+}
+
+// post sends one raw message to the session and returns the HTTP status.
+func (c *sseConn) post(t *testing.T, message string) int {
+	t.Helper()
+	response, err := http.Post(c.endpoint, "application/json", strings.NewReader(message))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+// exchange posts a message followed by a ping and returns the messages sent before the ping
+// was answered, as stdioConn does. Tool calls run concurrently and may be answered after
+// the ping, so the response to a tool call is waited for as well.
+func (c *sseConn) exchange(t *testing.T, message string) []map[string]interface{} {
+	t.Helper()
+	for _, m := range []string{message, conformanceSync} {
+		if status := c.post(t, m); status != http.StatusAccepted {
+			t.Fatalf("expected 202 Accepted for %s, got %d", m, status)
+		}
+	}
+	var call struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+	}
+	_ = json.Unmarshal([]byte(message), &call)
+	awaiting := call.Method == "tools/call" && validID(call.ID)
+	synced := false
+	var responses []map[string]interface{}
+	for !synced || awaiting {
+		event := c.next(t)
+		if event.name != "message" {
+			t.Fatalf("expected a message event, got %+v", event)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(event.data), &response); err != nil {
+			t.Fatalf("server sent invalid JSON %q: %v", event.data, err)
+		}
+		if response["id"] == "conformance-sync" {
+			synced = true
+			continue
+		}
+		if _, ok := response["method"]; !ok && response["id"] == call.ID {
+			awaiting = false
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func TestHTTPTransport_Conformance(t *testing.T) {
+	conn := connectSSE(t, newConformanceHandler(t))
+	for _, step := range conformanceSteps {
+		if !json.Valid([]byte(step.message)) {
+			continue // Rejected with 400 before reaching the session, see TestHTTPTransport_Errors
+		}
+		t.Run(step.name, func(t *testing.T) { step.check(t, conn.exchange(t, step.message)) })
+	}
+}
+
+func TestHTTPTransport_Errors(t *testing.T) {
+	h := newConformanceHandler(t)
+	h.converter.Config().MaxMessageSizeMB = 1
+	server := httptest.NewServer(h.httpHandler())
+	t.Cleanup(server.Close)
+	conn := openSSE(t, server.URL)
+
+	if status := conn.post(t, `{"jsonrpc":"2.0","id":10,"method":`); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid JSON, got %d", status)
+	}
+	if status := conn.post(t, `{"data":"`+strings.Repeat("A", 1<<20)+`"}`); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a message over MAX_MESSAGE_SIZE_MB, got %d", status)
+	}
+	conn.exchange(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`) // The session still works
+
+	requests := []struct {
+		name   string
+		method string
+		url    string
+		host   string
+		origin string
+		want   int
+	}{
+		{"unknown session", http.MethodPost, server.URL + messageEndpointPath + "?sessionId=unknown", "", "", http.StatusNotFound},
+		{"GET of the message endpoint", http.MethodGet, conn.endpoint, "", "", http.StatusMethodNotAllowed},
+		{"POST to the event stream", http.MethodPost, server.URL + sseEndpointPath, "", "", http.StatusMethodNotAllowed},
+		{"other origin", http.MethodPost, conn.endpoint, "", "https://attacker.example", http.StatusForbidden},
+		{"loopback origin", http.MethodPost, conn.endpoint, "", "http://localhost:3000", http.StatusAccepted},
+		{"DNS rebinding", http.MethodPost, conn.endpoint, "evil.example:8080", "http://evil.example:8080", http.StatusForbidden},
+		{"unknown host", http.MethodPost, conn.endpoint, "evil.example:8080", "", http.StatusForbidden},
+		{"allowed host", http.MethodPost, conn.endpoint, "mcp.example:8080", "http://mcp.example:8080", http.StatusAccepted},
+	}
+	h.converter.Config().AllowedHosts = []string{"mcp.example"}
+	for _, tt := range requests {
+		request, err := http.NewRequest(tt.method, tt.url, strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.host != "" {
+			request.Host = tt.host
+		}
+		if tt.origin != "" {
+			request.Header.Set("Origin", tt.origin)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, response.StatusCode)
+		}
+	}
+
+	// Closing the event stream ends the session
+	conn.cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.post(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`) != http.StatusNotFound {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the session closed with its event stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,271 @@
+// Package mcp - Asynchronous jobs.
+// This file runs long tool calls, such as batch conversions of hundreds of files, as jobs in
+// the background. The call returns a job ID right away, and clients poll get_job_status and
+// fetch the outcome with get_job_result instead of holding the request open for the whole
+// batch. Jobs run one at a time, highest priority first and in submission order within a
+// priority, except that a high priority job submitted while another job runs starts at once
+// on a lane reserved for it, so an interactive conversion is not stuck behind a large batch.
+// The queue holds at most MAX_QUEUED_JOBS waiting jobs; further submissions fail with a
+// queue_full error. The queue is shared by all sessions, but each job belongs to the session
+// that submitted it: only that session can poll it or collect its result, and the jobs of a
+// session that ends are dropped, its running jobs cancelled. A Streamable HTTP client that
+// reconnects with its session ID still finds its jobs.
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"datasheet-to-md-mcp/logger"
+)
+
+// Job status values
+const (
+	JobQueued    = "queued"    // Waiting for the jobs submitted before it
+	JobRunning   = "running"   // Being run
+	JobCompleted = "completed" // Finished; get_job_result returns the tool result
+	JobFailed    = "failed"    // Finished with an error; get_job_result returns the error
+)
+
+// Job priorities
+const (
+	PriorityHigh   = "high"   // Interactive requests, such as a single document; the default for single conversions
+	PriorityNormal = "normal" // The default for batch conversions
+	PriorityLow    = "low"    // Background work that may wait for everything else
+)
+
+// JobPriorities are the accepted job priorities, highest first.
+var JobPriorities = []string{PriorityHigh, PriorityNormal, PriorityLow}
+
+// errQueueFull is returned when a job is submitted to a queue holding MAX_QUEUED_JOBS jobs.
+var errQueueFull = errors.New("job queue is full")
+
+// maxFinishedJobs is the number of finished jobs whose results are kept. The oldest finished
+// jobs are forgotten beyond it.
+const maxFinishedJobs = 100
+
+// JobStatus is the state of an asynchronous job, the structuredContent of get_job_status.
+type JobStatus struct {
+	ID       string     `json:"job_id"`
+	Tool     string     `json:"tool"`
+	Input    string     `json:"input"`
+	Status   string     `json:"status"`
+	Priority string     `json:"priority"`
+	Position int        `json:"queue_position,omitempty"` // 1-based position of a queued job in the queue
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// jobFunc runs the tool call of a job and returns its tool result.
+type jobFunc func(ctx context.Context) (map[string]interface{}, error)
+
+// job is a tool call submitted to the job queue.
+type job struct {
+	session string // MCP session ID of the submitting session, "" for stdio
+	status  JobStatus
+	run     jobFunc
+	cancel  context.CancelFunc // Cancels the job while it runs
+	result  map[string]interface{}
+	err     error
+}
+
+// jobQueue runs submitted jobs one at a time on a worker goroutine that exits when the queue
+// is empty. While it is busy, a second worker runs the waiting high priority jobs.
+type jobQueue struct {
+	logger *logger.Logger
+	limit  int // Jobs that may wait in the queue, 0 for no limit
+
+	mu       sync.Mutex
+	jobs     map[string]*job
+	queue    []*job   // Jobs waiting to run, highest priority first, then in submission order
+	finished []string // IDs of finished jobs, oldest first
+	working  bool     // Whether the worker goroutine is running
+	express  bool     // Whether the worker of the high priority lane is running
+}
+
+// newJobQueue returns an empty job queue holding at most limit waiting jobs, 0 for no limit.
+func newJobQueue(logger *logger.Logger, limit int) *jobQueue {
+	return &jobQueue{logger: logger, limit: limit, jobs: map[string]*job{}}
+}
+
+// submit queues a job of session for tool on input with one of JobPriorities, PriorityNormal
+// when priority is empty, and returns its status. The job is queued after the waiting jobs of
+// the same or a higher priority. A full queue rejects the job with an errQueueFull error.
+func (q *jobQueue) submit(session, tool, input, priority string, run jobFunc) (JobStatus, error) {
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	rank := slices.Index(JobPriorities, priority)
+	if rank < 0 {
+		return JobStatus{}, fmt.Errorf("invalid job priority '%s': must be one of %v", priority, JobPriorities)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return JobStatus{}, fmt.Errorf("failed to create job ID: %v", err)
+	}
+	j := &job{session: session, status: JobStatus{ID: "job-" + hex.EncodeToString(id), Tool: tool, Input: input, Status: JobQueued, Priority: priority, Created: time.Now()}, run: run}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limit > 0 && len(q.queue) >= q.limit {
+		q.logger.Warn("Rejected job for %s %s: %d jobs are waiting (MAX_QUEUED_JOBS)", tool, input, len(q.queue))
+		return JobStatus{}, fmt.Errorf("%w: %d jobs are waiting (MAX_QUEUED_JOBS); retry once a queued job has started", errQueueFull, len(q.queue))
+	}
+	position := len(q.queue)
+	for i, queued := range q.queue {
+		if slices.Index(JobPriorities, queued.status.Priority) > rank {
+			position = i
+			break
+		}
+	}
+	q.jobs[j.status.ID] = j
+	q.queue = slices.Insert(q.queue, position, j)
+	switch {
+	case !q.working:
+		q.working = true
+		go q.work(false)
+	case priority == PriorityHigh && !q.express:
+		q.express = true
+		go q.work(true)
+	}
+	q.logger.Info("Queued job %s with %s priority: %s %s", j.status.ID, priority, tool, input)
+	return q.snapshot(j), nil
+}
+
+// work runs the queued jobs in turn until the queue is empty. The worker of the high priority
+// lane, express, only runs high priority jobs and exits when none are waiting.
+func (q *jobQueue) work(express bool) {
+	for {
+		q.mu.Lock()
+		if len(q.queue) == 0 || (express && q.queue[0].status.Priority != PriorityHigh) {
+			if express {
+				q.express = false
+			} else {
+				q.working = false
+			}
+			q.mu.Unlock()
+			return
+		}
+		j := q.queue[0]
+		q.queue = q.queue[1:]
+		started := time.Now()
+		j.status.Status, j.status.Started = JobRunning, &started
+		ctx, cancel := context.WithCancel(context.Background())
+		j.cancel = cancel
+		q.mu.Unlock()
+
+		q.logger.Info("Running job %s", j.status.ID)
+		result, err := j.run(ctx)
+		cancel()
+
+		q.mu.Lock()
+		finished := time.Now()
+		j.status.Finished = &finished
+		j.result, j.err, j.cancel = result, err, nil
+		if err != nil {
+			j.status.Status, j.status.Error = JobFailed, err.Error()
+			q.logger.Error("Job %s failed: %v", j.status.ID, err)
+		} else {
+			j.status.Status = JobCompleted
+			q.logger.Info("Job %s completed in %s", j.status.ID, finished.Sub(started).Round(time.Millisecond))
+		}
+		j.run = nil
+		if q.jobs[j.status.ID] == j { // Its session has not ended meanwhile
+			q.finished = append(q.finished, j.status.ID)
+		}
+		for len(q.finished) > maxFinishedJobs {
+			delete(q.jobs, q.finished[0])
+			q.finished = q.finished[1:]
+		}
+		q.mu.Unlock()
+	}
+}
+
+// status returns the status of a job of session, false when the session has no job with the
+// ID.
+func (q *jobQueue) status(session, id string) (JobStatus, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok || j.session != session {
+		return JobStatus{}, false
+	}
+	return q.snapshot(j), true
+}
+
+// result returns the tool result or the error of a finished job of session. Jobs that have
+// not finished, and jobs unknown to the session, are reported as errors.
+func (q *jobQueue) result(session, id string) (map[string]interface{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	switch {
+	case !ok || j.session != session:
+		return nil, fmt.Errorf("unknown job: %s", id)
+	case j.status.Status == JobQueued || j.status.Status == JobRunning:
+		return nil, fmt.Errorf("job %s is %s; poll get_job_status until it has finished", id, j.status.Status)
+	case j.err != nil:
+		return nil, fmt.Errorf("job %s failed: %w", id, j.err)
+	}
+	return j.result, nil
+}
+
+// forget drops the jobs of a session that has ended, as no session can poll them anymore:
+// its queued jobs are removed from the queue, its running jobs are cancelled and its finished
+// jobs are forgotten.
+func (q *jobQueue) forget(session string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	owned := func(j *job) bool { return j.session == session }
+	queued := len(q.queue)
+	q.queue = slices.DeleteFunc(q.queue, owned)
+	q.finished = slices.DeleteFunc(q.finished, func(id string) bool { return owned(q.jobs[id]) })
+	for id, j := range q.jobs {
+		if owned(j) {
+			if j.cancel != nil {
+				q.logger.Info("Cancelling running job %s of ended session %s", id, session)
+				j.cancel()
+			}
+			delete(q.jobs, id)
+		}
+	}
+	if dropped := queued - len(q.queue); dropped > 0 {
+		q.logger.Info("Dropped %d queued jobs of ended session %s", dropped, session)
+	}
+}
+
+// snapshot returns a copy of the status of a job with its queue position. q.mu must be held.
+func (q *jobQueue) snapshot(j *job) JobStatus {
+	status := j.status
+	for i, queued := range q.queue {
+		if queued == j {
+			status.Position = i + 1
+		}
+	}
+	return status
+}
+
+// jobTimeout applies CONVERSION_TIMEOUT to a job, as callTool does to tool calls.
+func (h *MCPHandler) jobTimeout(run jobFunc) jobFunc {
+	timeout := time.Duration(h.converter.Config().ToolTimeout) * time.Second
+	if timeout <= 0 {
+		return run
+	}
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := run(ctx)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("job timed out after %s (CONVERSION_TIMEOUT): %w", timeout, err)
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,78 @@
+// Package mcp - Client logging.
+// This file implements the logging capability: once a client sets a level with
+// logging/setLevel, server log messages at or above it are streamed to the client as
+// notifications/message, so assistants can show conversion warnings inline. Messages come
+// from the shared server log, so with several HTTP clients each one receives all of them;
+// logging is therefore not offered in restricted mode.
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"datasheet-to-md-mcp/logger"
+)
+
+// mcpLogLevels are the MCP log levels, the syslog severities, from least to most severe.
+var mcpLogLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// logSeverities maps the levels of the server log to their index in mcpLogLevels.
+var logSeverities = map[logger.LogLevel]int{
+	logger.LogDebug: 0,
+	logger.LogInfo:  1,
+	logger.LogWarn:  3,
+	logger.LogError: 4,
+	logger.LogFatal: 5,
+}
+
+// clientLog is the log sink of a session, which sends log messages to its client.
+type clientLog struct {
+	h     *MCPHandler
+	level atomic.Int32 // Index in mcpLogLevels of the least severe level sent
+}
+
+// Log sends a message at or above the client's level as a notifications/message.
+func (l *clientLog) Log(level logger.LogLevel, message string) {
+	severity := logSeverities[level]
+	if int32(severity) < l.level.Load() {
+		return
+	}
+	// A failure is not logged, which would come back here
+	_ = l.h.send(MCPMessage{JSONRPC: "2.0", Method: "notifications/message", Params: map[string]interface{}{
+		"level":  mcpLogLevels[severity],
+		"logger": l.h.converter.Config().ServerName,
+		"data":   message,
+	}})
+}
+
+// handleSetLevel processes a logging/setLevel request, starting to stream log messages to
+// the client at the first call.
+func (h *MCPHandler) handleSetLevel(params map[string]interface{}) (map[string]interface{}, error) {
+	if h.restricted() {
+		return nil, fmt.Errorf("logging is disabled in restricted mode")
+	}
+	level, _ := params["level"].(string)
+	severity := slices.Index(mcpLogLevels, level)
+	if severity < 0 {
+		return nil, fmt.Errorf("invalid log level %q (expected one of %s)", level, strings.Join(mcpLogLevels, ", "))
+	}
+	if h.clientLog == nil {
+		h.clientLog = &clientLog{h: h}
+		h.clientLog.level.Store(int32(severity))
+		h.logger.AddSink(h.clientLog)
+	} else {
+		h.clientLog.level.Store(int32(severity))
+	}
+	h.logger.Info("Client log level set to %s", level)
+	return map[string]interface{}{}, nil
+}
+
+// stopClientLog stops streaming log messages to the client when its connection ends.
+func (h *MCPHandler) stopClientLog() {
+	if h.clientLog != nil {
+		h.logger.RemoveSink(h.clientLog)
+		h.clientLog = nil
+	}
+}
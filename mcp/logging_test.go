@@ -0,0 +1,47 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestClientLog(t *testing.T) {
+	h := newConformanceHandler(t)
+	var out bytes.Buffer
+	h.out = json.NewEncoder(&out)
+
+	h.logger.Warn("before setLevel")
+	if out.Len() != 0 {
+		t.Fatalf("expected no notification before logging/setLevel, got %s", out.String())
+	}
+	if _, err := h.handleSetLevel(map[string]interface{}{"level": "warning"}); err != nil {
+		t.Fatalf("handleSetLevel() error = %v", err)
+	}
+	h.logger.Info("below the level")
+	h.logger.Warn("Table on page %d has ragged rows", 3)
+	h.logger.Error("conversion failed")
+
+	decoder := json.NewDecoder(&out)
+	for _, want := range []struct{ level, data string }{{"warning", "Table on page 3 has ragged rows"}, {"error", "conversion failed"}} {
+		var message MCPMessage
+		if err := decoder.Decode(&message); err != nil {
+			t.Fatalf("expected a notification for %q: %v", want.data, err)
+		}
+		if message.Method != "notifications/message" || message.ID != nil || message.Params["level"] != want.level || message.Params["data"] != want.data {
+			t.Errorf("unexpected notification: %+v", message)
+		}
+	}
+	if decoder.More() {
+		t.Errorf("unexpected notifications: %s", out.String())
+	}
+
+	if _, err := h.handleSetLevel(map[string]interface{}{"level": "verbose"}); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+	h.stopClientLog()
+	h.logger.Error("after the connection ended")
+	if out.Len() != 0 {
+		t.Errorf("expected no notification after stopClientLog, got %s", out.String())
+	}
+}
@@ -0,0 +1,602 @@
+// Package mcp - Localized messages.
+// This file holds the message catalogs for LOCALE: tool descriptions and the templates of
+// the conversion result summaries. Messages missing from a catalog fall back to English.
+package mcp
+
+import "fmt"
+
+// messageID identifies a localized message.
+type messageID int
+
+// Localized messages
+const (
+	msgToolConvertPDF messageID = iota
+	msgToolConvertPages
+	msgToolConvertDirectory
+	msgToolConvertImages
+	msgToolSplitPDF
+	msgToolServerVersion
+	msgToolServerStats
+	msgToolFindDatasheet
+	msgToolLibraryStats
+	msgToolExtractMetadata
+	msgToolListPDFs
+	msgToolPreviewText
+	msgToolJobStatus
+	msgToolJobResult
+	msgToolSessionDefaults
+
+	msgPromptSummarize
+	msgPromptPinFunctions
+	msgPromptAbsoluteMaximum
+	msgPromptRegisterMap
+
+	msgConversionResult
+	msgConversionTitle
+	msgPartialTitle
+	msgFailedPagesNote
+	msgFailedPageLine
+	msgImagesNone
+	msgImagesOne
+	msgImagesMany
+	msgRedactionNote
+	msgRedactionLine
+	msgRedactionSection
+	msgBrokenLinkNote
+	msgRepairNote
+	msgVariantNote
+	msgPackageNote
+	msgCurveNote
+	msgBOMNote
+	msgComplianceNote
+	msgErrataNote
+	msgBatchSummaryNote
+	msgBatchResultsNote
+
+	msgBatchResult
+	msgBatchTitle
+	msgPortfolioTitle
+	msgInputDirectory
+	msgPortfolio
+	msgFilesFound
+	msgDocumentsFound
+	msgBatchErrors
+	msgBatchWarnings
+	msgBatchTableHeader
+
+	msgSplitResult
+	msgSplitSection
+	msgSplitSectionPartial
+
+	msgEstimate
+	msgEstimateSizeOnly
+	msgBatchEstimate
+	msgEstimateTableHeader
+	msgEstimateErrors
+
+	msgMetadataResult
+	msgMetadataUnencrypted
+	msgMetadataEncrypted
+	msgMetadataLocked
+
+	msgDocumentList
+	msgPreviewTruncated
+	msgDocumentListEmpty
+	msgDocumentListLocked
+
+	msgJobQueued
+	msgDocumentJobQueued
+	msgJobStatus
+	msgJobQueuePosition
+	msgJobError
+
+	msgSessionDefaults
+	msgSessionDefaultConfigured
+	msgSessionDefaultNoPreset
+)
+
+// messageCatalogs maps each LOCALE to its messages.
+var messageCatalogs = map[string]map[messageID]string{
+	"en": {
+		msgToolConvertPDF:       "Convert a single PDF, XPS/OpenXPS or DjVu file to Markdown format with extracted images. PDF portfolios are converted one embedded document at a time",
+		msgToolConvertPages:     "Convert only the selected pages of a PDF, XPS/OpenXPS or DjVu file to Markdown, e.g. \"1-10,15,20-\", to quickly pull a section out of a long datasheet",
+		msgToolConvertDirectory: "Convert all PDF, XPS/OpenXPS and DjVu files in a directory to Markdown format with extracted images",
+		msgToolConvertImages:    "Convert a directory of page scans (TIFF, PNG, JPEG), ordered by file name, into one Markdown document using OCR (requires tesseract) and diagram detection",
+		msgToolSplitPDF:         "Convert each top-level chapter of a PDF (from its bookmarks/outline) into its own Markdown output directory",
+		msgToolServerVersion:    "Report the server version, MCP protocol version, Go runtime and platform, and whether a newer release is available (when UPDATE_CHECK is enabled)",
+		msgToolServerStats:      "Report server uptime, conversions performed, pages and images processed, average conversion time and per-tool call statistics",
+		msgToolFindDatasheet:    "Find datasheets in the input directory by part number or keyword, matching file names and first page text, and return candidate files with a confidence",
+		msgToolLibraryStats:     "Report statistics of all converted documents in the output directory: documents, pages, images, tables, diagrams, quality scores and disk usage",
+		msgToolExtractMetadata:  "Read the document information of a PDF without converting it: title, author, subject, creator, producer, creation and modification dates, page count, PDF version and encryption status",
+		msgToolListPDFs:         "List the PDF, XPS/OpenXPS and DjVu files below a directory with their size, modification time and page count, to browse the input documents before converting them",
+		msgToolPreviewText:      "Return the Markdown of a PDF, or of a page range, directly in the response without writing any files, to inspect a document quickly. Images are left out",
+		msgToolJobStatus:        "Report the status of an asynchronous job: queued with its queue position, running, completed or failed, with its start and finish times",
+		msgToolJobResult:        "Return the result of a finished asynchronous job, the same result the tool call returns when it is not run as a job",
+		msgToolSessionDefaults:  "Set the output directory and preset used by this session's tool calls that pass none, without affecting other clients of the server",
+
+		msgPromptSummarize:       "Summarize a converted datasheet: device function, key features, electrical characteristics, packages and ordering information",
+		msgPromptPinFunctions:    "Extract the pin functions of a converted datasheet as a table of pin numbers, names, types and descriptions",
+		msgPromptAbsoluteMaximum: "Extract the absolute maximum ratings and recommended operating conditions of a converted datasheet",
+		msgPromptRegisterMap:     "Extract the register map of a converted datasheet: addresses, names, reset values and bit fields",
+
+		msgConversionResult: `%s
+
+Output Directory: %s
+Document File: %s
+Report File: %s
+Pages Processed: %d
+Images Extracted: %d
+Quality Score: %s
+Conversion Time: %s (%.1f pages/s; %s)
+
+The PDF has been converted to Markdown format with all text content preserved and structured with appropriate headers. %s%s%s%s%s`,
+		msgConversionTitle:  "PDF Conversion Completed Successfully",
+		msgPartialTitle:     "PDF Conversion Partially Completed (%d of %d pages failed)",
+		msgFailedPagesNote:  "\n\nFailed Pages: These pages could not be converted and are missing from the output; the other pages were converted.\n",
+		msgFailedPageLine:   "- Page %d: %s",
+		msgImagesNone:       "No images were found in the PDF or image extraction was disabled.",
+		msgImagesOne:        "One image was extracted and saved as a PNG file.",
+		msgImagesMany:       "All %d images were extracted and saved as PNG files.",
+		msgRedactionNote:    "\n\nRedactions Detected: The source document intentionally hides content; missing text on these pages is not a conversion error.\n",
+		msgRedactionLine:    "- Page %d: %d %s region(s), %.1f%% of the page",
+		msgRedactionSection: " (section %q)",
+		msgBrokenLinkNote:   "\n\nBroken Links: These links or images in the generated Markdown do not resolve and will fail a documentation site build.\n",
+		msgRepairNote:       "\n\nRepair Applied: The PDF was malformed; its cross-reference table was rebuilt by scanning the file before conversion. Check the output for missing content.",
+		msgVariantNote:      "\n\nPart Variants: %d orderable variant(s) from the ordering information tables were joined by part number into a comparison table and %s.",
+		msgPackageNote:      "\n\nPackage Dimensions: the dimensions of %d package(s) from the mechanical dimension tables were exported to %s.",
+		msgCurveNote:        "\n\nCurve Data: %d curve(s) of %d graph(s) were digitized to CSV files listed in %s.",
+		msgBOMNote:          "\n\nBill of Materials: %d component(s) of the typical application circuits were listed in %s.",
+		msgComplianceNote:   "\n\nCompliance: the document states %s, listed with their pages in %s.",
+		msgErrataNote:       "\n\nErrata: %d errata document(s) linked to their datasheets, with %d note(s) added to the affected datasheet sections.",
+		msgBatchSummaryNote: "\n\nBatch summary: %s",
+		msgBatchResultsNote: "\n\nBatch results: %s",
+
+		msgBatchResult: `%s
+
+%s: %s
+Output Directory: %s
+%s: %d
+Successfully Converted: %d
+Failed Conversions: %d
+Total Pages Processed: %d
+Total Images Extracted: %d
+
+%s
+%s%s%s`,
+		msgBatchTitle:       "Batch PDF Conversion Completed",
+		msgPortfolioTitle:   "PDF Portfolio Conversion Completed",
+		msgInputDirectory:   "Input Directory",
+		msgPortfolio:        "Portfolio",
+		msgFilesFound:       "PDF Files Found",
+		msgDocumentsFound:   "Embedded Documents Found",
+		msgBatchErrors:      "\n\nErrors occurred during processing:\n",
+		msgBatchWarnings:    "Warnings:\n",
+		msgBatchTableHeader: "| Status | File | Quality | Pages | Time | Warnings |\n|--------|------|---------|-------|------|----------|\n",
+
+		msgSplitResult: `PDF Section Split Completed
+
+Output Directory: %s
+Index File: %s
+Pages Processed: %d
+Sections Created: %d
+Conversion Time: %s
+
+%s
+%s%s%s`,
+		msgSplitSection:        "%d. %s (pages %d-%d) -> %s, quality %.1f/100, %s%s\n",
+		msgSplitSectionPartial: ", partial (%d page(s) failed)",
+
+		msgEstimate: `PDF Conversion Estimate (dry run, nothing written)
+
+File: %s
+Pages: %d
+Images: %d
+Estimated Conversion Time: %s
+Estimated Output Size: %s
+
+Estimated from the first %d page(s) with the current configuration. Documents whose later pages differ from the first ones (for example image-heavy appendices) may take longer.`,
+		msgEstimateSizeOnly: `PDF Conversion Estimate (dry run, nothing written)
+
+File: %s
+Estimated Output Size: %s
+
+Only the output size can be estimated for this format, from the input file size.`,
+		msgBatchEstimate: `Batch PDF Conversion Estimate (dry run, nothing written)
+
+Input Directory: %s
+Files Found: %d
+Total Pages: %d
+Total Images: %d
+Estimated Conversion Time: %s
+Estimated Output Size: %s
+
+%s%s`,
+		msgEstimateTableHeader: "| File | Pages | Images | Time | Size |\n|------|-------|--------|------|------|\n",
+		msgEstimateErrors:      "\nFiles that could not be estimated:\n",
+
+		msgMetadataResult: `PDF Metadata
+
+File: %s
+Title: %s
+Author: %s
+Subject: %s
+Keywords: %s
+Creator: %s
+Producer: %s
+Created: %s
+Modified: %s
+Pages: %s
+PDF Version: %s
+File Size: %s
+Encrypted: %s`,
+		msgMetadataUnencrypted: "no",
+		msgMetadataEncrypted:   "yes (readable without a password)",
+		msgMetadataLocked:      "yes, a password is required; the document information and pages cannot be read",
+
+		msgDocumentList:       "Documents in %s (%d files, %s):\n\n| File | Pages | Size | Modified |\n|------|-------|------|----------|\n",
+		msgPreviewTruncated:   "\n\n[Preview truncated after %d characters; select fewer pages or raise max_chars to see more]",
+		msgDocumentListEmpty:  "No PDF, XPS or DjVu files in %s.\n",
+		msgDocumentListLocked: "password required",
+
+		msgJobQueued: `Batch conversion of %s queued as job %s (position %d in the queue).
+Poll get_job_status with this job_id, and fetch the conversion result with get_job_result once the job has finished.`,
+		msgDocumentJobQueued: `Conversion of %s queued as job %s (position %d in the queue).
+Poll get_job_status with this job_id, and fetch the conversion result with get_job_result once the job has finished.`,
+		msgJobStatus: `Job %s
+
+Tool: %s
+Input: %s
+Status: %s
+Created: %s
+Started: %s
+Finished: %s`,
+		msgJobQueuePosition: "%s (position %d in the queue, %s priority)",
+		msgJobError:         "\nError: %s",
+
+		msgSessionDefaults: `Session defaults
+
+Output directory: %s
+Preset: %s`,
+		msgSessionDefaultConfigured: "%s (config default)",
+		msgSessionDefaultNoPreset:   "none (configured settings)",
+	},
+	"ja": {
+		msgToolConvertPDF:       "PDF、XPS/OpenXPS、DjVu ファイルを 1 つ、画像を抽出して Markdown 形式に変換します。PDF ポートフォリオは埋め込まれた文書ごとに変換します",
+		msgToolConvertPages:     "PDF、XPS/OpenXPS または DjVu ファイルの指定したページ (例: \"1-10,15,20-\") だけを Markdown に変換します。長いデータシートから特定のセクションを素早く取り出すのに使います",
+		msgToolConvertDirectory: "ディレクトリ内のすべての PDF、XPS/OpenXPS、DjVu ファイルを、画像を抽出して Markdown 形式に変換します",
+		msgToolConvertImages:    "ディレクトリ内のページスキャン画像 (TIFF、PNG、JPEG) をファイル名順に、OCR (tesseract が必要) と図の検出を使って 1 つの Markdown 文書に変換します",
+		msgToolSplitPDF:         "PDF のしおり (アウトライン) の最上位の章ごとに、個別の Markdown 出力ディレクトリへ変換します",
+		msgToolServerVersion:    "サーバーのバージョン、MCP プロトコルのバージョン、Go ランタイムとプラットフォーム、新しいリリースの有無 (UPDATE_CHECK が有効な場合) を表示します",
+		msgToolServerStats:      "サーバーの稼働時間、変換件数、処理したページ数と画像数、平均変換時間、ツールごとの呼び出し統計を表示します",
+		msgToolFindDatasheet:    "型番またはキーワードで入力ディレクトリのデータシートをファイル名と1ページ目のテキストから検索し、候補ファイルを信頼度付きで返します",
+		msgToolLibraryStats:     "出力ディレクトリ内のすべての変換済みドキュメントの統計 (ドキュメント数、ページ数、画像数、表の数、図の数、品質スコア、ディスク使用量) を表示します",
+		msgToolExtractMetadata:  "PDF を変換せずに文書情報 (タイトル、作成者、サブジェクト、作成アプリケーション、PDF 作成ツール、作成日時と更新日時、ページ数、PDF バージョン、暗号化の有無) を読み取ります",
+		msgToolListPDFs:         "ディレクトリ以下の PDF、XPS/OpenXPS、DjVu ファイルをサイズ、更新日時、ページ数とともに一覧表示します。変換する前に入力文書を確認するのに使います",
+		msgToolPreviewText:      "PDF 全体またはページ範囲の Markdown をファイルを書き出さずに応答で直接返し、文書をすばやく確認します。画像は含まれません",
+		msgToolJobStatus:        "非同期ジョブの状態 (待機中とキュー内の位置、実行中、完了、失敗) と開始・終了時刻を表示します",
+		msgToolJobResult:        "完了した非同期ジョブの結果を返します。ジョブとして実行しない場合のツール呼び出しと同じ結果です",
+		msgToolSessionDefaults:  "このセッションのツール呼び出しで指定がない場合に使う出力ディレクトリとプリセットを設定します。サーバーの他のクライアントには影響しません",
+
+		msgPromptSummarize:       "変換済みデータシートを要約します: デバイスの機能、主な特長、電気的特性、パッケージ、注文情報",
+		msgPromptPinFunctions:    "変換済みデータシートのピン機能を、ピン番号、名前、種類、説明の表として抽出します",
+		msgPromptAbsoluteMaximum: "変換済みデータシートの絶対最大定格と推奨動作条件を抽出します",
+		msgPromptRegisterMap:     "変換済みデータシートのレジスタマップ (アドレス、名前、リセット値、ビットフィールド) を抽出します",
+
+		msgConversionResult: `%s
+
+出力ディレクトリ: %s
+ドキュメント ファイル: %s
+レポートファイル: %s
+処理ページ数: %d
+抽出画像数: %d
+品質スコア: %s
+変換時間: %s (%.1f ページ/秒; %s)
+
+PDF を Markdown 形式に変換しました。テキストはすべて保持され、適切な見出しで構成されています。%s%s%s%s%s`,
+		msgConversionTitle:  "PDF の変換が完了しました",
+		msgPartialTitle:     "PDF の変換が一部完了しました (%d / %d ページが失敗)",
+		msgFailedPagesNote:  "\n\n失敗したページ: 次のページは変換できず、出力に含まれていません。その他のページは変換されました。\n",
+		msgFailedPageLine:   "- %d ページ: %s",
+		msgImagesNone:       "PDF に画像が見つからなかったか、画像の抽出が無効になっています。",
+		msgImagesOne:        "画像を 1 つ抽出し、PNG ファイルとして保存しました。",
+		msgImagesMany:       "%d 個の画像をすべて抽出し、PNG ファイルとして保存しました。",
+		msgRedactionNote:    "\n\n墨消しを検出しました: 元の文書は意図的に内容を隠しています。これらのページでテキストが欠けているのは変換エラーではありません。\n",
+		msgRedactionLine:    "- %d ページ: %d 個の %s 領域、ページの %.1f%%",
+		msgRedactionSection: " (セクション %q)",
+		msgBrokenLinkNote:   "\n\nリンク切れ: 生成された Markdown の次のリンクまたは画像は解決できず、ドキュメントサイトのビルドに失敗します。\n",
+		msgRepairNote:       "\n\n修復を適用しました: PDF が破損していたため、変換前にファイルを走査して相互参照テーブルを再構築しました。出力に欠落がないか確認してください。",
+		msgVariantNote:      "\n\n製品バリエーション: 注文情報の表から %d 件の注文可能なバリエーションを型番ごとにまとめ、比較表と %s に出力しました。",
+		msgPackageNote:      "\n\nパッケージ寸法: 外形寸法表から %d 種類のパッケージの寸法を %s に出力しました。",
+		msgCurveNote:        "\n\n特性曲線データ: %d 本の曲線 (%d 個のグラフ) を CSV ファイルに数値化しました。一覧は %s にあります。",
+		msgBOMNote:          "\n\n部品表: 代表的なアプリケーション回路の部品 %d 点を %s に出力しました。",
+		msgComplianceNote:   "\n\n適合規格: 文書に記載された規格は %s です。詳細は %s を参照してください。",
+		msgErrataNote:       "\n\n正誤表: %d 件の正誤表をデータシートにリンクし、該当するデータシートのセクションに注記を %d 件追加しました。",
+		msgBatchSummaryNote: "\n\n一括変換の概要: %s",
+		msgBatchResultsNote: "\n\n一括変換の結果: %s",
+
+		msgBatchResult: `%s
+
+%s: %s
+出力ディレクトリ: %s
+%s: %d
+変換成功: %d
+変換失敗: %d
+総処理ページ数: %d
+総抽出画像数: %d
+
+%s
+%s%s%s`,
+		msgBatchTitle:       "PDF の一括変換が完了しました",
+		msgPortfolioTitle:   "PDF ポートフォリオの変換が完了しました",
+		msgInputDirectory:   "入力ディレクトリ",
+		msgPortfolio:        "ポートフォリオ",
+		msgFilesFound:       "検出した PDF ファイル数",
+		msgDocumentsFound:   "埋め込み文書数",
+		msgBatchErrors:      "\n\n処理中にエラーが発生しました:\n",
+		msgBatchWarnings:    "警告:\n",
+		msgBatchTableHeader: "| 状態 | ファイル | 品質 | ページ | 時間 | 警告 |\n|------|----------|------|--------|------|------|\n",
+
+		msgSplitResult: `PDF のセクション分割が完了しました
+
+出力ディレクトリ: %s
+索引ファイル: %s
+処理ページ数: %d
+作成セクション数: %d
+変換時間: %s
+
+%s
+%s%s%s`,
+		msgSplitSection:        "%d. %s (%d-%d ページ) -> %s、品質 %.1f/100、%s%s\n",
+		msgSplitSectionPartial: "、一部完了 (%d ページが失敗)",
+
+		msgEstimate: `PDF 変換の見積もり (ドライラン、出力なし)
+
+ファイル: %s
+ページ数: %d
+画像数: %d
+推定変換時間: %s
+推定出力サイズ: %s
+
+現在の設定で最初の %d ページから見積もりました。後半のページが最初のページと異なる文書 (画像の多い付録など) では、さらに時間がかかる場合があります。`,
+		msgEstimateSizeOnly: `PDF 変換の見積もり (ドライラン、出力なし)
+
+ファイル: %s
+推定出力サイズ: %s
+
+この形式では、入力ファイルのサイズから出力サイズのみを見積もれます。`,
+		msgBatchEstimate: `PDF 一括変換の見積もり (ドライラン、出力なし)
+
+入力ディレクトリ: %s
+検出ファイル数: %d
+総ページ数: %d
+総画像数: %d
+推定変換時間: %s
+推定出力サイズ: %s
+
+%s%s`,
+		msgEstimateTableHeader: "| ファイル | ページ | 画像 | 時間 | サイズ |\n|----------|--------|------|------|--------|\n",
+		msgEstimateErrors:      "\n見積もれなかったファイル:\n",
+
+		msgMetadataResult: `PDF メタデータ
+
+ファイル: %s
+タイトル: %s
+作成者: %s
+サブジェクト: %s
+キーワード: %s
+作成アプリケーション: %s
+PDF 作成ツール: %s
+作成日時: %s
+更新日時: %s
+ページ数: %s
+PDF バージョン: %s
+ファイルサイズ: %s
+暗号化: %s`,
+		msgMetadataUnencrypted: "なし",
+		msgMetadataEncrypted:   "あり (パスワードなしで読み取り可能)",
+		msgMetadataLocked:      "あり、パスワードが必要なため文書情報とページを読み取れません",
+
+		msgDocumentList:       "%s の文書 (%d ファイル、%s):\n\n| ファイル | ページ | サイズ | 更新日時 |\n|----------|--------|--------|----------|\n",
+		msgPreviewTruncated:   "\n\n[プレビューは %d 文字で切り詰められました。続きを見るにはページを絞るか max_chars を増やしてください]",
+		msgDocumentListEmpty:  "%s に PDF、XPS、DjVu ファイルはありません。\n",
+		msgDocumentListLocked: "パスワードが必要",
+
+		msgJobQueued: `%s の一括変換をジョブ %s としてキューに追加しました (キュー内の位置: %d)。
+この job_id で get_job_status を呼び出して状態を確認し、ジョブの完了後に get_job_result で変換結果を取得してください。`,
+		msgDocumentJobQueued: `%s の変換をジョブ %s としてキューに追加しました (キュー内の位置: %d)。
+この job_id で get_job_status を呼び出して状態を確認し、ジョブの完了後に get_job_result で変換結果を取得してください。`,
+		msgJobStatus: `ジョブ %s
+
+ツール: %s
+入力: %s
+状態: %s
+作成日時: %s
+開始日時: %s
+終了日時: %s`,
+		msgJobQueuePosition: "%s (キュー内の位置: %d、優先度: %s)",
+		msgJobError:         "\nエラー: %s",
+
+		msgSessionDefaults: `セッションの既定値
+
+出力ディレクトリ: %s
+プリセット: %s`,
+		msgSessionDefaultConfigured: "%s (設定の既定値)",
+		msgSessionDefaultNoPreset:   "なし (設定どおり)",
+	},
+	"zh": {
+		msgToolConvertPDF:       "将单个 PDF、XPS/OpenXPS 或 DjVu 文件转换为 Markdown 格式并提取图像。PDF 文件包按其中嵌入的每个文档分别转换",
+		msgToolConvertPages:     "仅将 PDF、XPS/OpenXPS 或 DjVu 文件中选定的页面 (例如 \"1-10,15,20-\") 转换为 Markdown，用于从长篇数据手册中快速提取某个章节",
+		msgToolConvertDirectory: "将目录中的所有 PDF、XPS/OpenXPS 和 DjVu 文件转换为 Markdown 格式并提取图像",
+		msgToolConvertImages:    "将目录中的页面扫描图像 (TIFF、PNG、JPEG) 按文件名顺序，使用 OCR (需要 tesseract) 和图表检测转换为一个 Markdown 文档",
+		msgToolSplitPDF:         "根据 PDF 书签 (大纲) 将每个顶级章节转换到各自的 Markdown 输出目录",
+		msgToolServerVersion:    "报告服务器版本、MCP 协议版本、Go 运行时和平台，以及是否有更新的版本 (启用 UPDATE_CHECK 时)",
+		msgToolServerStats:      "报告服务器运行时间、转换次数、处理的页数和图像数、平均转换时间以及各工具的调用统计",
+		msgToolFindDatasheet:    "按型号或关键词在输入目录中查找数据手册，匹配文件名和首页文本，并返回带置信度的候选文件",
+		msgToolLibraryStats:     "报告输出目录中所有已转换文档的统计：文档数、页数、图像数、表格数、图表数、质量评分和磁盘占用",
+		msgToolExtractMetadata:  "无需转换即可读取 PDF 的文档信息：标题、作者、主题、创建程序、PDF 生成器、创建和修改日期、页数、PDF 版本及加密状态",
+		msgToolListPDFs:         "列出目录下的 PDF、XPS/OpenXPS 和 DjVu 文件及其大小、修改时间和页数，用于在转换前浏览输入文档",
+		msgToolPreviewText:      "直接在响应中返回 PDF 或页面范围的 Markdown，不写入任何文件，用于快速查看文档。不包含图像",
+		msgToolJobStatus:        "报告异步作业的状态：排队中及其队列位置、运行中、已完成或失败，以及开始和结束时间",
+		msgToolJobResult:        "返回已完成的异步作业的结果，与不作为作业运行时工具调用返回的结果相同",
+		msgToolSessionDefaults:  "设置本会话的工具调用未指定时使用的输出目录和预设，不影响服务器的其他客户端",
+
+		msgPromptSummarize:       "总结已转换的数据手册：器件功能、主要特性、电气特性、封装和订购信息",
+		msgPromptPinFunctions:    "以引脚编号、名称、类型和说明的表格形式提取已转换数据手册的引脚功能",
+		msgPromptAbsoluteMaximum: "提取已转换数据手册的绝对最大额定值和推荐工作条件",
+		msgPromptRegisterMap:     "提取已转换数据手册的寄存器映射：地址、名称、复位值和位字段",
+
+		msgConversionResult: `%s
+
+输出目录: %s
+文档文件: %s
+报告文件: %s
+处理页数: %d
+提取图像数: %d
+质量评分: %s
+转换时间: %s (%.1f 页/秒; %s)
+
+PDF 已转换为 Markdown 格式，保留了全部文本内容，并使用适当的标题组织结构。%s%s%s%s%s`,
+		msgConversionTitle:  "PDF 转换成功完成",
+		msgPartialTitle:     "PDF 部分转换完成 (%d / %d 页失败)",
+		msgFailedPagesNote:  "\n\n失败的页面: 以下页面无法转换，输出中缺少这些页面；其余页面已转换。\n",
+		msgFailedPageLine:   "- 第 %d 页: %s",
+		msgImagesNone:       "PDF 中未找到图像，或图像提取已禁用。",
+		msgImagesOne:        "已提取 1 个图像并保存为 PNG 文件。",
+		msgImagesMany:       "已提取全部 %d 个图像并保存为 PNG 文件。",
+		msgRedactionNote:    "\n\n检测到涂黑内容: 源文档有意隐藏了部分内容；这些页面缺少文本并非转换错误。\n",
+		msgRedactionLine:    "- 第 %d 页: %d 个 %s 区域，占页面的 %.1f%%",
+		msgRedactionSection: " (章节 %q)",
+		msgBrokenLinkNote:   "\n\n失效链接: 生成的 Markdown 中以下链接或图像无法解析，会导致文档站点构建失败。\n",
+		msgRepairNote:       "\n\n已修复: PDF 文件格式有误，转换前已通过扫描文件重建交叉引用表。请检查输出是否缺少内容。",
+		msgVariantNote:      "\n\n产品型号: 已按型号合并订购信息表中的 %d 个可订购型号，输出为对比表和 %s。",
+		msgPackageNote:      "\n\n封装尺寸: 已将机械尺寸表中 %d 个封装的尺寸导出到 %s。",
+		msgCurveNote:        "\n\n特性曲线数据: 已将 %d 条曲线（%d 个图表）数字化为 CSV 文件，列表见 %s。",
+		msgBOMNote:          "\n\n物料清单: 已将典型应用电路中的 %d 个元件列入 %s。",
+		msgComplianceNote:   "\n\n合规: 文档声明符合 %s，详见 %s。",
+		msgErrataNote:       "\n\n勘误表: 已将 %d 份勘误文档链接到其数据手册，并在受影响的数据手册章节中添加了 %d 条注释。",
+		msgBatchSummaryNote: "\n\n批量转换摘要: %s",
+		msgBatchResultsNote: "\n\n批量转换结果: %s",
+
+		msgBatchResult: `%s
+
+%s: %s
+输出目录: %s
+%s: %d
+转换成功: %d
+转换失败: %d
+总处理页数: %d
+总提取图像数: %d
+
+%s
+%s%s%s`,
+		msgBatchTitle:       "PDF 批量转换完成",
+		msgPortfolioTitle:   "PDF 文件包转换完成",
+		msgInputDirectory:   "输入目录",
+		msgPortfolio:        "文件包",
+		msgFilesFound:       "找到的 PDF 文件数",
+		msgDocumentsFound:   "嵌入文档数",
+		msgBatchErrors:      "\n\n处理过程中发生错误:\n",
+		msgBatchWarnings:    "警告:\n",
+		msgBatchTableHeader: "| 状态 | 文件 | 质量 | 页数 | 时间 | 警告 |\n|------|------|------|------|------|------|\n",
+
+		msgSplitResult: `PDF 章节拆分完成
+
+输出目录: %s
+索引文件: %s
+处理页数: %d
+创建章节数: %d
+转换时间: %s
+
+%s
+%s%s%s`,
+		msgSplitSection:        "%d. %s (第 %d-%d 页) -> %s，质量 %.1f/100，%s%s\n",
+		msgSplitSectionPartial: "，部分完成 (%d 页失败)",
+
+		msgEstimate: `PDF 转换估算 (试运行，未写入任何内容)
+
+文件: %s
+页数: %d
+图像数: %d
+预计转换时间: %s
+预计输出大小: %s
+
+根据当前配置从前 %d 页估算。后续页面与前几页差异较大的文档 (例如图像较多的附录) 可能耗时更长。`,
+		msgEstimateSizeOnly: `PDF 转换估算 (试运行，未写入任何内容)
+
+文件: %s
+预计输出大小: %s
+
+此格式只能根据输入文件大小估算输出大小。`,
+		msgBatchEstimate: `PDF 批量转换估算 (试运行，未写入任何内容)
+
+输入目录: %s
+找到的文件数: %d
+总页数: %d
+总图像数: %d
+预计转换时间: %s
+预计输出大小: %s
+
+%s%s`,
+		msgEstimateTableHeader: "| 文件 | 页数 | 图像 | 时间 | 大小 |\n|------|------|------|------|------|\n",
+		msgEstimateErrors:      "\n无法估算的文件:\n",
+
+		msgMetadataResult: `PDF 元数据
+
+文件: %s
+标题: %s
+作者: %s
+主题: %s
+关键词: %s
+创建程序: %s
+PDF 生成器: %s
+创建日期: %s
+修改日期: %s
+页数: %s
+PDF 版本: %s
+文件大小: %s
+加密: %s`,
+		msgMetadataUnencrypted: "否",
+		msgMetadataEncrypted:   "是 (无需密码即可读取)",
+		msgMetadataLocked:      "是，需要密码，无法读取文档信息和页面",
+
+		msgDocumentList:       "%s 中的文档 (%d 个文件，%s):\n\n| 文件 | 页数 | 大小 | 修改时间 |\n|------|------|------|----------|\n",
+		msgPreviewTruncated:   "\n\n[预览已在 %d 个字符处截断；请选择更少的页面或增大 max_chars 以查看更多]",
+		msgDocumentListEmpty:  "%s 中没有 PDF、XPS 或 DjVu 文件。\n",
+		msgDocumentListLocked: "需要密码",
+
+		msgJobQueued: `已将 %s 的批量转换加入队列，作业为 %s (队列位置: %d)。
+请使用此 job_id 调用 get_job_status 查询状态，作业完成后使用 get_job_result 获取转换结果。`,
+		msgDocumentJobQueued: `已将 %s 的转换加入队列，作业为 %s (队列位置: %d)。
+请使用此 job_id 调用 get_job_status 查询状态，作业完成后使用 get_job_result 获取转换结果。`,
+		msgJobStatus: `作业 %s
+
+工具: %s
+输入: %s
+状态: %s
+创建时间: %s
+开始时间: %s
+结束时间: %s`,
+		msgJobQueuePosition: "%s (队列位置: %d，优先级: %s)",
+		msgJobError:         "\n错误: %s",
+
+		msgSessionDefaults: `会话默认值
+
+输出目录: %s
+预设: %s`,
+		msgSessionDefaultConfigured: "%s (配置默认值)",
+		msgSessionDefaultNoPreset:   "无 (使用配置)",
+	},
+}
+
+// text returns the message in the configured LOCALE, falling back to English.
+func (h *MCPHandler) text(id messageID) string {
+	if message, ok := messageCatalogs[h.converter.Config().Locale][id]; ok {
+		return message
+	}
+	return messageCatalogs["en"][id]
+}
+
+// textf formats the message in the configured LOCALE.
+func (h *MCPHandler) textf(id messageID, args ...interface{}) string {
+	return fmt.Sprintf(h.text(id), args...)
+}
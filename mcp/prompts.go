@@ -0,0 +1,159 @@
+// Package mcp - Prompts.
+// This file implements the prompts capability: canned prompts for common datasheet tasks,
+// such as summarizing a datasheet or extracting its pin functions, that clients offer as
+// templates. Each prompt takes the path of a converted document and embeds its content in
+// the prompt, so the conversion output can be worked on without a tool call.
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// maxPromptDocumentBytes is the largest part of a converted document embedded in a prompt.
+// Longer documents are cut at a line boundary, with a note naming the file.
+const maxPromptDocumentBytes = 256 * 1024
+
+// datasheetPrompt is a prompt template offered by prompts/list.
+type datasheetPrompt struct {
+	name         string
+	description  messageID
+	instructions string
+}
+
+// datasheetPrompts are the prompts offered by the server, in listing order.
+var datasheetPrompts = []datasheetPrompt{
+	{
+		name:        "summarize_datasheet",
+		description: msgPromptSummarize,
+		instructions: `Summarize the datasheet below for an engineer evaluating the part. Cover:
+- what the device is and its typical applications
+- key features and main specifications (supply range, current consumption, speed or accuracy figures)
+- available packages and temperature grades
+- ordering information and part number variants
+Quote figures with their units and test conditions as stated in the datasheet, and say when information is missing rather than guessing.`,
+	},
+	{
+		name:        "extract_pin_functions",
+		description: msgPromptPinFunctions,
+		instructions: `Extract the pin functions of the device in the datasheet below as a Markdown table with the columns Pin, Name, Type (input, output, I/O, power, ground, analog, no connect) and Description.
+List one row per pin in pin number order. When the datasheet covers several packages, make one table per package and name the package above it. Keep pin names exactly as written in the datasheet, including overbars written as a leading "/" or "n".`,
+	},
+	{
+		name:        "extract_absolute_maximum_ratings",
+		description: msgPromptAbsoluteMaximum,
+		instructions: `Extract the absolute maximum ratings and the recommended operating conditions of the device in the datasheet below as two Markdown tables with the columns Parameter, Symbol, Min, Typ, Max, Unit and Conditions.
+Leave cells empty where the datasheet gives no value, keep footnotes as a list below each table, and do not mix the two tables: absolute maximum ratings are stress limits, not operating conditions.`,
+	},
+	{
+		name:        "extract_register_map",
+		description: msgPromptRegisterMap,
+		instructions: `Extract the register map of the device in the datasheet below. First list all registers as a Markdown table with the columns Address, Name, Access, Reset value and Description. Then, for each register with bit fields, give a table with the columns Bits, Field, Access, Reset and Description.
+Write addresses and reset values in hexadecimal as in the datasheet. If the datasheet has no register map, say so.`,
+	},
+}
+
+// markdownPathArgument is the argument of every prompt: the converted document to work on.
+var markdownPathArgument = map[string]interface{}{
+	"name":        "markdown_path",
+	"description": "Converted document: a README.md (or other output format file) or the MARKDOWN_* output directory holding it, relative to OUTPUT_BASE_DIR unless absolute",
+	"required":    true,
+}
+
+// handlePromptsList returns the prompts offered by the server.
+func (h *MCPHandler) handlePromptsList() map[string]interface{} {
+	prompts := []map[string]interface{}{}
+	for _, prompt := range datasheetPrompts {
+		prompts = append(prompts, map[string]interface{}{
+			"name":        prompt.name,
+			"description": h.text(prompt.description),
+			"arguments":   []map[string]interface{}{markdownPathArgument},
+		})
+	}
+	return map[string]interface{}{"prompts": prompts}
+}
+
+// handlePromptsGet returns the messages of a prompt with the converted document embedded.
+func (h *MCPHandler) handlePromptsGet(params map[string]interface{}) (map[string]interface{}, error) {
+	name, _ := params["name"].(string)
+	var prompt *datasheetPrompt
+	for i := range datasheetPrompts {
+		if datasheetPrompts[i].name == name {
+			prompt = &datasheetPrompts[i]
+		}
+	}
+	if prompt == nil {
+		return nil, fmt.Errorf("unknown prompt: %q", name)
+	}
+	arguments, _ := params["arguments"].(map[string]interface{})
+	markdownPath, _ := arguments["markdown_path"].(string)
+	if markdownPath == "" {
+		return nil, fmt.Errorf("missing required argument: markdown_path")
+	}
+
+	path, err := h.promptDocumentPath(markdownPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read converted document: %v", err)
+	}
+	document := string(data)
+	if len(document) > maxPromptDocumentBytes {
+		cut := strings.LastIndexByte(document[:maxPromptDocumentBytes], '\n') + 1
+		if cut == 0 {
+			cut = maxPromptDocumentBytes
+		}
+		document = document[:cut] + fmt.Sprintf("\n[Document truncated after %d of %d bytes; the rest is in %s]\n", cut, len(data), path)
+	}
+	h.logger.Debug("Prompt %s built from %s", prompt.name, path)
+
+	text := fmt.Sprintf("%s\n\nDatasheet (%s):\n\n%s", prompt.instructions, path, document)
+	return map[string]interface{}{
+		"description": h.text(prompt.description),
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": map[string]interface{}{"type": "text", "text": text}},
+		},
+	}, nil
+}
+
+// promptDocumentPath resolves the markdown_path argument of a prompt to the document file.
+// Relative paths are resolved against OUTPUT_BASE_DIR, and the path must stay inside it in
+// restricted mode and inside the client's roots when the client provides them. Output
+// directories resolve to the document file written to them.
+func (h *MCPHandler) promptDocumentPath(markdownPath string) (string, error) {
+	base := h.converter.Config().OutputBaseDir
+	path, err := h.sandboxPath(base, markdownPath, "markdown_path")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	roots, err := h.rootDirs()
+	if err != nil {
+		return "", err
+	}
+	if roots != nil {
+		if path, err = rootPath(roots, path, "markdown_path"); err != nil {
+			return "", err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("converted document not found: %v", err)
+	}
+	if info.IsDir() {
+		if path, err = pdfconv.OutputDocument(path); err != nil {
+			return "", err
+		}
+		// The document file itself may be a symbolic link leading out of the sandbox
+		return h.sandboxPath(base, path, "markdown_path")
+	}
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandlePromptsGet(t *testing.T) {
+	h := newConformanceHandler(t)
+	base := h.converter.Config().OutputBaseDir
+	dir := filepath.Join(base, "MARKDOWN_LM317")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# LM317\n\n| Pin | Name |\n|-----|------|\n| 1 | ADJ |\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, markdownPath := range []string{"MARKDOWN_LM317", dir, filepath.Join("MARKDOWN_LM317", "README.md")} {
+		result, err := h.handlePromptsGet(map[string]interface{}{"name": "extract_pin_functions", "arguments": map[string]interface{}{"markdown_path": markdownPath}})
+		if err != nil {
+			t.Fatalf("handlePromptsGet(%q) error = %v", markdownPath, err)
+		}
+		messages, _ := result["messages"].([]map[string]interface{})
+		if len(messages) != 1 || messages[0]["role"] != "user" {
+			t.Fatalf("expected one user message, got %v", result["messages"])
+		}
+		content, _ := messages[0]["content"].(map[string]interface{})
+		text, _ := content["text"].(string)
+		if !strings.HasPrefix(text, "Extract the pin functions") || !strings.Contains(text, "| 1 | ADJ |") {
+			t.Errorf("expected the instructions followed by the document, got:\n%s", text)
+		}
+	}
+
+	if _, err := h.handlePromptsGet(map[string]interface{}{"name": "summarize_datasheet", "arguments": map[string]interface{}{"markdown_path": "MARKDOWN_MISSING"}}); err == nil {
+		t.Error("expected an error for a missing output directory")
+	}
+	if err := os.Mkdir(filepath.Join(base, "MARKDOWN_EMPTY"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.handlePromptsGet(map[string]interface{}{"name": "summarize_datasheet", "arguments": map[string]interface{}{"markdown_path": "MARKDOWN_EMPTY"}}); err == nil || !strings.Contains(err.Error(), "no converted document") {
+		t.Errorf("expected an error for a directory without a document, got %v", err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret.md")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h.converter.Config().RestrictedMode = true
+	if _, err := h.handlePromptsGet(map[string]interface{}{"name": "summarize_datasheet", "arguments": map[string]interface{}{"markdown_path": outside}}); err == nil || !strings.Contains(err.Error(), "restricted mode") {
+		t.Errorf("expected restricted mode to reject a document outside OUTPUT_BASE_DIR, got %v", err)
+	}
+	if _, err := h.handlePromptsGet(map[string]interface{}{"name": "summarize_datasheet", "arguments": map[string]interface{}{"markdown_path": "MARKDOWN_LM317"}}); err != nil {
+		t.Errorf("expected restricted mode to allow documents inside OUTPUT_BASE_DIR, got %v", err)
+	}
+}
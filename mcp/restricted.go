@@ -0,0 +1,99 @@
+// Package mcp - Restricted mode.
+// This file limits the tools offered to untrusted clients when RESTRICTED_MODE is set:
+// only single file conversion is exposed, and its input and output paths must stay inside
+// the configured input and output directories.
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// restrictedTools lists the tools available in restricted mode. Batch conversion, scanned
+// image directories and section splitting are left out.
+var restrictedTools = map[string]bool{
+	"convert_pdf_to_markdown": true,
+	"convert_pdf_pages":       true,
+	"extract_pdf_metadata":    true,
+	"list_pdfs":               true,
+	"preview_pdf_text":        true,
+	"get_server_version":      true,
+	"get_server_stats":        true,
+}
+
+// restricted reports whether RESTRICTED_MODE is enabled.
+func (h *MCPHandler) restricted() bool {
+	return h.converter.Config().RestrictedMode
+}
+
+// toolAllowed reports whether a tool may be listed and called.
+func (h *MCPHandler) toolAllowed(name string) bool {
+	return !h.restricted() || restrictedTools[name]
+}
+
+// sandboxPath returns path unchanged outside restricted mode. In restricted mode relative
+// paths are resolved against base, and paths that lead outside base, directly or through a
+// symbolic link, are rejected. An empty base, which would resolve to the working directory,
+// rejects every path.
+func (h *MCPHandler) sandboxPath(base, path, parameter string) (string, error) {
+	if !h.restricted() {
+		return path, nil
+	}
+	if base == "" {
+		return "", fmt.Errorf("%s is not available in restricted mode without PDF_INPUT_DIR and OUTPUT_BASE_DIR", parameter)
+	}
+	root, err := resolvePath(base)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %v", base, err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %v", parameter, err)
+	}
+	if !insidePath(root, resolved) {
+		return "", fmt.Errorf("%s must be inside %s in restricted mode", parameter, base)
+	}
+	return resolved, nil
+}
+
+// insidePath reports whether the resolved path is root or below it.
+func insidePath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolvePath returns the absolute path with symbolic links resolved. Path elements that do
+// not exist yet, such as an output directory about to be created, are kept as they are
+// below their nearest existing parent. A symbolic link to a missing target is resolved to
+// its target, where writing through it would create the file.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	missing := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			return "", err
+		}
+		if target, err := os.Readlink(dir); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(dir), target)
+			}
+			if resolved, err = resolvePath(target); err != nil {
+				return "", err
+			}
+			return filepath.Join(resolved, missing), nil
+		}
+		missing = filepath.Join(filepath.Base(dir), missing)
+	}
+}
@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSandboxPath(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(base, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{filepath.Join(base, "a.pdf"), filepath.Join(outside, "x.pdf")} {
+		if err := os.WriteFile(file, []byte("%PDF-1.4"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"escape":   outside,
+		"dangling": filepath.Join(outside, "missing"),
+		"internal": filepath.Join(base, "sub"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(base, name)); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+
+	h := newConformanceHandler(t)
+	h.converter.Config().RestrictedMode = true
+	tests := []struct {
+		name string
+		base string
+		path string
+		want string // Resolved path, "" when the path is rejected
+	}{
+		{"relative inside", base, "a.pdf", filepath.Join(base, "a.pdf")},
+		{"absolute inside", base, filepath.Join(base, "sub", "b.pdf"), filepath.Join(base, "sub", "b.pdf")},
+		{"base itself", base, ".", base},
+		{"relative escape", base, "../x", ""},
+		{"relative escape below a subdirectory", base, "sub/../../x", ""},
+		{"absolute outside", base, filepath.Join(outside, "x.pdf"), ""},
+		{"sibling with the base as prefix", base, base + "-other/a.pdf", ""},
+		{"symlink to outside", base, "escape/x.pdf", ""},
+		{"missing directory below a symlink to outside", base, "escape/new/out", ""},
+		{"dangling symlink to outside", base, "dangling", ""},
+		{"symlink inside the base", base, "internal/b.pdf", filepath.Join(base, "sub", "b.pdf")},
+		{"output directory not created yet", base, "new/out", filepath.Join(base, "new", "out")},
+		{"empty base", "", "a.pdf", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.sandboxPath(tt.base, tt.path, "pdf_path")
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("sandboxPath(%q) = %q, want an error", tt.path, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("sandboxPath(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+
+	// Outside restricted mode paths are passed through unchanged
+	h.converter.Config().RestrictedMode = false
+	if got, err := h.sandboxPath(base, "../x", "pdf_path"); err != nil || got != "../x" {
+		t.Errorf("expected the path unchanged outside restricted mode, got %q, %v", got, err)
+	}
+}
+
+func TestRestrictedMode_Tools(t *testing.T) {
+	h := newConformanceHandler(t)
+	var all []string
+	for _, tool := range h.toolDefinitions() {
+		all = append(all, tool["name"].(string))
+	}
+
+	h.converter.Config().RestrictedMode = true
+	h.converter.Config().PDFInputDir = t.TempDir()
+	var listed []string
+	for _, tool := range h.handleToolsList()["tools"].([]map[string]interface{}) {
+		listed = append(listed, tool["name"].(string))
+	}
+	for _, name := range all {
+		if restrictedTools[name] {
+			if !h.toolAllowed(name) {
+				t.Errorf("expected %s allowed in restricted mode", name)
+			}
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			if h.toolAllowed(name) {
+				t.Errorf("expected %s rejected in restricted mode", name)
+			}
+			if slices.Contains(listed, name) {
+				t.Errorf("expected %s left out of tools/list", name)
+			}
+			_, err := h.handleToolsCall(context.Background(), map[string]interface{}{"name": name, "arguments": map[string]interface{}{}})
+			if err == nil || !strings.Contains(err.Error(), "disabled in restricted mode") {
+				t.Errorf("expected a call of %s rejected, got %v", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package mcp - Conversion result summaries.
+// This file builds the machine-readable summary of a single document conversion, returned
+// next to the text result so agents can find the output files, page statistics and images
+// of a conversion without parsing the localized text.
+package mcp
+
+import (
+	"encoding/json"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// ConversionSummary is the structuredContent of single document conversion results.
+type ConversionSummary struct {
+	Source       string                `json:"source"`
+	Status       string                `json:"status"` // "complete", or "partial" when some pages failed
+	OutputDir    string                `json:"output_dir"`
+	MarkdownFile string                `json:"markdown_file"`
+	Thumbnail    string                `json:"thumbnail,omitempty"`
+	PageCount    int                   `json:"page_count"`
+	ImageCount   int                   `json:"image_count"`
+	TableCount   int                   `json:"table_count"`
+	DiagramCount int                   `json:"diagram_count"`
+	Quality      float64               `json:"quality"` // Quality score from 0 to 100
+	DurationMS   int64                 `json:"duration_ms"`
+	Timings      pdfconv.PhaseTimings  `json:"timings"` // Per-phase times in milliseconds
+	Warnings     []string              `json:"warnings,omitempty"`
+	FailedPages  []pdfconv.PageFailure `json:"failed_pages,omitempty"`
+	Pages        []pdfconv.PageStats   `json:"pages"`
+	Images       []pdfconv.ImageFile   `json:"images"`
+}
+
+// DocumentList is the structuredContent of list_pdfs results.
+type DocumentList struct {
+	InputDir  string                 `json:"input_dir"`
+	Documents []pdfconv.DocumentFile `json:"documents"`
+}
+
+// summarizeConversion builds the summary of a single document conversion.
+func summarizeConversion(result *pdfconv.ConversionResult) ConversionSummary {
+	summary := ConversionSummary{
+		Source:       result.Source,
+		Status:       result.Status,
+		OutputDir:    result.OutputDir,
+		MarkdownFile: result.MarkdownFile,
+		Thumbnail:    result.Thumbnail,
+		PageCount:    result.PageCount,
+		ImageCount:   result.ImageCount,
+		TableCount:   result.TableCount,
+		DiagramCount: result.DiagramCount,
+		Quality:      result.Quality.Score,
+		DurationMS:   result.Duration.Milliseconds(),
+		Timings:      result.Timings,
+		Warnings:     result.Warnings(),
+		FailedPages:  result.FailedPages,
+		Pages:        result.Pages,
+		Images:       result.Images,
+	}
+	if summary.Pages == nil {
+		summary.Pages = []pdfconv.PageStats{}
+	}
+	if summary.Images == nil {
+		summary.Images = []pdfconv.ImageFile{}
+	}
+	return summary
+}
+
+// structuredToolResult returns a tool result with the text for people followed by data as
+// a JSON text block, for clients of protocol versions without structuredContent, and as
+// structuredContent.
+func structuredToolResult(text string, data interface{}) map[string]interface{} {
+	content := []map[string]interface{}{{"type": "text", "text": text}}
+	if encoded, err := json.MarshalIndent(data, "", "  "); err == nil {
+		content = append(content, map[string]interface{}{"type": "text", "text": string(encoded)})
+	}
+	return map[string]interface{}{
+		"content":           content,
+		"structuredContent": data,
+	}
+}
+
+// conversionToolResult returns the tool result of a single document conversion: the text
+// summary plus the conversion summary as JSON.
+func (h *MCPHandler) conversionToolResult(result *pdfconv.ConversionResult) map[string]interface{} {
+	return structuredToolResult(h.formatConversionResult(result), summarizeConversion(result))
+}
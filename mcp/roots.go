@@ -0,0 +1,112 @@
+// Package mcp - Client roots.
+// This file requests the filesystem roots of clients that declare the roots capability and
+// keeps the path arguments of tool calls inside them: relative paths, including the default
+// output directory, are resolved against the first root, and paths outside every root are
+// rejected, so the server only touches what the client intends to expose.
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// rootPathArguments are the tool arguments holding paths constrained to the client's roots.
+var rootPathArguments = []string{"pdf_path", "input_dir", "output_dir"}
+
+// loadRoots sends a roots/list request to the client and keeps the local directories of the
+// returned file:// roots. The previous roots are kept when the request fails, and until the
+// client first lists its roots, path arguments are rejected.
+func (h *MCPHandler) loadRoots() {
+	result, err := h.requestClient(context.Background(), "roots/list", map[string]interface{}{})
+	if err != nil {
+		h.logger.Warn("Failed to list client roots: %v", err)
+		return
+	}
+	entries, _ := result["roots"].([]interface{})
+	roots := []string{}
+	for _, entry := range entries {
+		root, _ := entry.(map[string]interface{})
+		uri, _ := root["uri"].(string)
+		u, err := url.Parse(uri)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			h.logger.Warn("Ignoring client root %q: only file:// roots are supported", uri)
+			continue
+		}
+		roots = append(roots, filepath.FromSlash(u.Path))
+	}
+	h.mu.Lock()
+	h.roots, h.rootsPending = roots, false
+	h.mu.Unlock()
+	h.logger.Info("Client roots: %s", strings.Join(roots, ", "))
+}
+
+// rootDirs returns the directories of the client's roots, nil when the client provided none.
+// Paths cannot be checked, and an error is returned, while the client has declared roots but
+// not listed them yet, or when it exposes no file:// roots.
+func (h *MCPHandler) rootDirs() ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case h.rootsPending:
+		return nil, fmt.Errorf("the client has not listed its roots yet; retry once it has answered roots/list")
+	case h.roots != nil && len(h.roots) == 0:
+		return nil, fmt.Errorf("the client exposes no file:// roots")
+	}
+	return h.roots, nil
+}
+
+// applyRoots resolves the path arguments of a tool call against the client's roots and
+// rejects paths outside them. Calls omitting an input_dir or output_dir the tool declares
+// get PDF_INPUT_DIR or OUTPUT_BASE_DIR, resolved the same way, so the configured defaults do
+// not lead outside the roots either. Calls with path arguments fail while the roots are not
+// known. It does nothing when the client did not declare roots.
+func (h *MCPHandler) applyRoots(toolName string, arguments map[string]interface{}) error {
+	roots, rootsErr := h.rootDirs()
+	if roots == nil && rootsErr == nil {
+		return nil
+	}
+	cfg := h.converter.Config()
+	properties := h.toolProperties(toolName)
+	if _, declared := properties["output_dir"]; declared && arguments["output_dir"] == nil && !toolHints[toolName].sessionOnly {
+		arguments["output_dir"] = cfg.OutputBaseDir
+	}
+	if _, declared := properties["input_dir"]; declared && arguments["input_dir"] == nil && cfg.PDFInputDir != "" {
+		arguments["input_dir"] = cfg.PDFInputDir
+	}
+	for _, name := range rootPathArguments {
+		path, ok := arguments[name].(string)
+		if !ok {
+			continue
+		}
+		if rootsErr != nil {
+			return rootsErr
+		}
+		resolved, err := rootPath(roots, path, name)
+		if err != nil {
+			return err
+		}
+		arguments[name] = resolved
+	}
+	return nil
+}
+
+// rootPath resolves a relative path against the first of roots and checks that the path is
+// inside one of them.
+func rootPath(roots []string, path, parameter string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(roots[0], path)
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %v", parameter, err)
+	}
+	for _, root := range roots {
+		if resolvedRoot, err := resolvePath(root); err == nil && insidePath(resolvedRoot, resolved) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%s must be inside the client's roots (%s)", parameter, strings.Join(roots, ", "))
+}
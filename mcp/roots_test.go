@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyRoots(t *testing.T) {
+	h := newConformanceHandler(t)
+	var roots []string
+	for i := 0; i < 2; i++ {
+		root, err := filepath.EvalSymlinks(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(roots[0], "escape")); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	h.roots = roots
+	h.converter.Config().PDFInputDir = outside
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+		want      map[string]interface{} // Arguments after resolution, nil when the call is rejected
+	}{
+		{"relative path against the first root", "extract_pdf_metadata", map[string]interface{}{"pdf_path": "a.pdf"}, map[string]interface{}{"pdf_path": filepath.Join(roots[0], "a.pdf")}},
+		{"absolute path in another root", "extract_pdf_metadata", map[string]interface{}{"pdf_path": filepath.Join(roots[1], "b.pdf")}, map[string]interface{}{"pdf_path": filepath.Join(roots[1], "b.pdf")}},
+		{"absolute path outside the roots", "extract_pdf_metadata", map[string]interface{}{"pdf_path": filepath.Join(outside, "x.pdf")}, nil},
+		{"relative escape", "list_pdfs", map[string]interface{}{"input_dir": "../x"}, nil},
+		{"sibling with a root as prefix", "list_pdfs", map[string]interface{}{"input_dir": roots[0] + "-other"}, nil},
+		{"symlink inside a root pointing outside", "extract_pdf_metadata", map[string]interface{}{"pdf_path": "escape/x.pdf"}, nil},
+		{"output directory not created yet", "convert_pdf_to_markdown", map[string]interface{}{"pdf_path": "a.pdf", "output_dir": "out/new"}, map[string]interface{}{"pdf_path": filepath.Join(roots[0], "a.pdf"), "output_dir": filepath.Join(roots[0], "out", "new")}},
+		{"default output directory outside the roots", "convert_pdf_to_markdown", map[string]interface{}{"pdf_path": "a.pdf"}, nil},
+		{"default output directory of a read-only tool", "get_library_stats", map[string]interface{}{}, nil},
+		{"default input directory outside the roots", "find_datasheet", map[string]interface{}{"query": "LM317"}, nil},
+		{"section split outside the roots", "split_pdf_by_sections", map[string]interface{}{"pdf_path": filepath.Join(outside, "x.pdf"), "output_dir": "out"}, nil},
+		{"batch output outside the roots", "convert_pdfs_in_directory", map[string]interface{}{"input_dir": "pdfs", "output_dir": outside}, nil},
+		{"read-only tool without an output directory", "preview_pdf_text", map[string]interface{}{"pdf_path": "a.pdf"}, map[string]interface{}{"pdf_path": filepath.Join(roots[0], "a.pdf")}},
+		{"session defaults without an output directory", "set_session_defaults", map[string]interface{}{"preset": "fast"}, map[string]interface{}{"preset": "fast"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.applyRoots(tt.tool, tt.arguments)
+			if tt.want == nil {
+				if err == nil || !strings.Contains(err.Error(), "must be inside the client's roots") {
+					t.Errorf("expected the call rejected, got %v, %v", tt.arguments, err)
+				}
+				return
+			}
+			if err != nil || fmt.Sprint(tt.arguments) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, %v, want %v", tt.arguments, err, tt.want)
+			}
+		})
+	}
+
+	// The default output directory is resolved like an argument when it is inside a root
+	h.converter.Config().OutputBaseDir = filepath.Join(roots[1], "output")
+	arguments := map[string]interface{}{"pdf_path": "a.pdf"}
+	if err := h.applyRoots("convert_pdf_to_markdown", arguments); err != nil || arguments["output_dir"] != filepath.Join(roots[1], "output") {
+		t.Errorf("expected the default output directory inside the roots, got %v, %v", arguments, err)
+	}
+
+	h.roots = []string{}
+	if err := h.applyRoots("extract_pdf_metadata", map[string]interface{}{"pdf_path": "a.pdf"}); err == nil || !strings.Contains(err.Error(), "no file:// roots") {
+		t.Errorf("expected calls rejected when the client exposes no roots, got %v", err)
+	}
+	h.roots, h.rootsPending = nil, true
+	if err := h.applyRoots("get_library_stats", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "not listed its roots yet") {
+		t.Errorf("expected calls rejected until the client lists its roots, got %v", err)
+	}
+	if err := h.applyRoots("get_job_status", map[string]interface{}{"job_id": "job-1"}); err != nil {
+		t.Errorf("expected calls without paths accepted while the roots are pending, got %v", err)
+	}
+	h.rootsPending = false
+	arguments = map[string]interface{}{"pdf_path": "../a.pdf"}
+	if err := h.applyRoots("extract_pdf_metadata", arguments); err != nil || arguments["pdf_path"] != "../a.pdf" {
+		t.Errorf("expected paths unchanged without client roots, got %v, %v", arguments, err)
+	}
+}
+
+func TestRoots_Stdio(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newConformanceHandler(t)
+	conn := connectStdio(t, h).(*stdioConn)
+	expectResult(1.0, nil)(t, conn.exchange(t, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{"roots":{"listChanged":true}},"clientInfo":{"name":"roots","version":"1.0"}}}`))
+
+	// answerRoots sends a notification and answers the roots/list request it triggers
+	answerRoots := func(notification string, roots ...string) {
+		t.Helper()
+		go func() { _, _ = io.WriteString(conn.in, notification+"\n") }()
+		line, err := conn.out.ReadBytes('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		var request MCPMessage
+		if err := json.Unmarshal(line, &request); err != nil || request.Method != "roots/list" {
+			t.Fatalf("expected a roots/list request, got %s", line)
+		}
+		entries := []map[string]interface{}{}
+		for _, root := range roots {
+			entries = append(entries, map[string]interface{}{"uri": root})
+		}
+		response, _ := json.Marshal(MCPMessage{JSONRPC: "2.0", ID: request.ID, Result: map[string]interface{}{"roots": entries}})
+		go func() { _, _ = io.WriteString(conn.in, string(response)+"\n") }()
+	}
+	listPDFs := func(id int, dir string) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"list_pdfs","arguments":{"input_dir":%q}}}`, id, dir)
+	}
+
+	// Until the client lists its roots, calls with paths are rejected rather than unrestricted
+	pending := singleResponse(t, conn.exchange(t, listPDFs(20, root)), 20.0)
+	if !strings.Contains(fmt.Sprint(pending), "has not listed its roots yet") {
+		t.Errorf("expected the call rejected while the roots are pending, got %v", pending)
+	}
+	expectResult(21.0, nil)(t, conn.exchange(t, `{"jsonrpc":"2.0","id":21,"method":"tools/call","params":{"name":"get_server_version"}}`))
+
+	fileURI := (&url.URL{Scheme: "file", Path: filepath.ToSlash(root)}).String()
+	answerRoots(`{"jsonrpc":"2.0","method":"notifications/initialized"}`, fileURI, "https://example.com/repo")
+	expectResult(2.0, nil)(t, conn.exchange(t, listPDFs(2, root)))
+	expectResult(3.0, nil)(t, conn.exchange(t, listPDFs(3, ".")))
+	expectToolError(4.0)(t, conn.exchange(t, listPDFs(4, filepath.Dir(root))))
+
+	// A changed root list replaces the roots
+	answerRoots(`{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}`, "https://example.com/repo")
+	expectToolError(5.0)(t, conn.exchange(t, listPDFs(5, root)))
+	if roots, err := h.rootDirs(); roots != nil || err == nil || !strings.Contains(err.Error(), "no file:// roots") {
+		t.Errorf("expected no usable roots after the change, got %v, %v", roots, err)
+	}
+}
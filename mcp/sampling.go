@@ -0,0 +1,94 @@
+// Package mcp - Sampling requests to the client.
+// This file sends sampling/createMessage requests to the MCP client while a tool call is
+// running, which lets the server caption extracted images with the client's model.
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// Image caption request settings
+const (
+	CaptionMaxTokens = 100 // Token limit of a caption response
+	captionPrompt    = "Write alt text for this figure from an electronics datasheet: one sentence of at most 20 words naming the kind of figure (block diagram, pinout, timing diagram, graph, package drawing, photo) and what it shows. Reply with the alt text only."
+)
+
+// imageCaptioner returns a captioner that asks the client's model to describe images, or nil
+// when captions are not configured or the client does not support sampling. The caption
+// requests of a cancelled tool call are cancelled through ctx.
+func (h *MCPHandler) imageCaptioner(ctx context.Context) pdfconv.ImageCaptioner {
+	h.mu.Lock()
+	clientSampling := h.clientSampling
+	h.mu.Unlock()
+	if !clientSampling || h.converter.Config().ImageAltText != "caption" {
+		return nil
+	}
+	return func(png []byte) (string, error) {
+		result, err := h.requestClient(ctx, "sampling/createMessage", map[string]interface{}{
+			"messages": []map[string]interface{}{
+				{"role": "user", "content": map[string]interface{}{"type": "image", "data": base64.StdEncoding.EncodeToString(png), "mimeType": "image/png"}},
+				{"role": "user", "content": map[string]interface{}{"type": "text", "text": captionPrompt}},
+			},
+			"includeContext": "none",
+			"maxTokens":      CaptionMaxTokens,
+		})
+		if err != nil {
+			return "", err
+		}
+		content, _ := result["content"].(map[string]interface{})
+		text, _ := content["text"].(string)
+		if content["type"] != "text" || strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("client returned no caption text")
+		}
+		return text, nil
+	}
+}
+
+// requestClient sends a request, such as sampling/createMessage, to the client and waits for
+// its response, which the reader goroutine of serve passes on. The request is cancelled
+// when ctx is, for example when the tool call making it is.
+func (h *MCPHandler) requestClient(ctx context.Context, method string, params map[string]interface{}) (map[string]interface{}, error) {
+	h.mu.Lock()
+	if h.awaiting == nil || h.out == nil {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("no client connection")
+	}
+	h.requestID++
+	id := fmt.Sprintf("server-%d", h.requestID)
+	key := requestKey(id)
+	response := make(chan MCPMessage, 1)
+	h.awaiting[key] = response
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.awaiting, key)
+		h.mu.Unlock()
+	}()
+
+	request := MCPMessage{JSONRPC: "2.0", ID: id, Method: method, Params: params}
+	if call := ctx.Value(toolCallKey{}); call != nil && h.relate != nil {
+		h.relate(id, call)
+	}
+	if err := h.send(request); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %v", method, err)
+	}
+	h.logger.Debug("Sent %s request %s", method, id)
+
+	select {
+	case message := <-response:
+		if message.Error != nil {
+			return nil, fmt.Errorf("client rejected %s request: %s (code %d)", method, message.Error.Message, message.Error.Code)
+		}
+		return message.Result, nil
+	case <-h.disconnected:
+		return nil, fmt.Errorf("client closed the connection before answering the %s request", method)
+	case <-ctx.Done():
+		_ = h.send(MCPMessage{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]interface{}{"requestId": id, "reason": "tool call cancelled"}})
+		return nil, fmt.Errorf("%s request cancelled: %v", method, ctx.Err())
+	}
+}
@@ -0,0 +1,76 @@
+// Package mcp - Session defaults.
+// This file keeps the defaults a client sets for its own MCP session with set_session_defaults:
+// the output directory and the conversion preset used when a tool call passes none. Network
+// transports run a handler per session ID, so assistants sharing one server do not clobber
+// each other's defaults, just as they only see their own jobs.
+package mcp
+
+import "fmt"
+
+// SessionDefaults are the defaults of a session, the structuredContent of
+// set_session_defaults. Empty fields fall back to the configuration.
+type SessionDefaults struct {
+	OutputDir string `json:"output_dir,omitempty"`
+	Preset    string `json:"preset,omitempty"`
+}
+
+// sessionDefaultArguments maps the tool arguments that take a session default to it.
+var sessionDefaultArguments = map[string]func(SessionDefaults) string{
+	"output_dir": func(d SessionDefaults) string { return d.OutputDir },
+	"preset":     func(d SessionDefaults) string { return d.Preset },
+}
+
+// applySessionDefaults fills in the session defaults for the arguments the tool declares and
+// the call omits, before they are resolved against the client's roots and the sandbox.
+func (h *MCPHandler) applySessionDefaults(toolName string, arguments map[string]interface{}) {
+	if toolName == "set_session_defaults" {
+		return
+	}
+	h.mu.Lock()
+	defaults := h.defaults
+	h.mu.Unlock()
+	properties := h.toolProperties(toolName)
+	for name, value := range sessionDefaultArguments {
+		if _, declared := properties[name]; declared && arguments[name] == nil && value(defaults) != "" {
+			arguments[name] = value(defaults)
+		}
+	}
+}
+
+// setSessionDefaults handles set_session_defaults: reset clears the defaults, then the
+// output_dir and preset arguments passed replace them, an empty string clearing one.
+func (h *MCPHandler) setSessionDefaults(arguments map[string]interface{}) (map[string]interface{}, error) {
+	h.mu.Lock()
+	defaults := h.defaults
+	h.mu.Unlock()
+	if reset, _ := arguments["reset"].(bool); reset {
+		defaults = SessionDefaults{}
+	}
+	if outputDir, ok := arguments["output_dir"].(string); ok {
+		defaults.OutputDir = outputDir
+	}
+	if preset, ok := arguments["preset"].(string); ok {
+		if _, err := h.converter.WithPreset(preset); err != nil {
+			return nil, fmt.Errorf("invalid preset: %v", err)
+		}
+		defaults.Preset = preset
+	}
+	h.mu.Lock()
+	h.defaults = defaults
+	h.mu.Unlock()
+	h.logger.Info("Session defaults set: output_dir=%q preset=%q", defaults.OutputDir, defaults.Preset)
+	return structuredToolResult(h.formatSessionDefaults(defaults), defaults), nil
+}
+
+// formatSessionDefaults describes the defaults of a session, naming the configured values
+// used for the defaults that are not set.
+func (h *MCPHandler) formatSessionDefaults(defaults SessionDefaults) string {
+	outputDir, preset := defaults.OutputDir, defaults.Preset
+	if outputDir == "" {
+		outputDir = h.textf(msgSessionDefaultConfigured, h.converter.Config().OutputBaseDir)
+	}
+	if preset == "" {
+		preset = h.text(msgSessionDefaultNoPreset)
+	}
+	return h.textf(msgSessionDefaults, outputDir, preset)
+}
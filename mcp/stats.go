@@ -0,0 +1,117 @@
+// Package mcp - Server execution statistics.
+// This file tracks per-tool call counts and timings together with global conversion totals,
+// reported by the get_server_stats tool for long-running shared servers.
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverStats accumulates execution statistics for the lifetime of the server process.
+type serverStats struct {
+	mu             sync.Mutex
+	started        time.Time
+	tools          map[string]*toolStats
+	active         int           // Tool calls currently executing
+	conversions    int           // PDFs converted successfully
+	pages          int           // Pages processed across all conversions
+	images         int           // Images extracted across all conversions
+	conversionTime time.Duration // Total time spent in conversion tools
+}
+
+// toolStats holds the counters for a single tool.
+type toolStats struct {
+	calls  int
+	errors int
+	total  time.Duration
+}
+
+// newServerStats creates an empty statistics collector starting now.
+func newServerStats() *serverStats {
+	return &serverStats{started: time.Now(), tools: map[string]*toolStats{}}
+}
+
+// begin marks the start of a tool call.
+func (s *serverStats) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active++
+}
+
+// end records the outcome and duration of a tool call started with begin.
+func (s *serverStats) end(tool string, elapsed time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	t, ok := s.tools[tool]
+	if !ok {
+		t = &toolStats{}
+		s.tools[tool] = t
+	}
+	t.calls++
+	t.total += elapsed
+	if err != nil {
+		t.errors++
+	}
+}
+
+// recordConversion adds the totals of a successful conversion tool call.
+func (s *serverStats) recordConversion(pdfs, pages, images int, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversions += pdfs
+	s.pages += pages
+	s.images += images
+	s.conversionTime += elapsed
+}
+
+// report renders the statistics as the text returned by get_server_stats.
+func (s *serverStats) report() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	avgConversion := "n/a"
+	if s.conversions > 0 {
+		avgConversion = (s.conversionTime / time.Duration(s.conversions)).Round(time.Millisecond).String()
+	}
+
+	names := make([]string, 0, len(s.tools))
+	for name := range s.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var tools strings.Builder
+	if len(names) == 0 {
+		tools.WriteString("- none yet\n")
+	}
+	for _, name := range names {
+		t := s.tools[name]
+		avg := (t.total / time.Duration(t.calls)).Round(time.Millisecond)
+		tools.WriteString(fmt.Sprintf("- %s: %d calls, %d errors, avg %s\n", name, t.calls, t.errors, avg))
+	}
+
+	return fmt.Sprintf(`Server Statistics
+
+Uptime: %s
+Conversions Performed: %d
+Pages Processed: %d
+Images Extracted: %d
+Average Conversion Time: %s
+Cache Hit Rate: n/a (no conversion cache configured)
+Active Tool Calls (including this one): %d
+
+Per-Tool Statistics:
+%s`,
+		time.Since(s.started).Round(time.Second),
+		s.conversions,
+		s.pages,
+		s.images,
+		avgConversion,
+		s.active,
+		tools.String(),
+	)
+}
@@ -0,0 +1,93 @@
+// Package mcp - Client message reading.
+// This file reads the newline-delimited JSON-RPC messages of the stdio transport. Unlike
+// bufio.Scanner, whose 64 KB token limit ended the message loop on the first tool call
+// carrying a base64 PDF, the reader accepts messages of any length up to MAX_MESSAGE_SIZE_MB
+// and skips an oversized message with an error instead of closing the connection.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// messageReadBufferSize is the read buffer of the message reader; longer messages are
+// assembled from several reads.
+const messageReadBufferSize = 64 * 1024
+
+// messageReader reads client messages, one per line.
+type messageReader struct {
+	r     *bufio.Reader
+	limit int     // Largest message in bytes (0 = unlimited)
+	trace *tracer // Records the messages read when MCP_TRACE is enabled
+}
+
+// messageTooLargeError reports a client message that exceeded the size limit. The message
+// has been read and discarded, so the next message can still be read.
+type messageTooLargeError struct {
+	size  int // Bytes in the discarded message, 0 when not read to the end
+	limit int // Configured limit in bytes
+}
+
+func (e *messageTooLargeError) Error() string {
+	if e.size == 0 {
+		return fmt.Sprintf("message exceeds the maximum message size of %d bytes (MAX_MESSAGE_SIZE_MB=%d)", e.limit, e.limit>>20)
+	}
+	return fmt.Sprintf("message of %d bytes exceeds the maximum message size of %d bytes (MAX_MESSAGE_SIZE_MB=%d)", e.size, e.limit, e.limit>>20)
+}
+
+// newMessageReader returns a reader of the messages in r that rejects messages longer than
+// maxSizeMB megabytes, or none when maxSizeMB is 0.
+func newMessageReader(r io.Reader, maxSizeMB int) *messageReader {
+	return &messageReader{r: bufio.NewReaderSize(r, messageReadBufferSize), limit: maxSizeMB << 20}
+}
+
+// next returns the next non-empty message. It returns io.EOF when the client closed the
+// stream and a *messageTooLargeError for a message over the limit.
+func (m *messageReader) next() ([]byte, error) {
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line = bytes.TrimRight(line, "\r"); len(line) > 0 {
+			m.trace.record("<-", line)
+			return line, nil
+		}
+	}
+}
+
+// readLine reads up to the next newline, which is not included. Once a line exceeds the
+// limit its content is dropped while the rest of it is skipped.
+func (m *messageReader) readLine() ([]byte, error) {
+	var line []byte
+	size := 0
+	for {
+		chunk, err := m.r.ReadSlice('\n')
+		if err == nil {
+			chunk = chunk[:len(chunk)-1]
+		}
+		size += len(chunk)
+		if m.limit > 0 && size > m.limit {
+			line = nil
+		} else {
+			line = append(line, chunk...)
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && size > 0:
+			// Last message without a trailing newline
+		case err != nil:
+			return nil, err
+		}
+		if m.limit > 0 && size > m.limit {
+			err := &messageTooLargeError{size: size, limit: m.limit}
+			m.trace.record("<-", []byte(err.Error()))
+			return nil, err
+		}
+		return line, nil
+	}
+}
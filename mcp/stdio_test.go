@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageReader(t *testing.T) {
+	const limit = 1 << 20
+	large := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"data":"` + strings.Repeat("A", 100*1024) + `"}}`
+	oversized := `{"jsonrpc":"2.0","id":2,"method":"ping","params":{"data":"` + strings.Repeat("A", limit) + `"}}`
+	valid := `{"jsonrpc":"2.0","id":3,"method":"ping"}`
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Messages expected in turn; "too large" for a rejected message
+	}{
+		{"message larger than the read buffer", large + "\n", []string{large}},
+		{"over-limit message followed by a valid message", oversized + "\n" + valid + "\n", []string{"too large", valid}},
+		{"over-limit final message without a newline", valid + "\n" + oversized, []string{valid, "too large"}},
+		{"final message without a trailing newline", valid + "\n" + large, []string{valid, large}},
+		{"CRLF line endings", valid + "\r\n\r\n" + large + "\r\n", []string{valid, large}},
+		{"blank lines", "\n\n" + valid + "\n\n", []string{valid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newMessageReader(strings.NewReader(tt.input), 1)
+			for i, want := range tt.want {
+				message, err := reader.next()
+				var tooLarge *messageTooLargeError
+				switch {
+				case want == "too large":
+					if !errors.As(err, &tooLarge) || tooLarge.size <= limit || tooLarge.limit != limit {
+						t.Fatalf("message %d: expected a message too large error, got %v", i, err)
+					}
+				case err != nil:
+					t.Fatalf("message %d: unexpected error %v", i, err)
+				case string(message) != want:
+					t.Fatalf("message %d: got %d bytes, want %d bytes", i, len(message), len(want))
+				}
+			}
+			if message, err := reader.next(); err != io.EOF {
+				t.Errorf("expected io.EOF after the messages, got %q, %v", message, err)
+			}
+		})
+	}
+
+	// Without a limit any size is accepted
+	reader := newMessageReader(strings.NewReader(oversized+"\n"), 0)
+	if message, err := reader.next(); err != nil || len(message) != len(oversized) {
+		t.Errorf("expected an unlimited reader to accept %d bytes, got %d, %v", len(oversized), len(message), err)
+	}
+}
+
+func TestServe_OversizedMessage(t *testing.T) {
+	h := newConformanceHandler(t)
+	h.converter.Config().MaxMessageSizeMB = 1
+	oversized := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"data":"` + strings.Repeat("A", 1<<20) + `"}}`
+	var out bytes.Buffer
+	if err := h.serve(strings.NewReader(oversized+"\r\n"+`{"jsonrpc":"2.0","id":2,"method":"ping"}`), &out); err != nil {
+		t.Fatalf("serve() error = %v", err)
+	}
+
+	var responses []MCPMessage
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var response MCPMessage
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected two responses, got %+v", responses)
+	}
+	if responses[0].Error == nil || responses[0].Error.Code != -32600 || responses[0].ID != nil {
+		t.Errorf("expected a message too large error without an id, got %+v", responses[0])
+	}
+	if responses[1].Error != nil || responses[1].ID != 2.0 {
+		t.Errorf("expected the ping after the oversized message answered, got %+v", responses[1])
+	}
+}
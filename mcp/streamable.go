@@ -0,0 +1,587 @@
+// Package mcp - Streamable HTTP transport.
+// This file implements the Streamable HTTP transport of the 2025-03-26 protocol on the single
+// /mcp endpoint, for clients that no longer speak the older HTTP+SSE transport. Clients POST
+// JSON-RPC messages, alone or batched. A POST carrying requests is answered with an event
+// stream that carries the server's requests made while handling them and ends with their
+// responses, or with a JSON body for clients that do not accept event streams. The
+// initialize response assigns the session ID that later requests send in the
+// Mcp-Session-Id header. A GET opens a stream for server messages unrelated to a POST. Every
+// event has an ID, so a client can resume a dropped stream with a GET carrying
+// Last-Event-ID, and DELETE ends the session.
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Streamable HTTP transport settings
+const (
+	streamableEndpointPath = "/mcp"           // Single endpoint of the transport
+	sessionIDHeader        = "Mcp-Session-Id" // Header carrying the session ID
+	streamHistory          = 256              // Events kept per stream for resuming it
+	streamableSessionIdle  = 30 * time.Minute // Sessions unused this long are ended
+	streamableReapInterval = time.Minute      // Interval of the checks for idle sessions
+)
+
+// streamableSession is the state of one Streamable HTTP session. It is the output of the
+// session's handler and routes each message to the stream it belongs on.
+type streamableSession struct {
+	id    string
+	queue *messageQueue // Client messages for the session's handler
+
+	mu         sync.Mutex
+	streams    map[int]*eventStream // Event streams by ID; 0 is the stream opened with GET
+	nextStream int                  // ID of the next POST stream
+	requests   []pendingRequest     // Client requests awaiting a response, in arrival order
+	related    map[string]string    // Keys of the tool calls that requests to the client are made for
+	lastSeen   time.Time            // Time of the last HTTP request or stream of the session
+}
+
+// pendingRequest is a client request and the stream its response goes to.
+type pendingRequest struct {
+	key    string // JSON encoding of the request ID
+	stream int
+	call   bool // Whether it is a tool call, which is not answered once cancelled
+}
+
+// eventStream holds the events of a stream until they are delivered, and the last
+// streamHistory of them for resuming the stream.
+type eventStream struct {
+	events    []string      // Data of the kept events
+	first     int           // Sequence number of events[0]; the first event is 1
+	delivered int           // Sequence number of the last event written to a connection
+	awaiting  int           // Responses still to be sent on a POST stream
+	jsonOnly  bool          // Whether the POST is answered with JSON instead of events
+	attached  bool          // Whether a connection is delivering the stream
+	wake      chan struct{} // Signaled when an event is added
+}
+
+// newEventStream returns an empty stream.
+func newEventStream() *eventStream {
+	return &eventStream{first: 1, wake: make(chan struct{}, 1)}
+}
+
+// add appends an event, dropping the oldest beyond streamHistory.
+func (st *eventStream) add(data string) {
+	st.events = append(st.events, data)
+	if drop := len(st.events) - streamHistory; drop > 0 {
+		st.events = st.events[drop:]
+		st.first += drop
+	}
+	st.signal()
+}
+
+// signal wakes the connection delivering the stream.
+func (st *eventStream) signal() {
+	select {
+	case st.wake <- struct{}{}:
+	default:
+	}
+}
+
+// last returns the sequence number of the newest event.
+func (st *eventStream) last() int {
+	return st.first + len(st.events) - 1
+}
+
+// Write routes a message of the session's handler: a response to the stream of the POST
+// that carried its request, a request made for a tool call to the stream of the call,
+// another message to the stream of the oldest request awaiting its response, and anything
+// else to the GET stream.
+func (s *streamableSession) Write(p []byte) (int, error) {
+	var message struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+	}
+	_ = json.Unmarshal(p, &message)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream := 0
+	if message.Method == "" {
+		key := requestKey(message.ID)
+		for i, request := range s.requests {
+			if request.key == key {
+				stream = request.stream
+				s.requests = append(s.requests[:i], s.requests[i+1:]...)
+				break
+			}
+		}
+		if st := s.streams[stream]; stream != 0 && st != nil {
+			st.awaiting--
+		}
+	} else {
+		var request *pendingRequest
+		if len(s.requests) > 0 {
+			request = &s.requests[0]
+		}
+		if call, ok := s.related[requestKey(message.ID)]; ok {
+			delete(s.related, requestKey(message.ID))
+			for i := range s.requests {
+				if s.requests[i].key == call {
+					request = &s.requests[i]
+					break
+				}
+			}
+		}
+		if request != nil {
+			if st := s.streams[request.stream]; st != nil && !st.jsonOnly {
+				stream = request.stream
+			}
+		}
+	}
+	st := s.streams[stream]
+	if st == nil {
+		st = s.streams[0]
+	}
+	st.add(string(bytes.TrimRight(p, "\n")))
+	return len(p), nil
+}
+
+// relate records the tool call that a request to the client is made for, so the request
+// is sent on the stream of the call.
+func (s *streamableSession) relate(request, call interface{}) {
+	s.mu.Lock()
+	s.related[requestKey(request)] = requestKey(call)
+	s.mu.Unlock()
+}
+
+// cancel stops waiting for the response to a tool call that the client cancelled, since
+// the handler does not answer it.
+func (s *streamableSession) cancel(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, request := range s.requests {
+		if request.key == key && request.call {
+			s.requests = append(s.requests[:i], s.requests[i+1:]...)
+			if st := s.streams[request.stream]; st != nil {
+				st.awaiting--
+				st.signal()
+			}
+			return
+		}
+	}
+}
+
+// requestKey identifies a request ID independent of its Go type.
+func requestKey(id interface{}) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// handleStreamable serves the /mcp endpoint.
+func (t *httpTransport) handleStreamable(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		t.handleStreamablePost(w, r)
+	case http.MethodGet:
+		t.handleStreamableGet(w, r)
+	case http.MethodDelete:
+		session, ok := t.streamableSession(w, r)
+		if !ok {
+			return
+		}
+		t.endStreamable(session)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleStreamablePost queues the POSTed messages for the session's handler. A POST without
+// requests is answered with 202 Accepted; otherwise the responses are streamed or returned
+// as JSON once all requests have been handled or cancelled.
+func (t *httpTransport) handleStreamablePost(w http.ResponseWriter, r *http.Request) {
+	data, ok := t.readBody(w, r)
+	if !ok {
+		return
+	}
+	if !json.Valid(data) {
+		writeJSONRPCError(w, http.StatusBadRequest, -32700, "Parse error", "body is not valid JSON")
+		return
+	}
+	messages, batch, err := splitMessages(data)
+	if err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, -32600, "Invalid Request", err.Error())
+		return
+	}
+
+	var requests []pendingRequest
+	var cancelled []string
+	initialize := false
+	for _, data := range messages {
+		// Answers to messages without a usable ID could not be routed to this POST, so they
+		// are answered here
+		var message MCPMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "Invalid Request", "message must be a JSON-RPC request object with object params")
+			return
+		}
+		if message.Method == "" && (message.Result != nil || message.Error != nil) {
+			continue // Response to a request of the server
+		}
+		if message.hasID && !validID(message.ID) {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "Invalid Request", "id must be a string or a number")
+			return
+		}
+		initialize = initialize || message.Method == "initialize"
+		if message.hasID {
+			requests = append(requests, pendingRequest{key: requestKey(message.ID), call: message.Method == "tools/call"})
+		} else if message.Method == "notifications/cancelled" {
+			cancelled = append(cancelled, requestKey(message.Params["requestId"]))
+		}
+	}
+
+	var session *streamableSession
+	if initialize {
+		if len(messages) > 1 {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "Invalid Request", "initialize must not be batched")
+			return
+		}
+		if session, err = t.newStreamableSession(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(sessionIDHeader, session.id)
+	} else if session, ok = t.streamableSession(w, r); !ok {
+		return
+	}
+
+	for _, key := range cancelled {
+		session.cancel(key)
+	}
+	if len(requests) == 0 {
+		if t.enqueue(w, r, session, messages) {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		return
+	}
+
+	jsonOnly := !acceptsEventStream(r)
+	session.mu.Lock()
+	session.nextStream++
+	streamID := session.nextStream
+	st := newEventStream()
+	st.awaiting, st.jsonOnly, st.attached = len(requests), jsonOnly, true
+	session.streams[streamID] = st
+	for _, request := range requests {
+		request.stream = streamID
+		session.requests = append(session.requests, request)
+	}
+	session.mu.Unlock()
+
+	if !t.enqueue(w, r, session, messages) {
+		return
+	}
+	if jsonOnly {
+		t.writeJSONResponses(w, r, session, streamID, batch)
+		return
+	}
+	t.streamEvents(w, r, session, streamID, 0)
+}
+
+// handleStreamableGet opens the session's stream for server messages unrelated to a POST,
+// or resumes a dropped stream after the event named in Last-Event-ID.
+func (t *httpTransport) handleStreamableGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusMethodNotAllowed)
+		return
+	}
+	session, ok := t.streamableSession(w, r)
+	if !ok {
+		return
+	}
+
+	streamID, after := 0, -1
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if _, err := fmt.Sscanf(lastEventID, "%d-%d", &streamID, &after); err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	session.mu.Lock()
+	st := session.streams[streamID]
+	switch {
+	case st == nil:
+		session.mu.Unlock()
+		http.Error(w, "unknown event stream", http.StatusNotFound)
+		return
+	case st.attached:
+		session.mu.Unlock()
+		http.Error(w, "event stream already open", http.StatusConflict)
+		return
+	}
+	st.attached = true
+	if after < 0 {
+		// Messages sent while no GET stream was open are delivered now
+		after = st.delivered
+	}
+	session.mu.Unlock()
+
+	t.streamEvents(w, r, session, streamID, after)
+}
+
+// streamEvents delivers the events of a stream after sequence number after, until a POST
+// stream has sent all its responses or the client disconnects. The stream must have been
+// attached by the caller.
+func (t *httpTransport) streamEvents(w http.ResponseWriter, r *http.Request, session *streamableSession, streamID, after int) {
+	defer func() {
+		session.mu.Lock()
+		session.lastSeen = time.Now()
+		if st := session.streams[streamID]; st != nil {
+			st.attached = false
+			if streamID != 0 && st.awaiting <= 0 && st.delivered >= st.last() {
+				delete(session.streams, streamID)
+			}
+		}
+		session.mu.Unlock()
+	}()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		session.mu.Lock()
+		st := session.streams[streamID]
+		var events []string
+		first := max(after+1, st.first)
+		for seq := first; seq <= st.last(); seq++ {
+			events = append(events, st.events[seq-st.first])
+		}
+		complete := streamID != 0 && st.awaiting <= 0
+		wake := st.wake
+		session.mu.Unlock()
+
+		for i, data := range events {
+			if _, err := fmt.Fprintf(w, "id: %d-%d\nevent: message\ndata: %s\n\n", streamID, first+i, data); err != nil {
+				return
+			}
+			flusher.Flush()
+			after = first + i
+			session.mu.Lock()
+			st.delivered = after
+			session.mu.Unlock()
+		}
+		if complete {
+			return
+		}
+
+		select {
+		case <-wake:
+		case <-r.Context().Done():
+			return
+		case <-session.queue.done:
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeJSONResponses waits until all requests of a POST stream have been answered and
+// writes the responses as the JSON body: one object, or an array for a batch. A POST whose
+// requests were all cancelled is answered with 202 Accepted.
+func (t *httpTransport) writeJSONResponses(w http.ResponseWriter, r *http.Request, session *streamableSession, streamID int, batch bool) {
+	defer func() {
+		session.mu.Lock()
+		delete(session.streams, streamID)
+		session.mu.Unlock()
+	}()
+	for {
+		session.mu.Lock()
+		st := session.streams[streamID]
+		complete, events, wake := st.awaiting <= 0, st.events, st.wake
+		session.mu.Unlock()
+
+		if complete {
+			if len(events) == 0 {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if len(events) == 1 && !batch {
+				fmt.Fprintln(w, events[0])
+			} else {
+				fmt.Fprintf(w, "[%s]\n", strings.Join(events, ","))
+			}
+			return
+		}
+		select {
+		case <-wake:
+		case <-r.Context().Done():
+			return
+		case <-session.queue.done:
+			http.Error(w, "session ended", http.StatusNotFound)
+			return
+		}
+	}
+}
+
+// enqueue passes messages to the session's handler. On failure the error has been answered.
+func (t *httpTransport) enqueue(w http.ResponseWriter, r *http.Request, session *streamableSession, messages []json.RawMessage) bool {
+	for _, message := range messages {
+		select {
+		case session.queue.messages <- append(message, '\n'):
+		case <-session.queue.done:
+			http.Error(w, "session ended", http.StatusNotFound)
+			return false
+		case <-r.Context().Done():
+			return false
+		}
+	}
+	return true
+}
+
+// newStreamableSession starts a session and its handler.
+func (t *httpTransport) newStreamableSession() (*streamableSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	session := &streamableSession{
+		id:       id,
+		queue:    newMessageQueue(),
+		streams:  map[int]*eventStream{0: newEventStream()},
+		related:  make(map[string]string),
+		lastSeen: time.Now(),
+	}
+	t.mu.Lock()
+	t.streamable[id] = session
+	t.mu.Unlock()
+
+	handler := t.base.newSession(id)
+	handler.relate = session.relate
+	go func() {
+		if err := handler.serve(session.queue, session); err != nil {
+			t.base.logger.Error("HTTP session %s failed: %v", id, err)
+		}
+	}()
+	t.base.logger.Info("HTTP session %s opened", id)
+	return session, nil
+}
+
+// reapStreamable ends the sessions idle for streamableSessionIdle every
+// streamableReapInterval, for as long as the server runs.
+func (t *httpTransport) reapStreamable() {
+	ticker := time.NewTicker(streamableReapInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		t.endIdleStreamable(now)
+	}
+}
+
+// endIdleStreamable ends the sessions that at time now have had no HTTP request or open
+// stream for streamableSessionIdle.
+func (t *httpTransport) endIdleStreamable(now time.Time) {
+	t.mu.Lock()
+	var idle []*streamableSession
+	for _, session := range t.streamable {
+		session.mu.Lock()
+		attached := false
+		for _, st := range session.streams {
+			attached = attached || st.attached
+		}
+		if !attached && now.Sub(session.lastSeen) > streamableSessionIdle {
+			idle = append(idle, session)
+		}
+		session.mu.Unlock()
+	}
+	t.mu.Unlock()
+	for _, session := range idle {
+		t.base.logger.Info("HTTP session %s idle for %v", session.id, streamableSessionIdle)
+		t.endStreamable(session)
+	}
+}
+
+// streamableSession returns the session named by the request's Mcp-Session-Id header. On
+// failure the error has been answered.
+func (t *httpTransport) streamableSession(w http.ResponseWriter, r *http.Request) (*streamableSession, bool) {
+	id := r.Header.Get(sessionIDHeader)
+	if id == "" {
+		http.Error(w, "missing "+sessionIDHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+	t.mu.Lock()
+	session, ok := t.streamable[id]
+	t.mu.Unlock()
+	if !ok {
+		// Clients start a new session with initialize when a session is not found
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	session.mu.Lock()
+	session.lastSeen = time.Now()
+	session.mu.Unlock()
+	return session, true
+}
+
+// endStreamable ends a session, stopping its handler and closing its streams.
+func (t *httpTransport) endStreamable(session *streamableSession) {
+	t.mu.Lock()
+	_, ok := t.streamable[session.id]
+	delete(t.streamable, session.id)
+	t.mu.Unlock()
+	if ok {
+		close(session.queue.done)
+		t.base.jobs.forget(session.id)
+		t.base.logger.Info("HTTP session %s closed", session.id)
+	}
+}
+
+// splitMessages returns the messages of a valid JSON POST body, which holds one JSON-RPC
+// message or a batch array of them.
+func splitMessages(data []byte) (messages []json.RawMessage, batch bool, err error) {
+	data = bytes.TrimSpace(data)
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, true, err
+		}
+		if len(messages) == 0 {
+			return nil, true, fmt.Errorf("empty batch")
+		}
+		batch = true
+	} else {
+		messages = []json.RawMessage{data}
+	}
+	// The session's handler reads one message per line
+	for i, message := range messages {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, message); err != nil {
+			return nil, batch, err
+		}
+		messages[i] = compact.Bytes()
+	}
+	return messages, batch, nil
+}
+
+// acceptsEventStream reports whether the client accepts an event stream response.
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// writeJSONRPCError answers a POST with a JSON-RPC error response.
+func writeJSONRPCError(w http.ResponseWriter, status, code int, message, data string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(MCPMessage{JSONRPC: "2.0", Error: &MCPError{Code: code, Message: message, Data: data}})
+}
@@ -0,0 +1,320 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+	"datasheet-to-md-mcp/pdfconv"
+)
+
+// streamableResult is a response of the Streamable HTTP endpoint with its body read.
+type streamableResult struct {
+	status  int
+	header  http.Header
+	body    string
+	session string // Mcp-Session-Id header of the response
+}
+
+// streamableRequest sends a request to the Streamable HTTP endpoint of the server at url.
+func streamableRequest(t *testing.T, method, url, session, accept, body string, header map[string]string) streamableResult {
+	t.Helper()
+	request, err := http.NewRequest(method, url+streamableEndpointPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	if session != "" {
+		request.Header.Set(sessionIDHeader, session)
+	}
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+	if host := request.Header.Get("Host"); host != "" {
+		request.Host = host
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return streamableResult{status: response.StatusCode, header: response.Header, body: string(data), session: response.Header.Get(sessionIDHeader)}
+}
+
+// initializeStreamable starts a Streamable HTTP session and returns its ID.
+func initializeStreamable(t *testing.T, url string) string {
+	t.Helper()
+	result := streamableRequest(t, http.MethodPost, url, "", "application/json", conformanceSteps[0].message, nil)
+	if result.status != http.StatusOK || result.session == "" {
+		t.Fatalf("expected a session from initialize, got %d %q", result.status, result.body)
+	}
+	return result.session
+}
+
+func TestStreamableTransport_Messages(t *testing.T) {
+	server := httptest.NewServer(newConformanceHandler(t).httpHandler())
+	t.Cleanup(server.Close)
+	session := initializeStreamable(t, server.URL)
+
+	// Notifications and responses are accepted without a body
+	for _, message := range []string{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"never-sent"}}`,
+		`[{"jsonrpc":"2.0","method":"notifications/no_such_notification"},{"jsonrpc":"2.0","id":"server-1","result":{}}]`,
+	} {
+		for _, accept := range []string{"application/json", "application/json, text/event-stream"} {
+			if result := streamableRequest(t, http.MethodPost, server.URL, session, accept, message, nil); result.status != http.StatusAccepted || result.body != "" {
+				t.Errorf("expected 202 without a body for %s, got %d %q", message, result.status, result.body)
+			}
+		}
+	}
+
+	// A batch is answered with the responses to its requests only, in a JSON array
+	result := streamableRequest(t, http.MethodPost, server.URL, session, "application/json",
+		`[{"jsonrpc":"2.0","id":"a","method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":"b","method":"ping"}]`, nil)
+	var responses []map[string]interface{}
+	if err := json.Unmarshal([]byte(result.body), &responses); err != nil || result.status != http.StatusOK {
+		t.Fatalf("expected a JSON array of responses, got %d %q", result.status, result.body)
+	}
+	ids := map[interface{}]bool{}
+	for _, response := range responses {
+		ids[response["id"]] = true
+	}
+	if len(responses) != 2 || !ids["a"] || !ids["b"] {
+		t.Errorf("expected the responses to a and b, got %v", responses)
+	}
+
+	// An event stream response carries event IDs and ends after the response
+	result = streamableRequest(t, http.MethodPost, server.URL, session, "application/json, text/event-stream", `{"jsonrpc":"2.0","id":"c","method":"ping"}`, nil)
+	if !strings.HasPrefix(result.header.Get("Content-Type"), "text/event-stream") || !strings.Contains(result.body, "id: ") || !strings.Contains(result.body, `"id":"c"`) {
+		t.Errorf("expected the response as an event with an ID, got %q", result.body)
+	}
+}
+
+func TestStreamableTransport_Errors(t *testing.T) {
+	h := newConformanceHandler(t)
+	h.converter.Config().MaxMessageSizeMB = 1
+	server := httptest.NewServer(h.httpHandler())
+	t.Cleanup(server.Close)
+	session := initializeStreamable(t, server.URL)
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	tests := []struct {
+		name    string
+		method  string
+		session string
+		accept  string
+		body    string
+		header  map[string]string
+		want    int
+	}{
+		{"missing session", http.MethodPost, "", "application/json", ping, nil, http.StatusBadRequest},
+		{"unknown session", http.MethodPost, "unknown", "application/json", ping, nil, http.StatusNotFound},
+		{"invalid JSON", http.MethodPost, session, "application/json", `{"jsonrpc":`, nil, http.StatusBadRequest},
+		{"empty batch", http.MethodPost, session, "application/json", `[]`, nil, http.StatusBadRequest},
+		{"batched initialize", http.MethodPost, "", "application/json", "[" + conformanceSteps[0].message + "," + ping + "]", nil, http.StatusBadRequest},
+		{"message too large", http.MethodPost, session, "application/json", `{"data":"` + strings.Repeat("A", 1<<20) + `"}`, nil, http.StatusRequestEntityTooLarge},
+		{"GET without an event stream", http.MethodGet, session, "application/json", "", nil, http.StatusMethodNotAllowed},
+		{"invalid Last-Event-ID", http.MethodGet, session, "text/event-stream", "", map[string]string{"Last-Event-ID": "x"}, http.StatusBadRequest},
+		{"resume of an unknown stream", http.MethodGet, session, "text/event-stream", "", map[string]string{"Last-Event-ID": "99-1"}, http.StatusNotFound},
+		{"other origin", http.MethodPost, session, "application/json", ping, map[string]string{"Origin": "https://attacker.example"}, http.StatusForbidden},
+		{"DNS rebinding", http.MethodPost, session, "application/json", ping, map[string]string{"Origin": "http://evil.example:8080", "Host": "evil.example:8080"}, http.StatusForbidden},
+		{"unsupported method", http.MethodPut, session, "application/json", ping, nil, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := streamableRequest(t, tt.method, server.URL, tt.session, tt.accept, tt.body, tt.header); result.status != tt.want {
+				t.Errorf("expected %d, got %d %q", tt.want, result.status, result.body)
+			}
+		})
+	}
+}
+
+func TestStreamableTransport_SessionLifecycle(t *testing.T) {
+	server := httptest.NewServer(newConformanceHandler(t).httpHandler())
+	t.Cleanup(server.Close)
+	session := initializeStreamable(t, server.URL)
+
+	// Only one GET stream may be open for the session at a time
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+streamableEndpointPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set(sessionIDHeader, session)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("expected the GET stream opened, got %v, %v", response, err)
+	}
+	defer response.Body.Close()
+	if result := streamableRequest(t, http.MethodGet, server.URL, session, "text/event-stream", "", nil); result.status != http.StatusConflict {
+		t.Errorf("expected a second GET stream rejected with 409, got %d", result.status)
+	}
+
+	// DELETE ends the session and its streams
+	if result := streamableRequest(t, http.MethodDelete, server.URL, session, "", "", nil); result.status != http.StatusOK {
+		t.Fatalf("expected DELETE to end the session, got %d %q", result.status, result.body)
+	}
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, response.Body)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the GET stream closed with the session")
+	}
+	if result := streamableRequest(t, http.MethodPost, server.URL, session, "application/json", `{"jsonrpc":"2.0","id":1,"method":"ping"}`, nil); result.status != http.StatusNotFound {
+		t.Errorf("expected the ended session unknown, got %d", result.status)
+	}
+}
+
+// openStreamablePost POSTs a message to a session, accepting an event stream, and returns
+// the stream of the response.
+func openStreamablePost(t *testing.T, url, session, message string) *sseConn {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url+streamableEndpointPath, strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json, text/event-stream")
+	request.Header.Set(sessionIDHeader, session)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		t.Fatalf("expected an event stream, got %s", response.Status)
+	}
+	conn := &sseConn{events: make(chan sseEvent), cancel: cancel}
+	go readEvents(ctx, response.Body, conn.events)
+	return conn
+}
+
+func TestStreamableTransport_ConcurrentToolCalls(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageAltText: "caption", ImageFormat: "png", ImageMaxDPI: 300, OutputBaseDir: t.TempDir(), MaxToolCalls: 2}
+	log := logger.NewLogger("error")
+	converter, _ := pdfconv.NewPDFConverter(cfg, log)
+	server := httptest.NewServer(NewMCPHandler(converter, log).httpHandler())
+	t.Cleanup(server.Close)
+
+	result := streamableRequest(t, http.MethodPost, server.URL, "", "application/json",
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{"sampling":{}}}}`, nil)
+	session := result.session
+	if result.status != http.StatusOK || session == "" {
+		t.Fatalf("expected a session from initialize, got %d %q", result.status, result.body)
+	}
+	post := func(message string) streamableResult {
+		t.Helper()
+		return streamableRequest(t, http.MethodPost, server.URL, session, "application/json", message, nil)
+	}
+	read := func(conn *sseConn) map[string]interface{} {
+		t.Helper()
+		var message map[string]interface{}
+		if event := conn.next(t); json.Unmarshal([]byte(event.data), &message) != nil {
+			t.Fatalf("server sent invalid JSON %q", event.data)
+		}
+		return message
+	}
+	call := func(id string) string {
+		arguments, _ := json.Marshal(map[string]interface{}{"pdf_path": createFigurePDF(t)})
+		return `{"jsonrpc":"2.0","id":"` + id + `","method":"tools/call","params":{"name":"convert_pdf_to_markdown","arguments":` + string(arguments) + `}}`
+	}
+
+	// The conversion asks for its caption on its own stream and waits while a ping is answered
+	convert := openStreamablePost(t, server.URL, session, call("convert"))
+	sampling := read(convert)
+	if sampling["method"] != "sampling/createMessage" {
+		t.Fatalf("expected a sampling request on the stream of the call, got %v", sampling)
+	}
+	if result := post(`{"jsonrpc":"2.0","id":"ping","method":"ping"}`); result.status != http.StatusOK || !strings.Contains(result.body, `"id":"ping"`) {
+		t.Fatalf("expected the ping answered during the conversion, got %d %q", result.status, result.body)
+	}
+	samplingID, _ := json.Marshal(sampling["id"])
+	if result := post(`{"jsonrpc":"2.0","id":` + string(samplingID) + `,"result":{"role":"assistant","model":"test","content":{"type":"text","text":"Diagonal line."}}}`); result.status != http.StatusAccepted {
+		t.Fatalf("expected the caption accepted, got %d %q", result.status, result.body)
+	}
+	if response := read(convert); response["id"] != "convert" || response["result"] == nil {
+		t.Fatalf("expected the conversion result, got %v", response)
+	}
+
+	// A cancelled call is not answered and its stream ends
+	cancelled := openStreamablePost(t, server.URL, session, call("cancel"))
+	if sampling := read(cancelled); sampling["method"] != "sampling/createMessage" {
+		t.Fatalf("expected a sampling request on the stream of the call, got %v", sampling)
+	}
+	if result := post(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"cancel"}}`); result.status != http.StatusAccepted {
+		t.Fatalf("expected the cancellation accepted, got %d %q", result.status, result.body)
+	}
+	for {
+		select {
+		case event, ok := <-cancelled.events:
+			if !ok {
+				return
+			}
+			if strings.Contains(event.data, `"id":"cancel"`) {
+				t.Errorf("expected no response to the cancelled call, got %s", event.data)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the stream of the cancelled call ended")
+		}
+	}
+}
+
+func TestStreamableTransport_IdleSessions(t *testing.T) {
+	transport := newHTTPTransport(newConformanceHandler(t))
+	server := httptest.NewServer(transport.handler())
+	t.Cleanup(server.Close)
+	idle := initializeStreamable(t, server.URL)
+	listening := initializeStreamable(t, server.URL)
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	// A session with an open GET stream is in use even without requests
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+streamableEndpointPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set(sessionIDHeader, listening)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("expected the GET stream opened, got %v, %v", response, err)
+	}
+	defer response.Body.Close()
+
+	transport.endIdleStreamable(time.Now())
+	if result := streamableRequest(t, http.MethodPost, server.URL, idle, "application/json", ping, nil); result.status != http.StatusOK {
+		t.Fatalf("expected a recently used session kept, got %d", result.status)
+	}
+
+	transport.endIdleStreamable(time.Now().Add(streamableSessionIdle + time.Minute))
+	if result := streamableRequest(t, http.MethodPost, server.URL, idle, "application/json", ping, nil); result.status != http.StatusNotFound {
+		t.Errorf("expected the idle session ended, got %d", result.status)
+	}
+	if result := streamableRequest(t, http.MethodPost, server.URL, listening, "application/json", ping, nil); result.status != http.StatusOK {
+		t.Errorf("expected the session with an open stream kept, got %d", result.status)
+	}
+}
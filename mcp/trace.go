@@ -0,0 +1,124 @@
+// Package mcp - Message tracing.
+// This file writes the JSON-RPC messages the server receives and sends to a trace file when
+// MCP_TRACE is enabled, to debug client integrations. Values under keys that look like
+// secrets are redacted and long strings, such as base64 encoded files, are truncated, so a
+// trace can be shared in a bug report.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Trace size caps
+const (
+	traceMaxString  = 1024      // Bytes of a string value kept in the trace
+	traceMaxMessage = 64 * 1024 // Bytes of a message kept in the trace
+)
+
+// secretKeySuffixes end the object keys whose values are redacted from traces, compared in
+// lower case without '_' and '-', so "api_key", "apiKey" and "client-secret" all match
+// while "maxTokens" does not.
+var secretKeySuffixes = []string{"password", "passphrase", "secret", "token", "apikey", "accesskey", "privatekey", "authorization", "credential", "credentials", "cookie"}
+
+// isSecretKey reports whether the value of an object key is redacted from traces.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tracer appends traced messages to a file, one per line.
+type tracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// newTracer opens path for appending traced messages.
+func newTracer(path string) (*tracer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %v", err)
+	}
+	return &tracer{w: file}, nil
+}
+
+// record writes a message with its direction: "<-" for received and "->" for sent. A nil
+// tracer records nothing.
+func (t *tracer) record(direction string, message []byte) {
+	if t == nil {
+		return
+	}
+	line := fmt.Sprintf("%s %s %s\n", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), direction, sanitizeTrace(message))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.w, line)
+}
+
+// tracingWriter records everything written through it as sent messages.
+type tracingWriter struct {
+	w     io.Writer
+	trace *tracer
+}
+
+func (w tracingWriter) Write(p []byte) (int, error) {
+	w.trace.record("->", p)
+	return w.w.Write(p)
+}
+
+// sanitizeTrace returns message with secrets redacted and long strings truncated, capped at
+// traceMaxMessage bytes. Messages that are not JSON are only capped.
+func sanitizeTrace(message []byte) string {
+	var value interface{}
+	text := string(message)
+	if json.Unmarshal(message, &value) == nil {
+		if data, err := json.Marshal(sanitizeTraceValue(value)); err == nil {
+			text = string(data)
+		}
+	}
+	return truncateTrace(text, traceMaxMessage)
+}
+
+// sanitizeTraceValue redacts and truncates the values of a decoded JSON value.
+func sanitizeTraceValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSecretKey(key) {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = sanitizeTraceValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeTraceValue(item)
+		}
+	case string:
+		return truncateTrace(v, traceMaxString)
+	}
+	return value
+}
+
+// truncateTrace shortens text to at most limit bytes on a rune boundary, noting how much
+// was left out.
+func truncateTrace(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…[%d bytes truncated]", text[:cut], len(text)-cut)
+}
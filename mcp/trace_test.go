@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIsSecretKey(t *testing.T) {
+	tests := map[string]bool{
+		"password": true, "api_key": true, "apiKey": true, "client-secret": true, "Authorization": true,
+		"access_token": true, "privateKey": true, "Cookie": true, "credentials": true,
+		"maxTokens": false, "pdf_path": false, "author": false, "name": false,
+	}
+	for key, want := range tests {
+		if got := isSecretKey(key); got != want {
+			t.Errorf("isSecretKey(%q) = %t, want %t", key, got, want)
+		}
+	}
+}
+
+func TestSanitizeTrace(t *testing.T) {
+	message := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"convert","arguments":{"pdf_path":"a.pdf","password":"hunter2","nested":[{"api_key":"k-123"}],"data":"` + strings.Repeat("A", 5000) + `"}}}`
+	text := sanitizeTrace([]byte(message))
+	for _, secret := range []string{"hunter2", "k-123"} {
+		if strings.Contains(text, secret) {
+			t.Errorf("expected %q redacted, got %s", secret, text)
+		}
+	}
+	var traced map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &traced); err != nil {
+		t.Fatalf("expected the sanitized message to stay JSON: %v", err)
+	}
+	arguments := traced["params"].(map[string]interface{})["arguments"].(map[string]interface{})
+	if arguments["password"] != "[REDACTED]" || arguments["pdf_path"] != "a.pdf" {
+		t.Errorf("expected only the secret redacted, got %v", arguments)
+	}
+	data := arguments["data"].(string)
+	if !strings.HasPrefix(data, strings.Repeat("A", traceMaxString)+"…") || !strings.HasSuffix(data, "[3976 bytes truncated]") {
+		t.Errorf("expected the long string cut at %d bytes, got %d bytes ending %q", traceMaxString, len(data), data[len(data)-30:])
+	}
+
+	// A message of many short strings is capped as a whole
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = `"` + strings.Repeat("B", 1000) + `"`
+	}
+	text = sanitizeTrace([]byte(`{"items":[` + strings.Join(items, ",") + `]}`))
+	if cut, _, ok := strings.Cut(text, "…["); !ok || len(cut) > traceMaxMessage || !strings.HasSuffix(text, "bytes truncated]") {
+		t.Errorf("expected the message capped at %d bytes, got %d bytes", traceMaxMessage, len(text))
+	}
+
+	// Messages that are not JSON are capped too, on a rune boundary
+	text = sanitizeTrace([]byte(strings.Repeat("é", traceMaxMessage)))
+	if !utf8.ValidString(text) || !strings.HasSuffix(text, "bytes truncated]") || len(text) > traceMaxMessage+32 {
+		t.Errorf("expected an invalid message capped on a rune boundary, got %d bytes", len(text))
+	}
+}
+
+func TestTrace_Serve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.log")
+	t.Setenv("MCP_TRACE", "true")
+	t.Setenv("MCP_TRACE_FILE", path)
+	h := newConformanceHandler(t)
+	if h.trace == nil {
+		t.Fatal("expected tracing enabled")
+	}
+	conn := connectStdio(t, h)
+	initialize := conformanceSteps[0]
+	initialize.check(t, conn.exchange(t, initialize.message))
+	expectToolError(2.0)(t, conn.exchange(t, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"extract_pdf_metadata","arguments":{"pdf_path":"missing.pdf","password":"hunter2"}}}`))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace := string(data)
+	if strings.Contains(trace, "hunter2") {
+		t.Errorf("expected the password redacted from the trace, got %s", trace)
+	}
+	for _, want := range []string{`<- {"id":2,"jsonrpc":"2.0","method":"tools/call"`, `"password":"[REDACTED]"`, `-> {"error":`, `"id":2`} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("expected %q in the trace, got %s", want, trace)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package mcp - Server version and update check.
+// This file reports the server version and build details for get_server_version and runs
+// the opt-in UPDATE_CHECK against the project's release feed, so long-running deployments
+// notice when a release with conversion fixes is available.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// protocolVersion is the MCP protocol version the server implements.
+const protocolVersion = "2024-11-05"
+
+// updateCheckTimeout bounds the release feed request so a slow network never delays the server.
+const updateCheckTimeout = 10 * time.Second
+
+// releaseInfo is the latest release reported by the release feed.
+type releaseInfo struct {
+	Version   string    // Release tag, e.g. "v1.2.0"
+	URL       string    // Release page
+	Published time.Time // Publication time
+	Fixes     int       // Entries under "Fixed" in the release notes
+}
+
+// updateStatus holds the result of the last update check.
+type updateStatus struct {
+	mu      sync.Mutex
+	checked time.Time
+	latest  *releaseInfo
+	err     error
+}
+
+// CheckForUpdates queries the configured release feed once and logs when a newer version
+// is available. It does nothing unless UPDATE_CHECK is enabled, and is meant to run in the
+// background at startup; failures are logged at debug level only.
+func (h *MCPHandler) CheckForUpdates() {
+	cfg := h.converter.Config()
+	if !cfg.UpdateCheck {
+		return
+	}
+	latest, err := fetchLatestRelease(cfg.UpdateCheckURL)
+
+	h.updates.mu.Lock()
+	h.updates.checked, h.updates.latest, h.updates.err = time.Now(), latest, err
+	h.updates.mu.Unlock()
+
+	if err != nil {
+		h.logger.Debug("Update check failed: %v", err)
+		return
+	}
+	if compareVersions(latest.Version, cfg.ServerVersion) <= 0 {
+		h.logger.Debug("Server version %s is up to date (latest release %s)", cfg.ServerVersion, latest.Version)
+		return
+	}
+	fixes := ""
+	if latest.Fixes > 0 {
+		fixes = fmt.Sprintf(" with %d conversion fix(es)", latest.Fixes)
+	}
+	h.logger.Info("A newer version is available: %s%s (running %s): %s", latest.Version, fixes, cfg.ServerVersion, latest.URL)
+}
+
+// fetchLatestRelease reads the latest release from a GitHub releases API endpoint.
+func fetchLatestRelease(feedURL string) (*releaseInfo, error) {
+	req, err := http.NewRequest(http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release feed URL: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "pdf-to-markdown-server")
+	resp, err := (&http.Client{Timeout: updateCheckTimeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release feed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release feed returned %s", resp.Status)
+	}
+
+	var release struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		Body        string    `json:"body"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode release feed: %v", err)
+	}
+	if release.TagName == "" {
+		return nil, fmt.Errorf("release feed has no tag_name")
+	}
+	return &releaseInfo{Version: release.TagName, URL: release.HTMLURL, Published: release.PublishedAt, Fixes: countFixes(release.Body)}, nil
+}
+
+// countFixes counts the list entries of the "Fixed" section of Keep a Changelog style
+// release notes.
+func countFixes(notes string) int {
+	fixes, inFixed := 0, false
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			inFixed = strings.EqualFold(strings.TrimSpace(strings.TrimLeft(line, "#")), "fixed")
+			continue
+		}
+		if inFixed && (strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")) {
+			fixes++
+		}
+	}
+	return fixes
+}
+
+// compareVersions compares dotted version numbers such as "v1.2.0" and "1.10", returning
+// -1, 0 or 1. A leading "v" and pre-release or build suffixes are ignored.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts returns the numeric components of a version string.
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// versionReport returns the get_server_version text.
+func (h *MCPHandler) versionReport() string {
+	cfg := h.converter.Config()
+	var update string
+	h.updates.mu.Lock()
+	switch {
+	case !cfg.UpdateCheck:
+		update = "disabled (set UPDATE_CHECK=true to check the release feed at startup)"
+	case h.updates.checked.IsZero():
+		update = "pending"
+	case h.updates.err != nil:
+		update = fmt.Sprintf("failed: %v", h.updates.err)
+	case compareVersions(h.updates.latest.Version, cfg.ServerVersion) > 0:
+		update = fmt.Sprintf("newer version %s available: %s", h.updates.latest.Version, h.updates.latest.URL)
+		if h.updates.latest.Fixes > 0 {
+			update += fmt.Sprintf(" (%d conversion fix(es))", h.updates.latest.Fixes)
+		}
+	default:
+		update = fmt.Sprintf("up to date (latest release %s)", h.updates.latest.Version)
+	}
+	h.updates.mu.Unlock()
+
+	return fmt.Sprintf(`Server Version
+
+Name: %s
+Version: %s
+MCP Protocol: %s
+Go: %s
+Platform: %s/%s
+Update Check: %s
+`,
+		cfg.ServerName,
+		cfg.ServerVersion,
+		protocolVersion,
+		runtime.Version(),
+		runtime.GOOS, runtime.GOARCH,
+		update,
+	)
+}
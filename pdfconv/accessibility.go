@@ -0,0 +1,117 @@
+// Package pdfconv - Accessible output.
+// This file implements ACCESSIBLE_OUTPUT: every image gets alt text, tables get complete
+// header rows, heading levels never skip and the document language is declared in front
+// matter, so converted documents can pass accessibility review.
+package pdfconv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// languageTagPattern matches a BCP 47 language tag such as "en", "en-US" or "zh-Hans".
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// tesseractLanguages maps Tesseract language codes to BCP 47 language tags.
+var tesseractLanguages = map[string]string{
+	"eng": "en", "deu": "de", "fra": "fr", "spa": "es", "ita": "it", "nld": "nl", "por": "pt",
+	"dan": "da", "swe": "sv", "fin": "fi", "nor": "no", "pol": "pl", "ces": "cs", "rus": "ru",
+	"jpn": "ja", "kor": "ko", "chi_sim": "zh-Hans", "chi_tra": "zh-Hant",
+}
+
+// pdfLanguage returns the natural language declared in the PDF catalog (/Lang), if any.
+func pdfLanguage(reader *pdf.Reader) (lang string) {
+	defer func() {
+		if recover() != nil {
+			lang = ""
+		}
+	}()
+	lang = strings.TrimSpace(reader.Trailer().Key("Root").Key("Lang").Text())
+	if !languageTagPattern.MatchString(lang) {
+		return ""
+	}
+	return lang
+}
+
+// documentLanguage returns the language to declare for a document: the one declared in the
+// source, else the first OCR_LANGUAGE, else "und" (undetermined).
+func (c *PDFConverter) documentLanguage(declared string) string {
+	if declared != "" {
+		return declared
+	}
+	first, _, _ := strings.Cut(c.ocrLanguage(), "+")
+	if lang, ok := tesseractLanguages[first]; ok {
+		return lang
+	}
+	return "und"
+}
+
+// prepareAccessiblePages gives every image alt text and every table header cell a label
+// before the pages are rendered.
+func (c *PDFConverter) prepareAccessiblePages(pages []PDFPage) {
+	if !c.config.AccessibleOutput {
+		return
+	}
+	for i := range pages {
+		page := &pages[i]
+		for j := range page.Images {
+			img := &page.Images[j]
+			switch {
+			case img.AltText != "":
+			case img.Placeholder:
+				img.AltText = fmt.Sprintf("Image on page %d that could not be extracted", page.Number)
+			default:
+				img.AltText = fmt.Sprintf("Figure on page %d", page.Number)
+			}
+		}
+		for j := range page.Tables {
+			header := page.Tables[j].Header
+			for k := range header {
+				if strings.TrimSpace(header[k]) == "" {
+					header[k] = fmt.Sprintf("Column %d", k+1)
+				}
+			}
+		}
+	}
+}
+
+// tableImageAltText returns the alt text of the image a low-confidence table is shown as.
+func (c *PDFConverter) tableImageAltText(table PDFTable) string {
+	if !c.config.AccessibleOutput {
+		return "Table"
+	}
+	if table.Caption != "" {
+		return formatAltText(table.Caption)
+	}
+	return "Table that could not be reconstructed as text"
+}
+
+// accessibleMarkdown declares the document language in YAML front matter and lowers
+// headings that skip levels, so each heading is at most one level below the previous one.
+func (c *PDFConverter) accessibleMarkdown(markdown, language string) string {
+	if !c.config.AccessibleOutput {
+		return markdown
+	}
+	lines := strings.Split(markdown, "\n")
+	var fences fenceTracker
+	previous := 0
+	for i, line := range lines {
+		if fences.inCode(line) {
+			continue
+		}
+		m := markdownHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		level := len(m[1])
+		if level > previous+1 {
+			level = previous + 1
+			lines[i] = strings.Repeat("#", level) + " " + m[2]
+		}
+		previous = level
+	}
+	return fmt.Sprintf("---\nlang: %s\n---\n\n", language) + strings.Join(lines, "\n")
+}
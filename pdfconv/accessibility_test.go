@@ -0,0 +1,122 @@
+package pdfconv
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestConvertPDF_AccessibleOutput(t *testing.T) {
+	pixels := bytes.Repeat([]byte{0x80}, 8*8)
+	content := "q 100 0 0 100 50 600 cm /Fig Do Q"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Lang (de-DE) >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /XObject << /Fig 4 0 R >> >> /Contents 5 0 R >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 8 /Height 8 /ColorSpace /DeviceGray /BitsPerComponent 8 /Length %d >>\nstream\n%s\nendstream", len(pixels), pixels),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	})
+	pdfPath := filepath.Join(t.TempDir(), "figure.pdf")
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 3, ExtractImages: true, AccessibleOutput: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	out := string(md)
+	if !strings.HasPrefix(out, "---\nlang: de-DE\n---\n\n# PDF Document\n") {
+		t.Errorf("expected language front matter, got:\n%s", out)
+	}
+	if !strings.Contains(out, "\n## Page 1\n") {
+		t.Errorf("expected page heading one level below the title, got:\n%s", out)
+	}
+	if !strings.Contains(out, "![Figure on page 1](./image_") {
+		t.Errorf("expected alt text on the image, got:\n%s", out)
+	}
+
+	// Without the flag the output is unchanged
+	cfg.AccessibleOutput = false
+	res, err = conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ = os.ReadFile(res.MarkdownFile)
+	if !strings.HasPrefix(string(md), "# PDF Document\n") || !strings.Contains(string(md), "#### Page 1") || !strings.Contains(string(md), "![Image](./image_") {
+		t.Errorf("expected default output without ACCESSIBLE_OUTPUT, got:\n%s", md)
+	}
+}
+
+func TestAccessibleMarkdown(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{AccessibleOutput: true}, logger.NewLogger("error"))
+	in := strings.Join([]string{
+		"# Title",
+		"### Page 1",
+		"##### Deep",
+		"```",
+		"###### not a heading",
+		"```",
+		"#### Sibling",
+		"## Page 2",
+	}, "\n")
+	want := strings.Join([]string{
+		"---",
+		"lang: en",
+		"---",
+		"",
+		"# Title",
+		"## Page 1",
+		"### Deep",
+		"```",
+		"###### not a heading",
+		"```",
+		"#### Sibling",
+		"## Page 2",
+	}, "\n")
+	if got := conv.accessibleMarkdown(in, "en"); got != want {
+		t.Errorf("accessibleMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrepareAccessiblePages(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{AccessibleOutput: true}, logger.NewLogger("error"))
+	pages := []PDFPage{{
+		Number: 4,
+		Images: []PDFImage{{Filename: "a.png"}, {Filename: "b.png", Placeholder: true}, {Filename: "c.png", AltText: "Pinout"}},
+		Tables: []PDFTable{{Header: []string{"", "Min", " "}}},
+	}}
+	conv.prepareAccessiblePages(pages)
+	for i, want := range []string{"Figure on page 4", "Image on page 4 that could not be extracted", "Pinout"} {
+		if got := pages[0].Images[i].AltText; got != want {
+			t.Errorf("image %d alt text = %q, want %q", i, got, want)
+		}
+	}
+	if got := strings.Join(pages[0].Tables[0].Header, ","); got != "Column 1,Min,Column 3" {
+		t.Errorf("table header = %q", got)
+	}
+}
+
+func TestDocumentLanguage(t *testing.T) {
+	tests := []struct{ ocr, declared, want string }{
+		{"", "fr-CA", "fr-CA"},
+		{"", "", "en"},
+		{"chi_sim+eng", "", "zh-Hans"},
+		{"tlh", "", "und"},
+	}
+	for _, tt := range tests {
+		conv, _ := NewPDFConverter(&config.Config{OCRLanguage: tt.ocr}, logger.NewLogger("error"))
+		if got := conv.documentLanguage(tt.declared); got != tt.want {
+			t.Errorf("documentLanguage(%q) with OCR_LANGUAGE %q = %q, want %q", tt.declared, tt.ocr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package pdfconv - Image alt text.
+// This file fills in the alt text of extracted images from the text recognized in each
+// figure, or from a caption written by the MCP client's model, according to IMAGE_ALT_TEXT.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Image alt text limits
+const (
+	MaxAltTextLength     = 125 // Longest alt text in characters; longer text is cut at a word
+	MinAltTextConfidence = 0.6 // Lowest mean OCR confidence accepted for alt text
+)
+
+// ImageCaptioner returns a short description of a PNG image, for example by asking the
+// MCP client's model to caption it.
+type ImageCaptioner func(png []byte) (string, error)
+
+// describeImages sets the alt text of the extracted images saved in outputDir. Images with
+// the same content hash are described once. In caption mode the first captioning failure
+// switches the rest of the conversion to OCR.
+func (c *PDFConverter) describeImages(pages []PDFPage, outputDir string, opts ConversionOptions) {
+	mode := c.config.ImageAltText
+	if mode != "ocr" && mode != "caption" {
+		return
+	}
+	captioner := opts.Captioner
+	if mode != "caption" {
+		captioner = nil
+	}
+	ocr := ocrAvailable()
+	if captioner == nil && !ocr {
+		c.logger.Warn("tesseract not found on PATH; images are written without OCR alt text")
+	}
+
+	described := make(map[string]string)
+	for i := range pages {
+		for j := range pages[i].Images {
+			img := &pages[i].Images[j]
+			if img.Placeholder || img.AltText != "" {
+				continue
+			}
+			// The text of a page scan is the page text itself
+			if img.PageScan {
+				img.AltText = fmt.Sprintf("Scan of page %d", pages[i].Number)
+				continue
+			}
+			if text, ok := described[img.Filename]; ok {
+				img.AltText = text
+				continue
+			}
+			imagePath := filepath.Join(outputDir, img.Filename)
+			var text string
+			if captioner != nil {
+				caption, err := captionImage(captioner, imagePath)
+				if err != nil {
+					c.logger.Warn("Image captioning failed, using OCR for the remaining images: %v", err)
+					captioner = nil
+				}
+				text = caption
+			}
+			if text == "" && ocr {
+				ocrStart := time.Now()
+				recognized, confidence, err := c.ocrImage(imagePath)
+				opts.timings.record(phaseOCR, ocrStart)
+				if err != nil {
+					c.logger.Debug("OCR failed for image %s: %v", img.Filename, err)
+				} else if confidence >= MinAltTextConfidence {
+					text = recognized
+				}
+			}
+			img.AltText = formatAltText(text)
+			described[img.Filename] = img.AltText
+		}
+	}
+}
+
+// captionImage reads a saved PNG and passes it to the captioner.
+func captionImage(captioner ImageCaptioner, imagePath string) (string, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", err
+	}
+	return captioner(data)
+}
+
+// formatAltText collapses whitespace, escapes the characters that would end the Markdown
+// alt text and shortens the text to MaxAltTextLength characters.
+func formatAltText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > MaxAltTextLength {
+		runes := []rune(text)[:MaxAltTextLength-1]
+		cut := string(runes)
+		if i := strings.LastIndex(cut, " "); i > MaxAltTextLength/2 {
+			cut = cut[:i]
+		}
+		text = strings.TrimRight(cut, " ,.;:") + "…"
+	}
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+}
@@ -0,0 +1,133 @@
+package pdfconv
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// installFakeTesseract puts a tesseract script that prints the given words as TSV, with the
+// given confidence, first on PATH.
+func installFakeTesseract(t *testing.T, words []string, conf int) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tesseract script requires a POSIX shell")
+	}
+	var tsv strings.Builder
+	for i, w := range words {
+		fmt.Fprintf(&tsv, "5\t1\t1\t1\t1\t%d\t0\t0\t10\t10\t%d\t%s\n", i+1, conf, w)
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncat <<'EOF'\n" + tsv.String() + "EOF\n"
+	if err := os.WriteFile(filepath.Join(dir, "tesseract"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake tesseract: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// createTempPDFWithRawImage builds a single-page PDF drawing one uncompressed grayscale image.
+func createTempPDFWithRawImage(t *testing.T) string {
+	t.Helper()
+	pixels := bytes.Repeat([]byte{0xff}, 16*16)
+	for i := 0; i < 16; i++ {
+		pixels[i*16+i] = 0
+	}
+	content := "q 100 0 0 100 50 600 cm /Fig Do Q"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /XObject << /Fig 4 0 R >> >> /Contents 5 0 R >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 16 /Height 16 /ColorSpace /DeviceGray /BitsPerComponent 8 /Length %d >>\nstream\n%s\nendstream", len(pixels), pixels),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	})
+	pdfPath := filepath.Join(t.TempDir(), "figure.pdf")
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+	return pdfPath
+}
+
+func TestConvertPDF_OCRAltText(t *testing.T) {
+	installFakeTesseract(t, []string{"VDD", "[3.3V]", "GND"}, 91)
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageAltText: "ocr"}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempPDFWithRawImage(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), `![VDD \[3.3V\] GND](./image_`) {
+		t.Errorf("expected OCR alt text, got:\n%s", md)
+	}
+}
+
+func TestConvertPDF_OCRAltTextLowConfidence(t *testing.T) {
+	installFakeTesseract(t, []string{"~~", "|/"}, 20)
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageAltText: "ocr"}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempPDFWithRawImage(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "![Image](./image_") {
+		t.Errorf("expected default alt text for unreliable OCR, got:\n%s", md)
+	}
+}
+
+func TestConvertPDF_CaptionAltText(t *testing.T) {
+	// Without tesseract, a failing captioner leaves the default alt text
+	t.Setenv("PATH", t.TempDir())
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageAltText: "caption"}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+
+	calls := 0
+	opts := ConversionOptions{Captioner: func(data []byte) (string, error) {
+		calls++
+		if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+			return "", fmt.Errorf("not a png: %v", err)
+		}
+		return "Diagonal line on a white background.\n", nil
+	}}
+	res, err := conv.ConvertPDFWithOptions(createTempPDFWithRawImage(t), t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("ConvertPDFWithOptions() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if calls != 1 || !strings.Contains(string(md), "![Diagonal line on a white background.](./image_") {
+		t.Errorf("expected caption alt text after %d call(s), got:\n%s", calls, md)
+	}
+
+	opts.Captioner = func([]byte) (string, error) { return "", fmt.Errorf("sampling not supported") }
+	res, err = conv.ConvertPDFWithOptions(createTempPDFWithRawImage(t), t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("ConvertPDFWithOptions() error = %v", err)
+	}
+	md, _ = os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "![Image](./image_") {
+		t.Errorf("expected default alt text after caption failure, got:\n%s", md)
+	}
+}
+
+func TestFormatAltText(t *testing.T) {
+	long := strings.Repeat("word ", 40)
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"  Block\n diagram  ", "Block diagram"},
+		{"see [1] and C:\\path", `see \[1\] and C:\\path`},
+		{long, strings.TrimSpace(strings.Repeat("word ", 24)) + "…"},
+	}
+	for _, tt := range tests {
+		if got := formatAltText(tt.in); got != tt.want {
+			t.Errorf("formatAltText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
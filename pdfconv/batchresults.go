@@ -0,0 +1,92 @@
+// Package pdfconv - Batch results.
+// This file writes results.json to the output base directory after a batch conversion: the
+// counts of the batch, the conversion report of every converted document and the documents
+// that failed, matching the published results.schema.json. It is the machine-readable
+// counterpart of BATCH_SUMMARY.md, for CI jobs that gate on failure counts and quality.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BatchResultsFileName is the batch results file written when BATCH_RESULTS is enabled.
+const BatchResultsFileName = "results.json"
+
+// BatchResults is the content of results.json.
+type BatchResults struct {
+	InputDir       string            `json:"input_dir"`
+	OutputDir      string            `json:"output_dir"`
+	Portfolio      bool              `json:"portfolio"`
+	FileCount      int               `json:"file_count"`
+	SuccessCount   int               `json:"success_count"`
+	FailureCount   int               `json:"failure_count"`
+	PartialCount   int               `json:"partial_count"` // Converted documents missing failed pages
+	PageCount      int               `json:"page_count"`
+	ImageCount     int               `json:"image_count"`
+	MinQuality     *float64          `json:"min_quality,omitempty"`     // Lowest quality score, absent without converted documents
+	AverageQuality *float64          `json:"average_quality,omitempty"` // Mean quality score, absent without converted documents
+	Documents      []BatchDocument   `json:"documents"`
+	Errors         []ConversionError `json:"errors"`
+	ErrataLinks    []ErrataLink      `json:"errata_links,omitempty"`
+}
+
+// BatchDocument is a converted document of results.json.
+type BatchDocument struct {
+	OutputDir    string           `json:"output_dir"`
+	MarkdownFile string           `json:"markdown_file"`
+	Report       ConversionReport `json:"report"` // Same content as the conversion_report.json of the document
+}
+
+// batchResults builds the results.json content of a batch conversion.
+func batchResults(result *BatchConversionResult) BatchResults {
+	results := BatchResults{
+		InputDir:     result.InputDir,
+		OutputDir:    result.OutputBaseDir,
+		Portfolio:    result.Portfolio,
+		FileCount:    result.FileCount,
+		SuccessCount: result.SuccessCount,
+		FailureCount: result.FailureCount,
+		PageCount:    result.TotalPageCount,
+		ImageCount:   result.TotalImageCount,
+		Documents:    make([]BatchDocument, 0, len(result.Results)),
+		Errors:       append([]ConversionError{}, result.Errors...),
+		ErrataLinks:  result.ErrataLinks,
+	}
+	total := 0.0
+	for _, r := range result.Results {
+		if r.Status == StatusPartial {
+			results.PartialCount++
+		}
+		score := r.Quality.Score
+		if results.MinQuality == nil || score < *results.MinQuality {
+			results.MinQuality = &score
+		}
+		total += score
+		results.Documents = append(results.Documents, BatchDocument{OutputDir: r.OutputDir, MarkdownFile: r.MarkdownFile, Report: conversionReport(r.Source, r)})
+	}
+	if len(result.Results) > 0 {
+		average := total / float64(len(result.Results))
+		results.AverageQuality = &average
+	}
+	return results
+}
+
+// writeBatchResults writes the results of a batch conversion to its output base directory
+// and returns its path.
+func writeBatchResults(result *BatchConversionResult) (string, error) {
+	path := filepath.Join(result.OutputBaseDir, BatchResultsFileName)
+	if err := os.MkdirAll(result.OutputBaseDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+	data, err := json.MarshalIndent(batchResults(result), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %v", BatchResultsFileName, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", BatchResultsFileName, err)
+	}
+	return path, nil
+}
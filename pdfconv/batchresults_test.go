@@ -0,0 +1,67 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestConvertPDFsInDirectory_BatchResults(t *testing.T) {
+	inputDir, outputDir := t.TempDir(), t.TempDir()
+	writeTitlePage(t, filepath.Join(inputDir, "lm317.pdf"), "LM317 3-Terminal Adjustable Regulator")
+	if err := os.WriteFile(filepath.Join(inputDir, "broken.pdf"), []byte("not a pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BaseHeaderLevel: 1, BatchResults: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+
+	result, err := conv.ConvertPDFsInDirectory(inputDir, outputDir)
+	if err != nil {
+		t.Fatalf("ConvertPDFsInDirectory() error = %v", err)
+	}
+	if result.ResultsFile != filepath.Join(outputDir, BatchResultsFileName) {
+		t.Fatalf("expected the results in the output directory, got %q", result.ResultsFile)
+	}
+	data, err := os.ReadFile(result.ResultsFile)
+	if err != nil {
+		t.Fatalf("expected %s: %v", BatchResultsFileName, err)
+	}
+	var results BatchResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatalf("invalid %s: %v", BatchResultsFileName, err)
+	}
+	if results.FileCount != 2 || results.SuccessCount != 1 || results.FailureCount != 1 || len(results.Documents) != 1 || len(results.Errors) != 1 {
+		t.Fatalf("unexpected counts: %+v", results)
+	}
+	document := results.Documents[0]
+	if document.MarkdownFile != result.Results[0].MarkdownFile || document.Report.Source != filepath.Join(inputDir, "lm317.pdf") || document.Report.PageCount != 1 {
+		t.Errorf("unexpected document: %+v", document)
+	}
+	if results.MinQuality == nil || *results.MinQuality != result.Results[0].Quality.Score || *results.AverageQuality != *results.MinQuality {
+		t.Errorf("expected the quality of the only document, got %v %v", results.MinQuality, results.AverageQuality)
+	}
+	if results.Errors[0].PDFPath != filepath.Join(inputDir, "broken.pdf") || results.Errors[0].Error == "" {
+		t.Errorf("unexpected error: %+v", results.Errors[0])
+	}
+	violations, checked, err := ValidateOutput(outputDir)
+	if err != nil || len(violations) != 0 {
+		t.Errorf("expected the output to match its schemas, got %v %v", err, violations)
+	}
+	if checked < 2 {
+		t.Errorf("expected results.json and the report validated, got %d file(s)", checked)
+	}
+
+	// Off by default
+	cfg.BatchResults = false
+	outputDir = t.TempDir()
+	if result, _ := conv.ConvertPDFsInDirectory(inputDir, outputDir); result.ResultsFile != "" {
+		t.Errorf("expected no results, got %q", result.ResultsFile)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, BatchResultsFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no %s, got %v", BatchResultsFileName, err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package pdfconv - Batch summaries.
+// This file writes BATCH_SUMMARY.md to the output base directory after a batch conversion,
+// listing every converted document with a link to its Markdown, its statistics and quality
+// score, followed by the documents that failed, as a human-readable record of the batch.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BatchSummaryFileName is the batch summary written when BATCH_SUMMARY is enabled.
+const BatchSummaryFileName = "BATCH_SUMMARY.md"
+
+// writeBatchSummary writes the summary of a batch conversion to its output base directory
+// and returns its path.
+func writeBatchSummary(result *BatchConversionResult) (string, error) {
+	path := filepath.Join(result.OutputBaseDir, BatchSummaryFileName)
+	if err := os.MkdirAll(result.OutputBaseDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(renderBatchSummary(result, path)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", BatchSummaryFileName, err)
+	}
+	return path, nil
+}
+
+// renderBatchSummary renders the batch summary written to path. Documents are named by
+// their path relative to the input directory and linked relative to the summary.
+func renderBatchSummary(result *BatchConversionResult, path string) string {
+	name := func(source string) string {
+		if rel, err := filepath.Rel(result.InputDir, source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = filepath.ToSlash(rel)
+		}
+		return escapeSummaryCell(source)
+	}
+
+	var md strings.Builder
+	md.WriteString("# Batch Conversion Summary\n\n")
+	fmt.Fprintf(&md, "- **Input:** `%s`\n", result.InputDir)
+	fmt.Fprintf(&md, "- **Documents:** %d converted, %d failed, %d total\n", result.SuccessCount, result.FailureCount, result.FileCount)
+	fmt.Fprintf(&md, "- **Pages:** %d\n", result.TotalPageCount)
+	fmt.Fprintf(&md, "- **Images:** %d\n", result.TotalImageCount)
+	if len(result.Results) > 0 {
+		total := 0.0
+		for _, r := range result.Results {
+			total += r.Quality.Score
+		}
+		fmt.Fprintf(&md, "- **Average quality:** %.1f\n", total/float64(len(result.Results)))
+	}
+
+	if len(result.Results) > 0 {
+		md.WriteString("\n## Documents\n\n")
+		md.WriteString("| Document | Status | Pages | Images | Tables | Diagrams | Quality | Time | Warnings |\n")
+		md.WriteString("|----------|--------|-------|--------|--------|----------|---------|------|----------|\n")
+		for _, r := range result.Results {
+			warnings := strings.Join(r.Warnings(), ", ")
+			if warnings == "" {
+				warnings = "-"
+			}
+			fmt.Fprintf(&md, "| [%s](%s) | %s | %d | %d | %d | %d | %.1f | %s | %s |\n",
+				name(r.Source), relativeLink(path, r.MarkdownFile), r.Status, r.PageCount, r.ImageCount,
+				r.TableCount, r.DiagramCount, r.Quality.Score, FormatDuration(r.Duration), escapeSummaryCell(warnings))
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		md.WriteString("\n## Errors\n\n")
+		md.WriteString("| Document | Error |\n")
+		md.WriteString("|----------|-------|\n")
+		for _, e := range result.Errors {
+			fmt.Fprintf(&md, "| %s | %s |\n", name(e.PDFPath), escapeSummaryCell(e.Error))
+		}
+	}
+	return md.String()
+}
+
+// escapeSummaryCell keeps a value on one table row and within its cell.
+func escapeSummaryCell(value string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(value), " "), "|", `\|`)
+}
@@ -0,0 +1,62 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestConvertPDFsInDirectory_BatchSummary(t *testing.T) {
+	inputDir, outputDir := t.TempDir(), t.TempDir()
+	if err := os.Mkdir(filepath.Join(inputDir, "regulators"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTitlePage(t, filepath.Join(inputDir, "regulators", "lm317.pdf"), "LM317 3-Terminal Adjustable Regulator")
+	if err := os.WriteFile(filepath.Join(inputDir, "broken.pdf"), []byte("not a | pdf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BaseHeaderLevel: 1, BatchSummary: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+
+	result, err := conv.ConvertPDFsInDirectory(inputDir, outputDir)
+	if err != nil {
+		t.Fatalf("ConvertPDFsInDirectory() error = %v", err)
+	}
+	if result.SummaryFile != filepath.Join(outputDir, BatchSummaryFileName) {
+		t.Fatalf("expected the summary in the output directory, got %q", result.SummaryFile)
+	}
+	data, err := os.ReadFile(result.SummaryFile)
+	if err != nil {
+		t.Fatalf("expected %s: %v", BatchSummaryFileName, err)
+	}
+	summary := string(data)
+	link := relativeLink(result.SummaryFile, result.Results[0].MarkdownFile)
+	for _, expected := range []string{
+		"# Batch Conversion Summary\n",
+		"- **Documents:** 1 converted, 1 failed, 2 total\n",
+		"| [regulators/lm317.pdf](" + link + ") | complete | 1 | 0 | ",
+		"## Errors\n",
+		"| broken.pdf | ",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected %q in:\n%s", expected, summary)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(link))); err != nil {
+		t.Errorf("expected the document link to resolve: %v", err)
+	}
+
+	// Off by default
+	cfg.BatchSummary = false
+	outputDir = t.TempDir()
+	if result, _ := conv.ConvertPDFsInDirectory(inputDir, outputDir); result.SummaryFile != "" {
+		t.Errorf("expected no summary, got %q", result.SummaryFile)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, BatchSummaryFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no %s, got %v", BatchSummaryFileName, err)
+	}
+}
@@ -0,0 +1,418 @@
+// Package pdfconv - Application circuit bill of materials.
+// This file finds the "Typical Application" schematics of datasheets and collects the
+// component designators and values given by their labels, the text around them and
+// component tables into a bill of materials, the starting point of an application design.
+package pdfconv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BOMFileName is the name of the bill of materials written next to the Markdown.
+const BOMFileName = "bom.csv"
+
+const (
+	// bomLabelDistance is the largest distance in points between a designator label of a
+	// schematic and the value label below it.
+	bomLabelDistance = 14.0
+	// bomLabelOffset is the largest horizontal offset in points between a designator label
+	// and its value label.
+	bomLabelOffset = 24.0
+	// bomMaxRange is the largest number of designators a range such as "R1-R4" expands to.
+	bomMaxRange = 50
+)
+
+// BOMItem is one line of the bill of materials: the components that share a value and
+// description.
+type BOMItem struct {
+	Designators []string // Component designators in order, e.g. "C1", "C2" or "CIN"
+	Value       string   // Value as printed, e.g. "10 µF" or a part number
+	Description string   // Description from a component table, or the component kind
+	Pages       []int    // Pages the components are listed on
+}
+
+// bomComponent is one component found on a page.
+type bomComponent struct {
+	designator  string
+	value       string
+	description string
+	page        int
+}
+
+// bomKind describes the components of one designator prefix.
+type bomKind struct {
+	prefix      string
+	description string
+	value       *regexp.Regexp // Matches a value at the start of the text after a designator
+}
+
+var (
+	// applicationTitlePattern matches the titles of application circuits.
+	applicationTitlePattern = regexp.MustCompile(`(?i)\b(typical\s+applications?|application\s+(circuits?|schematics?|diagrams?|examples?)|reference\s+(designs?|schematics?|circuits?)|simplified\s+schematics?)\b`)
+	// bomTitlePattern matches the titles of component tables outside application sections.
+	bomTitlePattern = regexp.MustCompile(`(?i)\b(bill\s+of\s+materials?|BOM|parts\s+list|external\s+components?|component\s+(selection|list|values)|recommended\s+(external\s+)?components?)\b`)
+	// designatorPattern matches component designators such as "R1", "C12A", "FB2" or "CIN".
+	designatorPattern = regexp.MustCompile(`\b((?:R|C|L)(?:IN|OUT|FB|BOOT|BST|SS|COMP|SET|ILIM|T|VCC|VDD)\d?|(?:FB|R|C|L|D|Q|U|Y|F)\d{1,3}[A-Z]?)\b`)
+	// designatorRangePattern matches a designator range such as "R1-R4" or "C3 to C5".
+	designatorRangePattern = regexp.MustCompile(`^([A-Z]+)(\d+)\s*(?:-|–|~|to)\s*(?:([A-Z]+))?(\d+)$`)
+	// designatorListSeparator matches the text between the designators of a list such as
+	// "C1, C2 = 10 µF".
+	designatorListSeparator = regexp.MustCompile(`^\s*(,|/|&|and)\s*$`)
+	// bomPartNumberPattern matches the part number value of a semiconductor.
+	bomPartNumberPattern = regexp.MustCompile(`^(?:[A-Z]+[A-Z0-9-]*\d|\d+[A-Z]+)[A-Z0-9-]*`)
+)
+
+// bomKinds lists the designator prefixes with values, longer prefixes first.
+var bomKinds = []bomKind{
+	{"FB", "Ferrite bead", bomPartNumberPattern},
+	{"R", "Resistor", regexp.MustCompile(`^(\d+(?:\.\d+)?\s?[kKM]?\s?(?:Ω|Ω|[Oo]hms?)|\d+(?:\.\d+)?\s?[kKM]\b|\d+[RkKM]\d+\b)`)},
+	{"C", "Capacitor", regexp.MustCompile(`^\d+(?:\.\d+)?\s?[pnuµμm]?F\b`)},
+	{"L", "Inductor", regexp.MustCompile(`^\d+(?:\.\d+)?\s?[nuµμm]?H\b`)},
+	{"D", "Diode", bomPartNumberPattern},
+	{"Q", "Transistor", bomPartNumberPattern},
+	{"U", "Integrated circuit", bomPartNumberPattern},
+	{"Y", "Crystal", regexp.MustCompile(`^\d+(?:\.\d+)?\s?[kM]Hz\b`)},
+	{"F", "Fuse", regexp.MustCompile(`^\d+(?:\.\d+)?\s?m?A\b`)},
+}
+
+// designatorKind returns the kind of a designator.
+func designatorKind(designator string) bomKind {
+	for _, kind := range bomKinds {
+		if strings.HasPrefix(designator, kind.prefix) {
+			return kind
+		}
+	}
+	return bomKind{}
+}
+
+// collectBOM finds the application circuits of a document and lists their components by
+// value. Component tables come first, then values in the text and schematic labels of the
+// application pages; a designator keeps the first value found for it.
+func (c *PDFConverter) collectBOM(pages []PDFPage) []BOMItem {
+	if !c.config.ApplicationBOM {
+		return nil
+	}
+	var components []bomComponent
+	seen := map[string]bool{}
+	add := func(found []bomComponent) {
+		for _, component := range found {
+			if !seen[component.designator] {
+				seen[component.designator] = true
+				components = append(components, component)
+			}
+		}
+	}
+	for _, page := range pages {
+		title := applicationTitleLine(page)
+		for _, table := range page.Tables {
+			if table.Fallback || (title < 0 && !bomTitlePattern.MatchString(tableTitle(page, table))) {
+				continue
+			}
+			add(tableComponents(table, page.Number))
+		}
+		if title >= 0 {
+			add(textComponents(page.Lines[title:], page.Number))
+			add(labelComponents(page.Lines[title:], page.Number))
+		}
+	}
+	items := groupComponents(components)
+	if len(items) > 0 {
+		c.logger.Info("Found %d component(s) in typical application circuits", len(components))
+	}
+	return items
+}
+
+// applicationTitleLine returns the index of the first line of a page that titles an
+// application circuit, or -1. Table of contents entries, with dot leaders, are not titles.
+func applicationTitleLine(page PDFPage) int {
+	for i, line := range page.Lines {
+		if applicationTitlePattern.MatchString(line.Text) && !strings.Contains(line.Text, "....") {
+			return i
+		}
+	}
+	return -1
+}
+
+// tableComponents returns the components of a table with a designator column and a value
+// column. The designator column is the first one mostly holding designators; the value
+// column is the one labeled as such, or else the first column mostly holding values of the
+// designated components. Columns labeled as descriptions or part numbers describe them.
+func tableComponents(table PDFTable, pageNum int) []bomComponent {
+	columns := len(table.Header)
+	if columns < 2 || len(table.Rows) == 0 {
+		return nil
+	}
+	designators := -1
+	for col := 0; col < columns && designators < 0; col++ {
+		count := 0
+		for _, row := range table.Rows {
+			if col < len(row) && len(parseDesignators(row[col])) > 0 {
+				count++
+			}
+		}
+		if count*2 > len(table.Rows) {
+			designators = col
+		}
+	}
+	if designators < 0 {
+		return nil
+	}
+	value := -1
+	var descriptions []int
+	for col, label := range table.Header {
+		l := strings.ToLower(label)
+		switch {
+		case col == designators:
+		case value < 0 && (strings.Contains(l, "value") || strings.Contains(l, "typ") || strings.Contains(l, "recommended")):
+			value = col
+		case strings.Contains(l, "description") || strings.Contains(l, "function") || strings.Contains(l, "part") ||
+			strings.Contains(l, "manufacturer") || strings.Contains(l, "mfr") || strings.Contains(l, "comment"):
+			descriptions = append(descriptions, col)
+		}
+	}
+	for col := 0; col < columns && value < 0; col++ {
+		if col == designators || slices.Contains(descriptions, col) {
+			continue
+		}
+		count := 0
+		for _, row := range table.Rows {
+			if col < len(row) && designators < len(row) {
+				if ds := parseDesignators(row[designators]); len(ds) > 0 && designatorKind(ds[0]).value.MatchString(strings.TrimSpace(row[col])) {
+					count++
+				}
+			}
+		}
+		if count*2 > len(table.Rows) {
+			value = col
+		}
+	}
+	if value < 0 {
+		return nil
+	}
+
+	var components []bomComponent
+	for _, row := range table.Rows {
+		if designators >= len(row) || value >= len(row) {
+			continue
+		}
+		v := strings.TrimSpace(row[value])
+		if v == "" || v == "-" {
+			continue
+		}
+		var parts []string
+		for _, col := range descriptions {
+			if col < len(row) && strings.TrimSpace(row[col]) != "" && row[col] != "-" {
+				parts = append(parts, strings.TrimSpace(row[col]))
+			}
+		}
+		for _, designator := range parseDesignators(row[designators]) {
+			description := strings.Join(parts, ", ")
+			if description == "" {
+				description = designatorKind(designator).description
+			}
+			components = append(components, bomComponent{designator: designator, value: v, description: description, page: pageNum})
+		}
+	}
+	return components
+}
+
+// parseDesignators returns the designators of a table cell such as "C1, C2", "R1/R2" or
+// "R3-R5", or nil when the cell holds anything else.
+func parseDesignators(cell string) []string {
+	var designators []string
+	for _, field := range strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' || r == '/' || r == '&' }) {
+		field = strings.TrimSpace(field)
+		if m := designatorRangePattern.FindStringSubmatch(field); m != nil && (m[3] == "" || m[3] == m[1]) && designatorKind(m[1]).prefix == m[1] {
+			from, _ := strconv.Atoi(m[2])
+			to, _ := strconv.Atoi(m[4])
+			if to < from || to-from >= bomMaxRange {
+				return nil
+			}
+			for n := from; n <= to; n++ {
+				designators = append(designators, m[1]+strconv.Itoa(n))
+			}
+			continue
+		}
+		if designatorPattern.FindString(field) != field || field == "" {
+			return nil
+		}
+		designators = append(designators, field)
+	}
+	return designators
+}
+
+// textComponents returns the components whose value the text gives right after their
+// designator, as in "R1 = 10 kΩ", "CIN: 22 µF" or "C1, C2 = 100 nF".
+func textComponents(lines []TextLine, pageNum int) []bomComponent {
+	var components []bomComponent
+	for _, line := range lines {
+		text := line.Text
+		matches := designatorPattern.FindAllStringIndex(text, -1)
+		var pending []string
+		for i, m := range matches {
+			designator := text[m[0]:m[1]]
+			kind := designatorKind(designator)
+			pending = append(pending, designator)
+			if value := kind.value.FindString(strings.TrimLeft(text[m[1]:], " =:(")); value != "" {
+				for _, d := range pending {
+					if designatorKind(d).prefix == kind.prefix {
+						components = append(components, bomComponent{designator: d, value: value, description: kind.description, page: pageNum})
+					}
+				}
+				pending = nil
+				continue
+			}
+			if i+1 < len(matches) && designatorListSeparator.MatchString(text[m[1]:matches[i+1][0]]) {
+				continue
+			}
+			pending = nil
+		}
+	}
+	return components
+}
+
+// labelComponents returns the components of a schematic whose designator label stands in
+// a cell of its own with the value label just below it.
+func labelComponents(lines []TextLine, pageNum int) []bomComponent {
+	var components []bomComponent
+	for i, line := range lines {
+		for _, cell := range line.Cells {
+			designator := strings.TrimSpace(cell.Text)
+			if designatorPattern.FindString(designator) != designator || designator == "" {
+				continue
+			}
+			kind := designatorKind(designator)
+			if value := belowLabel(lines[i+1:], line.Y, cell.X, kind); value != "" {
+				components = append(components, bomComponent{designator: designator, value: value, description: kind.description, page: pageNum})
+			}
+		}
+	}
+	return components
+}
+
+// belowLabel returns the value label of a kind of component below the label at y and x, or
+// "" when there is none.
+func belowLabel(lines []TextLine, y, x float64, kind bomKind) string {
+	for _, line := range lines {
+		if y-line.Y > bomLabelDistance {
+			break
+		}
+		for _, cell := range line.Cells {
+			text := strings.TrimSpace(cell.Text)
+			if math.Abs(cell.X-x) <= bomLabelOffset && kind.value.FindString(text) == text && text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// groupComponents joins the components with the same value and description into BOM items
+// ordered by their first designator.
+func groupComponents(components []bomComponent) []BOMItem {
+	var items []BOMItem
+	index := map[string]int{}
+	for _, component := range components {
+		key := component.value + "\x00" + component.description
+		i, ok := index[key]
+		if !ok {
+			i = len(items)
+			index[key] = i
+			items = append(items, BOMItem{Value: component.value, Description: component.description})
+		}
+		items[i].Designators = append(items[i].Designators, component.designator)
+		if !slices.Contains(items[i].Pages, component.page) {
+			items[i].Pages = append(items[i].Pages, component.page)
+		}
+	}
+	for i := range items {
+		sort.Slice(items[i].Designators, func(a, b int) bool {
+			return designatorLess(items[i].Designators[a], items[i].Designators[b])
+		})
+		sort.Ints(items[i].Pages)
+	}
+	sort.SliceStable(items, func(a, b int) bool { return designatorLess(items[a].Designators[0], items[b].Designators[0]) })
+	return items
+}
+
+// designatorLess orders designators by prefix, then by number, so "R2" comes before "R10".
+func designatorLess(a, b string) bool {
+	split := func(d string) (string, int, string) {
+		i := strings.IndexAny(d, "0123456789")
+		if i < 0 {
+			return d, -1, ""
+		}
+		j := i
+		for j < len(d) && d[j] >= '0' && d[j] <= '9' {
+			j++
+		}
+		n, _ := strconv.Atoi(d[i:j])
+		return d[:i], n, d[j:]
+	}
+	pa, na, sa := split(a)
+	pb, nb, sb := split(b)
+	if pa != pb {
+		return pa < pb
+	}
+	if na != nb {
+		return na < nb
+	}
+	return sa < sb
+}
+
+// quantity returns the number of components of the item.
+func (item BOMItem) quantity() string {
+	return strconv.Itoa(len(item.Designators))
+}
+
+// pages returns the pages of the item as a comma separated list.
+func (item BOMItem) pages() string {
+	pages := make([]string, len(item.Pages))
+	for i, page := range item.Pages {
+		pages[i] = strconv.Itoa(page)
+	}
+	return strings.Join(pages, ", ")
+}
+
+// bomMarkdown renders the bill of materials as a section appended to the document.
+func (c *PDFConverter) bomMarkdown(items []BOMItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+	table := PDFTable{Header: []string{"Designators", "Qty", "Value", "Description", "Pages"}}
+	for _, item := range items {
+		table.Rows = append(table.Rows, []string{strings.Join(item.Designators, ", "), item.quantity(), item.Value, item.Description, item.pages()})
+	}
+	return fmt.Sprintf("---\n\n%s\n\n%s\n", c.heading(c.config.BaseHeaderLevel+1, "Bill of Materials"), table.markdown())
+}
+
+// writeBOMFile writes bom.csv for a document into dir.
+func writeBOMFile(dir string, items []BOMItem) error {
+	file, err := os.Create(filepath.Join(dir, BOMFileName))
+	if err != nil {
+		return fmt.Errorf("failed to write bill of materials: %v", err)
+	}
+	w := csv.NewWriter(file)
+	w.Write([]string{"designators", "quantity", "value", "description", "pages"})
+	for _, item := range items {
+		w.Write([]string{strings.Join(item.Designators, ", "), item.quantity(), item.Value, item.Description, item.pages()})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write bill of materials: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write bill of materials: %v", err)
+	}
+	return nil
+}
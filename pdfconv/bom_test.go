@@ -0,0 +1,132 @@
+package pdfconv
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCollectBOM(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractTables: true, ApplicationBOM: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	// Designators outside an application section are not components
+	overview := []TextLine{
+		tableLine(700, "The internal feedback divider R1 = 99 kΩ sets the output."),
+	}
+	// Schematic labels with their values below, and values given in the text
+	application := []TextLine{
+		tableLine(760, "9.2 Typical Application"),
+		tableLine(600, "R1", "C1"),
+		tableLine(590, "10kΩ", "100 nF"),
+		tableLine(400, "Use CIN, COUT = 22 µF ceramic capacitors and L1 (2.2 µH) with U1 TPS62130."),
+	}
+	components := []TextLine{
+		tableLine(760, "Table 5. Recommended External Components"),
+		tableLine(740, "Designator", "Value", "Description"),
+		tableLine(720, "C2, C3", "10 µF", "X7R, 16 V"),
+		tableLine(700, "R2-R4", "100 kΩ", "Pull-up"),
+		tableLine(680, "C1", "1 µF", "Bypass"),
+	}
+	var pages []PDFPage
+	for i, lines := range [][]TextLine{overview, application, components} {
+		pages = append(pages, PDFPage{Number: i + 1, Lines: lines, Tables: detectTables(lines)})
+	}
+
+	items := conv.collectBOM(pages)
+	want := []BOMItem{
+		{Designators: []string{"C1"}, Value: "100 nF", Description: "Capacitor", Pages: []int{2}},
+		{Designators: []string{"C2", "C3"}, Value: "10 µF", Description: "X7R, 16 V", Pages: []int{3}},
+		{Designators: []string{"CIN", "COUT"}, Value: "22 µF", Description: "Capacitor", Pages: []int{2}},
+		{Designators: []string{"L1"}, Value: "2.2 µH", Description: "Inductor", Pages: []int{2}},
+		{Designators: []string{"R1"}, Value: "10kΩ", Description: "Resistor", Pages: []int{2}},
+		{Designators: []string{"R2", "R3", "R4"}, Value: "100 kΩ", Description: "Pull-up", Pages: []int{3}},
+		{Designators: []string{"U1"}, Value: "TPS62130", Description: "Integrated circuit", Pages: []int{2}},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("unexpected bill of materials:\n got %+v\nwant %+v", items, want)
+	}
+	if md := conv.bomMarkdown(items); !strings.Contains(md, "## Bill of Materials") || !strings.Contains(md, "| R2, R3, R4 | 3 | 100 kΩ | Pull-up | 3 |") {
+		t.Errorf("unexpected bill of materials section:\n%s", md)
+	}
+
+	cfg.ApplicationBOM = false
+	if got := conv.collectBOM(pages); got != nil {
+		t.Errorf("expected no bill of materials with APPLICATION_BOM off, got %+v", got)
+	}
+}
+
+func TestParseDesignators(t *testing.T) {
+	tests := []struct {
+		cell string
+		want []string
+	}{
+		{"C1, C2", []string{"C1", "C2"}},
+		{"R1/R2", []string{"R1", "R2"}},
+		{"R3-R5", []string{"R3", "R4", "R5"}},
+		{"C7 to C8", []string{"C7", "C8"}},
+		{"CIN", []string{"CIN"}},
+		{"R1-C3", nil},
+		{"VDD", nil},
+		{"Input capacitor", nil},
+	}
+	for _, tt := range tests {
+		if got := parseDesignators(tt.cell); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseDesignators(%q) = %v, want %v", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestConvertPDF_ApplicationBOM(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "buck.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Helvetica", "", 10)
+	doc.AddPage()
+	doc.SetXY(20, 20)
+	for _, text := range []string{"Typical Application Circuit", "The output voltage is set by R1 = 100k and R2 = 22k.", "Place C1 = 4.7 nF close to the SS pin."} {
+		doc.SetX(20)
+		doc.CellFormat(170, 6, text, "", 1, "L", false, 0, "")
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create application pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ApplicationBOM: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if len(res.BOM) != 3 {
+		t.Fatalf("expected 3 BOM items, got %+v", res.BOM)
+	}
+	file, err := os.Open(filepath.Join(res.OutputDir, BOMFileName))
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", BOMFileName, err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid %s: %v", BOMFileName, err)
+	}
+	want := [][]string{
+		{"designators", "quantity", "value", "description", "pages"},
+		{"C1", "1", "4.7 nF", "Capacitor", "1"},
+		{"R1", "1", "100k", "Resistor", "1"},
+		{"R2", "1", "22k", "Resistor", "1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("unexpected %s content: %v", BOMFileName, records)
+	}
+	markdown, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(markdown), "Bill of Materials") {
+		t.Errorf("expected a bill of materials section in the Markdown:\n%s", markdown)
+	}
+}
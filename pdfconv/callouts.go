@@ -0,0 +1,245 @@
+// Package pdfconv - Callouts.
+// This file finds the warning, caution and note boxes of datasheets, by their colored
+// background fill or a leading icon glyph, and writes them as GFM alerts (> [!WARNING]) so
+// safety-critical notes stand out in the converted output.
+package pdfconv
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Callout detection thresholds
+const (
+	CalloutMinWidth      = 0.3  // Narrowest box counted as a callout, as a fraction of the page width
+	CalloutMaxHeight     = 0.5  // Tallest box counted as a callout, as a fraction of the page height
+	calloutMinSaturation = 0.15 // Fills less saturated than this are gray and need a label or icon
+)
+
+// GFM alert types
+const (
+	AlertNote      = "NOTE"
+	AlertTip       = "TIP"
+	AlertImportant = "IMPORTANT"
+	AlertWarning   = "WARNING"
+	AlertCaution   = "CAUTION"
+)
+
+// Callout is a highlighted note on a page.
+type Callout struct {
+	Kind  string   // GFM alert type, one of the Alert* constants
+	Lines []string // Text of the callout, top to bottom
+	top   float64  // Top Y coordinate, for ordering
+}
+
+// calloutLabels map the labels callouts start with to their alert type.
+var calloutLabels = map[string]string{
+	"WARNING": AlertWarning, "CAUTION": AlertCaution, "DANGER": AlertCaution, "NOTE": AlertNote,
+	"NOTICE": AlertNote, "INFO": AlertNote, "TIP": AlertTip, "HINT": AlertTip,
+	"IMPORTANT": AlertImportant, "ATTENTION": AlertImportant,
+}
+
+// calloutIcons map the icon glyphs callouts start with to their alert type.
+var calloutIcons = map[rune]string{
+	'⚠': AlertWarning, '⛔': AlertCaution, '🛑': AlertCaution, '☠': AlertCaution, '⚡': AlertWarning,
+	'❗': AlertImportant, '❕': AlertImportant, 'ℹ': AlertNote, '🛈': AlertNote, '💡': AlertTip,
+}
+
+// calloutLabelPattern matches a callout label at the start of a line, with its punctuation.
+var calloutLabelPattern = regexp.MustCompile(`(?i)^(warning|caution|danger|notice|note|info|tip|hint|important|attention)\b[\s:.!-]*`)
+
+// detectCallouts returns the callouts of a PDF page, top to bottom: boxes with a colored
+// fill, or a gray fill and a label or icon, that hold text, and lines starting with an icon.
+func (c *PDFConverter) detectCallouts(page pdf.Page, pageNum int, runs []pdf.Text) (callouts []Callout) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Debug("Failed to check page %d for callouts: %v", pageNum, r)
+		}
+	}()
+	width, height := pageSize(page)
+	if width <= 0 || height <= 0 {
+		width, height = 612, 792
+	}
+	used := make([]bool, len(runs))
+	for _, box := range filledRects(page) {
+		w, h := box.Max.X-box.Min.X, box.Max.Y-box.Min.Y
+		if !box.color.known || box.color.dark() || w < CalloutMinWidth*width || h > CalloutMaxHeight*height {
+			continue
+		}
+		var inside []pdf.Text
+		for i, run := range runs {
+			x, y := run.X+run.W/2, run.Y+run.FontSize/3
+			if !used[i] && strings.TrimSpace(run.S) != "" && x >= box.Min.X && x <= box.Max.X && y >= box.Min.Y && y <= box.Max.Y {
+				inside = append(inside, run)
+				used[i] = true
+			}
+		}
+		lines := groupTextLines(inside)
+		if len(lines) == 0 {
+			continue
+		}
+		kind := calloutLabel(lines[0].Text)
+		if kind == "" {
+			kind = box.color.alertKind()
+		}
+		if kind != "" {
+			callouts = append(callouts, Callout{Kind: kind, Lines: strings.Split(linesText(lines), "\n"), top: box.Max.Y})
+		}
+	}
+	var free []pdf.Text
+	for i, run := range runs {
+		if !used[i] {
+			free = append(free, run)
+		}
+	}
+	for _, line := range groupTextLines(free) {
+		first, _ := utf8.DecodeRuneInString(line.Text)
+		if kind, ok := calloutIcons[first]; ok {
+			callouts = append(callouts, Callout{Kind: kind, Lines: []string{line.Text}, top: line.Y})
+		}
+	}
+	sort.SliceStable(callouts, func(i, j int) bool { return callouts[i].top > callouts[j].top })
+	if len(callouts) > 0 {
+		c.logger.Debug("Found %d callout(s) on page %d", len(callouts), pageNum)
+	}
+	return callouts
+}
+
+// calloutLabel returns the alert type of a callout whose first line starts with an icon or
+// a label such as "WARNING:", or "".
+func calloutLabel(line string) string {
+	line = strings.TrimSpace(line)
+	first, size := utf8.DecodeRuneInString(line)
+	if kind, ok := calloutIcons[first]; ok {
+		return kind
+	}
+	if !unicode.IsLetter(first) {
+		line = strings.TrimSpace(line[size:]) // an icon font glyph
+	}
+	if m := calloutLabelPattern.FindStringSubmatch(line); m != nil {
+		return calloutLabels[strings.ToUpper(m[1])]
+	}
+	return ""
+}
+
+// alertKind returns the alert type whose GitHub color the fill color is closest to by hue:
+// red for caution, orange and yellow for warning, green for tip, blue for note and purple
+// for important. Gray and unknown colors return "".
+func (c fillColor) alertKind() string {
+	hi, lo := math.Max(c.r, math.Max(c.g, c.b)), math.Min(c.r, math.Min(c.g, c.b))
+	if !c.known || hi-lo < 0.05 {
+		return ""
+	}
+	lightness := (hi + lo) / 2
+	if (hi-lo)/(1-math.Abs(2*lightness-1)) < calloutMinSaturation {
+		return ""
+	}
+	var hue float64
+	switch hi {
+	case c.r:
+		hue = math.Mod((c.g-c.b)/(hi-lo)+6, 6) * 60
+	case c.g:
+		hue = ((c.b-c.r)/(hi-lo) + 2) * 60
+	default:
+		hue = ((c.r-c.g)/(hi-lo) + 4) * 60
+	}
+	switch {
+	case hue < 15 || hue >= 330:
+		return AlertCaution
+	case hue < 70:
+		return AlertWarning
+	case hue < 170:
+		return AlertTip
+	case hue < 260:
+		return AlertNote
+	default:
+		return AlertImportant
+	}
+}
+
+// markCallouts writes the callouts of page in text as GFM alerts when DETECT_CALLOUTS is set.
+func (c *PDFConverter) markCallouts(text string, page PDFPage) string {
+	if !c.config.DetectCallouts {
+		return text
+	}
+	return markCalloutLines(text, page.Callouts)
+}
+
+// markCalloutLines replaces the lines of text holding each callout, in order, with a GFM
+// alert. Callouts are matched ignoring whitespace, since text extraction may break their
+// lines differently; a leading icon or label is dropped as the alert type shows it.
+func markCalloutLines(text string, callouts []Callout) string {
+	if len(callouts) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	var out []string
+	next := 0
+	for i := 0; i < len(lines); i++ {
+		end, k := -1, next
+		for ; k < len(callouts) && end < 0; k++ {
+			end = calloutEnd(lines, i, strings.Join(callouts[k].Lines, ""))
+		}
+		if end < 0 {
+			out = append(out, lines[i])
+			continue
+		}
+		out = append(out, "> [!"+callouts[k-1].Kind+"]")
+		for j, line := range lines[i:end] {
+			line = strings.TrimSpace(line)
+			if j == 0 {
+				line = stripCalloutLabel(line)
+			}
+			if line != "" {
+				out = append(out, "> "+line)
+			}
+		}
+		i, next = end-1, k
+	}
+	return strings.Join(out, "\n")
+}
+
+// calloutEnd returns the end of the lines starting at lines[i] whose text, ignoring
+// whitespace, is exactly text, or -1.
+func calloutEnd(lines []string, i int, text string) int {
+	want := stripSpace(text)
+	var got strings.Builder
+	for j := i; j < len(lines) && want != ""; j++ {
+		line := stripSpace(lines[j])
+		if j == i && line == "" {
+			return -1
+		}
+		got.WriteString(line)
+		if !strings.HasPrefix(want, got.String()) {
+			return -1
+		}
+		if got.Len() == len(want) {
+			return j + 1
+		}
+	}
+	return -1
+}
+
+// stripSpace removes all whitespace from s.
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// stripCalloutLabel removes a leading icon and label from the first line of a callout.
+func stripCalloutLabel(line string) string {
+	if first, size := utf8.DecodeRuneInString(line); !unicode.IsLetter(first) && !unicode.IsDigit(first) && calloutLabel(line) != "" {
+		line = strings.TrimSpace(line[size:])
+	}
+	return strings.TrimSpace(calloutLabelPattern.ReplaceAllString(line, ""))
+}
@@ -0,0 +1,107 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCalloutKinds(t *testing.T) {
+	for line, want := range map[string]string{
+		"WARNING: Do not exceed 3.6 V": AlertWarning,
+		"Caution - hot surface":        AlertCaution,
+		"DANGER High voltage":          AlertCaution,
+		"Note: see Table 3":            AlertNote,
+		"⚠ Electrostatic sensitive":    AlertWarning,
+		"💡 Tip text":                   AlertTip,
+		"Notes on layout":              "",
+		"VDD must not exceed 3.6 V":    "",
+	} {
+		if got := calloutLabel(line); got != want {
+			t.Errorf("calloutLabel(%q) = %q, want %q", line, got, want)
+		}
+	}
+	for _, tt := range []struct {
+		color fillColor
+		want  string
+	}{
+		{fillColor{1, 0.85, 0.85, true}, AlertCaution},
+		{fillColor{1, 0.95, 0.7, true}, AlertWarning},
+		{fillColor{0.85, 1, 0.85, true}, AlertTip},
+		{fillColor{0.85, 0.9, 1, true}, AlertNote},
+		{fillColor{0.9, 0.85, 1, true}, AlertImportant},
+		{fillColor{0.9, 0.9, 0.9, true}, ""},
+		{fillColor{}, ""},
+	} {
+		if got := tt.color.alertKind(); got != tt.want {
+			t.Errorf("alertKind(%+v) = %q, want %q", tt.color, got, tt.want)
+		}
+	}
+}
+
+func TestMarkCalloutLines(t *testing.T) {
+	text := "Absolute Ratings\nWARNING: Do not exceed\n3.6 V on any pin.\nSupply ranges\n⚠ ESD sensitive device\nEnd"
+	callouts := []Callout{
+		{Kind: AlertNote, Lines: []string{"Not on this page"}},
+		{Kind: AlertWarning, Lines: []string{"WARNING: Do not exceed 3.6 V", "on any pin."}},
+		{Kind: AlertWarning, Lines: []string{"⚠ ESD sensitive device"}},
+	}
+	want := "Absolute Ratings\n> [!WARNING]\n> Do not exceed\n> 3.6 V on any pin.\nSupply ranges\n> [!WARNING]\n> ESD sensitive device\nEnd"
+	if got := markCalloutLines(text, callouts); got != want {
+		t.Errorf("markCalloutLines() = %q, want %q", got, want)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	formatted := conv.formatTextContent(want)
+	if !strings.Contains(formatted, "Absolute Ratings\n\n> [!WARNING]\n> Do not exceed") || !strings.Contains(formatted, "> 3.6 V on any pin.\n\nSupply ranges") {
+		t.Errorf("expected alerts set apart by blank lines, got:\n%s", formatted)
+	}
+}
+
+func TestConvertPDF_Callouts(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "callouts.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.AddPage()
+	doc.SetFont("Arial", "", 11)
+	doc.Cell(120, 6, "The device operates from a single supply.")
+	doc.Ln(12)
+	doc.SetFillColor(255, 236, 179)
+	doc.CellFormat(170, 8, "Exceeding the absolute ratings may damage the device.", "", 1, "L", true, 0, "")
+	doc.Ln(6)
+	doc.SetFillColor(230, 230, 230)
+	doc.CellFormat(170, 8, "Note: the pins are 5 V tolerant.", "", 1, "L", true, 0, "")
+	doc.Ln(6)
+	doc.CellFormat(170, 8, "Shaded table row", "", 1, "L", true, 0, "")
+	doc.Ln(6)
+	doc.Cell(120, 6, "Decouple the supply close to the pins.")
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, DetectCallouts: true}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	for _, want := range []string{"> [!WARNING]\n> Exceeding the absolute ratings may damage the device.", "> [!NOTE]\n> the pins are 5 V tolerant.", "\nShaded table row\n"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("expected %q in the Markdown, got:\n%s", want, md)
+		}
+	}
+
+	plain, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err = plain.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if md, _ := os.ReadFile(res.MarkdownFile); strings.Contains(string(md), "[!") {
+		t.Errorf("expected no alerts without DETECT_CALLOUTS, got:\n%s", md)
+	}
+}
@@ -0,0 +1,112 @@
+// Package pdfconv - Optional external tools.
+// This file probes for the external programs some features depend on, so the server can
+// report at startup what is enabled and turn off features whose tools are missing instead
+// of failing when they are used.
+package pdfconv
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Optional tool names
+const (
+	CapabilityOCR    = "tesseract" // Text recognition of scans and image alt text
+	CapabilityRender = "renderer"  // Page rendering with pdftoppm, Ghostscript or pdfium
+	CapabilityDjVu   = "djvulibre" // DjVu input
+)
+
+// Capability is an optional external tool and whether it was found on PATH.
+type Capability struct {
+	Name      string   // Tool name, one of the Capability* constants
+	Binaries  []string // Executables that must all be on PATH, or one of them when AnyOf is set
+	AnyOf     bool     // Whether any one of Binaries is enough
+	Features  string   // Features that depend on the tool
+	Available bool     // Whether the binaries were found
+	Missing   []string // Binaries that were not found
+	Using     string   // Binary used when AnyOf is set and one was found
+}
+
+// capabilitySpecs lists the optional tools in the order they are reported.
+var capabilitySpecs = []Capability{
+	{Name: CapabilityOCR, Binaries: []string{"tesseract"}, Features: "OCR of page scans (convert_images_to_markdown) and IMAGE_ALT_TEXT=ocr"},
+	{Name: CapabilityRender, AnyOf: true, Features: "rendering low-confidence tables as images, OCR of garbled text layers and thumbnails"},
+	{Name: CapabilityDjVu, Binaries: []string{"djvused", "djvutxt", "ddjvu"}, Features: "DjVu input"},
+}
+
+// DetectCapabilities probes PATH for the optional tools, logs which features are enabled
+// and remembers the result for HasCapability. It is called once at startup.
+func (c *PDFConverter) DetectCapabilities() []Capability {
+	capabilities := make([]Capability, 0, len(capabilitySpecs))
+	for _, spec := range capabilitySpecs {
+		capability := spec
+		capability.Missing = nil
+		if spec.Name == CapabilityRender {
+			// The renderers RENDERER allows
+			capability.Binaries = c.rendererBinaries()
+		}
+		for _, bin := range capability.Binaries {
+			if _, err := exec.LookPath(bin); err != nil {
+				capability.Missing = append(capability.Missing, bin)
+			} else if capability.AnyOf && capability.Using == "" {
+				capability.Using = bin
+			}
+		}
+		capability.Available = len(capability.Missing) == 0 || capability.Using != ""
+		if capability.Using != "" {
+			capability.Missing = nil
+			c.logger.Info("Optional tool %s found (%s): %s enabled", capability.Name, capability.Using, capability.Features)
+		} else if capability.Available {
+			c.logger.Info("Optional tool %s found: %s enabled", capability.Name, capability.Features)
+		} else {
+			c.logger.Warn("Optional tool %s not found (missing %s): %s disabled", capability.Name, strings.Join(capability.Missing, ", "), capability.Features)
+		}
+		capabilities = append(capabilities, capability)
+	}
+	if c.config.ImageAltText == "ocr" && !capabilityAvailable(capabilities, CapabilityOCR) {
+		c.logger.Warn("IMAGE_ALT_TEXT=ocr has no effect without tesseract; images are written without alt text")
+	}
+	c.capabilities = capabilities
+	return capabilities
+}
+
+// Capabilities returns the optional tools found by DetectCapabilities, or nil when the
+// tools were not probed.
+func (c *PDFConverter) Capabilities() []Capability {
+	return c.capabilities
+}
+
+// HasCapability reports whether the named optional tool is available. Before
+// DetectCapabilities has run every tool counts as available, and the features check
+// for their tool when they are used.
+func (c *PDFConverter) HasCapability(name string) bool {
+	if c.capabilities == nil {
+		return true
+	}
+	return capabilityAvailable(c.capabilities, name)
+}
+
+// capabilityAvailable reports whether the named tool is available in capabilities.
+func capabilityAvailable(capabilities []Capability, name string) bool {
+	for _, capability := range capabilities {
+		if capability.Name == name {
+			return capability.Available
+		}
+	}
+	return false
+}
+
+// RequireCapability returns an error naming the missing binaries when the named optional
+// tool was not found at startup.
+func (c *PDFConverter) RequireCapability(name string) error {
+	for _, capability := range c.capabilities {
+		if capability.Name == name && !capability.Available {
+			if capability.AnyOf {
+				return fmt.Errorf("none of %s found on PATH", strings.Join(capability.Missing, ", "))
+			}
+			return fmt.Errorf("%s not found on PATH", strings.Join(capability.Missing, ", "))
+		}
+	}
+	return nil
+}
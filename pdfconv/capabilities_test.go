@@ -0,0 +1,53 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	if conv.Capabilities() != nil || !conv.HasCapability(CapabilityDjVu) {
+		t.Fatal("expected every tool to count as available before probing")
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools require a POSIX shell")
+	}
+	// Only tesseract and one of the DjVuLibre tools are installed
+	dir := t.TempDir()
+	for _, name := range []string{"tesseract", "djvused"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	capabilities := conv.DetectCapabilities()
+	if len(capabilities) != len(capabilitySpecs) {
+		t.Fatalf("expected %d capabilities, got %d", len(capabilitySpecs), len(capabilities))
+	}
+	if !conv.HasCapability(CapabilityOCR) || conv.HasCapability(CapabilityRender) || conv.HasCapability(CapabilityDjVu) {
+		t.Errorf("unexpected capabilities: %+v", capabilities)
+	}
+	if err := conv.RequireCapability(CapabilityOCR); err != nil {
+		t.Errorf("RequireCapability(tesseract) error = %v", err)
+	}
+	err := conv.RequireCapability(CapabilityDjVu)
+	if err == nil || !strings.Contains(err.Error(), "djvutxt, ddjvu not found") {
+		t.Errorf("expected the missing DjVuLibre tools to be named, got %v", err)
+	}
+
+	// DjVu input is rejected before any DjVuLibre tool is run
+	djvuPath := filepath.Join(dir, "doc.djvu")
+	os.WriteFile(djvuPath, []byte("AT&TFORM"), 0644)
+	if _, err := conv.ConvertDocument(djvuPath, t.TempDir(), ConversionOptions{}); err == nil || !strings.Contains(err.Error(), "DjVu input is disabled") {
+		t.Errorf("expected DjVu input to be disabled, got %v", err)
+	}
+}
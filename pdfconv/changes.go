@@ -0,0 +1,261 @@
+// Package pdfconv - Revision change reports.
+// This file compares a re-converted document with the previous output in the same directory
+// and writes CHANGES.md listing the added, removed and modified sections and the changed
+// values of min/typ/max specification tables, to track datasheet revisions.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChangesFileName is the change report written when CHANGE_REPORT is enabled.
+const ChangesFileName = "CHANGES.md"
+
+// DocumentChanges lists the differences between a document and its previous conversion.
+type DocumentChanges struct {
+	Added      []string          `json:"added,omitempty"`       // Sections only in the new document
+	Removed    []string          `json:"removed,omitempty"`     // Sections only in the previous document
+	Modified   []SectionChange   `json:"modified,omitempty"`    // Sections whose content changed
+	SpecValues []SpecValueChange `json:"spec_values,omitempty"` // Changed specification table values
+}
+
+// SectionChange is a section whose content differs from the previous conversion.
+type SectionChange struct {
+	Section      string `json:"section"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+}
+
+// SpecValueChange is a value of a min/typ/max table that differs from the previous conversion.
+type SpecValueChange struct {
+	Section   string `json:"section"`
+	Parameter string `json:"parameter"`
+	Column    string `json:"column"`
+	Previous  string `json:"previous"`
+	Current   string `json:"current"`
+}
+
+// Count returns the number of changes.
+func (d DocumentChanges) Count() int {
+	return len(d.Added) + len(d.Removed) + len(d.Modified) + len(d.SpecValues)
+}
+
+// documentSection is the content of a section of a converted document.
+type documentSection struct {
+	title string
+	lines []string
+	specs map[string][]specValue // Values of min/typ/max table rows by parameter
+	order []string               // Parameters in document order
+}
+
+// specValue is a cell of a min/typ/max table row.
+type specValue struct {
+	column, value string
+}
+
+// writeChangeReport compares the Markdown of a conversion with the document in the previous
+// output directory, if any, and writes CHANGES.md to dir. It returns nil when there is no
+// previous Markdown or JSON document to compare with.
+func (c *PDFConverter) writeChangeReport(dir, previousDir, docPath, markdown string) (*DocumentChanges, error) {
+	previous, ok := previousBlocks(previousDir)
+	if !ok {
+		c.logger.Debug("No previous Markdown or JSON output in %s to compare with", previousDir)
+		return nil, nil
+	}
+	_, current := parseMarkdownBlocks(markdown)
+	changes := compareDocuments(previous, current)
+	if err := os.WriteFile(filepath.Join(dir, ChangesFileName), []byte(renderChanges(filepath.Base(docPath), changes)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", ChangesFileName, err)
+	}
+	c.logger.Info("Change report: %d added, %d removed, %d modified section(s), %d changed spec value(s)", len(changes.Added), len(changes.Removed), len(changes.Modified), len(changes.SpecValues))
+	return &changes, nil
+}
+
+// previousBlocks reads the blocks of the document in a previous output directory, from
+// README.md or README.json.
+func previousBlocks(dir string) ([]DocumentBlock, bool) {
+	if data, err := os.ReadFile(filepath.Join(dir, formatFileNames[FormatMarkdown])); err == nil {
+		_, blocks := parseMarkdownBlocks(string(data))
+		return blocks, true
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, formatFileNames[FormatJSON])); err == nil {
+		var file documentFile
+		if json.Unmarshal(data, &file) == nil {
+			return file.Blocks, true
+		}
+	}
+	return nil, false
+}
+
+// documentSections splits blocks into sections at every heading. Page headings do not start
+// a section, so text moving to another page is not reported as a change. Repeated titles are
+// numbered so each section has a unique title.
+func documentSections(blocks []DocumentBlock) []*documentSection {
+	current := &documentSection{specs: map[string][]specValue{}}
+	sections := []*documentSection{current}
+	seen := map[string]int{}
+	for _, block := range blocks {
+		switch block.Type {
+		case BlockHeading:
+			if pageHeadingPattern.MatchString(block.Text) {
+				continue
+			}
+			title := block.Text
+			if seen[block.Text]++; seen[block.Text] > 1 {
+				title = fmt.Sprintf("%s (%d)", block.Text, seen[block.Text])
+			}
+			current = &documentSection{title: title, specs: map[string][]specValue{}}
+			sections = append(sections, current)
+		case BlockParagraph, BlockQuote, BlockCode:
+			current.lines = append(current.lines, strings.Split(block.Text, "\n")...)
+		case BlockList:
+			current.lines = append(current.lines, block.Items...)
+		case BlockImage:
+			current.lines = append(current.lines, fmt.Sprintf("![%s](%s)", block.Alt, block.Src))
+		case BlockTable:
+			current.lines = append(current.lines, strings.Join(block.Header, " | "))
+			for _, row := range block.Rows {
+				current.lines = append(current.lines, strings.Join(row, " | "))
+			}
+			current.addSpecs(block.Header, block.Rows)
+		}
+	}
+	return sections
+}
+
+// addSpecs records the values of a min/typ/max table. Rows are identified by their cells
+// outside the value and unit columns, such as the parameter, symbol and conditions.
+func (s *documentSection) addSpecs(header []string, rows [][]string) {
+	kinds := specColumns(header)
+	if kinds == nil {
+		return
+	}
+	for _, row := range rows {
+		var names []string
+		var values []specValue
+		for i, kind := range kinds {
+			if i >= len(row) {
+				break
+			}
+			cell := strings.TrimSpace(strings.ReplaceAll(row[i], "**", ""))
+			if kind == "" {
+				if cell != "" {
+					names = append(names, cell)
+				}
+			} else {
+				values = append(values, specValue{strings.TrimSpace(header[i]), cell})
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		parameter := strings.Join(names, ", ")
+		if _, ok := s.specs[parameter]; !ok {
+			s.order = append(s.order, parameter)
+		}
+		s.specs[parameter] = values
+	}
+}
+
+// compareDocuments compares the sections of two documents.
+func compareDocuments(previous, current []DocumentBlock) DocumentChanges {
+	var changes DocumentChanges
+	before := map[string]*documentSection{}
+	for _, section := range documentSections(previous) {
+		before[section.title] = section
+	}
+	after := map[string]bool{}
+	for _, section := range documentSections(current) {
+		after[section.title] = true
+		old, ok := before[section.title]
+		if !ok {
+			changes.Added = append(changes.Added, section.title)
+			continue
+		}
+		if added, removed := diffLines(old.lines, section.lines); added > 0 || removed > 0 {
+			name := section.title
+			if name == "" {
+				name = "(document start)"
+			}
+			changes.Modified = append(changes.Modified, SectionChange{Section: name, LinesAdded: added, LinesRemoved: removed})
+		}
+		for _, parameter := range section.order {
+			oldValues, ok := old.specs[parameter]
+			if !ok {
+				continue
+			}
+			for _, value := range section.specs[parameter] {
+				for _, oldValue := range oldValues {
+					if oldValue.column == value.column && oldValue.value != value.value {
+						changes.SpecValues = append(changes.SpecValues, SpecValueChange{Section: section.title, Parameter: parameter, Column: value.column, Previous: oldValue.value, Current: value.value})
+					}
+				}
+			}
+		}
+	}
+	for _, section := range documentSections(previous) {
+		if !after[section.title] {
+			changes.Removed = append(changes.Removed, section.title)
+		}
+	}
+	return changes
+}
+
+// diffLines counts the lines only in current and only in previous, ignoring their order.
+func diffLines(previous, current []string) (added, removed int) {
+	counts := map[string]int{}
+	for _, line := range previous {
+		counts[strings.TrimSpace(line)]++
+	}
+	for _, line := range current {
+		line = strings.TrimSpace(line)
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added++
+		}
+	}
+	for _, n := range counts {
+		removed += n
+	}
+	return added, removed
+}
+
+// renderChanges formats the changes as CHANGES.md.
+func renderChanges(source string, changes DocumentChanges) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Changes\n\nCompared with the previous conversion of `%s`.\n\n", source)
+	if changes.Count() == 0 {
+		md.WriteString("No changes.\n")
+		return md.String()
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&md, "## %s\n\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&md, "- %s\n", item)
+		}
+		md.WriteString("\n")
+	}
+	list("Added Sections", changes.Added)
+	list("Removed Sections", changes.Removed)
+	var modified []string
+	for _, m := range changes.Modified {
+		modified = append(modified, fmt.Sprintf("%s: %d line(s) added, %d removed", m.Section, m.LinesAdded, m.LinesRemoved))
+	}
+	list("Modified Sections", modified)
+	if len(changes.SpecValues) > 0 {
+		md.WriteString("## Changed Specification Values\n\n| Section | Parameter | Column | Previous | New |\n| --- | --- | --- | --- | --- |\n")
+		cell := strings.NewReplacer("|", `\|`).Replace
+		for _, v := range changes.SpecValues {
+			fmt.Fprintf(&md, "| %s | %s | %s | %s | %s |\n", cell(v.Section), cell(v.Parameter), cell(v.Column), cell(v.Previous), cell(v.Current))
+		}
+	}
+	return strings.TrimRight(md.String(), "\n") + "\n"
+}
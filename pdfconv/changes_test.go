@@ -0,0 +1,118 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+const previousRevision = `# PDF Document
+
+## Page 1
+
+### 1 Features
+
+Low power operation.
+
+### 6 Electrical Characteristics
+
+| Parameter | Symbol | Min | Typ | Max | Unit |
+| --- | --- | --- | --- | --- | --- |
+| Supply voltage | VDD | 1.8 | **3.3** | 3.6 | V |
+| Supply current | IDD | | 1.2 | 2.0 | mA |
+
+### 7 Obsolete Mode
+
+Removed in this revision.
+`
+
+const currentRevision = `# PDF Document
+
+## Page 1
+
+### 1 Features
+
+Low power operation.
+
+## Page 2
+
+### 6 Electrical Characteristics
+
+| Parameter | Symbol | Min | Typ | Max | Unit |
+| --- | --- | --- | --- | --- | --- |
+| Supply voltage | VDD | 1.7 | **3.3** | 3.6 | V |
+| Supply current | IDD | | 1.2 | 2.0 | mA |
+
+### 8 Power-Down Mode
+
+Added in this revision.
+`
+
+func TestCompareDocuments(t *testing.T) {
+	_, previous := parseMarkdownBlocks(previousRevision)
+	_, current := parseMarkdownBlocks(currentRevision)
+	changes := compareDocuments(previous, current)
+
+	if len(changes.Added) != 1 || changes.Added[0] != "8 Power-Down Mode" {
+		t.Errorf("unexpected added sections: %v", changes.Added)
+	}
+	if len(changes.Removed) != 1 || changes.Removed[0] != "7 Obsolete Mode" {
+		t.Errorf("unexpected removed sections: %v", changes.Removed)
+	}
+	// The features moved to another page but did not change
+	if len(changes.Modified) != 1 || changes.Modified[0] != (SectionChange{Section: "6 Electrical Characteristics", LinesAdded: 1, LinesRemoved: 1}) {
+		t.Errorf("unexpected modified sections: %+v", changes.Modified)
+	}
+	want := SpecValueChange{Section: "6 Electrical Characteristics", Parameter: "Supply voltage, VDD", Column: "Min", Previous: "1.8", Current: "1.7"}
+	if len(changes.SpecValues) != 1 || changes.SpecValues[0] != want {
+		t.Errorf("unexpected spec value changes: %+v", changes.SpecValues)
+	}
+
+	md := renderChanges("ds.pdf", changes)
+	for _, s := range []string{"## Added Sections\n\n- 8 Power-Down Mode", "## Removed Sections\n\n- 7 Obsolete Mode", "- 6 Electrical Characteristics: 1 line(s) added, 1 removed", "| 6 Electrical Characteristics | Supply voltage, VDD | Min | 1.8 | 1.7 |"} {
+		if !strings.Contains(md, s) {
+			t.Errorf("expected %q in CHANGES.md:\n%s", s, md)
+		}
+	}
+	if md := renderChanges("ds.pdf", compareDocuments(current, current)); !strings.HasSuffix(md, "No changes.\n") {
+		t.Errorf("expected no changes, got:\n%s", md)
+	}
+}
+
+func TestConvertPDF_ChangeReport(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "manual.pdf")
+	outputBase := t.TempDir()
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, ChangeReport: true}, logger.NewLogger("error"))
+
+	writeRevision(t, pdfPath, "Supply voltage 3.3 V")
+	first, err := conv.ConvertPDF(pdfPath, outputBase)
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if first.Changes != nil {
+		t.Errorf("expected no change report without a previous output, got %+v", first.Changes)
+	}
+	if _, err := os.Stat(filepath.Join(first.OutputDir, ChangesFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected no %s on the first conversion", ChangesFileName)
+	}
+
+	writeRevision(t, pdfPath, "Supply voltage 5.0 V")
+	second, err := conv.ConvertPDF(pdfPath, outputBase)
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if second.Changes == nil || second.Changes.Count() == 0 {
+		t.Fatalf("expected changes, got %+v", second.Changes)
+	}
+	data, err := os.ReadFile(filepath.Join(second.OutputDir, ChangesFileName))
+	if err != nil {
+		t.Fatalf("expected %s: %v", ChangesFileName, err)
+	}
+	if !strings.Contains(string(data), "Compared with the previous conversion of `manual.pdf`") {
+		t.Errorf("unexpected %s:\n%s", ChangesFileName, data)
+	}
+}
@@ -0,0 +1,36 @@
+// Package pdfconv - Input checksum verification.
+// This file checks an input document against the SHA-256 expected by the caller before it is
+// converted, so pipelines that sync datasheets from elsewhere fail clearly on a stale or
+// corrupted copy instead of converting it.
+package pdfconv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// VerifyChecksum returns an error unless the SHA-256 of the file at path equals expected, a
+// hex digest optionally prefixed with "sha256:".
+func VerifyChecksum(path, expected string) error {
+	want := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(expected), "sha256:"))
+	if _, err := hex.DecodeString(want); err != nil || len(want) != sha256.Size*2 {
+		return fmt.Errorf("invalid SHA-256 checksum '%s': must be %d hex digits", expected, sha256.Size*2)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for checksum verification: %v", path, err)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read %s for checksum verification: %v", path, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected SHA-256 %s, got %s", path, want, got)
+	}
+	return nil
+}
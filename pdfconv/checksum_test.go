@@ -0,0 +1,28 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ds.pdf")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	const sum = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+	for _, expected := range []string{sum, strings.ToUpper(sum), "sha256:" + sum} {
+		if err := VerifyChecksum(path, expected); err != nil {
+			t.Errorf("VerifyChecksum(%q) error = %v", expected, err)
+		}
+	}
+	if err := VerifyChecksum(path, strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "checksum mismatch") || !strings.Contains(err.Error(), sum) {
+		t.Errorf("expected a mismatch error naming the actual checksum, got %v", err)
+	}
+	if err := VerifyChecksum(path, "abc"); err == nil || !strings.Contains(err.Error(), "invalid SHA-256") {
+		t.Errorf("expected an invalid checksum error, got %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+// Package pdfconv - Compliance and qualification tagging.
+// This file finds the environmental compliance, certification and qualification statements
+// of a datasheet, such as RoHS, REACH, AEC-Q100 or UL, tags the document with them in YAML
+// front matter and summarizes them in compliance.json, so downstream tools can filter parts
+// by qualification level without reading the text.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ComplianceFileName is the name of the compliance summary written next to the Markdown.
+const ComplianceFileName = "compliance.json"
+
+// Compliance tags
+const (
+	ComplianceRoHS        = "rohs"
+	ComplianceREACH       = "reach"
+	ComplianceHalogenFree = "halogen-free"
+	CompliancePbFree      = "pb-free"
+	ComplianceAECQ100     = "aec-q100" // Integrated circuits
+	ComplianceAECQ101     = "aec-q101" // Discrete semiconductors
+	ComplianceAECQ200     = "aec-q200" // Passive components
+	ComplianceUL          = "ul"
+	ComplianceISO26262    = "iso-26262" // Functional safety
+	ComplianceMilitary    = "mil-prf-38535"
+)
+
+// complianceExcerptLength is the longest excerpt, in characters, kept of the statement that
+// first mentions a standard.
+const complianceExcerptLength = 200
+
+// ComplianceStandard is a compliance, certification or qualification standard the document
+// states the part meets.
+type ComplianceStandard struct {
+	Tag         string   `json:"tag"`                   // One of the Compliance* constants
+	Name        string   `json:"name"`                  // Display name, e.g. "AEC-Q100"
+	Grade       string   `json:"grade,omitempty"`       // AEC-Q100 or AEC-Q200 temperature grade, or ISO 26262 ASIL
+	Temperature string   `json:"temperature,omitempty"` // Ambient temperature range of the AEC-Q100 grade
+	References  []string `json:"references,omitempty"`  // Standard and file numbers, e.g. "UL 1577" or "E181974"
+	Pages       []int    `json:"pages"`                 // Pages mentioning the standard
+	Sections    []string `json:"sections,omitempty"`    // Headings of the sections mentioning it
+	Excerpt     string   `json:"excerpt"`               // First statement mentioning it
+}
+
+// ComplianceSection is a section of the document titled as a compliance, certification or
+// qualification section.
+type ComplianceSection struct {
+	Title string   `json:"title"`
+	Page  int      `json:"page,omitempty"`
+	Tags  []string `json:"tags"` // Standards mentioned in the section
+}
+
+// ComplianceSummary lists the standards a document states the part meets.
+type ComplianceSummary struct {
+	Qualification string // GradeAutomotive or GradeMilitary when qualified to such standards, "" otherwise
+	Tags          []string
+	Standards     []ComplianceStandard
+	Sections      []ComplianceSection
+}
+
+// complianceFile is the content of compliance.json.
+type complianceFile struct {
+	Source        string               `json:"source"`
+	Qualification string               `json:"qualification,omitempty"`
+	Tags          []string             `json:"tags"`
+	Standards     []ComplianceStandard `json:"standards"`
+	Sections      []ComplianceSection  `json:"sections"`
+}
+
+// complianceRules recognize the standards, in the order their tags are listed.
+var complianceRules = []struct {
+	tag, name string
+	pattern   *regexp.Regexp
+}{
+	{ComplianceRoHS, "RoHS", regexp.MustCompile(`\bRoHS\b|2011/65/EU|2015/863|2002/95/EC`)},
+	{ComplianceREACH, "REACH", regexp.MustCompile(`\bREACH\b|\bSVHC\b|1907/2006`)},
+	{ComplianceHalogenFree, "Halogen-free", regexp.MustCompile(`(?i)\bhalogen[-\s]free\b|IEC\s?61249-2-21`)},
+	{CompliancePbFree, "Pb-free", regexp.MustCompile(`(?i)\b(?:pb|lead)[-\s]free\b`)},
+	{ComplianceAECQ100, "AEC-Q100", regexp.MustCompile(`\bAEC[-\s]?Q100\b`)},
+	{ComplianceAECQ101, "AEC-Q101", regexp.MustCompile(`\bAEC[-\s]?Q101\b`)},
+	{ComplianceAECQ200, "AEC-Q200", regexp.MustCompile(`\bAEC[-\s]?Q200\b`)},
+	{ComplianceUL, "UL", regexp.MustCompile(`\bUL\s?\d{2,5}(?:-\d+)?|\bc?UL(?:us)?[-\s](?:recogni[sz]ed|listed|certified|approv(?:ed|al))|\bUL\s+file\b`)},
+	{ComplianceISO26262, "ISO 26262", regexp.MustCompile(`\bISO\s?26262\b|\bASIL[-\s]?[A-D]\b`)},
+	{ComplianceMilitary, "MIL-PRF-38535", regexp.MustCompile(`\bMIL-PRF-38535\b|\bMIL-STD-883\b|\bQML(?:[-\s]?[QVY])?\b`)},
+}
+
+var (
+	// complianceSectionPattern matches headings of compliance and qualification sections.
+	complianceSectionPattern = regexp.MustCompile(`(?i)\b(compliance|certifications?|approvals?|environmental|regulatory|qualifications?|material\s+(content|declaration|composition)|green\s+(status|package))\b`)
+	// complianceNegationPattern matches a negation right before a standard, as in
+	// "not RoHS compliant" or "non-AEC-Q100".
+	complianceNegationPattern = regexp.MustCompile(`(?i)\b(not|non)[\s-]*$`)
+	// aecGradePattern matches an AEC temperature grade, as in "Grade 1" or "Temperature Grade 0".
+	aecGradePattern = regexp.MustCompile(`(?i)\bgrade\s?([0-5])\b`)
+	// asilPattern matches an ISO 26262 safety integrity level, as in "ASIL-D" or "ASIL B".
+	asilPattern = regexp.MustCompile(`\bASIL[-\s]?([A-D])\b`)
+	// ulReferencePattern matches UL standard numbers, as in "UL 1577" or "UL94".
+	ulReferencePattern = regexp.MustCompile(`\bUL\s?(\d{2,5}(?:-\d+)?)`)
+	// ulFilePattern matches UL file numbers, as in "E181974".
+	ulFilePattern = regexp.MustCompile(`\bE\d{5,6}\b`)
+)
+
+// aecQ100Temperatures are the ambient temperature ranges of the AEC-Q100 grades.
+var aecQ100Temperatures = map[string]string{
+	"0": "-40 °C to 150 °C",
+	"1": "-40 °C to 125 °C",
+	"2": "-40 °C to 105 °C",
+	"3": "-40 °C to 85 °C",
+	"4": "0 °C to 70 °C",
+}
+
+// collectCompliance finds the standards the Markdown of a document mentions, with the pages
+// and sections mentioning them, and the sections titled as compliance sections. Negated
+// mentions, such as "not RoHS compliant", and code blocks are skipped.
+func (c *PDFConverter) collectCompliance(markdown string) *ComplianceSummary {
+	if !c.config.ComplianceTags {
+		return nil
+	}
+	summary := &ComplianceSummary{}
+	found := map[string]*ComplianceStandard{}
+	var fences fenceTracker
+	page, section := 0, ""
+	current := -1 // Index of the compliance section being read, -1 outside one
+	for _, line := range strings.Split(markdown, "\n") {
+		if fences.inCode(line) {
+			continue
+		}
+		text := strings.TrimSpace(inlineAnchorPattern.ReplaceAllString(line, ""))
+		if m := markdownHeadingPattern.FindStringSubmatch(text); m != nil {
+			title := strings.TrimSpace(m[2])
+			if p := pageHeadingPattern.FindStringSubmatch(title); p != nil {
+				page, _ = strconv.Atoi(p[1])
+				continue
+			}
+			section, current = title, -1
+			if complianceSectionPattern.MatchString(title) {
+				current = len(summary.Sections)
+				summary.Sections = append(summary.Sections, ComplianceSection{Title: title, Page: page, Tags: []string{}})
+			}
+		}
+		for _, rule := range complianceRules {
+			if !mentions(rule.pattern, text) {
+				continue
+			}
+			standard, ok := found[rule.tag]
+			if !ok {
+				standard = &ComplianceStandard{Tag: rule.tag, Name: rule.name, Excerpt: complianceExcerpt(text)}
+				found[rule.tag] = standard
+			}
+			standard.note(text, page, section)
+			if current >= 0 && !slices.Contains(summary.Sections[current].Tags, rule.tag) {
+				summary.Sections[current].Tags = append(summary.Sections[current].Tags, rule.tag)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	for _, rule := range complianceRules {
+		if standard, ok := found[rule.tag]; ok {
+			summary.Tags = append(summary.Tags, rule.tag)
+			summary.Standards = append(summary.Standards, *standard)
+		}
+	}
+	switch {
+	case found[ComplianceMilitary] != nil:
+		summary.Qualification = GradeMilitary
+	case found[ComplianceAECQ100] != nil || found[ComplianceAECQ101] != nil || found[ComplianceAECQ200] != nil:
+		summary.Qualification = GradeAutomotive
+	}
+	c.logger.Info("Found compliance statements: %s", strings.Join(summary.Tags, ", "))
+	return summary
+}
+
+// mentions reports whether text mentions a standard without negating it.
+func mentions(pattern *regexp.Regexp, text string) bool {
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		if !complianceNegationPattern.MatchString(text[:loc[0]]) {
+			return true
+		}
+	}
+	return false
+}
+
+// note records a mention of the standard: its page and section, and the grade and
+// reference numbers given with it.
+func (s *ComplianceStandard) note(text string, page int, section string) {
+	if page > 0 && !slices.Contains(s.Pages, page) {
+		s.Pages = append(s.Pages, page)
+	}
+	if section != "" && !slices.Contains(s.Sections, section) {
+		s.Sections = append(s.Sections, section)
+	}
+	switch s.Tag {
+	case ComplianceAECQ100, ComplianceAECQ200:
+		if m := aecGradePattern.FindStringSubmatch(text); m != nil && s.Grade == "" {
+			s.Grade = m[1]
+			if s.Tag == ComplianceAECQ100 {
+				s.Temperature = aecQ100Temperatures[m[1]]
+			}
+		}
+	case ComplianceISO26262:
+		if m := asilPattern.FindStringSubmatch(text); m != nil && s.Grade == "" {
+			s.Grade = "ASIL " + m[1]
+		}
+	case ComplianceUL:
+		for _, m := range ulReferencePattern.FindAllStringSubmatch(text, -1) {
+			if reference := "UL " + m[1]; !slices.Contains(s.References, reference) {
+				s.References = append(s.References, reference)
+			}
+		}
+		for _, reference := range ulFilePattern.FindAllString(text, -1) {
+			if !slices.Contains(s.References, reference) {
+				s.References = append(s.References, reference)
+			}
+		}
+	}
+}
+
+// complianceExcerpt returns a statement without Markdown list, quote and emphasis markup,
+// shortened to complianceExcerptLength characters.
+func complianceExcerpt(text string) string {
+	text = strings.TrimLeft(text, "#>-*|+ ")
+	text = strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+	if utf8.RuneCountInString(text) > complianceExcerptLength {
+		text = string([]rune(text)[:complianceExcerptLength-1]) + "…"
+	}
+	return strings.TrimSpace(text)
+}
+
+// complianceFrontMatter tags the Markdown with the compliance tags and qualification level in
+// YAML front matter, added to the front matter declaring the language, if any.
+func complianceFrontMatter(markdown string, summary *ComplianceSummary) string {
+	if summary == nil {
+		return markdown
+	}
+	meta := "compliance: [" + strings.Join(summary.Tags, ", ") + "]\n"
+	if summary.Qualification != "" {
+		meta += "qualification: " + summary.Qualification + "\n"
+	}
+	if rest, ok := strings.CutPrefix(markdown, "---\n"); ok {
+		return "---\n" + meta + rest
+	}
+	return "---\n" + meta + "---\n\n" + markdown
+}
+
+// writeComplianceFile writes compliance.json for a document into dir.
+func writeComplianceFile(dir, docPath string, summary *ComplianceSummary) error {
+	data, err := json.MarshalIndent(complianceFile{Source: docPath, Qualification: summary.Qualification, Tags: summary.Tags, Standards: summary.Standards, Sections: summary.Sections}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode compliance summary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ComplianceFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write compliance summary: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+const complianceMarkdown = `# PDF Document
+
+## Page 1
+
+### Features
+
+- AEC-Q100 qualified for automotive applications: Temperature Grade 1
+- Functional safety capable, documentation to aid ISO 26262 system design up to ASIL-D
+
+## Page 9
+
+### Certifications
+
+UL 1577 recognized, file E181974
+
+### Environmental Compliance
+
+**RoHS** compliant and REACH SVHC free. This device is not halogen-free.
+
+` + "```" + `
+Pb-free example in a code block
+` + "```" + `
+`
+
+func TestCollectCompliance(t *testing.T) {
+	cfg := &config.Config{ComplianceTags: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	summary := conv.collectCompliance(complianceMarkdown)
+	if summary == nil {
+		t.Fatal("expected compliance statements")
+	}
+	if want := []string{ComplianceRoHS, ComplianceREACH, ComplianceAECQ100, ComplianceUL, ComplianceISO26262}; !reflect.DeepEqual(summary.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, summary.Tags)
+	}
+	if summary.Qualification != GradeAutomotive {
+		t.Errorf("expected automotive qualification, got %q", summary.Qualification)
+	}
+	standards := map[string]ComplianceStandard{}
+	for _, standard := range summary.Standards {
+		standards[standard.Tag] = standard
+	}
+	if aec := standards[ComplianceAECQ100]; aec.Grade != "1" || aec.Temperature != "-40 °C to 125 °C" || !reflect.DeepEqual(aec.Pages, []int{1}) || !reflect.DeepEqual(aec.Sections, []string{"Features"}) {
+		t.Errorf("unexpected AEC-Q100 statement: %+v", aec)
+	}
+	if iso := standards[ComplianceISO26262]; iso.Grade != "ASIL D" {
+		t.Errorf("expected ASIL D, got %+v", iso)
+	}
+	if ul := standards[ComplianceUL]; !reflect.DeepEqual(ul.References, []string{"UL 1577", "E181974"}) || !reflect.DeepEqual(ul.Pages, []int{9}) {
+		t.Errorf("unexpected UL statement: %+v", ul)
+	}
+	if rohs := standards[ComplianceRoHS]; rohs.Excerpt != "RoHS compliant and REACH SVHC free. This device is not halogen-free." {
+		t.Errorf("unexpected RoHS excerpt: %q", rohs.Excerpt)
+	}
+	wantSections := []ComplianceSection{
+		{Title: "Certifications", Page: 9, Tags: []string{ComplianceUL}},
+		{Title: "Environmental Compliance", Page: 9, Tags: []string{ComplianceRoHS, ComplianceREACH}},
+	}
+	if !reflect.DeepEqual(summary.Sections, wantSections) {
+		t.Errorf("expected sections %+v, got %+v", wantSections, summary.Sections)
+	}
+
+	front := complianceFrontMatter("---\nlang: en\n---\n\n# PDF Document\n", summary)
+	if want := "---\ncompliance: [rohs, reach, aec-q100, ul, iso-26262]\nqualification: automotive\nlang: en\n---\n\n# PDF Document\n"; front != want {
+		t.Errorf("unexpected front matter:\n%s", front)
+	}
+	if language, _ := parseMarkdownBlocks(front); language != "en" {
+		t.Errorf("expected the language kept in front matter, got %q", language)
+	}
+
+	if got := conv.collectCompliance("# PDF Document\n\nGeneral purpose amplifier.\n"); got != nil {
+		t.Errorf("expected no compliance statements, got %+v", got)
+	}
+	cfg.ComplianceTags = false
+	if got := conv.collectCompliance(complianceMarkdown); got != nil {
+		t.Errorf("expected no compliance summary with COMPLIANCE_TAGS off, got %+v", got)
+	}
+}
+
+func TestConvertPDF_ComplianceTags(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "sensor.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Helvetica", "", 10)
+	doc.AddPage()
+	doc.SetXY(20, 20)
+	for _, text := range []string{"Temperature sensor", "RoHS compliant, AEC-Q100 Grade 0 qualified"} {
+		doc.SetX(20)
+		doc.CellFormat(170, 6, text, "", 1, "L", false, 0, "")
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create sensor pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ComplianceTags: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	markdown, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.HasPrefix(string(markdown), "---\ncompliance: [rohs, aec-q100]\nqualification: automotive\n---\n") {
+		t.Errorf("expected compliance front matter, got:\n%s", markdown)
+	}
+	data, err := os.ReadFile(filepath.Join(res.OutputDir, ComplianceFileName))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", ComplianceFileName, err)
+	}
+	var file complianceFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid %s: %v", ComplianceFileName, err)
+	}
+	if file.Source != pdfPath || len(file.Standards) != 2 || file.Standards[1].Grade != "0" || file.Standards[1].Temperature != "-40 °C to 150 °C" {
+		t.Errorf("unexpected %s content: %s", ComplianceFileName, data)
+	}
+	if violations, err := ValidateSidecar(ComplianceFileName, data); err != nil || len(violations) != 0 {
+		t.Errorf("expected %s to match its schema, got %v %v", ComplianceFileName, err, violations)
+	}
+}
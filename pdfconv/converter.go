@@ -5,6 +5,9 @@ package pdfconv
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"image"
 	"image/color"
@@ -15,6 +18,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/ledongthuc/pdf"
@@ -36,12 +40,23 @@ const (
 	ShortHeaderLength   = 40      // Length threshold for short headers
 )
 
+// Extracted image file names: <prefix>_<hash>.png
+const (
+	ImageFilePrefix  = "image" // Prefix of embedded images and page images
+	TableImagePrefix = "table" // Prefix of rendered fallback table images
+	ImageHashLength  = 16      // Hex digits of the content hash kept in the name
+)
+
 // PDFConverter handles the conversion of PDF files to Markdown format with image extraction.
 // It manages the PDF document parsing, text extraction, image processing, and Markdown generation.
 type PDFConverter struct {
 	config          *config.Config       // Server configuration containing conversion settings
 	logger          *logger.Logger       // Logger instance for tracking conversion progress and errors
 	diagramDetector *uml.DiagramDetector // Diagram detector for converting diagrams to PlantUML
+	headers         *headerMatcher       // Keyword and pattern matcher used for header detection
+	capabilities    []Capability         // Optional tools found at startup, nil until probed
+	pageIndex       *firstPageIndex      // First page text of documents searched by FindDocuments
+	stripPatterns   []*regexp.Regexp     // Lines removed from the text, set by a document settings file
 }
 
 // Config returns the underlying config for convenience
@@ -49,32 +64,76 @@ func (c *PDFConverter) Config() *config.Config { return c.config }
 
 // ConversionResult contains the details of a completed PDF to Markdown conversion.
 type ConversionResult struct {
+	Source       string        // Path of the converted document
+	Status       string        // StatusComplete, or StatusPartial when some pages failed
+	FailedPages  []PageFailure // Pages whose content could not be extracted
 	OutputDir    string
 	MarkdownFile string
 	ImageCount   int
 	PageCount    int
+	TableCount   int // Tables, not counting continuations merged into the table they continue
+	DiagramCount int // Diagrams detected in the extracted images
+	Quality      QualityReport
+	Repaired     bool                // Whether the PDF was malformed and its cross-reference table was rebuilt
+	BrokenLinks  []BrokenLink        // Links and images in the Markdown whose targets do not resolve
+	Variants     []PartVariant       // Part variants from ordering information tables, written to variants.json
+	Packages     []PackageDimensions // Package dimensions from mechanical dimension tables, written to package.json
+	Graphs       []CurveGraph        // Digitized characteristic curve graphs, written to curves.json
+	BOM          []BOMItem           // Components of typical application circuits, written to bom.csv
+	Compliance   *ComplianceSummary  // Compliance and qualification standards, written to compliance.json; nil when none
+	Pages        []PageStats         // Content counts of each converted page
+	Images       []ImageFile         // Image files written next to the document, in page order
+	Languages    map[string]int      // Weighted letter count of each language found in the text
+	ReusedPages  int                 // Unchanged pages reused from the previous output by incremental conversion
+	Changes      *DocumentChanges    // Differences from the previous output, written to CHANGES.md; nil when not compared
+	Thumbnail    string              // Path of the first page thumbnail, "" when none was written
+	Duration     time.Duration       // Conversion time from opening the document to writing the report
+	Timings      PhaseTimings        // Time spent in each conversion phase
 }
 
 // PDFPage represents the content of a single page from the PDF document.
 type PDFPage struct {
-	Number int
-	Text   string
-	Images []PDFImage
+	Number         int
+	Text           string
+	Lines          []TextLine // Positioned text lines, populated for inline image placement and table extraction
+	Tables         []PDFTable // Tables reconstructed from Lines when table extraction is enabled
+	Images         []PDFImage
+	Verbatim       bool           // Emit text with original line breaks and spacing in a fenced block
+	OCR            bool           // Whether Text was recognized from a page image
+	OCRConfidence  float64        // Mean OCR word confidence between 0.0 and 1.0
+	TextConfidence float64        // Plausibility of Text between 0.0 and 1.0, 0 when not checked
+	Unreliable     bool           // Whether Text looks garbled and no better OCR text was available
+	ImageFailures  int            // Images on the page that could not be extracted or saved
+	Redactions     []Redaction    // Content the source document intentionally hides on this page
+	DuplicateText  *DuplicateText // Second text layer removed from the page, nil when none
+	Segments       []TextSegment  // Text split by language in multilingual documents, nil otherwise
+	Reused         bool           // Whether the page was unchanged and reused from the previous output
+	Failure        string         // Why the page content could not be extracted, "" when it was
+	StyledSpans    []StyledSpan   // Text set in monospace, bold or italic fonts, in content stream order
+	Callouts       []Callout      // Warning, caution and note boxes, top to bottom
+	Graphs         []CurveGraph   // Characteristic curve graphs digitized with CURVE_DATA
 }
 
 // PDFImage represents an image extracted from a PDF page.
 type PDFImage struct {
-	Data     image.Image
-	Width    int
-	Height   int
-	Filename string
-	Diagrams []uml.DetectedDiagram
+	Data        image.Image
+	Width       int
+	Height      int
+	Filename    string
+	Diagrams    []uml.DetectedDiagram
+	ObjectName  string  // XObject resource name the image was drawn with
+	PositionY   float64 // Top edge of the image on the page in points (bottom-up)
+	HasPosition bool    // Whether PositionY was found in the page content stream
+	Placeholder bool    // Whether the image data could not be decoded and a placeholder was saved
+	PageScan    bool    // Whether the image is the whole page, scanned or rendered
+	AltText     string  // Markdown alt text; "Image" is used when empty
 }
 
 // BatchConversionResult contains the results of processing multiple PDF files from a directory.
 type BatchConversionResult struct {
 	InputDir        string
 	OutputBaseDir   string
+	Portfolio       bool // InputDir is a PDF portfolio whose embedded documents were converted
 	Results         []ConversionResult
 	SuccessCount    int
 	FailureCount    int
@@ -82,43 +141,69 @@ type BatchConversionResult struct {
 	FileCount       int
 	TotalPageCount  int
 	TotalImageCount int
+	ErrataLinks     []ErrataLink // Errata documents linked to their datasheets with ERRATA_LINKS
+	SummaryFile     string       // Path of BATCH_SUMMARY.md, "" when none was written
+	ResultsFile     string       // Path of results.json, "" when none was written
 }
 
 // ConversionError represents an error that occurred while processing a specific PDF file.
 type ConversionError struct {
-	PDFPath string
-	Error   string
+	PDFPath string `json:"source"`
+	Error   string `json:"error"`
+	Code    string `json:"code,omitempty"` // Failure class from ErrorCode, "" when unclassified
 }
 
 // NewPDFConverter creates a new PDFConverter instance with the provided configuration and logger.
 func NewPDFConverter(cfg *config.Config, log *logger.Logger) (*PDFConverter, error) {
 	diagramDetector := uml.NewDiagramDetector(cfg, log)
-	return &PDFConverter{config: cfg, logger: log, diagramDetector: diagramDetector}, nil
+	headers, err := newHeaderMatcher(cfg.HeaderKeywordLocales, cfg.HeaderKeywords, cfg.HeaderRegexes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure header detection: %v", err)
+	}
+	return &PDFConverter{config: cfg, logger: log, diagramDetector: diagramDetector, headers: headers, pageIndex: &firstPageIndex{}}, nil
 }
 
-// ConvertPDF processes a PDF file and converts it to Markdown format with extracted images.
-func (c *PDFConverter) ConvertPDF(pdfPath, outputBaseDir string) (*ConversionResult, error) {
-	c.logger.Info("Starting PDF conversion: %s", pdfPath)
-
-	// Validate input parameters
-	if strings.TrimSpace(pdfPath) == "" {
-		return nil, fmt.Errorf("PDF path cannot be empty")
+// WithPreset returns a converter using the configuration with the named conversion preset
+// applied on top, for a single tool call. The converter itself is returned when name is "".
+// The optional tools found by DetectCapabilities and the lookup cache are shared.
+func (c *PDFConverter) WithPreset(name string) (*PDFConverter, error) {
+	if name == "" {
+		return c, nil
 	}
-	if strings.TrimSpace(outputBaseDir) == "" {
-		return nil, fmt.Errorf("output base directory cannot be empty")
+	cfg := *c.config
+	if err := cfg.ApplyPreset(strings.ToLower(name), nil); err != nil {
+		return nil, err
 	}
+	cfg.Preset = strings.ToLower(name)
+	return c.withConfig(&cfg), nil
+}
 
-	// Clean and validate paths
-	pdfPath = filepath.Clean(pdfPath)
-	outputBaseDir = filepath.Clean(outputBaseDir)
-
-	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("PDF file does not exist: %s", pdfPath)
+// withConfig returns a converter using cfg, sharing the state of c that does not depend on it.
+func (c *PDFConverter) withConfig(cfg *config.Config) *PDFConverter {
+	return &PDFConverter{
+		config:          cfg,
+		logger:          c.logger,
+		diagramDetector: uml.NewDiagramDetector(cfg, c.logger),
+		headers:         c.headers,
+		capabilities:    c.capabilities,
+		pageIndex:       c.pageIndex,
+		stripPatterns:   c.stripPatterns,
 	}
+}
+
+// ConvertPDF processes a PDF file and converts it to Markdown format with extracted images.
+func (c *PDFConverter) ConvertPDF(pdfPath, outputBaseDir string) (*ConversionResult, error) {
+	return c.ConvertPDFWithOptions(pdfPath, outputBaseDir, ConversionOptions{})
+}
 
-	// Check if it's actually a file, not a directory
-	if fileInfo, err := os.Stat(pdfPath); err == nil && fileInfo.IsDir() {
-		return nil, fmt.Errorf("path is a directory, not a file: %s", pdfPath)
+// ConvertPDFWithOptions behaves like ConvertPDF but applies per-call conversion options.
+func (c *PDFConverter) ConvertPDFWithOptions(pdfPath, outputBaseDir string, opts ConversionOptions) (*ConversionResult, error) {
+	c.logger.Info("Starting PDF conversion: %s", pdfPath)
+	opts.started = time.Now()
+
+	pdfPath, outputBaseDir, err := cleanInputPaths(pdfPath, outputBaseDir)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate file extension
@@ -126,41 +211,32 @@ func (c *PDFConverter) ConvertPDF(pdfPath, outputBaseDir string) (*ConversionRes
 		return nil, fmt.Errorf("file does not have a .pdf extension: %s", pdfPath)
 	}
 
-	file, reader, err := pdf.Open(pdfPath)
+	reader, closeFile, repaired, err := c.openPDF(pdfPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open PDF: %v", err)
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
 	}
-	defer file.Close()
+	defer closeFile()
+	opts.repaired = repaired
+	opts.language = pdfLanguage(reader)
+	opts.render = func(pageNum, dpi int) (image.Image, error) { return c.renderPage(pdfPath, pageNum, dpi) }
 
 	c.logger.Info("PDF opened successfully, %d pages found", reader.NumPage())
 
-	outputDir, err := c.createOutputDirectory(pdfPath, outputBaseDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	if err := c.preflightDiskSpace(reader, pdfPath, outputBaseDir); err != nil {
+		return nil, err
 	}
 
-	pages, totalImages, err := c.extractPagesContent(reader, outputDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract PDF content: %v", err)
+	var previous *pageCache
+	if c.config.Incremental {
+		previous = c.loadPageCache(filepath.Join(outputBaseDir, outputDirectoryName(pdfPath)))
 	}
-
-	markdownContent := c.generateMarkdown(pages)
-
-	markdownPath := filepath.Join(outputDir, "README.md")
-	if err := c.writeMarkdownFile(markdownPath, markdownContent); err != nil {
-		return nil, fmt.Errorf("failed to write Markdown file: %v", err)
-	}
-
-	c.logger.Info("PDF conversion completed successfully")
-
-	return &ConversionResult{OutputDir: outputDir, MarkdownFile: markdownPath, ImageCount: totalImages, PageCount: len(pages)}, nil
+	return c.generateOutput(pdfPath, outputBaseDir, opts, func(stagingDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+		return c.extractPages(opts.context(), reader, pdfPath, stagingDir, 1, reader.NumPage(), opts.Pages, previous, timings)
+	})
 }
 
 func (c *PDFConverter) createOutputDirectory(pdfPath, outputBaseDir string) (string, error) {
-	baseName := filepath.Base(pdfPath)
-	nameWithoutExt := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-	dirName := fmt.Sprintf("MARKDOWN_%s", nameWithoutExt)
-	outputDir := filepath.Join(outputBaseDir, dirName)
+	outputDir := filepath.Join(outputBaseDir, outputDirectoryName(pdfPath))
 	c.logger.Debug("Creating output directory: %s", outputDir)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create directory %s: %v", outputDir, err)
@@ -168,40 +244,146 @@ func (c *PDFConverter) createOutputDirectory(pdfPath, outputBaseDir string) (str
 	return outputDir, nil
 }
 
-func (c *PDFConverter) extractPagesContent(reader *pdf.Reader, outputDir string) ([]PDFPage, int, error) {
+// extractPageRange extracts text and images for the inclusive 1-based page range [first, last].
+// The PDF path is used to render page regions that cannot be reconstructed from the PDF objects.
+// Text and image extraction times are added to timings, which may be nil.
+func (c *PDFConverter) extractPageRange(ctx context.Context, reader *pdf.Reader, pdfPath, outputDir string, first, last int, timings *PhaseTimings) ([]PDFPage, int, error) {
+	return c.extractPages(ctx, reader, pdfPath, outputDir, first, last, nil, nil, timings)
+}
+
+// extractPages behaves like extractPageRange, skipping the pages outside selection unless it
+// is nil. When previous is not nil, pages that are unchanged since the previous output are
+// reused from its page cache instead of being extracted, and the page cache of the new output
+// is written to outputDir. Extraction stops with the context's error when ctx is done.
+func (c *PDFConverter) extractPages(ctx context.Context, reader *pdf.Reader, pdfPath, outputDir string, first, last int, selection PageSelection, previous *pageCache, timings *PhaseTimings) ([]PDFPage, int, error) {
 	var pages []PDFPage
+	var cache *pageCache
+	if previous != nil {
+		cache = &pageCache{Settings: previous.Settings}
+	}
 	totalImages := 0
-	for pageNum := 1; pageNum <= reader.NumPage(); pageNum++ {
+	if first < 1 {
+		first = 1
+	}
+	if last > reader.NumPage() {
+		last = reader.NumPage()
+	}
+	tree := newPageTree(reader)
+	for pageNum := first; pageNum <= last; pageNum++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if selection != nil && !selection.Contains(pageNum) {
+			continue
+		}
 		c.logger.Debug("Processing page %d/%d", pageNum, reader.NumPage())
-		page := PDFPage{Number: pageNum, Images: []PDFImage{}}
-		p := reader.Page(pageNum)
+		p, err := tree.page(pageNum)
+		if err != nil {
+			c.logger.Warn("Page %d could not be read: %v", pageNum, err)
+			pages = append(pages, PDFPage{Number: pageNum, Images: []PDFImage{}, Failure: fmt.Sprintf("page object could not be read: %v", err)})
+			continue
+		}
 		if p.V.IsNull() {
 			c.logger.Warn("Page %d is null, skipping", pageNum)
+			pages = append(pages, PDFPage{Number: pageNum, Images: []PDFImage{}, Failure: "page object not found"})
 			continue
 		}
-		text, err := p.GetPlainText(nil)
-		if err != nil {
-			c.logger.Warn("Failed to extract text from page %d: %v", pageNum, err)
-			text = ""
-		}
-		page.Text = text
-
-		if c.config.ExtractImages {
-			images, err := c.extractImagesFromPage(p, pageNum, outputDir)
-			if err != nil {
-				c.logger.Warn("Failed to extract images from page %d: %v", pageNum, err)
-			} else {
-				page.Images = images
-				totalImages += len(images)
+		var hash string
+		if previous != nil {
+			hash = pageHash(p)
+			if page, ok := c.reusePage(previous, pageNum, hash, outputDir); ok {
+				cache.add(pageNum, hash, page)
+				pages = append(pages, page)
+				totalImages += len(page.Images)
+				continue
 			}
 		}
+		page := c.safeExtractPage(ctx, p, pdfPath, pageNum, outputDir, timings)
+		// Failed pages are not cached, so the next conversion tries them again
+		if previous != nil && page.Failure == "" {
+			cache.add(pageNum, hash, page)
+		}
 		pages = append(pages, page)
+		totalImages += len(page.Images)
+	}
+	// Work cancelled on the last page only surfaces here
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
 	}
+	if cache != nil {
+		if err := cache.write(outputDir); err != nil {
+			c.logger.Warn("Failed to write page cache: %v", err)
+		}
+	}
+	finishStart := time.Now()
+	c.finishPages(pages)
+	timings.record(phaseText, finishStart)
 	c.logger.Info("Extracted content from %d pages, %d images total", len(pages), totalImages)
 	return pages, totalImages, nil
 }
 
-func (c *PDFConverter) extractImagesFromPage(page pdf.Page, pageNum int, outputDir string) ([]PDFImage, error) {
+// extractPage extracts the text, tables and images of a single page.
+func (c *PDFConverter) extractPage(ctx context.Context, p pdf.Page, pdfPath string, pageNum int, outputDir string, timings *PhaseTimings) PDFPage {
+	page := PDFPage{Number: pageNum, Images: []PDFImage{}}
+	textStart := time.Now()
+	text, err := p.GetPlainText(nil)
+	if err != nil {
+		c.logger.Warn("Failed to extract text from page %d: %v", pageNum, err)
+		text = ""
+		page.Failure = fmt.Sprintf("text extraction failed: %v", err)
+	}
+	page.Text = text
+	page.Redactions = c.detectPDFRedactions(p, pageNum)
+	runs, runsErr := pageTextRuns(p)
+	if runs, page.DuplicateText = c.removeDuplicateText(runs, pageNum); page.DuplicateText != nil {
+		page.Text = linesText(groupTextLines(runs))
+	}
+	page.StyledSpans = styledSpans(runs)
+	if c.config.DetectCallouts {
+		page.Callouts = c.detectCallouts(p, pageNum, runs)
+	}
+
+	inline := c.config.ImagePlacement == "inline"
+	if inline || c.config.ExtractTables || c.config.ApplicationBOM {
+		if runsErr != nil {
+			c.logger.Warn("Failed to extract positioned text from page %d, falling back to plain text: %v", pageNum, runsErr)
+		}
+		lines := groupTextLines(runs)
+		page.Lines = lines
+		if c.config.ExtractTables {
+			page.Tables = detectTables(lines)
+			c.applyTableFallback(pdfPath, p, pageNum, outputDir, page.Tables)
+		}
+	}
+	if c.config.CurveData {
+		page.Graphs = c.extractCurveGraphs(pdfPath, p, pageNum, outputDir, runs)
+	}
+	timings.record(phaseText, textStart)
+	c.checkTextLayer(&page, func() (image.Image, error) { return c.renderPage(pdfPath, pageNum, textOCRRenderDPI) }, timings)
+
+	if c.config.ExtractImages {
+		imageStart := time.Now()
+		images, failures, err := c.extractImagesFromPage(ctx, p, pageNum, outputDir)
+		page.ImageFailures = failures
+		if err != nil {
+			c.logger.Warn("Failed to extract images from page %d: %v", pageNum, err)
+		} else {
+			if inline {
+				placements := c.imagePlacements(p)
+				for i := range images {
+					images[i].PositionY, images[i].HasPosition = placements[images[i].ObjectName]
+				}
+			}
+			page.Images = images
+		}
+		timings.record(phaseImages, imageStart)
+	}
+	return page
+}
+
+// extractImagesFromPage saves the image XObjects of a page and returns them together with the
+// number of images that could not be saved. It stops with the context's error when ctx is done.
+func (c *PDFConverter) extractImagesFromPage(ctx context.Context, page pdf.Page, pageNum int, outputDir string) ([]PDFImage, int, error) {
 	var images []PDFImage
 	c.logger.Debug("Extracting images from page %d", pageNum)
 
@@ -209,19 +391,22 @@ func (c *PDFConverter) extractImagesFromPage(page pdf.Page, pageNum int, outputD
 	resources := page.V.Key("Resources")
 	if resources.IsNull() {
 		c.logger.Debug("No resources found on page %d", pageNum)
-		return images, nil
+		return images, 0, nil
 	}
 
 	xObjects := resources.Key("XObject")
 	if xObjects.IsNull() {
 		c.logger.Debug("No XObject resources found on page %d", pageNum)
-		return images, nil
+		return images, 0, nil
 	}
 
-	imageCount := 0
+	imageCount, failures := 0, 0
 	objKeys := xObjects.Keys()
 
 	for _, name := range objKeys {
+		if err := ctx.Err(); err != nil {
+			return images, failures, err
+		}
 		// Skip if name is empty or invalid
 		if name == "" {
 			continue
@@ -232,6 +417,7 @@ func (c *PDFConverter) extractImagesFromPage(page pdf.Page, pageNum int, outputD
 			defer func() {
 				if r := recover(); r != nil {
 					c.logger.Warn("Recovered from panic while processing image %s on page %d: %v", name, pageNum, r)
+					failures++
 				}
 			}()
 
@@ -241,52 +427,50 @@ func (c *PDFConverter) extractImagesFromPage(page pdf.Page, pageNum int, outputD
 			}
 
 			imageCount++
-			filename := fmt.Sprintf("page_%d_image_%d.png", pageNum, imageCount)
-			imagePath := filepath.Join(outputDir, filename)
 
 			// Extract actual image data from PDF
-			img, err := c.extractImageFromXObject(obj)
-			if err != nil {
-				c.logger.Warn("Failed to extract image data for %s: %v, using placeholder", filename, err)
+			img, err := c.extractImageFromXObject(ctx, obj)
+			if ctx.Err() != nil {
+				return // Cancelled while decoding, reported by the next iteration
+			}
+			placeholder := err != nil
+			if placeholder {
+				c.logger.Warn("Failed to extract image data for %s on page %d: %v, using placeholder", name, pageNum, err)
 				img = c.createPlaceholderImage(DefaultImageWidth, DefaultImageHeight)
 			}
 
-			if err := c.saveImage(img, imagePath); err != nil {
-				c.logger.Warn("Failed to save image %s: %v", imagePath, err)
+			filename, err := c.saveHashedImage(img, outputDir, ImageFilePrefix)
+			if err != nil {
+				c.logger.Warn("Failed to save image %s on page %d: %v", name, pageNum, err)
+				failures++
 				return // Exit anonymous function only - this is correct, continue processing other images
 			}
+			imagePath := filepath.Join(outputDir, filename)
 
-			var diagrams []uml.DetectedDiagram
-			if c.config.DetectDiagrams {
-				detectedDiagrams, err := c.diagramDetector.DetectDiagramsInImage(imagePath)
-				if err != nil {
-					c.logger.Warn("Failed to analyze image %s for diagrams: %v", imagePath, err)
-				} else {
-					diagrams = detectedDiagrams
-					if len(detectedDiagrams) > 0 {
-						c.logger.Info("Found %d diagram(s) in %s", len(detectedDiagrams), filename)
-					}
-				}
-			}
 			pdfImage := PDFImage{
-				Data:     img,
-				Width:    img.Bounds().Dx(),
-				Height:   img.Bounds().Dy(),
-				Filename: filename,
-				Diagrams: diagrams,
+				Data:        img,
+				Width:       img.Bounds().Dx(),
+				Height:      img.Bounds().Dy(),
+				Filename:    filename,
+				Diagrams:    c.detectImageDiagrams(ctx, img, imagePath),
+				ObjectName:  name,
+				Placeholder: placeholder,
 			}
 			images = append(images, pdfImage)
 		}()
 	}
+	if err := ctx.Err(); err != nil {
+		return images, failures, err
+	}
 	if imageCount > 0 {
 		c.logger.Info("Extracted %d images from page %d", imageCount, pageNum)
 	} else {
 		c.logger.Debug("No images found on page %d", pageNum)
 	}
-	return images, nil
+	return images, failures, nil
 }
 
-func (c *PDFConverter) extractImageFromXObject(obj pdf.Value) (image.Image, error) {
+func (c *PDFConverter) extractImageFromXObject(ctx context.Context, obj pdf.Value) (image.Image, error) {
 	// Get the image stream data
 	reader := obj.Reader()
 	if reader == nil {
@@ -330,27 +514,29 @@ func (c *PDFConverter) extractImageFromXObject(obj pdf.Value) (image.Image, erro
 			img, err := jpeg.Decode(bytes.NewReader(stream))
 			if err != nil {
 				c.logger.Debug("Failed to decode JPEG directly: %v", err)
-				return c.decodeRawImageData(stream, width, height, colorSpace, bitsPerComponent)
+				return c.decodeRawImageData(ctx, stream, width, height, colorSpace, bitsPerComponent)
 			}
 			return img, nil
 		case "FlateDecode":
 			// This is compressed data, the stream should already be decompressed by the PDF library
-			return c.decodeRawImageData(stream, width, height, colorSpace, bitsPerComponent)
+			return c.decodeRawImageData(ctx, stream, width, height, colorSpace, bitsPerComponent)
 		case "CCITTFaxDecode":
 			// CCITT Fax encoding, typically used for black and white images
 			c.logger.Debug("CCITT Fax encoded image detected, using placeholder")
 			return c.createPlaceholderImage(width, height), nil
 		default:
 			c.logger.Debug("Unknown filter %s, attempting raw decode", filterName)
-			return c.decodeRawImageData(stream, width, height, colorSpace, bitsPerComponent)
+			return c.decodeRawImageData(ctx, stream, width, height, colorSpace, bitsPerComponent)
 		}
 	}
 
 	// No filter specified, decode as raw image data
-	return c.decodeRawImageData(stream, width, height, colorSpace, bitsPerComponent)
+	return c.decodeRawImageData(ctx, stream, width, height, colorSpace, bitsPerComponent)
 }
 
-func (c *PDFConverter) decodeRawImageData(data []byte, width, height int, colorSpace string, bitsPerComponent int) (image.Image, error) {
+// decodeRawImageData decodes uncompressed samples into an image, stopping with the context's
+// error when ctx is done.
+func (c *PDFConverter) decodeRawImageData(ctx context.Context, data []byte, width, height int, colorSpace string, bitsPerComponent int) (image.Image, error) {
 	if width <= 0 || height <= 0 {
 		return nil, fmt.Errorf("invalid image dimensions: %dx%d", width, height)
 	}
@@ -397,7 +583,7 @@ func (c *PDFConverter) decodeRawImageData(data []byte, width, height int, colorS
 
 	// Special handling for 1-bit images (black and white)
 	if bitsPerComponent == 1 {
-		return c.decode1BitImage(data, width, height), nil
+		return c.decode1BitImage(ctx, data, width, height)
 	}
 
 	expectedDataSize := width * height * bytesPerPixel
@@ -412,6 +598,9 @@ func (c *PDFConverter) decodeRawImageData(data []byte, width, height int, colorS
 	// Convert pixel data based on color space
 	dataIndex := 0
 	for y := 0; y < height && dataIndex < len(data); y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		for x := 0; x < width && dataIndex < len(data); x++ {
 			var r, g, b uint8 = 0, 0, 0
 
@@ -470,17 +659,20 @@ func (c *PDFConverter) decodeRawImageData(data []byte, width, height int, colorS
 	return img, nil
 }
 
-func (c *PDFConverter) decode1BitImage(data []byte, width, height int) image.Image {
+func (c *PDFConverter) decode1BitImage(ctx context.Context, data []byte, width, height int) (image.Image, error) {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 
 	bitIndex := 0
 	for y := 0; y < height; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		for x := 0; x < width; x++ {
 			byteIndex := bitIndex / 8
 			bitPosition := 7 - (bitIndex % 8)
 
 			if byteIndex >= len(data) {
-				return img
+				return img, nil
 			}
 
 			bit := (data[byteIndex] >> bitPosition) & 1
@@ -501,7 +693,7 @@ func (c *PDFConverter) decode1BitImage(data []byte, width, height int) image.Ima
 		}
 	}
 
-	return img
+	return img, nil
 }
 
 func (c *PDFConverter) createPlaceholderImage(width, height int) image.Image {
@@ -510,48 +702,121 @@ func (c *PDFConverter) createPlaceholderImage(width, height int) image.Image {
 }
 
 func (c *PDFConverter) saveImage(img image.Image, filePath string) error {
-	file, err := os.Create(filePath)
+	data, err := encodePNG(img)
 	if err != nil {
+		return fmt.Errorf("failed to encode image %s: %v", filePath, err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to create image file %s: %v", filePath, err)
 	}
-	defer file.Close()
 
+	c.logger.Debug("Successfully saved image: %s", filePath)
+	return nil
+}
+
+// saveHashedImage saves an image into outputDir under a name derived from its content,
+// <prefix>_<hash>.png, and returns the file name. Identical images get the same name in
+// every conversion, so links survive re-conversions and identical figures are stored once.
+func (c *PDFConverter) saveHashedImage(img image.Image, outputDir, prefix string) (string, error) {
 	// Always save as PNG for consistency and quality
-	if err := png.Encode(file, img); err != nil {
-		return fmt.Errorf("failed to encode image %s: %v", filePath, err)
+	data, err := encodePNG(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s image: %v", prefix, err)
+	}
+	filename := hashedImageName(prefix, data)
+	filePath := filepath.Join(outputDir, filename)
+	if _, err := os.Stat(filePath); err == nil {
+		c.logger.Debug("Image already saved: %s", filePath)
+		return filename, nil
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to create image file %s: %v", filePath, err)
 	}
-
 	c.logger.Debug("Successfully saved image: %s", filePath)
-	return nil
+	return filename, nil
+}
+
+// encodePNG encodes an image as PNG.
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// hashedImageName returns <prefix>_<hash>.png, where hash is the start of the SHA-256 of
+// the encoded image.
+func hashedImageName(prefix string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%s_%s.png", prefix, hex.EncodeToString(sum[:])[:ImageHashLength])
 }
 
 func (c *PDFConverter) generateMarkdown(pages []PDFPage) string {
+	return c.generateMarkdownWithTitle("PDF Document", pages)
+}
+
+// generateMarkdownWithTitle renders pages as Markdown under the given document title.
+func (c *PDFConverter) generateMarkdownWithTitle(title string, pages []PDFPage) string {
 	var md strings.Builder
-	md.WriteString("# PDF Document\n\n")
+	md.WriteString(fmt.Sprintf("# %s\n\n", title))
 	if c.config.IncludeTOC {
 		md.WriteString(c.generateTableOfContents(pages))
 		md.WriteString("\n")
 	}
-	for _, page := range pages {
-		headerLevel := strings.Repeat("#", c.config.BaseHeaderLevel+1)
-		md.WriteString(fmt.Sprintf("%s Page %d\n\n", headerLevel, page.Number))
-		if page.Text != "" {
-			formattedText := c.formatTextContent(page.Text)
-			md.WriteString(formattedText)
-			md.WriteString("\n\n")
-		}
-		for _, img := range page.Images {
-			md.WriteString(fmt.Sprintf("![Image](./%s)\n\n", img.Filename))
-			for _, diagram := range img.Diagrams {
-				diagramMarkdown := c.diagramDetector.GetPlantUMLMarkdown(diagram)
-				md.WriteString(diagramMarkdown)
+	for i, page := range pages {
+		md.WriteString(c.heading(c.config.BaseHeaderLevel+1, fmt.Sprintf("Page %d", page.Number)) + "\n\n")
+		if len(page.Redactions) > 0 {
+			md.WriteString(redactionNote(page.Redactions))
+		}
+		if page.Unreliable {
+			md.WriteString(unreliableTextNote(page.TextConfidence))
+		}
+		if page.Verbatim {
+			if page.Text != "" {
+				md.WriteString(formatVerbatimText(page.Text))
+				md.WriteString("\n\n")
+			}
+			for _, img := range page.Images {
+				c.writeImageMarkdown(&md, img)
+			}
+		} else if (c.config.ImagePlacement == "inline" || len(page.Tables) > 0) && len(page.Lines) > 0 {
+			c.renderLayoutPage(&md, page)
+		} else {
+			for _, segment := range page.Segments {
+				md.WriteString(languageMarker(segment.Language))
+				if formatted := c.formatTextContent(c.markStyles(c.markCallouts(segment.Text, page), page)); formatted != "" {
+					md.WriteString(formatted)
+					md.WriteString("\n\n")
+				}
+			}
+			if page.Text != "" && page.Segments == nil {
+				formattedText := c.formatTextContent(c.markStyles(c.markCallouts(page.Text, page), page))
+				md.WriteString(formattedText)
+				md.WriteString("\n\n")
+			}
+			for _, img := range page.Images {
+				c.writeImageMarkdown(&md, img)
 			}
 		}
-		if page.Number < len(pages) {
+		if i < len(pages)-1 {
 			md.WriteString("---\n\n")
 		}
 	}
-	return md.String()
+	return c.linkCrossReferences(c.numberSections(md.String()))
+}
+
+// writeImageMarkdown writes an image reference followed by any diagrams detected in it.
+func (c *PDFConverter) writeImageMarkdown(md *strings.Builder, img PDFImage) {
+	alt := img.AltText
+	if alt == "" {
+		alt = "Image"
+	}
+	md.WriteString(fmt.Sprintf("![%s](./%s)\n\n", alt, img.Filename))
+	for _, diagram := range img.Diagrams {
+		diagramMarkdown := c.diagramDetector.GetPlantUMLMarkdown(diagram)
+		md.WriteString(diagramMarkdown)
+	}
 }
 
 func (c *PDFConverter) generateTableOfContents(pages []PDFPage) string {
@@ -570,17 +835,26 @@ func (c *PDFConverter) formatTextContent(text string) string {
 	}
 	lines := strings.Split(text, "\n")
 	var formatted []string
+	inFence := false
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
+		if line == "```" {
+			inFence = !inFence
+		}
 		if line == "" {
 			continue
 		}
-		if c.looksLikeHeader(line) {
+		if !inFence && len(formatted) > 0 && quoteBreak(formatted[len(formatted)-1], line) {
+			formatted = append(formatted, "") // keeps alerts apart from the paragraphs around them
+		}
+		if !inFence && line != "```" && !strings.HasPrefix(line, "`") && !strings.HasPrefix(line, ">") && c.looksLikeHeader(line) {
 			if len(formatted) > 0 {
 				formatted = append(formatted, "")
 			}
-			headerLevel := strings.Repeat("#", c.config.BaseHeaderLevel+2)
-			formatted = append(formatted, fmt.Sprintf("%s %s", headerLevel, line))
+			if c.config.HeadingNormalize {
+				line = normalizeHeading(line)
+			}
+			formatted = append(formatted, c.heading(c.config.BaseHeaderLevel+2, line))
 			formatted = append(formatted, "")
 		} else {
 			formatted = append(formatted, line)
@@ -592,30 +866,74 @@ func (c *PDFConverter) formatTextContent(text string) string {
 	return result
 }
 
+// quoteBreak reports whether a blank line must separate line from the previous line: where
+// a block quote starts or ends, and between two alerts.
+func quoteBreak(previous, line string) bool {
+	if previous == "" {
+		return false
+	}
+	quoted, wasQuoted := strings.HasPrefix(line, ">"), strings.HasPrefix(previous, ">")
+	return quoted != wasQuoted || quoted && strings.HasPrefix(line, "> [!")
+}
+
+// formatVerbatimText wraps page text in a fenced block, keeping line breaks and spacing intact.
+// Only trailing whitespace and leading/trailing blank lines are removed.
+func formatVerbatimText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	body := strings.Join(lines, "\n")
+
+	// The fence must be longer than any backtick run inside the content
+	longest, run := 0, 0
+	for _, r := range body {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fmt.Sprintf("%stext\n%s\n%s", fence, body, fence)
+}
+
 func (c *PDFConverter) looksLikeHeader(line string) bool {
+	line = strings.TrimSpace(line)
+	// Custom patterns are explicit user intent and bypass the length heuristic
+	if c.headers.matchesPattern(line) {
+		return true
+	}
+	if c.sectionNumbering() != "off" && looksLikeNumberedHeading(line) {
+		return true
+	}
 	if len(line) > DefaultHeaderLength {
 		return false
 	}
-	line = strings.TrimSpace(line)
 	if strings.HasSuffix(line, ":") {
 		return true
 	}
 	if len(line) < ShortHeaderLength && strings.ToUpper(line) == line && len(strings.Fields(line)) <= MaxHeaderWords {
 		return true
 	}
-	headerKeywords := []string{"OVERVIEW", "DESCRIPTION", "FEATURES", "SPECIFICATIONS",
-		"PARAMETERS", "APPLICATIONS", "CHARACTERISTICS", "OPERATION", "CONFIGURATION"}
-	upperLine := strings.ToUpper(line)
-	for _, keyword := range headerKeywords {
-		if strings.Contains(upperLine, keyword) {
-			return true
-		}
-	}
-	return false
+	return c.headers.containsKeyword(line)
 }
 
 func (c *PDFConverter) writeMarkdownFile(filePath, content string) error {
 	c.logger.Debug("Writing Markdown file: %s", filePath)
+	content = c.lintMarkdown(content)
 	file, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create file %s: %v", filePath, err)
@@ -629,7 +947,16 @@ func (c *PDFConverter) writeMarkdownFile(filePath, content string) error {
 }
 
 func (c *PDFConverter) ConvertPDFsInDirectory(inputDir, outputBaseDir string) (*BatchConversionResult, error) {
+	return c.ConvertPDFsInDirectoryWithOptions(inputDir, outputBaseDir, ConversionOptions{})
+}
+
+// ConvertPDFsInDirectoryWithOptions behaves like ConvertPDFsInDirectory but applies per-call
+// conversion options to every document.
+func (c *PDFConverter) ConvertPDFsInDirectoryWithOptions(inputDir, outputBaseDir string, opts ConversionOptions) (*BatchConversionResult, error) {
 	c.logger.Info("Starting batch PDF conversion from directory: %s", inputDir)
+	if opts.batch.IsZero() {
+		opts.batch = time.Now()
+	}
 	if _, err := os.Stat(inputDir); os.IsNotExist(err) {
 		return nil, fmt.Errorf("input directory does not exist: %s", inputDir)
 	}
@@ -649,13 +976,38 @@ func (c *PDFConverter) ConvertPDFsInDirectory(inputDir, outputBaseDir string) (*
 		Errors:        make([]ConversionError, 0), // Pre-allocate to avoid nil slice issues
 	}
 
+	result.FileCount = len(pdfFiles)
 	for i, pdfPath := range pdfFiles {
+		if err := opts.context().Err(); err != nil {
+			return nil, fmt.Errorf("batch conversion stopped after %d of %d file(s): %w", i, len(pdfFiles), err)
+		}
 		c.logger.Info("Processing PDF file (%d/%d): %s", i+1, len(pdfFiles), filepath.Base(pdfPath))
-		conversionResult, err := c.ConvertPDF(pdfPath, outputBaseDir)
+		// Portfolios count as the documents they embed
+		if c.IsPortfolio(pdfPath) {
+			portfolio, err := c.ConvertPortfolio(pdfPath, outputBaseDir, opts)
+			if err != nil {
+				c.logger.Error("Failed to convert PDF portfolio %s: %v", pdfPath, err)
+				result.FailureCount++
+				result.Errors = append(result.Errors, newConversionError(pdfPath, err))
+				continue
+			}
+			for _, e := range portfolio.Errors {
+				result.Errors = append(result.Errors, ConversionError{PDFPath: filepath.Join(pdfPath, e.PDFPath), Error: e.Error, Code: e.Code})
+			}
+			result.FileCount += portfolio.FileCount - 1
+			result.SuccessCount += portfolio.SuccessCount
+			result.FailureCount += portfolio.FailureCount
+			result.Results = append(result.Results, portfolio.Results...)
+			result.TotalPageCount += portfolio.TotalPageCount
+			result.TotalImageCount += portfolio.TotalImageCount
+			result.ErrataLinks = append(result.ErrataLinks, portfolio.ErrataLinks...)
+			continue
+		}
+		conversionResult, err := c.ConvertDocument(pdfPath, outputBaseDir, opts)
 		if err != nil {
 			c.logger.Error("Failed to convert PDF %s: %v", pdfPath, err)
 			result.FailureCount++
-			result.Errors = append(result.Errors, ConversionError{PDFPath: pdfPath, Error: err.Error()})
+			result.Errors = append(result.Errors, newConversionError(pdfPath, err))
 		} else {
 			c.logger.Info("Successfully converted PDF: %s", filepath.Base(pdfPath))
 			result.SuccessCount++
@@ -664,34 +1016,17 @@ func (c *PDFConverter) ConvertPDFsInDirectory(inputDir, outputBaseDir string) (*
 			result.TotalImageCount += conversionResult.ImageCount
 		}
 	}
-	result.FileCount = len(pdfFiles)
-	c.logger.Info("Batch conversion completed: %d successful, %d failed", result.SuccessCount, result.FailureCount)
-	return result, nil
-}
-
-func (c *PDFConverter) findPDFFiles(dir string) ([]string, error) {
-	var pdfFiles []string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			c.logger.Warn("Error accessing path %s: %v", path, err)
-			return nil
-		}
-		if info.IsDir() {
-			return nil
+	result.ErrataLinks = append(result.ErrataLinks, c.linkErrata(result.Results)...)
+	if c.config.BatchSummary {
+		if result.SummaryFile, err = writeBatchSummary(result); err != nil {
+			c.logger.Warn("Failed to write batch summary: %v", err)
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".pdf" {
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				c.logger.Warn("Could not get absolute path for %s: %v", path, err)
-				return nil
-			}
-			pdfFiles = append(pdfFiles, absPath)
+	}
+	if c.config.BatchResults {
+		if result.ResultsFile, err = writeBatchResults(result); err != nil {
+			c.logger.Warn("Failed to write batch results: %v", err)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("error walking directory %s: %v", dir, err)
 	}
-	return pdfFiles, nil
+	c.logger.Info("Batch conversion completed: %d successful, %d failed", result.SuccessCount, result.FailureCount)
+	return result, nil
 }
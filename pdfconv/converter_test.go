@@ -1,13 +1,17 @@
 package pdfconv
 
 import (
+	"context"
+	"errors"
 	"image/png"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jung-kurt/gofpdf"
 
@@ -37,6 +41,30 @@ func TestNewPDFConverter(t *testing.T) {
 	}
 }
 
+func TestWithPreset(t *testing.T) {
+	cfg := &config.Config{ImageMaxDPI: 300, DetectDiagrams: true, IncludeTOC: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	if same, err := conv.WithPreset(""); err != nil || same != conv {
+		t.Errorf("expected the converter itself without a preset, got %v", err)
+	}
+	fast, err := conv.WithPreset("fast")
+	if err != nil {
+		t.Fatalf("WithPreset() error = %v", err)
+	}
+	if fast.config.ImageMaxDPI != 150 || fast.config.DetectDiagrams || fast.config.Preset != "fast" {
+		t.Errorf("expected the fast preset applied, got %+v", fast.config)
+	}
+	if cfg.ImageMaxDPI != 300 || !cfg.DetectDiagrams {
+		t.Error("WithPreset must not change the converter's configuration")
+	}
+	if fast.pageIndex != conv.pageIndex {
+		t.Error("expected the lookup cache shared")
+	}
+	if _, err := conv.WithPreset("slow"); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
 func TestCreateOutputDirectory(t *testing.T) {
 	cfg := &config.Config{}
 	logr := logger.NewLogger("error")
@@ -152,6 +180,34 @@ func TestGenerateMarkdown_WithTOC_Images_Diagrams(t *testing.T) {
 	}
 }
 
+func TestFormatVerbatimText(t *testing.T) {
+	text := "\n\nADDR   NAME    RESET  \n0x00   CTRL    0x0000\n  0x04 ``` STATUS\n\n"
+	got := formatVerbatimText(text)
+	want := "````text\nADDR   NAME    RESET\n0x00   CTRL    0x0000\n  0x04 ``` STATUS\n````"
+	if got != want {
+		t.Errorf("formatVerbatimText() = %q, want %q", got, want)
+	}
+	if formatVerbatimText(" \n \n") != "" {
+		t.Error("expected empty output for blank text")
+	}
+}
+
+func TestGenerateMarkdown_VerbatimPage(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	pages := []PDFPage{
+		{Number: 1, Text: "FEATURES\nreflowed  text"},
+		{Number: 2, Text: "FEATURES\nkept    spacing", Verbatim: true},
+	}
+	md := conv.generateMarkdown(pages)
+	if !strings.Contains(md, "### FEATURES\n\nreflowed  text") {
+		t.Errorf("page 1 should be formatted normally, got:\n%s", md)
+	}
+	if !strings.Contains(md, "```text\nFEATURES\nkept    spacing\n```") {
+		t.Errorf("page 2 should be fenced verbatim, got:\n%s", md)
+	}
+}
+
 func TestWriteMarkdownFile(t *testing.T) {
 	cfg := &config.Config{}
 	logr := logger.NewLogger("error")
@@ -299,6 +355,33 @@ func TestConvertPDF_EndToEnd_WithRealPDF(t *testing.T) {
 	}
 }
 
+func TestConvertPDF_Cancelled(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, ExtractImages: true}, logger.NewLogger("error"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	outputDir := t.TempDir()
+	_, err := conv.ConvertPDFWithOptions(createTempValidPDF(t), outputDir, ConversionOptions{Context: ctx})
+	if !errors.Is(err, context.Canceled) || ErrorCode(err) != "canceled" {
+		t.Fatalf("expected a canceled conversion, got %v", err)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("expected no output left behind, got %d entries", len(entries))
+	}
+
+	// Pixel loops stop at the next row once the deadline has passed
+	deadline, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := conv.decodeRawImageData(deadline, make([]byte, 64*64*3), 64, 64, "DeviceRGB", 8); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the RGB decode to stop, got %v", err)
+	}
+	if _, err := conv.decodeRawImageData(deadline, make([]byte, 64*8), 64, 64, "DeviceGray", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the 1-bit decode to stop, got %v", err)
+	}
+	if img, err := conv.decodeRawImageData(context.Background(), make([]byte, 4*4*3), 4, 4, "DeviceRGB", 8); err != nil || img.Bounds().Dx() != 4 {
+		t.Errorf("expected the decode to complete without a deadline, got %v", err)
+	}
+}
+
 func TestConvertPDFsInDirectory(t *testing.T) {
 	pdfSrc := createTempValidPDF(t)
 	inDir := t.TempDir()
@@ -337,6 +420,24 @@ func TestConvertPDFsInDirectory(t *testing.T) {
 	}
 }
 
+func TestConvertPDFsInDirectory_Timeout(t *testing.T) {
+	inDir := t.TempDir()
+	if err := copyFile(createTempValidPDF(t), filepath.Join(inDir, "one.pdf")); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	outBase := t.TempDir()
+	_, err := conv.ConvertPDFsInDirectoryWithOptions(inDir, outBase, ConversionOptions{Context: ctx})
+	if ErrorCode(err) != "timeout" {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(outBase); len(entries) != 0 {
+		t.Errorf("expected no output left behind, got %v", entries)
+	}
+}
+
 func TestConvertPDFsInDirectory_NoPDFs(t *testing.T) {
 	cfg := &config.Config{}
 	logr := logger.NewLogger("error")
@@ -416,3 +517,36 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// imageLinkPattern matches the Markdown link of an extracted image and captures its file name.
+var imageLinkPattern = regexp.MustCompile(`!\[Image\]\(\./(image_[0-9a-f]{16}\.png)\)`)
+
+func TestSaveHashedImage(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	dir := t.TempDir()
+	first, err := conv.saveHashedImage(conv.createPlaceholderImage(20, 10), dir, ImageFilePrefix)
+	if err != nil {
+		t.Fatalf("saveHashedImage() error = %v", err)
+	}
+	if !regexp.MustCompile(`^image_[0-9a-f]{16}\.png$`).MatchString(first) {
+		t.Errorf("unexpected file name %q", first)
+	}
+
+	// The same content gets the same name, in any directory; different content does not
+	again, _ := conv.saveHashedImage(conv.createPlaceholderImage(20, 10), t.TempDir(), ImageFilePrefix)
+	other, _ := conv.saveHashedImage(conv.createPlaceholderImage(10, 20), dir, ImageFilePrefix)
+	table, _ := conv.saveHashedImage(conv.createPlaceholderImage(20, 10), dir, TableImagePrefix)
+	if again != first {
+		t.Errorf("expected identical images to share a name, got %q and %q", first, again)
+	}
+	if other == first {
+		t.Errorf("expected different images to get different names")
+	}
+	if table != "table"+strings.TrimPrefix(first, "image") {
+		t.Errorf("expected table prefix with the same hash, got %q", table)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("expected 3 files, got %d", len(entries))
+	}
+}
@@ -0,0 +1,134 @@
+// Package pdfconv - In-text cross-reference linking.
+// This file builds registries of the sections, figures and tables found in the generated
+// Markdown and turns textual references ("see Figure 12", "Table 5", "Section 4.2") into links.
+package pdfconv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// captionPattern matches figure and table captions such as "Figure 12. Block Diagram" or "Table 5-1: Pin Functions".
+	// Without a separator the title must start with a capital letter ("Table 5 Pin Functions").
+	captionPattern = regexp.MustCompile(`^(Figure|Fig\.|Table)\s+(\d{1,4}(?:[-.]\d{1,4})?)(?:\s*[.:–—-]\s*\S|\s+\p{Lu})`)
+	// referencePattern matches in-text references to sections, figures and tables.
+	referencePattern = regexp.MustCompile(`\b(Sections?|Sect\.|Sec\.|Figures?|Fig\.|Tables?)\s+(\d{1,4}(?:[-.]\d{1,4})*)\b|§\s*(\d{1,3}(?:\.\d{1,3}){0,5})\b`)
+	// sectionAnchorLine matches the anchors emitted for numbered section headings.
+	sectionAnchorLine = regexp.MustCompile(`^<a id="section-([0-9-]+)"></a>$`)
+)
+
+// crossReferences holds the anchor registries built from a generated document.
+type crossReferences struct {
+	sections map[string]bool
+	figures  map[string]bool
+	tables   map[string]bool
+}
+
+// figureAnchor returns the anchor id used for a figure number.
+func figureAnchor(number string) string {
+	return "figure-" + strings.ReplaceAll(number, ".", "-")
+}
+
+// tableAnchor returns the anchor id used for a table number.
+func tableAnchor(number string) string {
+	return "table-" + strings.ReplaceAll(number, ".", "-")
+}
+
+// captionNumber returns the caption kind ("figure" or "table") and number when the line
+// is a figure or table caption. Captions end without a period and are short enough to be titles.
+func captionNumber(line string) (kind, number string) {
+	if len(line) > DefaultHeaderLength+20 || strings.HasSuffix(line, ".") {
+		return "", ""
+	}
+	m := captionPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", ""
+	}
+	if m[1] == "Table" {
+		return "table", m[2]
+	}
+	return "figure", m[2]
+}
+
+// linkCrossReferences anchors figure and table captions and replaces references to known
+// sections, figures and tables with Markdown links. Headings, image lines and fenced code
+// are left untouched, as are references to numbers that do not exist in the document.
+func (c *PDFConverter) linkCrossReferences(markdown string) string {
+	if !c.config.CrossReferenceLinks {
+		return markdown
+	}
+	lines := strings.Split(markdown, "\n")
+	refs := crossReferences{sections: map[string]bool{}, figures: map[string]bool{}, tables: map[string]bool{}}
+
+	// Build the registries and anchor the first caption of each figure and table
+	captions := map[int]bool{}
+	var fences fenceTracker
+	for i, line := range lines {
+		if fences.inCode(line) {
+			continue
+		}
+		if m := sectionAnchorLine.FindStringSubmatch(line); m != nil {
+			refs.sections[strings.ReplaceAll(m[1], "-", ".")] = true
+			continue
+		}
+		// Captions may also have been promoted to headings; the anchor goes before the caption text
+		prefix, text := "", line
+		if m := markdownHeadingPattern.FindStringSubmatch(line); m != nil {
+			prefix, text = m[1]+" ", m[2]
+		}
+		kind, number := captionNumber(text)
+		if kind == "" {
+			continue
+		}
+		captions[i] = true
+		registry, anchor := refs.figures, figureAnchor(number)
+		if kind == "table" {
+			registry, anchor = refs.tables, tableAnchor(number)
+		}
+		if !registry[number] {
+			registry[number] = true
+			lines[i] = fmt.Sprintf("%s<a id=\"%s\"></a>%s", prefix, anchor, text)
+		}
+	}
+
+	fences = fenceTracker{}
+	for i, line := range lines {
+		if fences.inCode(line) || captions[i] || markdownHeadingPattern.MatchString(line) || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "<a id=") {
+			continue
+		}
+		lines[i] = refs.link(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// link replaces the references in a single line that resolve to a registered anchor.
+func (r crossReferences) link(line string) string {
+	return referencePattern.ReplaceAllStringFunc(line, func(ref string) string {
+		m := referencePattern.FindStringSubmatch(ref)
+		label, number := m[1], m[2]
+		if label == "" {
+			label, number = "§", m[3]
+		}
+		var anchor string
+		switch {
+		case strings.HasPrefix(label, "Fig"):
+			if r.figures[number] {
+				anchor = figureAnchor(number)
+			}
+		case strings.HasPrefix(label, "Table"):
+			if r.tables[number] {
+				anchor = tableAnchor(number)
+			}
+		default:
+			if r.sections[number] {
+				anchor = sectionAnchor(number)
+			}
+		}
+		if anchor == "" {
+			return ref
+		}
+		return fmt.Sprintf("[%s](#%s)", ref, anchor)
+	})
+}
@@ -0,0 +1,60 @@
+package pdfconv
+
+import (
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCaptionNumber(t *testing.T) {
+	cases := []struct {
+		line, kind, number string
+	}{
+		{"Figure 12. Functional Block Diagram", "figure", "12"},
+		{"Fig. 3: Timing", "figure", "3"},
+		{"Table 5-1. Pin Functions", "table", "5-1"},
+		{"Table 7 Absolute Maximum Ratings", "table", "7"},
+		{"Figure 12 shows the block diagram", "", ""},
+		{"Table 5 lists the register defaults.", "", ""},
+	}
+	for _, c := range cases {
+		kind, number := captionNumber(c.line)
+		if kind != c.kind || number != c.number {
+			t.Errorf("captionNumber(%q) = %q, %q, want %q, %q", c.line, kind, number, c.kind, c.number)
+		}
+	}
+}
+
+func TestLinkCrossReferences(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, CrossReferenceLinks: true}, logger.NewLogger("error"))
+	md := strings.Join([]string{
+		"## Page 1",
+		"",
+		"The block diagram is shown in Figure 12, see Table 5-1 and Table 9.",
+		"Figure 12. Functional Block Diagram",
+		"",
+		"### Table 5-1. Pin Functions",
+		"",
+		"```text",
+		"see Figure 12",
+		"```",
+	}, "\n")
+	got := conv.linkCrossReferences(md)
+	for _, want := range []string{
+		"shown in [Figure 12](#figure-12), see [Table 5-1](#table-5-1) and Table 9.",
+		"<a id=\"figure-12\"></a>Figure 12. Functional Block Diagram",
+		"### <a id=\"table-5-1\"></a>Table 5-1. Pin Functions",
+		"```text\nsee Figure 12\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	disabled, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	if got := disabled.linkCrossReferences(md); got != md {
+		t.Errorf("expected Markdown unchanged when CrossReferenceLinks is off, got:\n%s", got)
+	}
+}
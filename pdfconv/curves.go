@@ -0,0 +1,850 @@
+// Package pdfconv - Characteristic curve data.
+// This file digitizes characteristic curve graphs, such as power derating and thermal curves,
+// with CURVE_DATA. A graph is found where stroked vector paths run inside a plot area framed
+// by a column and a row of numeric axis labels in the text layer. The labels are fitted to
+// linear or logarithmic scales, and the points of every curve are converted to data values
+// and written to a CSV file next to a cropped image of the graph, so the curves can be
+// re-plotted and compared. Graphs embedded as raster images are not digitized.
+package pdfconv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// CurvesFileName is the index of the digitized graphs written with CURVE_DATA.
+const CurvesFileName = "curves.json"
+
+// CurveFilePrefix starts the names of the files written for a graph:
+// curve_p<page>_<n>.csv with the curve points and curve_p<page>_<n>.png with its image.
+const CurveFilePrefix = "curve"
+
+// Graph kinds
+const (
+	GraphDerating       = "derating"       // Derating curve, such as power dissipation over temperature
+	GraphThermal        = "thermal"        // Other curve over or of a temperature
+	GraphCharacteristic = "characteristic" // Any other characteristic curve
+)
+
+// Axis scales
+const (
+	ScaleLinear = "linear"
+	ScaleLog    = "log"
+)
+
+// Curve detection thresholds
+const (
+	axisMinLabels      = 3    // Fewest numeric labels that make an axis
+	axisFitTolerance   = 0.02 // Largest deviation of a label from the fitted scale, as a fraction of the axis range
+	axisLabelAlign     = 2.0  // Largest misalignment of the labels of an axis, in points
+	axisMaxGap         = 40.0 // Farthest the x axis labels may be below the plot area, in points
+	curveMinExtent     = 0.15 // Narrowest curve, as a fraction of the plot width
+	curveInsideShare   = 0.9  // Share of the points of a curve that must lie inside the plot area
+	curveJoinDistance  = 0.5  // Largest gap between stroked segments joined into one curve, in points
+	curveLabelDistance = 20.0 // Farthest a label may be from a curve to name it, in points
+	bezierSteps        = 8    // Straight segments a Bézier curve is sampled into
+)
+
+// CurveGraph is a digitized characteristic curve graph.
+type CurveGraph struct {
+	Page   int          `json:"page"`
+	Title  string       `json:"title,omitempty"` // Figure caption, "" when none was found
+	Kind   string       `json:"kind"`
+	X      GraphAxis    `json:"x"`
+	Y      GraphAxis    `json:"y"`
+	Curves []GraphCurve `json:"curves"`
+	Data   string       `json:"data"`            // CSV file with the points of all curves
+	Image  string       `json:"image,omitempty"` // Cropped graph image, "" when the page could not be rendered
+}
+
+// GraphAxis is an axis of a digitized graph.
+type GraphAxis struct {
+	Label string  `json:"label,omitempty"` // Axis title, "" when none was found
+	Unit  string  `json:"unit,omitempty"`  // Unit from the axis title
+	Scale string  `json:"scale"`
+	Min   float64 `json:"min"` // Values of the first and last axis labels
+	Max   float64 `json:"max"`
+}
+
+// GraphCurve is a curve of a digitized graph.
+type GraphCurve struct {
+	Name   string `json:"name"`            // Label drawn next to the curve, or "Curve <n>"
+	Color  string `json:"color,omitempty"` // Stroke color as #rrggbb, "" when not known
+	Points int    `json:"points"`
+
+	data [][2]float64 // X and Y values of the points, in drawing order
+}
+
+// curvesFile is the content of curves.json.
+type curvesFile struct {
+	Source string       `json:"source"`
+	Graphs []CurveGraph `json:"graphs"`
+}
+
+// strokedPath is a subpath stroked on a page, in page coordinates.
+type strokedPath struct {
+	points []pdf.Point
+	color  fillColor
+}
+
+// axisLabel is a number printed on a page, a candidate axis label.
+type axisLabel struct {
+	value             float64
+	left, right, base float64 // Horizontal extent and baseline in points
+	size              float64
+}
+
+// center returns the middle of the label's digits.
+func (l axisLabel) center() pdf.Point {
+	return pdf.Point{X: (l.left + l.right) / 2, Y: l.base + l.size*0.35}
+}
+
+// axisScale maps page coordinates along an axis to values: value = a*position + b, or
+// 10^(a*position + b) on logarithmic axes.
+type axisScale struct {
+	log  bool
+	a, b float64
+}
+
+// value returns the value at a page coordinate.
+func (s axisScale) value(position float64) float64 {
+	v := s.a*position + s.b
+	if s.log {
+		return math.Pow(10, v)
+	}
+	return v
+}
+
+// plotArea is a pair of axes framing a graph.
+type plotArea struct {
+	yLabels, xLabels []axisLabel
+	x, y             axisScale
+	rect             pdf.Rect // Area between the first and last labels of both axes
+}
+
+var (
+	axisNumberPattern   = regexp.MustCompile(`^([+-]?(?:\d+(?:\.\d*)?|\.\d+))([kMGmµunp]?)%?$`)
+	axisUnitPattern     = regexp.MustCompile(`(?:[(\[]([^()\[\]]+)[)\]]|\s[-–—]\s*(\S+))\s*$`)
+	figureCaptionLine   = regexp.MustCompile(`(?i)^(?:figure|fig\.)\s*[A-Z]?\d+`)
+	deratingKeywords    = regexp.MustCompile(`(?i)derat`)
+	temperatureKeywords = regexp.MustCompile(`(?i)temperature|thermal|junction|°\s*[CF]\b|\bT[AJC]\b`)
+)
+
+// siPrefixes are the multipliers of the SI prefixes accepted after axis label numbers.
+var siPrefixes = map[string]float64{"": 1, "k": 1e3, "M": 1e6, "G": 1e9, "m": 1e-3, "µ": 1e-6, "u": 1e-6, "n": 1e-9, "p": 1e-12}
+
+// axisNumber parses an axis label such as "25", "-40", "0.5", "10k" or "80%".
+func axisNumber(text string) (float64, bool) {
+	text = strings.NewReplacer("−", "-", "–", "-", ",", "").Replace(strings.TrimSpace(text))
+	m := axisNumberPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * siPrefixes[m[2]], true
+}
+
+// extractCurveGraphs finds the characteristic curve graphs on a page and writes the data
+// and image files of each into outputDir.
+func (c *PDFConverter) extractCurveGraphs(pdfPath string, page pdf.Page, pageNum int, outputDir string, runs []pdf.Text) []CurveGraph {
+	graphs := findCurveGraphs(page, pageNum, runs)
+	if len(graphs) == 0 {
+		return nil
+	}
+	var pageImg image.Image
+	width, height := pageSize(page)
+	if width > 0 && height > 0 {
+		img, err := c.renderPage(pdfPath, pageNum, c.config.ImageMaxDPI)
+		if err != nil {
+			c.logger.Debug("Cannot render page %d for curve graph images: %v", pageNum, err)
+		}
+		pageImg = img
+	}
+	var written []CurveGraph
+	for i, found := range graphs {
+		graph := found.graph
+		base := fmt.Sprintf("%s_p%d_%d", CurveFilePrefix, pageNum, i+1)
+		graph.Data = base + ".csv"
+		if err := writeCurveData(filepath.Join(outputDir, graph.Data), graph); err != nil {
+			c.logger.Warn("Failed to write curve data of graph %d on page %d: %v", i+1, pageNum, err)
+			continue
+		}
+		if pageImg != nil {
+			name := base + ".png"
+			if err := c.saveImage(cropPageRect(pageImg, width, height, found.bounds), filepath.Join(outputDir, name)); err != nil {
+				c.logger.Warn("Failed to save image of graph %d on page %d: %v", i+1, pageNum, err)
+			} else {
+				graph.Image = name
+			}
+		}
+		c.logger.Info("Extracted %d curve(s) from %s graph on page %d", len(graph.Curves), graph.Kind, pageNum)
+		written = append(written, graph)
+	}
+	return written
+}
+
+// foundGraph is a graph found on a page with the region it covers.
+type foundGraph struct {
+	graph  CurveGraph
+	bounds pdf.Rect
+}
+
+// findCurveGraphs digitizes the graphs on a page whose axes carry numeric labels in the text
+// layer. Graphs without a curve are left out, which also skips number tables that line up
+// like axes.
+func findCurveGraphs(page pdf.Page, pageNum int, runs []pdf.Text) (graphs []foundGraph) {
+	defer func() {
+		if r := recover(); r != nil {
+			graphs = nil
+		}
+	}()
+	areas := plotAreas(axisLabels(runs))
+	if len(areas) == 0 {
+		return nil
+	}
+	paths := joinStrokedPaths(strokedPaths(page))
+	lines := groupTextLines(runs)
+	for _, area := range areas {
+		curves := area.curves(paths)
+		if len(curves) == 0 {
+			continue
+		}
+		graph := CurveGraph{Page: pageNum, X: graphAxis(area.xLabels, area.x), Y: graphAxis(area.yLabels, area.y)}
+		xTitle, xTitleY := area.xTitle(lines)
+		graph.X.Label, graph.X.Unit = xTitle, axisUnit(xTitle)
+		yTitle := area.yTitle(runs, lines)
+		graph.Y.Label, graph.Y.Unit = yTitle, axisUnit(yTitle)
+		graph.Title = area.caption(lines)
+		graph.Kind = graphKind(graph.Title + " " + xTitle + " " + yTitle)
+
+		names := area.curveNames(curves, lines)
+		for i, path := range curves {
+			curve := GraphCurve{Name: names[i], Color: path.color.hex()}
+			for _, p := range path.points {
+				point := [2]float64{roundSignificant(area.x.value(p.X), 4), roundSignificant(area.y.value(p.Y), 4)}
+				if n := len(curve.data); n == 0 || curve.data[n-1] != point {
+					curve.data = append(curve.data, point)
+				}
+			}
+			curve.Points = len(curve.data)
+			graph.Curves = append(graph.Curves, curve)
+		}
+
+		bounds := area.rect
+		bounds.Min.X = math.Min(bounds.Min.X, area.yLabels[0].left) - 25
+		bounds.Max.X = math.Max(bounds.Max.X, area.xLabels[len(area.xLabels)-1].right) + 10
+		bounds.Min.Y = math.Min(area.xLabels[0].base, xTitleY) - 6
+		bounds.Max.Y += 12
+		graphs = append(graphs, foundGraph{graph: graph, bounds: bounds})
+	}
+	return graphs
+}
+
+// axisLabels returns the numbers on a page. Glyph runs on the same baseline without a gap
+// are joined into words first. Runs of fonts without glyph widths, such as the standard 14
+// fonts, have no width and are taken to be half as wide as they are high.
+func axisLabels(runs []pdf.Text) []axisLabel {
+	var labels []axisLabel
+	var word strings.Builder
+	var current axisLabel
+	flush := func() {
+		if v, ok := axisNumber(word.String()); ok {
+			current.value = v
+			labels = append(labels, current)
+		}
+		word.Reset()
+	}
+	for _, run := range runs {
+		if strings.TrimSpace(run.S) == "" {
+			flush()
+			continue
+		}
+		joins := word.Len() > 0 && math.Abs(run.Y-current.base) < 0.5 && run.X >= current.left-0.5 && run.X-current.right < run.FontSize*0.25
+		if !joins {
+			flush()
+			current = axisLabel{left: run.X, right: run.X, base: run.Y, size: run.FontSize}
+		}
+		word.WriteString(run.S)
+		if run.W > 0 {
+			current.right = math.Max(current.right, run.X+run.W)
+		} else {
+			// Glyphs without widths are not advanced either
+			current.right = math.Max(current.right, run.X) + float64(len([]rune(run.S)))*run.FontSize*0.5
+		}
+	}
+	flush()
+	return labels
+}
+
+// plotAreas pairs the columns of labels with a common right edge (y axes) with the rows of
+// labels on a common baseline just below them (x axes).
+func plotAreas(labels []axisLabel) []plotArea {
+	type axis struct {
+		labels []axisLabel
+		scale  axisScale
+	}
+	var columns, rows []axis
+	for _, group := range alignedLabels(labels, func(l axisLabel) float64 { return l.right }, func(l axisLabel) float64 { return l.center().Y }) {
+		if scale, ok := fitAxis(group, func(l axisLabel) float64 { return l.center().Y }); ok {
+			columns = append(columns, axis{group, scale})
+		}
+	}
+	for _, group := range alignedLabels(labels, func(l axisLabel) float64 { return l.base }, func(l axisLabel) float64 { return l.center().X }) {
+		if scale, ok := fitAxis(group, func(l axisLabel) float64 { return l.center().X }); ok {
+			rows = append(rows, axis{group, scale})
+		}
+	}
+
+	var areas []plotArea
+	usedRows := make([]bool, len(rows))
+	for _, column := range columns {
+		bottom := column.labels[0]
+		best, bestScore := -1, math.Inf(1)
+		for i, row := range rows {
+			first := row.labels[0].center()
+			below := bottom.center().Y - row.labels[0].base
+			right := first.X - bottom.right
+			if usedRows[i] || below <= 0 || below > axisMaxGap || right < -axisLabelAlign || right > 60 {
+				continue
+			}
+			if score := below + right; score < bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		usedRows[best] = true
+		row := rows[best]
+		areas = append(areas, plotArea{
+			yLabels: column.labels,
+			xLabels: row.labels,
+			x:       row.scale,
+			y:       column.scale,
+			rect: pdf.Rect{
+				Min: pdf.Point{X: row.labels[0].center().X, Y: column.labels[0].center().Y},
+				Max: pdf.Point{X: row.labels[len(row.labels)-1].center().X, Y: column.labels[len(column.labels)-1].center().Y},
+			},
+		})
+	}
+	return areas
+}
+
+// alignedLabels groups labels whose align coordinate agrees, ordered by their position along
+// the axis. Groups are split at gaps much wider than their usual label spacing, so unrelated
+// numbers further along do not join an axis.
+func alignedLabels(labels []axisLabel, align, position func(axisLabel) float64) [][]axisLabel {
+	sorted := append([]axisLabel(nil), labels...)
+	sort.SliceStable(sorted, func(i, j int) bool { return align(sorted[i]) < align(sorted[j]) })
+	var groups [][]axisLabel
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && align(sorted[end])-align(sorted[start]) <= axisLabelAlign {
+			end++
+		}
+		group := append([]axisLabel(nil), sorted[start:end]...)
+		start = end
+		if len(group) < axisMinLabels {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return position(group[i]) < position(group[j]) })
+		gaps := make([]float64, len(group)-1)
+		for i := range gaps {
+			gaps[i] = position(group[i+1]) - position(group[i])
+		}
+		sortedGaps := append([]float64(nil), gaps...)
+		sort.Float64s(sortedGaps)
+		median := sortedGaps[len(sortedGaps)/2]
+		first := 0
+		for i, gap := range gaps {
+			if gap > 2*median || gap < 1 {
+				if i+1-first >= axisMinLabels {
+					groups = append(groups, group[first:i+1])
+				}
+				first = i + 1
+			}
+		}
+		if len(group)-first >= axisMinLabels {
+			groups = append(groups, group[first:])
+		}
+	}
+	return groups
+}
+
+// fitAxis fits the values of labels ordered along an axis to a linear scale, or to a
+// logarithmic one when they are positive and do not fit linearly. Labels must be strictly
+// monotonic and all within axisFitTolerance of the fitted scale.
+func fitAxis(labels []axisLabel, position func(axisLabel) float64) (axisScale, bool) {
+	increasing, decreasing := true, true
+	for i := 1; i < len(labels); i++ {
+		increasing = increasing && labels[i].value > labels[i-1].value
+		decreasing = decreasing && labels[i].value < labels[i-1].value
+	}
+	if !increasing && !decreasing {
+		return axisScale{}, false
+	}
+	positions := make([]float64, len(labels))
+	values := make([]float64, len(labels))
+	for i, l := range labels {
+		positions[i], values[i] = position(l), l.value
+	}
+	if scale, ok := fitLine(positions, values); ok {
+		return scale, true
+	}
+	for i, v := range values {
+		if v <= 0 {
+			return axisScale{}, false
+		}
+		values[i] = math.Log10(v)
+	}
+	scale, ok := fitLine(positions, values)
+	scale.log = true
+	return scale, ok
+}
+
+// fitLine fits values = a*positions + b by least squares and reports whether every value is
+// within axisFitTolerance of the range from the line.
+func fitLine(positions, values []float64) (axisScale, bool) {
+	n := float64(len(positions))
+	var sx, sy, sxx, sxy float64
+	for i := range positions {
+		sx += positions[i]
+		sy += values[i]
+		sxx += positions[i] * positions[i]
+		sxy += positions[i] * values[i]
+	}
+	denominator := n*sxx - sx*sx
+	if denominator == 0 {
+		return axisScale{}, false
+	}
+	a := (n*sxy - sx*sy) / denominator
+	b := (sy - a*sx) / n
+	span := math.Abs(values[len(values)-1] - values[0])
+	if a == 0 || span == 0 {
+		return axisScale{}, false
+	}
+	for i := range positions {
+		if math.Abs(a*positions[i]+b-values[i]) > axisFitTolerance*span {
+			return axisScale{}, false
+		}
+	}
+	return axisScale{a: a, b: b}, true
+}
+
+// graphAxis describes an axis by its scale and the values of its first and last labels.
+func graphAxis(labels []axisLabel, scale axisScale) GraphAxis {
+	axis := GraphAxis{Scale: ScaleLinear, Min: labels[0].value, Max: labels[len(labels)-1].value}
+	if scale.log {
+		axis.Scale = ScaleLog
+	}
+	return axis
+}
+
+// contains reports whether a point lies inside the plot area, with a small margin for
+// curves drawn on the frame.
+func (area plotArea) contains(p pdf.Point) bool {
+	marginX := 2 + 0.02*(area.rect.Max.X-area.rect.Min.X)
+	marginY := 2 + 0.02*(area.rect.Max.Y-area.rect.Min.Y)
+	return p.X >= area.rect.Min.X-marginX && p.X <= area.rect.Max.X+marginX && p.Y >= area.rect.Min.Y-marginY && p.Y <= area.rect.Max.Y+marginY
+}
+
+// curves returns the stroked paths that are curves of the graph: paths inside the plot area,
+// wide enough not to be tick marks or legend swatches, and with a sloped segment, which
+// leaves out the frame and grid lines. Points outside the plot area are dropped.
+func (area plotArea) curves(paths []strokedPath) []strokedPath {
+	var curves []strokedPath
+	for _, path := range paths {
+		var inside []pdf.Point
+		for _, p := range path.points {
+			if area.contains(p) {
+				inside = append(inside, p)
+			}
+		}
+		if len(inside) < 2 || float64(len(inside)) < curveInsideShare*float64(len(path.points)) {
+			continue
+		}
+		minX, maxX, sloped := inside[0].X, inside[0].X, false
+		for i, p := range inside {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			if i > 0 && math.Abs(p.X-inside[i-1].X) > 0.5 && math.Abs(p.Y-inside[i-1].Y) > 0.5 {
+				sloped = true
+			}
+		}
+		if sloped && maxX-minX >= curveMinExtent*(area.rect.Max.X-area.rect.Min.X) {
+			curves = append(curves, strokedPath{points: inside, color: path.color})
+		}
+	}
+	return curves
+}
+
+// curveNames names each curve after the nearest text label inside the plot area within
+// curveLabelDistance, such as "TA = 85°C" drawn next to it, or "Curve <n>".
+func (area plotArea) curveNames(curves []strokedPath, lines []TextLine) []string {
+	names := make([]string, len(curves))
+	distances := make([]float64, len(curves))
+	for i := range distances {
+		distances[i] = curveLabelDistance
+	}
+	for _, line := range lines {
+		for _, cell := range line.Cells {
+			anchor := pdf.Point{X: cell.X, Y: line.Y}
+			if _, numeric := axisNumber(cell.Text); numeric || !area.contains(anchor) {
+				continue
+			}
+			right := cell.X + float64(len([]rune(cell.Text)))*line.Size*0.5
+			for i, curve := range curves {
+				for _, p := range curve.points {
+					dx := math.Max(0, math.Max(cell.X-p.X, p.X-right))
+					dy := math.Max(0, math.Max(line.Y-p.Y, p.Y-(line.Y+line.Size)))
+					if d := math.Hypot(dx, dy); d < distances[i] {
+						names[i], distances[i] = cell.Text, d
+					}
+				}
+			}
+		}
+	}
+	seen := map[string]int{}
+	for i := range names {
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("Curve %d", i+1)
+		}
+		if seen[names[i]]++; seen[names[i]] > 1 {
+			names[i] = fmt.Sprintf("%s (%d)", names[i], seen[names[i]])
+		}
+	}
+	return names
+}
+
+// xTitle returns the title below the x axis labels and its baseline, or "" and the label
+// baseline when there is none.
+func (area plotArea) xTitle(lines []TextLine) (string, float64) {
+	labels := area.xLabels[0]
+	for _, line := range lines {
+		if line.Y >= labels.base-1 || line.Y < labels.base-3*labels.size-line.Size || figureCaptionLine.MatchString(line.Text) {
+			continue
+		}
+		var texts []string
+		for _, cell := range line.Cells {
+			if cell.X >= area.rect.Min.X-20 && cell.X <= area.rect.Max.X {
+				texts = append(texts, cell.Text)
+			}
+		}
+		if title := strings.Join(texts, " "); title != "" {
+			if _, numeric := axisNumber(title); !numeric {
+				return title, line.Y
+			}
+		}
+	}
+	return "", labels.base
+}
+
+// yTitle returns the title of the y axis: text rotated to run upward left of the labels, or
+// else a line just above the top label.
+func (area plotArea) yTitle(runs []pdf.Text, lines []TextLine) string {
+	left := area.yLabels[0].left
+	for _, l := range area.yLabels {
+		left = math.Min(left, l.left)
+	}
+	// Glyphs of rotated text share their X coordinate; the column closest to the labels is used
+	columns := map[int][]pdf.Text{}
+	for _, run := range runs {
+		if run.X < left-40 || run.X >= left-1 || run.Y < area.rect.Min.Y-20 || run.Y > area.rect.Max.Y+20 {
+			continue
+		}
+		key := int(math.Round(run.X / axisLabelAlign))
+		columns[key] = append(columns[key], run)
+	}
+	var column []pdf.Text
+	for key := range columns {
+		if len(columns[key]) >= 3 && (column == nil || columns[key][0].X > column[0].X) {
+			column = columns[key]
+		}
+	}
+	if column != nil {
+		sort.SliceStable(column, func(i, j int) bool { return column[i].Y < column[j].Y })
+		var title strings.Builder
+		for _, run := range column {
+			title.WriteString(run.S)
+		}
+		return strings.Join(strings.Fields(title.String()), " ")
+	}
+
+	top := area.yLabels[len(area.yLabels)-1]
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if line.Y <= top.base+1 || line.Y > top.base+3*top.size+line.Size || len(line.Cells) == 0 {
+			continue
+		}
+		if cell := line.Cells[0]; math.Abs(cell.X-left) <= 30 && !figureCaptionLine.MatchString(cell.Text) {
+			return cell.Text
+		}
+	}
+	return ""
+}
+
+// caption returns the nearest figure caption above or below the graph.
+func (area plotArea) caption(lines []TextLine) string {
+	best, bestDistance := "", 80.0
+	for _, line := range lines {
+		if !figureCaptionLine.MatchString(line.Text) || len(line.Cells) == 0 || line.Cells[0].X > area.rect.Max.X {
+			continue
+		}
+		distance := area.rect.Min.Y - line.Y
+		if line.Y > area.rect.Max.Y {
+			distance = line.Y - area.rect.Max.Y
+		}
+		if distance > 0 && distance < bestDistance {
+			best, bestDistance = line.Text, distance
+		}
+	}
+	return best
+}
+
+// axisUnit returns the unit of an axis title, written in parentheses or brackets at its
+// end or after a dash, as in "Temperature (°C)", "Power [W]" or "Temperature - °C".
+func axisUnit(title string) string {
+	m := axisUnitPattern.FindStringSubmatch(title)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1] + m[2])
+}
+
+// graphKind classifies a graph by its caption and axis titles.
+func graphKind(text string) string {
+	switch {
+	case deratingKeywords.MatchString(text):
+		return GraphDerating
+	case temperatureKeywords.MatchString(text):
+		return GraphThermal
+	}
+	return GraphCharacteristic
+}
+
+// roundSignificant rounds v to the given number of significant digits.
+func roundSignificant(v float64, digits int) float64 {
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return v
+	}
+	scale := math.Pow(10, float64(digits-1)-math.Floor(math.Log10(math.Abs(v))))
+	return math.Round(v*scale) / scale
+}
+
+// hex returns the color as #rrggbb, or "" when it is not known.
+func (c fillColor) hex() string {
+	if !c.known {
+		return ""
+	}
+	component := func(v float64) int { return int(math.Round(math.Max(0, math.Min(1, v)) * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", component(c.r), component(c.g), component(c.b))
+}
+
+// strokedPaths returns the subpaths stroked on a page with their stroke color, in page
+// coordinates and content stream order. Bézier segments are sampled into straight ones.
+// Paths in form XObjects are not followed.
+func strokedPaths(page pdf.Page) []strokedPath {
+	type state struct {
+		ctm    [6]float64
+		stroke fillColor
+	}
+	// The initial stroke color is black
+	black := fillColor{known: true}
+	gs := state{ctm: [6]float64{1, 0, 0, 1, 0, 0}, stroke: black}
+	var stack []state
+	var subpaths [][]pdf.Point
+	var current []pdf.Point
+	var stroked []strokedPath
+
+	transform := func(x, y float64) pdf.Point {
+		m := gs.ctm
+		return pdf.Point{X: m[0]*x + m[2]*y + m[4], Y: m[1]*x + m[3]*y + m[5]}
+	}
+	endSubpath := func() {
+		if len(current) > 1 {
+			subpaths = append(subpaths, current)
+		}
+		current = nil
+	}
+	closeSubpath := func() {
+		if len(current) > 1 {
+			current = append(current, current[0])
+		}
+	}
+	bezier := func(p1, p2, p3 pdf.Point) {
+		if len(current) == 0 {
+			return
+		}
+		p0 := current[len(current)-1]
+		for i := 1; i <= bezierSteps; i++ {
+			t := float64(i) / bezierSteps
+			u := 1 - t
+			current = append(current, pdf.Point{
+				X: u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+				Y: u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+			})
+		}
+	}
+	contents := page.V.Key("Contents")
+	if contents.IsNull() {
+		return nil
+	}
+	pdf.Interpret(contents, func(stk *pdf.Stack, op string) {
+		n := stk.Len()
+		args := make([]pdf.Value, n)
+		for i := n - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		number := func(i int) float64 { return args[i].Float64() }
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(args) == 6 {
+				var m [6]float64
+				for i := range m {
+					m[i] = number(i)
+				}
+				a := gs.ctm
+				gs.ctm = [6]float64{
+					m[0]*a[0] + m[1]*a[2], m[0]*a[1] + m[1]*a[3],
+					m[2]*a[0] + m[3]*a[2], m[2]*a[1] + m[3]*a[3],
+					m[4]*a[0] + m[5]*a[2] + a[4], m[4]*a[1] + m[5]*a[3] + a[5],
+				}
+			}
+		case "G", "RG", "K", "SC", "SCN":
+			gs.stroke = parseFillColor(args)
+		case "CS":
+			gs.stroke = black
+		case "m":
+			if len(args) == 2 {
+				endSubpath()
+				current = []pdf.Point{transform(number(0), number(1))}
+			}
+		case "l":
+			if len(args) == 2 && len(current) > 0 {
+				current = append(current, transform(number(0), number(1)))
+			}
+		case "c":
+			if len(args) == 6 {
+				bezier(transform(number(0), number(1)), transform(number(2), number(3)), transform(number(4), number(5)))
+			}
+		case "v":
+			if len(args) == 4 && len(current) > 0 {
+				bezier(current[len(current)-1], transform(number(0), number(1)), transform(number(2), number(3)))
+			}
+		case "y":
+			if len(args) == 4 {
+				end := transform(number(2), number(3))
+				bezier(transform(number(0), number(1)), end, end)
+			}
+		case "h":
+			closeSubpath()
+		case "re":
+			if len(args) == 4 {
+				endSubpath()
+				x, y, w, h := number(0), number(1), number(2), number(3)
+				current = []pdf.Point{transform(x, y), transform(x+w, y), transform(x+w, y+h), transform(x, y+h), transform(x, y)}
+				endSubpath()
+			}
+		case "S", "s", "B", "B*", "b", "b*":
+			if op == "s" || op == "b" || op == "b*" {
+				closeSubpath()
+			}
+			endSubpath()
+			for _, points := range subpaths {
+				stroked = append(stroked, strokedPath{points: points, color: gs.stroke})
+			}
+			subpaths = nil
+		case "f", "F", "f*", "n":
+			current, subpaths = nil, nil
+		}
+	})
+	return stroked
+}
+
+// joinStrokedPaths joins paths drawn as separate segments, where a path of the same color
+// starts at the end of the previous one, into one curve.
+func joinStrokedPaths(paths []strokedPath) []strokedPath {
+	var joined []strokedPath
+	for _, path := range paths {
+		if n := len(joined); n > 0 {
+			last := &joined[n-1]
+			end := last.points[len(last.points)-1]
+			if last.color == path.color && math.Hypot(path.points[0].X-end.X, path.points[0].Y-end.Y) <= curveJoinDistance {
+				last.points = append(last.points, path.points[1:]...)
+				continue
+			}
+		}
+		joined = append(joined, strokedPath{points: append([]pdf.Point(nil), path.points...), color: path.color})
+	}
+	return joined
+}
+
+// writeCurveData writes the points of the curves of a graph to a CSV file with the columns
+// curve, x and y, named after the axis titles when known.
+func writeCurveData(path string, graph CurveGraph) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	header := []string{"curve", graph.X.Label, graph.Y.Label}
+	if header[1] == "" {
+		header[1] = "x"
+	}
+	if header[2] == "" {
+		header[2] = "y"
+	}
+	w.Write(header)
+	for _, curve := range graph.Curves {
+		for _, p := range curve.data {
+			w.Write([]string{curve.Name, strconv.FormatFloat(p[0], 'g', -1, 64), strconv.FormatFloat(p[1], 'g', -1, 64)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// collectGraphs returns the digitized graphs of all pages in page order.
+func collectGraphs(pages []PDFPage) []CurveGraph {
+	var graphs []CurveGraph
+	for _, page := range pages {
+		graphs = append(graphs, page.Graphs...)
+	}
+	return graphs
+}
+
+// writeCurvesFile writes curves.json for a document into dir.
+func writeCurvesFile(dir, docPath string, graphs []CurveGraph) error {
+	data, err := json.MarshalIndent(curvesFile{Source: docPath, Graphs: graphs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode curve graphs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, CurvesFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write curve graphs: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package pdfconv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestAxisNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"25", 25, true},
+		{"−40", -40, true},
+		{"0.5", 0.5, true},
+		{"10k", 10000, true},
+		{"100µ", 0.0001, true},
+		{"80%", 80, true},
+		{"1,000", 1000, true},
+		{"VDD", 0, false},
+		{"3.3 V", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := axisNumber(tt.text)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("axisNumber(%q) = %v, %v; want %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFitAxis(t *testing.T) {
+	y := func(l axisLabel) float64 { return l.base }
+	linear := []axisLabel{{value: 0, base: 100}, {value: 0.5, base: 150}, {value: 1, base: 200}, {value: 1.5, base: 250}}
+	scale, ok := fitAxis(linear, y)
+	if !ok || scale.log || math.Abs(scale.value(175)-0.75) > 1e-9 {
+		t.Errorf("expected a linear scale with 0.75 at 175, got %+v %v", scale, ok)
+	}
+	logarithmic := []axisLabel{{value: 1, base: 100}, {value: 10, base: 150}, {value: 100, base: 200}, {value: 1000, base: 250}}
+	scale, ok = fitAxis(logarithmic, y)
+	if !ok || !scale.log || math.Abs(scale.value(175)-31.6227766) > 1e-6 {
+		t.Errorf("expected a logarithmic scale with 31.6 at 175, got %+v %v", scale, ok)
+	}
+	// Minimum, typical and maximum values of a table row do not make an axis
+	if _, ok := fitAxis([]axisLabel{{value: 1.8, base: 100}, {value: 3.3, base: 150}, {value: 3.6, base: 200}}, y); ok {
+		t.Error("expected no scale for unevenly spaced values")
+	}
+	if _, ok := fitAxis([]axisLabel{{value: 1, base: 100}, {value: 3, base: 150}, {value: 2, base: 200}}, y); ok {
+		t.Error("expected no scale for values that are not monotonic")
+	}
+}
+
+func TestConvertPDF_CurveData(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "regulator.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	tr := doc.UnicodeTranslatorFromDescriptor("")
+	doc.SetFont("Helvetica", "", 8)
+	doc.AddPage()
+	// Specification table above the graph, whose numbers must not be taken for axes
+	for i, row := range [][]string{{"Parameter", "Min", "Typ", "Max"}, {"Supply voltage", "1.8", "3.3", "3.6"}, {"Quiescent current", "10", "25", "40"}} {
+		doc.SetXY(20, 20+float64(i)*5)
+		for j, cell := range row {
+			doc.CellFormat([]float64{50, 20, 20, 20}[j], 5, cell, "", 0, "L", false, 0, "")
+		}
+	}
+
+	// Plot area from x = 40 to 140 mm and y = 60 to 140 mm with a frame and grid lines
+	doc.Rect(40, 60, 100, 80, "D")
+	for y := 80.0; y < 140; y += 20 {
+		doc.Line(40, y, 140, y)
+	}
+	for i, label := range []string{"0", "0.5", "1.0", "1.5", "2.0"} {
+		doc.SetXY(28, 140-float64(i)*20-2)
+		doc.CellFormat(10, 4, label, "", 0, "R", false, 0, "")
+	}
+	for i, label := range []string{"-40", "0", "40", "80", "120"} {
+		doc.SetXY(35+float64(i)*25, 142)
+		doc.CellFormat(10, 4, label, "", 0, "C", false, 0, "")
+	}
+	doc.SetXY(40, 148)
+	doc.CellFormat(100, 4, tr("Ambient Temperature (°C)"), "", 0, "C", false, 0, "")
+	doc.TransformBegin()
+	doc.TransformRotate(90, 22, 115)
+	doc.Text(22, 115, "Power Dissipation (W)")
+	doc.TransformEnd()
+	doc.SetXY(40, 156)
+	doc.CellFormat(100, 4, "Figure 7. Power Derating", "", 0, "L", false, 0, "")
+
+	// A curve drawn as one path and one drawn as separate segments, labeled next to it
+	doc.SetDrawColor(255, 0, 0)
+	doc.MoveTo(40, 80)
+	doc.LineTo(90, 80)
+	doc.LineTo(127.5, 140)
+	doc.DrawPath("D")
+	doc.SetDrawColor(0, 0, 255)
+	doc.Line(40, 100, 90, 100)
+	doc.Line(90, 100, 115, 140)
+	doc.Text(93, 99, "2-layer board")
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create graph pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractTables: true, CurveData: true, TableMinConfidence: 0.5}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if len(res.Graphs) != 1 {
+		t.Fatalf("expected 1 graph, got %+v", res.Graphs)
+	}
+	graph := res.Graphs[0]
+	if graph.Kind != GraphDerating || graph.Title != "Figure 7. Power Derating" || graph.Page != 1 {
+		t.Errorf("unexpected graph: %+v", graph)
+	}
+	if graph.X.Label != "Ambient Temperature (°C)" || graph.X.Unit != "°C" || graph.X.Scale != ScaleLinear || graph.X.Min != -40 || graph.X.Max != 120 {
+		t.Errorf("unexpected x axis: %+v", graph.X)
+	}
+	if graph.Y.Label != "Power Dissipation (W)" || graph.Y.Unit != "W" || graph.Y.Min != 0 || graph.Y.Max != 2 {
+		t.Errorf("unexpected y axis: %+v", graph.Y)
+	}
+	if len(graph.Curves) != 2 || graph.Curves[0].Color != "#ff0000" || graph.Curves[0].Name != "Curve 1" || graph.Curves[1].Name != "2-layer board" {
+		t.Fatalf("unexpected curves: %+v", graph.Curves)
+	}
+
+	file, err := os.Open(filepath.Join(res.OutputDir, graph.Data))
+	if err != nil {
+		t.Fatalf("failed to open curve data: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid curve data: %v", err)
+	}
+	if len(records) != 7 || records[0][1] != "Ambient Temperature (°C)" || records[0][2] != "Power Dissipation (W)" {
+		t.Fatalf("unexpected curve data: %v", records)
+	}
+	want := [][2]float64{{-40, 1.5}, {40, 1.5}, {100, 0}, {-40, 1}, {40, 1}, {80, 0}}
+	for i, w := range want {
+		x, _ := axisNumber(records[i+1][1])
+		y, _ := axisNumber(records[i+1][2])
+		if math.Abs(x-w[0]) > 1.6 || math.Abs(y-w[1]) > 0.02 {
+			t.Errorf("point %d = (%v, %v); want about (%v, %v)", i+1, x, y, w[0], w[1])
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(res.OutputDir, CurvesFileName))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", CurvesFileName, err)
+	}
+	var index curvesFile
+	if err := json.Unmarshal(data, &index); err != nil || len(index.Graphs) != 1 || index.Graphs[0].Curves[1].Points != 3 {
+		t.Fatalf("unexpected %s content: %s", CurvesFileName, data)
+	}
+	if violations, err := ValidateSidecar(CurvesFileName, data); err != nil || len(violations) != 0 {
+		t.Errorf("expected %s to match its schema, got %v %v", CurvesFileName, err, violations)
+	}
+}
@@ -0,0 +1,169 @@
+// Package pdfconv - PDF file discovery.
+// This file walks input directory trees for PDF files, guarding against symlink cycles,
+// special files, hidden directories and pathologically large trees, and leaving out the
+// files excluded by .pdfmdignore files.
+package pdfconv
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pdfWalker holds the state of a single findPDFFiles walk.
+type pdfWalker struct {
+	c       *PDFConverter
+	visited map[string]bool // Resolved directories already walked, to break symlink cycles
+	listed  map[string]bool // Resolved files already listed, so a linked file is converted once
+	files   []string
+	limited bool          // Whether MAX_DISCOVERED_FILES stopped the walk
+	ignores []*ignoreFile // Ignore files of the directories walked so far, outermost first
+}
+
+// findPDFFiles returns the absolute paths of the supported documents (PDF, XPS, DjVu) below dir. Symlinks are skipped
+// unless FOLLOW_SYMLINKS is set, in which case each document is listed once, under the first
+// path found for it; hidden directories are skipped unless INCLUDE_HIDDEN_DIRS
+// is set, files and directories excluded by a .pdfmdignore file are skipped, and discovery
+// stops after MAX_DISCOVERED_FILES files (0 = unlimited).
+func (c *PDFConverter) findPDFFiles(dir string) ([]string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not get absolute path for %s: %v", dir, err)
+	}
+	// The input directory itself is always followed, even when it is a symlink
+	if info, err := os.Lstat(root); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		if target, err := filepath.EvalSymlinks(root); err == nil {
+			root = target
+		}
+	}
+	w := &pdfWalker{c: c, visited: map[string]bool{}, listed: map[string]bool{}}
+	w.walk(root)
+	if w.limited {
+		c.logger.Warn("Stopped searching %s after %d PDF files (MAX_DISCOVERED_FILES)", dir, len(w.files))
+	}
+	return w.files, nil
+}
+
+// walk collects the PDF files below root, which may be the target of a followed symlink.
+func (w *pdfWalker) walk(root string) {
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			w.c.logger.Warn("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") && !w.c.config.IncludeHiddenDirs {
+				return filepath.SkipDir
+			}
+			if path != root && ignored(w.ignores, path, true) {
+				w.c.logger.Debug("Skipping ignored directory %s", path)
+				return filepath.SkipDir
+			}
+			if w.seen(path) {
+				return filepath.SkipDir
+			}
+			w.readIgnoreFile(path)
+			return nil
+		}
+		supported := IsSupportedDocument(path)
+		mode := d.Type()
+		if (supported || mode&fs.ModeSymlink != 0) && ignored(w.ignores, path, false) {
+			w.c.logger.Debug("Skipping ignored file %s", path)
+			return nil
+		}
+		if mode&fs.ModeSymlink != 0 {
+			if !w.c.config.FollowSymlinks {
+				w.c.logger.Debug("Skipping symlink %s", path)
+				return nil
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				w.c.logger.Warn("Skipping broken symlink %s: %v", path, err)
+				return nil
+			}
+			if info.IsDir() {
+				// Walk the resolved directory; seen stops it when it was already walked
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					w.c.logger.Warn("Skipping symlink %s: %v", path, err)
+					return nil
+				}
+				w.walk(target)
+				if w.limited {
+					return filepath.SkipAll
+				}
+				return nil
+			}
+			mode = info.Mode()
+		}
+		if !supported {
+			return nil
+		}
+		if !mode.IsRegular() {
+			// Devices, pipes and sockets named like documents would block or fail on open
+			w.c.logger.Warn("Skipping special file %s", path)
+			return nil
+		}
+
+		if w.duplicate(path) {
+			return nil
+		}
+		if limit := w.c.config.MaxDiscoveredFiles; limit > 0 && len(w.files) >= limit {
+			w.limited = true
+			return filepath.SkipAll
+		}
+		w.files = append(w.files, path)
+		return nil
+	})
+}
+
+// readIgnoreFile adds the ignore file of dir, if any, to the rules of the walk. An unreadable
+// ignore file is reported and skipped.
+func (w *pdfWalker) readIgnoreFile(dir string) {
+	file, err := readIgnoreFile(dir)
+	if err != nil {
+		w.c.logger.Warn("Ignoring unreadable %s: %v", IgnoreFileName, err)
+		return
+	}
+	if file != nil {
+		w.ignores = append(w.ignores, file)
+	}
+}
+
+// duplicate records a file by its resolved path when symlinks are followed and reports
+// whether it was already listed under another path, such as a symlink to a listed document.
+func (w *pdfWalker) duplicate(path string) bool {
+	if !w.c.config.FollowSymlinks {
+		return false
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if w.listed[real] {
+		w.c.logger.Debug("Skipping %s: already listed through another path", path)
+		return true
+	}
+	w.listed[real] = true
+	return false
+}
+
+// seen records a directory by its resolved path when symlinks are followed and reports whether
+// it was already walked, so links back into the tree neither loop nor list files twice.
+func (w *pdfWalker) seen(dir string) bool {
+	if !w.c.config.FollowSymlinks {
+		return false
+	}
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	if w.visited[real] {
+		w.c.logger.Warn("Skipping directory %s: already visited through a symlink", dir)
+		return true
+	}
+	w.visited[real] = true
+	return false
+}
@@ -0,0 +1,74 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// writeDiscoveryTree creates root/a.pdf, root/sub/b.pdf, root/.hidden/c.pdf, a symlink
+// root/sub/loop pointing back at root, a symlink root/link.pdf to root/a.pdf and a symlink
+// root/outside.pdf to a PDF outside the tree.
+func writeDiscoveryTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, p := range []string{"a.pdf", filepath.Join("sub", "b.pdf"), filepath.Join(".hidden", "c.pdf")} {
+		path := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("%PDF-1.4\n%"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(root, filepath.Join(root, "sub", "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "a.pdf"), filepath.Join(root, "link.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "d.pdf")
+	if err := os.WriteFile(outside, []byte("%PDF-1.4\n%"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "outside.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestFindPDFFiles_Safety(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []string
+	}{
+		{"symlinks and hidden directories skipped", config.Config{}, []string{"a.pdf", "sub/b.pdf"}},
+		{"hidden directories included", config.Config{IncludeHiddenDirs: true}, []string{".hidden/c.pdf", "a.pdf", "sub/b.pdf"}},
+		// link.pdf resolves to a.pdf, which is listed already
+		{"symlinks followed without looping or duplicates", config.Config{FollowSymlinks: true}, []string{"a.pdf", "outside.pdf", "sub/b.pdf"}},
+		{"discovery limit", config.Config{MaxDiscoveredFiles: 1}, []string{"a.pdf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeDiscoveryTree(t)
+			conv, _ := NewPDFConverter(&tt.cfg, logger.NewLogger("error"))
+			found, err := conv.findPDFFiles(root)
+			if err != nil {
+				t.Fatalf("findPDFFiles() error = %v", err)
+			}
+			var names []string
+			for _, path := range found {
+				name, _ := filepath.Rel(root, path)
+				names = append(names, filepath.ToSlash(name))
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, names)
+			}
+		})
+	}
+}
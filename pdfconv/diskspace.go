@@ -0,0 +1,180 @@
+// Package pdfconv - Output disk space management.
+// This file estimates the output size of a conversion, checks it against the free space
+// of the output location, and prunes old conversions according to the retention policy.
+package pdfconv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Disk space estimation parameters
+const (
+	OutputSizeFactor     = 2                  // Markdown and metadata relative to the PDF size
+	ImageBytesPerPixel   = 3                  // Upper bound for PNG output of RGB images
+	MaxEstimatedImage    = 64 * 1024 * 1024   // Cap per image so corrupt dimensions do not block conversions
+	DiskSpaceSafetyBytes = 16 * 1024 * 1024   // Headroom kept free on the output volume
+	bytesPerGB           = 1024 * 1024 * 1024 // Conversion factor for MAX_OUTPUT_TOTAL_GB
+)
+
+// errDiskSpaceUnsupported is returned by freeDiskSpace on platforms without a free space query.
+var errDiskSpaceUnsupported = errors.New("free disk space query not supported on this platform")
+
+// estimateOutputSize estimates the bytes a conversion will write from the PDF size and
+// the dimensions of the images on the extracted pages.
+func (c *PDFConverter) estimateOutputSize(reader *pdf.Reader, pdfSize int64) int64 {
+	estimate := pdfSize * OutputSizeFactor
+	if !c.config.ExtractImages {
+		return estimate
+	}
+	_, imageBytes := c.pdfImageStats(reader)
+	return estimate + imageBytes
+}
+
+// pdfImageStats counts the image XObjects on all pages of the PDF and estimates their
+// uncompressed size from their dimensions.
+func (c *PDFConverter) pdfImageStats(reader *pdf.Reader) (count int, size int64) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Debug("Failed to inspect images for the size estimate: %v", r)
+		}
+	}()
+	tree := newPageTree(reader)
+	for n := 1; n <= reader.NumPage(); n++ {
+		page, err := tree.page(n)
+		if err != nil {
+			continue
+		}
+		xObjects := page.V.Key("Resources").Key("XObject")
+		for _, name := range xObjects.Keys() {
+			obj := xObjects.Key(name)
+			if obj.Key("Subtype").Name() != "Image" {
+				continue
+			}
+			imageSize := obj.Key("Width").Int64() * obj.Key("Height").Int64() * ImageBytesPerPixel
+			if imageSize <= 0 || imageSize > MaxEstimatedImage {
+				imageSize = MaxEstimatedImage
+			}
+			count++
+			size += imageSize
+		}
+	}
+	return count, size
+}
+
+// preflightDiskSpace runs the disk space check for a conversion of pdfPath when enabled.
+func (c *PDFConverter) preflightDiskSpace(reader *pdf.Reader, pdfPath, outputBaseDir string) error {
+	if !c.config.DiskSpaceCheck {
+		return nil
+	}
+	info, err := os.Stat(pdfPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat PDF: %v", err)
+	}
+	return c.checkDiskSpace(outputBaseDir, c.estimateOutputSize(reader, info.Size()))
+}
+
+// checkDiskSpace verifies that the volume holding outputBaseDir has room for the estimated
+// output. Platforms without a free space query skip the check.
+func (c *PDFConverter) checkDiskSpace(outputBaseDir string, needed int64) error {
+	// The output directory may not exist yet; query its nearest existing ancestor
+	dir := outputBaseDir
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		c.logger.Debug("Skipping disk space check for %s: %v", dir, err)
+		return nil
+	}
+	if uint64(needed+DiskSpaceSafetyBytes) > free {
+		return classify(ErrQuotaExceeded, fmt.Errorf("insufficient disk space in %s: %d MB free, about %d MB needed", dir, free/(1024*1024), (needed+DiskSpaceSafetyBytes)/(1024*1024)))
+	}
+	c.logger.Debug("Disk space check passed: %d MB free, about %d MB needed", free/(1024*1024), needed/(1024*1024))
+	return nil
+}
+
+// outputEntry is a conversion output directory considered by the retention policy.
+type outputEntry struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// pruneOutputs applies MAX_OUTPUT_AGE_DAYS and MAX_OUTPUT_TOTAL_GB to the MARKDOWN_*
+// directories in outputBaseDir, removing the oldest conversions first. The directory
+// named by keep, usually the conversion that just finished, is never removed, and neither
+// are outputs modified after since, the start of the pruning conversion or of its batch:
+// those were committed by conversions running concurrently or earlier in the same batch.
+func (c *PDFConverter) pruneOutputs(outputBaseDir, keep string, since time.Time) {
+	maxAge := c.config.MaxOutputAgeDays
+	maxTotal := int64(c.config.MaxOutputTotalGB * bytesPerGB)
+	if maxAge <= 0 && maxTotal <= 0 {
+		return
+	}
+
+	dirEntries, err := os.ReadDir(outputBaseDir)
+	if err != nil {
+		c.logger.Warn("Failed to read output directory for retention: %v", err)
+		return
+	}
+	var outputs []outputEntry
+	var total int64
+	for _, e := range dirEntries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "MARKDOWN_") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		entry := outputEntry{path: filepath.Join(outputBaseDir, e.Name()), modTime: info.ModTime(), size: dirSize(filepath.Join(outputBaseDir, e.Name()))}
+		total += entry.size
+		outputs = append(outputs, entry)
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].modTime.Before(outputs[j].modTime) })
+
+	cutoff := time.Now().AddDate(0, 0, -maxAge)
+	for _, entry := range outputs {
+		if filepath.Clean(entry.path) == filepath.Clean(keep) || entry.modTime.After(since) {
+			continue
+		}
+		expired := maxAge > 0 && entry.modTime.Before(cutoff)
+		oversize := maxTotal > 0 && total > maxTotal
+		if !expired && !oversize {
+			continue
+		}
+		if err := os.RemoveAll(entry.path); err != nil {
+			c.logger.Warn("Failed to remove old output %s: %v", entry.path, err)
+			continue
+		}
+		total -= entry.size
+		c.logger.Info("Removed old conversion output %s (retention policy)", entry.path)
+	}
+}
+
+// dirSize returns the total size of the regular files below dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package pdfconv
+
+// freeDiskSpace is not available on this platform; the disk space check is skipped.
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
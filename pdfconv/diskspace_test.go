@@ -0,0 +1,99 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	if _, err := freeDiskSpace(t.TempDir()); err != nil {
+		t.Skipf("free disk space query unavailable: %v", err)
+	}
+	missing := filepath.Join(t.TempDir(), "not", "created", "yet")
+	if err := conv.checkDiskSpace(missing, 1024); err != nil {
+		t.Errorf("expected small output to fit, got %v", err)
+	}
+	err := conv.checkDiskSpace(missing, 1<<62)
+	if err == nil || !strings.Contains(err.Error(), "insufficient disk space") || ErrorCode(err) != "quota_exceeded" {
+		t.Errorf("expected insufficient disk space error, got %v", err)
+	}
+}
+
+func TestPruneOutputs(t *testing.T) {
+	base := t.TempDir()
+	mkOutput := func(name string, size int, age time.Duration) string {
+		dir := filepath.Join(base, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "README.md"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		when := time.Now().Add(-age)
+		if err := os.Chtimes(dir, when, when); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	expired := mkOutput("MARKDOWN_expired", 10, 40*24*time.Hour)
+	oldest := mkOutput("MARKDOWN_oldest", 600, 3*time.Hour)
+	newer := mkOutput("MARKDOWN_newer", 600, 2*time.Hour)
+	current := mkOutput("MARKDOWN_current", 600, 100*24*time.Hour) // kept even though expired
+	unrelated := mkOutput("notes", 10, 400*24*time.Hour)
+
+	// A size limit of ~1300 bytes leaves room for two of the three 600 byte outputs
+	cfg := &config.Config{MaxOutputAgeDays: 30, MaxOutputTotalGB: 1300.0 / bytesPerGB}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	conv.pruneOutputs(base, current, time.Now())
+
+	for path, want := range map[string]bool{expired: false, oldest: false, newer: true, current: true, unrelated: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, want)
+		}
+	}
+}
+
+func TestPruneOutputs_SparesConcurrentOutputs(t *testing.T) {
+	inputDir, base := t.TempDir(), t.TempDir()
+	writeTitlePage(t, filepath.Join(inputDir, "first.pdf"), "First Regulator")
+	writeTitlePage(t, filepath.Join(inputDir, "second.pdf"), "Second Regulator")
+	stale := filepath.Join(base, "MARKDOWN_stale")
+	if err := os.MkdirAll(stale, 0755); err != nil {
+		t.Fatal(err)
+	}
+	when := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(stale, when, when); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cap of one byte is exceeded by every output, so only the time bound protects them
+	cfg := &config.Config{BaseHeaderLevel: 1, MaxOutputTotalGB: 1.0 / bytesPerGB}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	result, err := conv.ConvertPDFsInDirectory(inputDir, base)
+	if err != nil || result.SuccessCount != 2 {
+		t.Fatalf("batch conversion failed: %v %+v", err, result)
+	}
+	for _, r := range result.Results {
+		if _, err := os.Stat(r.OutputDir); err != nil {
+			t.Errorf("expected %s, committed earlier in the batch, kept: %v", filepath.Base(r.OutputDir), err)
+		}
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected the output older than the batch pruned, got %v", err)
+	}
+
+	// A conversion that started before another one committed leaves that output alone
+	started := time.Now().Add(-time.Minute)
+	keep := result.Results[0].OutputDir
+	conv.pruneOutputs(base, keep, started)
+	if _, err := os.Stat(result.Results[1].OutputDir); err != nil {
+		t.Errorf("expected the output committed after the pruning conversion started kept: %v", err)
+	}
+}
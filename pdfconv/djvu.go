@@ -0,0 +1,220 @@
+// Package pdfconv - DjVu input.
+// This file transcodes DjVu documents with the DjVuLibre command line tools: djvused for the
+// page count, djvutxt for the hidden text layer and ddjvu for page images.
+package pdfconv
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConvertDjVu converts a DjVu document to Markdown. Each page contributes its text layer and,
+// when image extraction is enabled, a rendering of the page, since DjVu pages are scans.
+// It requires the DjVuLibre tools on PATH.
+func (c *PDFConverter) ConvertDjVu(djvuPath, outputBaseDir string, opts ConversionOptions) (*ConversionResult, error) {
+	c.logger.Info("Starting DjVu conversion: %s", djvuPath)
+	opts.started = time.Now()
+
+	djvuPath, outputBaseDir, err := cleanInputPaths(djvuPath, outputBaseDir)
+	if err != nil {
+		return nil, err
+	}
+	pageCount, err := djvuPageCount(djvuPath)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Info("DjVu opened successfully, %d pages found", pageCount)
+
+	if err := c.preflightFileSize(djvuPath, outputBaseDir); err != nil {
+		return nil, err
+	}
+
+	ctx := opts.context()
+	opts.render = func(pageNum, _ int) (image.Image, error) { return c.renderDjVuPage(ctx, djvuPath, pageNum) }
+	return c.generateOutput(djvuPath, outputBaseDir, opts, func(stagingDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+		return c.extractDjVuPages(ctx, djvuPath, pageCount, opts.Pages, stagingDir, timings)
+	})
+}
+
+// extractDjVuPages converts each DjVu page into the page model shared with PDF conversion,
+// skipping the pages outside selection unless it is nil.
+func (c *PDFConverter) extractDjVuPages(ctx context.Context, djvuPath string, pageCount int, selection PageSelection, outputDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+	var pages []PDFPage
+	totalImages := 0
+	for pageNum := 1; pageNum <= pageCount; pageNum++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		if selection != nil && !selection.Contains(pageNum) {
+			continue
+		}
+		c.logger.Debug("Processing page %d/%d", pageNum, pageCount)
+		page := PDFPage{Number: pageNum, Images: []PDFImage{}}
+
+		textStart := time.Now()
+		out, err := exec.CommandContext(ctx, "djvutxt", "--page="+strconv.Itoa(pageNum), djvuPath).Output()
+		if err != nil {
+			c.logger.Warn("Failed to extract text from page %d: %v", pageNum, err)
+		}
+		page.Text = strings.TrimSpace(strings.ReplaceAll(string(out), "\f", ""))
+		timings.record(phaseText, textStart)
+		c.checkTextLayer(&page, func() (image.Image, error) { return c.renderDjVuPage(ctx, djvuPath, pageNum) }, timings)
+
+		if c.config.ExtractImages {
+			imageStart := time.Now()
+			img, err := c.renderDjVuPage(ctx, djvuPath, pageNum)
+			if err != nil {
+				c.logger.Warn("Failed to render page %d: %v", pageNum, err)
+				page.ImageFailures++
+			} else {
+				page.Redactions = detectBlackouts(ctx, img, pageNum)
+				filename, err := c.saveHashedImage(img, outputDir, ImageFilePrefix)
+				if err != nil {
+					c.logger.Warn("Failed to save image of page %d: %v", pageNum, err)
+					page.ImageFailures++
+				} else {
+					imagePath := filepath.Join(outputDir, filename)
+					page.Images = append(page.Images, PDFImage{
+						Data:     img,
+						Width:    img.Bounds().Dx(),
+						Height:   img.Bounds().Dy(),
+						Filename: filename,
+						Diagrams: c.detectImageDiagrams(ctx, img, imagePath),
+						PageScan: true,
+					})
+					totalImages++
+				}
+			}
+			timings.record(phaseImages, imageStart)
+		}
+		pages = append(pages, page)
+	}
+	finishStart := time.Now()
+	c.finishPages(pages)
+	timings.record(phaseText, finishStart)
+	c.logger.Info("Extracted content from %d pages, %d images total", len(pages), totalImages)
+	return pages, totalImages, nil
+}
+
+// djvuPageCount returns the number of pages reported by djvused.
+func djvuPageCount(djvuPath string) (int, error) {
+	if _, err := exec.LookPath("djvused"); err != nil {
+		return 0, fmt.Errorf("DjVu conversion requires the DjVuLibre tools (djvused, djvutxt, ddjvu): %v", err)
+	}
+	out, err := exec.Command("djvused", "-e", "n", djvuPath).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open DjVu document: %v", err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("failed to read DjVu page count from %q", strings.TrimSpace(string(out)))
+	}
+	return count, nil
+}
+
+// djvuRenderSize is the box ddjvu scales rendered pages into, keeping their aspect ratio. Its
+// area stays within MaxImagePixels, about 200 dpi for a letter page, so full resolution scans
+// of 600 dpi and more are not rejected by decodePPM.
+const djvuRenderSize = "1700x2350"
+
+// renderDjVuPage renders a 1-based page with ddjvu as a binary PPM image.
+func (c *PDFConverter) renderDjVuPage(ctx context.Context, djvuPath string, pageNum int) (image.Image, error) {
+	dir, err := c.makeTempDir("djvu")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create render directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "page.ppm")
+	cmd := exec.CommandContext(ctx, "ddjvu", "-format=ppm", "-size="+djvuRenderSize, "-page="+strconv.Itoa(pageNum), djvuPath, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ddjvu failed: %v: %s", err, out)
+	}
+	file, err := os.Open(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rendered page: %v", err)
+	}
+	defer file.Close()
+	return decodePPM(ctx, file)
+}
+
+// decodePPM decodes a binary (P6) PPM image with 8-bit samples, as written by ddjvu.
+// Images larger than MaxImagePixels are rejected before any pixel memory is allocated.
+// Decoding stops with the context's error when ctx is done.
+func decodePPM(ctx context.Context, r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	var header [4]int
+	magic, err := ppmToken(br)
+	if err != nil {
+		return nil, err
+	}
+	if magic != "P6" {
+		return nil, fmt.Errorf("unsupported PNM format %q", magic)
+	}
+	for i := 1; i < 4; i++ {
+		tok, err := ppmToken(br)
+		if err != nil {
+			return nil, err
+		}
+		if header[i], err = strconv.Atoi(tok); err != nil {
+			return nil, fmt.Errorf("invalid PPM header: %v", err)
+		}
+	}
+	width, height, maxVal := header[1], header[2], header[3]
+	if width <= 0 || height <= 0 || width > MaxImageWidth || height > MaxImageHeight || maxVal <= 0 || maxVal > 255 {
+		return nil, fmt.Errorf("unsupported PPM dimensions %dx%d (max value %d)", width, height, maxVal)
+	}
+	if width*height > MaxImagePixels {
+		return nil, fmt.Errorf("PPM image too large (%dx%d = %d pixels, limit %d)", width, height, width*height, MaxImagePixels)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	row := make([]byte, width*3)
+	for y := 0; y < height; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(br, row); err != nil {
+			return nil, fmt.Errorf("truncated PPM data: %v", err)
+		}
+		pix := img.Pix[y*img.Stride : y*img.Stride+width*4]
+		for x := 0; x < width; x++ {
+			pix[x*4], pix[x*4+1], pix[x*4+2], pix[x*4+3] = row[x*3], row[x*3+1], row[x*3+2], 255
+		}
+	}
+	return img, nil
+}
+
+// ppmToken reads the next whitespace-separated header token, skipping comments. The single
+// whitespace byte after the token is consumed, so after the last header value the reader is
+// positioned at the pixel data.
+func ppmToken(br *bufio.Reader) (string, error) {
+	var tok []byte
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return "", fmt.Errorf("truncated PPM header: %v", err)
+		}
+		switch {
+		case b == '#' && len(tok) == 0:
+			if _, err := br.ReadString('\n'); err != nil {
+				return "", fmt.Errorf("truncated PPM header: %v", err)
+			}
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			if len(tok) > 0 {
+				return string(tok), nil
+			}
+		default:
+			tok = append(tok, b)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package pdfconv
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestDecodePPM(t *testing.T) {
+	data := append([]byte("P6\n# rendered by ddjvu\n2 1\n255\n"), 255, 0, 0, 0, 0, 255)
+	img, err := decodePPM(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decodePPM() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("expected 2x1 image, got %v", b)
+	}
+	if got := color.RGBAModel.Convert(img.At(1, 0)).(color.RGBA); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("unexpected pixel %v", got)
+	}
+
+	if _, err := decodePPM(context.Background(), strings.NewReader("P5\n2 1\n255\n\x00\x00")); err == nil {
+		t.Errorf("expected error for unsupported PNM format")
+	}
+	if _, err := decodePPM(context.Background(), strings.NewReader("P6\n2 2\n255\n\x00")); err == nil {
+		t.Errorf("expected error for truncated pixel data")
+	}
+	// Within the width and height limits, but over MaxImagePixels; rejected from the header alone
+	if _, err := decodePPM(context.Background(), strings.NewReader("P6\n10000 10000\n255\n")); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("expected an oversized image rejected, got %v", err)
+	}
+}
+
+func TestConvertDjVu_RequiresDjVuLibre(t *testing.T) {
+	if _, err := exec.LookPath("djvused"); err == nil {
+		t.Skip("DjVuLibre is installed")
+	}
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	path := createTempValidPDF(t) // any existing file; the tool check comes first
+	if _, err := conv.ConvertDjVu(path, t.TempDir(), ConversionOptions{}); err == nil || !strings.Contains(err.Error(), "DjVuLibre") {
+		t.Errorf("expected missing DjVuLibre error, got %v", err)
+	}
+}
+
+func TestCleanInputPaths_EmptyInput(t *testing.T) {
+	if _, _, err := cleanInputPaths(" ", t.TempDir()); err == nil || err.Error() != "input path cannot be empty" {
+		t.Errorf("expected an empty input path error, got %v", err)
+	}
+}
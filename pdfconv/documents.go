@@ -0,0 +1,289 @@
+// Package pdfconv - Input document formats.
+// This file dispatches conversions by file format and holds the steps shared by the PDF,
+// XPS and DjVu front-ends, which all extract into the same page model.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"datasheet-to-md-mcp/uml"
+)
+
+// documentFormats maps the supported input file extensions to their format name.
+var documentFormats = map[string]string{
+	".pdf":  "pdf",
+	".xps":  "xps",
+	".oxps": "xps",
+	".djvu": "djvu",
+	".djv":  "djvu",
+}
+
+// IsSupportedDocument reports whether the file extension is one of the supported input formats.
+func IsSupportedDocument(path string) bool {
+	_, ok := documentFormats[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// SupportedDocumentExtensions returns the supported input file extensions in sorted order.
+func SupportedDocumentExtensions() []string {
+	exts := make([]string, 0, len(documentFormats))
+	for ext := range documentFormats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// ConvertDocument converts a PDF, XPS or DjVu file to Markdown, choosing the front-end by
+// file extension. XPS and DjVu pages go through the same Markdown pipeline as PDF pages. A
+// document settings file next to the document overrides settings for it.
+func (c *PDFConverter) ConvertDocument(docPath, outputBaseDir string, opts ConversionOptions) (*ConversionResult, error) {
+	conv, opts, err := c.withDocumentSettings(docPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	switch documentFormats[strings.ToLower(filepath.Ext(docPath))] {
+	case "pdf":
+		return conv.ConvertPDFWithOptions(docPath, outputBaseDir, opts)
+	case "xps":
+		return conv.ConvertXPS(docPath, outputBaseDir, opts)
+	case "djvu":
+		if err := conv.RequireCapability(CapabilityDjVu); err != nil {
+			return nil, fmt.Errorf("DjVu input is disabled: %v", err)
+		}
+		return conv.ConvertDjVu(docPath, outputBaseDir, opts)
+	}
+	return nil, fmt.Errorf("unsupported document format: %s (supported: %s)", docPath, strings.Join(SupportedDocumentExtensions(), ", "))
+}
+
+// cleanInputPaths validates and cleans the input file and output base directory paths.
+func cleanInputPaths(docPath, outputBaseDir string) (string, string, error) {
+	if strings.TrimSpace(docPath) == "" {
+		return "", "", fmt.Errorf("input path cannot be empty")
+	}
+	if strings.TrimSpace(outputBaseDir) == "" {
+		return "", "", fmt.Errorf("output base directory cannot be empty")
+	}
+	docPath = filepath.Clean(docPath)
+	outputBaseDir = filepath.Clean(outputBaseDir)
+
+	fileInfo, err := os.Stat(docPath)
+	if os.IsNotExist(err) {
+		return "", "", fmt.Errorf("input file does not exist: %s", docPath)
+	}
+	// Check if it's actually a file, not a directory
+	if err == nil && fileInfo.IsDir() {
+		return "", "", fmt.Errorf("path is a directory, not a file: %s", docPath)
+	}
+	return docPath, outputBaseDir, nil
+}
+
+// generateOutput extracts the document pages into a staging directory, writes the Markdown
+// and moves the finished output into place as MARKDOWN_<name>. The time since opts.started
+// counts as opening the document; extract records its phases in the timings it is given.
+func (c *PDFConverter) generateOutput(docPath, outputBaseDir string, opts ConversionOptions, extract func(stagingDir string, timings *PhaseTimings) ([]PDFPage, int, error)) (*ConversionResult, error) {
+	if opts.started.IsZero() {
+		opts.started = time.Now()
+	}
+	opts.timings = &PhaseTimings{}
+	opts.timings.record(phaseOpen, opts.started)
+
+	// Generate into a staging directory so a crash never leaves a partial MARKDOWN_<name>
+	stagingDir, outputDir, err := c.createStagingDirectory(docPath, outputBaseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	defer os.RemoveAll(stagingDir) // no-op once committed
+
+	pages, totalImages, err := extract(stagingDir, opts.timings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract document content: %w", err)
+	}
+	if len(pages) == 0 && opts.Pages != nil {
+		return nil, fmt.Errorf("page selection %s matches no page of the document", opts.Pages)
+	}
+	if err := c.checkStrict(pages); err != nil {
+		return nil, err
+	}
+	reused := 0
+	for i := range pages {
+		pages[i].Verbatim = opts.verbatimPage(pages[i].Number)
+		if pages[i].Reused {
+			reused++
+		}
+	}
+	if reused > 0 {
+		c.logger.Info("Reused %d unchanged page(s) of %d from the previous output", reused, len(pages))
+	}
+	languages := c.segmentLanguages(pages, c.documentLanguage(opts.language))
+	c.describeImages(pages, stagingDir, opts)
+	c.prepareAccessiblePages(pages)
+
+	markdownStart := time.Now()
+	variants := c.collectVariants(pages)
+	packages := c.collectPackages(pages)
+	graphs := collectGraphs(pages)
+	bom := c.collectBOM(pages)
+	markdownContent := c.accessibleMarkdown(c.generateMarkdown(pages)+c.variantMarkdown(variants)+c.bomMarkdown(bom), c.documentLanguage(opts.language))
+	compliance := c.collectCompliance(markdownContent)
+	markdownContent = complianceFrontMatter(markdownContent, compliance)
+	quality := assessQuality(pages)
+	redactionSections(markdownContent, quality.Redactions)
+
+	documentName, err := c.writeDocument(stagingDir, docPath, markdownContent, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write Markdown file: %v", err)
+	}
+	if len(variants) > 0 {
+		if err := writeVariantsFile(stagingDir, docPath, variants); err != nil {
+			return nil, err
+		}
+	}
+	if len(packages) > 0 {
+		if err := writePackageFile(stagingDir, docPath, packages); err != nil {
+			return nil, err
+		}
+	}
+	if len(graphs) > 0 {
+		if err := writeCurvesFile(stagingDir, docPath, graphs); err != nil {
+			return nil, err
+		}
+	}
+	if compliance != nil {
+		if err := writeComplianceFile(stagingDir, docPath, compliance); err != nil {
+			return nil, err
+		}
+	}
+	if len(bom) > 0 {
+		if err := writeBOMFile(stagingDir, bom); err != nil {
+			return nil, err
+		}
+	}
+	images := imageFiles(pages)
+	if c.config.DetectDiagrams && len(images) > 0 {
+		if err := writeImagesFile(stagingDir, docPath, images); err != nil {
+			return nil, err
+		}
+	}
+	brokenLinks := checkMarkdownLinks(stagingDir, documentName, markdownContent)
+	if documentName == formatFileNames[FormatMarkdown] {
+		if brokenLinks, err = checkLinks(stagingDir, documentName); err != nil {
+			return nil, err
+		}
+	}
+	c.logBrokenLinks(docPath, brokenLinks)
+	opts.timings.record(phaseMarkdown, markdownStart)
+	failures := pageFailures(pages)
+	tables, diagrams := pageContentCounts(pages)
+	result := &ConversionResult{Source: docPath, Status: conversionStatus(failures), FailedPages: failures, OutputDir: outputDir, MarkdownFile: filepath.Join(outputDir, documentName), ImageCount: totalImages, PageCount: len(pages), TableCount: tables, DiagramCount: diagrams, Quality: quality, Repaired: opts.repaired, BrokenLinks: brokenLinks, Variants: variants, Packages: packages, Graphs: graphs, BOM: bom, Compliance: compliance, Pages: pageStatistics(pages), Images: images, Languages: languages, ReusedPages: reused, Duration: time.Since(opts.started), Timings: *opts.timings}
+	if name := c.writeThumbnail(stagingDir, opts.render); name != "" {
+		result.Thumbnail = filepath.Join(outputDir, name)
+	}
+	if c.config.ChangeReport {
+		if result.Changes, err = c.writeChangeReport(stagingDir, outputDir, docPath, markdownContent); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.writeConversionReport(stagingDir, docPath, *result); err != nil {
+		return nil, err
+	}
+	if err := c.commitOutputDirectory(stagingDir, outputDir); err != nil {
+		return nil, err
+	}
+
+	if len(failures) > 0 {
+		c.logger.Warn("Conversion partially completed: %s (%d of %d page(s) failed, quality %.1f/100) in %s: %s", docPath, len(failures), len(pages), result.Quality.Score, FormatDuration(result.Duration), result.Timings)
+	} else {
+		c.logger.Info("Conversion completed successfully: %s (quality %.1f/100) in %s: %s", docPath, result.Quality.Score, FormatDuration(result.Duration), result.Timings)
+	}
+	c.pruneOutputs(outputBaseDir, outputDir, opts.pruneSince())
+	return result, nil
+}
+
+// finishPages runs the passes over all extracted pages: removal of the lines matching the
+// strip patterns of a document settings file, number and date normalization for the
+// configured locale, removal of running headers and footers and joining of sentences across
+// page breaks, then merging and formatting of tables.
+func (c *PDFConverter) finishPages(pages []PDFPage) {
+	c.stripLines(pages)
+	c.normalizeNumberLocale(pages)
+	c.joinPageBreaks(pages)
+	c.finishTables(pages)
+}
+
+// preflightFileSize runs the disk space check for formats whose images cannot be inspected
+// up front, estimating the output from the input file size alone.
+func (c *PDFConverter) preflightFileSize(docPath, outputBaseDir string) error {
+	if !c.config.DiskSpaceCheck {
+		return nil
+	}
+	info, err := os.Stat(docPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat document: %v", err)
+	}
+	return c.checkDiskSpace(outputBaseDir, info.Size()*OutputSizeFactor)
+}
+
+// detectImageDiagrams runs diagram detection on a saved image when enabled. Images smaller
+// than DIAGRAM_MIN_SIZE or with less line detail than DIAGRAM_MIN_EDGE_DENSITY, such as
+// logos, are skipped. The axis labels and legend of detected plots, and the labels of
+// diagrams rendered with a PlantUML template, are read with OCR when tesseract is installed.
+func (c *PDFConverter) detectImageDiagrams(ctx context.Context, img image.Image, imagePath string) []uml.DetectedDiagram {
+	if !c.config.DetectDiagrams {
+		return nil
+	}
+	if size := c.config.DiagramMinSize; img.Bounds().Dx() < size || img.Bounds().Dy() < size {
+		c.logger.Debug("Skipping diagram detection for %s: %dx%d is below DIAGRAM_MIN_SIZE", filepath.Base(imagePath), img.Bounds().Dx(), img.Bounds().Dy())
+		return nil
+	}
+	if c.config.DiagramMinEdgeDensity > 0 {
+		if density := uml.EdgeDensity(img); density < c.config.DiagramMinEdgeDensity {
+			c.logger.Debug("Skipping diagram detection for %s: edge density %.3f is below DIAGRAM_MIN_EDGE_DENSITY", filepath.Base(imagePath), density)
+			return nil
+		}
+	}
+	diagrams, err := c.diagramDetector.DetectDiagramsInImage(ctx, imagePath)
+	if err != nil {
+		c.logger.Warn("Failed to analyze image %s for diagrams: %v", imagePath, err)
+		return nil
+	}
+	for i := range diagrams {
+		if diagrams[i].Omitted || diagrams[i].Plot == nil && c.config.PlantUMLTemplateDir == "" || !ocrAvailable() {
+			continue
+		}
+		words, err := c.ocrWords(imagePath)
+		if err != nil {
+			c.logger.Debug("OCR of diagram labels failed for %s: %v", filepath.Base(imagePath), err)
+			continue
+		}
+		if diagrams[i].Plot != nil {
+			c.diagramDetector.LabelPlot(&diagrams[i], words)
+		} else if err := c.diagramDetector.LabelDiagram(&diagrams[i], words); err != nil {
+			c.logger.Warn("Failed to label diagram in %s: %v", filepath.Base(imagePath), err)
+		}
+	}
+	if len(diagrams) > 0 {
+		c.logger.Info("Found %d diagram(s) in %s", len(diagrams), filepath.Base(imagePath))
+	}
+	return diagrams
+}
+
+// detectLineTables reconstructs tables from positioned lines for formats that cannot render
+// page regions; low-confidence tables fall back to their raw text.
+func (c *PDFConverter) detectLineTables(lines []TextLine, pageNum int) []PDFTable {
+	tables := detectTables(lines)
+	for i := range tables {
+		if tables[i].Confidence < c.config.TableMinConfidence {
+			tables[i].Fallback = true
+			c.logger.Warn("Table on page %d has low reconstruction confidence (%.2f), using fallback", pageNum, tables[i].Confidence)
+		}
+	}
+	return tables
+}
@@ -0,0 +1,184 @@
+// Package pdfconv - Duplicate text layer detection.
+// This file finds pages whose text is drawn twice at the same position, typically a scan that
+// was made searchable again on top of an existing OCR or digital text layer, and keeps a
+// single copy so the Markdown does not repeat every line.
+package pdfconv
+
+import (
+	"math"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Duplicate text layer thresholds
+const (
+	duplicateMinWords  = 3   // Fewer overlapping words are incidental, not a second layer
+	duplicateMinMatch  = 0.5 // Share of overlapping words whose text must match
+	duplicateMinCover  = 0.5 // Fraction of the smaller word box that must be covered to overlap
+	duplicateWordSpace = 0.2 // Horizontal gap, in font sizes, that separates words
+)
+
+// Duplicate text copies
+const (
+	DuplicateKeptFirst  = "first"  // The copy drawn first was kept
+	DuplicateKeptSecond = "second" // The copy drawn later was kept
+)
+
+// DuplicateText records a page whose text was drawn twice and which copy was kept.
+type DuplicateText struct {
+	Page              int     `json:"page"`
+	Words             int     `json:"words"`              // Words drawn over another word
+	Kept              string  `json:"kept"`               // DuplicateKeptFirst or DuplicateKeptSecond
+	KeptConfidence    float64 `json:"kept_confidence"`    // Text plausibility of the kept copy
+	DroppedConfidence float64 `json:"dropped_confidence"` // Text plausibility of the dropped copy
+}
+
+// textWord is a word of positioned glyphs with its bounding box. Glyphs of fonts without
+// widths are all placed at the origin of their string, so such strings are kept whole as a
+// single unmeasured word located at that origin.
+type textWord struct {
+	first, last    int // Glyph index range in the run list, inclusive
+	text           string
+	words          int // Words in text, more than 1 only for unmeasured strings
+	x0, x1, y0, y1 float64
+	measured       bool // Whether the glyphs have widths and the box is known
+	partner        int  // Index of the earlier word this word is drawn over, -1 for none
+}
+
+// removeDuplicateText detects a second text layer drawn over the first one and returns the
+// runs of the page with one copy removed. Words drawn over an earlier word belong to the later
+// copy; when enough of them repeat the text underneath, the copy whose text scores higher with
+// textConfidence is kept (the first one on ties). The returned record is nil when the page has
+// a single text layer, in which case runs are returned unchanged.
+func (c *PDFConverter) removeDuplicateText(runs []pdf.Text, pageNum int) ([]pdf.Text, *DuplicateText) {
+	words := splitTextWords(runs)
+	overlapping, matching := 0, 0
+	paired := make([]bool, len(words))
+	for j := range words {
+		for i := 0; i < j; i++ {
+			if paired[i] || words[i].partner >= 0 || !drawnOver(words[i], words[j]) {
+				continue
+			}
+			words[j].partner = i
+			paired[i] = true
+			overlapping += words[j].words
+			if strings.EqualFold(words[i].text, words[j].text) {
+				matching += words[j].words
+			}
+			break
+		}
+	}
+	if overlapping < duplicateMinWords || float64(matching) < float64(overlapping)*duplicateMinMatch {
+		return runs, nil
+	}
+
+	var firstCopy, secondCopy []string
+	for _, word := range words {
+		if word.partner >= 0 {
+			firstCopy = append(firstCopy, words[word.partner].text)
+			secondCopy = append(secondCopy, word.text)
+		}
+	}
+	dup := &DuplicateText{Page: pageNum, Words: overlapping, Kept: DuplicateKeptFirst}
+	first, second := textConfidence(strings.Join(firstCopy, " ")), textConfidence(strings.Join(secondCopy, " "))
+	dup.KeptConfidence, dup.DroppedConfidence = first, second
+	if second > first {
+		dup.Kept, dup.KeptConfidence, dup.DroppedConfidence = DuplicateKeptSecond, second, first
+	}
+
+	drop := make([]bool, len(runs))
+	for _, word := range words {
+		if word.partner < 0 {
+			continue
+		}
+		dropped := word
+		if dup.Kept == DuplicateKeptSecond {
+			dropped = words[word.partner]
+		}
+		k := dropped.first
+		for ; k <= dropped.last; k++ {
+			drop[k] = true
+		}
+		// The spaces after a dropped word belong to the dropped copy as well
+		for ; k < len(runs) && strings.TrimSpace(runs[k].S) == ""; k++ {
+			drop[k] = true
+		}
+	}
+	kept := make([]pdf.Text, 0, len(runs))
+	for k, run := range runs {
+		if !drop[k] {
+			kept = append(kept, run)
+		}
+	}
+	c.logger.Info("Page %d draws its text twice (%d overlapping words); kept the %s copy (confidence %.2f, dropped %.2f)", pageNum, overlapping, dup.Kept, dup.KeptConfidence, dup.DroppedConfidence)
+	return kept, dup
+}
+
+// splitTextWords groups glyphs into words in content stream order. A word ends at a space, a
+// change of font or line, or a horizontal jump.
+func splitTextWords(runs []pdf.Text) []textWord {
+	var words []textWord
+	var current *textWord
+	var builder strings.Builder
+	finish := func() {
+		if current != nil {
+			current.text = strings.TrimSpace(builder.String())
+			current.words = len(strings.Fields(current.text))
+			if current.words > 0 {
+				words = append(words, *current)
+			}
+			current = nil
+			builder.Reset()
+		}
+	}
+	for k, run := range runs {
+		size := math.Max(run.FontSize, 1)
+		measured := run.W > 0
+		if current != nil {
+			prev := runs[current.last]
+			switch {
+			case current.measured != measured || prev.Font != run.Font:
+				finish()
+			case !measured && (prev.X != run.X || prev.Y != run.Y):
+				finish()
+			case measured && (math.Abs(prev.Y-run.Y) > size*0.5 || run.X < prev.X || run.X > current.x1+size*duplicateWordSpace):
+				finish()
+			}
+		}
+		if measured && strings.TrimSpace(run.S) == "" {
+			finish()
+			continue
+		}
+		if current == nil {
+			current = &textWord{first: k, x0: run.X, x1: run.X, y0: run.Y, y1: run.Y + size, measured: measured, partner: -1}
+		}
+		current.last = k
+		current.x0 = math.Min(current.x0, run.X)
+		current.x1 = math.Max(current.x1, run.X+run.W)
+		current.y0 = math.Min(current.y0, run.Y)
+		current.y1 = math.Max(current.y1, run.Y+size)
+		builder.WriteString(run.S)
+	}
+	finish()
+	return words
+}
+
+// drawnOver reports whether word b is drawn over the earlier word a. Measured words must
+// cover each other, measured against the smaller box so a word over part of a longer word
+// still counts; unmeasured strings must start at the same point with the same text.
+func drawnOver(a, b textWord) bool {
+	if a.measured != b.measured {
+		return false
+	}
+	if !a.measured {
+		return math.Abs(a.x0-b.x0) < 1 && math.Abs(a.y0-b.y0) < 1 && strings.EqualFold(a.text, b.text)
+	}
+	w := math.Min(a.x1, b.x1) - math.Max(a.x0, b.x0)
+	h := math.Min(a.y1, b.y1) - math.Max(a.y0, b.y0)
+	if w <= 0 || h <= 0 {
+		return false
+	}
+	smaller := math.Min((a.x1-a.x0)*(a.y1-a.y0), (b.x1-b.x0)*(b.y1-b.y0))
+	return smaller > 0 && w*h >= smaller*duplicateMinCover
+}
@@ -0,0 +1,98 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/ledongthuc/pdf"
+)
+
+// glyphRuns lays out text as 6pt wide glyphs in the given font, starting at x, y.
+func glyphRuns(font string, x, y float64, text string) []pdf.Text {
+	var runs []pdf.Text
+	for _, r := range text {
+		runs = append(runs, pdf.Text{Font: font, FontSize: 12, X: x, Y: y, W: 6, S: string(r)})
+		x += 6
+	}
+	return runs
+}
+
+func runsText(runs []pdf.Text) string {
+	var b strings.Builder
+	for _, run := range runs {
+		b.WriteString(run.S)
+	}
+	return b.String()
+}
+
+func TestRemoveDuplicateText(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	visible := "Supply voltage range for all outputs"
+
+	// A single layer is left alone, including repeated words at other positions
+	single := append(glyphRuns("Helvetica", 72, 700, visible), glyphRuns("Helvetica", 72, 680, visible)...)
+	if runs, dup := conv.removeDuplicateText(single, 1); dup != nil || len(runs) != len(single) {
+		t.Errorf("expected single layer to be unchanged, got %+v", dup)
+	}
+
+	// An identical OCR layer drawn over the text is dropped and the first copy kept
+	doubled := append(glyphRuns("Helvetica", 72, 700, visible), glyphRuns("GlyphLessFont", 72, 700, visible)...)
+	runs, dup := conv.removeDuplicateText(doubled, 2)
+	if dup == nil || dup.Kept != DuplicateKeptFirst || dup.Words != 6 || dup.Page != 2 {
+		t.Fatalf("expected duplicate layer keeping the first copy, got %+v", dup)
+	}
+	if got := runsText(runs); got != visible {
+		t.Errorf("expected %q after removing the duplicate, got %q", visible, got)
+	}
+
+	// A garbled first layer loses against a readable copy drawn over it
+	garbled := "Sxqzvbnm vxltkgp rqnzx fxr qll xxtpxts"
+	doubled = append(glyphRuns("Helvetica", 72, 700, garbled), glyphRuns("GlyphLessFont", 72, 700, visible)...)
+	doubled = append(doubled, glyphRuns("GlyphLessFont", 72, 680, "voltage range")...)
+	// Matching words are required: the garbled copy shares none, so the layers are not merged
+	if _, dup := conv.removeDuplicateText(doubled, 3); dup != nil {
+		t.Errorf("expected overlapping but different text to be kept, got %+v", dup)
+	}
+	mixed := "Supply vxltkgp range fxr all xxtpxts"
+	doubled = append(glyphRuns("Helvetica", 72, 700, mixed), glyphRuns("GlyphLessFont", 72, 700, visible)...)
+	runs, dup = conv.removeDuplicateText(doubled, 4)
+	if dup == nil || dup.Kept != DuplicateKeptSecond || dup.KeptConfidence <= dup.DroppedConfidence {
+		t.Fatalf("expected the readable second copy to be kept, got %+v", dup)
+	}
+	if got := runsText(runs); got != visible {
+		t.Errorf("expected %q after removing the garbled copy, got %q", visible, got)
+	}
+}
+
+func TestConvertPDF_DuplicateTextLayer(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "doubled.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.AddPage()
+	// The searchable layer is drawn in its own font over the same position
+	for _, font := range []string{"Arial", "Times"} {
+		doc.SetFont(font, "", 12)
+		doc.Text(20, 30, "Absolute maximum ratings of the device")
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatal(err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if n := strings.Count(string(md), "Absolute maximum ratings"); n != 1 {
+		t.Errorf("expected the text once, found %d times:\n%s", n, md)
+	}
+	if dups := res.Quality.DuplicateText; len(dups) != 1 || dups[0].Page != 1 || dups[0].Kept != DuplicateKeptFirst {
+		t.Errorf("expected a duplicate text record for page 1, got %+v", dups)
+	}
+}
@@ -0,0 +1,335 @@
+// Package pdfconv - Errata linking.
+// This file relates errata documents to the datasheets of the same part converted in the
+// same batch or portfolio. Documents are matched by the part numbers in their file names and
+// on their first page; the two Markdown documents are then linked to each other and, with
+// ERRATA_LINKS=inject, each erratum is noted in the datasheet sections it affects.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ERRATA_LINKS modes
+const (
+	ErrataLinksOff    = "off"    // Errata documents are converted like any other document
+	ErrataLinksLink   = "link"   // Errata documents and their datasheets link to each other
+	ErrataLinksInject = "inject" // Errata notes are also added to the affected datasheet sections
+)
+
+// Errata detection limits
+const (
+	errataHeaderLines = 40 // Lines read for part numbers when a document has no page headings
+	errataTitleLines  = 5  // Non-empty lines of the first page searched for an errata title
+	errataTitleWords  = 8  // Longest line taken for a title
+)
+
+// ErrataLink relates an errata document to the datasheet it corrects.
+type ErrataLink struct {
+	Datasheet   string   `json:"datasheet"`    // Markdown file of the datasheet
+	Errata      string   `json:"errata"`       // Markdown file of the errata document
+	PartNumbers []string `json:"part_numbers"` // Part numbers both documents name
+	Notes       int      `json:"notes"`        // Errata notes added to datasheet sections
+}
+
+var (
+	// errataTitlePattern matches the titles of errata documents.
+	errataTitlePattern = regexp.MustCompile(`(?i)\b(errata|erratum|device\s+limitations)\b`)
+	// errataPartPattern matches part numbers such as "LM317", "TPS62130" or "STM32F40x",
+	// without the trailing "x" wildcards of part families.
+	errataPartPattern = regexp.MustCompile(`\b([A-Z]{2,}[0-9][A-Z0-9]{2,})(?:[xX]+)?\b`)
+	// errataStandardPattern matches standards that look like part numbers, such as "ISO26262".
+	errataStandardPattern = regexp.MustCompile(`^(ISO|IEC|JESD|AEC|MIL|IPC|ANSI|IEEE|UL)\d`)
+	// markdownHeadingLine matches any Markdown heading line in a multi-line text.
+	markdownHeadingLine = regexp.MustCompile(`(?m)^#{1,6} `)
+	// errataModulePattern splits the affected module from an erratum title, as in
+	// "2.3 I2C: Spurious bus error" or "ADC - Offset error above 85 °C".
+	errataModulePattern = regexp.MustCompile(`^(?:\d{1,3}(?:\.\d{1,3}){0,5}\.?\s+)?([^:–—]{2,40}?)\s*(?::|\s[–—-]\s)\s*\S`)
+)
+
+// errataDocument is a converted Markdown document considered for errata linking.
+type errataDocument struct {
+	path     string   // Markdown file
+	errata   bool     // Whether the document is an errata document
+	parts    []string // Part numbers in the file name and on the first page
+	markdown string
+}
+
+// erratum is one entry of an errata document: a heading with the text below it.
+type erratum struct {
+	title  string
+	anchor string
+	text   string
+}
+
+// linkErrata links the errata documents among the results of a batch to the datasheets
+// naming the same part numbers. Pairs linked before, such as the documents of a portfolio
+// converted within a directory, are skipped. Only Markdown outputs are linked.
+func (c *PDFConverter) linkErrata(results []ConversionResult) []ErrataLink {
+	mode := c.config.ErrataLinks
+	if mode == "" || mode == ErrataLinksOff {
+		return nil
+	}
+	var docs []*errataDocument
+	for _, r := range results {
+		if filepath.Ext(r.MarkdownFile) != ".md" {
+			continue
+		}
+		data, err := os.ReadFile(r.MarkdownFile)
+		if err != nil {
+			c.logger.Warn("Failed to read %s for errata linking: %v", r.MarkdownFile, err)
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(r.Source), filepath.Ext(r.Source))
+		header := documentHeader(string(data))
+		docs = append(docs, &errataDocument{
+			path:     r.MarkdownFile,
+			errata:   errataTitlePattern.MatchString(name) || errataTitled(header),
+			parts:    errataPartNumbers(strings.ToUpper(name) + "\n" + header),
+			markdown: string(data),
+		})
+	}
+
+	var links []ErrataLink
+	changed := map[*errataDocument]bool{}
+	for _, errata := range docs {
+		if !errata.errata {
+			continue
+		}
+		for _, datasheet := range docs {
+			if datasheet.errata {
+				continue
+			}
+			parts := sharedPartNumbers(errata.parts, datasheet.parts)
+			if len(parts) == 0 {
+				continue
+			}
+			toErrata, toDatasheet := relativeLink(datasheet.path, errata.path), relativeLink(errata.path, datasheet.path)
+			if strings.Contains(datasheet.markdown, "]("+toErrata+")") {
+				continue
+			}
+			link := ErrataLink{Datasheet: datasheet.path, Errata: errata.path, PartNumbers: parts}
+			if mode == ErrataLinksInject {
+				datasheet.markdown, link.Notes = c.injectErrataNotes(datasheet.markdown, errata.markdown, toErrata)
+			}
+			datasheet.markdown = c.appendRelatedDocument(datasheet.markdown, "Errata", filepath.Base(filepath.Dir(errata.path)), toErrata, parts)
+			errata.markdown = c.appendRelatedDocument(errata.markdown, "Datasheets", filepath.Base(filepath.Dir(datasheet.path)), toDatasheet, parts)
+			changed[datasheet], changed[errata] = true, true
+			links = append(links, link)
+			c.logger.Info("Linked errata %s to datasheet %s (%s, %d note(s))", errata.path, datasheet.path, strings.Join(parts, ", "), link.Notes)
+		}
+	}
+	for _, doc := range docs {
+		if changed[doc] {
+			if err := os.WriteFile(doc.path, []byte(doc.markdown), 0644); err != nil {
+				c.logger.Warn("Failed to write errata links to %s: %v", doc.path, err)
+			}
+		}
+	}
+	return links
+}
+
+// documentHeader returns the title and first page of a Markdown document, or its first
+// lines when it has no page headings. The table of contents is left out, as it names the
+// sections of the whole document.
+func documentHeader(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	title, start, end := "", 0, len(lines)
+	for i, line := range lines {
+		m := markdownHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if len(m[1]) == 1 && title == "" {
+			title = m[2]
+		}
+		if !pageHeadingPattern.MatchString(m[2]) {
+			continue
+		}
+		if start > 0 {
+			end = i
+			break
+		}
+		start = i + 1
+	}
+	if start == 0 {
+		return strings.Join(lines[:min(end, errataHeaderLines)], "\n")
+	}
+	return title + "\n" + strings.Join(lines[start:end], "\n")
+}
+
+// errataTitled reports whether a short line among the first lines of a document titles it
+// as errata, so a datasheet that merely refers to its errata is not taken for one.
+func errataTitled(header string) bool {
+	n := 0
+	for _, line := range strings.Split(header, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(strings.Fields(line)) <= errataTitleWords && errataTitlePattern.MatchString(line) {
+			return true
+		}
+		if n++; n == errataTitleLines {
+			break
+		}
+	}
+	return false
+}
+
+// errataPartNumbers returns the distinct part numbers in text, in order of appearance.
+func errataPartNumbers(text string) []string {
+	var parts []string
+	for _, m := range errataPartPattern.FindAllStringSubmatch(text, -1) {
+		if !errataStandardPattern.MatchString(m[1]) && !slices.Contains(parts, m[1]) {
+			parts = append(parts, m[1])
+		}
+	}
+	return parts
+}
+
+// sharedPartNumbers returns the part numbers of a that b names too, exactly or as the
+// family of a longer part number, such as "STM32F40" for "STM32F407".
+func sharedPartNumbers(a, b []string) []string {
+	var shared []string
+	for _, pa := range a {
+		for _, pb := range b {
+			if pa == pb || strings.HasPrefix(pb, pa) || strings.HasPrefix(pa, pb) {
+				shared = append(shared, pa)
+				break
+			}
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// relativeLink returns the link from the Markdown file from to the Markdown file to.
+func relativeLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(from), to)
+	if err != nil {
+		return filepath.ToSlash(to)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// appendRelatedDocument adds a link to a related document to the section titled title at
+// the end of the Markdown, starting the section unless the document already ends with it.
+func (c *PDFConverter) appendRelatedDocument(markdown, title, name, link string, parts []string) string {
+	heading := c.heading(c.config.BaseHeaderLevel+1, title)
+	item := fmt.Sprintf("- [%s](%s) (%s)\n", name, link, strings.Join(parts, ", "))
+	markdown = strings.TrimRight(markdown, "\n")
+	if i := strings.LastIndex(markdown, "\n"+heading+"\n"); i < 0 || markdownHeadingLine.MatchString(markdown[i+len(heading)+2:]) {
+		return markdown + "\n\n---\n\n" + heading + "\n\n" + item
+	}
+	return markdown + "\n" + item
+}
+
+// errataEntries returns the entries of an errata document: its headings other than the
+// title and page headings, with the text below them.
+func errataEntries(markdown string) []erratum {
+	var entries []erratum
+	seen := map[string]int{}
+	var fences fenceTracker
+	var current *erratum
+	for _, line := range strings.Split(markdown, "\n") {
+		if fences.inCode(line) {
+			continue
+		}
+		m := markdownHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			if current != nil {
+				current.text += line + "\n"
+			}
+			continue
+		}
+		slug := headingSlug(m[2])
+		anchor := slug
+		if n := seen[slug]; n > 0 {
+			anchor = fmt.Sprintf("%s-%d", slug, n)
+		}
+		seen[slug]++
+		current = nil
+		if len(m[1]) == 1 || pageHeadingPattern.MatchString(m[2]) {
+			continue
+		}
+		entries = append(entries, erratum{title: m[2], anchor: anchor})
+		current = &entries[len(entries)-1]
+	}
+	return entries
+}
+
+// affectedSection returns the line index of the datasheet heading an erratum affects: the
+// section it refers to by number, or else the first section naming the module in the
+// erratum title. It returns -1 when no section is affected.
+func (e erratum) affectedSection(headings map[int]string) int {
+	lines := make([]int, 0, len(headings))
+	for i := range headings {
+		lines = append(lines, i)
+	}
+	sort.Ints(lines)
+	for _, m := range referencePattern.FindAllStringSubmatch(e.title+"\n"+e.text, -1) {
+		number := m[3]
+		if strings.HasPrefix(m[1], "Sec") {
+			number = m[2]
+		}
+		if number == "" {
+			continue
+		}
+		for _, i := range lines {
+			if sm := sectionNumberPrefix.FindStringSubmatch(headings[i]); sm != nil && sm[1] == number {
+				return i
+			}
+		}
+	}
+	mm := errataModulePattern.FindStringSubmatch(e.title)
+	if mm == nil {
+		return -1
+	}
+	module := regexp.MustCompile(`(?i)(^|\W)` + regexp.QuoteMeta(strings.TrimSpace(mm[1])) + `(\W|$)`)
+	for _, i := range lines {
+		if module.MatchString(headings[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// injectErrataNotes adds a note linking to each erratum below the datasheet heading it
+// affects, and returns the Markdown with the number of notes added.
+func (c *PDFConverter) injectErrataNotes(markdown, errataMarkdown, link string) (string, int) {
+	lines := strings.Split(markdown, "\n")
+	headings := map[int]string{}
+	var fences fenceTracker
+	for i, line := range lines {
+		if fences.inCode(line) {
+			continue
+		}
+		if m := markdownHeadingPattern.FindStringSubmatch(line); m != nil && len(m[1]) > 1 && !pageHeadingPattern.MatchString(m[2]) {
+			headings[i] = m[2]
+		}
+	}
+	notes := map[int][]string{}
+	count := 0
+	for _, e := range errataEntries(errataMarkdown) {
+		if i := e.affectedSection(headings); i >= 0 {
+			notes[i] = append(notes[i], fmt.Sprintf("> **Erratum:** [%s](%s#%s)", e.title, link, e.anchor))
+			count++
+		}
+	}
+	if count == 0 {
+		return markdown, 0
+	}
+	var out []string
+	for i, line := range lines {
+		out = append(out, line)
+		for _, note := range notes[i] {
+			out = append(out, "", note)
+		}
+	}
+	return strings.Join(out, "\n"), count
+}
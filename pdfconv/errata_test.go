@@ -0,0 +1,166 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+const errataDatasheetMarkdown = `# STM32F407xx
+
+## Page 1
+
+ARM Cortex-M4 MCU, STM32F407VG and STM32F407ZG. See the errata sheet for known limitations.
+
+<a id="section-7-3"></a>
+
+### 7.3 I2C Interface
+
+The I2C interface supports standard and fast mode.
+
+### 7.4 Analog-to-Digital Converter (ADC)
+
+Three 12-bit ADCs.
+`
+
+const errataSheetMarkdown = `# ES0182
+
+## Page 1
+
+STM32F40x and STM32F41x Errata sheet
+
+### 2.1 I2C: Spurious bus error detection in master mode
+
+Workaround: ignore the error flag.
+
+### 2.2 ADC - Offset error above 85 °C
+
+See Section 7.4 of the datasheet.
+
+### 2.3 Debug: Breakpoint skipped after reset
+`
+
+// writeErrataResult writes Markdown into an output directory below dir and returns its
+// conversion result.
+func writeErrataResult(t *testing.T, dir, name, markdown string) ConversionResult {
+	t.Helper()
+	outputDir := filepath.Join(dir, "MARKDOWN_"+name)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(outputDir, "README.md")
+	if err := os.WriteFile(path, []byte(markdown), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ConversionResult{Source: filepath.Join(dir, name+".pdf"), OutputDir: outputDir, MarkdownFile: path}
+}
+
+func TestLinkErrata(t *testing.T) {
+	dir := t.TempDir()
+	results := []ConversionResult{
+		writeErrataResult(t, dir, "stm32f407", errataDatasheetMarkdown),
+		writeErrataResult(t, dir, "es0182", errataSheetMarkdown),
+		writeErrataResult(t, dir, "lm317", "# LM317\n\n## Page 1\n\nLM317 3-Terminal Adjustable Regulator\n"),
+	}
+	cfg := &config.Config{BaseHeaderLevel: 1, ErrataLinks: ErrataLinksInject}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+
+	links := conv.linkErrata(results)
+	want := []ErrataLink{{Datasheet: results[0].MarkdownFile, Errata: results[1].MarkdownFile, PartNumbers: []string{"STM32F40"}, Notes: 2}}
+	if !reflect.DeepEqual(links, want) {
+		t.Fatalf("unexpected errata links:\n got %+v\nwant %+v", links, want)
+	}
+
+	datasheet, _ := os.ReadFile(results[0].MarkdownFile)
+	for _, expected := range []string{
+		"### 7.3 I2C Interface\n\n> **Erratum:** [2.1 I2C: Spurious bus error detection in master mode](../MARKDOWN_es0182/README.md#21-i2c-spurious-bus-error-detection-in-master-mode)\n",
+		"### 7.4 Analog-to-Digital Converter (ADC)\n\n> **Erratum:** [2.2 ADC - Offset error above 85 °C](../MARKDOWN_es0182/README.md#22-adc---offset-error-above-85-c)\n",
+		"---\n\n## Errata\n\n- [MARKDOWN_es0182](../MARKDOWN_es0182/README.md) (STM32F40)\n",
+	} {
+		if !strings.Contains(string(datasheet), expected) {
+			t.Errorf("expected the datasheet to contain %q:\n%s", expected, datasheet)
+		}
+	}
+	if strings.Contains(string(datasheet), "Breakpoint") {
+		t.Errorf("expected no note for an erratum without an affected section:\n%s", datasheet)
+	}
+	errata, _ := os.ReadFile(results[1].MarkdownFile)
+	if !strings.HasSuffix(string(errata), "## Datasheets\n\n- [MARKDOWN_stm32f407](../MARKDOWN_stm32f407/README.md) (STM32F40)\n") {
+		t.Errorf("expected a link back to the datasheet:\n%s", errata)
+	}
+	anchors := markdownAnchors(string(errata))
+	if !anchors["21-i2c-spurious-bus-error-detection-in-master-mode"] || !anchors["22-adc---offset-error-above-85-c"] {
+		t.Errorf("expected the errata notes to link to errata headings, got %v", anchors)
+	}
+
+	// Linking the same documents again, as a portfolio within a batch, adds nothing
+	if links := conv.linkErrata(results); len(links) != 0 {
+		t.Errorf("expected linked documents to be skipped, got %+v", links)
+	}
+	if again, _ := os.ReadFile(results[0].MarkdownFile); string(again) != string(datasheet) {
+		t.Errorf("expected the datasheet unchanged:\n%s", again)
+	}
+
+	cfg.ErrataLinks = ErrataLinksOff
+	if links := conv.linkErrata(results); links != nil {
+		t.Errorf("expected no errata links with ERRATA_LINKS off, got %+v", links)
+	}
+}
+
+func TestSharedPartNumbers(t *testing.T) {
+	errata := errataPartNumbers("ES0182\nSTM32F40x and STM32F41x Errata sheet, ISO26262 ready")
+	if want := []string{"ES0182", "STM32F40", "STM32F41"}; !reflect.DeepEqual(errata, want) {
+		t.Fatalf("errataPartNumbers() = %v, want %v", errata, want)
+	}
+	if got := sharedPartNumbers(errata, []string{"STM32F407VG", "STM32F417"}); !reflect.DeepEqual(got, []string{"STM32F40", "STM32F41"}) {
+		t.Errorf("expected the part families to match, got %v", got)
+	}
+	if got := sharedPartNumbers(errata, []string{"LM317", "TPS62130"}); got != nil {
+		t.Errorf("expected no shared part numbers, got %v", got)
+	}
+}
+
+func TestConvertPDFsInDirectory_ErrataLinks(t *testing.T) {
+	inputDir := t.TempDir()
+	for name, lines := range map[string][]string{
+		"lm317.pdf":        {"LM317 3-Terminal Adjustable Regulator", "The LM317 supplies 1.5 A over an output range of 1.25 V to 37 V."},
+		"lm317_errata.pdf": {"LM317 Silicon Errata", "Output may overshoot at startup."},
+	} {
+		doc := gofpdf.New("P", "mm", "A4", "")
+		doc.SetFont("Helvetica", "", 10)
+		doc.AddPage()
+		doc.SetXY(20, 20)
+		for _, text := range lines {
+			doc.SetX(20)
+			doc.CellFormat(170, 6, text, "", 1, "L", false, 0, "")
+		}
+		if err := doc.OutputFileAndClose(filepath.Join(inputDir, name)); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ErrataLinks: ErrataLinksLink}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	result, err := conv.ConvertPDFsInDirectory(inputDir, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDFsInDirectory() error = %v", err)
+	}
+	if len(result.ErrataLinks) != 1 || !reflect.DeepEqual(result.ErrataLinks[0].PartNumbers, []string{"LM317"}) {
+		t.Fatalf("expected the errata linked to the datasheet, got %+v", result.ErrataLinks)
+	}
+	link := result.ErrataLinks[0]
+	if filepath.Base(filepath.Dir(link.Datasheet)) != "MARKDOWN_lm317" || filepath.Base(filepath.Dir(link.Errata)) != "MARKDOWN_lm317_errata" {
+		t.Errorf("unexpected documents linked: %+v", link)
+	}
+	datasheet, _ := os.ReadFile(link.Datasheet)
+	if !strings.Contains(string(datasheet), "- [MARKDOWN_lm317_errata](../MARKDOWN_lm317_errata/README.md) (LM317)") {
+		t.Errorf("expected a link to the errata in the datasheet:\n%s", datasheet)
+	}
+}
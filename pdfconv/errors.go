@@ -0,0 +1,88 @@
+// Package pdfconv - Error taxonomy.
+// This file defines the classes of conversion failures that clients may want to handle
+// differently, such as asking for a password or freeing disk space, with a stable
+// machine-readable code for each. Failures keep their descriptive messages; the class is
+// attached to the error and found with errors.Is or ErrorCode.
+package pdfconv
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Classes of conversion failures.
+var (
+	ErrEncrypted         = errors.New("document is encrypted")
+	ErrCorrupt           = errors.New("document is corrupt")
+	ErrUnsupportedFilter = errors.New("document uses an unsupported stream filter")
+	ErrQuotaExceeded     = errors.New("output quota exceeded")
+	ErrIncomplete        = errors.New("conversion is incomplete")
+	errorCodes           = []struct {
+		err  error
+		code string
+	}{
+		{ErrEncrypted, "encrypted"},
+		{ErrCorrupt, "corrupt"},
+		{ErrUnsupportedFilter, "unsupported_filter"},
+		{ErrQuotaExceeded, "quota_exceeded"},
+		{ErrIncomplete, "incomplete"},
+		{context.Canceled, "canceled"},
+		{context.DeadlineExceeded, "timeout"},
+	}
+)
+
+// classifiedError attaches a failure class to an error without changing its message.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string   { return e.err.Error() }
+func (e *classifiedError) Unwrap() []error { return []error{e.class, e.err} }
+
+// classify attaches class to err. A nil err stays nil.
+func classify(class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{class: class, err: err}
+}
+
+// ErrorCode returns the machine-readable code of the failure class of err: "encrypted",
+// "corrupt", "unsupported_filter", "quota_exceeded" or "incomplete", or "canceled" and
+// "timeout" for conversions stopped by their context. Errors outside these classes have
+// no code and return "".
+func ErrorCode(err error) string {
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return ""
+}
+
+// classifyOpenError attaches the failure class of an error returned by the PDF reader while
+// opening a document. Files that cannot be read at all are left unclassified.
+func classifyOpenError(err error) error {
+	var pathErr *os.PathError
+	message := err.Error()
+	switch {
+	case errors.As(err, &pathErr):
+		return err
+	case errors.Is(err, pdf.ErrInvalidPassword), strings.Contains(message, "encryption"):
+		return classify(ErrEncrypted, err)
+	case strings.Contains(message, "filter"):
+		return classify(ErrUnsupportedFilter, err)
+	default:
+		return classify(ErrCorrupt, err)
+	}
+}
+
+// newConversionError describes the failure of one file of a batch.
+func newConversionError(path string, err error) ConversionError {
+	return ConversionError{PDFPath: path, Error: err.Error(), Code: ErrorCode(err)}
+}
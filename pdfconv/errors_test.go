@@ -0,0 +1,79 @@
+package pdfconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestConvertPDF_ErrorCodes(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "encrypted.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetProtection(gofpdf.CnProtectPrint, "secret", "owner")
+	doc.SetFont("Arial", "", 12)
+	doc.AddPage()
+	doc.Cell(40, 10, "Confidential")
+	if err := doc.OutputFileAndClose(encrypted); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+	corrupt := filepath.Join(dir, "corrupt.pdf")
+	if err := os.WriteFile(corrupt, []byte("%PDF-1.4\nnothing to see here\n"), 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	for path, want := range map[string]error{encrypted: ErrEncrypted, corrupt: ErrCorrupt} {
+		_, err := conv.ConvertPDF(path, t.TempDir())
+		if !errors.Is(err, want) {
+			t.Errorf("%s: expected %v, got %v", filepath.Base(path), want, err)
+		}
+	}
+	if _, err := conv.ConvertPDF(filepath.Join(dir, "missing.pdf"), t.TempDir()); err == nil || ErrorCode(err) != "" {
+		t.Errorf("expected a missing file to have no code, got %v", err)
+	}
+
+	batch, err := conv.ConvertPDFsInDirectoryWithOptions(dir, t.TempDir(), ConversionOptions{})
+	if err != nil {
+		t.Fatalf("ConvertPDFsInDirectoryWithOptions() error = %v", err)
+	}
+	codes := map[string]string{}
+	for _, e := range batch.Errors {
+		codes[filepath.Base(e.PDFPath)] = e.Code
+	}
+	if codes["encrypted.pdf"] != "encrypted" || codes["corrupt.pdf"] != "corrupt" {
+		t.Errorf("expected error codes in the batch result, got %v", codes)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{classifyOpenError(fmt.Errorf("malformed PDF: unsupported filter /JBIG2Decode")), "unsupported_filter"},
+		{classifyOpenError(fmt.Errorf("unsupported PDF: encryption version V=5")), "encrypted"},
+		{classifyOpenError(fmt.Errorf("malformed PDF: page tree not found")), "corrupt"},
+		{fmt.Errorf("conversion failed: %w", classify(ErrQuotaExceeded, fmt.Errorf("insufficient disk space"))), "quota_exceeded"},
+		{fmt.Errorf("failed to extract document content: %w", context.Canceled), "canceled"},
+		{fmt.Errorf("failed to extract document content: %w", context.DeadlineExceeded), "timeout"},
+		{classifyOpenError(&os.PathError{Op: "open", Path: "x.pdf", Err: os.ErrNotExist}), ""},
+		{fmt.Errorf("unexpected"), ""},
+	}
+	for _, tt := range tests {
+		if got := ErrorCode(tt.err); got != tt.code {
+			t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.code)
+		}
+	}
+	if err := classify(ErrCorrupt, fmt.Errorf("bad xref")); err.Error() != "bad xref" {
+		t.Errorf("expected the message kept, got %q", err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+// Package pdfconv - Dry-run conversion estimates.
+// This file estimates how long a conversion will take and how much output it will write,
+// without writing any output, by converting the first pages of a document and extrapolating.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultEstimateSamplePages is the number of pages sampled when ESTIMATE_SAMPLE_PAGES is 0.
+const DefaultEstimateSamplePages = 5
+
+// ConversionEstimate is the dry-run estimate for a single document.
+type ConversionEstimate struct {
+	Source       string
+	PageCount    int           // Pages in the document, 0 when not known before conversion
+	ImageCount   int           // Embedded images found on all pages
+	SampledPages int           // Pages converted to measure the per-page cost, 0 for file size estimates
+	Duration     time.Duration // Estimated conversion time, 0 when not sampled
+	OutputBytes  int64         // Estimated size of the output directory
+}
+
+// Sampled reports whether the estimate is based on converted sample pages rather than
+// on the input file size alone.
+func (e ConversionEstimate) Sampled() bool {
+	return e.SampledPages > 0
+}
+
+// BatchEstimate is the dry-run estimate for a directory of documents.
+type BatchEstimate struct {
+	InputDir        string
+	Estimates       []ConversionEstimate
+	Errors          []ConversionError
+	TotalPageCount  int
+	TotalImageCount int
+	Duration        time.Duration // Sum of the sampled estimates
+	OutputBytes     int64
+}
+
+// EstimateConversion estimates the conversion time and output size of a document. PDFs
+// are estimated by converting the first ESTIMATE_SAMPLE_PAGES pages into a temporary
+// directory with the configured options; XPS and DjVu files only get a size estimate
+// from the input file size. Nothing is written to the output directory.
+func (c *PDFConverter) EstimateConversion(docPath string) (*ConversionEstimate, error) {
+	if strings.TrimSpace(docPath) == "" {
+		return nil, fmt.Errorf("input path cannot be empty")
+	}
+	docPath = filepath.Clean(docPath)
+	info, err := os.Stat(docPath)
+	if err != nil {
+		return nil, fmt.Errorf("input file does not exist: %s", docPath)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", docPath)
+	}
+	format, ok := documentFormats[strings.ToLower(filepath.Ext(docPath))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported document format: %s (supported: %s)", docPath, strings.Join(SupportedDocumentExtensions(), ", "))
+	}
+	estimate := &ConversionEstimate{Source: docPath, OutputBytes: info.Size() * OutputSizeFactor}
+	if format != "pdf" {
+		return estimate, nil
+	}
+
+	started := time.Now()
+	reader, closeFile, _, err := c.openPDF(docPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer closeFile()
+	openTime := time.Since(started)
+	estimate.PageCount = reader.NumPage()
+	imageCount, imageBytes := c.pdfImageStats(reader)
+	estimate.ImageCount = imageCount
+	if estimate.PageCount == 0 {
+		return estimate, nil
+	}
+
+	sample := c.config.EstimateSamplePages
+	if sample <= 0 {
+		sample = DefaultEstimateSamplePages
+	}
+	if sample > estimate.PageCount {
+		sample = estimate.PageCount
+	}
+	sampleDir, err := c.makeTempDir("estimate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sample directory: %v", err)
+	}
+	defer os.RemoveAll(sampleDir)
+
+	sampleStart := time.Now()
+	timings := &PhaseTimings{}
+	pages, sampleImages, err := c.extractPageRange(context.Background(), reader, docPath, sampleDir, 1, sample, timings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert sample pages: %v", err)
+	}
+	c.describeImages(pages, sampleDir, ConversionOptions{timings: timings})
+	markdown := c.lintMarkdown(c.generateMarkdown(pages))
+	sampleTime := time.Since(sampleStart)
+
+	scale := float64(estimate.PageCount) / float64(sample)
+	estimate.SampledPages = sample
+	estimate.Duration = openTime + time.Duration(float64(sampleTime)*scale)
+	estimate.OutputBytes = int64(float64(len(markdown)) * scale)
+	switch {
+	case !c.config.ExtractImages:
+	case sampleImages > 0 && imageCount > 0:
+		// Saved images are usually much smaller than their uncompressed pixels
+		estimate.OutputBytes += dirSize(sampleDir) / int64(sampleImages) * int64(imageCount)
+	default:
+		estimate.OutputBytes += imageBytes
+	}
+	c.logger.Debug("Estimated %s from %d sample pages: %s, %d bytes (%s)", docPath, sample, FormatDuration(estimate.Duration), estimate.OutputBytes, timings)
+	return estimate, nil
+}
+
+// EstimateDirectory estimates the conversion of every supported document below inputDir,
+// as a dry run of ConvertPDFsInDirectory.
+func (c *PDFConverter) EstimateDirectory(inputDir string) (*BatchEstimate, error) {
+	if _, err := os.Stat(inputDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("input directory does not exist: %s", inputDir)
+	}
+	files, err := c.findPDFFiles(inputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find PDF files: %v", err)
+	}
+	result := &BatchEstimate{InputDir: inputDir, Estimates: make([]ConversionEstimate, 0, len(files)), Errors: []ConversionError{}}
+	for _, path := range files {
+		estimate, err := c.EstimateConversion(path)
+		if err != nil {
+			c.logger.Warn("Failed to estimate %s: %v", path, err)
+			result.Errors = append(result.Errors, newConversionError(path, err))
+			continue
+		}
+		result.Estimates = append(result.Estimates, *estimate)
+		result.TotalPageCount += estimate.PageCount
+		result.TotalImageCount += estimate.ImageCount
+		result.Duration += estimate.Duration
+		result.OutputBytes += estimate.OutputBytes
+	}
+	return result, nil
+}
+
+// FormatBytes renders a byte count compactly: "512 B", "3.4 KB", "12.0 MB" or "1.2 GB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,125 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestEstimateConversion(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, EstimateSamplePages: 2}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+
+	// A six page document is estimated from its first two pages
+	pdfPath := filepath.Join(t.TempDir(), "long.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	for i := 0; i < 6; i++ {
+		doc.AddPage()
+		doc.Cell(40, 10, "Electrical characteristics of the device")
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := conv.EstimateConversion(pdfPath)
+	if err != nil {
+		t.Fatalf("EstimateConversion() error = %v", err)
+	}
+	if estimate.PageCount != 6 || estimate.SampledPages != 2 || !estimate.Sampled() {
+		t.Errorf("expected 6 pages with 2 sampled, got %+v", estimate)
+	}
+	if estimate.Duration <= 0 || estimate.OutputBytes <= 0 {
+		t.Errorf("expected positive time and size estimates, got %+v", estimate)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(pdfPath)); len(entries) != 1 {
+		t.Errorf("dry run wrote %d entries next to the input", len(entries))
+	}
+
+	// Images are counted on every page and their saved size is included
+	estimate, err = conv.EstimateConversion(createTempPDFWithRawImage(t))
+	if err != nil {
+		t.Fatalf("EstimateConversion() error = %v", err)
+	}
+	if estimate.ImageCount != 1 || estimate.SampledPages != 1 {
+		t.Errorf("expected 1 image on 1 sampled page, got %+v", estimate)
+	}
+	withImages := estimate.OutputBytes
+	conv.config.ExtractImages = false
+	estimate, _ = conv.EstimateConversion(createTempPDFWithRawImage(t))
+	if estimate.OutputBytes >= withImages {
+		t.Errorf("expected a smaller estimate without images, got %d >= %d", estimate.OutputBytes, withImages)
+	}
+}
+
+func TestEstimateConversion_Errors(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	dir := t.TempDir()
+	if _, err := conv.EstimateConversion(filepath.Join(dir, "missing.pdf")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	if _, err := conv.EstimateConversion(dir); err == nil {
+		t.Error("expected error for a directory")
+	}
+	textPath := filepath.Join(dir, "notes.txt")
+	os.WriteFile(textPath, []byte("notes"), 0644)
+	if _, err := conv.EstimateConversion(textPath); err == nil {
+		t.Error("expected error for an unsupported format")
+	}
+
+	// Formats that are not sampled are estimated from the file size
+	xpsPath := filepath.Join(dir, "doc.xps")
+	os.WriteFile(xpsPath, make([]byte, 1000), 0644)
+	estimate, err := conv.EstimateConversion(xpsPath)
+	if err != nil {
+		t.Fatalf("EstimateConversion() error = %v", err)
+	}
+	if estimate.Sampled() || estimate.OutputBytes != 1000*OutputSizeFactor {
+		t.Errorf("expected a file size estimate, got %+v", estimate)
+	}
+}
+
+func TestEstimateDirectory(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	dir := t.TempDir()
+	for _, name := range []string{"a.pdf", "b.pdf"} {
+		data, _ := os.ReadFile(createTempValidPDF(t))
+		os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "broken.pdf"), []byte("not a pdf"), 0644)
+
+	result, err := conv.EstimateDirectory(dir)
+	if err != nil {
+		t.Fatalf("EstimateDirectory() error = %v", err)
+	}
+	if len(result.Estimates) != 2 || len(result.Errors) != 1 {
+		t.Fatalf("expected 2 estimates and 1 error, got %d and %d", len(result.Estimates), len(result.Errors))
+	}
+	if result.TotalPageCount != result.Estimates[0].PageCount+result.Estimates[1].PageCount || result.OutputBytes <= 0 {
+		t.Errorf("unexpected totals: %+v", result)
+	}
+	if _, err := conv.EstimateDirectory(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{512, "512 B"},
+		{3482, "3.4 KB"},
+		{12 * 1024 * 1024, "12.0 MB"},
+		{1288490188, "1.2 GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.in); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
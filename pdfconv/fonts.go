@@ -0,0 +1,256 @@
+// Package pdfconv - Fonts.
+// This file records the font of each text run and carries the styling of fonts into the
+// Markdown: text typeset in known monospace fonts, such as register listings and command
+// examples set in Courier, becomes code spans, consecutive lines of such text code blocks,
+// and text in bold or italic fonts, such as parameter names, keeps its emphasis.
+package pdfconv
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// subsetTagPattern matches the tag that prefixes the names of embedded font subsets.
+var subsetTagPattern = regexp.MustCompile(`^[A-Z]{6}\+`)
+
+// monospaceFontNames are name fragments of common monospace font families, lowercase and
+// without spaces or hyphens.
+var monospaceFontNames = []string{
+	"courier", "consola", "menlo", "monaco", "inconsolata", "lucidaconsole", "lucidatypewriter",
+	"lucidasanstypewriter", "andalemono", "lettergothic", "ocra", "ocrb", "sourcecode", "firacode",
+	"cascadia", "fixedsys", "mono",
+}
+
+// boldStyleNames and italicStyleNames are name fragments of bold and italic font styles,
+// lowercase, matched in the style part of a font name (Arial-BoldMT, Times,BoldItalic,
+// MyriadPro-SemiboldIt).
+var (
+	boldStyleNames   = []string{"bold", "black", "heavy", "semibold", "demi"}
+	italicStyleNames = []string{"ital", "oblique"}
+)
+
+// StyledSpan is text of a page set in a monospace, bold or italic font.
+type StyledSpan struct {
+	Text   string
+	Code   bool // Set in a monospace font; Code spans carry no emphasis
+	Bold   bool
+	Italic bool
+}
+
+// codeLinePattern matches a line that is a single code span.
+var codeLinePattern = regexp.MustCompile("^`[^`]+`$|^`` [^`]+ ``$")
+
+// baseFontName returns a font name without the subset tag of embedded font subsets
+// (ABCDEF+Courier becomes Courier).
+func baseFontName(name string) string {
+	return subsetTagPattern.ReplaceAllString(name, "")
+}
+
+// isMonospaceFont reports whether a font name belongs to a known monospace family.
+func isMonospaceFont(name string) bool {
+	name = strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(baseFontName(name)))
+	if strings.Contains(name, "monotype") { // a foundry, not a monospace family
+		name = strings.ReplaceAll(name, "monotype", "")
+	}
+	for _, fragment := range monospaceFontNames {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// fontStyle reports whether a font name has a bold or italic style. Only the style part
+// after the family name is checked, so families such as Blackadder are not taken for bold.
+func fontStyle(name string) (bold, italic bool) {
+	name = strings.ToLower(baseFontName(name))
+	i := strings.LastIndexAny(name, "-,")
+	if i < 0 {
+		// Without a separator only unambiguous style names are recognized
+		return strings.Contains(name, "bold"), strings.Contains(name, "italic") || strings.Contains(name, "oblique")
+	}
+	style := name[i+1:]
+	for _, fragment := range boldStyleNames {
+		bold = bold || strings.Contains(style, fragment)
+	}
+	for _, fragment := range italicStyleNames {
+		italic = italic || strings.Contains(style, fragment)
+	}
+	return bold, italic || strings.HasSuffix(style, "it")
+}
+
+// styledSpans returns the texts set in monospace, bold or italic fonts on a page, in content
+// stream order. A span ends at a glyph in another style or on another line; spans shorter
+// than two characters are left out.
+func styledSpans(runs []pdf.Text) []StyledSpan {
+	var spans []StyledSpan
+	var span StyledSpan
+	var text strings.Builder
+	var y float64
+	flush := func() {
+		if span.Text = strings.TrimSpace(text.String()); utf8.RuneCountInString(span.Text) >= 2 {
+			spans = append(spans, span)
+		}
+		text.Reset()
+	}
+	for _, run := range runs {
+		style := StyledSpan{Code: isMonospaceFont(run.Font)}
+		if !style.Code {
+			style.Bold, style.Italic = fontStyle(run.Font)
+		}
+		switch {
+		case text.Len() > 0 && strings.TrimSpace(run.S) == "":
+			text.WriteString(run.S) // spacing between words of a span
+		case !style.Code && !style.Bold && !style.Italic:
+			flush()
+		default:
+			if text.Len() > 0 && (style != span || math.Abs(run.Y-y) > math.Max(2, run.FontSize*0.5)) {
+				flush()
+			}
+			span, y = style, run.Y
+			text.WriteString(run.S)
+		}
+	}
+	flush()
+	return spans
+}
+
+// markStyles marks the styled text of page in text: code when MONOSPACE_CODE is set and
+// emphasis when PRESERVE_EMPHASIS is set.
+func (c *PDFConverter) markStyles(text string, page PDFPage) string {
+	var spans []StyledSpan
+	for _, span := range page.StyledSpans {
+		if span.Code && c.config.MonospaceCode || !span.Code && c.config.PreserveEmphasis {
+			spans = append(spans, span)
+		}
+	}
+	return markStyledSpans(text, spans)
+}
+
+// markStyledSpans marks the styled spans of a page, in order, where they occur in text:
+// monospace spans as code spans, turning consecutive lines that are entirely code into a
+// fenced block, and bold and italic spans with emphasis. Emphasis covering a whole line is
+// left out, since such lines are mostly headings. Spans are matched ignoring whitespace,
+// which text extraction may add or drop.
+func markStyledSpans(text string, spans []StyledSpan) string {
+	if len(spans) == 0 {
+		return text
+	}
+	var out strings.Builder
+	rest := text
+	for _, span := range spans {
+		start, end := findSpan(rest, span.Text)
+		if start < 0 {
+			continue
+		}
+		out.WriteString(rest[:start])
+		match := rest[start:end]
+		switch {
+		case span.Code:
+			out.WriteString(codeSpan(match))
+		case wholeLine(rest, start, end) || strings.ContainsAny(match, "*_`"):
+			out.WriteString(match)
+		default:
+			out.WriteString(emphasis(match, span.Bold, span.Italic))
+		}
+		rest = rest[end:]
+	}
+	out.WriteString(rest)
+	return fenceCodeLines(out.String())
+}
+
+// wholeLine reports whether text[start:end] is all of its line apart from whitespace.
+func wholeLine(text string, start, end int) bool {
+	lineStart := strings.LastIndex(text[:start], "\n") + 1
+	lineEnd := len(text)
+	if i := strings.Index(text[end:], "\n"); i >= 0 {
+		lineEnd = end + i
+	}
+	return strings.TrimSpace(text[lineStart:start]) == "" && strings.TrimSpace(text[end:lineEnd]) == ""
+}
+
+// emphasis returns text as bold, italic or bold italic Markdown. Italic is written with
+// underscores, which AsciiDoc reads the same way.
+func emphasis(text string, bold, italic bool) string {
+	if italic {
+		text = "_" + text + "_"
+	}
+	if bold {
+		text = "**" + text + "**"
+	}
+	return text
+}
+
+// findSpan returns the byte offsets of the first occurrence of span in text that does not
+// start or end inside a word, or -1, -1.
+func findSpan(text, span string) (int, int) {
+	var parts []string
+	for _, r := range span {
+		if !unicode.IsSpace(r) {
+			parts = append(parts, regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern, err := regexp.Compile(strings.Join(parts, `[ \t]*`))
+	if err != nil {
+		return -1, -1
+	}
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+		after, _ := utf8.DecodeRuneInString(text[loc[1]:])
+		first, _ := utf8.DecodeRuneInString(text[loc[0]:])
+		last, _ := utf8.DecodeLastRuneInString(text[:loc[1]])
+		if isWordRune(first) && isWordRune(before) || isWordRune(last) && isWordRune(after) {
+			continue
+		}
+		return loc[0], loc[1]
+	}
+	return -1, -1
+}
+
+// isWordRune reports whether r is a letter or digit.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// codeSpan returns text as a Markdown code span.
+func codeSpan(text string) string {
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+// fenceCodeLines replaces runs of two or more lines that are each a single code span with a
+// fenced code block holding the unwrapped lines.
+func fenceCodeLines(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j < len(lines) && codeLinePattern.MatchString(strings.TrimSpace(lines[j])) {
+			j++
+		}
+		if j-i < 2 {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		out = append(out, "```")
+		for _, line := range lines[i:j] {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "`` ") {
+				out = append(out, line[3:len(line)-3])
+			} else {
+				out = append(out, line[1:len(line)-1])
+			}
+		}
+		out = append(out, "```")
+		i = j
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,153 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestIsMonospaceFont(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Courier":              true,
+		"ABCDEF+Courier-Bold":  true,
+		"Consolas":             true,
+		"DejaVuSansMono":       true,
+		"Lucida Console":       true,
+		"Helvetica":            false,
+		"ABCDEF+TimesNewRoman": false,
+		"MonotypeCorsiva":      false,
+	} {
+		if got := isMonospaceFont(name); got != want {
+			t.Errorf("isMonospaceFont(%q) = %t, want %t", name, got, want)
+		}
+	}
+	if got := baseFontName("QWERTY+Arial-BoldMT"); got != "Arial-BoldMT" {
+		t.Errorf("baseFontName() = %q", got)
+	}
+}
+
+func TestFontStyle(t *testing.T) {
+	tests := []struct {
+		name         string
+		bold, italic bool
+	}{
+		{"Helvetica-Bold", true, false},
+		{"ABCDEF+Arial-BoldMT", true, false},
+		{"Times-BoldItalic", true, true},
+		{"Arial,BoldItalic", true, true},
+		{"Helvetica-Oblique", false, true},
+		{"MyriadPro-SemiboldIt", true, true},
+		{"MinionPro-It", false, true},
+		{"TimesNewRomanBold", true, false},
+		{"Helvetica", false, false},
+		{"Blackadder", false, false},
+		{"ArialMT", false, false},
+	}
+	for _, tt := range tests {
+		if bold, italic := fontStyle(tt.name); bold != tt.bold || italic != tt.italic {
+			t.Errorf("fontStyle(%q) = %t, %t, want %t, %t", tt.name, bold, italic, tt.bold, tt.italic)
+		}
+	}
+}
+
+func TestMarkStyledSpans(t *testing.T) {
+	text := "Write the value to CTRL_REG to start.\nThe CTRL register\nmcu write 0x40 0x01\nmcu read 0x40\nDone"
+	spans := []StyledSpan{{Text: "CTRL_REG", Code: true}, {Text: "mcu write 0x40 0x01", Code: true}, {Text: "mcu read0x40", Code: true}}
+	want := "Write the value to `CTRL_REG` to start.\nThe CTRL register\n```\nmcu write 0x40 0x01\nmcu read 0x40\n```\nDone"
+	if got := markStyledSpans(text, spans); got != want {
+		t.Errorf("markStyledSpans() = %q, want %q", got, want)
+	}
+	// Spans are not matched inside words
+	if got := markStyledSpans("ABORT and OK", []StyledSpan{{Text: "OK", Code: true}}); got != "ABORT and `OK`" {
+		t.Errorf("markStyledSpans() = %q", got)
+	}
+
+	text = "Electrical Characteristics\nVDD must stay below the absolute maximum rating.\nSee note 3 for details."
+	spans = []StyledSpan{{Text: "Electrical Characteristics", Bold: true}, {Text: "VDD", Bold: true}, {Text: "absolute maximum", Italic: true}, {Text: "note 3", Bold: true, Italic: true}}
+	want = "Electrical Characteristics\n**VDD** must stay below the _absolute maximum_ rating.\nSee **_note 3_** for details."
+	if got := markStyledSpans(text, spans); got != want {
+		t.Errorf("markStyledSpans() = %q, want %q", got, want)
+	}
+}
+
+func TestConvertPDF_FontStyles(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "code.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.AddPage()
+	doc.SetFont("Arial", "", 11)
+	doc.Cell(70, 6, "Set the enable bit with")
+	doc.SetFont("Courier", "", 11)
+	doc.Cell(25, 6, "reg_write")
+	doc.SetFont("Arial", "", 11)
+	doc.Cell(40, 6, "as shown below.")
+	doc.Ln(10)
+	doc.SetFont("Courier", "", 11)
+	for _, line := range []string{"i2cset -y 1 0x40 0x01", "i2cget -y 1 0x40"} {
+		doc.Cell(80, 6, line)
+		doc.Ln(6)
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, MonospaceCode: true}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	for _, want := range []string{"`reg_write`", "```\ni2cset -y 1 0x40 0x01\ni2cget -y 1 0x40\n```"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("expected %q in the Markdown, got:\n%s", want, md)
+		}
+	}
+
+	// Emphasis within a line is kept; bold lines are left to heading detection
+	emphasisPath := filepath.Join(t.TempDir(), "emphasis.pdf")
+	doc = gofpdf.New("P", "mm", "A4", "")
+	doc.AddPage()
+	doc.SetFont("Arial", "B", 11)
+	doc.Cell(60, 6, "Absolute Ratings")
+	doc.Ln(10)
+	doc.Cell(12, 6, "VDD")
+	doc.SetFont("Arial", "", 11)
+	doc.Cell(40, 6, "must not exceed")
+	doc.SetFont("Arial", "I", 11)
+	doc.Cell(20, 6, "3.6 V")
+	doc.SetFont("Arial", "", 11)
+	doc.Cell(40, 6, "at any time.")
+	if err := doc.OutputFileAndClose(emphasisPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+	emphasis, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, PreserveEmphasis: true, ImagePlacement: "inline"}, logger.NewLogger("error"))
+	res, err = emphasis.ConvertPDF(emphasisPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ = os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "**VDD** must not exceed _3.6 V_ at any time.") || strings.Contains(string(md), "**Absolute Ratings**") {
+		t.Errorf("expected emphasis within the line only, got:\n%s", md)
+	}
+
+	plain, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err = plain.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if md, _ := os.ReadFile(res.MarkdownFile); strings.Contains(string(md), "`") {
+		t.Errorf("expected no code without MONOSPACE_CODE, got:\n%s", md)
+	}
+	res, err = plain.ConvertPDF(emphasisPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if md, _ := os.ReadFile(res.MarkdownFile); strings.Contains(string(md), "**VDD**") {
+		t.Errorf("expected no emphasis without PRESERVE_EMPHASIS, got:\n%s", md)
+	}
+}
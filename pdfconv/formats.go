@@ -0,0 +1,552 @@
+// Package pdfconv - Output formats.
+// This file writes the generated Markdown in the output format selected with OUTPUT_FORMAT
+// or per call: Markdown in the GitHub or CommonMark flavor, AsciiDoc, HTML, or JSON with the
+// document split into blocks, so one server can feed different documentation toolchains.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"datasheet-to-md-mcp/config"
+)
+
+// Output formats
+const (
+	FormatMarkdown = "markdown"
+	FormatAsciiDoc = "asciidoc"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Markdown flavors
+const (
+	FlavorGFM        = "gfm"        // GitHub-flavored Markdown with pipe tables
+	FlavorCommonMark = "commonmark" // CommonMark, which has no tables; tables are written as HTML
+)
+
+// formatFileNames maps output formats to the document file written to the output directory.
+var formatFileNames = map[string]string{
+	FormatMarkdown: "README.md",
+	FormatAsciiDoc: "README.adoc",
+	FormatHTML:     "README.html",
+	FormatJSON:     "README.json",
+}
+
+// Document block types
+const (
+	BlockHeading   = "heading"
+	BlockParagraph = "paragraph"
+	BlockList      = "list"
+	BlockTable     = "table"
+	BlockImage     = "image"
+	BlockCode      = "code"
+	BlockQuote     = "quote"
+	BlockComment   = "comment"
+	BlockRule      = "rule"
+)
+
+var (
+	// anchorLinePattern matches a line holding only an explicit HTML anchor.
+	anchorLinePattern = regexp.MustCompile(`^<a id="([^"]+)"></a>$`)
+	// inlineAnchorPattern matches an explicit HTML anchor inside a line.
+	inlineAnchorPattern = regexp.MustCompile(`<a id="([^"]+)"></a>`)
+	// commentLinePattern matches a line holding only an HTML comment.
+	commentLinePattern = regexp.MustCompile(`^<!--\s*(.*?)\s*-->$`)
+	// imageLinePattern matches a line holding only an image.
+	imageLinePattern = regexp.MustCompile(`^!\[([^\]]*)\]\(([^)\s]+)\)$`)
+	// alertLinePattern matches the first line of a GFM alert quote.
+	alertLinePattern = regexp.MustCompile(`^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\]$`)
+	// tableSeparatorPattern matches the separator row below a Markdown table header.
+	tableSeparatorPattern = regexp.MustCompile(`^\|(\s*:?-+:?\s*\|)+$`)
+	// inlinePattern matches the inline Markdown the converter writes: code spans, images,
+	// links, bold text and italic text. Italic is only matched between word boundaries, so
+	// identifiers such as CTRL_REG_EN are left alone.
+	inlinePattern = regexp.MustCompile("`([^`]+)`|!\\[([^\\]]*)\\]\\(([^)\\s]+)\\)|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)|\\*\\*([^*]+)\\*\\*|\\b_([^_]+)_\\b")
+)
+
+// DocumentBlock is a block of a converted document. Text, list items and table cells keep
+// their inline Markdown (links, bold text and code spans).
+type DocumentBlock struct {
+	Type     string     `json:"type"`
+	Anchors  []string   `json:"anchors,omitempty"`  // Link targets of the block; headings include their slug
+	Level    int        `json:"level,omitempty"`    // Heading level
+	Text     string     `json:"text,omitempty"`     // Heading, paragraph, quote, comment or code text
+	Alert    string     `json:"alert,omitempty"`    // GFM alert type of a quote (NOTE, TIP, IMPORTANT, WARNING, CAUTION)
+	Language string     `json:"language,omitempty"` // Code block language
+	Items    []string   `json:"items,omitempty"`    // List items
+	Header   []string   `json:"header,omitempty"`   // Table header cells
+	Rows     [][]string `json:"rows,omitempty"`     // Table body rows
+	Alt      string     `json:"alt,omitempty"`      // Image alt text
+	Src      string     `json:"src,omitempty"`      // Image path, relative to the output directory
+}
+
+// documentFile is the content of README.json.
+type documentFile struct {
+	Source   string          `json:"source"`
+	Language string          `json:"language,omitempty"` // Language declared in the front matter
+	Blocks   []DocumentBlock `json:"blocks"`
+}
+
+// OutputDocument returns the path of the document file in an output directory written by a
+// conversion, looking for the file of each output format in turn, Markdown first.
+func OutputDocument(dir string) (string, error) {
+	for _, format := range config.OutputFormats {
+		path := filepath.Join(dir, formatFileNames[format])
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no converted document found in %s", dir)
+}
+
+// outputFormat returns the output format of a conversion: the per-call choice in opts, else
+// OUTPUT_FORMAT, else Markdown.
+func (c *PDFConverter) outputFormat(opts ConversionOptions) string {
+	for _, format := range []string{opts.OutputFormat, c.config.OutputFormat} {
+		if _, ok := formatFileNames[format]; ok {
+			return format
+		}
+	}
+	return FormatMarkdown
+}
+
+// markdownFlavor returns the Markdown flavor of a conversion: the per-call choice in opts,
+// else MARKDOWN_FLAVOR, else GitHub-flavored Markdown.
+func (c *PDFConverter) markdownFlavor(opts ConversionOptions) string {
+	for _, flavor := range []string{opts.MarkdownFlavor, c.config.MarkdownFlavor} {
+		if flavor == FlavorGFM || flavor == FlavorCommonMark {
+			return flavor
+		}
+	}
+	return FlavorGFM
+}
+
+// writeDocument writes the Markdown of a converted document to dir in the output format of
+// opts and returns the name of the file written.
+func (c *PDFConverter) writeDocument(dir, docPath, markdown string, opts ConversionOptions) (string, error) {
+	format := c.outputFormat(opts)
+	name := formatFileNames[format]
+	if format == FormatMarkdown {
+		if c.markdownFlavor(opts) == FlavorCommonMark {
+			markdown = commonMarkTables(markdown)
+		}
+		return name, c.writeMarkdownFile(filepath.Join(dir, name), markdown)
+	}
+
+	language, blocks := parseMarkdownBlocks(c.lintMarkdown(markdown))
+	var content string
+	switch format {
+	case FormatAsciiDoc:
+		content = renderAsciiDoc(language, blocks)
+	case FormatHTML:
+		content = renderHTML(language, blocks)
+	case FormatJSON:
+		data, err := json.MarshalIndent(documentFile{Source: docPath, Language: language, Blocks: blocks}, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s: %v", name, err)
+		}
+		content = string(data) + "\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	c.logger.Info("%s document written successfully: %s", format, filepath.Join(dir, name))
+	return name, nil
+}
+
+// parseMarkdownBlocks splits the Markdown written by the converter into blocks. It returns
+// the language declared in YAML front matter, if any, and the blocks. Anchor lines and inline
+// anchors are attached to their block, and headings get their GitHub-style slug as an anchor.
+func parseMarkdownBlocks(markdown string) (string, []DocumentBlock) {
+	lines := strings.Split(markdown, "\n")
+	language := ""
+	i := 0
+	if len(lines) > 0 && lines[0] == "---" {
+		for j := 1; j < len(lines); j++ {
+			if lines[j] == "---" {
+				for _, meta := range lines[1:j] {
+					if value, ok := strings.CutPrefix(meta, "lang:"); ok {
+						language = strings.TrimSpace(value)
+					}
+				}
+				i = j + 1
+				break
+			}
+		}
+	}
+
+	var blocks []DocumentBlock
+	var anchors, paragraph []string
+	slugs := map[string]int{}
+	add := func(block DocumentBlock) {
+		if block.Type != BlockCode {
+			for _, m := range inlineAnchorPattern.FindAllStringSubmatch(block.Text, -1) {
+				anchors = append(anchors, m[1])
+			}
+			block.Text = inlineAnchorPattern.ReplaceAllString(block.Text, "")
+		}
+		if block.Type == BlockHeading {
+			slug := headingSlug(block.Text)
+			if n := slugs[slug]; n > 0 {
+				anchors = append(anchors, fmt.Sprintf("%s-%d", slug, n))
+			} else {
+				anchors = append(anchors, slug)
+			}
+			slugs[slug]++
+		}
+		block.Anchors, anchors = anchors, nil
+		blocks = append(blocks, block)
+	}
+	flush := func() {
+		if len(paragraph) > 0 {
+			add(DocumentBlock{Type: BlockParagraph, Text: strings.Join(paragraph, "\n")})
+			paragraph = nil
+		}
+	}
+
+	for i < len(lines) {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			flush()
+			i++
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			flush()
+			fence := strings.TrimRight(trimmed, "abcdefghijklmnopqrstuvwxyz")
+			var code []string
+			j := i + 1
+			for ; j < len(lines) && !(strings.TrimSpace(lines[j]) == fence); j++ {
+				code = append(code, lines[j])
+			}
+			add(DocumentBlock{Type: BlockCode, Language: strings.TrimPrefix(trimmed, fence), Text: strings.Join(code, "\n")})
+			i = j + 1
+			continue
+		}
+		if header, rows, next, ok := parseMarkdownTable(lines, i); ok {
+			flush()
+			add(DocumentBlock{Type: BlockTable, Header: header, Rows: rows})
+			i = next
+			continue
+		}
+		i++
+		switch m := markdownHeadingPattern.FindStringSubmatch(trimmed); {
+		case anchorLinePattern.MatchString(trimmed):
+			flush()
+			anchors = append(anchors, anchorLinePattern.FindStringSubmatch(trimmed)[1])
+		case m != nil:
+			flush()
+			add(DocumentBlock{Type: BlockHeading, Level: len(m[1]), Text: m[2]})
+		case commentLinePattern.MatchString(trimmed):
+			flush()
+			add(DocumentBlock{Type: BlockComment, Text: commentLinePattern.FindStringSubmatch(trimmed)[1]})
+		case trimmed == "---" || trimmed == "***":
+			flush()
+			add(DocumentBlock{Type: BlockRule})
+		case imageLinePattern.MatchString(trimmed):
+			flush()
+			image := imageLinePattern.FindStringSubmatch(trimmed)
+			add(DocumentBlock{Type: BlockImage, Alt: image[1], Src: image[2]})
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			quote := []string{strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")))
+			}
+			block := DocumentBlock{Type: BlockQuote}
+			if m := alertLinePattern.FindStringSubmatch(quote[0]); m != nil {
+				block.Alert, quote = m[1], quote[1:]
+			}
+			block.Text = strings.Join(quote, "\n")
+			add(block)
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flush()
+			items := []string{trimmed[2:]}
+			for ; i < len(lines); i++ {
+				next := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(next, "- ") && !strings.HasPrefix(next, "* ") {
+					break
+				}
+				items = append(items, next[2:])
+			}
+			add(DocumentBlock{Type: BlockList, Items: items})
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+	return language, blocks
+}
+
+// parseMarkdownTable parses the pipe table starting at lines[i]. It returns the header and
+// body rows, the index of the first line after the table, and whether a table starts there.
+func parseMarkdownTable(lines []string, i int) ([]string, [][]string, int, bool) {
+	if i+1 >= len(lines) || !strings.HasPrefix(strings.TrimSpace(lines[i]), "|") || !tableSeparatorPattern.MatchString(strings.TrimSpace(lines[i+1])) {
+		return nil, nil, i, false
+	}
+	header := splitTableRow(lines[i])
+	var rows [][]string
+	j := i + 2
+	for ; j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "|"); j++ {
+		rows = append(rows, splitTableRow(lines[j]))
+	}
+	return header, rows, j, true
+}
+
+// splitTableRow splits a pipe table row into its cells, unescaping \| inside cells.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// commonMarkTables replaces the pipe tables of GitHub-flavored Markdown, which CommonMark
+// does not support, with HTML tables.
+func commonMarkTables(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	var out []string
+	var fences fenceTracker
+	for i := 0; i < len(lines); {
+		if fences.inCode(lines[i]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		header, rows, next, ok := parseMarkdownTable(lines, i)
+		if !ok {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		out = append(out, strings.TrimSuffix(htmlTable(nil, header, rows), "\n"))
+		i = next
+	}
+	return strings.Join(out, "\n")
+}
+
+// htmlTable renders a table as HTML, one row per line.
+func htmlTable(anchors, header []string, rows [][]string) string {
+	var out strings.Builder
+	out.WriteString("<table>\n")
+	for _, anchor := range anchors {
+		fmt.Fprintf(&out, "<caption><a id=\"%s\"></a></caption>\n", html.EscapeString(anchor))
+	}
+	out.WriteString("<thead><tr>")
+	for _, cell := range header {
+		out.WriteString("<th>" + htmlInline(cell) + "</th>")
+	}
+	out.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		out.WriteString("<tr>")
+		for _, cell := range row {
+			out.WriteString("<td>" + htmlInline(cell) + "</td>")
+		}
+		out.WriteString("</tr>\n")
+	}
+	out.WriteString("</tbody>\n</table>\n")
+	return out.String()
+}
+
+// htmlInline converts inline Markdown to HTML, escaping all other text.
+func htmlInline(text string) string {
+	return convertInline(text, html.EscapeString, func(m []string) string {
+		switch {
+		case m[1] != "":
+			return "<code>" + html.EscapeString(m[1]) + "</code>"
+		case m[3] != "":
+			return fmt.Sprintf("<img src=\"%s\" alt=\"%s\">", html.EscapeString(m[3]), html.EscapeString(m[2]))
+		case m[5] != "":
+			return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(m[5]), html.EscapeString(m[4]))
+		case m[6] != "":
+			return "<strong>" + htmlInline(m[6]) + "</strong>"
+		default:
+			return "<em>" + html.EscapeString(m[7]) + "</em>"
+		}
+	})
+}
+
+// asciidocInline converts inline Markdown to AsciiDoc. Bold and italic text and code spans
+// are written the same way in both.
+func asciidocInline(text string) string {
+	return convertInline(text, func(s string) string { return s }, func(m []string) string {
+		switch {
+		case m[3] != "":
+			return fmt.Sprintf("image:%s[%s]", m[3], m[2])
+		case m[5] == "":
+			return m[0]
+		case strings.HasPrefix(m[5], "#"):
+			return fmt.Sprintf("<<%s,%s>>", m[5][1:], m[4])
+		case externalLinkPattern.MatchString(m[5]):
+			return fmt.Sprintf("%s[%s]", m[5], m[4])
+		default:
+			return fmt.Sprintf("link:%s[%s]", m[5], m[4])
+		}
+	})
+}
+
+// convertInline rewrites the inline Markdown in text with replace and the text in between
+// with plain.
+func convertInline(text string, plain func(string) string, replace func(m []string) string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range inlinePattern.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(plain(text[last:loc[0]]))
+		m := make([]string, len(loc)/2)
+		for k := range m {
+			if loc[2*k] >= 0 {
+				m[k] = text[loc[2*k]:loc[2*k+1]]
+			}
+		}
+		out.WriteString(replace(m))
+		last = loc[1]
+	}
+	out.WriteString(plain(text[last:]))
+	return out.String()
+}
+
+// renderHTML renders document blocks as a standalone HTML page.
+func renderHTML(language string, blocks []DocumentBlock) string {
+	title := "Document"
+	for _, block := range blocks {
+		if block.Type == BlockHeading && block.Level == 1 {
+			title = block.Text
+			break
+		}
+	}
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n")
+	if language != "" {
+		fmt.Fprintf(&out, "<html lang=\"%s\">\n", html.EscapeString(language))
+	} else {
+		out.WriteString("<html>\n")
+	}
+	fmt.Fprintf(&out, "<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+	for _, block := range blocks {
+		anchors := block.Anchors
+		if block.Type == BlockHeading && len(anchors) > 0 {
+			// The slug is the id of the heading itself, explicit anchors go inside it
+			slug := anchors[len(anchors)-1]
+			fmt.Fprintf(&out, "<h%d id=\"%s\">%s%s</h%d>\n", block.Level, html.EscapeString(slug), htmlAnchors(anchors[:len(anchors)-1]), htmlInline(block.Text), block.Level)
+			continue
+		}
+		switch block.Type {
+		case BlockParagraph:
+			fmt.Fprintf(&out, "<p>%s%s</p>\n", htmlAnchors(anchors), htmlInline(block.Text))
+		case BlockList:
+			out.WriteString("<ul>\n")
+			for _, item := range block.Items {
+				out.WriteString("<li>" + htmlInline(item) + "</li>\n")
+			}
+			out.WriteString("</ul>\n")
+		case BlockTable:
+			out.WriteString(htmlTable(anchors, block.Header, block.Rows))
+		case BlockImage:
+			fmt.Fprintf(&out, "<p>%s<img src=\"%s\" alt=\"%s\"></p>\n", htmlAnchors(anchors), html.EscapeString(block.Src), html.EscapeString(block.Alt))
+		case BlockCode:
+			class := ""
+			if block.Language != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(block.Language))
+			}
+			fmt.Fprintf(&out, "%s<pre><code%s>%s</code></pre>\n", htmlAnchors(anchors), class, html.EscapeString(block.Text))
+		case BlockQuote:
+			if block.Alert != "" {
+				kind := strings.ToLower(block.Alert)
+				fmt.Fprintf(&out, "<blockquote class=\"alert alert-%s\"><p class=\"alert-title\">%s</p><p>%s</p></blockquote>\n", kind, strings.ToUpper(kind[:1])+kind[1:], htmlInline(block.Text))
+			} else {
+				fmt.Fprintf(&out, "<blockquote><p>%s</p></blockquote>\n", htmlInline(block.Text))
+			}
+		case BlockComment:
+			fmt.Fprintf(&out, "<!-- %s -->\n", strings.ReplaceAll(block.Text, "--", "- -"))
+		case BlockRule:
+			out.WriteString("<hr>\n")
+		}
+	}
+	out.WriteString("</body>\n</html>\n")
+	return out.String()
+}
+
+// htmlAnchors renders explicit anchors as empty HTML anchors.
+func htmlAnchors(anchors []string) string {
+	var out strings.Builder
+	for _, anchor := range anchors {
+		fmt.Fprintf(&out, "<a id=\"%s\"></a>", html.EscapeString(anchor))
+	}
+	return out.String()
+}
+
+// renderAsciiDoc renders document blocks as an AsciiDoc document. Anchors are written as
+// block IDs, so cross-reference links keep working.
+func renderAsciiDoc(language string, blocks []DocumentBlock) string {
+	var out strings.Builder
+	for i, block := range blocks {
+		for _, anchor := range block.Anchors {
+			if i > 0 || block.Type != BlockHeading || block.Level != 1 {
+				fmt.Fprintf(&out, "[[%s]]\n", anchor)
+			}
+		}
+		switch block.Type {
+		case BlockHeading:
+			fmt.Fprintf(&out, "%s %s\n", strings.Repeat("=", block.Level), asciidocInline(block.Text))
+			if i == 0 && block.Level == 1 && language != "" {
+				fmt.Fprintf(&out, ":lang: %s\n", language)
+			}
+		case BlockParagraph:
+			out.WriteString(asciidocInline(block.Text) + "\n")
+		case BlockList:
+			for _, item := range block.Items {
+				out.WriteString("* " + asciidocInline(item) + "\n")
+			}
+		case BlockTable:
+			out.WriteString("[options=\"header\"]\n|===\n")
+			for _, row := range append([][]string{block.Header}, block.Rows...) {
+				cells := make([]string, len(row))
+				for k, cell := range row {
+					cells[k] = strings.ReplaceAll(asciidocInline(cell), "|", "\\|")
+				}
+				out.WriteString("|" + strings.Join(cells, " |") + "\n")
+			}
+			out.WriteString("|===\n")
+		case BlockImage:
+			fmt.Fprintf(&out, "image::%s[%s]\n", block.Src, block.Alt)
+		case BlockCode:
+			if block.Language != "" {
+				fmt.Fprintf(&out, "[source,%s]\n", block.Language)
+			}
+			fmt.Fprintf(&out, "----\n%s\n----\n", block.Text)
+		case BlockQuote:
+			if block.Alert != "" {
+				// GFM alert types are AsciiDoc admonition types as well
+				fmt.Fprintf(&out, "[%s]\n====\n%s\n====\n", block.Alert, asciidocInline(block.Text))
+			} else {
+				fmt.Fprintf(&out, "____\n%s\n____\n", asciidocInline(block.Text))
+			}
+		case BlockComment:
+			fmt.Fprintf(&out, "// %s\n", block.Text)
+		case BlockRule:
+			out.WriteString("'''\n")
+		}
+		out.WriteString("\n")
+	}
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
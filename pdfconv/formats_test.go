@@ -0,0 +1,135 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+const formatsMarkdown = `---
+lang: en
+---
+
+# PDF Document
+
+## Page 1
+
+> **Redacted:** 1 region(s) on this page are intentionally hidden.
+
+<a id="section-7-3"></a>
+
+### 7.3 Timing
+
+See [Section 7.3](#section-7-3) and [the datasheet](https://example.com/ds.pdf).
+Supply is **3.3 V**, set _typical_ with **_EN order_**.
+
+<a id="table-2"></a>Table 2. Pins
+
+| Pin | Name |
+| --- | --- |
+| 1 | VDD \| VCC |
+
+![Image](./image_ab12.png)
+
+<!-- lang: zh -->
+
+` + "```plantuml\n@startuml\nA -> B\n@enduml\n```" + `
+
+> [!WARNING]
+> Do not exceed **3.6 V**.
+
+---
+
+- [Page 1](#page-1)
+`
+
+func TestParseMarkdownBlocks(t *testing.T) {
+	language, blocks := parseMarkdownBlocks(formatsMarkdown)
+	if language != "en" {
+		t.Errorf("expected front matter language en, got %q", language)
+	}
+	var types []string
+	for _, block := range blocks {
+		types = append(types, block.Type)
+	}
+	want := "heading heading quote heading paragraph paragraph table image comment code quote rule list"
+	if got := strings.Join(types, " "); got != want {
+		t.Fatalf("unexpected blocks:\n got %s\nwant %s", got, want)
+	}
+	if section := blocks[3]; section.Level != 3 || strings.Join(section.Anchors, ",") != "section-7-3,73-timing" {
+		t.Errorf("unexpected section heading: %+v", section)
+	}
+	if caption := blocks[5]; caption.Text != "Table 2. Pins" || caption.Anchors[0] != "table-2" {
+		t.Errorf("expected the inline anchor moved to the caption block, got %+v", caption)
+	}
+	if table := blocks[6]; len(table.Rows) != 1 || table.Rows[0][1] != "VDD | VCC" {
+		t.Errorf("unexpected table: %+v", table)
+	}
+	if code := blocks[9]; code.Language != "plantuml" || code.Text != "@startuml\nA -> B\n@enduml" {
+		t.Errorf("unexpected code block: %+v", code)
+	}
+	if alert := blocks[10]; alert.Alert != AlertWarning || alert.Text != "Do not exceed **3.6 V**." {
+		t.Errorf("unexpected alert: %+v", alert)
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	language, blocks := parseMarkdownBlocks(formatsMarkdown)
+
+	adoc := renderAsciiDoc(language, blocks)
+	for _, want := range []string{"= PDF Document\n:lang: en", "[[section-7-3]]\n[[73-timing]]\n=== 7.3 Timing", "See <<section-7-3,Section 7.3>> and https://example.com/ds.pdf[the datasheet].", "|===\n|Pin |Name\n|1 |VDD \\| VCC\n|===", "**3.3 V**, set _typical_ with **_EN order_**.", "image::./image_ab12.png[Image]", "[source,plantuml]\n----\n@startuml", "// lang: zh", "[WARNING]\n====\nDo not exceed **3.6 V**.\n====", "* <<page-1,Page 1>>"} {
+		if !strings.Contains(adoc, want) {
+			t.Errorf("expected %q in AsciiDoc:\n%s", want, adoc)
+		}
+	}
+
+	page := renderHTML(language, blocks)
+	for _, want := range []string{"<html lang=\"en\">", "<title>PDF Document</title>", "<h3 id=\"73-timing\"><a id=\"section-7-3\"></a>7.3 Timing</h3>", "<a href=\"#section-7-3\">Section 7.3</a>", "<strong>3.3 V</strong>, set <em>typical</em> with <strong><em>EN order</em></strong>.", "<td>VDD | VCC</td>", "<img src=\"./image_ab12.png\" alt=\"Image\">", "<pre><code class=\"language-plantuml\">@startuml\nA -&gt; B", "<blockquote class=\"alert alert-warning\"><p class=\"alert-title\">Warning</p><p>Do not exceed <strong>3.6 V</strong>.</p></blockquote>", "<hr>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %q in HTML:\n%s", want, page)
+		}
+	}
+
+	commonMark := commonMarkTables(formatsMarkdown)
+	if strings.Contains(commonMark, "| --- |") || !strings.Contains(commonMark, "<thead><tr><th>Pin</th><th>Name</th></tr></thead>") {
+		t.Errorf("expected the pipe table replaced by HTML, got:\n%s", commonMark)
+	}
+}
+
+func TestConvertPDF_OutputFormats(t *testing.T) {
+	pdfPath := createTempValidPDF(t)
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, OutputFormat: FormatHTML}, logger.NewLogger("error"))
+
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if filepath.Base(res.MarkdownFile) != "README.html" {
+		t.Fatalf("expected README.html from OUTPUT_FORMAT=html, got %s", res.MarkdownFile)
+	}
+	if _, err := os.Stat(filepath.Join(res.OutputDir, "README.md")); !os.IsNotExist(err) {
+		t.Errorf("expected no README.md next to README.html")
+	}
+
+	// Per-call options override the configuration
+	res, err = conv.ConvertDocument(pdfPath, t.TempDir(), ConversionOptions{OutputFormat: FormatJSON})
+	if err != nil {
+		t.Fatalf("ConvertDocument() error = %v", err)
+	}
+	data, err := os.ReadFile(res.MarkdownFile)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", res.MarkdownFile, err)
+	}
+	var file documentFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid README.json: %v", err)
+	}
+	if file.Source != pdfPath || len(file.Blocks) == 0 || file.Blocks[0].Type != BlockHeading {
+		t.Errorf("unexpected README.json: %s", data)
+	}
+}
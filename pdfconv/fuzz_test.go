@@ -0,0 +1,148 @@
+package pdfconv
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// crashersDir holds inputs that made the converter panic. FuzzConvertPDF saves them as
+// plain PDF files, so they can be opened in a viewer or converted with the CLI, and
+// TestFuzzCrashers replays them as regression fixtures after the panic is fixed.
+const crashersDir = "testdata/crashers"
+
+// fuzzMaxInput bounds fuzz inputs so that iterations stay fast.
+const fuzzMaxInput = 1 << 20
+
+// FuzzConvertPDF feeds mutated PDFs through opening, repair, page extraction and Markdown
+// generation. Errors are expected for malformed input; panics are not.
+//
+//	go test ./pdfconv -run '^$' -fuzz FuzzConvertPDF -fuzztime 5m
+func FuzzConvertPDF(f *testing.F) {
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "B", 16)
+	doc.AddPage()
+	doc.Cell(40, 10, "1 Overview")
+	doc.Ln(12)
+	doc.SetFont("Arial", "", 11)
+	for _, row := range [][]string{{"Parameter", "Min", "Typ", "Max"}, {"VDD", "1.8", "3.3", "3.6"}, {"IDD", "-", "2.5", "4"}} {
+		for _, cell := range row {
+			doc.CellFormat(30, 6, cell, "1", 0, "L", false, 0, "")
+		}
+		doc.Ln(6)
+	}
+	doc.SetFillColor(255, 240, 200)
+	doc.Rect(10, 60, 150, 12, "F")
+	doc.Text(12, 67, "WARNING: Do not exceed the absolute maximum ratings.")
+	doc.AddPage()
+	doc.SetFont("Courier", "", 10)
+	doc.Text(10, 20, "uint8_t reg = 0x3F;")
+	var buf bytes.Buffer
+	if err := doc.Output(&buf); err != nil {
+		f.Fatalf("failed to create seed pdf: %v", err)
+	}
+	valid := buf.Bytes()
+
+	last := bytes.LastIndex(valid, []byte("startxref"))
+	f.Add(valid)
+	f.Add(append(append([]byte{}, valid[:last]...), []byte("startxref\n10\n%%EOF\n")...))
+	f.Add(append([]byte("HTTP/1.1 200 OK\r\n\r\n"), valid...))
+	f.Add(valid[:bytes.LastIndex(valid, []byte("xref"))])
+	f.Add(valid[:len(valid)/2])
+	f.Add([]byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n%%EOF\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > fuzzMaxInput {
+			t.Skip()
+		}
+		convertFuzzInput(t, data)
+	})
+}
+
+// TestFuzzCrashers converts every input saved by FuzzConvertPDF, failing while any of them
+// still makes the converter panic.
+func TestFuzzCrashers(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join(crashersDir, "*.pdf"))
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			convertFuzzInput(t, data)
+		})
+	}
+}
+
+// convertFuzzInput converts data as a PDF file. A panic is triaged by saving the input to
+// crashersDir under the hash of its content, with the panic and stack in the failure.
+// While fuzzing, the fuzz engine records and minimizes the input itself in
+// testdata/fuzz/FuzzConvertPDF; replaying that corpus with go test saves the fixture.
+func convertFuzzInput(t *testing.T, data []byte) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			if fuzzing() {
+				t.Fatalf("converter panicked: %v\n%s", r, debug.Stack())
+			}
+			t.Fatalf("converter panicked: %v\ninput saved as %s\n%s", r, saveCrasher(t, data), debug.Stack())
+		}
+	}()
+	pdfPath := filepath.Join(t.TempDir(), "fuzz.pdf")
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		BaseHeaderLevel:     1,
+		MaxHeaderDepth:      6,
+		IncludeTOC:          true,
+		ExtractTables:       true,
+		TableMinConfidence:  0.5,
+		NormalizeSpecTables: true,
+		MonospaceCode:       true,
+		PreserveEmphasis:    true,
+		DetectCallouts:      true,
+		JoinPageBreaks:      true,
+		CrossReferenceLinks: true,
+		SectionNumbering:    "preserve",
+	}
+	conv, err := NewPDFConverter(cfg, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = conv.ConvertPDF(pdfPath, t.TempDir())
+}
+
+// fuzzing reports whether the fuzz engine is running rather than replaying seeds.
+func fuzzing() bool {
+	f := flag.Lookup("test.fuzz")
+	return f != nil && f.Value.String() != ""
+}
+
+// saveCrasher writes a crashing input to crashersDir and returns its path.
+func saveCrasher(t *testing.T, data []byte) string {
+	sum := sha256.Sum256(data)
+	path := filepath.Join(crashersDir, hex.EncodeToString(sum[:8])+".pdf")
+	if err := os.MkdirAll(crashersDir, 0755); err != nil {
+		t.Logf("failed to save crashing input: %v", err)
+		return "(not saved)"
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Logf("failed to save crashing input: %v", err)
+		return "(not saved)"
+	}
+	return path
+}
@@ -0,0 +1,166 @@
+// Package pdfconv - Header detection heuristics.
+// This file holds the locale-specific header keyword sets and builds the matcher used by
+// looksLikeHeader from the configured keywords and regular expressions.
+package pdfconv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// headerKeywordSets contains the built-in section keywords for each supported locale.
+// Keywords are matched case-insensitively as substrings of a candidate line.
+var headerKeywordSets = map[string][]string{
+	"en": {"OVERVIEW", "DESCRIPTION", "FEATURES", "SPECIFICATIONS", "PARAMETERS", "APPLICATIONS", "CHARACTERISTICS", "OPERATION", "CONFIGURATION"},
+	"de": {"ÜBERSICHT", "BESCHREIBUNG", "MERKMALE", "EIGENSCHAFTEN", "TECHNISCHE DATEN", "ANWENDUNGEN", "KENNWERTE", "BETRIEB", "KONFIGURATION"},
+	"fr": {"APERÇU", "DESCRIPTION", "CARACTÉRISTIQUES", "SPÉCIFICATIONS", "PARAMÈTRES", "APPLICATIONS", "FONCTIONNEMENT", "CONFIGURATION"},
+	"ja": {"概要", "特長", "特徴", "仕様", "電気的特性", "用途", "アプリケーション", "動作説明", "機能説明", "端子説明"},
+	"zh": {"概述", "描述", "特性", "特点", "规格", "参数", "应用", "电气特性", "工作原理", "配置"},
+}
+
+// headerMatcher holds the compiled keyword list and patterns used for header detection.
+type headerMatcher struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// newHeaderMatcher combines the keyword sets of the given locales (English when none are set)
+// with any custom keywords, and compiles the custom header regular expressions.
+func newHeaderMatcher(locales, keywords, regexes []string) (*headerMatcher, error) {
+	if len(locales) == 0 {
+		locales = []string{"en"}
+	}
+	m := &headerMatcher{}
+	seen := map[string]bool{}
+	add := func(keyword string) {
+		keyword = strings.ToUpper(strings.TrimSpace(keyword))
+		if keyword != "" && !seen[keyword] {
+			seen[keyword] = true
+			m.keywords = append(m.keywords, keyword)
+		}
+	}
+	for _, locale := range locales {
+		set, ok := headerKeywordSets[strings.ToLower(locale)]
+		if !ok {
+			return nil, fmt.Errorf("unknown header keyword locale: %s", locale)
+		}
+		for _, keyword := range set {
+			add(keyword)
+		}
+	}
+	for _, keyword := range keywords {
+		add(keyword)
+	}
+	for _, expr := range regexes {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header regex %q: %v", expr, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// matchesPattern reports whether the line matches one of the custom header regular expressions.
+func (m *headerMatcher) matchesPattern(line string) bool {
+	for _, re := range m.patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeyword reports whether the line contains one of the header keywords.
+func (m *headerMatcher) containsKeyword(line string) bool {
+	upperLine := strings.ToUpper(line)
+	for _, keyword := range m.keywords {
+		if strings.Contains(upperLine, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// headingSmallWords stay lowercase in title-cased headings unless they start the heading.
+var headingSmallWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true, "for": true, "from": true,
+	"in": true, "of": true, "on": true, "or": true, "the": true, "to": true, "vs": true, "via": true, "with": true,
+}
+
+// headingAcronyms are common datasheet abbreviations that keep their capitalization when title-casing.
+var headingAcronyms = map[string]bool{
+	"AC": true, "ADC": true, "BGA": true, "CAN": true, "CMOS": true, "CPU": true, "CRC": true, "DAC": true,
+	"DC": true, "DIP": true, "DMA": true, "ECC": true, "EMC": true, "EMI": true, "ESD": true, "FIFO": true,
+	"GPIO": true, "IC": true, "IRQ": true, "JTAG": true, "LDO": true, "LED": true, "LIN": true, "LVDS": true,
+	"MCU": true, "MOSFET": true, "PCB": true, "PLL": true, "PWM": true, "QFN": true, "RAM": true, "RF": true,
+	"ROM": true, "SOIC": true, "SPI": true, "SWD": true, "TSSOP": true, "TTL": true, "UART": true, "USB": true,
+}
+
+var headingRepeatedNumber = regexp.MustCompile(`^((?:\d+\.)*\d+)(\.?\s+)((?:\d+\.)*\d+)\.?\s+`)
+
+// normalizeHeading cleans up a detected heading: trailing colons are removed, repeated section
+// numbers ("7.3 7.3 Timing") are collapsed, and ALL-CAPS headings are converted to title case.
+func normalizeHeading(heading string) string {
+	heading = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(heading), ":："))
+
+	// Collapse duplicate numbering only when the repeated numbers are identical
+	for {
+		m := headingRepeatedNumber.FindStringSubmatch(heading)
+		if m == nil || m[1] != m[3] {
+			break
+		}
+		heading = m[1] + m[2] + heading[len(m[0]):]
+	}
+
+	if isAllCaps(heading) {
+		heading = titleCase(heading)
+	}
+	return heading
+}
+
+// isAllCaps reports whether the heading has cased letters and all of them are uppercase.
+func isAllCaps(s string) bool {
+	hasLetter := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+// titleCase converts an upper-case heading to title case, keeping acronyms, words containing
+// digits (part numbers, I2C) and section numbers untouched.
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	first := true
+	for i, word := range words {
+		core := strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if core == "" || strings.IndexFunc(core, unicode.IsLetter) < 0 {
+			continue // numbering or punctuation
+		}
+		switch {
+		case headingAcronyms[core] || strings.IndexFunc(core, unicode.IsDigit) >= 0:
+			// keep as-is
+		case !first && headingSmallWords[strings.ToLower(core)]:
+			words[i] = strings.ToLower(word)
+		default:
+			lower := []rune(strings.ToLower(word))
+			for j, r := range lower {
+				if unicode.IsLetter(r) {
+					lower[j] = unicode.ToUpper(r)
+					break
+				}
+			}
+			words[i] = string(lower)
+		}
+		first = false
+	}
+	return strings.Join(words, " ")
+}
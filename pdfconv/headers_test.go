@@ -0,0 +1,83 @@
+package pdfconv
+
+import (
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestNewHeaderMatcher(t *testing.T) {
+	m, err := newHeaderMatcher([]string{"en", "DE"}, []string{"pinout", " ", "FEATURES"}, []string{`^\d+\.\d+ `})
+	if err != nil {
+		t.Fatalf("newHeaderMatcher() error = %v", err)
+	}
+	if !m.containsKeyword("Pinout and Signals") || !m.containsKeyword("Technische Daten") || !m.containsKeyword("features") {
+		t.Error("expected custom, German and English keywords to match")
+	}
+	if !m.matchesPattern("7.3 Timing Requirements") || m.matchesPattern("Timing 7.3") {
+		t.Error("header regex not applied correctly")
+	}
+	if _, err := newHeaderMatcher([]string{"xx"}, nil, nil); err == nil {
+		t.Error("expected error for unknown locale")
+	}
+	if _, err := newHeaderMatcher(nil, nil, []string{"("}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestLooksLikeHeader_Configured(t *testing.T) {
+	cfg := &config.Config{
+		HeaderKeywordLocales: []string{"ja"},
+		HeaderKeywords:       []string{"Register Map"},
+		HeaderRegexes:        []string{`^\d+(\.\d+)+\s+\S`},
+	}
+	conv, err := NewPDFConverter(cfg, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatalf("NewPDFConverter() error = %v", err)
+	}
+	cases := []struct {
+		line string
+		want bool
+	}{
+		{"電気的特性", true},
+		{"the register map lists all registers", true},
+		{"7.3.2 Timing requirements for the serial interface when operating above 85 degrees", true},
+		{"this describes the features", false}, // English set not enabled
+	}
+	for _, c := range cases {
+		if got := conv.looksLikeHeader(c.line); got != c.want {
+			t.Errorf("looksLikeHeader(%q) = %v, want %v", c.line, got, c.want)
+		}
+	}
+	if _, err := NewPDFConverter(&config.Config{HeaderRegexes: []string{"["}}, logger.NewLogger("error")); err == nil {
+		t.Error("expected NewPDFConverter to reject invalid header regex")
+	}
+}
+
+func TestNormalizeHeading(t *testing.T) {
+	cases := map[string]string{
+		"ELECTRICAL CHARACTERISTICS:":     "Electrical Characteristics",
+		"7.3 7.3 TIMING REQUIREMENTS":     "7.3 Timing Requirements",
+		"7.3. 7.3. Timing":                "7.3. Timing",
+		"7.3 7.4 Timing":                  "7.3 7.4 Timing",
+		"SPI AND I2C INTERFACE OF THE IC": "SPI and I2C Interface of the IC",
+		"THE LM317 REGULATOR":             "The LM317 Regulator",
+		"Pin Configuration and Functions": "Pin Configuration and Functions",
+		"概要：":                             "概要",
+	}
+	for in, want := range cases {
+		if got := normalizeHeading(in); got != want {
+			t.Errorf("normalizeHeading(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatTextContent_HeadingNormalize(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, HeadingNormalize: true}, logger.NewLogger("error"))
+	formatted := conv.formatTextContent("FEATURES:\nLow noise")
+	if !strings.Contains(formatted, "### Features\n") {
+		t.Errorf("expected normalized heading, got: %s", formatted)
+	}
+}
@@ -0,0 +1,156 @@
+// Package pdfconv - Ignore files.
+// This file reads the .pdfmdignore files of input directories, which exclude documents from
+// directory conversion with gitignore-style patterns, so brochures, slide decks and
+// duplicate scans in an archive can be left out declaratively. A file applies to its own
+// directory and everything below it; rules of deeper files and later lines take precedence.
+package pdfconv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the name of the ignore files read in input directories.
+const IgnoreFileName = ".pdfmdignore"
+
+// ignoreFile holds the rules of the ignore file of a directory.
+type ignoreFile struct {
+	dir   string
+	rules []ignoreRule
+}
+
+// ignoreRule is a pattern line of an ignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp // Matches slash separated paths relative to the ignore file's directory
+	negate  bool           // "!pattern" includes paths that an earlier rule excluded
+	dirOnly bool           // "pattern/" only matches directories
+}
+
+// readIgnoreFile reads the ignore file of dir. It returns nil when dir has none.
+func readIgnoreFile(dir string) (*ignoreFile, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	file := &ignoreFile{dir: dir}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		rule, ok, err := parseIgnoreRule(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", filepath.Join(dir, IgnoreFileName), lineNum, err)
+		}
+		if ok {
+			file.rules = append(file.rules, rule)
+		}
+	}
+	return file, nil
+}
+
+// parseIgnoreRule parses a line of an ignore file. Blank lines and comments yield no rule.
+func parseIgnoreRule(line string) (ignoreRule, bool, error) {
+	var rule ignoreRule
+	line = strings.TrimRight(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false, nil
+	}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return rule, false, nil
+	}
+	// Patterns with a slash are relative to the ignore file; others match a name at any depth
+	prefix := "^(.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+	pattern, err := globRegexp(line)
+	if err != nil {
+		return rule, false, err
+	}
+	if rule.re, err = regexp.Compile(prefix + pattern + "$"); err != nil {
+		return rule, false, fmt.Errorf("invalid pattern %q: %v", line, err)
+	}
+	return rule, true, nil
+}
+
+// globRegexp translates a gitignore glob into a regular expression: "*" and "?" do not match
+// "/", "**" matches across directories, and "[...]" is a character class.
+func globRegexp(glob string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class in %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
+
+// ignored reports whether path is excluded by the ignore files, which must be ordered from
+// the outermost directory inwards. The last matching rule of the files above path decides.
+func ignored(files []*ignoreFile, path string, isDir bool) bool {
+	excluded := false
+	for _, file := range files {
+		rel, err := filepath.Rel(file.dir, path)
+		rel = filepath.ToSlash(rel)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		for _, rule := range file.rules {
+			if (!rule.dirOnly || isDir) && rule.re.MatchString(rel) {
+				excluded = !rule.negate
+			}
+		}
+	}
+	return excluded
+}
@@ -0,0 +1,111 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.pdf", "a.pdf", false, true},
+		{"*.pdf", "sub/deep/a.pdf", false, true},
+		{"brochure*", "sub/brochure-2024.pdf", false, true},
+		{"brochure*", "sub/datasheet.pdf", false, false},
+		{"/top.pdf", "top.pdf", false, true},
+		{"/top.pdf", "sub/top.pdf", false, false},
+		{"sub/*.pdf", "sub/a.pdf", false, true},
+		{"sub/*.pdf", "sub/deep/a.pdf", false, false},
+		{"sub/**/a.pdf", "sub/deep/er/a.pdf", false, true},
+		{"sub/**/a.pdf", "sub/a.pdf", false, true},
+		{"**/scans", "x/y/scans", true, true},
+		{"archive/**", "archive/old/a.pdf", false, true},
+		{"rev?.pdf", "revA.pdf", false, true},
+		{"rev?.pdf", "rev10.pdf", false, false},
+		{"rev[0-9].pdf", "rev3.pdf", false, true},
+		{"rev[!0-9].pdf", "rev3.pdf", false, false},
+		{"slides/", "slides", true, true},
+		{"slides/", "slides", false, false},
+		{`\#1.pdf`, "#1.pdf", false, true},
+		{`a\ `, "a ", false, true},
+		{"a.pdf   ", "a.pdf", false, true},
+	}
+	for _, tt := range tests {
+		rule, ok, err := parseIgnoreRule(tt.pattern)
+		if err != nil || !ok {
+			t.Errorf("parseIgnoreRule(%q) = %v, %v", tt.pattern, ok, err)
+			continue
+		}
+		got := (!rule.dirOnly || tt.isDir) && rule.re.MatchString(tt.path)
+		if got != tt.want {
+			t.Errorf("pattern %q matching %q (dir %v) = %v; want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if _, ok, err := parseIgnoreRule(line); ok || err != nil {
+			t.Errorf("expected no rule for %q, got %v, %v", line, ok, err)
+		}
+	}
+	if _, _, err := parseIgnoreRule("rev[0-9.pdf"); err == nil {
+		t.Error("expected an error for an unterminated character class")
+	}
+}
+
+func TestFindPDFFiles_IgnoreFile(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"datasheet.pdf",
+		"brochure.pdf",
+		"brochure-pinout.pdf",
+		filepath.Join("slides", "deck.pdf"),
+		filepath.Join("scans", "dup.pdf"),
+		filepath.Join("scans", "original.pdf"),
+		filepath.Join("vendor", "errata.pdf"),
+		filepath.Join("vendor", "old", "errata.pdf"),
+	}
+	for _, p := range files {
+		path := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("%PDF-1.4\n%"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignores := map[string]string{
+		root:                         "# Marketing material\nbrochure*.pdf\n!*-pinout.pdf\nslides/\n/vendor/old/\n",
+		filepath.Join(root, "scans"): "*.pdf\n!original.pdf\n",
+	}
+	for dir, content := range ignores {
+		if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	found, err := conv.findPDFFiles(root)
+	if err != nil {
+		t.Fatalf("findPDFFiles() error = %v", err)
+	}
+	var got []string
+	for _, path := range found {
+		rel, _ := filepath.Rel(root, path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	want := []string{"brochure-pinout.pdf", "datasheet.pdf", "scans/original.pdf", "vendor/errata.pdf"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPDFFiles() = %v; want %v", got, want)
+	}
+}
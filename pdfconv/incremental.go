@@ -0,0 +1,227 @@
+// Package pdfconv - Incremental re-conversion.
+// This file keeps the extracted content of every page, together with a hash of the page's
+// objects, in a page cache inside the output directory when INCREMENTAL_CONVERSION is set.
+// Re-converting an updated revision of a document, such as a reference manual with a few
+// errata changes, extracts only the pages whose hash changed and reuses the other pages and
+// their images from the previous output.
+package pdfconv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PageCacheFileName is the page cache written to output directories by incremental conversion.
+const PageCacheFileName = ".page_cache.json"
+
+// pageHashDepth limits how deep the objects referenced by a page are hashed.
+const pageHashDepth = 8
+
+// pageHashSkipKeys are dictionary keys not followed when hashing a page: references back up
+// the page tree, which would hash the whole document, and embedded font programs, which are
+// large, shared by every page and already identified by the font dictionary.
+var pageHashSkipKeys = map[string]bool{"Parent": true, "P": true, "Popup": true, "FontDescriptor": true}
+
+// pageCache is the extracted content of the pages of one conversion.
+type pageCache struct {
+	Settings string       `json:"settings"` // Hash of the configuration the pages were extracted with
+	Pages    []cachedPage `json:"pages"`
+
+	dir    string             // Output directory the cache was read from
+	byPage map[int]cachedPage // Pages by number
+}
+
+// cachedPage is the extracted content of a page and the hash of the page objects it was
+// extracted from.
+type cachedPage struct {
+	Number int     `json:"number"`
+	Hash   string  `json:"hash"`
+	Page   PDFPage `json:"page"`
+}
+
+// settingsHash returns a hash of the configuration, so a cache is only used with the settings
+// and server version it was written with.
+func (c *PDFConverter) settingsHash() string {
+	data, _ := json.Marshal(c.config)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadPageCache reads the page cache of the previous output in outputDir. It returns an
+// empty cache when there is no previous output, or when it was converted with different
+// settings.
+func (c *PDFConverter) loadPageCache(outputDir string) *pageCache {
+	settings := c.settingsHash()
+	empty := &pageCache{Settings: settings, dir: outputDir}
+	data, err := os.ReadFile(filepath.Join(outputDir, PageCacheFileName))
+	if err != nil {
+		return empty
+	}
+	var cache pageCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		c.logger.Warn("Ignoring unreadable page cache in %s: %v", outputDir, err)
+		return empty
+	}
+	if cache.Settings != settings {
+		c.logger.Info("Configuration changed since the previous conversion, extracting all pages")
+		return empty
+	}
+	cache.dir = outputDir
+	cache.byPage = make(map[int]cachedPage, len(cache.Pages))
+	for _, page := range cache.Pages {
+		cache.byPage[page.Number] = page
+	}
+	return &cache
+}
+
+// add records the content of a page. Pages whose objects could not be hashed are left out
+// and always extracted.
+func (pc *pageCache) add(number int, hash string, page PDFPage) {
+	if hash == "" {
+		return
+	}
+	page.Reused = false
+	page.Images = append([]PDFImage(nil), page.Images...)
+	for i := range page.Images {
+		page.Images[i].Data = nil // Saved in the output directory
+	}
+	pc.Pages = append(pc.Pages, cachedPage{Number: number, Hash: hash, Page: page})
+}
+
+// write saves the page cache in dir.
+func (pc *pageCache) write(dir string) error {
+	data, err := json.Marshal(pc)
+	if err != nil {
+		return fmt.Errorf("failed to encode page cache: %v", err)
+	}
+	return os.WriteFile(filepath.Join(dir, PageCacheFileName), data, 0644)
+}
+
+// reusePage returns the cached content of a page whose objects are unchanged since the
+// previous output, after copying its images, table images and curve graph files into
+// outputDir. Pages are matched by number, since image file names are derived from it.
+func (c *PDFConverter) reusePage(previous *pageCache, number int, hash, outputDir string) (PDFPage, bool) {
+	cached, ok := previous.byPage[number]
+	if !ok || hash == "" || cached.Hash != hash {
+		return PDFPage{}, false
+	}
+	page := cached.Page
+	var files []string
+	for _, img := range page.Images {
+		files = append(files, img.Filename)
+		for _, diagram := range img.Diagrams {
+			if diagram.Plot != nil {
+				files = append(files, diagram.Plot.Image)
+			}
+		}
+	}
+	for _, table := range page.Tables {
+		if table.Image != "" {
+			files = append(files, table.Image)
+		}
+	}
+	for _, graph := range page.Graphs {
+		files = append(files, graph.Data)
+		if graph.Image != "" {
+			files = append(files, graph.Image)
+		}
+	}
+	for _, name := range files {
+		if err := copyOutputFile(filepath.Join(previous.dir, name), filepath.Join(outputDir, name)); err != nil {
+			c.logger.Debug("Extracting page %d again, previous output is incomplete: %v", number, err)
+			return PDFPage{}, false
+		}
+	}
+	page.Reused = true
+	c.logger.Debug("Page %d unchanged, reusing previous output", number)
+	return page, true
+}
+
+// copyOutputFile copies the file at src to dst.
+func copyOutputFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// pageHash returns a hash of the page dictionary and the objects it references, including
+// the decoded content streams, images and fonts. It returns "" when an object cannot be
+// read, for example a stream with a filter the PDF reader cannot decode.
+func pageHash(p pdf.Page) (sum string) {
+	defer func() {
+		if r := recover(); r != nil {
+			sum = ""
+		}
+	}()
+	h := sha256.New()
+	if err := hashValue(h, p.V, pageHashDepth); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashValue writes a PDF object to h, following references up to depth levels.
+func hashValue(h hash.Hash, v pdf.Value, depth int) error {
+	if depth == 0 {
+		return nil
+	}
+	fmt.Fprintf(h, "%d:", v.Kind())
+	switch v.Kind() {
+	case pdf.Bool:
+		fmt.Fprintf(h, "%t;", v.Bool())
+	case pdf.Integer:
+		fmt.Fprintf(h, "%d;", v.Int64())
+	case pdf.Real:
+		fmt.Fprintf(h, "%g;", v.Float64())
+	case pdf.String:
+		fmt.Fprintf(h, "%q;", v.RawString())
+	case pdf.Name:
+		fmt.Fprintf(h, "/%s;", v.Name())
+	case pdf.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := hashValue(h, v.Index(i), depth-1); err != nil {
+				return err
+			}
+		}
+	case pdf.Dict, pdf.Stream:
+		keys := v.Keys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			if pageHashSkipKeys[key] {
+				continue
+			}
+			fmt.Fprintf(h, "/%s=", key)
+			if err := hashValue(h, v.Key(key), depth-1); err != nil {
+				return err
+			}
+		}
+		if v.Kind() == pdf.Stream {
+			rd := v.Reader()
+			defer rd.Close()
+			if _, err := io.Copy(h, rd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
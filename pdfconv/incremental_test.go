@@ -0,0 +1,72 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// writeRevision writes a three-page PDF whose second page says text.
+func writeRevision(t *testing.T, path, text string) {
+	t.Helper()
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	for _, line := range []string{"Features of the device", text, "Package information"} {
+		doc.AddPage()
+		doc.Cell(40, 10, line)
+	}
+	if err := doc.OutputFileAndClose(path); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+}
+
+func TestConvertPDF_Incremental(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "manual.pdf")
+	outputBase := t.TempDir()
+	writeRevision(t, pdfPath, "Supply voltage 3.3 V")
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, Incremental: true}, logger.NewLogger("error"))
+
+	first, err := conv.ConvertPDF(pdfPath, outputBase)
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if first.ReusedPages != 0 {
+		t.Errorf("expected no reused pages on the first conversion, got %d", first.ReusedPages)
+	}
+	if _, err := os.Stat(filepath.Join(first.OutputDir, PageCacheFileName)); err != nil {
+		t.Fatalf("expected a page cache: %v", err)
+	}
+
+	writeRevision(t, pdfPath, "Supply voltage 5.0 V")
+	second, err := conv.ConvertPDF(pdfPath, outputBase)
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if second.ReusedPages != 2 {
+		t.Errorf("expected the two unchanged pages reused, got %d", second.ReusedPages)
+	}
+	data, err := os.ReadFile(second.MarkdownFile)
+	if err != nil {
+		t.Fatalf("failed to read Markdown: %v", err)
+	}
+	md := string(data)
+	if !strings.Contains(md, "5.0 V") || strings.Contains(md, "3.3 V") || !strings.Contains(md, "Features of the device") || !strings.Contains(md, "Package information") {
+		t.Errorf("expected the revised page merged with the reused ones, got:\n%s", md)
+	}
+
+	// A configuration change invalidates the cache
+	conv.config.IncludeTOC = true
+	third, err := conv.ConvertPDF(pdfPath, outputBase)
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if third.ReusedPages != 0 {
+		t.Errorf("expected no reused pages after a configuration change, got %d", third.ReusedPages)
+	}
+}
@@ -0,0 +1,249 @@
+// Package pdfconv - Language segmentation.
+// This file splits the text of multilingual documents, such as bilingual datasheets that
+// alternate English and Chinese sections, into runs of one script, tags each run with its
+// language in the Markdown and optionally keeps only the CONTENT_LANGUAGE_FILTER language.
+package pdfconv
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Language segmentation parameters
+const (
+	languageMinLetters = 2    // Lines with fewer letters have no language of their own
+	languageCJKWeight  = 3    // A Han, kana or Hangul character counts as this many letters
+	languageMinShare   = 0.05 // Languages below this share of the letters do not make a document multilingual
+)
+
+// scriptLanguages maps non-Latin scripts to the language their text is tagged with.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// TextSegment is a run of consecutive lines of page text in one language.
+type TextSegment struct {
+	Language string // Language tag such as "en" or "zh"
+	Text     string
+}
+
+// languageMarker is the Markdown comment written before text in a different language.
+func languageMarker(language string) string {
+	return fmt.Sprintf("<!-- lang: %s -->\n\n", language)
+}
+
+// latinLanguage returns the language that Latin script text is tagged with: the primary
+// language of the document when it is written in Latin script, else English.
+func latinLanguage(declared string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(declared), "-")
+	switch primary {
+	case "", "und", "zh", "ja", "ko", "ru", "uk", "bg", "el", "ar", "he", "th", "hi":
+		return "en"
+	}
+	return primary
+}
+
+// lineLanguage returns the language of a line of text from the script of its letters, or ""
+// when the line has too few letters, together with the weighted letter count. Han, kana and
+// Hangul characters weigh more than Latin letters, since each one is a syllable or a word;
+// a line with both Han and kana characters is Japanese.
+func lineLanguage(line, latin string) (string, int) {
+	counts := map[string]int{}
+	han, kana := 0, 0
+	for _, r := range line {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case !unicode.IsLetter(r):
+		case unicode.Is(unicode.Latin, r):
+			counts[latin]++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.script, r) {
+					weight := 1
+					if s.language == "ko" {
+						weight = languageCJKWeight
+					}
+					counts[s.language] += weight
+					break
+				}
+			}
+		}
+	}
+	if kana > 0 {
+		counts["ja"] += (han + kana) * languageCJKWeight
+	} else if han > 0 {
+		counts["zh"] += han * languageCJKWeight
+	}
+	best, total := "", 0
+	for language, n := range counts {
+		total += n
+		if n > counts[best] || n == counts[best] && language < best {
+			best = language
+		}
+	}
+	if total < languageMinLetters {
+		return "", total
+	}
+	return best, total
+}
+
+// segmentLanguages tags the text of multilingual documents by language. Every line gets the
+// language of its script; lines without letters, such as numbers or symbols, belong to the
+// language of the line before them. When more than one language makes up a noticeable share
+// of the document, pages are split into language segments for tagging, or, with
+// CONTENT_LANGUAGE_FILTER set, the lines in other languages are removed. Tables are always
+// kept. It returns the weighted letter count of each language found.
+func (c *PDFConverter) segmentLanguages(pages []PDFPage, declared string) map[string]int {
+	latin := latinLanguage(declared)
+	totals := map[string]int{}
+	for _, page := range pages {
+		for _, line := range strings.Split(page.Text, "\n") {
+			if language, n := lineLanguage(line, latin); language != "" {
+				totals[language] += n
+			}
+		}
+	}
+	if len(totals) == 0 {
+		return nil
+	}
+	if !multilingual(totals) {
+		return totals
+	}
+
+	filter := c.config.ContentLanguage
+	if filter == "off" {
+		filter = ""
+	}
+	removed := 0
+	for i := range pages {
+		page := &pages[i]
+		if page.Verbatim {
+			continue
+		}
+		segments := splitLanguageSegments(page.Text, latin)
+		if segments == nil {
+			continue // No letters on the page
+		}
+		if filter == "" {
+			page.Segments = segments
+		} else {
+			var kept []string
+			for _, segment := range segments {
+				if segment.Language == filter {
+					kept = append(kept, segment.Text)
+				} else {
+					removed += strings.Count(segment.Text, "\n") + 1
+				}
+			}
+			page.Text = strings.Join(kept, "\n")
+		}
+		c.segmentLines(page, latin, filter)
+	}
+	if filter != "" {
+		c.logger.Info("Kept %s text of a multilingual document, removed %d line(s) in other languages", filter, removed)
+	}
+	return totals
+}
+
+// multilingual reports whether more than one language has a noticeable share of the letters.
+func multilingual(totals map[string]int) bool {
+	sum := 0
+	for _, n := range totals {
+		sum += n
+	}
+	languages := 0
+	for _, n := range totals {
+		if float64(n) >= float64(sum)*languageMinShare {
+			languages++
+		}
+	}
+	return languages > 1
+}
+
+// splitLanguageSegments splits text into runs of consecutive lines in one language. Lines
+// without a language join the run before them, or the first run when they lead the text.
+func splitLanguageSegments(text, latin string) []TextSegment {
+	var segments []TextSegment
+	var leading []string
+	for _, line := range strings.Split(text, "\n") {
+		language, _ := lineLanguage(line, latin)
+		switch {
+		case len(segments) == 0 && language == "":
+			leading = append(leading, line)
+		case len(segments) == 0:
+			segments = append(segments, TextSegment{Language: language, Text: strings.Join(append(leading, line), "\n")})
+		case language == "" || language == segments[len(segments)-1].Language:
+			segments[len(segments)-1].Text += "\n" + line
+		default:
+			segments = append(segments, TextSegment{Language: language, Text: line})
+		}
+	}
+	return segments
+}
+
+// segmentLines tags the positioned lines of a page with their language, or, when filter is
+// set, removes the lines in other languages. Table rows and captions are kept, and the line
+// indices of the tables are updated.
+func (c *PDFConverter) segmentLines(page *PDFPage, latin, filter string) {
+	if len(page.Lines) == 0 {
+		return
+	}
+	inTable := make([]bool, len(page.Lines))
+	for _, table := range page.Tables {
+		for i := table.FirstLine; i <= table.LastLine && i < len(inTable); i++ {
+			inTable[i] = true
+		}
+		if table.CaptionLine >= 0 && table.CaptionLine < len(inTable) {
+			inTable[table.CaptionLine] = true
+		}
+	}
+	current := ""
+	newIndex := make([]int, len(page.Lines))
+	kept := page.Lines[:0:0]
+	for i, line := range page.Lines {
+		if language, _ := lineLanguage(line.Text, latin); language != "" {
+			current = language
+		}
+		newIndex[i] = len(kept)
+		if filter != "" && current != "" && current != filter && !inTable[i] {
+			newIndex[i] = -1
+			continue
+		}
+		if filter == "" {
+			line.Language = current
+		}
+		kept = append(kept, line)
+	}
+	if filter == "" {
+		// Leading lines without letters belong to the first language on the page
+		for i := range kept {
+			if kept[i].Language != "" {
+				for j := 0; j < i; j++ {
+					kept[j].Language = kept[i].Language
+				}
+				break
+			}
+		}
+	}
+	page.Lines = kept
+	for i := range page.Tables {
+		table := &page.Tables[i]
+		table.FirstLine, table.LastLine = newIndex[table.FirstLine], newIndex[table.LastLine]
+		if table.CaptionLine >= 0 {
+			table.CaptionLine = newIndex[table.CaptionLine]
+		}
+	}
+}
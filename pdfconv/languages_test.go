@@ -0,0 +1,95 @@
+package pdfconv
+
+import (
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestLineLanguage(t *testing.T) {
+	tests := []struct {
+		line, latin, want string
+	}{
+		{"Absolute Maximum Ratings", "en", "en"},
+		{"Absolute Maximum Ratings", "de", "de"},
+		{"绝对最大额定值", "en", "zh"},
+		{"電源電圧は安定している必要があります", "en", "ja"},
+		{"VDD 电源电压", "en", "zh"},
+		{"Напряжение питания", "en", "ru"},
+		{"3.3 V", "en", ""},
+		{"12345 -40 +85", "en", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := lineLanguage(tt.line, tt.latin); got != tt.want {
+			t.Errorf("lineLanguage(%q, %q) = %q, want %q", tt.line, tt.latin, got, tt.want)
+		}
+	}
+}
+
+func TestSegmentLanguages(t *testing.T) {
+	text := "1.2\nFeatures\nLow power operation\n特性\n低功耗工作\n3.3 V\nApplications"
+	segments := splitLanguageSegments(text, "en")
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %+v", segments)
+	}
+	if segments[0].Language != "en" || segments[0].Text != "1.2\nFeatures\nLow power operation" {
+		t.Errorf("unexpected first segment: %+v", segments[0])
+	}
+	if segments[1].Language != "zh" || segments[1].Text != "特性\n低功耗工作\n3.3 V" {
+		t.Errorf("unexpected second segment: %+v", segments[1])
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ContentLanguage: "off"}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	pages := []PDFPage{{Number: 1, Text: text}}
+	totals := conv.segmentLanguages(pages, "")
+	if totals["en"] == 0 || totals["zh"] == 0 || len(pages[0].Segments) != 3 {
+		t.Fatalf("expected an English and Chinese page, got totals=%v segments=%+v", totals, pages[0].Segments)
+	}
+	md := conv.generateMarkdown(pages)
+	en, zh := strings.Index(md, "<!-- lang: en -->"), strings.Index(md, "<!-- lang: zh -->")
+	if en < 0 || zh < 0 || en > strings.Index(md, "Features") || zh < strings.Index(md, "Low power") || zh > strings.Index(md, "特性") {
+		t.Errorf("expected language markers, got:\n%s", md)
+	}
+
+	// A single language, or a few words in another one, is not segmented
+	pages = []PDFPage{{Number: 1, Text: strings.Repeat("Low power operation for sensors\n", 10) + "特性"}}
+	if conv.segmentLanguages(pages, ""); pages[0].Segments != nil {
+		t.Errorf("expected no segments for an English document, got %+v", pages[0].Segments)
+	}
+}
+
+func TestSegmentLanguages_Filter(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ContentLanguage: "zh"}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	lines := []TextLine{
+		tableLine(720, "Electrical Characteristics"),
+		tableLine(700, "电气特性"),
+		tableLine(680, "Parameter", "Min", "Max"),
+		tableLine(660, "VDD", "1.8", "3.6"),
+		tableLine(640, "Notes apply to all devices"),
+		tableLine(620, "注释适用于所有器件"),
+	}
+	page := PDFPage{Number: 1, Lines: lines, Tables: []PDFTable{{FirstLine: 2, LastLine: 3, CaptionLine: -1}}}
+	for _, line := range lines {
+		page.Text += line.Text + "\n"
+	}
+	page.Text = strings.TrimSuffix(page.Text, "\n")
+	pages := []PDFPage{page}
+	conv.segmentLanguages(pages, "")
+
+	if strings.Contains(pages[0].Text, "Electrical") || !strings.Contains(pages[0].Text, "电气特性") {
+		t.Errorf("expected only Chinese text, got %q", pages[0].Text)
+	}
+	if len(pages[0].Lines) != 4 || pages[0].Lines[0].Text != "电气特性" {
+		t.Fatalf("expected the English lines removed, got %+v", pages[0].Lines)
+	}
+	if table := pages[0].Tables[0]; table.FirstLine != 1 || table.LastLine != 2 {
+		t.Errorf("expected the table moved to lines 1-2, got %d-%d", table.FirstLine, table.LastLine)
+	}
+	if pages[0].Segments != nil {
+		t.Errorf("expected no language markers when filtering, got %+v", pages[0].Segments)
+	}
+}
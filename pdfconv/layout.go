@@ -0,0 +1,237 @@
+// Package pdfconv - Page layout analysis.
+// This file reconstructs positioned text lines and image placements from page content
+// streams so figures can be interleaved with the surrounding text flow.
+package pdfconv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// TextLine is a line of page text together with its vertical position on the page.
+type TextLine struct {
+	Y        float64    // Baseline Y coordinate in points, increasing bottom to top
+	Size     float64    // Largest font size on the line in points
+	Text     string     // Cell texts joined by single spaces
+	Cells    []TextCell // Horizontally separated text fragments, left to right
+	Language string     // Language tag of multilingual documents, "" when not segmented
+}
+
+// TextCell is a run of text on a line separated from its neighbours by a column-sized gap.
+type TextCell struct {
+	X    float64 // Left X coordinate in points
+	Text string
+	Font string // Font of the first run of the cell, without the subset tag
+}
+
+// extractTextLines groups the positioned text runs of a page into lines ordered top to bottom.
+func (c *PDFConverter) extractTextLines(page pdf.Page) ([]TextLine, error) {
+	runs, err := pageTextRuns(page)
+	return groupTextLines(runs), err
+}
+
+// pageTextRuns returns the positioned glyphs of a page in content stream order.
+func pageTextRuns(page pdf.Page) (runs []pdf.Text, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			runs, err = nil, fmt.Errorf("failed to interpret page content: %v", r)
+		}
+	}()
+	return page.Content().Text, nil
+}
+
+// groupTextLines groups positioned text runs into lines ordered top to bottom.
+func groupTextLines(runs []pdf.Text) (lines []TextLine) {
+	type lineBuilder struct {
+		y      float64
+		size   float64
+		end    float64
+		cells  []TextCell
+		text   strings.Builder
+		hasEnd bool
+	}
+	endCell := func(b *lineBuilder) {
+		if text := strings.TrimSpace(b.text.String()); text != "" {
+			b.cells[len(b.cells)-1].Text = text
+		} else {
+			b.cells = b.cells[:len(b.cells)-1]
+		}
+		b.text.Reset()
+	}
+	var builders []*lineBuilder
+	for _, run := range runs {
+		tolerance := math.Max(2, run.FontSize*0.5)
+		var line *lineBuilder
+		for _, b := range builders {
+			if math.Abs(b.y-run.Y) <= tolerance {
+				line = b
+				break
+			}
+		}
+		if line == nil {
+			line = &lineBuilder{y: run.Y}
+			builders = append(builders, line)
+		}
+		// A gap wider than a character starts a new cell; smaller gaps are word breaks
+		// where the PDF positions words apart instead of emitting spaces
+		switch {
+		case !line.hasEnd:
+			line.cells = append(line.cells, TextCell{X: run.X, Font: baseFontName(run.Font)})
+		case run.X > line.end+math.Max(run.FontSize, 4):
+			endCell(line)
+			line.cells = append(line.cells, TextCell{X: run.X, Font: baseFontName(run.Font)})
+		case run.X > line.end+run.FontSize*0.2 && !strings.HasSuffix(line.text.String(), " ") && run.S != " ":
+			line.text.WriteString(" ")
+		}
+		line.text.WriteString(run.S)
+		line.size = math.Max(line.size, run.FontSize)
+		line.end = run.X + run.W
+		line.hasEnd = true
+	}
+
+	sort.SliceStable(builders, func(i, j int) bool { return builders[i].y > builders[j].y })
+	for _, b := range builders {
+		endCell(b)
+		if len(b.cells) == 0 {
+			continue
+		}
+		texts := make([]string, len(b.cells))
+		for i, cell := range b.cells {
+			texts[i] = cell.Text
+		}
+		lines = append(lines, TextLine{Y: b.y, Size: b.size, Text: strings.Join(texts, " "), Cells: b.cells})
+	}
+	return lines
+}
+
+// linesText joins the text of lines, one line per row.
+func linesText(lines []TextLine) string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// imagePlacements returns the top Y coordinate of every XObject drawn on the page, keyed by
+// resource name. Only the first placement of each XObject is recorded.
+func (c *PDFConverter) imagePlacements(page pdf.Page) (placements map[string]float64) {
+	placements = map[string]float64{}
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Debug("Failed to determine image placements: %v", r)
+		}
+	}()
+
+	type matrix [6]float64
+	multiply := func(a, b matrix) matrix {
+		return matrix{
+			a[0]*b[0] + a[1]*b[2], a[0]*b[1] + a[1]*b[3],
+			a[2]*b[0] + a[3]*b[2], a[2]*b[1] + a[3]*b[3],
+			a[4]*b[0] + a[5]*b[2] + b[4], a[4]*b[1] + a[5]*b[3] + b[5],
+		}
+	}
+	ctm := matrix{1, 0, 0, 1, 0, 0}
+	var stack []matrix
+
+	contents := page.V.Key("Contents")
+	if contents.IsNull() {
+		return placements
+	}
+	pdf.Interpret(contents, func(stk *pdf.Stack, op string) {
+		n := stk.Len()
+		args := make([]pdf.Value, n)
+		for i := n - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		switch op {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if len(stack) > 0 {
+				ctm = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(args) == 6 {
+				var m matrix
+				for i := range m {
+					m[i] = args[i].Float64()
+				}
+				ctm = multiply(m, ctm)
+			}
+		case "Do":
+			if len(args) == 1 {
+				name := args[0].Name()
+				if _, seen := placements[name]; !seen {
+					// The unit square is mapped through the CTM; its top edge is the higher corner
+					placements[name] = math.Max(ctm[5], ctm[5]+ctm[3])
+				}
+			}
+		}
+	})
+	return placements
+}
+
+// renderLayoutPage writes page text from its positioned lines, with detected tables rendered
+// as Markdown tables and images interleaved by their vertical position.
+// Images without a known position are appended after the text.
+func (c *PDFConverter) renderLayoutPage(md *strings.Builder, page PDFPage) {
+	var positioned, trailing []PDFImage
+	for _, img := range page.Images {
+		if img.HasPosition {
+			positioned = append(positioned, img)
+		} else {
+			trailing = append(trailing, img)
+		}
+	}
+	sort.SliceStable(positioned, func(i, j int) bool { return positioned[i].PositionY > positioned[j].PositionY })
+
+	var pending []string
+	language := ""
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if formatted := c.formatTextContent(c.markStyles(c.markCallouts(strings.Join(pending, "\n"), page), page)); formatted != "" {
+			md.WriteString(formatted)
+			md.WriteString("\n\n")
+		}
+		pending = nil
+	}
+	for i, line := range page.Lines {
+		table, inTable := tableAt(page.Tables, i)
+		if inTable && i > table.FirstLine {
+			continue // remaining rows were written with the header
+		}
+		if line.Language != "" && line.Language != language {
+			flush()
+			md.WriteString(languageMarker(line.Language))
+			language = line.Language
+		}
+		for len(positioned) > 0 && positioned[0].PositionY >= line.Y {
+			flush()
+			c.writeImageMarkdown(md, positioned[0])
+			positioned = positioned[1:]
+		}
+		switch {
+		case inTable && i == table.CaptionLine:
+			if !table.Merged {
+				pending = append(pending, line.Text)
+			}
+		case inTable:
+			flush()
+			c.writeTableMarkdown(md, table)
+		default:
+			pending = append(pending, line.Text)
+		}
+	}
+	flush()
+	for _, img := range append(positioned, trailing...) {
+		c.writeImageMarkdown(md, img)
+	}
+}
@@ -0,0 +1,91 @@
+package pdfconv
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/ledongthuc/pdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestExtractTextLinesAndImagePlacements(t *testing.T) {
+	pdfPath := createTempPDFWithFigure(t)
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	file, reader, err := pdf.Open(pdfPath)
+	if err != nil {
+		t.Fatalf("open pdf: %v", err)
+	}
+	defer file.Close()
+	page := reader.Page(1)
+
+	lines, err := conv.extractTextLines(page)
+	if err != nil {
+		t.Fatalf("extractTextLines() error = %v", err)
+	}
+	if len(lines) != 2 || lines[0].Text != "Top paragraph" || lines[1].Text != "Bottom paragraph" {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+
+	placements := conv.imagePlacements(page)
+	if len(placements) != 1 {
+		t.Fatalf("expected 1 image placement, got %v", placements)
+	}
+	for name, y := range placements {
+		if !(y < lines[0].Y && y > lines[1].Y) {
+			t.Errorf("image %s placed at y=%.1f, expected between %.1f and %.1f", name, y, lines[1].Y, lines[0].Y)
+		}
+	}
+}
+
+func TestRenderInlinePage_UnpositionedImagesTrail(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	page := PDFPage{
+		Number: 1,
+		Lines:  []TextLine{{Y: 700, Text: "first line"}, {Y: 300, Text: "second line"}},
+		Images: []PDFImage{{Filename: "loose.png"}, {Filename: "middle.png", PositionY: 500, HasPosition: true}},
+	}
+	var md strings.Builder
+	conv.renderLayoutPage(&md, page)
+	out := md.String()
+	first, middle, second, loose := strings.Index(out, "first line"), strings.Index(out, "middle.png"), strings.Index(out, "second line"), strings.Index(out, "loose.png")
+	if !(first < middle && middle < second && second < loose) {
+		t.Errorf("unexpected inline ordering:\n%s", out)
+	}
+}
+
+// createTempPDFWithFigure builds a single-page PDF with a paragraph, an image, and a second paragraph.
+func createTempPDFWithFigure(t *testing.T) string {
+	t.Helper()
+	pdfPath := filepath.Join(t.TempDir(), "figure.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	doc.AddPage()
+	doc.Cell(40, 10, "Top paragraph")
+
+	fig := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := 0; i < 16; i++ {
+		fig.Set(i, i, color.Black)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, fig); err != nil {
+		t.Fatalf("encode figure: %v", err)
+	}
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	doc.RegisterImageOptionsReader("figure", opts, &buf)
+	doc.ImageOptions("figure", 10, 30, 40, 40, false, opts, 0, "")
+
+	doc.SetY(90)
+	doc.Cell(40, 10, "Bottom paragraph")
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf with figure: %v", err)
+	}
+	return pdfPath
+}
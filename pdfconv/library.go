@@ -0,0 +1,158 @@
+// Package pdfconv - Library statistics.
+// This file aggregates the conversion reports of all outputs below an output base directory
+// into totals of documents, pages, images, tables and diagrams, quality scores and disk
+// usage, giving maintainers of a converted library an overview of their corpus.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"datasheet-to-md-mcp/uml"
+)
+
+// LibraryLowestQuality is the number of lowest quality documents listed in library statistics.
+const LibraryLowestQuality = 5
+
+// LibraryDocument is the summary of one converted document in library statistics.
+type LibraryDocument struct {
+	Dir      string  `json:"dir"`    // Output directory name
+	Source   string  `json:"source"` // Path of the converted document
+	Status   string  `json:"status"`
+	Pages    int     `json:"pages"`
+	Images   int     `json:"images"`
+	Tables   int     `json:"tables"`
+	Diagrams int     `json:"diagrams"`
+	Quality  float64 `json:"quality"` // Quality score from 0 to 100
+	Bytes    int64   `json:"bytes"`   // Disk usage of the output directory
+}
+
+// LibraryStats aggregates the converted documents below an output base directory.
+type LibraryStats struct {
+	BaseDir       string            `json:"base_dir"`
+	Documents     int               `json:"documents"`
+	PartialCount  int               `json:"partial"`    // Documents converted without some failed pages
+	Unreported    int               `json:"unreported"` // Output directories without a readable conversion report
+	Pages         int               `json:"pages"`
+	Images        int               `json:"images"`
+	Tables        int               `json:"tables"`
+	Diagrams      int               `json:"diagrams"`
+	MeanQuality   float64           `json:"mean_quality"`
+	MinQuality    float64           `json:"min_quality"`
+	MaxQuality    float64           `json:"max_quality"`
+	Bytes         int64             `json:"bytes"`                    // Disk usage of all output directories
+	LowestQuality []LibraryDocument `json:"lowest_quality,omitempty"` // Up to LibraryLowestQuality documents, lowest first
+}
+
+// pageContentCounts returns the number of tables, not counting continuations merged into
+// the table they continue, and of diagrams written for the images of pages.
+func pageContentCounts(pages []PDFPage) (tables, diagrams int) {
+	for _, page := range pages {
+		for _, table := range page.Tables {
+			if !table.Merged {
+				tables++
+			}
+		}
+		for _, img := range page.Images {
+			diagrams += writtenDiagrams(img.Diagrams)
+		}
+	}
+	return tables, diagrams
+}
+
+// writtenDiagrams counts the diagrams that are written, leaving out the types
+// DETECT_DIAGRAM_TYPES does not select.
+func writtenDiagrams(diagrams []uml.DetectedDiagram) int {
+	n := 0
+	for _, diagram := range diagrams {
+		if !diagram.Omitted {
+			n++
+		}
+	}
+	return n
+}
+
+// CollectLibraryStats reads the conversion report of every MARKDOWN_* output directory
+// directly below baseDir. Directories without a readable report, such as outputs of older
+// versions, count towards disk usage only.
+func CollectLibraryStats(baseDir string) (*LibraryStats, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %v", err)
+	}
+	stats := &LibraryStats{BaseDir: baseDir}
+	var qualityTotal float64
+	var docs []LibraryDocument
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "MARKDOWN_") {
+			continue
+		}
+		dir := filepath.Join(baseDir, e.Name())
+		size := dirSize(dir)
+		stats.Bytes += size
+		data, err := os.ReadFile(filepath.Join(dir, ReportFileName))
+		var report ConversionReport
+		if err == nil {
+			err = json.Unmarshal(data, &report)
+		}
+		if err != nil {
+			stats.Unreported++
+			continue
+		}
+		doc := LibraryDocument{Dir: e.Name(), Source: report.Source, Status: report.Status, Pages: report.PageCount, Images: report.ImageCount, Tables: report.TableCount, Diagrams: report.DiagramCount, Quality: report.Quality.Score, Bytes: size}
+		if doc.Status == "" {
+			doc.Status = StatusComplete
+		}
+		if doc.Status == StatusPartial {
+			stats.PartialCount++
+		}
+		if stats.Documents == 0 || doc.Quality < stats.MinQuality {
+			stats.MinQuality = doc.Quality
+		}
+		if doc.Quality > stats.MaxQuality {
+			stats.MaxQuality = doc.Quality
+		}
+		stats.Documents++
+		stats.Pages += doc.Pages
+		stats.Images += doc.Images
+		stats.Tables += doc.Tables
+		stats.Diagrams += doc.Diagrams
+		qualityTotal += doc.Quality
+		docs = append(docs, doc)
+	}
+	if stats.Documents > 0 {
+		stats.MeanQuality = qualityTotal / float64(stats.Documents)
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].Quality < docs[j].Quality })
+	if len(docs) > LibraryLowestQuality {
+		docs = docs[:LibraryLowestQuality]
+	}
+	stats.LowestQuality = docs
+	return stats, nil
+}
+
+// Summary renders the statistics as text.
+func (s LibraryStats) Summary() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "Library Statistics: %s\n\n", s.BaseDir)
+	fmt.Fprintf(&out, "Documents: %d (%d partial)\n", s.Documents, s.PartialCount)
+	if s.Unreported > 0 {
+		fmt.Fprintf(&out, "Outputs Without Report: %d\n", s.Unreported)
+	}
+	fmt.Fprintf(&out, "Pages: %d\nImages: %d\nTables: %d\nDiagrams: %d\n", s.Pages, s.Images, s.Tables, s.Diagrams)
+	if s.Documents > 0 {
+		fmt.Fprintf(&out, "Quality: mean %.1f, min %.1f, max %.1f\n", s.MeanQuality, s.MinQuality, s.MaxQuality)
+	}
+	fmt.Fprintf(&out, "Disk Usage: %s\n", FormatBytes(s.Bytes))
+	if len(s.LowestQuality) > 0 {
+		out.WriteString("\nLowest Quality:\n")
+		for _, doc := range s.LowestQuality {
+			fmt.Fprintf(&out, "- %s: %.1f/100, %d page(s), %s\n", doc.Dir, doc.Quality, doc.Pages, doc.Status)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,186 @@
+// Package pdfconv - Output link validation.
+// This file checks the links and images referenced by generated Markdown: relative files
+// must exist in the output directory and in-document anchors must resolve, so broken
+// references are reported with the conversion instead of surfacing in a doc site build.
+package pdfconv
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// markdownLinkPattern matches inline links and images and captures the target, without
+	// an optional title.
+	markdownLinkPattern = regexp.MustCompile(`!?\[(?:[^\]\\]|\\.)*\]\(\s*<?([^)\s>]*)>?(?:\s+"[^"]*")?\s*\)`)
+	// htmlAnchorPattern matches explicit HTML anchors such as <a id="figure-3"></a>.
+	htmlAnchorPattern = regexp.MustCompile(`<a\s+(?:id|name)="([^"]+)"`)
+	// externalLinkPattern matches link targets with a URL scheme (http:, mailto:, ...).
+	externalLinkPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+	// htmlTagPattern matches inline HTML tags, which do not contribute to heading slugs.
+	htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// BrokenLink is a link or image in the generated Markdown whose target does not resolve.
+type BrokenLink struct {
+	File   string `json:"file"` // Markdown file, relative to the output directory
+	Line   int    `json:"line"`
+	Target string `json:"target"`
+	Reason string `json:"reason"`
+}
+
+// String renders the broken link for tool output.
+func (b BrokenLink) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", b.File, b.Line, b.Target, b.Reason)
+}
+
+// checkLinks validates the links in the Markdown file at dir/name. Relative targets must
+// exist below dir, and fragments must match a heading or HTML anchor of the target file.
+// External URLs are not checked.
+func checkLinks(dir, name string) ([]BrokenLink, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s for link check: %v", name, err)
+	}
+	return checkMarkdownLinks(dir, name, string(data)), nil
+}
+
+// checkMarkdownLinks validates the links in Markdown that is written to dir/name, either
+// as is or converted to another output format.
+func checkMarkdownLinks(dir, name, markdown string) []BrokenLink {
+	anchorCache := map[string]map[string]bool{name: markdownAnchors(markdown)}
+
+	var broken []BrokenLink
+	var fences fenceTracker
+	for i, line := range strings.Split(markdown, "\n") {
+		if fences.inCode(line) {
+			continue
+		}
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(stripInlineCode(line), -1) {
+			target := m[1]
+			if target == "" || externalLinkPattern.MatchString(target) {
+				continue
+			}
+			if reason := checkLinkTarget(dir, name, target, anchorCache); reason != "" {
+				broken = append(broken, BrokenLink{File: name, Line: i + 1, Target: target, Reason: reason})
+			}
+		}
+	}
+	return broken
+}
+
+// logBrokenLinks warns about the broken links found in a document's output.
+func (c *PDFConverter) logBrokenLinks(docPath string, broken []BrokenLink) {
+	for _, link := range broken {
+		c.logger.Warn("Broken link in output of %s: %s", docPath, link)
+	}
+}
+
+// checkLinkTarget returns why a relative link target in file name does not resolve, or "".
+func checkLinkTarget(dir, name, target string, anchorCache map[string]map[string]bool) string {
+	path, fragment, _ := strings.Cut(target, "#")
+	file := name
+	if path != "" {
+		unescaped, err := url.PathUnescape(path)
+		if err != nil {
+			return "invalid escape in path"
+		}
+		file = filepath.ToSlash(filepath.Clean(filepath.Join(filepath.Dir(name), unescaped)))
+		if file == ".." || strings.HasPrefix(file, "../") {
+			return "points outside the output directory"
+		}
+		info, err := os.Stat(filepath.Join(dir, file))
+		if err != nil {
+			return "file not found"
+		}
+		if info.IsDir() {
+			file = filepath.ToSlash(filepath.Join(file, "README.md"))
+			if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+				return "directory has no README.md"
+			}
+		}
+	}
+	if fragment == "" {
+		return ""
+	}
+	if !strings.HasSuffix(strings.ToLower(file), ".md") {
+		return ""
+	}
+	anchors, ok := anchorCache[file]
+	if !ok {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return "file not readable"
+		}
+		anchors = markdownAnchors(string(data))
+		anchorCache[file] = anchors
+	}
+	if !anchors[fragment] {
+		return "anchor not found"
+	}
+	return ""
+}
+
+// markdownAnchors returns the anchors of a Markdown document: explicit HTML anchors and
+// the GitHub-style slugs of its headings, with "-1", "-2" suffixes for repeated headings.
+func markdownAnchors(markdown string) map[string]bool {
+	anchors := map[string]bool{}
+	seen := map[string]int{}
+	var fences fenceTracker
+	for _, line := range strings.Split(markdown, "\n") {
+		if fences.inCode(line) {
+			continue
+		}
+		for _, m := range htmlAnchorPattern.FindAllStringSubmatch(line, -1) {
+			anchors[m[1]] = true
+		}
+		m := markdownHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		slug := headingSlug(m[2])
+		if n := seen[slug]; n > 0 {
+			anchors[fmt.Sprintf("%s-%d", slug, n)] = true
+		} else {
+			anchors[slug] = true
+		}
+		seen[slug]++
+	}
+	return anchors
+}
+
+// headingSlug returns the anchor GitHub generates for a heading: link targets and
+// HTML tags reduced to their text, lower case, punctuation removed and spaces as hyphens.
+func headingSlug(heading string) string {
+	heading = markdownLinkPattern.ReplaceAllStringFunc(heading, func(link string) string {
+		start, end := strings.Index(link, "["), strings.LastIndex(link, "]")
+		return link[start+1 : end]
+	})
+	heading = htmlTagPattern.ReplaceAllString(heading, "")
+	var slug strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			slug.WriteRune(r)
+		case r == ' ':
+			slug.WriteRune('-')
+		}
+	}
+	return slug.String()
+}
+
+// stripInlineCode blanks out `inline code` spans so links shown as code are not checked.
+func stripInlineCode(line string) string {
+	parts := strings.Split(line, "`")
+	for i := 1; i < len(parts); i += 2 {
+		if i < len(parts)-1 {
+			parts[i] = ""
+		}
+	}
+	return strings.Join(parts, "`")
+}
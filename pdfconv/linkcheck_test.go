@@ -0,0 +1,109 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCheckLinks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "SECTION_01_intro"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"image_ab.png":               "png",
+		"pin map.png":                "png",
+		"SECTION_01_intro/README.md": "# Intro\n\n## Pin Description\n",
+		"README.md": strings.Join([]string{
+			"# Title",
+			"## Electrical Characteristics (DC)",
+			"## Notes",
+			"## Notes",
+			`<a id="figure-1"></a>`,
+			"![Image](./image_ab.png) ![Pins](pin%20map.png)",
+			"![Missing](./image_cd.png)",
+			"[DC](#electrical-characteristics-dc) [second](#notes-1) [fig](#figure-1) [bad](#notes-2)",
+			"[intro](SECTION_01_intro/README.md#pin-description) [dir](SECTION_01_intro/) [gone](SECTION_01_intro/README.md#pinout)",
+			"[web](https://example.com/x.png) [mail](mailto:a@example.com) [up](../other.md)",
+			"`[code](missing.md)`",
+			"```",
+			"![fenced](missing.png)",
+			"```",
+		}, "\n"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broken, err := checkLinks(dir, "README.md")
+	if err != nil {
+		t.Fatalf("checkLinks() error = %v", err)
+	}
+	var got []string
+	for _, b := range broken {
+		got = append(got, b.String())
+	}
+	want := []string{
+		"README.md:7: ./image_cd.png (file not found)",
+		"README.md:8: #notes-2 (anchor not found)",
+		"README.md:9: SECTION_01_intro/README.md#pinout (anchor not found)",
+		"README.md:10: ../other.md (points outside the output directory)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("checkLinks() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHeadingSlug(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Page 3", "page-3"},
+		{"1.2 Absolute Maximum Ratings", "12-absolute-maximum-ratings"},
+		{"I²C Interface — Timing", "ic-interface--timing"},
+		{`<a id="section-x"></a>Pin [Map](#map)`, "pin-map"},
+	}
+	for _, tt := range tests {
+		if got := headingSlug(tt.in); got != tt.want {
+			t.Errorf("headingSlug(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertPDF_NoBrokenLinks(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, IncludeTOC: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempPDFWithRawImage(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if len(res.BrokenLinks) != 0 {
+		t.Errorf("expected no broken links, got %v", res.BrokenLinks)
+	}
+
+	// Removing an image afterwards is caught by a re-check
+	for _, name := range mustGlob(t, filepath.Join(res.OutputDir, "image_*.png")) {
+		os.Remove(name)
+	}
+	broken, err := checkLinks(res.OutputDir, "README.md")
+	if err != nil {
+		t.Fatalf("checkLinks() error = %v", err)
+	}
+	if len(broken) != 1 || broken[0].Reason != "file not found" {
+		t.Errorf("expected one missing image, got %v", broken)
+	}
+}
+
+func mustGlob(t *testing.T, pattern string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(pattern)
+	if err != nil || len(matches) == 0 {
+		t.Fatalf("no files match %s: %v", pattern, err)
+	}
+	return matches
+}
@@ -0,0 +1,84 @@
+// Package pdfconv - Document listing.
+// This file lists the supported documents of an input directory with their size,
+// modification time and page count, so a client can browse the input corpus before
+// deciding what to convert. Only the page count is read from each file.
+package pdfconv
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DocumentFile is a supported document found below an input directory.
+type DocumentFile struct {
+	Path             string    `json:"path"`
+	Format           string    `json:"format"` // "pdf", "xps" or "djvu"
+	Size             int64     `json:"size"`
+	Modified         time.Time `json:"modified"`
+	PageCount        int       `json:"page_count"`                  // 0 when the pages could not be counted
+	PasswordRequired bool      `json:"password_required,omitempty"` // Encrypted with a user password; the pages cannot be counted
+}
+
+// ListDocuments returns the supported documents below dir found by the same discovery as
+// batch conversion, in discovery order. Files whose pages cannot be counted are listed with
+// a page count of 0.
+func (c *PDFConverter) ListDocuments(dir string) ([]DocumentFile, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("input directory does not exist: %s", dir)
+	}
+	files, err := c.findPDFFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find PDF files: %v", err)
+	}
+	documents := make([]DocumentFile, 0, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			c.logger.Warn("Failed to read %s: %v", path, err)
+			continue
+		}
+		document := DocumentFile{
+			Path:     path,
+			Format:   documentFormats[strings.ToLower(filepath.Ext(path))],
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		}
+		document.PageCount, err = c.documentPageCount(path, document.Format)
+		if errors.Is(err, ErrEncrypted) {
+			document.PasswordRequired = true
+		} else if err != nil {
+			c.logger.Debug("Failed to count the pages of %s: %v", path, err)
+		}
+		documents = append(documents, document)
+	}
+	c.logger.Debug("Listed %d document(s) in %s", len(documents), dir)
+	return documents, nil
+}
+
+// documentPageCount returns the number of pages of a document without extracting them.
+func (c *PDFConverter) documentPageCount(path, format string) (int, error) {
+	switch format {
+	case "xps":
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open XPS package: %v", err)
+		}
+		defer archive.Close()
+		pagePaths, err := xpsPagePaths(&archive.Reader)
+		return len(pagePaths), err
+	case "djvu":
+		return djvuPageCount(path)
+	default:
+		reader, closeFile, _, err := c.openPDF(path)
+		if err != nil {
+			return 0, err
+		}
+		defer closeFile()
+		return reader.NumPage(), nil
+	}
+}
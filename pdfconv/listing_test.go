@@ -0,0 +1,62 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestListDocuments(t *testing.T) {
+	dir := t.TempDir()
+	writeTitlePage(t, filepath.Join(dir, "lm317.pdf"), "LM317")
+	locked := gofpdf.New("P", "mm", "A4", "")
+	locked.SetProtection(gofpdf.CnProtectPrint, "secret", "owner")
+	locked.AddPage()
+	if err := locked.OutputFileAndClose(filepath.Join(dir, "locked.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "app-notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	xps, err := os.ReadFile(createTempXPS(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app-notes", "an-1234.xps"), xps, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a document"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+
+	documents, err := conv.ListDocuments(dir)
+	if err != nil {
+		t.Fatalf("ListDocuments() error = %v", err)
+	}
+	if len(documents) != 3 {
+		t.Fatalf("expected three documents, got %+v", documents)
+	}
+	byName := map[string]DocumentFile{}
+	for _, d := range documents {
+		byName[filepath.Base(d.Path)] = d
+	}
+	if d := byName["lm317.pdf"]; d.Format != "pdf" || d.PageCount != 1 || d.Size == 0 || d.Modified.IsZero() || d.PasswordRequired {
+		t.Errorf("unexpected listing of lm317.pdf: %+v", d)
+	}
+	if d := byName["locked.pdf"]; !d.PasswordRequired || d.PageCount != 0 {
+		t.Errorf("expected locked.pdf listed as password protected, got %+v", d)
+	}
+	if d := byName["an-1234.xps"]; d.Format != "xps" || d.PageCount != 2 {
+		t.Errorf("expected the XPS pages counted, got %+v", d)
+	}
+
+	if _, err := conv.ListDocuments(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
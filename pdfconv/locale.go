@@ -0,0 +1,193 @@
+// Package pdfconv - Locale-aware number and date normalization.
+// This file rewrites numbers written with the conventions of the document's locale, such as
+// "1.234,5" in German datasheets, into the plain "1234.5" form, and dates into ISO 8601.
+package pdfconv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// numberConventions describes how a locale writes numbers and dates.
+type numberConventions struct {
+	Decimal  rune   // Decimal separator
+	Group    string // Digit group separators
+	DayFirst bool   // Numeric dates are written day, month, year
+}
+
+// numberLocales holds the conventions for each NUMBER_LOCALE value. Spaces used for digit
+// grouping are only recognized as no-break spaces, since plain spaces also separate values.
+var numberLocales = map[string]numberConventions{
+	"de":    {Decimal: ',', Group: ".\u00a0\u202f", DayFirst: true},
+	"es":    {Decimal: ',', Group: ".\u00a0\u202f", DayFirst: true},
+	"it":    {Decimal: ',', Group: ".\u00a0\u202f", DayFirst: true},
+	"nl":    {Decimal: ',', Group: ".\u00a0\u202f", DayFirst: true},
+	"pt":    {Decimal: ',', Group: ".\u00a0\u202f", DayFirst: true},
+	"da":    {Decimal: ',', Group: ".\u00a0\u202f", DayFirst: true},
+	"fr":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"sv":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"fi":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"nb":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"pl":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"cs":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"ru":    {Decimal: ',', Group: "\u00a0\u202f", DayFirst: true},
+	"en":    {Decimal: '.', Group: ","},
+	"en-gb": {Decimal: '.', Group: ",", DayFirst: true},
+	"ja":    {Decimal: '.', Group: ","},
+	"zh":    {Decimal: '.', Group: ","},
+}
+
+// numericDatePattern matches dates such as 15.03.2024, 15/03/2024 or 3-15-2024.
+var numericDatePattern = regexp.MustCompile(`\b(\d{1,2})([./-])(\d{1,2})([./-])(\d{4})\b`)
+
+// normalizeNumberLocale rewrites the numbers and dates in page text, positioned lines and
+// table cells according to NUMBER_LOCALE. It runs before spec table formatting so that
+// "3,3 V" is recognized as a number there.
+func (c *PDFConverter) normalizeNumberLocale(pages []PDFPage) {
+	conv, ok := numberLocales[c.config.NumberLocale]
+	if !ok {
+		return
+	}
+	for i := range pages {
+		page := &pages[i]
+		page.Text = conv.normalize(page.Text)
+		for j := range page.Lines {
+			line := &page.Lines[j]
+			line.Text = conv.normalize(line.Text)
+			for k := range line.Cells {
+				line.Cells[k].Text = conv.normalize(line.Cells[k].Text)
+			}
+		}
+		for j := range page.Tables {
+			table := &page.Tables[j]
+			table.Caption = conv.normalize(table.Caption)
+			for k := range table.Header {
+				table.Header[k] = conv.normalize(table.Header[k])
+			}
+			for _, row := range table.Rows {
+				for k := range row {
+					row[k] = conv.normalize(row[k])
+				}
+			}
+		}
+	}
+}
+
+// normalize rewrites the dates and numbers in s.
+func (n numberConventions) normalize(s string) string {
+	if s == "" {
+		return s
+	}
+	s = numericDatePattern.ReplaceAllStringFunc(s, n.normalizeDate)
+
+	var out strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		if !unicode.IsDigit(runes[i]) {
+			out.WriteRune(runes[i])
+			i++
+			continue
+		}
+		// Take the longest run of digits and separators, without trailing separators
+		end := i
+		for end < len(runes) && isNumberRune(runes[end], n) {
+			end++
+		}
+		for end > i && !unicode.IsDigit(runes[end-1]) {
+			end--
+		}
+		// Numbers glued to letters are part numbers or designators ("74HC", "R2,2"), not values
+		glued := i > 0 && unicode.IsLetter(runes[i-1]) || end < len(runes) && unicode.IsLetter(runes[end]) && !isUnitStart(runes[end:])
+		if glued {
+			out.WriteString(string(runes[i:end]))
+			i = end
+			continue
+		}
+		out.WriteString(n.normalizeNumber(string(runes[i:end])))
+		i = end
+	}
+	return out.String()
+}
+
+// normalizeNumber rewrites a single number token, or returns it unchanged when its
+// separators do not follow the locale's conventions unambiguously.
+func (n numberConventions) normalizeNumber(tok string) string {
+	decimal := string(n.Decimal)
+	intPart, frac, hasDecimal := strings.Cut(tok, decimal)
+	if hasDecimal && (frac == "" || strings.ContainsAny(frac, decimal+n.Group)) {
+		return tok
+	}
+	// Split the integer part into digit groups on any group separator
+	groups := strings.FieldsFunc(intPart, func(r rune) bool { return strings.ContainsRune(n.Group, r) })
+	if len(groups) > 1 {
+		if len(groups[0]) > 3 {
+			return tok
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return tok
+			}
+		}
+		// A single "1.234" is a decimal number in most documents; only treat the dot as a
+		// group separator when the number also has a decimal part or several groups
+		if strings.Contains(intPart, ".") && !hasDecimal && len(groups) == 2 {
+			return tok
+		}
+	} else if strings.ContainsAny(intPart, n.Group) {
+		return tok
+	}
+	result := strings.Join(groups, "")
+	if hasDecimal {
+		result += "." + frac
+	}
+	return result
+}
+
+// normalizeDate rewrites a numeric date as YYYY-MM-DD, leaving invalid dates unchanged.
+func (n numberConventions) normalizeDate(date string) string {
+	m := numericDatePattern.FindStringSubmatch(date)
+	if m[2] != m[4] {
+		return date
+	}
+	first, _ := strconv.Atoi(m[1])
+	second, _ := strconv.Atoi(m[3])
+	day, month := second, first
+	if n.DayFirst {
+		day, month = first, second
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return date
+	}
+	return fmt.Sprintf("%s-%02d-%02d", m[5], month, day)
+}
+
+// isNumberRune reports whether r can be part of a number in the locale.
+func isNumberRune(r rune, n numberConventions) bool {
+	return unicode.IsDigit(r) || r == n.Decimal || strings.ContainsRune(n.Group, r)
+}
+
+// isUnitStart reports whether the letters following a number look like a unit ("3,3V",
+// "2,5mA") rather than a part number suffix ("74HC").
+func isUnitStart(rest []rune) bool {
+	word := rest
+	for i, r := range rest {
+		if !unicode.IsLetter(r) {
+			word = rest[:i]
+			break
+		}
+	}
+	_, ok := specUnitReplacements[string(word)]
+	return ok || knownUnits[string(word)]
+}
+
+// knownUnits are the unit symbols that may directly follow a number.
+var knownUnits = map[string]bool{
+	"V": true, "mV": true, "µV": true, "kV": true, "A": true, "mA": true, "µA": true, "nA": true, "pA": true,
+	"W": true, "mW": true, "µW": true, "Ω": true, "kΩ": true, "MΩ": true, "F": true, "µF": true, "nF": true, "pF": true,
+	"H": true, "mH": true, "µH": true, "nH": true, "Hz": true, "kHz": true, "MHz": true, "GHz": true,
+	"s": true, "ms": true, "µs": true, "ns": true, "ps": true, "m": true, "mm": true, "cm": true, "g": true, "mg": true, "kg": true,
+	"dB": true, "dBm": true, "bit": true, "bits": true, "kB": true, "MB": true, "GB": true, "ppm": true, "K": true,
+}
@@ -0,0 +1,65 @@
+package pdfconv
+
+import (
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestNumberConventionsNormalize(t *testing.T) {
+	tests := []struct {
+		locale, in, want string
+	}{
+		{"de", "VDD = 3,3 V", "VDD = 3.3 V"},
+		{"de", "1.234,56 mA und 1.234.567 Zyklen", "1234.56 mA und 1234567 Zyklen"},
+		{"de", "2,5mA bei -40,0 °C", "2.5mA bei -40.0 °C"},
+		{"de", "Stand: 15.03.2024", "Stand: 2024-03-15"},
+		{"de", "Kapitel 4.2.1, Version 1.234, Werte 1,2,3", "Kapitel 4.2.1, Version 1.234, Werte 1,2,3"},
+		{"de", "74HC595 und R2,2", "74HC595 und R2,2"},
+		{"de", "Ende: 3,3.", "Ende: 3.3."},
+		{"fr", "1\u00a0234,5 Ω", "1234.5 Ω"},
+		{"en", "1,234.5 cycles on 03/15/2024", "1234.5 cycles on 2024-03-15"},
+		{"en-gb", "15/03/2024", "2024-03-15"},
+		{"en", "13/45/2024 and 1,23", "13/45/2024 and 1,23"},
+	}
+	for _, tt := range tests {
+		if got := numberLocales[tt.locale].normalize(tt.in); got != tt.want {
+			t.Errorf("%s: normalize(%q) = %q, want %q", tt.locale, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNumberLocalesCoverConfig(t *testing.T) {
+	for _, locale := range config.NumberLocales {
+		if _, ok := numberLocales[locale]; !ok {
+			t.Errorf("NUMBER_LOCALE %q has no number conventions", locale)
+		}
+	}
+}
+
+func TestNormalizeNumberLocale_BeforeSpecTables(t *testing.T) {
+	cfg := &config.Config{ExtractTables: true, NormalizeSpecTables: true, NumberLocale: "de"}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	pages := []PDFPage{{
+		Number: 1,
+		Text:   "Versorgung 3,3 V",
+		Tables: []PDFTable{{Header: []string{"Parameter", "Min", "Typ", "Max"}, Rows: [][]string{{"VDD", "– 3,0 V", "3,3V", "3,6 V"}}}},
+	}}
+	conv.finishPages(pages)
+	if pages[0].Text != "Versorgung 3.3 V" {
+		t.Errorf("unexpected text %q", pages[0].Text)
+	}
+	row := pages[0].Tables[0].Rows[0]
+	if row[1] != "-3.0 V" || row[2] != "3.3 V" || row[3] != "3.6 V" {
+		t.Errorf("expected normalized spec values, got %q", row)
+	}
+
+	// Off by default
+	conv, _ = NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	pages = []PDFPage{{Number: 1, Text: "3,3 V"}}
+	conv.finishPages(pages)
+	if pages[0].Text != "3,3 V" {
+		t.Errorf("expected no normalization when NUMBER_LOCALE is unset, got %q", pages[0].Text)
+	}
+}
@@ -0,0 +1,274 @@
+// Package pdfconv - Document lookup.
+// This file finds documents in an input directory by part number or keyword, matching file
+// names and the text of the first page, so a request such as "convert the LM317 datasheet"
+// can be resolved to a file without its exact path. First page text is cached per file and
+// read again only when the file changes.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Lookup settings
+const (
+	DefaultLookupResults = 10  // Candidates returned when no limit is given
+	lookupSnippetChars   = 120 // First page text shown around a match
+)
+
+// Match confidences by where a query term was found. Matches that need a typo correction
+// rank below exact matches of the same kind.
+const (
+	nameExactConfidence  = 1.0
+	nameTokenConfidence  = 0.9
+	nameFuzzyConfidence  = 0.6
+	pageExactConfidence  = 0.8
+	pageTokenConfidence  = 0.7
+	pageFuzzyConfidence  = 0.4
+	lookupMatchFileName  = "file name"
+	lookupMatchFirstPage = "first page"
+)
+
+// DocumentMatch is a candidate document for a lookup query.
+type DocumentMatch struct {
+	Path       string
+	Confidence float64 // From 0 to 1; the mean of the best match of every query term
+	MatchedIn  string  // Where the best match was found: "file name" or "first page"
+	Snippet    string  // First page text around the first page match, if any
+}
+
+// firstPageIndex caches the first page text of documents.
+type firstPageIndex struct {
+	mu      sync.Mutex
+	entries map[string]indexedPage
+}
+
+// indexedPage is the cached first page text of a file at a given size and modification time.
+type indexedPage struct {
+	size    int64
+	modTime time.Time
+	text    string
+}
+
+// FindDocuments searches the supported documents below dir for a part number or keywords
+// and returns up to limit candidates, best first. Every term of the query must match the file
+// name or the first page text: exactly, as part of a longer word (such as LM317 in
+// LM317T), or with a single typo.
+func (c *PDFConverter) FindDocuments(dir, query string, limit int) ([]DocumentMatch, error) {
+	terms := lookupTokens(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query must contain a part number or keyword")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("input directory does not exist: %s", dir)
+	}
+	if limit <= 0 {
+		limit = DefaultLookupResults
+	}
+	files, err := c.findPDFFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []DocumentMatch
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		nameTokens := append(lookupTokens(strings.ReplaceAll(name, "_", " ")), normalizeLookup(name))
+		var pageText string
+		var pageTokens []string
+		pageRead := false
+		total := 0.0
+		match := DocumentMatch{Path: path}
+		best := 0.0
+		for _, term := range terms {
+			score, where := termScore(term, nameTokens, nameExactConfidence, nameTokenConfidence, nameFuzzyConfidence), lookupMatchFileName
+			if score < nameExactConfidence {
+				if !pageRead {
+					pageText, pageRead = c.firstPageText(path), true
+					pageTokens = lookupTokens(pageText)
+				}
+				if pageScore := termScore(term, pageTokens, pageExactConfidence, pageTokenConfidence, pageFuzzyConfidence); pageScore > score {
+					score, where = pageScore, lookupMatchFirstPage
+					if match.Snippet == "" {
+						match.Snippet = lookupSnippet(pageText, term)
+					}
+				}
+			}
+			if score == 0 {
+				total = 0
+				break
+			}
+			if score > best {
+				best, match.MatchedIn = score, where
+			}
+			total += score
+		}
+		if total == 0 {
+			continue
+		}
+		match.Confidence = float64(int(total/float64(len(terms))*100+0.5)) / 100
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Confidence != matches[j].Confidence {
+			return matches[i].Confidence > matches[j].Confidence
+		}
+		return matches[i].Path < matches[j].Path
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	c.logger.Debug("Lookup %q in %s: %d candidate(s) of %d file(s)", query, dir, len(matches), len(files))
+	return matches, nil
+}
+
+// termScore returns the confidence of the best match of a normalized term among tokens.
+func termScore(term string, tokens []string, exact, partial, fuzzy float64) float64 {
+	best := 0.0
+	for _, token := range tokens {
+		switch {
+		case token == term:
+			return exact
+		case len(term) >= 3 && strings.Contains(token, term):
+			best = max(best, partial)
+		case len(term) >= 4 && editDistanceAtMost1(term, token):
+			best = max(best, fuzzy)
+		}
+	}
+	return best
+}
+
+// firstPageText returns the text of the first page of a PDF, from the cache when the file
+// is unchanged. Other formats and unreadable files have no first page text.
+func (c *PDFConverter) firstPageText(path string) string {
+	if strings.ToLower(filepath.Ext(path)) != ".pdf" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	c.pageIndex.mu.Lock()
+	entry, ok := c.pageIndex.entries[path]
+	c.pageIndex.mu.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.text
+	}
+
+	text := c.readFirstPage(path)
+	c.pageIndex.mu.Lock()
+	if c.pageIndex.entries == nil {
+		c.pageIndex.entries = map[string]indexedPage{}
+	}
+	c.pageIndex.entries[path] = indexedPage{size: info.Size(), modTime: info.ModTime(), text: text}
+	c.pageIndex.mu.Unlock()
+	return text
+}
+
+// readFirstPage extracts the plain text of the first page of a PDF, or "" when it cannot be
+// read.
+func (c *PDFConverter) readFirstPage(path string) (text string) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Debug("Failed to read first page of %s: %v", path, r)
+			text = ""
+		}
+	}()
+	reader, closeFn, _, err := c.openPDF(path)
+	if err != nil {
+		c.logger.Debug("Failed to open %s for lookup: %v", path, err)
+		return ""
+	}
+	defer closeFn()
+	if reader.NumPage() == 0 {
+		return ""
+	}
+	text, err = reader.Page(1).GetPlainText(nil)
+	if err != nil {
+		return ""
+	}
+	return text
+}
+
+// lookupTokens splits text into lowercase words of letters and digits. Hyphens, slashes and
+// dots inside part numbers are dropped, so "LM317-N" and "lm317n" match.
+func lookupTokens(text string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(text) {
+		if token := normalizeLookup(field); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// normalizeLookup lowercases text and removes everything but letters and digits.
+func normalizeLookup(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
+
+// editDistanceAtMost1 reports whether a and b differ by at most one inserted, deleted or
+// substituted character, or one swap of adjacent characters, the common typos in part numbers.
+func editDistanceAtMost1(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if i == len(a) {
+		return true
+	}
+	if len(a) == len(b) {
+		swapped := i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:]
+		return swapped || a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
+
+// lookupSnippet returns the first page words around the first word matching term, up to
+// about lookupSnippetChars characters.
+func lookupSnippet(text, term string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		token := normalizeLookup(word)
+		if token == "" || termScore(term, []string{token}, 1, 1, 1) == 0 {
+			continue
+		}
+		start, end, length := i, i+1, len(word)
+		for length < lookupSnippetChars && (start > 0 || end < len(words)) {
+			if start > 0 && (i-start <= end-i || end == len(words)) {
+				start--
+				length += len(words[start]) + 1
+			} else {
+				length += len(words[end]) + 1
+				end++
+			}
+		}
+		snippet := strings.Join(words[start:end], " ")
+		if start > 0 {
+			snippet = "…" + snippet
+		}
+		if end < len(words) {
+			snippet += "…"
+		}
+		return snippet
+	}
+	return ""
+}
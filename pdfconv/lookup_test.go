@@ -0,0 +1,62 @@
+package pdfconv
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// writeTitlePage writes a one-page PDF whose page says text.
+func writeTitlePage(t *testing.T, path, text string) {
+	t.Helper()
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	doc.AddPage()
+	doc.Cell(40, 10, text)
+	if err := doc.OutputFileAndClose(path); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+}
+
+func TestFindDocuments(t *testing.T) {
+	dir := t.TempDir()
+	writeTitlePage(t, filepath.Join(dir, "snvs774.pdf"), "LM317-N Wide Input Adjustable Regulator")
+	writeTitlePage(t, filepath.Join(dir, "LM317_datasheet.pdf"), "3-Terminal Adjustable Regulator")
+	writeTitlePage(t, filepath.Join(dir, "tps5430.pdf"), "3-A Step-Down Converter")
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+
+	matches, err := conv.FindDocuments(dir, "LM317", 0)
+	if err != nil {
+		t.Fatalf("FindDocuments() error = %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected two candidates, got %+v", matches)
+	}
+	if filepath.Base(matches[0].Path) != "LM317_datasheet.pdf" || matches[0].Confidence != nameExactConfidence || matches[0].MatchedIn != lookupMatchFileName {
+		t.Errorf("expected the file name match first, got %+v", matches[0])
+	}
+	if second := matches[1]; filepath.Base(second.Path) != "snvs774.pdf" || second.MatchedIn != lookupMatchFirstPage || second.Snippet == "" {
+		t.Errorf("expected the first page match second, got %+v", second)
+	}
+
+	// Every term must match; typos are tolerated with a lower confidence
+	if matches, _ := conv.FindDocuments(dir, "LM317 regulator", 0); len(matches) != 2 {
+		t.Errorf("expected two candidates for two terms, got %+v", matches)
+	}
+	if matches, _ := conv.FindDocuments(dir, "LM317 converter", 0); len(matches) != 0 {
+		t.Errorf("expected no candidate matching both terms, got %+v", matches)
+	}
+	if matches, _ := conv.FindDocuments(dir, "TPS5403", 0); len(matches) != 1 || matches[0].Confidence != nameFuzzyConfidence {
+		t.Errorf("expected a fuzzy match of tps5430, got %+v", matches)
+	}
+	if matches, _ := conv.FindDocuments(dir, "regulator", 1); len(matches) != 1 {
+		t.Errorf("expected the limit applied, got %+v", matches)
+	}
+	if _, err := conv.FindDocuments(dir, " - ", 0); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
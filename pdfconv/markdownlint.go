@@ -0,0 +1,178 @@
+// Package pdfconv - Markdown lint pass.
+// This file implements MARKDOWN_LINT: a final pass over the generated Markdown that fixes
+// what markdownlint reports for the enabled rules, so converted documents pass doc CI checks.
+package pdfconv
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"datasheet-to-md-mcp/config"
+)
+
+// Markdown lint settings
+const (
+	DefaultLineLength = 80     // MD013 line length when MARKDOWN_LINE_LENGTH is 0
+	LintFenceLanguage = "text" // MD040 language given to fenced blocks without one
+)
+
+var (
+	// looseHeadingPattern matches ATX headings with one or more spaces after the hashes.
+	looseHeadingPattern = regexp.MustCompile(`^(#{1,6})[ \t]+(.*\S)[ \t]*$`)
+	// wrapPrefixPattern matches the list marker or blockquote prefix of a line.
+	wrapPrefixPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d{1,9}[.)])\s+|\s*>\s?)`)
+	// blockStartPattern matches words that would start a different block at the start of a line.
+	blockStartPattern = regexp.MustCompile("^(#{1,6}|[-*+>|=]+|\\d{1,9}[.)]|```.*|<.*)$")
+)
+
+// lintRules returns the enabled lint rules, or nil when the lint pass is off.
+func (c *PDFConverter) lintRules() map[string]bool {
+	if !c.config.MarkdownLint {
+		return nil
+	}
+	rules := c.config.MarkdownLintRules
+	if rules == nil {
+		rules = config.DefaultMarkdownLintRules
+	}
+	enabled := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		enabled[rule] = true
+	}
+	return enabled
+}
+
+// lintMarkdown applies the enabled markdownlint rules to the Markdown:
+//   - MD009: trailing whitespace is removed
+//   - MD012: consecutive blank lines are collapsed
+//   - MD013: paragraphs, list items and quotes are wrapped at MARKDOWN_LINE_LENGTH
+//   - MD019: headings have a single space after the hashes
+//   - MD022 / MD031: headings and fenced code blocks are surrounded by blank lines
+//   - MD040: fenced code blocks without a language are marked as text
+//   - MD047: the file ends with a single newline
+//
+// Code block content is left unchanged apart from trailing whitespace.
+func (c *PDFConverter) lintMarkdown(markdown string) string {
+	rules := c.lintRules()
+	if rules == nil {
+		return markdown
+	}
+	lineLength := c.config.MarkdownLineLength
+	if lineLength == 0 {
+		lineLength = DefaultLineLength
+	}
+
+	var out []string
+	var fences fenceTracker
+	// needBlank is set after a heading or closing fence that must be followed by a blank line
+	needBlank := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if rules["MD009"] {
+			line = strings.TrimRight(line, " \t")
+		}
+		wasOpen := fences.open != ""
+		inCode := fences.inCode(line)
+		isFence := inCode && strings.HasPrefix(strings.TrimSpace(line), "```")
+		opening, closing := isFence && !wasOpen, isFence && wasOpen && fences.open == ""
+		blank := strings.TrimSpace(line) == ""
+
+		if inCode && !opening {
+			out = append(out, line)
+			if closing && rules["MD031"] {
+				needBlank = true
+			}
+			continue
+		}
+		if blank {
+			needBlank = false
+			if rules["MD012"] && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		if needBlank {
+			out = append(out, "")
+			needBlank = false
+		}
+
+		heading := looseHeadingPattern.FindStringSubmatch(line)
+		switch {
+		case opening:
+			if rules["MD040"] && strings.TrimLeft(strings.TrimSpace(line), "`") == "" {
+				line += LintFenceLanguage
+			}
+			if rules["MD031"] {
+				out = appendBlankLine(out)
+			}
+			out = append(out, line)
+		case heading != nil:
+			if rules["MD019"] {
+				line = heading[1] + " " + heading[2]
+			}
+			if rules["MD022"] {
+				out = appendBlankLine(out)
+				needBlank = true
+			}
+			out = append(out, line)
+		case rules["MD013"]:
+			out = append(out, wrapMarkdownLine(line, lineLength)...)
+		default:
+			out = append(out, line)
+		}
+	}
+
+	result := strings.Join(out, "\n")
+	if rules["MD047"] {
+		result = strings.TrimRight(result, "\n") + "\n"
+	}
+	return result
+}
+
+// appendBlankLine appends a blank line unless the output is empty or already ends with one.
+func appendBlankLine(lines []string) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[len(lines)-1]) == "" {
+		return lines
+	}
+	return append(lines, "")
+}
+
+// wrapMarkdownLine wraps a paragraph, list item or blockquote line at word boundaries so no
+// line exceeds width where possible. Continuation lines repeat a blockquote prefix and indent
+// under a list marker. Table rows, HTML and lines without spaces are returned unchanged, and a
+// word is never moved to the start of a line where it would begin a different block.
+func wrapMarkdownLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+	trimmed := strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "<") || strings.HasPrefix(trimmed, "![") {
+		return []string{line}
+	}
+
+	prefix := wrapPrefixPattern.FindString(line)
+	if prefix == "" && trimmed != line {
+		// Indented lines may be code or list continuations
+		return []string{line}
+	}
+	continuation := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+	if strings.HasPrefix(strings.TrimSpace(prefix), ">") {
+		continuation = prefix
+	}
+	words := strings.Fields(line[len(prefix):])
+	if len(words) < 2 {
+		return []string{line}
+	}
+
+	var lines []string
+	current := prefix + words[0]
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width && !blockStartPattern.MatchString(word) {
+			lines = append(lines, current)
+			current = continuation + word
+			continue
+		}
+		current += " " + word
+	}
+	return append(lines, current)
+}
@@ -0,0 +1,116 @@
+package pdfconv
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestLintMarkdown(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{MarkdownLint: true}, logger.NewLogger("error"))
+	in := strings.Join([]string{
+		"# Title   ",
+		"Intro text.",
+		"",
+		"",
+		"",
+		"###   Spaced Heading",
+		"```",
+		"  keep   this  ",
+		"",
+		"",
+		"```",
+		"After code.",
+		"```plantuml",
+		"@startuml",
+		"@enduml",
+		"```",
+		"",
+		"",
+	}, "\n")
+	want := strings.Join([]string{
+		"# Title",
+		"",
+		"Intro text.",
+		"",
+		"### Spaced Heading",
+		"",
+		"```text",
+		"  keep   this",
+		"",
+		"",
+		"```",
+		"",
+		"After code.",
+		"",
+		"```plantuml",
+		"@startuml",
+		"@enduml",
+		"```",
+		"",
+	}, "\n")
+	if got := conv.lintMarkdown(in); got != want {
+		t.Errorf("lintMarkdown() =\n%q\nwant\n%q", got, want)
+	}
+
+	// Only the configured rules are applied
+	conv.config.MarkdownLintRules = []string{"MD047"}
+	if got := conv.lintMarkdown("# A  \n##  B\n\n\n"); got != "# A  \n##  B\n" {
+		t.Errorf("lintMarkdown() with MD047 only = %q", got)
+	}
+
+	// Off by default
+	conv.config.MarkdownLint = false
+	if got := conv.lintMarkdown(in); got != in {
+		t.Errorf("lintMarkdown() changed output with MARKDOWN_LINT off")
+	}
+}
+
+func TestLintMarkdown_LineLength(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{MarkdownLint: true, MarkdownLintRules: []string{"MD013"}, MarkdownLineLength: 30}, logger.NewLogger("error"))
+	in := strings.Join([]string{
+		"The supply voltage must stay within the limits - 0.3 V to 3.6 V.",
+		"- A list item that is long enough to be wrapped twice over here",
+		"> **Redacted:** content on this page was hidden on purpose",
+		"| Parameter | Min | Typ | Max | Unit | Conditions |",
+		"    indented line that stays exactly the way it was written",
+		"![A long image description that should stay on one line](./image_0a.png)",
+	}, "\n")
+	want := strings.Join([]string{
+		"The supply voltage must stay",
+		"within the limits - 0.3 V to",
+		"3.6 V.",
+		"- A list item that is long",
+		"  enough to be wrapped twice",
+		"  over here",
+		"> **Redacted:** content on",
+		"> this page was hidden on",
+		"> purpose",
+		"| Parameter | Min | Typ | Max | Unit | Conditions |",
+		"    indented line that stays exactly the way it was written",
+		"![A long image description that should stay on one line](./image_0a.png)",
+	}, "\n")
+	if got := conv.lintMarkdown(in); got != want {
+		t.Errorf("lintMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConvertPDF_MarkdownLint(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, IncludeTOC: true, MarkdownLint: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempPDFWithRawImage(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	out := string(md)
+	if strings.Contains(out, "\n\n\n") || strings.Contains(out, " \n") || !strings.HasSuffix(out, "\n") || strings.HasSuffix(out, "\n\n") {
+		t.Errorf("expected linted output, got:\n%q", out)
+	}
+	if len(res.BrokenLinks) != 0 {
+		t.Errorf("lint pass broke links: %v", res.BrokenLinks)
+	}
+}
@@ -0,0 +1,152 @@
+// Package pdfconv - Document metadata.
+// This file reads the document information of a PDF (title, author, producer, dates), its
+// page count, version and encryption status without extracting any page content, so a
+// caller can identify a document before deciding to convert it.
+package pdfconv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfHeaderBytes is the number of bytes at the start of a file searched for the PDF header.
+const pdfHeaderBytes = 1024
+
+var (
+	// pdfVersionPattern matches the version in the PDF header, as in "%PDF-1.7".
+	pdfVersionPattern = regexp.MustCompile(`%PDF-(\d\.\d)`)
+	// pdfDatePattern matches a PDF date string, as in "D:20240115103000+01'00'". Only the
+	// year is required.
+	pdfDatePattern = regexp.MustCompile(`^(?:D:)?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?(?:([Zz+\-])(?:(\d{2})'?(\d{2})?'?)?)?`)
+)
+
+// PDFMetadata is the document information of a PDF.
+type PDFMetadata struct {
+	Path             string `json:"path"`
+	Title            string `json:"title,omitempty"`
+	Author           string `json:"author,omitempty"`
+	Subject          string `json:"subject,omitempty"`
+	Keywords         string `json:"keywords,omitempty"`
+	Creator          string `json:"creator,omitempty"`       // Application the document was created with
+	Producer         string `json:"producer,omitempty"`      // Application that produced the PDF
+	CreationDate     string `json:"creation_date,omitempty"` // RFC 3339, or as written when it is not a PDF date
+	ModDate          string `json:"mod_date,omitempty"`
+	Language         string `json:"language,omitempty"` // Natural language declared in the catalog
+	PageCount        int    `json:"page_count"`
+	Version          string `json:"version,omitempty"` // PDF version from the file header, e.g. "1.7"
+	FileSize         int64  `json:"file_size"`
+	Encrypted        bool   `json:"encrypted"`
+	PasswordRequired bool   `json:"password_required"`  // Encrypted with a user password; nothing else can be read
+	Repaired         bool   `json:"repaired,omitempty"` // The cross-reference table had to be rebuilt to open the file
+}
+
+// Inspect reads the document information of a PDF without converting it. Encrypted files
+// that need a password are reported with PasswordRequired set rather than as an error.
+func (c *PDFConverter) Inspect(pdfPath string) (*PDFMetadata, error) {
+	if strings.TrimSpace(pdfPath) == "" {
+		return nil, fmt.Errorf("PDF path cannot be empty")
+	}
+	pdfPath = filepath.Clean(pdfPath)
+	info, err := os.Stat(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("input file does not exist: %s", pdfPath)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", pdfPath)
+	}
+	if documentFormats[strings.ToLower(filepath.Ext(pdfPath))] != "pdf" {
+		return nil, fmt.Errorf("metadata can only be read from PDF files: %s", pdfPath)
+	}
+	metadata := &PDFMetadata{Path: pdfPath, FileSize: info.Size(), Version: pdfVersion(pdfPath)}
+
+	reader, closeFile, repaired, err := c.openPDF(pdfPath)
+	if errors.Is(err, ErrEncrypted) {
+		metadata.Encrypted, metadata.PasswordRequired = true, true
+		return metadata, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer closeFile()
+	metadata.Repaired = repaired
+	metadata.PageCount = reader.NumPage()
+	metadata.Language = pdfLanguage(reader)
+	readDocumentInfo(reader, metadata)
+	return metadata, nil
+}
+
+// readDocumentInfo copies the entries of the document information dictionary and the
+// encryption status from the trailer. Malformed entries are skipped.
+func readDocumentInfo(reader *pdf.Reader, metadata *PDFMetadata) {
+	defer func() {
+		recover() // Keep the entries read before the malformed one
+	}()
+	trailer := reader.Trailer()
+	metadata.Encrypted = !trailer.Key("Encrypt").IsNull()
+	info := trailer.Key("Info")
+	text := func(key string) string {
+		return strings.TrimSpace(strings.ReplaceAll(info.Key(key).Text(), "\x00", ""))
+	}
+	metadata.Title = text("Title")
+	metadata.Author = text("Author")
+	metadata.Subject = text("Subject")
+	metadata.Keywords = text("Keywords")
+	metadata.Creator = text("Creator")
+	metadata.Producer = text("Producer")
+	metadata.CreationDate = formatPDFDate(text("CreationDate"))
+	metadata.ModDate = formatPDFDate(text("ModDate"))
+}
+
+// pdfVersion returns the version in the header of a PDF file, "" when there is none.
+func pdfVersion(pdfPath string) string {
+	file, err := os.Open(pdfPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	header := make([]byte, pdfHeaderBytes)
+	n, _ := file.Read(header)
+	if m := pdfVersionPattern.FindSubmatch(bytes.TrimLeft(header[:n], "\x00")); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// formatPDFDate converts a PDF date string to RFC 3339. Strings that are not PDF dates are
+// returned as they are.
+func formatPDFDate(value string) string {
+	m := pdfDatePattern.FindStringSubmatch(value)
+	if m == nil {
+		return value
+	}
+	field := func(i, fallback int) int {
+		if m[i] == "" {
+			return fallback
+		}
+		var n int
+		fmt.Sscanf(m[i], "%d", &n)
+		return n
+	}
+	location := time.UTC
+	if m[7] == "+" || m[7] == "-" {
+		offset := field(8, 0)*3600 + field(9, 0)*60
+		if m[7] == "-" {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	}
+	date := time.Date(field(1, 0), time.Month(field(2, 1)), field(3, 1), field(4, 0), field(5, 0), field(6, 0), 0, location)
+	if m[7] == "" {
+		// Without a time zone the date is local to the author; write it without an offset
+		return date.Format("2006-01-02T15:04:05")
+	}
+	return date.Format(time.RFC3339)
+}
@@ -0,0 +1,85 @@
+package pdfconv
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	write := func(name, userPassword string, protect bool) string {
+		doc := gofpdf.New("P", "mm", "A4", "")
+		if protect {
+			doc.SetProtection(gofpdf.CnProtectPrint, userPassword, "owner")
+		}
+		doc.SetTitle("LM317 3-Terminal Adjustable Regulator", true)
+		doc.SetAuthor("Texas Instruments", true)
+		doc.SetSubject("Linear regulator", true)
+		doc.SetCreator("DITA Open Toolkit", true)
+		doc.SetCreationDate(created)
+		doc.SetFont("Helvetica", "", 10)
+		doc.AddPage()
+		doc.Cell(40, 10, "LM317")
+		doc.AddPage()
+		doc.Cell(40, 10, "Pin Configuration")
+		path := filepath.Join(dir, name)
+		if err := doc.OutputFileAndClose(path); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		return path
+	}
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+
+	metadata, err := conv.Inspect(write("lm317.pdf", "", false))
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if metadata.Title != "LM317 3-Terminal Adjustable Regulator" || metadata.Author != "Texas Instruments" || metadata.Subject != "Linear regulator" || metadata.Creator != "DITA Open Toolkit" {
+		t.Errorf("unexpected document information: %+v", metadata)
+	}
+	if metadata.CreationDate != "2024-01-15T10:30:00" {
+		t.Errorf("expected the creation date without a time zone, got %q", metadata.CreationDate)
+	}
+	if metadata.PageCount != 2 || metadata.Version == "" || metadata.FileSize == 0 || metadata.Encrypted || metadata.PasswordRequired {
+		t.Errorf("unexpected document properties: %+v", metadata)
+	}
+
+	locked, err := conv.Inspect(write("locked.pdf", "secret", true))
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if !locked.Encrypted || !locked.PasswordRequired || locked.Title != "" || locked.PageCount != 0 {
+		t.Errorf("expected a password-protected file reported as locked, got %+v", locked)
+	}
+
+	if _, err := conv.Inspect(filepath.Join(dir, "missing.pdf")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := conv.Inspect(dir); err == nil {
+		t.Error("expected an error for a directory")
+	}
+}
+
+func TestFormatPDFDate(t *testing.T) {
+	tests := map[string]string{
+		"D:20240115103000Z":       "2024-01-15T10:30:00Z",
+		"D:20240115103000+01'00'": "2024-01-15T10:30:00+01:00",
+		"D:20240115103000-05'30":  "2024-01-15T10:30:00-05:30",
+		"D:20240115":              "2024-01-15T00:00:00",
+		"2024":                    "2024-01-01T00:00:00",
+		"January 2024":            "January 2024",
+		"":                        "",
+	}
+	for value, want := range tests {
+		if got := formatPDFDate(value); got != want {
+			t.Errorf("formatPDFDate(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
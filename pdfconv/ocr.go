@@ -0,0 +1,144 @@
+// Package pdfconv - Optical character recognition.
+// This file runs the Tesseract OCR engine on page images that carry no text layer and
+// rebuilds the recognized words into lines together with an overall confidence.
+package pdfconv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"datasheet-to-md-mcp/uml"
+)
+
+// ocrLanguage returns the configured Tesseract language, defaulting to English.
+func (c *PDFConverter) ocrLanguage() string {
+	if c.config.OCRLanguage == "" {
+		return "eng"
+	}
+	return c.config.OCRLanguage
+}
+
+// ocrAvailable reports whether the tesseract binary is on PATH.
+func ocrAvailable() bool {
+	_, err := exec.LookPath("tesseract")
+	return err == nil
+}
+
+// ocrImage recognizes the text in an image file with tesseract and returns it with the mean
+// word confidence between 0.0 and 1.0.
+func (c *PDFConverter) ocrImage(imagePath string) (string, float64, error) {
+	bin, err := exec.LookPath("tesseract")
+	if err != nil {
+		return "", 0, fmt.Errorf("OCR requires tesseract: %v", err)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, imagePath, "stdout", "-l", c.ocrLanguage(), "tsv")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", 0, fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	text, confidence := parseTesseractTSV(out)
+	return text, confidence, nil
+}
+
+// ocrWords recognizes the words in an image file with tesseract, with their bounds.
+func (c *PDFConverter) ocrWords(imagePath string) ([]uml.PlotWord, error) {
+	bin, err := exec.LookPath("tesseract")
+	if err != nil {
+		return nil, fmt.Errorf("OCR requires tesseract: %v", err)
+	}
+	var stderr bytes.Buffer
+	// Sparse text mode finds labels scattered over a figure
+	cmd := exec.Command(bin, imagePath, "stdout", "-l", c.ocrLanguage(), "--psm", "11", "tsv")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractWords(out), nil
+}
+
+// parseTesseractWords returns the recognized words of tesseract TSV output with their
+// bounds, leaving out words below MinAltTextConfidence.
+func parseTesseractWords(tsv []byte) []uml.PlotWord {
+	var words []uml.PlotWord
+	for _, row := range strings.Split(string(tsv), "\n") {
+		// level page block par line word left top width height conf text
+		fields := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(fields[11])
+		conf, err := strconv.ParseFloat(fields[10], 64)
+		if text == "" || err != nil || conf/100 < MinAltTextConfidence {
+			continue
+		}
+		var box [4]int
+		valid := true
+		for i := range box {
+			n, err := strconv.Atoi(fields[6+i])
+			box[i], valid = n, valid && err == nil
+		}
+		if !valid {
+			continue
+		}
+		words = append(words, uml.PlotWord{Text: text, Box: image.Rect(box[0], box[1], box[0]+box[2], box[1]+box[3])})
+	}
+	return words
+}
+
+// parseTesseractTSV rebuilds text lines from tesseract TSV output, in which every recognized
+// word is a row keyed by block, paragraph and line number. Paragraphs are separated by a
+// blank line. The confidence is the mean of the word confidences scaled to 0.0-1.0.
+func parseTesseractTSV(tsv []byte) (string, float64) {
+	var text strings.Builder
+	var line []string
+	lineKey, parKey := "", ""
+	total, words := 0.0, 0
+	flush := func() {
+		if len(line) > 0 {
+			text.WriteString(strings.Join(line, " "))
+			text.WriteString("\n")
+			line = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(tsv))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// level page block par line word left top width height conf text
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+		word := strings.TrimSpace(fields[11])
+		conf, err := strconv.ParseFloat(fields[10], 64)
+		if word == "" || err != nil || conf < 0 {
+			continue
+		}
+		par := fields[2] + "." + fields[3]
+		key := par + "." + fields[4]
+		if key != lineKey {
+			flush()
+			if parKey != "" && par != parKey {
+				text.WriteString("\n")
+			}
+			lineKey, parKey = key, par
+		}
+		line = append(line, word)
+		total += conf
+		words++
+	}
+	flush()
+
+	if words == 0 {
+		return "", 0
+	}
+	return strings.TrimSpace(text.String()), total / float64(words) / 100
+}
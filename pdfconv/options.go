@@ -0,0 +1,130 @@
+// Package pdfconv - Per-call conversion options.
+// This file defines the options a single tool call can use to override configuration
+// defaults, and the page selection expressions they accept.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConversionOptions holds per-call overrides for a single PDF conversion.
+// The zero value converts the document using configuration defaults only.
+type ConversionOptions struct {
+	Verbatim       bool            // Preserve original line breaks and spacing on every page
+	VerbatimPages  PageSelection   // Pages to preserve verbatim when Verbatim is false
+	Pages          PageSelection   // Pages to convert, nil for all; taken from a document settings file when nil
+	Captioner      ImageCaptioner  // Writes image alt text when IMAGE_ALT_TEXT is "caption"
+	OutputFormat   string          // Output format overriding OUTPUT_FORMAT, "" for the configured one
+	MarkdownFlavor string          // Markdown flavor overriding MARKDOWN_FLAVOR, "" for the configured one
+	Context        context.Context // Cancels page extraction, image decoding and diagram detection; nil never cancels
+
+	repaired bool          // Set by the PDF front-end when the input had to be repaired to open
+	language string        // Set by the PDF front-end to the language declared in the document
+	started  time.Time     // Set by the front-ends when the conversion starts
+	batch    time.Time     // Set by batch and portfolio conversions to their start, for pruning
+	timings  *PhaseTimings // Set by generateOutput to collect phase timings
+	render   pageRender    // Set by the front-ends that can rasterize pages, for thumbnails
+}
+
+// context returns the context of the conversion, context.Background when none is set.
+func (o ConversionOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// pruneSince returns the time after which outputs are spared by the retention policy: the
+// start of the batch the conversion belongs to, or of the conversion itself.
+func (o ConversionOptions) pruneSince() time.Time {
+	if !o.batch.IsZero() && o.batch.Before(o.started) {
+		return o.batch
+	}
+	return o.started
+}
+
+// verbatimPage reports whether the given page should be emitted verbatim.
+func (o ConversionOptions) verbatimPage(page int) bool {
+	return o.Verbatim || o.VerbatimPages.Contains(page)
+}
+
+// PageRange is an inclusive 1-based page range. An End of 0 means "to the last page".
+type PageRange struct {
+	Start int
+	End   int
+}
+
+// PageSelection is a set of page ranges parsed from an expression such as "1-10,15,20-".
+// The zero value selects no pages.
+type PageSelection []PageRange
+
+// ParsePageSelection parses a comma separated list of pages and ranges.
+// Supported forms are "N", "N-M", "N-" (to the end) and "-M" (from the start).
+func ParsePageSelection(expr string) (PageSelection, error) {
+	var selection PageSelection
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var r PageRange
+		var err error
+		if idx := strings.Index(part, "-"); idx >= 0 {
+			startStr, endStr := strings.TrimSpace(part[:idx]), strings.TrimSpace(part[idx+1:])
+			r.Start = 1
+			if startStr != "" {
+				if r.Start, err = strconv.Atoi(startStr); err != nil {
+					return nil, fmt.Errorf("invalid page range %q: %v", part, err)
+				}
+			}
+			if endStr != "" {
+				if r.End, err = strconv.Atoi(endStr); err != nil {
+					return nil, fmt.Errorf("invalid page range %q: %v", part, err)
+				}
+			}
+		} else {
+			if r.Start, err = strconv.Atoi(part); err != nil {
+				return nil, fmt.Errorf("invalid page number %q: %v", part, err)
+			}
+			r.End = r.Start
+		}
+		if r.Start < 1 || (r.End != 0 && r.End < r.Start) {
+			return nil, fmt.Errorf("invalid page range %q", part)
+		}
+		selection = append(selection, r)
+	}
+	if len(selection) == 0 {
+		return nil, fmt.Errorf("page selection %q is empty", expr)
+	}
+	return selection, nil
+}
+
+// Contains reports whether the 1-based page number is selected.
+func (s PageSelection) Contains(page int) bool {
+	for _, r := range s {
+		if page >= r.Start && (r.End == 0 || page <= r.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the selection in the expression syntax accepted by ParsePageSelection.
+func (s PageSelection) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r.End == 0:
+			parts = append(parts, fmt.Sprintf("%d-", r.Start))
+		case r.Start == r.End:
+			parts = append(parts, strconv.Itoa(r.Start))
+		default:
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ",")
+}
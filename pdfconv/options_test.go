@@ -0,0 +1,40 @@
+package pdfconv
+
+import "testing"
+
+func TestParsePageSelection(t *testing.T) {
+	sel, err := ParsePageSelection("1-3, 7,10-")
+	if err != nil {
+		t.Fatalf("ParsePageSelection() error = %v", err)
+	}
+	for page, want := range map[int]bool{1: true, 3: true, 4: false, 7: true, 9: false, 10: true, 500: true} {
+		if got := sel.Contains(page); got != want {
+			t.Errorf("Contains(%d) = %v, want %v", page, got, want)
+		}
+	}
+	if sel.String() != "1-3,7,10-" {
+		t.Errorf("String() = %q", sel.String())
+	}
+
+	open, err := ParsePageSelection("-2")
+	if err != nil || !open.Contains(1) || open.Contains(3) {
+		t.Errorf("open-start range parsed incorrectly: %v %v", open, err)
+	}
+
+	for _, bad := range []string{"", "abc", "0", "5-2", "1-x", " , "} {
+		if _, err := ParsePageSelection(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestConversionOptionsVerbatimPage(t *testing.T) {
+	sel, _ := ParsePageSelection("2")
+	opts := ConversionOptions{VerbatimPages: sel}
+	if opts.verbatimPage(1) || !opts.verbatimPage(2) {
+		t.Error("verbatim pages not honoured")
+	}
+	if !(ConversionOptions{Verbatim: true}).verbatimPage(99) {
+		t.Error("Verbatim should apply to every page")
+	}
+}
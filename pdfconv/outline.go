@@ -0,0 +1,322 @@
+// Package pdfconv - PDF outline (bookmark) handling.
+// This file reads the document outline and uses it to split large manuals into
+// one Markdown output directory per top-level chapter.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// OutlineEntry is a single bookmark from the PDF outline resolved to a page number.
+type OutlineEntry struct {
+	Title    string
+	Page     int // 1-based page number the bookmark points at, 0 if unresolved
+	Children []OutlineEntry
+}
+
+// SectionResult describes one chapter produced by SplitPDFBySections.
+type SectionResult struct {
+	Title     string
+	StartPage int
+	EndPage   int
+	Result    ConversionResult
+}
+
+// SplitConversionResult contains the results of splitting a PDF into per-chapter outputs.
+type SplitConversionResult struct {
+	PDFPath   string
+	OutputDir string
+	IndexFile string
+	PageCount int
+	Sections  []SectionResult
+	// BrokenLinks lists unresolved links in the index; section links are in each section's result
+	BrokenLinks []BrokenLink
+	Duration    time.Duration // Time to convert all sections and write the index
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SplitPDFBySections converts each top-level outline chapter of a PDF into its own
+// Markdown output directory below MARKDOWN_<filename>, and writes an index README
+// linking all chapters. PDFs without an outline are rejected.
+func (c *PDFConverter) SplitPDFBySections(pdfPath, outputBaseDir string) (*SplitConversionResult, error) {
+	return c.SplitPDFBySectionsWithOptions(pdfPath, outputBaseDir, ConversionOptions{})
+}
+
+// SplitPDFBySectionsWithOptions splits a PDF like SplitPDFBySections. opts.Context stops
+// the split between and within sections, leaving no output behind; the other options do
+// not apply to splits. With STRICT_MODE a section with failed pages, images or tables
+// fails the whole split, again without output.
+func (c *PDFConverter) SplitPDFBySectionsWithOptions(pdfPath, outputBaseDir string, opts ConversionOptions) (*SplitConversionResult, error) {
+	c.logger.Info("Starting section split: %s", pdfPath)
+	start := time.Now()
+
+	if strings.TrimSpace(pdfPath) == "" {
+		return nil, fmt.Errorf("PDF path cannot be empty")
+	}
+	if strings.TrimSpace(outputBaseDir) == "" {
+		return nil, fmt.Errorf("output base directory cannot be empty")
+	}
+	pdfPath = filepath.Clean(pdfPath)
+	outputBaseDir = filepath.Clean(outputBaseDir)
+
+	reader, closeFile, repaired, err := c.openPDF(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer closeFile()
+
+	numPages := reader.NumPage()
+	sections := c.topLevelSections(c.readOutline(reader), numPages)
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("PDF has no usable outline (bookmarks) to split by: %s", pdfPath)
+	}
+	c.logger.Info("Found %d top-level sections", len(sections))
+
+	if err := c.preflightDiskSpace(reader, pdfPath, outputBaseDir); err != nil {
+		return nil, err
+	}
+
+	stagingDir, outputDir, err := c.createStagingDirectory(pdfPath, outputBaseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	defer os.RemoveAll(stagingDir) // no-op once committed
+
+	result := &SplitConversionResult{PDFPath: pdfPath, OutputDir: outputDir, PageCount: numPages}
+	language := c.documentLanguage(pdfLanguage(reader))
+	for i, section := range sections {
+		sectionDir := filepath.Join(stagingDir, fmt.Sprintf("SECTION_%02d_%s", i+1, slugify(section.Title)))
+		if err := os.MkdirAll(sectionDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create section directory %s: %v", sectionDir, err)
+		}
+		c.logger.Info("Converting section %d/%d: %s (pages %d-%d)", i+1, len(sections), section.Title, section.StartPage, section.EndPage)
+
+		sectionStart, timings := time.Now(), &PhaseTimings{}
+		pages, totalImages, err := c.extractPageRange(opts.context(), reader, pdfPath, sectionDir, section.StartPage, section.EndPage, timings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract section %q: %w", section.Title, err)
+		}
+		if err := c.checkStrict(pages); err != nil {
+			return nil, fmt.Errorf("section %q: %w", section.Title, err)
+		}
+		languages := c.segmentLanguages(pages, language)
+		c.describeImages(pages, sectionDir, ConversionOptions{timings: timings})
+		c.prepareAccessiblePages(pages)
+		markdownStart := time.Now()
+		markdownPath := filepath.Join(sectionDir, "README.md")
+		markdownContent := c.accessibleMarkdown(c.generateMarkdownWithTitle(section.Title, pages), language)
+		if err := c.writeMarkdownFile(markdownPath, markdownContent); err != nil {
+			return nil, fmt.Errorf("failed to write Markdown file: %v", err)
+		}
+		quality := assessQuality(pages)
+		redactionSections(markdownContent, quality.Redactions)
+		for j := range quality.Redactions {
+			if quality.Redactions[j].Section == "" {
+				quality.Redactions[j].Section = section.Title
+			}
+		}
+		brokenLinks, err := checkLinks(stagingDir, filepath.ToSlash(filepath.Join(filepath.Base(sectionDir), "README.md")))
+		if err != nil {
+			return nil, err
+		}
+		c.logBrokenLinks(pdfPath, brokenLinks)
+		timings.record(phaseMarkdown, markdownStart)
+		failures := pageFailures(pages)
+		section.Result = ConversionResult{Source: pdfPath, Status: conversionStatus(failures), FailedPages: failures, OutputDir: sectionDir, MarkdownFile: markdownPath, ImageCount: totalImages, PageCount: len(pages), Quality: quality, Repaired: repaired, BrokenLinks: brokenLinks, Languages: languages, Duration: time.Since(sectionStart), Timings: *timings}
+		if err := c.writeConversionReport(sectionDir, pdfPath, section.Result); err != nil {
+			return nil, err
+		}
+		result.Sections = append(result.Sections, section)
+	}
+
+	if err := c.writeMarkdownFile(filepath.Join(stagingDir, "README.md"), c.accessibleMarkdown(c.generateSectionIndex(pdfPath, result.Sections), language)); err != nil {
+		return nil, fmt.Errorf("failed to write section index: %v", err)
+	}
+	if result.BrokenLinks, err = checkLinks(stagingDir, "README.md"); err != nil {
+		return nil, err
+	}
+	c.logBrokenLinks(pdfPath, result.BrokenLinks)
+	if err := c.commitOutputDirectory(stagingDir, outputDir); err != nil {
+		return nil, err
+	}
+	result.IndexFile = filepath.Join(outputDir, "README.md")
+	for i := range result.Sections {
+		r := &result.Sections[i].Result
+		r.OutputDir = rebasePath(r.OutputDir, stagingDir, outputDir)
+		r.MarkdownFile = rebasePath(r.MarkdownFile, stagingDir, outputDir)
+	}
+
+	result.Duration = time.Since(start)
+	c.logger.Info("Section split completed: %d sections in %s", len(result.Sections), FormatDuration(result.Duration))
+	c.pruneOutputs(outputBaseDir, outputDir, start)
+	return result, nil
+}
+
+// readOutline walks the document outline tree and resolves each bookmark to a page number.
+func (c *PDFConverter) readOutline(reader *pdf.Reader) []OutlineEntry {
+	root := reader.Trailer().Key("Root")
+	outlines := root.Key("Outlines")
+	if outlines.IsNull() {
+		return nil
+	}
+
+	// Page dictionaries print with their object references, which makes their
+	// textual form a stable identity for matching bookmark destinations.
+	pageIndex := make(map[string]int, reader.NumPage())
+	tree := newPageTree(reader)
+	for n := 1; n <= reader.NumPage(); n++ {
+		if p, err := tree.page(n); err == nil && !p.V.IsNull() {
+			pageIndex[p.V.String()] = n
+		}
+	}
+	return c.readOutlineChildren(root, outlines, pageIndex, 0)
+}
+
+func (c *PDFConverter) readOutlineChildren(root, parent pdf.Value, pageIndex map[string]int, depth int) []OutlineEntry {
+	// Guard against malformed, cyclic outlines
+	if depth > 32 {
+		return nil
+	}
+	var entries []OutlineEntry
+	seen := 0
+	for item := parent.Key("First"); item.Kind() == pdf.Dict; item = item.Key("Next") {
+		if seen++; seen > 10000 {
+			c.logger.Warn("Outline has too many entries at depth %d, truncating", depth)
+			break
+		}
+		entry := OutlineEntry{
+			Title:    strings.TrimSpace(item.Key("Title").Text()),
+			Page:     resolveDestinationPage(root, outlineDestination(item), pageIndex),
+			Children: c.readOutlineChildren(root, item, pageIndex, depth+1),
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// outlineDestination returns the destination of an outline item, from either /Dest or a GoTo action.
+func outlineDestination(item pdf.Value) pdf.Value {
+	if dest := item.Key("Dest"); !dest.IsNull() {
+		return dest
+	}
+	action := item.Key("A")
+	if action.Key("S").Name() == "GoTo" {
+		return action.Key("D")
+	}
+	return pdf.Value{}
+}
+
+// resolveDestinationPage maps an explicit or named destination to a 1-based page number.
+func resolveDestinationPage(root, dest pdf.Value, pageIndex map[string]int) int {
+	switch dest.Kind() {
+	case pdf.Name, pdf.String:
+		var name string
+		if dest.Kind() == pdf.Name {
+			name = dest.Name()
+		} else {
+			name = dest.RawString()
+		}
+		// PDF 1.1 style destination dictionary, then the PDF 1.2+ name tree
+		named := root.Key("Dests").Key(name)
+		if named.IsNull() {
+			named = lookupNameTree(root.Key("Names").Key("Dests"), name, 0)
+		}
+		if named.Kind() == pdf.Dict {
+			named = named.Key("D")
+		}
+		if named.Kind() != pdf.Array {
+			return 0
+		}
+		dest = named
+	case pdf.Dict:
+		dest = dest.Key("D")
+	}
+	if dest.Kind() != pdf.Array || dest.Len() == 0 {
+		return 0
+	}
+	return pageIndex[dest.Index(0).String()]
+}
+
+// lookupNameTree finds a key in a PDF name tree.
+func lookupNameTree(node pdf.Value, key string, depth int) pdf.Value {
+	if node.IsNull() || depth > 32 {
+		return pdf.Value{}
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if names.Index(i).RawString() == key {
+			return names.Index(i + 1)
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		if v := lookupNameTree(kids.Index(i), key, depth+1); !v.IsNull() {
+			return v
+		}
+	}
+	return pdf.Value{}
+}
+
+// topLevelSections turns the top-level outline entries into contiguous page ranges.
+// Entries without a resolvable page are skipped, and each section ends where the next begins.
+func (c *PDFConverter) topLevelSections(outline []OutlineEntry, numPages int) []SectionResult {
+	var sections []SectionResult
+	for _, entry := range outline {
+		if entry.Page < 1 || entry.Page > numPages {
+			c.logger.Debug("Skipping outline entry %q with unresolved destination", entry.Title)
+			continue
+		}
+		if n := len(sections); n > 0 && sections[n-1].StartPage >= entry.Page {
+			// Out-of-order or same-page bookmarks are folded into the previous section
+			continue
+		}
+		title := entry.Title
+		if title == "" {
+			title = fmt.Sprintf("Section %d", len(sections)+1)
+		}
+		sections = append(sections, SectionResult{Title: title, StartPage: entry.Page})
+	}
+	for i := range sections {
+		if i+1 < len(sections) {
+			sections[i].EndPage = sections[i+1].StartPage - 1
+		} else {
+			sections[i].EndPage = numPages
+		}
+	}
+	// Any front matter before the first bookmark belongs to the first section
+	if len(sections) > 0 {
+		sections[0].StartPage = 1
+	}
+	return sections
+}
+
+// generateSectionIndex renders the index README linking every section directory.
+func (c *PDFConverter) generateSectionIndex(pdfPath string, sections []SectionResult) string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filepath.Base(pdfPath)))
+	md.WriteString("## Sections\n\n")
+	for _, s := range sections {
+		md.WriteString(fmt.Sprintf("- [%s](./%s/README.md) (pages %d-%d)\n", s.Title, filepath.Base(s.Result.OutputDir), s.StartPage, s.EndPage))
+	}
+	return md.String()
+}
+
+// slugify turns a section title into a filesystem- and anchor-friendly name.
+func slugify(title string) string {
+	slug := strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 48 {
+		slug = strings.TrimRight(slug[:48], "-")
+	}
+	if slug == "" {
+		slug = "section"
+	}
+	return slug
+}
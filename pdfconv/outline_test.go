@@ -0,0 +1,157 @@
+package pdfconv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestSplitPDFBySections(t *testing.T) {
+	pdfPath := createTempOutlinedPDF(t)
+	cfg := &config.Config{BaseHeaderLevel: 1}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.SplitPDFBySections(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("SplitPDFBySections() error = %v", err)
+	}
+	if len(res.Sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d: %+v", len(res.Sections), res.Sections)
+	}
+	first, second := res.Sections[0], res.Sections[1]
+	if first.StartPage != 1 || first.EndPage != 2 || second.StartPage != 3 || second.EndPage != 4 {
+		t.Errorf("unexpected page ranges: %+v", res.Sections)
+	}
+	if !strings.HasSuffix(second.Result.OutputDir, "SECTION_02_chapter-3") {
+		t.Errorf("unexpected section dir: %s", second.Result.OutputDir)
+	}
+	md, err := os.ReadFile(second.Result.MarkdownFile)
+	if err != nil {
+		t.Fatalf("read section markdown: %v", err)
+	}
+	if !strings.HasPrefix(string(md), "# Chapter 3") || !strings.Contains(string(md), "Page 3") || strings.Contains(string(md), "Page 2\n") {
+		t.Errorf("section markdown has unexpected content:\n%s", md)
+	}
+	index, err := os.ReadFile(res.IndexFile)
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	if !strings.Contains(string(index), "[Chapter 1](./SECTION_01_chapter-1/README.md)") {
+		t.Errorf("index missing section link:\n%s", index)
+	}
+}
+
+func TestSplitPDFBySections_NoOutline(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	if _, err := conv.SplitPDFBySections(createTempValidPDF(t), t.TempDir()); err == nil {
+		t.Fatal("expected error for PDF without outline")
+	}
+}
+
+func TestTopLevelSections(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	outline := []OutlineEntry{{Title: "Intro", Page: 2}, {Title: "Broken", Page: 0}, {Title: "", Page: 5}, {Title: "Dup", Page: 5}}
+	sections := conv.topLevelSections(outline, 9)
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %+v", sections)
+	}
+	if sections[0].StartPage != 1 || sections[0].EndPage != 4 {
+		t.Errorf("first section should absorb front matter: %+v", sections[0])
+	}
+	if sections[1].Title != "Section 2" || sections[1].EndPage != 9 {
+		t.Errorf("unexpected second section: %+v", sections[1])
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{"7.3 Timing Requirements": "7-3-timing-requirements", "  ": "section", "Électrique!!": "lectrique"}
+	for in, want := range cases {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// createTempOutlinedPDF builds a 4-page PDF with top-level bookmarks on pages 1 and 3.
+func createTempOutlinedPDF(t *testing.T) string {
+	t.Helper()
+	pdfPath := filepath.Join(t.TempDir(), "manual.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	for i := 1; i <= 4; i++ {
+		doc.AddPage()
+		if i%2 == 1 {
+			doc.Bookmark(fmt.Sprintf("Chapter %d", i), 0, 0)
+		}
+		doc.Bookmark(fmt.Sprintf("Topic %d", i), 1, -1)
+		doc.Cell(40, 10, fmt.Sprintf("Content of page %d", i))
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create outlined pdf: %v", err)
+	}
+	return pdfPath
+}
+
+func TestSplitPDFBySections_PartialSection(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err := conv.SplitPDFBySections(createTempPartialOutlinedPDF(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("expected a partial split, got error: %v", err)
+	}
+	first, second := res.Sections[0].Result, res.Sections[1].Result
+	if first.Status != StatusComplete || len(first.FailedPages) != 0 {
+		t.Errorf("expected the first section complete, got %s %+v", first.Status, first.FailedPages)
+	}
+	if second.Status != StatusPartial || len(second.FailedPages) != 1 || second.FailedPages[0].Page != 3 {
+		t.Fatalf("expected page 3 of the second section reported as failed, got %s %+v", second.Status, second.FailedPages)
+	}
+	report, _ := os.ReadFile(filepath.Join(second.OutputDir, ReportFileName))
+	if !strings.Contains(string(report), `"status": "partial"`) {
+		t.Errorf("expected the status in the section's conversion report, got:\n%s", report)
+	}
+}
+
+func TestSplitPDFBySections_StrictMode(t *testing.T) {
+	pdfPath := createTempPartialOutlinedPDF(t)
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, StrictMode: true}, logger.NewLogger("error"))
+	outputBase := t.TempDir()
+	_, err := conv.SplitPDFBySections(pdfPath, outputBase)
+	if !errors.Is(err, ErrIncomplete) || !strings.Contains(err.Error(), `section "Chapter 3"`) || !strings.Contains(err.Error(), "page 3: text extraction failed") {
+		t.Fatalf("expected strict mode to fail the split, got %v", err)
+	}
+	if entries, _ := os.ReadDir(outputBase); len(entries) != 0 {
+		t.Errorf("expected no output written, got %d entries", len(entries))
+	}
+	if _, err := conv.SplitPDFBySections(createTempOutlinedPDF(t), t.TempDir()); err != nil {
+		t.Errorf("expected a complete document to split in strict mode, got %v", err)
+	}
+}
+
+// createTempPartialOutlinedPDF writes the PDF of createTempOutlinedPDF with a third page
+// that cannot be read.
+func createTempPartialOutlinedPDF(t *testing.T) string {
+	t.Helper()
+	pdfPath := createTempOutlinedPDF(t)
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Give the content stream of page 3 a filter the reader cannot decode, keeping offsets
+	at := 0
+	for i := 0; i < 3; i++ {
+		at += bytes.Index(data[at:], []byte("/FlateDecode")) + 1
+	}
+	copy(data[at-1:], "/BrokenCodec")
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pdfPath
+}
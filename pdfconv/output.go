@@ -0,0 +1,86 @@
+// Package pdfconv - Crash-safe output directories.
+// This file stages conversion output in a hidden temporary directory next to the final
+// MARKDOWN_<name> directory and renames it into place only once generation succeeded.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StaleStagingAge is how old an abandoned staging directory must be before it is removed.
+const StaleStagingAge = 24 * time.Hour
+
+// outputDirectoryName returns the MARKDOWN_<name> directory name for a PDF.
+func outputDirectoryName(pdfPath string) string {
+	baseName := filepath.Base(pdfPath)
+	return fmt.Sprintf("MARKDOWN_%s", strings.TrimSuffix(baseName, filepath.Ext(baseName)))
+}
+
+// createStagingDirectory creates a hidden staging directory in outputBaseDir and returns it
+// together with the final output directory it will replace. Staging directories left behind
+// by an earlier crash for the same PDF are removed once they are older than StaleStagingAge.
+func (c *PDFConverter) createStagingDirectory(pdfPath, outputBaseDir string) (staging, final string, err error) {
+	name := outputDirectoryName(pdfPath)
+	final = filepath.Join(outputBaseDir, name)
+	if err := os.MkdirAll(outputBaseDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create directory %s: %v", outputBaseDir, err)
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(outputBaseDir, "."+name+".tmp-*"))
+	for _, dir := range stale {
+		if info, err := os.Stat(dir); err == nil && time.Since(info.ModTime()) > StaleStagingAge {
+			c.logger.Info("Removing abandoned staging directory %s", dir)
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	staging, err = os.MkdirTemp(outputBaseDir, "."+name+".tmp-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		_ = os.RemoveAll(staging)
+		return "", "", fmt.Errorf("failed to set staging directory permissions: %v", err)
+	}
+	c.logger.Debug("Staging output in %s", staging)
+	return staging, final, nil
+}
+
+// commitOutputDirectory replaces the final output directory with the completed staging
+// directory. An existing output is moved aside first and only deleted after the rename
+// succeeded, so readers see either the previous or the new complete output.
+func (c *PDFConverter) commitOutputDirectory(staging, final string) error {
+	var previous string
+	if _, err := os.Stat(final); err == nil {
+		previous = strings.Replace(staging, ".tmp-", ".old-", 1)
+		if err := os.Rename(final, previous); err != nil {
+			return fmt.Errorf("failed to move previous output aside: %v", err)
+		}
+	}
+	if err := os.Rename(staging, final); err != nil {
+		if previous != "" {
+			_ = os.Rename(previous, final)
+		}
+		return fmt.Errorf("failed to move output into place: %v", err)
+	}
+	if previous != "" {
+		if err := os.RemoveAll(previous); err != nil {
+			c.logger.Warn("Failed to remove previous output %s: %v", previous, err)
+		}
+	}
+	c.logger.Debug("Committed output directory %s", final)
+	return nil
+}
+
+// rebasePath maps a path inside the staging directory to the same path in the final directory.
+func rebasePath(path, staging, final string) string {
+	rel, err := filepath.Rel(staging, path)
+	if err != nil {
+		return path
+	}
+	return filepath.Join(final, rel)
+}
@@ -0,0 +1,81 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestStagingAndCommitOutputDirectory(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	base := t.TempDir()
+
+	// An abandoned staging directory from an earlier crash is cleaned up
+	abandoned := filepath.Join(base, ".MARKDOWN_doc.tmp-123")
+	if err := os.MkdirAll(abandoned, 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * StaleStagingAge)
+	_ = os.Chtimes(abandoned, old, old)
+
+	// A previous complete output is replaced as a whole
+	final := filepath.Join(base, "MARKDOWN_doc")
+	if err := os.MkdirAll(final, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(final, "stale.png"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	staging, gotFinal, err := conv.createStagingDirectory("/pdfs/doc.pdf", base)
+	if err != nil {
+		t.Fatalf("createStagingDirectory() error = %v", err)
+	}
+	if gotFinal != final || !strings.HasPrefix(filepath.Base(staging), ".MARKDOWN_doc.tmp-") {
+		t.Errorf("unexpected directories: staging=%s final=%s", staging, gotFinal)
+	}
+	if _, err := os.Stat(abandoned); !os.IsNotExist(err) {
+		t.Errorf("expected abandoned staging directory to be removed")
+	}
+	if err := os.WriteFile(filepath.Join(staging, "README.md"), []byte("# new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := conv.commitOutputDirectory(staging, final); err != nil {
+		t.Fatalf("commitOutputDirectory() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(final, "README.md")); err != nil {
+		t.Errorf("expected new output in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(final, "stale.png")); !os.IsNotExist(err) {
+		t.Errorf("expected previous output to be replaced")
+	}
+	entries, _ := os.ReadDir(base)
+	if len(entries) != 1 {
+		t.Errorf("expected only the final directory to remain, got %d entries", len(entries))
+	}
+}
+
+func TestConvertPDF_LeavesNoStagingDirectory(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	base := t.TempDir()
+	res, err := conv.ConvertPDF(createTempValidPDF(t), base)
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if _, err := os.Stat(res.MarkdownFile); err != nil {
+		t.Errorf("expected Markdown file at %s: %v", res.MarkdownFile, err)
+	}
+	entries, _ := os.ReadDir(base)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("unexpected leftover staging directory %s", e.Name())
+		}
+	}
+}
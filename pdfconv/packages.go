@@ -0,0 +1,619 @@
+// Package pdfconv - Package dimensions.
+// This file recognizes the package drawings and mechanical dimension tables at the end of
+// datasheets and exports their dimensions in millimeters, with the body size, lead pitch
+// and recommended land pattern pads derived from them, so converted datasheets can feed EDA
+// footprint generation tools. Dimensions are read by their JEDEC symbols: D and E1 (E for
+// leadless packages) are the body length and width, A the height, e the pitch, b the lead
+// width, L the lead length and E the lead span.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PackageFileName is the name of the package dimension file written next to the document.
+const PackageFileName = "package.json"
+
+// Sources of pad recommendations
+const (
+	PadsFromLandPattern = "land_pattern" // Land pattern table of the datasheet
+	PadsDerived         = "derived"      // Computed from the lead dimensions with IPC-7351 nominal fillets
+)
+
+// Dimension table column kinds
+const (
+	dimensionSymbol      = "symbol"
+	dimensionDescription = "description"
+	dimensionMin         = "min"
+	dimensionNom         = "nom"
+	dimensionMax         = "max"
+)
+
+// packageMinDimensions is the number of dimension rows a table must have to be read as a
+// mechanical dimension table.
+const packageMinDimensions = 3
+
+// drawingMinLabels is the number of dimension labels, such as "E1" or "0.65", a page with
+// a package title must have to count as a package drawing.
+const drawingMinLabels = 5
+
+// PackageDimension is a row of a mechanical dimension table, in millimeters.
+type PackageDimension struct {
+	Symbol      string   `json:"symbol"`
+	Description string   `json:"description,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Nom         *float64 `json:"nom,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Basic       bool     `json:"basic,omitempty"` // Theoretically exact value (BSC) or reference value (REF)
+}
+
+// PackageBody is the size of a package body in millimeters.
+type PackageBody struct {
+	Length *float64 `json:"length,omitempty"` // D
+	Width  *float64 `json:"width,omitempty"`  // E1, or E for leadless packages
+	Height *float64 `json:"height,omitempty"` // A, the maximum height above the board
+}
+
+// PadRecommendation is the land pattern pad of a package lead in millimeters.
+type PadRecommendation struct {
+	Width  float64  `json:"width"`          // Pad size across the lead
+	Length float64  `json:"length"`         // Pad size along the lead
+	Span   *float64 `json:"span,omitempty"` // Distance between the centers of opposite pad rows
+	Source string   `json:"source"`         // PadsFromLandPattern or PadsDerived
+}
+
+// PackageDimensions holds the dimensions of a package read from a mechanical dimension
+// table, with the pages of its drawing.
+type PackageDimensions struct {
+	Name         string             `json:"name,omitempty"` // Package name from the title, e.g. "SOIC-8"
+	Pages        []int              `json:"pages"`          // Pages of the dimension and land pattern tables
+	DrawingPages []int              `json:"drawing_pages,omitempty"`
+	Body         *PackageBody       `json:"body,omitempty"`
+	Pitch        *float64           `json:"pitch,omitempty"`
+	Pads         *PadRecommendation `json:"pads,omitempty"`
+	Dimensions   []PackageDimension `json:"dimensions"`
+	landPattern  []PackageDimension // Rows of the land pattern table, if the datasheet has one
+}
+
+// packageFile is the content of package.json.
+type packageFile struct {
+	Source   string              `json:"source"`
+	Unit     string              `json:"unit"`
+	Packages []PackageDimensions `json:"packages"`
+}
+
+// dimensionGroup locates the min, nom and max columns of one unit in a dimension table.
+type dimensionGroup struct {
+	unit          string
+	min, nom, max int // Column indexes, -1 when the table has no such column
+}
+
+var (
+	// packageTitlePattern matches titles of package drawings and dimension tables.
+	packageTitlePattern = regexp.MustCompile(`(?i)\b(package\s+(outlines?|dimensions?|drawings?|mechanical\s+data)|mechanical\s+(data|dimensions?|drawings?|outlines?)|physical\s+dimensions|outline\s+dimensions|dimensional\s+drawings?|case\s+outlines?)\b`)
+	// landPatternTitlePattern matches titles of recommended land pattern tables.
+	landPatternTitlePattern = regexp.MustCompile(`(?i)\b(land\s+patterns?|recommended\s+(footprints?|land|pads?|pcb|solder)|footprints?|pcb\s+(layout|pads?|land)|solder\s+pads?)\b`)
+	// packageNamePattern matches package names such as "SOIC-8", "VQFN-32" or "SOT-23-5".
+	packageNamePattern = regexp.MustCompile(`\b([A-Z]{0,2}(?:SOIC|SSOP|TSSOP|MSOP|SOP|QFN|DFN|SON|QFP|BGA|WLCSP|CSP|DIP|PLCC|LGA|TSOP|PAK)(?:[-\s]?\d{1,4})?|(?:SOT|TO|SC)-?\d{2,3}(?:-\d{1,2})?)\b`)
+	// leadlessPackagePattern matches names of packages whose leads are pads under the body.
+	leadlessPackagePattern = regexp.MustCompile(`QFN|DFN|SON`)
+	// dimensionSymbolPattern matches the symbol of a dimension, such as "A1", "b" or "E1".
+	dimensionSymbolPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{0,3}$`)
+	// dimensionValuePattern matches a dimension value, optionally marked basic or reference.
+	dimensionValuePattern = regexp.MustCompile(`(?i)^(\d*\.?\d+)\s*(BSC|REF|TYP\.?|NOM\.?)?$`)
+	// drawingLabelPattern matches the labels of a dimensioned drawing: symbols and values.
+	drawingLabelPattern = regexp.MustCompile(`^([A-Za-z]\d?|\d*\.\d+(\s*(BSC|REF))?)$`)
+	// millimeterPattern, inchPattern and milPattern match unit names in labels and titles.
+	millimeterPattern = regexp.MustCompile(`(?i)\b(mm|millimet(er|re)s?)\b`)
+	inchPattern       = regexp.MustCompile(`(?i)\b(inch(es)?|in\.)`)
+	milPattern        = regexp.MustCompile(`(?i)\bmils?\b`)
+)
+
+// collectPackages finds the mechanical dimension tables of a document, attaches land pattern
+// tables and drawing pages to their package and derives the body size, pitch and pads.
+func (c *PDFConverter) collectPackages(pages []PDFPage) []PackageDimensions {
+	if !c.config.PackageDimensions || !c.config.ExtractTables {
+		return nil
+	}
+	var packages []PackageDimensions
+	var drawings []PDFPage
+	for _, page := range pages {
+		pageTitle := packageTitleLine(page)
+		if pageTitle != "" && (len(page.Images) > 0 || drawingLabels(page) >= drawingMinLabels) {
+			drawings = append(drawings, page)
+		}
+		for _, table := range page.Tables {
+			if table.Merged || table.Fallback {
+				continue
+			}
+			title := tableTitle(page, table)
+			landPattern := landPatternTitlePattern.MatchString(title)
+			if !landPattern && !packageTitlePattern.MatchString(title) && pageTitle == "" {
+				continue
+			}
+			dimensions := dimensionTable(table, pageUnit(page, title))
+			if dimensions == nil {
+				continue
+			}
+			name := packageName(title)
+			if name == "" {
+				name = packageName(pageTitle)
+			}
+			if landPattern {
+				if i := findPackage(packages, name); i >= 0 {
+					packages[i].landPattern = dimensions
+					packages[i].addPage(page.Number)
+				}
+				continue
+			}
+			packages = append(packages, PackageDimensions{Name: name, Pages: []int{page.Number}, Dimensions: dimensions})
+		}
+	}
+	for _, page := range drawings {
+		if i := drawingPackage(packages, page); i >= 0 {
+			packages[i].DrawingPages = append(packages[i].DrawingPages, page.Number)
+		}
+	}
+	for i := range packages {
+		packages[i].finish()
+	}
+	if len(packages) > 0 {
+		c.logger.Info("Found dimensions of %d package(s) in mechanical dimension tables", len(packages))
+	}
+	return packages
+}
+
+// packageTitleLine returns the first line of a page that titles a package drawing, or "".
+// Table of contents entries, with dot leaders, are not titles.
+func packageTitleLine(page PDFPage) string {
+	for _, line := range page.Lines {
+		if packageTitlePattern.MatchString(line.Text) && !strings.Contains(line.Text, "....") {
+			return line.Text
+		}
+	}
+	return ""
+}
+
+// drawingLabels counts the lines of a page that look like the labels of a dimensioned
+// drawing.
+func drawingLabels(page PDFPage) int {
+	count := 0
+	for _, line := range page.Lines {
+		for _, cell := range line.Cells {
+			if drawingLabelPattern.MatchString(strings.TrimSpace(cell.Text)) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// tableTitle returns the caption of a table and the lines just above it.
+func tableTitle(page PDFPage, table PDFTable) string {
+	parts := []string{table.Caption}
+	start := table.FirstLine
+	if table.CaptionLine >= 0 {
+		start = table.CaptionLine
+	}
+	for i := start - 1; i >= 0 && i >= start-3; i-- {
+		if i < len(page.Lines) {
+			parts = append(parts, page.Lines[i].Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// packageName returns the first package name in text, with spaces replaced by dashes.
+func packageName(text string) string {
+	return strings.ReplaceAll(packageNamePattern.FindString(text), " ", "-")
+}
+
+// pageUnit returns the unit a table's title, or else its page, states dimensions in.
+// Millimeters are assumed when neither names a unit.
+func pageUnit(page PDFPage, title string) string {
+	if unit := dimensionUnit(title); unit != "" {
+		return unit
+	}
+	var text strings.Builder
+	for _, line := range page.Lines {
+		text.WriteString(line.Text + "\n")
+	}
+	if unit := dimensionUnit(text.String()); unit != "" {
+		return unit
+	}
+	return "mm"
+}
+
+// dimensionUnit returns the unit named in text: "mm", "inch" or "mil", or "" when none is.
+// Millimeters win when text names several units.
+func dimensionUnit(text string) string {
+	switch {
+	case millimeterPattern.MatchString(text):
+		return "mm"
+	case inchPattern.MatchString(text):
+		return "inch"
+	case milPattern.MatchString(text):
+		return "mil"
+	}
+	return ""
+}
+
+// dimensionColumnKind classifies a dimension table header label. Labels that are not a
+// known column return "".
+func dimensionColumnKind(label string) string {
+	words := strings.FieldsFunc(strings.ToLower(label), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		switch word {
+		case "symbol", "sym", "dim", "ref":
+			return dimensionSymbol
+		case "description", "parameter", "feature", "item", "characteristic":
+			return dimensionDescription
+		}
+	}
+	for _, word := range words {
+		switch word {
+		case "min", "minimum":
+			return dimensionMin
+		case "nom", "nominal", "typ", "typical":
+			return dimensionNom
+		case "max", "maximum":
+			return dimensionMax
+		}
+	}
+	return ""
+}
+
+// dimensionTable reads the rows of a mechanical dimension table in millimeters, or returns
+// nil when the table is not one. The min, nom and max labels may be in the header or, below
+// a header naming the units ("MILLIMETERS", "INCHES"), in the first row; of several unit
+// groups the millimeter columns are read.
+func dimensionTable(table PDFTable, unit string) []PackageDimension {
+	labels, body, units := table.Header, table.Rows, []string(nil)
+	if !hasDimensionColumns(labels) && len(body) > 0 && hasDimensionColumns(body[0]) {
+		for _, cell := range labels {
+			if u := dimensionUnit(cell); u != "" {
+				units = append(units, u)
+			}
+		}
+		labels, body = body[0], body[1:]
+	}
+
+	symbol, description := -1, -1
+	var groups []dimensionGroup
+	for i, label := range labels {
+		kind := dimensionColumnKind(label)
+		switch kind {
+		case dimensionSymbol:
+			if symbol < 0 {
+				symbol = i
+			}
+		case dimensionDescription:
+			if description < 0 {
+				description = i
+			}
+		case dimensionMin, dimensionNom, dimensionMax:
+			if len(groups) == 0 || *groups[len(groups)-1].column(kind) >= 0 {
+				groups = append(groups, dimensionGroup{min: -1, nom: -1, max: -1})
+			}
+			group := &groups[len(groups)-1]
+			*group.column(kind) = i
+			if u := dimensionUnit(label); u != "" {
+				group.unit = u
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	if symbol < 0 {
+		symbol = symbolColumn(body, len(labels), groups, description)
+	}
+	if description < 0 {
+		// An unlabeled text column left of the symbols describes them
+		for i := 0; i < symbol; i++ {
+			if !groupColumn(groups, i) {
+				description = i
+				break
+			}
+		}
+	}
+	group := groups[0]
+	for i := range groups {
+		if groups[i].unit == "" {
+			groups[i].unit = unit
+			if i < len(units) {
+				groups[i].unit = units[i]
+			}
+		}
+		if groups[i].unit == "mm" && group.unit != "mm" {
+			group = groups[i]
+		}
+	}
+	if group.unit == "" {
+		group.unit = groups[0].unit
+	}
+	factor := map[string]float64{"mm": 1, "inch": 25.4, "mil": 0.0254}[group.unit]
+
+	var dimensions []PackageDimension
+	for _, row := range body {
+		dimension := PackageDimension{Symbol: cleanPartNumber(row[symbol])}
+		if !dimensionSymbolPattern.MatchString(dimension.Symbol) || strings.Contains(strings.Join(row, " "), "°") {
+			continue // Angles and text rows
+		}
+		if description >= 0 {
+			dimension.Description = strings.TrimSpace(row[description])
+		}
+		found := false
+		for _, field := range []struct {
+			column int
+			value  **float64
+		}{{group.min, &dimension.Min}, {group.nom, &dimension.Nom}, {group.max, &dimension.Max}} {
+			if field.column < 0 {
+				continue
+			}
+			value, basic, ok := parseDimensionValue(row[field.column])
+			if !ok {
+				continue
+			}
+			value = math.Round(value*factor*1e4) / 1e4
+			*field.value = &value
+			dimension.Basic = dimension.Basic || basic
+			found = true
+		}
+		if found {
+			dimensions = append(dimensions, dimension)
+		}
+	}
+	if len(dimensions) < packageMinDimensions {
+		return nil
+	}
+	return dimensions
+}
+
+// symbolColumn returns the column outside the value groups whose cells are most often
+// dimension symbols, for tables whose symbol column has no label.
+func symbolColumn(rows [][]string, columns int, groups []dimensionGroup, description int) int {
+	best, most := 0, -1
+	for i := 0; i < columns; i++ {
+		if i == description || groupColumn(groups, i) {
+			continue
+		}
+		count := 0
+		for _, row := range rows {
+			if dimensionSymbolPattern.MatchString(cleanPartNumber(row[i])) {
+				count++
+			}
+		}
+		if count > most {
+			best, most = i, count
+		}
+	}
+	return best
+}
+
+// groupColumn reports whether column i is a min, nom or max column of a group.
+func groupColumn(groups []dimensionGroup, i int) bool {
+	for _, g := range groups {
+		if g.min == i || g.nom == i || g.max == i {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDimensionColumns reports whether a row holds min, nom or max column labels.
+func hasDimensionColumns(labels []string) bool {
+	for _, label := range labels {
+		switch dimensionColumnKind(label) {
+		case dimensionMin, dimensionNom, dimensionMax:
+			return true
+		}
+	}
+	return false
+}
+
+// column returns the column index field of a min, nom or max column kind.
+func (g *dimensionGroup) column(kind string) *int {
+	switch kind {
+	case dimensionMin:
+		return &g.min
+	case dimensionNom:
+		return &g.nom
+	}
+	return &g.max
+}
+
+// parseDimensionValue parses a dimension cell such as "4.90" or "1.27 BSC". basic reports a
+// basic or reference value.
+func parseDimensionValue(cell string) (value float64, basic bool, ok bool) {
+	m := dimensionValuePattern.FindStringSubmatch(strings.TrimSpace(cell))
+	if m == nil {
+		return 0, false, false
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false, false
+	}
+	suffix := strings.ToUpper(m[2])
+	return value, suffix == "BSC" || suffix == "REF", true
+}
+
+// findPackage returns the index of the last package named name, or of the last package
+// when name is empty or matches none. It returns -1 when there are no packages.
+func findPackage(packages []PackageDimensions, name string) int {
+	if name != "" {
+		for i := len(packages) - 1; i >= 0; i-- {
+			if packages[i].Name == name {
+				return i
+			}
+		}
+	}
+	return len(packages) - 1
+}
+
+// drawingPackage returns the index of the package a drawing page belongs to: the package
+// named on the page, else the package whose tables are on the page or next to it, or -1.
+func drawingPackage(packages []PackageDimensions, page PDFPage) int {
+	if name := packageName(packageTitleLine(page)); name != "" {
+		for i := range packages {
+			if packages[i].Name == name {
+				return i
+			}
+		}
+	}
+	best, distance := -1, 2
+	for i := range packages {
+		for _, number := range packages[i].Pages {
+			d := number - page.Number
+			if d < 0 {
+				d = -d
+			}
+			if d < distance {
+				best, distance = i, d
+			}
+		}
+	}
+	return best
+}
+
+// addPage adds a page to the pages of a package.
+func (p *PackageDimensions) addPage(number int) {
+	for _, existing := range p.Pages {
+		if existing == number {
+			return
+		}
+	}
+	p.Pages = append(p.Pages, number)
+}
+
+// dimension returns the first dimension with the symbol, which is case-sensitive.
+func (p *PackageDimensions) dimension(symbol string) *PackageDimension {
+	for i := range p.Dimensions {
+		if p.Dimensions[i].Symbol == symbol {
+			return &p.Dimensions[i]
+		}
+	}
+	return nil
+}
+
+// value returns the nominal value of a dimension, else the middle of its range, else its
+// only limit. It returns nil for a nil dimension.
+func (d *PackageDimension) value() *float64 {
+	switch {
+	case d == nil:
+		return nil
+	case d.Nom != nil:
+		return d.Nom
+	case d.Min != nil && d.Max != nil:
+		mid := math.Round((*d.Min+*d.Max)/2*1e4) / 1e4
+		return &mid
+	case d.Min != nil:
+		return d.Min
+	}
+	return d.Max
+}
+
+// finish derives the body size, pitch and pads of a package from its dimensions.
+func (p *PackageDimensions) finish() {
+	leaded := p.dimension("E1") != nil
+	leadless := !leaded && leadlessPackagePattern.MatchString(p.Name)
+	body := PackageBody{Length: p.dimension("D").value(), Width: p.dimension("E1").value()}
+	if leadless {
+		body.Width = p.dimension("E").value()
+	}
+	if height := p.dimension("A"); height != nil {
+		body.Height = height.Max
+		if body.Height == nil {
+			body.Height = height.value()
+		}
+	}
+	if body.Length != nil || body.Width != nil || body.Height != nil {
+		p.Body = &body
+	}
+	p.Pitch = p.dimension("e").value()
+
+	if p.landPattern != nil {
+		p.Pads = p.landPatternPads()
+	}
+	if p.Pads == nil && (leaded || leadless) {
+		p.Pads = p.derivedPads(leadless)
+	}
+}
+
+// landPatternPads reads the pad size and row span from the land pattern table, named by
+// description ("Contact Pad Width") or else by the symbols X (width), Y (length), C (span)
+// and E (pitch). The table's pitch is
+// used when the dimension table has none.
+func (p *PackageDimensions) landPatternPads() *PadRecommendation {
+	var width, length, span, pitch *float64
+	for i := range p.landPattern {
+		d := &p.landPattern[i]
+		description := strings.ToLower(d.Description)
+		symbol := strings.ToUpper(d.Symbol)
+		switch {
+		case strings.Contains(description, "pad width"):
+			width = d.value()
+		case strings.Contains(description, "pad length"):
+			length = d.value()
+		case strings.Contains(description, "spacing") || strings.Contains(description, "span"):
+			span = d.value()
+		case strings.Contains(description, "pitch"):
+			pitch = d.value()
+		case strings.HasPrefix(symbol, "X"):
+			width = d.value()
+		case strings.HasPrefix(symbol, "Y"):
+			length = d.value()
+		case strings.HasPrefix(symbol, "C"):
+			span = d.value()
+		case symbol == "E":
+			pitch = d.value()
+		}
+	}
+	if p.Pitch == nil {
+		p.Pitch = pitch
+	}
+	if width == nil || length == nil {
+		return nil
+	}
+	return &PadRecommendation{Width: *width, Length: *length, Span: span, Source: PadsFromLandPattern}
+}
+
+// derivedPads computes pads from the lead width b, lead length L and lead span E with the
+// IPC-7351 nominal toe, heel and side fillets, ignoring tolerances. Gull-wing leads get
+// 0.35 mm toe and heel fillets; leadless pads extend 0.4 mm beyond the package edge.
+func (p *PackageDimensions) derivedPads(leadless bool) *PadRecommendation {
+	b, l, e := p.dimension("b").value(), p.dimension("L").value(), p.dimension("E").value()
+	if b == nil || l == nil || e == nil {
+		return nil
+	}
+	toe, heel, side := 0.35, 0.35, 0.03
+	if p.Pitch != nil && *p.Pitch <= 0.625 {
+		side = 0.01
+	}
+	if leadless {
+		toe, heel, side = 0.4, 0, -0.04
+	}
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	span := round(*e + toe - heel - *l)
+	return &PadRecommendation{Width: round(*b + 2*side), Length: round(*l + toe + heel), Span: &span, Source: PadsDerived}
+}
+
+// writePackageFile writes package.json for a document into dir.
+func writePackageFile(dir, docPath string, packages []PackageDimensions) error {
+	data, err := json.MarshalIndent(packageFile{Source: docPath, Unit: "mm", Packages: packages}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode package dimensions: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, PackageFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write package dimensions: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCollectPackages(t *testing.T) {
+	cfg := &config.Config{ExtractTables: true, PackageDimensions: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	// Gull-wing package with millimeter and inch columns below a unit header row
+	soic := []TextLine{
+		tableLine(760, "SOIC-8 Package Outline"),
+		tableLine(740, "E1"),
+		tableLine(730, "E"),
+		tableLine(720, "D"),
+		tableLine(710, "0.25"),
+		tableLine(700, "e"),
+		tableLine(690, "L"),
+		tableLine(600, "DIM", "MILLIMETERS", "-", "-", "INCHES", "-", "-"),
+		tableLine(580, "-", "MIN", "NOM", "MAX", "MIN", "NOM", "MAX"),
+		tableLine(560, "A", "-", "-", "1.75", "-", "-", "0.069"),
+		tableLine(540, "A1", "0.10", "-", "0.25", "0.004", "-", "0.010"),
+		tableLine(520, "b", "0.31", "-", "0.51", "0.012", "-", "0.020"),
+		tableLine(500, "D", "4.80", "4.90", "5.00", "0.189", "0.193", "0.197"),
+		tableLine(480, "E", "-", "6.00 BSC", "-", "-", "0.236 BSC", "-"),
+		tableLine(460, "E1", "-", "3.90 BSC", "-", "-", "0.154 BSC", "-"),
+		tableLine(440, "e", "-", "1.27 BSC", "-", "-", "0.050 BSC", "-"),
+		tableLine(420, "L", "0.40", "0.60", "0.80", "0.016", "0.024", "0.031"),
+		tableLine(400, "θ", "0°", "-", "8°", "0°", "-", "8°"),
+	}
+	qfn := []TextLine{
+		tableLine(760, "VQFN-16 Mechanical Data"),
+		tableLine(700, "Symbol", "Min", "Nom", "Max"),
+		tableLine(680, "A", "0.80", "0.90", "1.00"),
+		tableLine(660, "D", "-", "3.00 BSC", "-"),
+		tableLine(640, "E", "-", "3.00 BSC", "-"),
+		tableLine(620, "b", "0.18", "0.25", "0.30"),
+		tableLine(600, "L", "0.30", "0.40", "0.50"),
+	}
+	landPattern := []TextLine{
+		tableLine(760, "Recommended Land Pattern (VQFN-16)"),
+		tableLine(740, "Description", "Symbol", "Min", "Nom", "Max"),
+		tableLine(720, "Contact Pitch", "E", "-", "0.50 BSC", "-"),
+		tableLine(700, "Contact Pad Spacing", "C", "-", "3.10", "-"),
+		tableLine(680, "Contact Pad Width", "X1", "-", "-", "0.30"),
+		tableLine(660, "Contact Pad Length", "Y1", "-", "-", "0.85"),
+	}
+	electrical := []TextLine{
+		tableLine(700, "Parameter", "Symbol", "Min", "Typ", "Max"),
+		tableLine(680, "Supply voltage", "VDD", "1.8", "3.3", "3.6"),
+		tableLine(660, "Rise time", "tR", "-", "5", "-"),
+		tableLine(640, "Fall time", "tF", "-", "5", "-"),
+	}
+	var pages []PDFPage
+	for i, lines := range [][]TextLine{electrical, soic, qfn, landPattern} {
+		pages = append(pages, PDFPage{Number: i + 1, Lines: lines, Tables: detectTables(lines)})
+	}
+
+	packages := conv.collectPackages(pages)
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d: %+v", len(packages), packages)
+	}
+	so := packages[0]
+	if so.Name != "SOIC-8" || len(so.Dimensions) != 8 || len(so.DrawingPages) != 1 || so.DrawingPages[0] != 2 {
+		t.Fatalf("unexpected SOIC package: %+v", so)
+	}
+	if so.Body == nil || *so.Body.Length != 4.9 || *so.Body.Width != 3.9 || *so.Body.Height != 1.75 || *so.Pitch != 1.27 {
+		t.Errorf("unexpected SOIC body or pitch: %+v %v", so.Body, so.Pitch)
+	}
+	if e := so.dimension("E"); e == nil || !e.Basic || *e.Nom != 6 {
+		t.Errorf("expected E as a basic 6 mm dimension, got %+v", e)
+	}
+	if so.Pads == nil || so.Pads.Source != PadsDerived || so.Pads.Width != 0.47 || so.Pads.Length != 1.3 || *so.Pads.Span != 5.4 {
+		t.Errorf("unexpected derived SOIC pads: %+v", so.Pads)
+	}
+
+	vqfn := packages[1]
+	if vqfn.Name != "VQFN-16" || len(vqfn.Pages) != 2 || vqfn.Pages[1] != 4 {
+		t.Fatalf("expected the land pattern attached to VQFN-16, got %+v", vqfn)
+	}
+	if vqfn.Body == nil || *vqfn.Body.Width != 3 || *vqfn.Body.Height != 1 || vqfn.Pitch == nil || *vqfn.Pitch != 0.5 {
+		t.Errorf("unexpected VQFN body or pitch: %+v %v", vqfn.Body, vqfn.Pitch)
+	}
+	if vqfn.Pads == nil || vqfn.Pads.Source != PadsFromLandPattern || vqfn.Pads.Width != 0.3 || vqfn.Pads.Length != 0.85 || *vqfn.Pads.Span != 3.1 {
+		t.Errorf("unexpected land pattern pads: %+v", vqfn.Pads)
+	}
+
+	cfg.PackageDimensions = false
+	if got := conv.collectPackages(pages); got != nil {
+		t.Errorf("expected no packages with PACKAGE_DIMENSIONS off, got %+v", got)
+	}
+}
+
+func TestDimensionTable_Inches(t *testing.T) {
+	table := detectTables([]TextLine{
+		tableLine(700, "Symbol", "Min", "Max"),
+		tableLine(680, "A", "0.053", "0.069"),
+		tableLine(660, "D", "0.189", "0.197"),
+		tableLine(640, "e", "0.050 BSC", "-"),
+	})[0]
+	dimensions := dimensionTable(table, "inch")
+	if len(dimensions) != 3 {
+		t.Fatalf("expected 3 dimensions, got %+v", dimensions)
+	}
+	if d := dimensions[1]; *d.Min != 4.8006 || *d.Max != 5.0038 {
+		t.Errorf("expected D converted to millimeters, got %v %v", *d.Min, *d.Max)
+	}
+	if e := dimensions[2]; !e.Basic || *e.Min != 1.27 {
+		t.Errorf("expected e as a basic 1.27 mm dimension, got %+v", e)
+	}
+}
+
+func TestConvertPDF_PackageDimensions(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "regulator.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Helvetica", "", 10)
+	doc.AddPage()
+	doc.SetXY(20, 20)
+	doc.CellFormat(100, 6, "Package Dimensions (SOT-23)", "", 1, "L", false, 0, "")
+	for _, row := range [][]string{{"Symbol", "Min", "Max"}, {"A", "0.90", "1.45"}, {"D", "2.80", "3.05"}, {"E1", "1.50", "1.75"}, {"e", "0.95", "0.95"}} {
+		doc.SetX(20)
+		doc.CellFormat(30, 6, row[0], "", 0, "L", false, 0, "")
+		doc.CellFormat(30, 6, row[1], "", 0, "L", false, 0, "")
+		doc.CellFormat(30, 6, row[2], "", 1, "L", false, 0, "")
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create package pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractTables: true, PackageDimensions: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(res.OutputDir, PackageFileName))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", PackageFileName, err)
+	}
+	var file packageFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid %s: %v", PackageFileName, err)
+	}
+	if file.Source != pdfPath || file.Unit != "mm" || len(file.Packages) != 1 || file.Packages[0].Name != "SOT-23" || len(file.Packages[0].Dimensions) != 4 {
+		t.Fatalf("unexpected %s content: %s", PackageFileName, data)
+	}
+	if body := file.Packages[0].Body; body == nil || *body.Length != 2.925 || *body.Height != 1.45 {
+		t.Errorf("unexpected body: %s", data)
+	}
+	if violations, err := ValidateSidecar(PackageFileName, data); err != nil || len(violations) != 0 {
+		t.Errorf("expected %s to match its schema, got %v %v", PackageFileName, err, violations)
+	}
+}
@@ -0,0 +1,253 @@
+// Package pdfconv - Page breaks.
+// This file removes the running headers and footers repeated at the top and bottom of the
+// pages and joins sentences that a page break split, so paragraphs read continuously
+// instead of being interrupted by the next page's header.
+package pdfconv
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Page break thresholds
+const (
+	RunningLineDepth    = 3   // Lines at the top and bottom of a page checked for running headers and footers
+	RunningLineMinPages = 3   // Fewest pages a line must repeat on to be a running header or footer
+	RunningLineMaxLen   = 100 // Longest running header or footer in characters
+	PageBreakMaxLines   = 4   // Most lines moved to finish a sentence split by a page break
+)
+
+// runningDigitsPattern matches the page numbers and other counters that change between the
+// repetitions of a running header or footer.
+var runningDigitsPattern = regexp.MustCompile(`\d+`)
+
+// pageLines is an editable view of the lines of a page: the plain text or the positioned
+// lines. Lines are removed by emptying them, so the line indices of tables stay valid.
+type pageLines struct {
+	texts []string
+	fixed []bool // Table rows and captions, which are left alone
+	save  func()
+}
+
+// lineViews returns the views of the lines of page that are rendered.
+func lineViews(page *PDFPage) []pageLines {
+	texts := strings.Split(page.Text, "\n")
+	views := []pageLines{{texts: texts, fixed: make([]bool, len(texts)), save: func() { page.Text = strings.Join(texts, "\n") }}}
+	if len(page.Lines) > 0 {
+		view := pageLines{texts: make([]string, len(page.Lines)), fixed: make([]bool, len(page.Lines))}
+		for i, line := range page.Lines {
+			view.texts[i] = line.Text
+			_, view.fixed[i] = tableAt(page.Tables, i)
+		}
+		view.save = func() {
+			for i := range page.Lines {
+				page.Lines[i].Text = view.texts[i]
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// edges returns the indices of the first and last RunningLineDepth non-empty lines, stopping
+// at table lines.
+func (v pageLines) edges() []int {
+	var top, bottom []int
+	for i := 0; i < len(v.texts) && len(top) < RunningLineDepth && !v.fixed[i]; i++ {
+		if strings.TrimSpace(v.texts[i]) != "" {
+			top = append(top, i)
+		}
+	}
+	for i := len(v.texts) - 1; i >= 0 && len(bottom) < RunningLineDepth && !v.fixed[i]; i-- {
+		if strings.TrimSpace(v.texts[i]) != "" {
+			bottom = append(bottom, i)
+		}
+	}
+	return append(top, bottom...)
+}
+
+// runningKey returns the text a running header or footer keeps on every page: s in lower
+// case with numbers replaced and whitespace collapsed, or "" for lines too long to be one.
+func runningKey(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || utf8.RuneCountInString(s) > RunningLineMaxLen {
+		return ""
+	}
+	return strings.Join(strings.Fields(runningDigitsPattern.ReplaceAllString(strings.ToLower(s), "#")), " ")
+}
+
+// breakablePage reports whether the lines of a page take part in running line removal and
+// sentence joining. Verbatim pages keep their text as is, and the text of multilingual
+// pages is rendered from its language segments.
+func breakablePage(page PDFPage) bool {
+	return !page.Verbatim && page.Segments == nil && page.Failure == "" && page.Text != ""
+}
+
+// joinPageBreaks removes running headers and footers and joins the sentences split across
+// page breaks when JOIN_PAGE_BREAKS is set. A sentence is joined when the last line of a
+// page does not end one and the first line of the next page starts in lower case; the
+// lines up to the end of the sentence move to the end of the previous page.
+func (c *PDFConverter) joinPageBreaks(pages []PDFPage) {
+	if !c.config.JoinPageBreaks || len(pages) < 2 {
+		return
+	}
+	removed := removeRunningLines(pages)
+	joined := 0
+	for i := 0; i+1 < len(pages); i++ {
+		if breakablePage(pages[i]) && breakablePage(pages[i+1]) && c.joinSentence(&pages[i], &pages[i+1]) {
+			joined++
+		}
+	}
+	if removed > 0 || joined > 0 {
+		c.logger.Debug("Removed %d running header and footer line(s), joined %d sentence(s) across page breaks", removed, joined)
+	}
+}
+
+// removeRunningLines empties the lines at the top and bottom of the pages that repeat, apart
+// from their numbers, on at least half of the pages and RunningLineMinPages, and returns how
+// many were removed.
+func removeRunningLines(pages []PDFPage) int {
+	counts := map[string]int{}
+	candidates := 0
+	for i := range pages {
+		if !breakablePage(pages[i]) {
+			continue
+		}
+		candidates++
+		seen := map[string]bool{}
+		for _, view := range lineViews(&pages[i]) {
+			for _, j := range view.edges() {
+				if key := runningKey(view.texts[j]); key != "" && !seen[key] {
+					seen[key] = true
+					counts[key]++
+				}
+			}
+		}
+	}
+	running := map[string]bool{}
+	for key, count := range counts {
+		if count >= RunningLineMinPages && count*2 >= candidates {
+			running[key] = true
+		}
+	}
+	if len(running) == 0 {
+		return 0
+	}
+	removed := 0
+	for i := range pages {
+		if !breakablePage(pages[i]) {
+			continue
+		}
+		for k, view := range lineViews(&pages[i]) {
+			for _, j := range view.edges() {
+				if running[runningKey(view.texts[j])] {
+					view.texts[j] = ""
+					if k == 0 {
+						removed++
+					}
+				}
+			}
+			view.save()
+		}
+	}
+	return removed
+}
+
+// joinSentence moves the start of the first sentence of next to the end of prev when a page
+// break split it, in every view of the pages, and reports whether it did.
+func (c *PDFConverter) joinSentence(prev, next *PDFPage) bool {
+	prevViews, nextViews := lineViews(prev), lineViews(next)
+	if len(prevViews) != len(nextViews) {
+		return false
+	}
+	type join struct{ last, first, end int }
+	joins := make([]join, len(prevViews))
+	for k := range prevViews {
+		last := lastLine(prevViews[k])
+		first, end := sentenceStart(nextViews[k])
+		if last < 0 || first < 0 || !continuesSentence(prevViews[k].texts[last]) || c.looksLikeHeader(prevViews[k].texts[last]) {
+			return false
+		}
+		joins[k] = join{last, first, end}
+	}
+	for k, j := range joins {
+		fragment := prevViews[k].texts[j.last]
+		for i := j.first; i < j.end; i++ {
+			fragment = joinLines(fragment, nextViews[k].texts[i])
+			nextViews[k].texts[i] = ""
+		}
+		prevViews[k].texts[j.last] = fragment
+		prevViews[k].save()
+		nextViews[k].save()
+	}
+	return true
+}
+
+// lastLine returns the index of the last non-empty line of a view, or -1 when there is none
+// or it belongs to a table.
+func lastLine(v pageLines) int {
+	for i := len(v.texts) - 1; i >= 0; i-- {
+		if strings.TrimSpace(v.texts[i]) != "" {
+			if v.fixed[i] {
+				return -1
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// sentenceStart returns the line range at the start of a view that finishes a sentence begun
+// on the previous page: from the first non-empty line, which starts in lower case, to the
+// first line ending a sentence, within PageBreakMaxLines. It returns -1, -1 otherwise.
+func sentenceStart(v pageLines) (int, int) {
+	first := -1
+	for i, text := range v.texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if v.fixed[i] {
+			return -1, -1
+		}
+		if first < 0 {
+			if r, _ := utf8.DecodeRuneInString(text); !unicode.IsLower(r) {
+				return -1, -1
+			}
+			first = i
+		}
+		if i-first >= PageBreakMaxLines {
+			return -1, -1
+		}
+		if endsSentence(text) {
+			return first, i + 1
+		}
+	}
+	return -1, -1
+}
+
+// continuesSentence reports whether a line ends inside a sentence: with a letter, digit,
+// comma, hyphen or opening parenthesis.
+func continuesSentence(line string) bool {
+	r, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(line))
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(",-(", r)
+}
+
+// endsSentence reports whether a line ends a sentence, allowing closing quotes and
+// parentheses after the punctuation.
+func endsSentence(line string) bool {
+	line = strings.TrimRight(strings.TrimSpace(line), `)"'”’`)
+	r, _ := utf8.DecodeLastRuneInString(line)
+	return strings.ContainsRune(".!?:", r)
+}
+
+// joinLines joins a line to the next one, without a space after a hyphen that breaks a word.
+func joinLines(line, next string) string {
+	line, next = strings.TrimRight(line, " \t"), strings.TrimSpace(next)
+	if strings.HasSuffix(line, "-") {
+		return line + next
+	}
+	return line + " " + next
+}
@@ -0,0 +1,114 @@
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestJoinPageBreaks(t *testing.T) {
+	pages := []PDFPage{
+		{Number: 1, Text: "XY123 Datasheet\nThe device starts when the enable pin is held high for at least\n1"},
+		{Number: 2, Text: "XY123 Datasheet\n10 ms after power-up.\nOperating Conditions\nThe supply must stay within the recommended low-\n2"},
+		{Number: 3, Text: "XY123 Datasheet\nnoise range during start-\nup and operation.\nThe rest of the page.\n3"},
+		{Number: 4, Text: "XY123 Datasheet\nthe\n4", Verbatim: true},
+	}
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, JoinPageBreaks: true}, logger.NewLogger("error"))
+	conv.joinPageBreaks(pages)
+
+	var got []string
+	for _, page := range pages {
+		got = append(got, strings.Join(strings.Fields(strings.ReplaceAll(page.Text, "\n", " | ")), " "))
+	}
+	want := []string{
+		// "10 ms" starts with a digit, so it is not taken as the rest of the sentence
+		"| The device starts when the enable pin is held high for at least |",
+		"| 10 ms after power-up. | Operating Conditions | The supply must stay within the recommended low-noise range during start-up and operation. |",
+		"| | | The rest of the page. |",
+		"XY123 Datasheet | the | 4",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("page %d = %q, want %q", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestJoinPageBreaks_Lines(t *testing.T) {
+	lines := func(texts ...string) []TextLine {
+		var out []TextLine
+		for i, text := range texts {
+			out = append(out, TextLine{Y: float64(800 - 20*i), Text: text})
+		}
+		return out
+	}
+	pages := []PDFPage{
+		{Number: 1, Text: "Header\nThe table below lists the\nA B\n1 2", Lines: lines("Header", "The table below lists the", "A B", "1 2"), Tables: []PDFTable{{FirstLine: 2, LastLine: 3, CaptionLine: -1}}},
+		{Number: 2, Text: "Header\npin functions.", Lines: lines("Header", "pin functions.")},
+		{Number: 3, Text: "Header\nSee the table", Lines: lines("Header", "See the table")},
+		{Number: 4, Text: "Header\nbelow.", Lines: lines("Header", "below.")},
+	}
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, JoinPageBreaks: true}, logger.NewLogger("error"))
+	conv.joinPageBreaks(pages)
+	if pages[0].Lines[0].Text != "" || pages[1].Lines[1].Text != "pin functions." {
+		t.Errorf("expected the header removed and no join after a table, got %+v %+v", pages[0].Lines, pages[1].Lines)
+	}
+	if pages[2].Lines[1].Text != "See the table below." || pages[3].Lines[1].Text != "" || pages[2].Text != "\nSee the table below." {
+		t.Errorf("expected the sentence joined in the text and the lines, got %+v %q", pages[2].Lines, pages[2].Text)
+	}
+}
+
+func TestConvertPDF_PageBreaks(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "breaks.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 11)
+	doc.SetAutoPageBreak(false, 0)
+	body := [][]string{
+		{"The regulator output is enabled when the EN pin is driven"},
+		{"above the logic threshold for longer than the debounce time.", "Thermal Shutdown"},
+		{"Other details."},
+	}
+	for i, lines := range body {
+		doc.AddPage()
+		doc.Cell(100, 6, "XY123 Step-Down Regulator")
+		doc.Ln(12)
+		for _, line := range lines {
+			doc.Cell(150, 6, line)
+			doc.Ln(8)
+		}
+		doc.SetY(280)
+		doc.Cell(30, 6, fmt.Sprintf("Page %d of 3", i+1))
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, JoinPageBreaks: true}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "driven above the logic threshold for longer than the debounce time.") {
+		t.Errorf("expected the sentence joined across the page break, got:\n%s", md)
+	}
+	if strings.Contains(string(md), "Step-Down Regulator") || strings.Contains(string(md), "of 3") {
+		t.Errorf("expected the running header and footer removed, got:\n%s", md)
+	}
+
+	plain, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err = plain.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if md, _ := os.ReadFile(res.MarkdownFile); strings.Count(string(md), "Step-Down Regulator") != 3 {
+		t.Errorf("expected the pages unchanged without JOIN_PAGE_BREAKS, got:\n%s", md)
+	}
+}
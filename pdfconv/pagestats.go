@@ -0,0 +1,111 @@
+// Package pdfconv - Page statistics.
+// This file summarizes the converted content of each page and lists the image files written
+// next to the document, so callers such as MCP clients can inspect a conversion page by page
+// without parsing the generated Markdown. With diagram detection the image list is also
+// written to images.json, including the diagram types DETECT_DIAGRAM_TYPES leaves out.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImagesFileName is the image list written next to the Markdown when DETECT_DIAGRAMS is on.
+const ImagesFileName = "images.json"
+
+// PageStats are the content counts of one converted page.
+type PageStats struct {
+	Page          int      `json:"page"`
+	Words         int      `json:"words"`
+	Tables        int      `json:"tables"` // Tables, not counting continuations merged into the table they continue
+	Images        int      `json:"images"`
+	Diagrams      int      `json:"diagrams"`                 // Diagrams written for the images of the page
+	OCR           bool     `json:"ocr,omitempty"`            // Whether the text was recognized from a page image
+	OCRConfidence *float64 `json:"ocr_confidence,omitempty"` // Mean OCR word confidence of an OCR page
+	Unreliable    bool     `json:"unreliable,omitempty"`     // Whether the text looks garbled
+	Reused        bool     `json:"reused,omitempty"`         // Whether the page was reused from the previous output
+	Failure       string   `json:"failure,omitempty"`        // Why the page content could not be extracted
+}
+
+// ImageFile is an image file written next to the converted document.
+type ImageFile struct {
+	File             string         `json:"file"` // File name relative to the output directory
+	Page             int            `json:"page"` // Page the image first appears on
+	Width            int            `json:"width"`
+	Height           int            `json:"height"`
+	Diagrams         int            `json:"diagrams,omitempty"`          // Diagrams written for the image
+	DetectedDiagrams []ImageDiagram `json:"detected_diagrams,omitempty"` // Every diagram detected in the image
+	Placeholder      bool           `json:"placeholder,omitempty"`       // Whether the image data could not be decoded
+}
+
+// ImageDiagram is a diagram detected in an image.
+type ImageDiagram struct {
+	Type       string  `json:"type"`
+	Confidence float64 `json:"confidence"`
+	Written    bool    `json:"written"` // False when DETECT_DIAGRAM_TYPES does not select the type
+}
+
+// imagesFile is the content of images.json.
+type imagesFile struct {
+	Source string      `json:"source"`
+	Images []ImageFile `json:"images"`
+}
+
+// pageStatistics returns the content counts of each page, in page order.
+func pageStatistics(pages []PDFPage) []PageStats {
+	stats := make([]PageStats, 0, len(pages))
+	for _, page := range pages {
+		s := PageStats{
+			Page:       page.Number,
+			Words:      len(strings.Fields(page.Text)),
+			Images:     len(page.Images),
+			OCR:        page.OCR,
+			Unreliable: page.Unreliable,
+			Reused:     page.Reused,
+			Failure:    page.Failure,
+		}
+		s.Tables, s.Diagrams = pageContentCounts([]PDFPage{page})
+		if page.OCR {
+			confidence := page.OCRConfidence
+			s.OCRConfidence = &confidence
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// imageFiles lists the image files of the pages in page order. Identical images share one
+// file, which is listed once on the page it first appears on.
+func imageFiles(pages []PDFPage) []ImageFile {
+	var files []ImageFile
+	seen := map[string]bool{}
+	for _, page := range pages {
+		for _, img := range page.Images {
+			if img.Filename == "" || seen[img.Filename] {
+				continue
+			}
+			seen[img.Filename] = true
+			file := ImageFile{File: img.Filename, Page: page.Number, Width: img.Width, Height: img.Height, Diagrams: writtenDiagrams(img.Diagrams), Placeholder: img.Placeholder}
+			for _, diagram := range img.Diagrams {
+				file.DetectedDiagrams = append(file.DetectedDiagrams, ImageDiagram{Type: diagram.Type.String(), Confidence: diagram.Confidence, Written: !diagram.Omitted})
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// writeImagesFile writes images.json for a document into dir.
+func writeImagesFile(dir, docPath string, images []ImageFile) error {
+	data, err := json.MarshalIndent(imagesFile{Source: docPath, Images: images}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode image list: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ImagesFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write image list: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestConvertPDF_ImagesFileWithUnselectedDiagramTypes(t *testing.T) {
+	pdfPath := createTempPDFWithRawImage(t)
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageFormat: "png", ImageMaxDPI: 300, DetectDiagrams: true, DiagramConfidence: 0.5, DiagramTypes: []string{"flowchart"}}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	result, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if result.DiagramCount != 0 {
+		t.Errorf("expected no diagrams written, got %d", result.DiagramCount)
+	}
+	markdown, _ := os.ReadFile(result.MarkdownFile)
+	if strings.Contains(string(markdown), "Detected Block Diagram") {
+		t.Errorf("expected the block diagram left out of the Markdown:\n%s", markdown)
+	}
+
+	data, err := os.ReadFile(filepath.Join(result.OutputDir, ImagesFileName))
+	if err != nil {
+		t.Fatalf("expected %s: %v", ImagesFileName, err)
+	}
+	var images imagesFile
+	if err := json.Unmarshal(data, &images); err != nil {
+		t.Fatalf("invalid %s: %v", ImagesFileName, err)
+	}
+	if len(images.Images) != 1 || images.Images[0].Diagrams != 0 {
+		t.Fatalf("expected one image without written diagrams, got %+v", images.Images)
+	}
+	if want := []ImageDiagram{{Type: "block", Confidence: 0.6}}; !reflect.DeepEqual(images.Images[0].DetectedDiagrams, want) {
+		t.Errorf("expected the unselected block diagram reported, got %+v", images.Images[0].DetectedDiagrams)
+	}
+	if violations, err := ValidateSidecar(ImagesFileName, data); err != nil || len(violations) != 0 {
+		t.Errorf("expected %s to match its schema, got %v %v", ImagesFileName, err, violations)
+	}
+
+	// With the type selected the diagram is written and reported as written
+	cfg.DiagramTypes = []string{"flowchart", "block"}
+	result, err = conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if result.DiagramCount != 1 || len(result.Images) != 1 || !result.Images[0].DetectedDiagrams[0].Written {
+		t.Errorf("expected the block diagram written, got %d %+v", result.DiagramCount, result.Images)
+	}
+}
+
+func TestConvertPDF_DiagramImageFilters(t *testing.T) {
+	pdfPath := createTempPDFWithRawImage(t)
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ImageFormat: "png", ImageMaxDPI: 300, DetectDiagrams: true, DiagramConfidence: 0.5, DiagramMinSize: 32}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	result, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if len(result.Images) != 1 || len(result.Images[0].DetectedDiagrams) != 0 {
+		t.Errorf("expected the 16x16 image skipped by DIAGRAM_MIN_SIZE, got %+v", result.Images)
+	}
+
+	// The diagonal line is too sparse for a high edge density threshold
+	cfg.DiagramMinSize, cfg.DiagramMinEdgeDensity = 16, 0.5
+	result, err = conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if len(result.Images) != 1 || len(result.Images[0].DetectedDiagrams) != 0 {
+		t.Errorf("expected the image skipped by DIAGRAM_MIN_EDGE_DENSITY, got %+v", result.Images)
+	}
+
+	cfg.DiagramMinEdgeDensity = 0.01
+	result, err = conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if result.DiagramCount != 1 {
+		t.Errorf("expected the image analyzed within both limits, got %d diagrams", result.DiagramCount)
+	}
+}
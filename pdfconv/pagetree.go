@@ -0,0 +1,105 @@
+// Package pdfconv - Lazy page loading.
+// This file walks the PDF page tree with a cursor that loads page objects on demand. The
+// PDF library's Reader.Page walks the tree from the root for every page, loading every
+// page object before the requested one, so converting a range near the end of a long
+// manual, or every page in turn, parsed the page objects of the whole document many times.
+// The cursor moves forward only: subtrees whose /Count ends before the requested page are
+// skipped without loading their pages, and nothing after the last requested page is read.
+package pdfconv
+
+import (
+	"fmt"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// MaxPageTreeDepth bounds the nesting of page tree nodes, guarding against cyclic trees.
+const MaxPageTreeDepth = 64
+
+// pageTree is a forward cursor over the pages of a PDF in document order.
+type pageTree struct {
+	reader *pdf.Reader
+	stack  []pageTreeFrame // Path from the root to the next unvisited kid
+	next   int             // Number of the page the cursor is at
+}
+
+// pageTreeFrame is an intermediate (/Pages) node and the index of its next kid.
+type pageTreeFrame struct {
+	kids  pdf.Value
+	index int
+}
+
+// newPageTree returns a cursor at the first page of the document.
+func newPageTree(reader *pdf.Reader) *pageTree {
+	t := &pageTree{reader: reader}
+	t.rewind()
+	return t
+}
+
+// rewind moves the cursor back to the first page.
+func (t *pageTree) rewind() {
+	t.stack, t.next = nil, 1
+	defer func() {
+		if recover() != nil {
+			t.stack = nil
+		}
+	}()
+	if root := t.reader.Trailer().Key("Root").Key("Pages"); root.Key("Type").Name() == "Pages" {
+		t.stack = []pageTreeFrame{{kids: root.Key("Kids")}}
+	}
+}
+
+// page returns the 1-based page num. Pages after the cursor are found without loading the
+// subtrees in between; asking for an earlier page rewinds to the start. A page beyond the
+// end of the tree is returned as a null page, like Reader.Page. A page tree node that
+// cannot be parsed counts as one page, which is returned as an error when it is num.
+func (t *pageTree) page(num int) (pdf.Page, error) {
+	if num < t.next {
+		t.rewind()
+	}
+	for len(t.stack) > 0 {
+		top := &t.stack[len(t.stack)-1]
+		if top.index >= top.kids.Len() {
+			t.stack = t.stack[:len(t.stack)-1]
+			continue
+		}
+		kid, kind, count, err := pageTreeKid(top.kids, top.index)
+		top.index++
+		switch {
+		case err != nil:
+			t.next++
+			if t.next-1 == num {
+				return pdf.Page{}, err
+			}
+		case kind == "Pages":
+			// Skip subtrees that end before num; an unknown count has to be walked
+			if count > 0 && t.next+count <= num {
+				t.next += count
+			} else if len(t.stack) < MaxPageTreeDepth {
+				t.stack = append(t.stack, pageTreeFrame{kids: kid.Key("Kids")})
+			}
+		case kind == "Page":
+			t.next++
+			if t.next-1 == num {
+				return pdf.Page{V: kid}, nil
+			}
+		}
+	}
+	return pdf.Page{}, nil
+}
+
+// pageTreeKid loads kid i of a page tree node with its type and, for intermediate nodes,
+// its page count, converting panics in the PDF library into errors.
+func pageTreeKid(kids pdf.Value, i int) (kid pdf.Value, kind string, count int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	kid = kids.Index(i)
+	kind = kid.Key("Type").Name()
+	if kind == "Pages" {
+		count = int(kid.Key("Count").Int64())
+	}
+	return kid, kind, count, nil
+}
@@ -0,0 +1,94 @@
+package pdfconv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// countingReaderAt counts the reads made while loading PDF objects.
+type countingReaderAt struct {
+	*bytes.Reader
+	reads int
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.reads++
+	return r.Reader.ReadAt(p, off)
+}
+
+// buildChapteredPDF writes a PDF whose page tree has one intermediate node per chapter.
+func buildChapteredPDF(chapters, pagesPerChapter int) []byte {
+	var chapterRefs []string
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	for c := 0; c < chapters; c++ {
+		chapterNum := len(objects) + 1
+		chapterRefs = append(chapterRefs, fmt.Sprintf("%d 0 R", chapterNum))
+		var pageRefs []string
+		for p := 0; p < pagesPerChapter; p++ {
+			pageRefs = append(pageRefs, fmt.Sprintf("%d 0 R", chapterNum+1+p))
+		}
+		objects = append(objects, fmt.Sprintf("<< /Type /Pages /Parent 2 0 R /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), pagesPerChapter))
+		for p := 0; p < pagesPerChapter; p++ {
+			objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] >>", chapterNum))
+		}
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(chapterRefs, " "), chapters*pagesPerChapter)
+	return buildPDF(objects)
+}
+
+func TestPageTree_MatchesReaderPage(t *testing.T) {
+	data := buildChapteredPDF(4, 3)
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open pdf: %v", err)
+	}
+	tree := newPageTree(reader)
+	// Forward, skipping ahead, rewinding and past the end
+	for _, n := range []int{1, 2, 3, 4, 8, 12, 13, 5, 6, 11, 1} {
+		got, err := tree.page(n)
+		if err != nil {
+			t.Fatalf("page %d: %v", n, err)
+		}
+		if want := reader.Page(n); got.V.String() != want.V.String() {
+			t.Errorf("page %d = %s, want %s", n, got.V, want.V)
+		}
+	}
+}
+
+func TestPageTree_LoadsOnlyRequestedRange(t *testing.T) {
+	data := buildChapteredPDF(20, 50)
+	load := func(get func(reader *pdf.Reader, n int) pdf.Page) int {
+		src := &countingReaderAt{Reader: bytes.NewReader(data)}
+		reader, err := pdf.NewReader(src, int64(len(data)))
+		if err != nil {
+			t.Fatalf("failed to open pdf: %v", err)
+		}
+		src.reads = 0
+		for n := 981; n <= 1000; n++ {
+			if get(reader, n).V.IsNull() {
+				t.Fatalf("page %d not found", n)
+			}
+		}
+		return src.reads
+	}
+
+	var tree *pageTree
+	lazy := load(func(reader *pdf.Reader, n int) pdf.Page {
+		if tree == nil {
+			tree = newPageTree(reader)
+		}
+		p, err := tree.page(n)
+		if err != nil {
+			t.Fatalf("page %d: %v", n, err)
+		}
+		return p
+	})
+	eager := load(func(reader *pdf.Reader, n int) pdf.Page { return reader.Page(n) })
+	if lazy*5 > eager {
+		t.Errorf("page tree made %d reads for pages 981-1000, Reader.Page %d", lazy, eager)
+	}
+}
@@ -0,0 +1,94 @@
+// Package pdfconv - Partial conversions.
+// This file records pages whose content could not be extracted, so a document with a few
+// broken pages is still converted and the result says which pages are missing and why,
+// instead of the conversion failing as a whole or the gaps going unnoticed. In STRICT_MODE
+// any failed page, image or table fails the conversion instead, for pipelines that must not
+// publish incomplete documents.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Conversion status values
+const (
+	StatusComplete = "complete" // Every page was converted
+	StatusPartial  = "partial"  // Some pages failed; the output holds the other pages
+)
+
+// PageFailure is a page whose content could not be extracted.
+type PageFailure struct {
+	Page   int    `json:"page"`
+	Reason string `json:"reason"`
+}
+
+// pageFailures returns the failed pages, in page order.
+func pageFailures(pages []PDFPage) []PageFailure {
+	var failures []PageFailure
+	for _, page := range pages {
+		if page.Failure != "" {
+			failures = append(failures, PageFailure{Page: page.Number, Reason: page.Failure})
+		}
+	}
+	return failures
+}
+
+// conversionStatus returns the status of a conversion with the given failed pages.
+func conversionStatus(failures []PageFailure) string {
+	if len(failures) > 0 {
+		return StatusPartial
+	}
+	return StatusComplete
+}
+
+// checkStrict returns an error listing the failed pages, images and tables when STRICT_MODE
+// is set and anything could not be converted, and nil otherwise.
+func (c *PDFConverter) checkStrict(pages []PDFPage) error {
+	if !c.config.StrictMode {
+		return nil
+	}
+	var problems []string
+	for _, page := range pages {
+		if page.Failure != "" {
+			problems = append(problems, fmt.Sprintf("page %d: %s", page.Number, page.Failure))
+		}
+		images := page.ImageFailures
+		for _, img := range page.Images {
+			if img.Placeholder {
+				images++
+			}
+		}
+		if images > 0 {
+			problems = append(problems, fmt.Sprintf("page %d: %d image(s) could not be extracted", page.Number, images))
+		}
+		tables := 0
+		for _, table := range page.Tables {
+			if table.Fallback && !table.Merged {
+				tables++
+			}
+		}
+		if tables > 0 {
+			problems = append(problems, fmt.Sprintf("page %d: %d table(s) could not be reconstructed", page.Number, tables))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return classify(ErrIncomplete, fmt.Errorf("strict mode: conversion incomplete: %s", strings.Join(problems, "; ")))
+}
+
+// safeExtractPage extracts a page like extractPage, turning a panic while reading the page
+// into a failed page so the other pages are still converted.
+func (c *PDFConverter) safeExtractPage(ctx context.Context, p pdf.Page, pdfPath string, pageNum int, outputDir string, timings *PhaseTimings) (page PDFPage) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Warn("Failed to extract page %d: %v", pageNum, r)
+			page = PDFPage{Number: pageNum, Images: []PDFImage{}, Failure: fmt.Sprintf("page content could not be read: %v", r)}
+		}
+	}()
+	return c.extractPage(ctx, p, pdfPath, pageNum, outputDir, timings)
+}
@@ -0,0 +1,105 @@
+package pdfconv
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// writePartialPDF writes a three page PDF whose second page cannot be read.
+func writePartialPDF(t *testing.T) string {
+	t.Helper()
+	pdfPath := filepath.Join(t.TempDir(), "partial.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	for _, text := range []string{"First page", "Second page", "Third page"} {
+		doc.AddPage()
+		doc.Cell(40, 10, text)
+	}
+	var buf bytes.Buffer
+	if err := doc.Output(&buf); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+	// Give the content stream of page 2 a filter the reader cannot decode, keeping offsets
+	data := buf.Bytes()
+	first := bytes.Index(data, []byte("/FlateDecode"))
+	second := first + 1 + bytes.Index(data[first+1:], []byte("/FlateDecode"))
+	copy(data[second:], "/BrokenCodec")
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+	return pdfPath
+}
+
+func TestConvertPDF_PartialSuccess(t *testing.T) {
+	pdfPath := writePartialPDF(t)
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("expected a partial conversion, got error: %v", err)
+	}
+	if res.Status != StatusPartial || len(res.FailedPages) != 1 || res.FailedPages[0].Page != 2 || !strings.Contains(res.FailedPages[0].Reason, "BrokenCodec") {
+		t.Fatalf("expected page 2 reported as failed, got %s %+v", res.Status, res.FailedPages)
+	}
+	if res.PageCount != 3 {
+		t.Errorf("expected 3 pages, got %d", res.PageCount)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "First page") || !strings.Contains(string(md), "Third page") {
+		t.Errorf("expected the other pages converted, got:\n%s", md)
+	}
+	if warnings := res.Warnings(); len(warnings) == 0 || warnings[0] != "1 page(s) failed" {
+		t.Errorf("expected a failed page warning, got %v", warnings)
+	}
+	report, _ := os.ReadFile(filepath.Join(res.OutputDir, ReportFileName))
+	if !strings.Contains(string(report), `"status": "partial"`) || !strings.Contains(string(report), `"failed_pages"`) {
+		t.Errorf("expected the status in the conversion report, got:\n%s", report)
+	}
+	if violations, err := ValidateSidecar(ReportFileName, report); err != nil || len(violations) > 0 {
+		t.Errorf("expected a valid conversion report, got %v %v", err, violations)
+	}
+
+	complete, err := conv.ConvertPDF(createTempValidPDF(t), t.TempDir())
+	if err != nil || complete.Status != StatusComplete || len(complete.FailedPages) != 0 {
+		t.Errorf("expected a complete conversion, got %v %+v", err, complete)
+	}
+}
+
+func TestConvertPDF_StrictMode(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, StrictMode: true}, logger.NewLogger("error"))
+	outputBase := t.TempDir()
+	_, err := conv.ConvertPDF(writePartialPDF(t), outputBase)
+	if !errors.Is(err, ErrIncomplete) || !strings.Contains(err.Error(), "page 2: text extraction failed") {
+		t.Fatalf("expected strict mode to fail the conversion, got %v", err)
+	}
+	if entries, _ := os.ReadDir(outputBase); len(entries) != 0 {
+		t.Errorf("expected no output written, got %d entries", len(entries))
+	}
+	if _, err := conv.ConvertPDF(createTempValidPDF(t), t.TempDir()); err != nil {
+		t.Errorf("expected a complete document to convert in strict mode, got %v", err)
+	}
+
+	pages := []PDFPage{
+		{Number: 1, ImageFailures: 1},
+		{Number: 2, Images: []PDFImage{{Placeholder: true}}},
+		{Number: 3, Tables: []PDFTable{{Fallback: true}, {Fallback: true, Merged: true}}},
+	}
+	err = conv.checkStrict(pages)
+	for _, want := range []string{"page 1: 1 image(s)", "page 2: 1 image(s)", "page 3: 1 table(s)"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+	lenient, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	if err := lenient.checkStrict(pages); err != nil {
+		t.Errorf("expected failures to be warnings in lenient mode, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+// Package pdfconv - PDF portfolios.
+// This file detects PDF portfolios (collections), which wrap several documents such as a
+// datasheet, its errata and application notes, and converts each embedded document separately.
+package pdfconv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// MaxEmbeddedFileSize is the largest embedded document extracted from a portfolio.
+const MaxEmbeddedFileSize = 1 << 30
+
+// maxNameTreeDepth bounds the recursion into the embedded files name tree.
+const maxNameTreeDepth = 32
+
+// embeddedFile is a document attached to a PDF.
+type embeddedFile struct {
+	Name   string
+	Stream pdf.Value
+}
+
+// IsPortfolio reports whether the file is a PDF portfolio: a PDF whose catalog has a
+// /Collection dictionary and which embeds at least one supported document.
+func (c *PDFConverter) IsPortfolio(pdfPath string) (portfolio bool) {
+	if documentFormats[strings.ToLower(filepath.Ext(pdfPath))] != "pdf" {
+		return false
+	}
+	file, reader, err := safeOpenPDF(pdfPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	defer func() {
+		if recover() != nil {
+			portfolio = false
+		}
+	}()
+	if reader.Trailer().Key("Root").Key("Collection").Kind() != pdf.Dict {
+		return false
+	}
+	files, err := embeddedDocuments(reader)
+	return err == nil && len(files) > 0
+}
+
+// ConvertPortfolio converts each supported document embedded in a PDF portfolio into its own
+// MARKDOWN_<name> directory below outputBaseDir/MARKDOWN_<portfolio>, and reports the
+// conversions as a batch. Embedded files that are not PDF, XPS or DjVu documents are skipped.
+func (c *PDFConverter) ConvertPortfolio(pdfPath, outputBaseDir string, opts ConversionOptions) (*BatchConversionResult, error) {
+	c.logger.Info("Starting PDF portfolio conversion: %s", pdfPath)
+	if opts.batch.IsZero() {
+		opts.batch = time.Now()
+	}
+
+	pdfPath, outputBaseDir, err := cleanInputPaths(pdfPath, outputBaseDir)
+	if err != nil {
+		return nil, err
+	}
+	reader, closeFile, _, err := c.openPDF(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer closeFile()
+
+	files, err := embeddedDocuments(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read portfolio: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("PDF portfolio contains no PDF, XPS or DjVu documents: %s", pdfPath)
+	}
+	c.logger.Info("Found %d embedded documents in portfolio", len(files))
+
+	extractDir, err := c.makeTempDir("portfolio")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extraction directory: %v", err)
+	}
+	defer os.RemoveAll(extractDir)
+
+	portfolioDir := filepath.Join(outputBaseDir, outputDirectoryName(pdfPath))
+	result := &BatchConversionResult{
+		InputDir:      pdfPath,
+		OutputBaseDir: portfolioDir,
+		Portfolio:     true,
+		Results:       make([]ConversionResult, 0, len(files)),
+		Errors:        make([]ConversionError, 0),
+		FileCount:     len(files),
+	}
+	used := make(map[string]bool)
+	for i, file := range files {
+		name := uniqueFileName(file.Name, used)
+		c.logger.Info("Processing embedded document (%d/%d): %s", i+1, len(files), name)
+		docPath := filepath.Join(extractDir, name)
+		if err := extractEmbeddedFile(file.Stream, docPath); err != nil {
+			c.logger.Error("Failed to extract embedded document %s: %v", name, err)
+			result.FailureCount++
+			result.Errors = append(result.Errors, newConversionError(name, err))
+			continue
+		}
+		conversionResult, err := c.ConvertDocument(docPath, portfolioDir, opts)
+		if err != nil {
+			c.logger.Error("Failed to convert embedded document %s: %v", name, err)
+			result.FailureCount++
+			result.Errors = append(result.Errors, newConversionError(name, err))
+			continue
+		}
+		result.SuccessCount++
+		result.Results = append(result.Results, *conversionResult)
+		result.TotalPageCount += conversionResult.PageCount
+		result.TotalImageCount += conversionResult.ImageCount
+	}
+	result.ErrataLinks = c.linkErrata(result.Results)
+	c.logger.Info("Portfolio conversion completed: %d successful, %d failed", result.SuccessCount, result.FailureCount)
+	return result, nil
+}
+
+// embeddedDocuments lists the supported documents in the catalog's EmbeddedFiles name tree.
+func embeddedDocuments(reader *pdf.Reader) (files []embeddedFile, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed embedded files: %v", r)
+		}
+	}()
+	tree := reader.Trailer().Key("Root").Key("Names").Key("EmbeddedFiles")
+	collectEmbeddedFiles(tree, 0, &files)
+	return files, nil
+}
+
+// collectEmbeddedFiles walks a name tree node, appending the supported documents it names.
+func collectEmbeddedFiles(node pdf.Value, depth int, files *[]embeddedFile) {
+	if node.Kind() != pdf.Dict || depth > maxNameTreeDepth {
+		return
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		spec := names.Index(i + 1)
+		name := spec.Key("UF").Text()
+		if name == "" {
+			name = spec.Key("F").Text()
+		}
+		if name == "" {
+			name = names.Index(i).Text()
+		}
+		stream := spec.Key("EF").Key("UF")
+		if stream.Kind() != pdf.Stream {
+			stream = spec.Key("EF").Key("F")
+		}
+		if stream.Kind() == pdf.Stream && IsSupportedDocument(name) {
+			*files = append(*files, embeddedFile{Name: name, Stream: stream})
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		collectEmbeddedFiles(kids.Index(i), depth+1, files)
+	}
+}
+
+// extractEmbeddedFile writes the decoded content of an embedded file stream to path.
+func extractEmbeddedFile(stream pdf.Value, path string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to decode embedded file: %v", r)
+		}
+	}()
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer out.Close()
+	rc := stream.Reader()
+	defer rc.Close()
+	n, err := io.Copy(out, io.LimitReader(rc, MaxEmbeddedFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to decode embedded file: %v", err)
+	}
+	if n > MaxEmbeddedFileSize {
+		return fmt.Errorf("embedded file exceeds %d bytes", int64(MaxEmbeddedFileSize))
+	}
+	return nil
+}
+
+// uniqueFileName reduces an embedded file name to a safe base name that has not been used yet.
+func uniqueFileName(name string, used map[string]bool) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || strings.HasPrefix(name, ".") {
+		name = "document" + filepath.Ext(name)
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s_%d%s", stem, i, ext)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,110 @@
+package pdfconv
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// buildPDF writes the objects, numbered from 1 with object 1 as the catalog, into a PDF
+// with a classic cross-reference table.
+func buildPDF(objects []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func embeddedStream(data []byte) string {
+	return fmt.Sprintf("<< /Type /EmbeddedFile /Length %d >>\nstream\n%s\nendstream", len(data), data)
+}
+
+func TestConvertPortfolio(t *testing.T) {
+	datasheet, err := os.ReadFile(createTempValidPDF(t))
+	if err != nil {
+		t.Fatalf("failed to read test pdf: %v", err)
+	}
+	portfolio := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Collection << /View /D >> /Names << /EmbeddedFiles << /Names [(a) 4 0 R (b) 6 0 R (c) 8 0 R (d) 10 0 R] >> >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Filespec /F (datasheet.pdf) /EF << /F 5 0 R >> >>",
+		embeddedStream(datasheet),
+		"<< /Type /Filespec /F (errata.pdf) /UF (../errata.pdf) /EF << /F 7 0 R >> >>",
+		embeddedStream(datasheet),
+		"<< /Type /Filespec /F (notes.txt) /EF << /F 9 0 R >> >>",
+		embeddedStream([]byte("plain text attachment")),
+		"<< /Type /Filespec /F (broken.pdf) /EF << /F 11 0 R >> >>",
+		embeddedStream([]byte("not a pdf")),
+	})
+	dir := t.TempDir()
+	pdfPath := filepath.Join(dir, "kit.pdf")
+	if err := os.WriteFile(pdfPath, portfolio, 0644); err != nil {
+		t.Fatalf("failed to write portfolio: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	if !conv.IsPortfolio(pdfPath) {
+		t.Fatalf("expected portfolio to be detected")
+	}
+	if conv.IsPortfolio(createTempValidPDF(t)) {
+		t.Errorf("expected plain PDF not to be a portfolio")
+	}
+
+	outDir := t.TempDir()
+	res, err := conv.ConvertPortfolio(pdfPath, outDir, ConversionOptions{})
+	if err != nil {
+		t.Fatalf("ConvertPortfolio() error = %v", err)
+	}
+	if !res.Portfolio || res.FileCount != 3 || res.SuccessCount != 2 || res.FailureCount != 1 {
+		t.Fatalf("unexpected batch result: %+v", res)
+	}
+	if res.Errors[0].PDFPath != "broken.pdf" {
+		t.Errorf("expected broken.pdf to fail, got %+v", res.Errors)
+	}
+	for _, name := range []string{"MARKDOWN_datasheet", "MARKDOWN_errata"} {
+		md, err := os.ReadFile(filepath.Join(outDir, "MARKDOWN_kit", name, "README.md"))
+		if err != nil || !strings.Contains(string(md), "Hello, PDF") {
+			t.Errorf("expected converted %s: %v", name, err)
+		}
+	}
+
+	// Directory conversion expands the portfolio into its documents
+	batch, err := conv.ConvertPDFsInDirectory(dir, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDFsInDirectory() error = %v", err)
+	}
+	if batch.FileCount != 3 || batch.SuccessCount != 2 || batch.FailureCount != 1 {
+		t.Errorf("unexpected directory batch result: %+v", batch)
+	}
+}
+
+func TestUniqueFileName(t *testing.T) {
+	used := make(map[string]bool)
+	for _, tc := range []struct{ in, want string }{
+		{"datasheet.pdf", "datasheet.pdf"},
+		{"docs\\Datasheet.pdf", "Datasheet_2.pdf"},
+		{"../../etc/datasheet.pdf", "datasheet_3.pdf"},
+		{".pdf", "document.pdf"},
+	} {
+		if got := uniqueFileName(tc.in, used); got != tc.want {
+			t.Errorf("uniqueFileName(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
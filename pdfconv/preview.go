@@ -0,0 +1,96 @@
+// Package pdfconv - Text previews.
+// This file renders the Markdown of a PDF, or of a selection of its pages, without writing
+// any output, for a quick look at a document before converting it. Images are not
+// extracted, and the pages are processed in a temporary directory that is removed again.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultPreviewMaxChars is the length of a preview when no limit is given.
+const DefaultPreviewMaxChars = 20000
+
+// Preview is the Markdown of a document rendered without writing output.
+type Preview struct {
+	Source    string `json:"source"`
+	Pages     string `json:"pages,omitempty"` // Selected pages, "" for the whole document
+	PageCount int    `json:"page_count"`      // Pages in the document
+	Previewed int    `json:"previewed_pages"` // Pages rendered into the preview
+	Markdown  string `json:"markdown"`
+	Truncated bool   `json:"truncated"` // The Markdown was cut at maxChars
+}
+
+// PreviewPDF renders the Markdown of the selected pages of a PDF, all pages when pages is
+// nil, with the configured text settings and without images. The Markdown is cut at the
+// last line break within maxChars characters, or DefaultPreviewMaxChars when maxChars is 0.
+func (c *PDFConverter) PreviewPDF(ctx context.Context, pdfPath string, pages PageSelection, maxChars int) (*Preview, error) {
+	if strings.TrimSpace(pdfPath) == "" {
+		return nil, fmt.Errorf("PDF path cannot be empty")
+	}
+	pdfPath = filepath.Clean(pdfPath)
+	info, err := os.Stat(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("input file does not exist: %s", pdfPath)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", pdfPath)
+	}
+	if !strings.HasSuffix(strings.ToLower(pdfPath), ".pdf") {
+		return nil, fmt.Errorf("file does not have a .pdf extension: %s", pdfPath)
+	}
+	if maxChars <= 0 {
+		maxChars = DefaultPreviewMaxChars
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	reader, closeFile, _, err := c.openPDF(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer closeFile()
+	workDir, err := c.makeTempDir("preview")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create preview directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	cfg := *c.config
+	cfg.ExtractImages, cfg.DetectDiagrams = false, false
+	textOnly := c.withConfig(&cfg)
+	extracted, _, err := textOnly.extractPages(ctx, reader, pdfPath, workDir, 1, reader.NumPage(), pages, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract document content: %w", err)
+	}
+	if len(extracted) == 0 && pages != nil {
+		return nil, fmt.Errorf("page selection %s matches no page of the document", pages)
+	}
+
+	preview := &Preview{Source: pdfPath, PageCount: reader.NumPage(), Previewed: len(extracted)}
+	if pages != nil {
+		preview.Pages = pages.String()
+	}
+	preview.Markdown, preview.Truncated = truncateMarkdown(textOnly.lintMarkdown(textOnly.generateMarkdown(extracted)), maxChars)
+	c.logger.Debug("Previewed %d page(s) of %s (%d characters)", preview.Previewed, pdfPath, len(preview.Markdown))
+	return preview, nil
+}
+
+// truncateMarkdown cuts markdown at the last line break within maxChars characters, or
+// within the line when it has none, and reports whether it was cut.
+func truncateMarkdown(markdown string, maxChars int) (string, bool) {
+	runes := []rune(markdown)
+	if len(runes) <= maxChars {
+		return markdown, false
+	}
+	cut := string(runes[:maxChars])
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i+1]
+	}
+	return cut, true
+}
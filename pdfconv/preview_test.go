@@ -0,0 +1,78 @@
+package pdfconv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestPreviewPDF(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "preview.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	for _, text := range []string{"Absolute Maximum Ratings", "Electrical Characteristics", "Package Information"} {
+		doc.AddPage()
+		doc.Cell(40, 10, text)
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+	outputDir, tempDir := t.TempDir(), t.TempDir()
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, ExtractImages: true, OutputBaseDir: outputDir, TempDir: tempDir}, logger.NewLogger("error"))
+
+	pages, _ := ParsePageSelection("2")
+	preview, err := conv.PreviewPDF(context.Background(), pdfPath, pages, 0)
+	if err != nil {
+		t.Fatalf("PreviewPDF() error = %v", err)
+	}
+	if preview.PageCount != 3 || preview.Previewed != 1 || preview.Pages != "2" || preview.Truncated {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+	if !strings.Contains(preview.Markdown, "Electrical Characteristics") || strings.Contains(preview.Markdown, "Absolute Maximum Ratings") {
+		t.Errorf("expected only page 2 in the preview:\n%s", preview.Markdown)
+	}
+	for _, dir := range []string{outputDir, tempDir} {
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("expected nothing left in %s, got %d entries", dir, len(entries))
+		}
+	}
+
+	preview, err = conv.PreviewPDF(context.Background(), pdfPath, nil, 20)
+	if err != nil {
+		t.Fatalf("PreviewPDF() error = %v", err)
+	}
+	if preview.Previewed != 3 || !preview.Truncated || len([]rune(preview.Markdown)) > 20 {
+		t.Errorf("expected all pages cut at 20 characters, got %+v", preview)
+	}
+
+	pages, _ = ParsePageSelection("9-")
+	if _, err := conv.PreviewPDF(context.Background(), pdfPath, pages, 0); err == nil {
+		t.Error("expected an error for a selection outside the document")
+	}
+}
+
+func TestTruncateMarkdown(t *testing.T) {
+	tests := []struct {
+		markdown  string
+		max       int
+		want      string
+		truncated bool
+	}{
+		{"# Title\n\nText\n", 100, "# Title\n\nText\n", false},
+		{"# Title\n\nText\n", 10, "# Title\n\n", true},
+		{"Überschrift", 4, "Über", true},
+	}
+	for _, tt := range tests {
+		got, truncated := truncateMarkdown(tt.markdown, tt.max)
+		if got != tt.want || truncated != tt.truncated {
+			t.Errorf("truncateMarkdown(%q, %d) = %q %t, want %q %t", tt.markdown, tt.max, got, truncated, tt.want, tt.truncated)
+		}
+	}
+}
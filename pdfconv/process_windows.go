@@ -0,0 +1,16 @@
+//go:build windows
+
+package pdfconv
+
+import "os"
+
+// processAlive reports whether a process with the given ID is running. On Windows
+// os.FindProcess opens the process and fails when it does not exist.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
@@ -0,0 +1,207 @@
+// Package pdfconv - Conversion quality scoring.
+// This file scores how completely a document was converted from the extracted pages and
+// writes the conversion report JSON next to the generated Markdown.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReportFileName is the name of the conversion report written into each output directory.
+const ReportFileName = "conversion_report.json"
+
+// QualityReport summarizes the signals used to triage conversions that need manual cleanup.
+// Components that do not apply to a document (no OCR, no tables, no images) are nil and
+// do not count towards the score.
+type QualityReport struct {
+	Score               float64         `json:"score"`                        // Overall score from 0 to 100
+	TextCoverage        float64         `json:"text_coverage"`                // Fraction of pages with usable text
+	OCRConfidence       *float64        `json:"ocr_confidence,omitempty"`     // Mean OCR confidence of OCR pages
+	TableConfidence     *float64        `json:"table_confidence,omitempty"`   // Mean reconstruction confidence of tables
+	ImageSuccessRate    *float64        `json:"image_success_rate,omitempty"` // Fraction of images extracted without errors
+	PagesWithoutText    []int           `json:"pages_without_text,omitempty"` // Pages that produced no text
+	UnreliablePages     []int           `json:"unreliable_pages,omitempty"`   // Pages whose text looks garbled
+	LowConfidenceTables int             `json:"low_confidence_tables"`        // Tables rendered as a fallback
+	Redactions          []Redaction     `json:"redactions,omitempty"`         // Regions the source intentionally hides
+	DuplicateText       []DuplicateText `json:"duplicate_text,omitempty"`     // Pages whose text was drawn twice
+}
+
+// ConversionReport is the content of the conversion report JSON.
+type ConversionReport struct {
+	Source       string           `json:"source"`
+	Status       string           `json:"status"`                 // "complete", or "partial" when some pages failed
+	FailedPages  []PageFailure    `json:"failed_pages,omitempty"` // Pages whose content could not be extracted
+	PageCount    int              `json:"page_count"`
+	ImageCount   int              `json:"image_count"`
+	TableCount   int              `json:"table_count"`   // Tables, not counting merged continuations
+	DiagramCount int              `json:"diagram_count"` // Diagrams detected in images
+	Quality      QualityReport    `json:"quality"`
+	Repaired     bool             `json:"repaired,omitempty"` // The PDF was malformed and repaired before conversion
+	BrokenLinks  []BrokenLink     `json:"broken_links,omitempty"`
+	Languages    map[string]int   `json:"languages,omitempty"`    // Weighted letter count of each language
+	ReusedPages  int              `json:"reused_pages,omitempty"` // Unchanged pages reused by incremental conversion
+	Changes      *DocumentChanges `json:"changes,omitempty"`      // Differences from the previous output
+	DurationMS   int64            `json:"duration_ms"`
+	Timings      PhaseTimings     `json:"timings"`
+}
+
+// assessQuality scores the extracted pages. The score is the mean of the applicable
+// components, each scaled to 0-100.
+func assessQuality(pages []PDFPage) QualityReport {
+	var q QualityReport
+	if len(pages) == 0 {
+		return q
+	}
+
+	var ocrTotal, tableTotal float64
+	ocrPages, tables, images, extracted, failures := 0, 0, 0, 0, 0
+	for _, page := range pages {
+		if strings.TrimSpace(page.Text) == "" {
+			q.PagesWithoutText = append(q.PagesWithoutText, page.Number)
+		} else if page.Unreliable {
+			q.UnreliablePages = append(q.UnreliablePages, page.Number)
+		}
+		if page.OCR {
+			ocrTotal += page.OCRConfidence
+			ocrPages++
+		}
+		for _, table := range page.Tables {
+			if table.Merged {
+				continue
+			}
+			tableTotal += table.Confidence
+			tables++
+			if table.Fallback {
+				q.LowConfidenceTables++
+			}
+		}
+		for _, img := range page.Images {
+			images++
+			if !img.Placeholder {
+				extracted++
+			}
+		}
+		failures += page.ImageFailures
+		q.Redactions = append(q.Redactions, page.Redactions...)
+		if page.DuplicateText != nil {
+			q.DuplicateText = append(q.DuplicateText, *page.DuplicateText)
+		}
+	}
+
+	q.TextCoverage = float64(len(pages)-len(q.PagesWithoutText)-len(q.UnreliablePages)) / float64(len(pages))
+	components := []float64{q.TextCoverage}
+	if ocrPages > 0 {
+		q.OCRConfidence = ratio(ocrTotal, float64(ocrPages))
+		components = append(components, *q.OCRConfidence)
+	}
+	if tables > 0 {
+		q.TableConfidence = ratio(tableTotal, float64(tables))
+		components = append(components, *q.TableConfidence)
+	}
+	if attempted := images + failures; attempted > 0 {
+		q.ImageSuccessRate = ratio(float64(extracted), float64(attempted))
+		components = append(components, *q.ImageSuccessRate)
+	}
+
+	sum := 0.0
+	for _, v := range components {
+		sum += v
+	}
+	q.Score = float64(int(sum/float64(len(components))*1000+0.5)) / 10
+	return q
+}
+
+// ratio returns a/b rounded to three decimals.
+func ratio(a, b float64) *float64 {
+	v := float64(int(a/b*1000+0.5)) / 1000
+	return &v
+}
+
+// Summary renders the report as a single line for tool output.
+func (q QualityReport) Summary() string {
+	parts := []string{fmt.Sprintf("text on %.0f%% of pages", q.TextCoverage*100)}
+	if n := len(q.UnreliablePages); n > 0 {
+		parts = append(parts, fmt.Sprintf("garbled text on %d page(s)", n))
+	}
+	if q.OCRConfidence != nil {
+		parts = append(parts, fmt.Sprintf("OCR confidence %.0f%%", *q.OCRConfidence*100))
+	}
+	if q.TableConfidence != nil {
+		parts = append(parts, fmt.Sprintf("table confidence %.0f%%", *q.TableConfidence*100))
+		if q.LowConfidenceTables > 0 {
+			parts = append(parts, fmt.Sprintf("%d low-confidence table(s)", q.LowConfidenceTables))
+		}
+	}
+	if q.ImageSuccessRate != nil {
+		parts = append(parts, fmt.Sprintf("images extracted %.0f%%", *q.ImageSuccessRate*100))
+	}
+	if pages := q.RedactedPages(); len(pages) > 0 {
+		parts = append(parts, fmt.Sprintf("redactions on %d page(s)", len(pages)))
+	}
+	if n := len(q.DuplicateText); n > 0 {
+		parts = append(parts, fmt.Sprintf("duplicate text layer removed on %d page(s)", n))
+	}
+	return fmt.Sprintf("%.1f/100 (%s)", q.Score, strings.Join(parts, ", "))
+}
+
+// Warnings lists the problems of a conversion that need manual review: failed pages, pages
+// without text, tables rendered as images, failed images, broken links and PDF repair. Redactions are not
+// warnings, since the missing content is intentional.
+func (r ConversionResult) Warnings() []string {
+	var warnings []string
+	q := r.Quality
+	if n := len(r.FailedPages); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d page(s) failed", n))
+	}
+	if n := len(q.PagesWithoutText); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d page(s) without text", n))
+	}
+	if n := len(q.UnreliablePages); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d page(s) with garbled text", n))
+	}
+	if q.LowConfidenceTables > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d low-confidence table(s)", q.LowConfidenceTables))
+	}
+	if q.ImageSuccessRate != nil && *q.ImageSuccessRate < 1 {
+		warnings = append(warnings, fmt.Sprintf("only %.0f%% of images extracted", *q.ImageSuccessRate*100))
+	}
+	if n := len(r.BrokenLinks); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d broken link(s)", n))
+	}
+	if r.Repaired {
+		warnings = append(warnings, "PDF was repaired")
+	}
+	return warnings
+}
+
+// RedactedPages returns the numbers of the pages with redactions, in order.
+func (q QualityReport) RedactedPages() []int {
+	var pages []int
+	for _, r := range q.Redactions {
+		if len(pages) == 0 || pages[len(pages)-1] != r.Page {
+			pages = append(pages, r.Page)
+		}
+	}
+	return pages
+}
+
+// conversionReport returns the conversion report of a result of converting docPath.
+func conversionReport(docPath string, result ConversionResult) ConversionReport {
+	return ConversionReport{Source: docPath, Status: result.Status, FailedPages: result.FailedPages, PageCount: result.PageCount, ImageCount: result.ImageCount, TableCount: result.TableCount, DiagramCount: result.DiagramCount, Quality: result.Quality, Repaired: result.Repaired, BrokenLinks: result.BrokenLinks, Languages: result.Languages, ReusedPages: result.ReusedPages, Changes: result.Changes, DurationMS: result.Duration.Milliseconds(), Timings: result.Timings}
+}
+
+// writeConversionReport writes the conversion report JSON for a result into dir.
+func (c *PDFConverter) writeConversionReport(dir, docPath string, result ConversionResult) error {
+	data, err := json.MarshalIndent(conversionReport(docPath, result), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode conversion report: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ReportFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write conversion report: %v", err)
+	}
+	return nil
+}
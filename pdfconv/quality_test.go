@@ -0,0 +1,94 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestAssessQuality(t *testing.T) {
+	pages := []PDFPage{
+		{Number: 1, Text: "Overview", Tables: []PDFTable{{Confidence: 0.9}, {Confidence: 0.3, Fallback: true}}, Images: []PDFImage{{}, {Placeholder: true}}},
+		{Number: 2, Text: " ", ImageFailures: 2},
+		{Number: 3, Text: "Pinout", Tables: []PDFTable{{Confidence: 0.2, Merged: true}}},
+	}
+	q := assessQuality(pages)
+	if q.TextCoverage < 0.666 || q.TextCoverage > 0.667 {
+		t.Errorf("expected text coverage 2/3, got %f", q.TextCoverage)
+	}
+	if len(q.PagesWithoutText) != 1 || q.PagesWithoutText[0] != 2 {
+		t.Errorf("expected page 2 without text, got %v", q.PagesWithoutText)
+	}
+	if q.TableConfidence == nil || *q.TableConfidence != 0.6 || q.LowConfidenceTables != 1 {
+		t.Errorf("expected table confidence 0.6 with 1 fallback, got %v %d", q.TableConfidence, q.LowConfidenceTables)
+	}
+	if q.ImageSuccessRate == nil || *q.ImageSuccessRate != 0.25 {
+		t.Errorf("expected image success rate 0.25, got %v", q.ImageSuccessRate)
+	}
+	if q.OCRConfidence != nil {
+		t.Errorf("expected no OCR component, got %v", *q.OCRConfidence)
+	}
+	// mean of 0.667, 0.6 and 0.25
+	if q.Score != 50.6 {
+		t.Errorf("expected score 50.6, got %.1f", q.Score)
+	}
+	if s := q.Summary(); !strings.HasPrefix(s, "50.6/100") || !strings.Contains(s, "1 low-confidence table") {
+		t.Errorf("unexpected summary %q", s)
+	}
+
+	if q := assessQuality([]PDFPage{{Number: 1, Text: "x", OCR: true, OCRConfidence: 0.8}}); q.Score != 90 {
+		t.Errorf("expected score 90 for a single OCR page, got %.1f", q.Score)
+	}
+}
+
+func TestConvertPDF_WritesConversionReport(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempValidPDF(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(res.OutputDir, ReportFileName))
+	if err != nil {
+		t.Fatalf("expected conversion report: %v", err)
+	}
+	var report ConversionReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid conversion report: %v", err)
+	}
+	if report.PageCount != res.PageCount || report.Quality.Score != res.Quality.Score {
+		t.Errorf("report %+v does not match result %+v", report, res)
+	}
+}
+
+func TestConversionResultWarnings(t *testing.T) {
+	pages := []PDFPage{
+		{Number: 1, Text: "Overview", Tables: []PDFTable{{Confidence: 0.3, Fallback: true}}, Images: []PDFImage{{}, {Placeholder: true}}},
+		{Number: 2, Redactions: []Redaction{{Page: 2, Kind: RedactionBox, Count: 1}}},
+	}
+	r := ConversionResult{Quality: assessQuality(pages), BrokenLinks: []BrokenLink{{Target: "#x"}}, Repaired: true}
+	want := "1 page(s) without text, 1 low-confidence table(s), only 50% of images extracted, 1 broken link(s), PDF was repaired"
+	if got := strings.Join(r.Warnings(), ", "); got != want {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+	if w := (ConversionResult{Quality: assessQuality([]PDFPage{{Number: 1, Text: "x"}})}).Warnings(); len(w) != 0 {
+		t.Errorf("expected no warnings, got %v", w)
+	}
+}
+
+func TestConvertDocument_SetsSourceAndDuration(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	pdfPath := createTempValidPDF(t)
+	res, err := conv.ConvertDocument(pdfPath, t.TempDir(), ConversionOptions{})
+	if err != nil {
+		t.Fatalf("ConvertDocument() error = %v", err)
+	}
+	if res.Source != pdfPath || res.Duration <= 0 {
+		t.Errorf("expected source %s and a duration, got %q %v", pdfPath, res.Source, res.Duration)
+	}
+}
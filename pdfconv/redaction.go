@@ -0,0 +1,352 @@
+// Package pdfconv - Redaction detection.
+// This file finds content the source document hides on purpose: redaction annotations, solid
+// black boxes drawn over the page and blacked-out regions in page scans, so missing text can
+// be told apart from conversion failures.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Redaction detection thresholds
+const (
+	RedactionMinWidth  = 10.0 // Narrowest filled box counted as a redaction, in points
+	RedactionMinHeight = 6.0  // Lowest filled box counted as a redaction, in points (excludes rules)
+	RedactionMaxArea   = 0.9  // Boxes covering more of the page are backgrounds, not redactions
+	redactionDarkLevel = 0.1  // Highest color component value treated as black
+	blackoutCellsMin   = 6    // Smallest blacked-out scan region, in grid cells
+)
+
+// Redaction kinds
+const (
+	RedactionAnnotation = "annotation" // Redact annotation marking content for removal
+	RedactionBox        = "box"        // Solid black rectangle drawn without text inside
+	RedactionBlackout   = "blackout"   // Solid black region in a page scan
+)
+
+// Redaction summarizes the redacted regions of one kind on a page.
+type Redaction struct {
+	Page    int     `json:"page"`
+	Section string  `json:"section,omitempty"` // Heading of the section the page starts in
+	Kind    string  `json:"kind"`
+	Count   int     `json:"count"`
+	Area    float64 `json:"area"` // Fraction of the page area covered
+}
+
+// detectPDFRedactions returns the redaction annotations and black boxes on a PDF page.
+func (c *PDFConverter) detectPDFRedactions(page pdf.Page, pageNum int) (redactions []Redaction) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Debug("Failed to check page %d for redactions: %v", pageNum, r)
+		}
+	}()
+	width, height := pageSize(page)
+	if width <= 0 || height <= 0 {
+		width, height = 612, 792
+	}
+	pageArea := width * height
+
+	annots := page.V.Key("Annots")
+	annotation := Redaction{Page: pageNum, Kind: RedactionAnnotation}
+	for i := 0; i < annots.Len(); i++ {
+		annot := annots.Index(i)
+		if annot.Key("Subtype").Name() != "Redact" {
+			continue
+		}
+		annotation.Count++
+		if rect := annot.Key("Rect"); rect.Len() == 4 {
+			annotation.Area += math.Abs((rect.Index(2).Float64()-rect.Index(0).Float64())*(rect.Index(3).Float64()-rect.Index(1).Float64())) / pageArea
+		}
+	}
+	if annotation.Count > 0 {
+		redactions = append(redactions, annotation)
+	}
+
+	var glyphs []pdf.Point
+	for _, t := range page.Content().Text {
+		if strings.TrimSpace(t.S) != "" {
+			glyphs = append(glyphs, pdf.Point{X: t.X + t.W/2, Y: t.Y + t.FontSize/3})
+		}
+	}
+	box := Redaction{Page: pageNum, Kind: RedactionBox}
+	for _, filled := range filledRects(page) {
+		if !filled.color.dark() {
+			continue
+		}
+		r := filled.Rect
+		w, h := r.Max.X-r.Min.X, r.Max.Y-r.Min.Y
+		if w < RedactionMinWidth || h < RedactionMinHeight || w*h > RedactionMaxArea*pageArea {
+			continue
+		}
+		// White text on a dark table header or label is visible content, not a redaction
+		covered := false
+		for _, g := range glyphs {
+			if g.X >= r.Min.X && g.X <= r.Max.X && g.Y >= r.Min.Y && g.Y <= r.Max.Y {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			box.Count++
+			box.Area += w * h / pageArea
+		}
+	}
+	if box.Count > 0 {
+		redactions = append(redactions, box)
+	}
+	for i := range redactions {
+		redactions[i].Area = roundArea(redactions[i].Area)
+	}
+	return redactions
+}
+
+// fillColor is a fill color as RGB components between 0 and 1. Pattern and other
+// non-numeric colors are not known.
+type fillColor struct {
+	r, g, b float64
+	known   bool
+}
+
+// filledRect is a rectangle filled with a color, in page coordinates.
+type filledRect struct {
+	pdf.Rect
+	color fillColor
+}
+
+// filledRects returns the rectangles filled on a page with their fill color, in page
+// coordinates and content stream order.
+func filledRects(page pdf.Page) []filledRect {
+	type state struct {
+		ctm  [6]float64
+		fill fillColor
+	}
+	// The initial fill color is black
+	black := fillColor{known: true}
+	gs := state{ctm: [6]float64{1, 0, 0, 1, 0, 0}, fill: black}
+	var stack []state
+	var path []pdf.Rect
+	complexPath := false
+	var filled []filledRect
+
+	transform := func(x, y float64) (float64, float64) {
+		m := gs.ctm
+		return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+	}
+	contents := page.V.Key("Contents")
+	if contents.IsNull() {
+		return nil
+	}
+	pdf.Interpret(contents, func(stk *pdf.Stack, op string) {
+		n := stk.Len()
+		args := make([]pdf.Value, n)
+		for i := n - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(args) == 6 {
+				var m [6]float64
+				for i := range m {
+					m[i] = args[i].Float64()
+				}
+				a := gs.ctm
+				gs.ctm = [6]float64{
+					m[0]*a[0] + m[1]*a[2], m[0]*a[1] + m[1]*a[3],
+					m[2]*a[0] + m[3]*a[2], m[2]*a[1] + m[3]*a[3],
+					m[4]*a[0] + m[5]*a[2] + a[4], m[4]*a[1] + m[5]*a[3] + a[5],
+				}
+			}
+		case "g", "rg", "k", "sc", "scn":
+			gs.fill = parseFillColor(args)
+		case "cs":
+			gs.fill = black
+		case "re":
+			if len(args) == 4 {
+				x, y, w, h := args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64()
+				x0, y0 := transform(x, y)
+				x1, y1 := transform(x+w, y+h)
+				path = append(path, pdf.Rect{
+					Min: pdf.Point{X: math.Min(x0, x1), Y: math.Min(y0, y1)},
+					Max: pdf.Point{X: math.Max(x0, x1), Y: math.Max(y0, y1)},
+				})
+			}
+		case "m", "l", "c", "v", "y":
+			complexPath = true
+		case "f", "F", "f*", "B", "B*", "b", "b*":
+			if !complexPath {
+				for _, r := range path {
+					filled = append(filled, filledRect{Rect: r, color: gs.fill})
+				}
+			}
+			path, complexPath = nil, false
+		case "n", "S", "s":
+			path, complexPath = nil, false
+		}
+	})
+	return filled
+}
+
+// parseFillColor converts the operands of a fill color operator (gray, RGB or CMYK) to RGB.
+// Pattern colors are not known.
+func parseFillColor(args []pdf.Value) fillColor {
+	for _, a := range args {
+		if a.Kind() != pdf.Integer && a.Kind() != pdf.Real {
+			return fillColor{}
+		}
+	}
+	switch len(args) {
+	case 1:
+		v := args[0].Float64()
+		return fillColor{v, v, v, true}
+	case 3:
+		return fillColor{args[0].Float64(), args[1].Float64(), args[2].Float64(), true}
+	case 4:
+		k := 1 - args[3].Float64()
+		return fillColor{(1 - args[0].Float64()) * k, (1 - args[1].Float64()) * k, (1 - args[2].Float64()) * k, true}
+	}
+	return fillColor{}
+}
+
+// dark reports whether the color is black.
+func (c fillColor) dark() bool {
+	return c.known && math.Max(c.r, math.Max(c.g, c.b)) <= redactionDarkLevel
+}
+
+// detectBlackouts finds solid black rectangular regions in a page image. The image is
+// divided into a grid of cells; cells that are almost entirely black are grouped into
+// connected regions, and roughly rectangular regions of a few cells or more are counted.
+// Nothing is found when ctx is done before the grid is scanned.
+func detectBlackouts(ctx context.Context, img image.Image, pageNum int) []Redaction {
+	bounds := img.Bounds()
+	cell := max(4, min(bounds.Dx(), bounds.Dy())/100)
+	cols, rows := bounds.Dx()/cell, bounds.Dy()/cell
+	if cols == 0 || rows == 0 {
+		return nil
+	}
+	black := make([]bool, cols*rows)
+	for cy := 0; cy < rows; cy++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		for cx := 0; cx < cols; cx++ {
+			dark, total := 0, 0
+			for y := bounds.Min.Y + cy*cell; y < bounds.Min.Y+(cy+1)*cell; y += 2 {
+				for x := bounds.Min.X + cx*cell; x < bounds.Min.X+(cx+1)*cell; x += 2 {
+					r, g, b, _ := img.At(x, y).RGBA()
+					if (299*r+587*g+114*b)/1000 < 0x2800 {
+						dark++
+					}
+					total++
+				}
+			}
+			black[cy*cols+cx] = dark*100 >= total*95
+		}
+	}
+
+	result := Redaction{Page: pageNum, Kind: RedactionBlackout}
+	seen := make([]bool, len(black))
+	for start := range black {
+		if !black[start] || seen[start] {
+			continue
+		}
+		// Flood fill the region and track its bounding box
+		queue := []int{start}
+		seen[start] = true
+		minX, minY, maxX, maxY, cells := cols, rows, 0, 0, 0
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			x, y := i%cols, i/cols
+			minX, minY, maxX, maxY = min(minX, x), min(minY, y), max(maxX, x), max(maxY, y)
+			cells++
+			for _, next := range []int{i - cols, i + cols, i - 1, i + 1} {
+				if next < 0 || next >= len(black) || (next == i-1 && x == 0) || (next == i+1 && x == cols-1) {
+					continue
+				}
+				if black[next] && !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		boxCells := (maxX - minX + 1) * (maxY - minY + 1)
+		area := float64(boxCells) / float64(cols*rows)
+		if cells < blackoutCellsMin || cells*10 < boxCells*9 || area > RedactionMaxArea {
+			continue
+		}
+		result.Count++
+		result.Area += area
+	}
+	if result.Count == 0 {
+		return nil
+	}
+	result.Area = roundArea(result.Area)
+	return []Redaction{result}
+}
+
+// roundArea rounds a page area fraction to three decimals.
+func roundArea(area float64) float64 {
+	return math.Round(area*1000) / 1000
+}
+
+// pageHeadingPattern matches the "Page N" headings that separate pages in the Markdown.
+var pageHeadingPattern = regexp.MustCompile(`^Page (\d+)$`)
+
+// redactionSections sets the Section of each redaction to the heading of the section its
+// page starts in, or the first heading on the page when the page starts before any section.
+func redactionSections(markdown string, redactions []Redaction) {
+	if len(redactions) == 0 {
+		return
+	}
+	startSection := map[int]string{}
+	current, page := "", 0
+	var fences fenceTracker
+	for _, line := range strings.Split(markdown, "\n") {
+		if fences.inCode(line) {
+			continue
+		}
+		m := markdownHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if pm := pageHeadingPattern.FindStringSubmatch(m[2]); pm != nil {
+			page, _ = strconv.Atoi(pm[1])
+			startSection[page] = current
+			continue
+		}
+		if page == 0 {
+			continue // document title
+		}
+		current = strings.TrimSpace(m[2])
+		if startSection[page] == "" {
+			startSection[page] = current
+		}
+	}
+	for i := range redactions {
+		redactions[i].Section = startSection[redactions[i].Page]
+	}
+}
+
+// redactionNote is the Markdown note written on pages with redacted content.
+func redactionNote(redactions []Redaction) string {
+	count := 0
+	for _, r := range redactions {
+		count += r.Count
+	}
+	return fmt.Sprintf("> **Redacted:** %d region(s) on this page are intentionally hidden in the source document.\n\n", count)
+}
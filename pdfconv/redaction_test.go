@@ -0,0 +1,150 @@
+package pdfconv
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestConvertPDF_DetectsRedactionBoxes(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "redacted.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Arial", "", 12)
+	doc.AddPage()
+	doc.Cell(40, 10, "Overview")
+	doc.AddPage()
+	doc.SetXY(10, 20)
+	doc.Cell(40, 10, "Pin assignments")
+	// Black box over withheld content
+	doc.SetFillColor(0, 0, 0)
+	doc.Rect(20, 60, 80, 12, "F")
+	// White text on a black table header is visible content
+	doc.Rect(20, 100, 80, 10, "F")
+	doc.SetTextColor(255, 255, 255)
+	doc.SetXY(22, 100)
+	doc.Cell(40, 10, "Parameter")
+	// Thin black rules and light grey shading are not redactions
+	doc.Rect(20, 130, 80, 0.5, "F")
+	doc.SetFillColor(200, 200, 200)
+	doc.Rect(20, 150, 80, 20, "F")
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	redactions := res.Quality.Redactions
+	if len(redactions) != 1 {
+		t.Fatalf("expected one redaction entry, got %+v", redactions)
+	}
+	r := redactions[0]
+	if r.Page != 2 || r.Kind != RedactionBox || r.Count != 1 || r.Area <= 0 || r.Area > 0.1 {
+		t.Errorf("unexpected redaction: %+v", r)
+	}
+
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "**Redacted:** 1 region(s)") {
+		t.Errorf("expected redaction note in Markdown, got:\n%s", md)
+	}
+	data, _ := os.ReadFile(filepath.Join(res.OutputDir, ReportFileName))
+	var report ConversionReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+	if len(report.Quality.Redactions) != 1 || report.Quality.Redactions[0].Page != 2 {
+		t.Errorf("expected redaction in conversion report, got:\n%s", data)
+	}
+	if !strings.Contains(res.Quality.Summary(), "redactions on 1 page(s)") {
+		t.Errorf("expected redactions in summary, got %q", res.Quality.Summary())
+	}
+}
+
+func TestConvertPDF_DetectsRedactAnnotations(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "marked.pdf")
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 600 800] /Annots [4 0 R 5 0 R 6 0 R] >>",
+		"<< /Type /Annot /Subtype /Redact /Rect [100 100 400 140] >>",
+		"<< /Type /Annot /Subtype /Redact /Rect [100 200 400 240] >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 600 800] >>",
+	})
+	if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	want := Redaction{Page: 1, Kind: RedactionAnnotation, Count: 2, Area: 0.05}
+	if len(res.Quality.Redactions) != 1 || res.Quality.Redactions[0] != want {
+		t.Errorf("expected %+v, got %+v", want, res.Quality.Redactions)
+	}
+}
+
+func TestDetectBlackouts(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 850, 1100))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	black := image.NewUniform(color.Black)
+	// Redacted block
+	draw.Draw(img, image.Rect(100, 200, 500, 260), black, image.Point{}, draw.Src)
+	// Text-like strokes: dark but never solid across a grid cell
+	for x := 100; x < 700; x += 6 {
+		draw.Draw(img, image.Rect(x, 500, x+2, 530), black, image.Point{}, draw.Src)
+	}
+	// Thin rule
+	draw.Draw(img, image.Rect(100, 700, 700, 703), black, image.Point{}, draw.Src)
+
+	got := detectBlackouts(context.Background(), img, 3)
+	if len(got) != 1 || got[0].Page != 3 || got[0].Kind != RedactionBlackout || got[0].Count != 1 {
+		t.Fatalf("unexpected blackouts: %+v", got)
+	}
+	if got[0].Area < 0.02 || got[0].Area > 0.04 {
+		t.Errorf("expected about 2.6%% of the page, got %v", got[0].Area)
+	}
+
+	// A page that is black all over is an inverted scan, not a redaction
+	draw.Draw(img, img.Bounds(), black, image.Point{}, draw.Src)
+	if got := detectBlackouts(context.Background(), img, 1); len(got) != 0 {
+		t.Errorf("expected no blackouts on a black page, got %+v", got)
+	}
+}
+
+func TestRedactionSections(t *testing.T) {
+	markdown := strings.Join([]string{
+		"# PDF Document",
+		"## Page 1",
+		"### 1 Overview",
+		"## Page 2",
+		"text",
+		"### 2 Electrical Characteristics",
+		"## Page 3",
+		"```",
+		"# not a heading",
+		"```",
+		"## Page 4",
+	}, "\n")
+	redactions := []Redaction{{Page: 1}, {Page: 2}, {Page: 3}, {Page: 4}}
+	redactionSections(markdown, redactions)
+	want := []string{"1 Overview", "1 Overview", "2 Electrical Characteristics", "2 Electrical Characteristics"}
+	for i, r := range redactions {
+		if r.Section != want[i] {
+			t.Errorf("page %d: section = %q, want %q", r.Page, r.Section, want[i])
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package pdfconv - Page rendering.
+// This file rasterizes PDF pages for content that cannot be reconstructed from the PDF
+// objects, such as low-confidence tables, for OCR of garbled text layers and for thumbnails.
+// Rendering arbitrary PDFs in pure Go is not feasible, so pages are rendered by an external
+// program: poppler's pdftoppm, Ghostscript or pdfium, selected with RENDERER or detected on
+// PATH at runtime.
+package pdfconv
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/ledongthuc/pdf"
+)
+
+// pageRenderer is an external program that rasterizes PDF pages to PNG.
+type pageRenderer struct {
+	name     string   // RENDERER value selecting the program
+	binaries []string // Executable names, the first one found on PATH is used
+	// command returns the command rendering a 1-based page of pdfPath at dpi into the empty
+	// directory dir, and the path of the PNG it writes
+	command func(bin, pdfPath string, pageNum, dpi int, dir string) (*exec.Cmd, string, error)
+}
+
+// pageRenderers lists the renderers in the order RENDERER=auto tries them, matching
+// config.Renderers.
+var pageRenderers = []pageRenderer{
+	{name: "pdftoppm", binaries: []string{"pdftoppm"}, command: pdftoppmCommand},
+	{name: "ghostscript", binaries: []string{"gs", "gswin64c", "gswin32c"}, command: ghostscriptCommand},
+	{name: "pdfium", binaries: []string{"pdfium_test"}, command: pdfiumCommand},
+}
+
+// pdftoppmCommand renders a page with poppler's pdftoppm.
+func pdftoppmCommand(bin, pdfPath string, pageNum, dpi int, dir string) (*exec.Cmd, string, error) {
+	prefix := filepath.Join(dir, "page")
+	page := strconv.Itoa(pageNum)
+	return exec.Command(bin, "-f", page, "-l", page, "-r", strconv.Itoa(dpi), "-png", "-singlefile", pdfPath, prefix), prefix + ".png", nil
+}
+
+// ghostscriptCommand renders a page with Ghostscript. -dSAFER keeps PostScript in the
+// document from touching the file system.
+func ghostscriptCommand(bin, pdfPath string, pageNum, dpi int, dir string) (*exec.Cmd, string, error) {
+	output := filepath.Join(dir, "page.png")
+	page := strconv.Itoa(pageNum)
+	return exec.Command(bin, "-q", "-dSAFER", "-dBATCH", "-dNOPAUSE", "-sDEVICE=png16m", "-dTextAlphaBits=4", "-dGraphicsAlphaBits=4",
+		"-r"+strconv.Itoa(dpi), "-dFirstPage="+page, "-dLastPage="+page, "-sOutputFile="+output, pdfPath), output, nil
+}
+
+// pdfiumCommand renders a page with pdfium's pdfium_test, which writes <input>.<index>.png
+// next to its input, so the document is linked (or copied) into dir first.
+func pdfiumCommand(bin, pdfPath string, pageNum, dpi int, dir string) (*exec.Cmd, string, error) {
+	input := filepath.Join(dir, "page.pdf")
+	abs, err := filepath.Abs(pdfPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.Symlink(abs, input); err != nil {
+		if err := copyOutputFile(pdfPath, input); err != nil {
+			return nil, "", fmt.Errorf("failed to copy document for pdfium: %v", err)
+		}
+	}
+	index := strconv.Itoa(pageNum - 1)
+	scale := strconv.FormatFloat(float64(dpi)/72, 'f', 4, 64)
+	return exec.Command(bin, "--png", "--pages="+index+"-"+index, "--scale="+scale, input), input + "." + index + ".png", nil
+}
+
+// rendererBinaries returns the executables that can serve RENDERER.
+func (c *PDFConverter) rendererBinaries() []string {
+	var binaries []string
+	for _, r := range pageRenderers {
+		if c.config.Renderer == "" || c.config.Renderer == "auto" || c.config.Renderer == r.name {
+			binaries = append(binaries, r.binaries...)
+		}
+	}
+	return binaries
+}
+
+// findRenderer returns the renderer selected by RENDERER and the path of its executable:
+// the named renderer, or for "auto" the first one found on PATH.
+func (c *PDFConverter) findRenderer() (pageRenderer, string, error) {
+	for _, r := range pageRenderers {
+		if c.config.Renderer != "" && c.config.Renderer != "auto" && c.config.Renderer != r.name {
+			continue
+		}
+		for _, name := range r.binaries {
+			if bin, err := exec.LookPath(name); err == nil {
+				return r, bin, nil
+			}
+		}
+	}
+	return pageRenderer{}, "", fmt.Errorf("page rendering requires one of %s on PATH", strings.Join(c.rendererBinaries(), ", "))
+}
+
+// renderPage rasterizes a single 1-based page at the given resolution.
+// It requires a page renderer on PATH and returns an error when none is available.
+func (c *PDFConverter) renderPage(pdfPath string, pageNum, dpi int) (image.Image, error) {
+	renderer, bin, err := c.findRenderer()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := c.makeTempDir("render")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create render directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cmd, output, err := renderer.command(bin, pdfPath, pageNum, dpi, dir)
+	if err != nil {
+		return nil, err
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", renderer.name, err, out)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rendered page: %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode rendered page: %v", err)
+	}
+	c.logger.Debug("Rendered page %d of %s at %d DPI with %s", pageNum, pdfPath, dpi, renderer.name)
+	return img, nil
+}
+
+// pageRender rasterizes a 1-based page of the document being converted at the given
+// resolution. Formats rendered at a fixed resolution ignore dpi.
+type pageRender func(pageNum, dpi int) (image.Image, error)
+
+// ThumbnailFileName is the name of the first page thumbnail written with THUMBNAIL_WIDTH.
+const ThumbnailFileName = "thumbnail.png"
+
+// thumbnailRenderDPI is the resolution pages are rendered at before scaling them down to
+// THUMBNAIL_WIDTH, wide enough for the largest thumbnails of A4 and letter pages.
+const thumbnailRenderDPI = 150
+
+// writeThumbnail writes a THUMBNAIL_WIDTH pixels wide PNG of the first page to outputDir and
+// returns its file name. It returns "" when thumbnails are off, the format cannot be
+// rendered or rendering fails; a missing thumbnail does not fail the conversion.
+func (c *PDFConverter) writeThumbnail(outputDir string, render pageRender) string {
+	if c.config.ThumbnailWidth <= 0 || render == nil {
+		return ""
+	}
+	img, err := render(1, thumbnailRenderDPI)
+	if err != nil {
+		c.logger.Warn("Cannot render thumbnail: %v", err)
+		return ""
+	}
+	thumbnail := imaging.Resize(img, c.config.ThumbnailWidth, 0, imaging.Lanczos)
+	if err := imaging.Save(thumbnail, filepath.Join(outputDir, ThumbnailFileName)); err != nil {
+		c.logger.Warn("Failed to save thumbnail: %v", err)
+		return ""
+	}
+	return ThumbnailFileName
+}
+
+// pageHeight returns the height of the page MediaBox in points, following inheritance
+// from the page tree, or 0 when the page has no usable MediaBox.
+func pageHeight(page pdf.Page) float64 {
+	_, height := pageSize(page)
+	return height
+}
+
+// pageSize returns the width and height of the page MediaBox in points, following
+// inheritance from the page tree, or zeros when the page has no usable MediaBox.
+func pageSize(page pdf.Page) (float64, float64) {
+	for v := page.V; !v.IsNull(); v = v.Key("Parent") {
+		if box := v.Key("MediaBox"); box.Len() == 4 {
+			return box.Index(2).Float64() - box.Index(0).Float64(), box.Index(3).Float64() - box.Index(1).Float64()
+		}
+	}
+	return 0, 0
+}
+
+// cropPageBand crops the full-width horizontal band between the PDF Y coordinates top and
+// bottom (points, origin at the bottom of a page pageHeight points tall) from a rendered page.
+func cropPageBand(pageImg image.Image, pageHeight, top, bottom float64) image.Image {
+	bounds := pageImg.Bounds()
+	scale := float64(bounds.Dy()) / pageHeight
+	y0 := bounds.Min.Y + int((pageHeight-top)*scale)
+	y1 := bounds.Min.Y + int((pageHeight-bottom)*scale+0.5)
+	return imaging.Crop(pageImg, image.Rect(bounds.Min.X, y0, bounds.Max.X, y1))
+}
+
+// cropPageRect crops a region given in PDF coordinates (points, origin at the bottom left of
+// a page width by height points) from a rendered page.
+func cropPageRect(pageImg image.Image, width, height float64, r pdf.Rect) image.Image {
+	bounds := pageImg.Bounds()
+	scaleX, scaleY := float64(bounds.Dx())/width, float64(bounds.Dy())/height
+	return imaging.Crop(pageImg, image.Rect(
+		bounds.Min.X+int(r.Min.X*scaleX), bounds.Min.Y+int((height-r.Max.Y)*scaleY),
+		bounds.Min.X+int(r.Max.X*scaleX+0.5), bounds.Min.Y+int((height-r.Min.Y)*scaleY+0.5),
+	))
+}
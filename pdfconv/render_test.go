@@ -0,0 +1,96 @@
+package pdfconv
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// installFakeGhostscript puts a gs on PATH that "renders" a fixed 400x600 page image to the
+// -sOutputFile path and records its arguments in args.txt.
+func installFakeGhostscript(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools require a POSIX shell")
+	}
+	cp, err := exec.LookPath("cp")
+	if err != nil {
+		t.Skip("cp not found")
+	}
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.png")
+	if err := imaging.Save(imaging.New(400, 600, color.White), page); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args.txt") + "\nfor a in \"$@\"; do case $a in -sOutputFile=*) " + cp + " " + page + " \"${a#-sOutputFile=}\";; esac; done\n"
+	if err := os.WriteFile(filepath.Join(dir, "gs"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+func TestRenderPage_Renderers(t *testing.T) {
+	dir := installFakeGhostscript(t)
+	conv, _ := NewPDFConverter(&config.Config{Renderer: "auto"}, logger.NewLogger("error"))
+	img, err := conv.renderPage("doc.pdf", 3, 200)
+	if err != nil {
+		t.Fatalf("renderPage() error = %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 400, 600) {
+		t.Errorf("unexpected rendered page bounds %v", img.Bounds())
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args.txt"))
+	for _, want := range []string{"-dSAFER", "-r200", "-dFirstPage=3", "-dLastPage=3", "doc.pdf"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("expected %s in the Ghostscript arguments, got %s", want, args)
+		}
+	}
+
+	capabilities := conv.DetectCapabilities()
+	if !conv.HasCapability(CapabilityRender) || capabilities[1].Using != "gs" {
+		t.Errorf("expected rendering with gs, got %+v", capabilities[1])
+	}
+
+	pinned, _ := NewPDFConverter(&config.Config{Renderer: "pdftoppm"}, logger.NewLogger("error"))
+	if _, err := pinned.renderPage("doc.pdf", 1, 72); err == nil || !strings.Contains(err.Error(), "requires one of pdftoppm on PATH") {
+		t.Errorf("expected RENDERER=pdftoppm to require pdftoppm, got %v", err)
+	}
+	pinned.DetectCapabilities()
+	if err := pinned.RequireCapability(CapabilityRender); err == nil || !strings.Contains(err.Error(), "none of pdftoppm found") {
+		t.Errorf("expected the missing renderer to be named, got %v", err)
+	}
+}
+
+func TestConvertPDF_Thumbnail(t *testing.T) {
+	installFakeGhostscript(t)
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, ThumbnailWidth: 100}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempValidPDF(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if res.Thumbnail != filepath.Join(res.OutputDir, ThumbnailFileName) {
+		t.Fatalf("expected a thumbnail in the output directory, got %q", res.Thumbnail)
+	}
+	thumbnail, err := imaging.Open(res.Thumbnail)
+	if err != nil || thumbnail.Bounds().Dx() != 100 || thumbnail.Bounds().Dy() != 150 {
+		t.Errorf("expected a 100x150 thumbnail, got %v %v", err, thumbnail)
+	}
+
+	// A missing renderer leaves out the thumbnail without failing the conversion
+	t.Setenv("PATH", t.TempDir())
+	res, err = conv.ConvertPDF(createTempValidPDF(t), t.TempDir())
+	if err != nil || res.Thumbnail != "" {
+		t.Errorf("expected a conversion without thumbnail, got %v %q", err, res.Thumbnail)
+	}
+}
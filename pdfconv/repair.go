@@ -0,0 +1,366 @@
+// Package pdfconv - Malformed PDF repair.
+// This file rebuilds the cross-reference data of PDFs whose xref table or trailer is missing,
+// truncated or points at the wrong offsets, by scanning the file for object definitions.
+package pdfconv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Patterns used to scan a malformed PDF
+var (
+	objDefPattern   = regexp.MustCompile(`(\d+)[\x00\t\n\f\r ]+(\d+)[\x00\t\n\f\r ]+obj\b`)
+	rootRefPattern  = regexp.MustCompile(`/Root\s*(\d+)\s+(\d+)\s+R`)
+	infoRefPattern  = regexp.MustCompile(`/Info\s*(\d+)\s+(\d+)\s+R`)
+	encryptPattern  = regexp.MustCompile(`/Encrypt\s*(\d+)\s+(\d+)\s+R`)
+	idArrayPattern  = regexp.MustCompile(`/ID\s*\[\s*<[0-9A-Fa-f\s]*>\s*<[0-9A-Fa-f\s]*>\s*\]`)
+	catalogPattern  = regexp.MustCompile(`/Type\s*/Catalog\b`)
+	objStmPattern   = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	intEntryPattern = regexp.MustCompile(`/(First|Length)\s*(\d+)(?:\s+(\d+)\s+R)?`)
+	intObjPattern   = regexp.MustCompile(`^obj\s*(\d+)`)
+	validPDFHeader  = regexp.MustCompile(`^%PDF-1\.[0-7][\r\n]`)
+)
+
+// openPDF opens a PDF for conversion. When the file cannot be opened, or opens but its page
+// tree cannot be read, the cross-reference data is rebuilt in memory with repairPDF and the
+// repaired copy is used instead. The returned function closes the file; repaired reports
+// whether repair was applied.
+func (c *PDFConverter) openPDF(pdfPath string) (*pdf.Reader, func(), bool, error) {
+	file, reader, err := safeOpenPDF(pdfPath)
+	if err == nil {
+		if err = probePDF(reader); err == nil {
+			return reader, func() { file.Close() }, false, nil
+		}
+		file.Close()
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) || errors.Is(err, pdf.ErrInvalidPassword) {
+		return nil, nil, false, classifyOpenError(err)
+	}
+
+	data, readErr := os.ReadFile(pdfPath)
+	if readErr != nil {
+		return nil, nil, false, classifyOpenError(err)
+	}
+	repaired, repairErr := repairPDF(data)
+	if repairErr != nil {
+		return nil, nil, false, fmt.Errorf("%w (repair failed: %v)", classifyOpenError(err), repairErr)
+	}
+	reader, repairErr = safeNewReader(repaired)
+	if repairErr == nil {
+		repairErr = probePDF(reader)
+	}
+	if repairErr != nil {
+		return nil, nil, false, fmt.Errorf("%w (repair failed: %v)", classifyOpenError(err), repairErr)
+	}
+	c.logger.Warn("PDF %s is malformed (%v); rebuilt its cross-reference table", pdfPath, err)
+	return reader, func() {}, true, nil
+}
+
+// safeOpenPDF opens a PDF file, converting panics in the PDF library into errors.
+func safeOpenPDF(pdfPath string) (file *os.File, reader *pdf.Reader, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	return pdf.Open(pdfPath)
+}
+
+// safeNewReader opens an in-memory PDF, converting panics in the PDF library into errors.
+func safeNewReader(data []byte) (reader *pdf.Reader, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	return pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// probePDF checks that the document catalog and first page can be loaded, which fails for
+// files whose xref offsets do not point at the objects they name.
+func probePDF(reader *pdf.Reader) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	if reader.NumPage() < 1 {
+		return fmt.Errorf("malformed PDF: page tree not found")
+	}
+	if reader.Page(1).V.Kind() != pdf.Dict {
+		return fmt.Errorf("malformed PDF: first page not found")
+	}
+	return nil
+}
+
+// xrefEntry locates an object either directly in the file (type 1) or inside an object
+// stream (type 2), as in a PDF cross-reference stream.
+type xrefEntry struct {
+	kind   byte
+	field2 int // Byte offset, or object stream number
+	field3 int // Generation, or index within the object stream
+}
+
+// repairPDF rebuilds the cross-reference data of a malformed PDF. Every "N G obj" definition
+// is located by scanning the file, with later definitions winning as in incremental updates,
+// and objects packed in object streams are listed from the stream headers. The result is the
+// original file with a new cross-reference stream and trailer appended, so it can be read
+// from its final startxref.
+func repairPDF(data []byte) ([]byte, error) {
+	start := bytes.Index(data[:min(len(data), 1024)], []byte("%PDF-"))
+	if start < 0 {
+		return nil, fmt.Errorf("no PDF header found")
+	}
+	data = data[start:]
+	if !validPDFHeader.Match(data) {
+		// Keep the original header line as a comment behind one the reader accepts
+		data = append([]byte("%PDF-1.7\n%"), data[5:]...)
+	}
+
+	entries := make(map[int]xrefEntry)
+	var objStms []int
+	for _, m := range objDefPattern.FindAllSubmatchIndex(data, -1) {
+		if m[0] > 0 && !isPDFDelimiter(data[m[0]-1]) {
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		gen, _ := strconv.Atoi(string(data[m[4]:m[5]]))
+		entries[num] = xrefEntry{kind: 1, field2: m[0], field3: gen}
+		if objStmPattern.Match(objectHeader(data, m[1])) {
+			objStms = append(objStms, num)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no objects found")
+	}
+
+	// Objects packed in object streams, and catalogs found inside them
+	catalog := -1
+	for _, num := range objStms {
+		content, first, err := objectStreamContent(data, entries, num)
+		if err != nil {
+			continue
+		}
+		index := objectStreamIndex(content, first)
+		for i, obj := range index {
+			if _, ok := entries[obj.num]; !ok {
+				entries[obj.num] = xrefEntry{kind: 2, field2: num, field3: i}
+			}
+			end := len(content)
+			if i+1 < len(index) {
+				end = first + index[i+1].offset
+			}
+			if begin := first + obj.offset; begin < end && end <= len(content) && catalogPattern.Match(content[begin:end]) {
+				catalog = obj.num
+			}
+		}
+	}
+
+	root := lastReference(data, rootRefPattern, entries)
+	if root == "" {
+		if loc := catalogPattern.FindAllIndex(data, -1); len(loc) > 0 {
+			catalog = enclosingObject(entries, loc[len(loc)-1][0])
+		}
+		if catalog < 0 {
+			return nil, fmt.Errorf("document catalog not found")
+		}
+		gen := 0
+		if entries[catalog].kind == 1 {
+			gen = entries[catalog].field3
+		}
+		root = fmt.Sprintf("%d %d R", catalog, gen)
+	}
+
+	maxNum := 0
+	for num := range entries {
+		maxNum = max(maxNum, num)
+	}
+	xrefNum := maxNum + 1
+	size := xrefNum + 1
+
+	var out bytes.Buffer
+	out.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		out.WriteByte('\n')
+	}
+	xrefOffset := out.Len()
+	entries[xrefNum] = xrefEntry{kind: 1, field2: xrefOffset}
+
+	var table bytes.Buffer
+	for num := 0; num < size; num++ {
+		entry, ok := entries[num]
+		if !ok || num == 0 {
+			entry = xrefEntry{}
+			if num == 0 {
+				entry.field3 = 65535
+			}
+		}
+		table.WriteByte(entry.kind)
+		binary.Write(&table, binary.BigEndian, uint32(entry.field2))
+		binary.Write(&table, binary.BigEndian, uint32(entry.field3))
+	}
+
+	trailer := fmt.Sprintf("/Type /XRef /Size %d /W [1 4 4] /Root %s", size, root)
+	if info := lastReference(data, infoRefPattern, entries); info != "" {
+		trailer += " /Info " + info
+	}
+	if enc := lastReference(data, encryptPattern, entries); enc != "" {
+		trailer += " /Encrypt " + enc
+		if ids := idArrayPattern.FindAll(data, -1); len(ids) > 0 {
+			trailer += " " + string(ids[len(ids)-1])
+		}
+	}
+	fmt.Fprintf(&out, "%d 0 obj\n<< %s /Length %d >>\nstream\n", xrefNum, trailer, table.Len())
+	out.Write(table.Bytes())
+	fmt.Fprintf(&out, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return out.Bytes(), nil
+}
+
+// isPDFDelimiter reports whether b can precede an object definition.
+func isPDFDelimiter(b byte) bool {
+	switch b {
+	case 0, '\t', '\n', '\f', '\r', ' ', '>', ']', ')', '}':
+		return true
+	}
+	return false
+}
+
+// objectHeader returns the part of an object after "obj" up to its stream data or end.
+func objectHeader(data []byte, from int) []byte {
+	end := len(data)
+	for _, kw := range []string{"stream", "endobj"} {
+		if i := bytes.Index(data[from:], []byte(kw)); i >= 0 && from+i < end {
+			end = from + i
+		}
+	}
+	return data[from:end]
+}
+
+// objectStreamContent returns the decoded content of an object stream and its /First offset.
+func objectStreamContent(data []byte, entries map[int]xrefEntry, num int) ([]byte, int, error) {
+	start := entries[num].field2
+	header := objectHeader(data, start)
+	streamStart := start + len(header)
+	if !bytes.HasPrefix(data[streamStart:], []byte("stream")) {
+		return nil, 0, fmt.Errorf("object %d has no stream data", num)
+	}
+	streamStart += len("stream")
+	if bytes.HasPrefix(data[streamStart:], []byte("\r\n")) {
+		streamStart += 2
+	} else if streamStart < len(data) && (data[streamStart] == '\n' || data[streamStart] == '\r') {
+		streamStart++
+	}
+
+	first, length := -1, -1
+	for _, m := range intEntryPattern.FindAllSubmatch(header, -1) {
+		v, _ := strconv.Atoi(string(m[2]))
+		switch string(m[1]) {
+		case "First":
+			first = v
+		case "Length":
+			if len(m[3]) > 0 {
+				v = indirectInt(data, entries, v)
+			}
+			length = v
+		}
+	}
+	if first < 0 {
+		return nil, 0, fmt.Errorf("object stream %d has no /First", num)
+	}
+	// Fall back to the endstream keyword when /Length is missing or wrong
+	if length < 0 || streamStart+length > len(data) || !bytes.HasPrefix(bytes.TrimLeft(data[streamStart+length:], "\r\n"), []byte("endstream")) {
+		end := bytes.Index(data[streamStart:], []byte("endstream"))
+		if end < 0 {
+			return nil, 0, fmt.Errorf("object stream %d is truncated", num)
+		}
+		length = len(bytes.TrimRight(data[streamStart:streamStart+end], "\r\n"))
+	}
+
+	raw := data[streamStart : streamStart+length]
+	if !bytes.Contains(header, []byte("/FlateDecode")) {
+		return raw, first, nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer zr.Close()
+	// A damaged stream can still yield the header and the objects before the damage
+	content, err := io.ReadAll(zr)
+	if len(content) == 0 {
+		return nil, 0, err
+	}
+	return content, first, nil
+}
+
+// objStmObject is an entry of an object stream header.
+type objStmObject struct {
+	num    int
+	offset int
+}
+
+// objectStreamIndex parses the "number offset" pairs at the start of an object stream.
+func objectStreamIndex(content []byte, first int) []objStmObject {
+	if first > len(content) {
+		first = len(content)
+	}
+	fields := bytes.Fields(content[:first])
+	var index []objStmObject
+	for i := 0; i+1 < len(fields); i += 2 {
+		num, err1 := strconv.Atoi(string(fields[i]))
+		offset, err2 := strconv.Atoi(string(fields[i+1]))
+		if err1 != nil || err2 != nil {
+			break
+		}
+		index = append(index, objStmObject{num: num, offset: offset})
+	}
+	return index
+}
+
+// indirectInt returns the integer value of a directly stored object, or -1.
+func indirectInt(data []byte, entries map[int]xrefEntry, num int) int {
+	entry, ok := entries[num]
+	if !ok || entry.kind != 1 {
+		return -1
+	}
+	m := intObjPattern.FindSubmatch(bytes.TrimLeft(data[entry.field2:], "0123456789 \t\r\n"))
+	if m == nil {
+		return -1
+	}
+	v, _ := strconv.Atoi(string(m[1]))
+	return v
+}
+
+// lastReference returns the last "N G R" reference matched by pattern whose object exists.
+func lastReference(data []byte, pattern *regexp.Regexp, entries map[int]xrefEntry) string {
+	matches := pattern.FindAllSubmatch(data, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		num, _ := strconv.Atoi(string(matches[i][1]))
+		if _, ok := entries[num]; ok {
+			return fmt.Sprintf("%s %s R", matches[i][1], matches[i][2])
+		}
+	}
+	return ""
+}
+
+// enclosingObject returns the directly stored object whose definition starts last before pos.
+func enclosingObject(entries map[int]xrefEntry, pos int) int {
+	best, bestOffset := -1, -1
+	for num, entry := range entries {
+		if entry.kind == 1 && entry.field2 <= pos && entry.field2 > bestOffset {
+			best, bestOffset = num, entry.field2
+		}
+	}
+	return best
+}
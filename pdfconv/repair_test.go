@@ -0,0 +1,120 @@
+package pdfconv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestConvertPDF_RepairsMalformedPDF(t *testing.T) {
+	original, err := os.ReadFile(createTempValidPDF(t))
+	if err != nil {
+		t.Fatalf("failed to read test pdf: %v", err)
+	}
+	last := bytes.LastIndex(original, []byte("startxref"))
+	cases := map[string][]byte{
+		// startxref points into the middle of the file
+		"bad startxref": append(append([]byte{}, original[:last]...), []byte("startxref\n10\n%%EOF\n")...),
+		// junk before the header shifts every xref offset
+		"leading junk": append([]byte("HTTP/1.1 200 OK\r\n\r\n"), original...),
+		// download cut off before the xref table
+		"truncated": original[:bytes.LastIndex(original, []byte("xref"))],
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			pdfPath := filepath.Join(t.TempDir(), "broken.pdf")
+			if err := os.WriteFile(pdfPath, data, 0644); err != nil {
+				t.Fatalf("failed to write pdf: %v", err)
+			}
+			conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+			res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+			if err != nil {
+				t.Fatalf("expected repaired conversion, got error: %v", err)
+			}
+			if !res.Repaired {
+				t.Errorf("expected result to report repair")
+			}
+			md, _ := os.ReadFile(res.MarkdownFile)
+			if !strings.Contains(string(md), "Hello, PDF") {
+				t.Errorf("expected repaired text in output, got:\n%s", md)
+			}
+			report, _ := os.ReadFile(filepath.Join(res.OutputDir, ReportFileName))
+			if !strings.Contains(string(report), `"repaired": true`) {
+				t.Errorf("expected repair in conversion report, got:\n%s", report)
+			}
+		})
+	}
+}
+
+func TestConvertPDF_ValidPDFNotRepaired(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempValidPDF(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if res.Repaired {
+		t.Errorf("expected valid PDF not to be repaired")
+	}
+}
+
+func TestConvertPDF_UnrepairablePDF(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "garbage.pdf")
+	if err := os.WriteFile(pdfPath, []byte("%PDF-1.4\nnothing to see here\n"), 0644); err != nil {
+		t.Fatalf("failed to write pdf: %v", err)
+	}
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	_, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "repair failed: no objects found") {
+		t.Fatalf("expected repair failure, got %v", err)
+	}
+}
+
+func TestRepairPDF_ObjectStreams(t *testing.T) {
+	// Catalog, page tree and page are packed in a compressed object stream and the
+	// cross-reference stream that located them is missing.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	}
+	var header, body strings.Builder
+	for i, obj := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+	var packed bytes.Buffer
+	zw := zlib.NewWriter(&packed)
+	zw.Write([]byte(header.String() + body.String()))
+	zw.Close()
+
+	var data bytes.Buffer
+	data.WriteString("%PDF-1.5\n")
+	fmt.Fprintf(&data, "4 0 obj\n<< /Type /ObjStm /N 3 /First %d /Length 5 0 R /Filter /FlateDecode >>\nstream\n", header.Len())
+	data.Write(packed.Bytes())
+	data.WriteString("\nendstream\nendobj\n")
+	fmt.Fprintf(&data, "5 0 obj\n%d\nendobj\n", packed.Len())
+	data.WriteString("startxref\n0\n%%EOF\n")
+
+	repaired, err := repairPDF(data.Bytes())
+	if err != nil {
+		t.Fatalf("repairPDF() error = %v", err)
+	}
+	reader, err := safeNewReader(repaired)
+	if err != nil {
+		t.Fatalf("failed to open repaired pdf: %v", err)
+	}
+	if err := probePDF(reader); err != nil {
+		t.Fatalf("repaired pdf unreadable: %v", err)
+	}
+	if n := reader.NumPage(); n != 1 {
+		t.Errorf("expected 1 page, got %d", n)
+	}
+}
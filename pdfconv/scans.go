@@ -0,0 +1,145 @@
+// Package pdfconv - Scanned page images as input.
+// This file converts a directory of page scans (TIFF, PNG, JPEG) into a single Markdown
+// document, one page per image, recognizing the text of each page with OCR.
+package pdfconv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// scanExtensions are the page scan file extensions accepted by ConvertImages.
+var scanExtensions = map[string]bool{".tif": true, ".tiff": true, ".png": true, ".jpg": true, ".jpeg": true}
+
+// ConvertImages converts the page scans in inputDir, ordered by file name, into one Markdown
+// document in outputBaseDir/MARKDOWN_<directory name>. Each scan becomes a page holding its
+// OCR text, when tesseract is installed, followed by the scan itself.
+func (c *PDFConverter) ConvertImages(inputDir, outputBaseDir string, opts ConversionOptions) (*ConversionResult, error) {
+	c.logger.Info("Starting scanned image conversion: %s", inputDir)
+	opts.started = time.Now()
+	if strings.TrimSpace(inputDir) == "" {
+		return nil, fmt.Errorf("input directory cannot be empty")
+	}
+	if strings.TrimSpace(outputBaseDir) == "" {
+		return nil, fmt.Errorf("output base directory cannot be empty")
+	}
+	inputDir = filepath.Clean(inputDir)
+	outputBaseDir = filepath.Clean(outputBaseDir)
+
+	scans, size, err := findScans(inputDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(scans) == 0 {
+		return nil, fmt.Errorf("no TIFF, PNG or JPEG page scans found in %s", inputDir)
+	}
+	c.logger.Info("Found %d page scans", len(scans))
+
+	if c.config.DiskSpaceCheck {
+		if err := c.checkDiskSpace(outputBaseDir, size*OutputSizeFactor); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.generateOutput(inputDir, outputBaseDir, opts, func(stagingDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+		return c.extractScanPages(opts.context(), scans, stagingDir, timings)
+	})
+}
+
+// extractScanPages converts each scan into a page of the model shared with PDF conversion.
+func (c *PDFConverter) extractScanPages(ctx context.Context, scans []string, outputDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+	ocr := ocrAvailable()
+	if !ocr {
+		c.logger.Warn("tesseract not found on PATH; page scans are converted without OCR text")
+	}
+
+	var pages []PDFPage
+	totalImages := 0
+	for i, scan := range scans {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		pageNum := i + 1
+		c.logger.Debug("Processing page %d/%d: %s", pageNum, len(scans), filepath.Base(scan))
+		page := PDFPage{Number: pageNum, Images: []PDFImage{}}
+
+		if ocr {
+			ocrStart := time.Now()
+			text, confidence, err := c.ocrImage(scan)
+			if err != nil {
+				c.logger.Warn("OCR failed for page %d: %v", pageNum, err)
+			} else {
+				page.Text, page.OCR, page.OCRConfidence = text, true, confidence
+			}
+			timings.record(phaseOCR, ocrStart)
+		}
+
+		if c.config.ExtractImages {
+			imageStart := time.Now()
+			img, err := imaging.Open(scan, imaging.AutoOrientation(true))
+			if err != nil {
+				c.logger.Warn("Failed to decode page scan %s: %v", scan, err)
+				page.ImageFailures++
+			} else {
+				page.Redactions = detectBlackouts(ctx, img, pageNum)
+				filename, err := c.saveHashedImage(img, outputDir, ImageFilePrefix)
+				if err != nil {
+					c.logger.Warn("Failed to save image of page %d: %v", pageNum, err)
+					page.ImageFailures++
+				} else {
+					imagePath := filepath.Join(outputDir, filename)
+					page.Images = append(page.Images, PDFImage{
+						Data:       img,
+						Width:      img.Bounds().Dx(),
+						Height:     img.Bounds().Dy(),
+						Filename:   filename,
+						Diagrams:   c.detectImageDiagrams(ctx, img, imagePath),
+						ObjectName: filepath.Base(scan),
+						PageScan:   true,
+					})
+					totalImages++
+				}
+			}
+			timings.record(phaseImages, imageStart)
+		}
+		pages = append(pages, page)
+	}
+	finishStart := time.Now()
+	c.finishPages(pages)
+	timings.record(phaseText, finishStart)
+	c.logger.Info("Extracted content from %d pages, %d images total", len(pages), totalImages)
+	return pages, totalImages, nil
+}
+
+// findScans returns the page scans directly in dir in natural file name order, together
+// with their total size in bytes.
+func findScans(dir string) ([]string, int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read input directory: %v", err)
+	}
+	var scans []string
+	var size int64
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !scanExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not get absolute path for %s: %v", entry.Name(), err)
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		scans = append(scans, path)
+	}
+	sort.Slice(scans, func(i, j int) bool { return naturalLess(scans[i], scans[j]) })
+	return scans, size, nil
+}
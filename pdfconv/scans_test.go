@@ -0,0 +1,97 @@
+package pdfconv
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/disintegration/imaging"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestParseTesseractTSV(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t100\t100\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t90\tPin\n" +
+		"5\t1\t1\t1\t1\t2\t0\t0\t10\t10\t80\tDescription\n" +
+		"5\t1\t1\t1\t2\t1\t0\t0\t10\t10\t70\tVDD\n" +
+		"5\t1\t1\t2\t1\t1\t0\t0\t10\t10\t60\tNotes\n"
+	text, conf := parseTesseractTSV([]byte(tsv))
+	if want := "Pin Description\nVDD\n\nNotes"; text != want {
+		t.Errorf("parseTesseractTSV() text = %q, want %q", text, want)
+	}
+	if conf < 0.749 || conf > 0.751 {
+		t.Errorf("expected mean confidence 0.75, got %f", conf)
+	}
+	if text, conf := parseTesseractTSV(nil); text != "" || conf != 0 {
+		t.Errorf("expected empty result for empty output, got %q %f", text, conf)
+	}
+}
+
+func TestParseTesseractWords(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t400\t300\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t150\t280\t80\t12\t91\tTemperature\n" +
+		"5\t1\t2\t1\t1\t1\t200\t200\t20\t10\t35\t~~\n" +
+		"5\t1\t3\t1\t1\t1\t30\t195\t12\t10\t88\t10\n"
+	words := parseTesseractWords([]byte(tsv))
+	if len(words) != 2 || words[0].Text != "Temperature" || words[0].Box != image.Rect(150, 280, 230, 292) || words[1].Text != "10" {
+		t.Errorf("expected two confident words with their bounds, got %+v", words)
+	}
+}
+
+func TestConvertImages(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"scan_10.png", "scan_2.jpg", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		if strings.HasSuffix(name, ".txt") {
+			if err := os.WriteFile(path, []byte("not a scan"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		width := 20
+		if name == "scan_10.png" {
+			width = 40
+		}
+		img := imaging.New(width, 30, color.White)
+		if err := imaging.Save(img, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertImages(dir, t.TempDir(), ConversionOptions{})
+	if err != nil {
+		t.Fatalf("ConvertImages() error = %v", err)
+	}
+	if res.PageCount != 2 || res.ImageCount != 2 {
+		t.Errorf("expected 2 pages and 2 images, got %d pages and %d images", res.PageCount, res.ImageCount)
+	}
+
+	// scan_2 precedes scan_10, so page 1 is the 20x30 JPEG re-saved as PNG
+	md, _ := os.ReadFile(res.MarkdownFile)
+	m := imageLinkPattern.FindSubmatch(md)
+	if m == nil {
+		t.Fatalf("expected page image link in Markdown:\n%s", md)
+	}
+	f, err := os.Open(filepath.Join(res.OutputDir, string(m[1])))
+	if err != nil {
+		t.Fatalf("expected page image: %v", err)
+	}
+	defer f.Close()
+	cfgImg, _, err := image.DecodeConfig(f)
+	if err != nil || cfgImg.Width != 20 || cfgImg.Height != 30 {
+		t.Errorf("unexpected page image: %v %+v", err, cfgImg)
+	}
+
+	if _, err := conv.ConvertImages(t.TempDir(), t.TempDir(), ConversionOptions{}); err == nil {
+		t.Errorf("expected error for a directory without scans")
+	}
+}
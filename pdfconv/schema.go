@@ -0,0 +1,306 @@
+// Package pdfconv - Sidecar schemas.
+// This file publishes the JSON Schemas of the JSON files written next to the converted
+// document (README.json, conversion_report.json and variants.json) and of the results.json
+// of batch conversions, and validates generated files against them, so tools consuming the
+// output can rely on a stable contract.
+package pdfconv
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// schemaFiles holds the published schemas, one per sidecar file.
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// SidecarSchemas maps the JSON files written to output directories to their schema file.
+// The page cache of incremental conversion is internal and has no published schema.
+var SidecarSchemas = map[string]string{
+	"README.json":        "document.schema.json",
+	ReportFileName:       "conversion_report.schema.json",
+	VariantsFileName:     "variants.schema.json",
+	PackageFileName:      "package.schema.json",
+	CurvesFileName:       "curves.schema.json",
+	ComplianceFileName:   "compliance.schema.json",
+	ImagesFileName:       "images.schema.json",
+	BatchResultsFileName: "results.schema.json",
+}
+
+// SchemaViolation is a value of a sidecar file that does not match its schema.
+type SchemaViolation struct {
+	File    string `json:"file"`    // Sidecar file, relative to the validated directory
+	Pointer string `json:"pointer"` // JSON Pointer to the value, "" for the whole document
+	Message string `json:"message"`
+}
+
+// String renders the violation for command output.
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s %s: %s", v.File, pointer, v.Message)
+}
+
+// SidecarSchema returns the JSON Schema of a sidecar file name, such as conversion_report.json.
+func SidecarSchema(file string) ([]byte, bool) {
+	name, ok := SidecarSchemas[file]
+	if !ok {
+		return nil, false
+	}
+	data, err := schemaFiles.ReadFile("schemas/" + name)
+	return data, err == nil
+}
+
+// ValidateSidecar checks the content of a sidecar file against its schema. file is the base
+// name of the sidecar; it is also used as the File of the violations.
+func ValidateSidecar(file string, data []byte) ([]SchemaViolation, error) {
+	name, ok := SidecarSchemas[filepath.Base(file)]
+	if !ok {
+		return nil, fmt.Errorf("no schema for %s", filepath.Base(file))
+	}
+	schema, err := loadSchema(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema for %s: %v", filepath.Base(file), err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []SchemaViolation{{File: file, Message: fmt.Sprintf("invalid JSON: %v", err)}}, nil
+	}
+	v := &schemaValidator{root: schema, file: file}
+	v.check(schema, value, "")
+	return v.violations, nil
+}
+
+// loadSchema parses a published schema file, such as conversion_report.schema.json.
+func loadSchema(name string) (map[string]any, error) {
+	data, err := schemaFiles.ReadFile("schemas/" + name)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// ValidateOutput checks every sidecar file below dir, including the section directories of
+// split documents, against its schema. It returns the violations found and the number of
+// files checked.
+func ValidateOutput(dir string) ([]SchemaViolation, int, error) {
+	var violations []SchemaViolation
+	checked := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := SidecarSchemas[d.Name()]; !ok {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		found, err := ValidateSidecar(filepath.ToSlash(rel), data)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, found...)
+		checked++
+		return nil
+	})
+	return violations, checked, err
+}
+
+// schemaValidator checks a JSON value against the subset of JSON Schema used by the
+// published schemas: type, enum, minimum, maximum, required, properties,
+// additionalProperties, items, and $ref to a local definition or another published schema.
+type schemaValidator struct {
+	root       map[string]any
+	file       string
+	violations []SchemaViolation
+}
+
+// fail records a violation at pointer.
+func (v *schemaValidator) fail(pointer, format string, args ...any) {
+	v.violations = append(v.violations, SchemaViolation{File: v.file, Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// check validates value against schema.
+func (v *schemaValidator) check(schema map[string]any, value any, pointer string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		if name, local, _ := strings.Cut(ref, "#"); name != "" {
+			v.checkPublished(name, local, value, pointer)
+			return
+		}
+		target, ok := v.resolve(ref)
+		if !ok {
+			v.fail(pointer, "unresolvable schema reference %s", ref)
+			return
+		}
+		schema = target
+	}
+	if types, ok := schema["type"]; ok && !matchesType(value, types) {
+		v.fail(pointer, "expected %s, got %s", typeNames(types), jsonTypeName(value))
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		allowed := false
+		var names []string
+		for _, option := range enum {
+			names = append(names, fmt.Sprint(option))
+			allowed = allowed || fmt.Sprint(option) == fmt.Sprint(value)
+		}
+		if !allowed {
+			v.fail(pointer, "%v is not one of %s", value, strings.Join(names, ", "))
+		}
+	}
+	switch value := value.(type) {
+	case json.Number:
+		n, _ := value.Float64()
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			v.fail(pointer, "%s is less than the minimum %g", value, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			v.fail(pointer, "%s is greater than the maximum %g", value, max)
+		}
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if _, ok := value[name.(string)]; !ok {
+					v.fail(pointer, "missing required property %q", name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := pointer + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+			if property, ok := properties[key].(map[string]any); ok {
+				v.check(property, value[key], child)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					v.fail(child, "unexpected property %q", key)
+				}
+			case map[string]any:
+				v.check(additional, value[key], child)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				v.check(items, item, fmt.Sprintf("%s/%d", pointer, i))
+			}
+		}
+	}
+}
+
+// checkPublished validates value against another published schema, or the definition at
+// the local part of the reference in it, resolving its own references within that schema.
+func (v *schemaValidator) checkPublished(name, local string, value any, pointer string) {
+	root, err := loadSchema(name)
+	if err != nil {
+		v.fail(pointer, "unresolvable schema reference %s", name)
+		return
+	}
+	other := &schemaValidator{root: root, file: v.file}
+	target := root
+	if local != "" {
+		if target, _ = other.resolve("#" + local); target == nil {
+			v.fail(pointer, "unresolvable schema reference %s#%s", name, local)
+			return
+		}
+	}
+	other.check(target, value, pointer)
+	v.violations = append(v.violations, other.violations...)
+}
+
+// resolve returns the schema a local reference such as "#/$defs/block" points to.
+func (v *schemaValidator) resolve(ref string) (map[string]any, bool) {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false
+	}
+	var node any = v.root
+	for _, name := range strings.Split(path, "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		node = object[name]
+	}
+	schema, ok := node.(map[string]any)
+	return schema, ok
+}
+
+// matchesType reports whether value has the schema type, or one of the schema types.
+func matchesType(value any, types any) bool {
+	names, ok := types.([]any)
+	if !ok {
+		names = []any{types}
+	}
+	actual := jsonTypeName(value)
+	for _, name := range names {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeNames renders a schema type for violation messages.
+func typeNames(types any) string {
+	names, ok := types.([]any)
+	if !ok {
+		return fmt.Sprint(types)
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprint(name)
+	}
+	return strings.Join(parts, " or ")
+}
+
+// jsonTypeName returns the JSON Schema type of a decoded value.
+func jsonTypeName(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(value.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
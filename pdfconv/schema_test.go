@@ -0,0 +1,124 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestValidateOutput_GeneratedSidecars(t *testing.T) {
+	pdfPath := createTempValidPDF(t)
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, OutputFormat: FormatJSON}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+
+	violations, checked, err := ValidateOutput(res.OutputDir)
+	if err != nil {
+		t.Fatalf("ValidateOutput() error = %v", err)
+	}
+	if checked != 2 || len(violations) != 0 {
+		t.Errorf("expected README.json and the report to validate, got %d file(s): %v", checked, violations)
+	}
+}
+
+func TestValidateSidecar_FullyPopulated(t *testing.T) {
+	// Every optional field set, so the schemas cannot fall behind the structs
+	score, min, max := 0.9, -40.0, 125.0
+	report := ConversionReport{
+		Source: "a.pdf", Status: StatusPartial, FailedPages: []PageFailure{{Page: 2, Reason: "text extraction failed"}}, PageCount: 2, ImageCount: 1, TableCount: 3, DiagramCount: 1, Repaired: true, ReusedPages: 1, DurationMS: 12,
+		Quality: QualityReport{Score: 87.5, TextCoverage: 1, OCRConfidence: &score, TableConfidence: &score, ImageSuccessRate: &score,
+			PagesWithoutText: []int{2}, UnreliablePages: []int{1}, LowConfidenceTables: 1,
+			Redactions:    []Redaction{{Page: 1, Section: "Pins", Kind: RedactionBox, Count: 2, Area: 0.1}},
+			DuplicateText: []DuplicateText{{Page: 1, Words: 3, Kept: DuplicateKeptFirst, KeptConfidence: 0.9, DroppedConfidence: 0.2}}},
+		BrokenLinks: []BrokenLink{{File: "README.md", Line: 3, Target: "#x", Reason: "missing anchor"}},
+		Languages:   map[string]int{"en": 120},
+		Changes: &DocumentChanges{Added: []string{"A"}, Removed: []string{"B"},
+			Modified:   []SectionChange{{Section: "C", LinesAdded: 1, LinesRemoved: 2}},
+			SpecValues: []SpecValueChange{{Section: "C", Parameter: "VDD", Column: "Max", Previous: "3.6", Current: "3.3"}}},
+		Timings: PhaseTimings{Text: 5 * time.Millisecond},
+	}
+	variants := variantsFile{Source: "a.pdf", Variants: []PartVariant{{PartNumber: "X1", Package: "QFN", Pins: 32, Temperature: "-40 °C to 125 °C",
+		TemperatureMin: &min, TemperatureMax: &max, Grade: GradeAutomotive, Attributes: map[string]string{"Flash": "64 KB"}, Pages: []int{4}}}}
+	_, blocks := parseMarkdownBlocks(formatsMarkdown)
+	document := documentFile{Source: "a.pdf", Language: "en", Blocks: blocks}
+	compliance := complianceFile{Source: "a.pdf", Qualification: GradeAutomotive, Tags: []string{ComplianceAECQ100, ComplianceUL},
+		Standards: []ComplianceStandard{{Tag: ComplianceAECQ100, Name: "AEC-Q100", Grade: "1", Temperature: "-40 °C to 125 °C", Pages: []int{1}, Sections: []string{"Features"}, Excerpt: "AEC-Q100 qualified, Grade 1"},
+			{Tag: ComplianceUL, Name: "UL", References: []string{"UL 1577", "E181974"}, Pages: []int{9}, Excerpt: "UL 1577 recognized, file E181974"}},
+		Sections: []ComplianceSection{{Title: "Certifications", Page: 9, Tags: []string{ComplianceUL}}}}
+	images := imagesFile{Source: "a.pdf", Images: []ImageFile{{File: "image_0123456789abcdef.png", Page: 1, Width: 640, Height: 480, Diagrams: 1, Placeholder: true,
+		DetectedDiagrams: []ImageDiagram{{Type: "block", Confidence: 0.8, Written: true}, {Type: "circuit", Confidence: 0.75}}}}}
+	results := BatchResults{InputDir: "in", OutputDir: "out", Portfolio: true, FileCount: 2, SuccessCount: 1, FailureCount: 1, PartialCount: 1, PageCount: 2, ImageCount: 1,
+		MinQuality: &report.Quality.Score, AverageQuality: &report.Quality.Score, Documents: []BatchDocument{{OutputDir: "out/a", MarkdownFile: "out/a/README.md", Report: report}},
+		Errors:      []ConversionError{{PDFPath: "b.pdf", Error: "document is encrypted", Code: "encrypted"}},
+		ErrataLinks: []ErrataLink{{Datasheet: "out/a/README.md", Errata: "out/e/README.md", PartNumbers: []string{"X1"}, Notes: 2}}}
+
+	for file, value := range map[string]any{ReportFileName: report, VariantsFileName: variants, "README.json": document, ComplianceFileName: compliance, ImagesFileName: images, BatchResultsFileName: results} {
+		data, _ := json.Marshal(value)
+		violations, err := ValidateSidecar(file, data)
+		if err != nil || len(violations) != 0 {
+			t.Errorf("%s: expected no violations, got %v %v", file, err, violations)
+		}
+	}
+}
+
+func TestValidateSidecar_Violations(t *testing.T) {
+	data := `{"source": "a.pdf", "page_count": -1, "image_count": 1.5, "quality": {"score": 50, "text_coverage": 1, "low_confidence_tables": 0,
+		"redactions": [{"page": 1, "kind": "stamp", "count": 1, "area": 0.1}]}, "duration_ms": 1, "extra": true}`
+	violations, err := ValidateSidecar("out/"+ReportFileName, []byte(data))
+	if err != nil {
+		t.Fatalf("ValidateSidecar() error = %v", err)
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	want := []string{
+		`out/conversion_report.json /: missing required property "timings"`,
+		`out/conversion_report.json /extra: unexpected property "extra"`,
+		`out/conversion_report.json /image_count: expected integer, got number`,
+		`out/conversion_report.json /page_count: -1 is less than the minimum 0`,
+		`out/conversion_report.json /quality/redactions/0/kind: stamp is not one of annotation, box, blackout`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected violations:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Reports nested in results.json are checked against conversion_report.schema.json
+	data = `{"input_dir": "in", "output_dir": "out", "portfolio": false, "file_count": 1, "success_count": 1, "failure_count": 0, "partial_count": 0,
+		"page_count": 1, "image_count": 0, "documents": [{"output_dir": "out/a", "markdown_file": "out/a/README.md", "report": {"source": "a.pdf"}}], "errors": []}`
+	violations, err = ValidateSidecar(BatchResultsFileName, []byte(data))
+	if err != nil || len(violations) != 5 || violations[0].String() != `results.json /documents/0/report: missing required property "page_count"` {
+		t.Errorf("expected the nested report validated, got %v %v", err, violations)
+	}
+
+	if violations, _ := ValidateSidecar(VariantsFileName, []byte("{")); len(violations) != 1 || !strings.Contains(violations[0].Message, "invalid JSON") {
+		t.Errorf("expected an invalid JSON violation, got %v", violations)
+	}
+	if _, err := ValidateSidecar(".page_cache.json", []byte("{}")); err == nil {
+		t.Error("expected an error for a file without a schema")
+	}
+}
+
+func TestSidecarSchemas_Published(t *testing.T) {
+	for file, name := range SidecarSchemas {
+		data, ok := SidecarSchema(file)
+		if !ok {
+			t.Fatalf("schema %s of %s not embedded", name, file)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(data, &schema); err != nil || schema["title"] != file {
+			t.Errorf("invalid schema %s: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join("schemas", name)); err != nil {
+			t.Errorf("schema %s not published: %v", name, err)
+		}
+	}
+}
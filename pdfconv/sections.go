@@ -0,0 +1,166 @@
+// Package pdfconv - Section numbering.
+// This file detects numbered section headings ("7.3.2 Timing Requirements"), anchors them,
+// and optionally numbers unnumbered headings. It also limits the depth of generated headings.
+package pdfconv
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// numberedHeadingPattern matches a section number followed by a capitalized title word.
+	numberedHeadingPattern = regexp.MustCompile(`^(\d{1,3}(?:\.\d{1,3}){0,5})\.?\s+(\p{Lu}\p{L}{2,}.*)$`)
+	// sectionNumberPrefix splits the leading section number from a heading.
+	sectionNumberPrefix    = regexp.MustCompile(`^(\d{1,3}(?:\.\d{1,3}){0,5})\.?\s+(.+)$`)
+	markdownHeadingPattern = regexp.MustCompile(`^(#{1,6}) (.+)$`)
+)
+
+// sectionNumbering returns the configured numbering mode, defaulting to "preserve".
+func (c *PDFConverter) sectionNumbering() string {
+	if c.config.SectionNumbering == "" {
+		return "preserve"
+	}
+	return c.config.SectionNumbering
+}
+
+// headingMarkup returns the Markdown written before and after the text of a heading at the
+// given level. Levels deeper than MAX_HEADER_DEPTH are written at that depth, or, with
+// HEADER_OVERFLOW=bold, as a bold paragraph, so no heading is deeper than h6.
+func (c *PDFConverter) headingMarkup(level int) (string, string) {
+	maxDepth := c.config.MaxHeaderDepth
+	if maxDepth == 0 {
+		maxDepth = 6
+	}
+	if level > maxDepth {
+		if c.config.HeaderOverflow == "bold" {
+			return "**", "**"
+		}
+		level = maxDepth
+	}
+	return strings.Repeat("#", level) + " ", ""
+}
+
+// heading renders text as a heading at the given level, limited as described for headingMarkup.
+func (c *PDFConverter) heading(level int, text string) string {
+	open, close := c.headingMarkup(level)
+	return open + text + close
+}
+
+// looksLikeNumberedHeading reports whether the line reads like a numbered section heading.
+// Sentences (trailing period) and long lines are rejected to avoid matching numbered list text.
+func looksLikeNumberedHeading(line string) bool {
+	if utf8.RuneCountInString(line) > DefaultHeaderLength || strings.HasSuffix(line, ".") {
+		return false
+	}
+	m := numberedHeadingPattern.FindStringSubmatch(line)
+	return m != nil && len(strings.Fields(m[2])) <= MaxHeaderWords+2
+}
+
+// sectionAnchor returns the anchor id used for a section number.
+func sectionAnchor(number string) string {
+	return "section-" + strings.ReplaceAll(number, ".", "-")
+}
+
+// numberSections post-processes generated Markdown: section headings produced by
+// formatTextContent get an explicit anchor and unnumbered headings are numbered in
+// "renumber" mode. The anchors are the targets of linkCrossReferences.
+func (c *PDFConverter) numberSections(markdown string) string {
+	mode := c.sectionNumbering()
+	if mode == "off" {
+		return markdown
+	}
+	open, close := c.headingMarkup(c.config.BaseHeaderLevel + 2)
+	lines := strings.Split(markdown, "\n")
+
+	known := map[string]bool{}
+	var current []int
+	var out []string
+	var fences fenceTracker
+	for _, line := range lines {
+		if fences.inCode(line) {
+			out = append(out, line)
+			continue
+		}
+		if len(line) <= len(open)+len(close) || !strings.HasPrefix(line, open) || !strings.HasSuffix(line, close) {
+			out = append(out, line)
+			continue
+		}
+		title := line[len(open) : len(line)-len(close)]
+		var number string
+		if sm := sectionNumberPrefix.FindStringSubmatch(title); sm != nil {
+			number = sm[1]
+			current = parseSectionNumber(number)
+		} else if mode == "renumber" {
+			current = nextSectionNumber(current)
+			number = formatSectionNumber(current)
+			title = number + " " + title
+		}
+		if number == "" || known[number] {
+			out = append(out, open+title+close)
+			continue
+		}
+		known[number] = true
+		out = append(out, fmt.Sprintf("<a id=\"%s\"></a>", sectionAnchor(number)), "")
+		out = append(out, open+title+close)
+	}
+	return strings.Join(out, "\n")
+}
+
+// fenceTracker follows fenced code blocks (such as verbatim pages) while scanning Markdown lines.
+type fenceTracker struct {
+	open string // Opening fence of the current code block, empty outside code
+}
+
+// inCode reports whether the line is a fence line or lies inside a fenced code block.
+func (f *fenceTracker) inCode(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "```") {
+		fence := strings.TrimRight(trimmed, "abcdefghijklmnopqrstuvwxyz")
+		switch {
+		case f.open == "":
+			f.open = fence
+		case trimmed == fence && strings.HasPrefix(fence, f.open):
+			f.open = ""
+		}
+		return true
+	}
+	return f.open != ""
+}
+
+// parseSectionNumber converts "7.3.2" into its numeric components.
+func parseSectionNumber(number string) []int {
+	parts := strings.Split(number, ".")
+	nums := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil
+		}
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+// nextSectionNumber returns the number following current at the same depth
+// (1 when no section has been seen yet).
+func nextSectionNumber(current []int) []int {
+	if len(current) == 0 {
+		return []int{1}
+	}
+	next := append([]int(nil), current...)
+	next[len(next)-1]++
+	return next
+}
+
+// formatSectionNumber converts numeric components back into "7.3.2" form.
+func formatSectionNumber(nums []int) string {
+	parts := make([]string, len(nums))
+	for i, n := range nums {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,92 @@
+package pdfconv
+
+import (
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestLooksLikeNumberedHeading(t *testing.T) {
+	cases := []struct {
+		line string
+		want bool
+	}{
+		{"7.3.2 Timing Requirements", true},
+		{"7 Detailed Description", true},
+		{"8.1. Application Information", true},
+		{"3.3 V supply rail", false},
+		{"1.8 volts are applied to the core.", false},
+		{"2 This pin must be tied low when the device is configured in standby mode for power saving", false},
+	}
+	for _, c := range cases {
+		if got := looksLikeNumberedHeading(c.line); got != c.want {
+			t.Errorf("looksLikeNumberedHeading(%q) = %v, want %v", c.line, got, c.want)
+		}
+	}
+}
+
+func TestNumberSections_PreserveAndLink(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, CrossReferenceLinks: true}, logger.NewLogger("error"))
+	pages := []PDFPage{
+		{Number: 1, Text: "7.3.2 Timing Requirements\nSetup time is 5 ns."},
+		{Number: 2, Text: "Use the values in Section 7.3.2 and Section 9.1."},
+		{Number: 3, Text: "Section 7.3.2", Verbatim: true},
+	}
+	md := conv.generateMarkdown(pages)
+	if !strings.Contains(md, "<a id=\"section-7-3-2\"></a>\n\n### 7.3.2 Timing Requirements") {
+		t.Errorf("expected anchored numbered heading, got:\n%s", md)
+	}
+	if !strings.Contains(md, "[Section 7.3.2](#section-7-3-2) and Section 9.1.") {
+		t.Errorf("expected known reference linked and unknown left alone, got:\n%s", md)
+	}
+	if !strings.Contains(md, "```text\nSection 7.3.2\n```") {
+		t.Errorf("references inside code fences must not be linked, got:\n%s", md)
+	}
+}
+
+func TestNumberSections_Renumber(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, SectionNumbering: "renumber", CrossReferenceLinks: true}, logger.NewLogger("error"))
+	md := conv.linkCrossReferences(conv.numberSections("# Doc\n\n### FEATURES\n\ntext\n\n### 4.2 Pinout\n\n### APPLICATIONS\n\nSee Section 4.3"))
+	for _, want := range []string{"### 1 FEATURES", "### 4.2 Pinout", "<a id=\"section-4-3\"></a>\n\n### 4.3 APPLICATIONS", "See [Section 4.3](#section-4-3)"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in:\n%s", want, md)
+		}
+	}
+
+	off, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, SectionNumbering: "off", CrossReferenceLinks: true}, logger.NewLogger("error"))
+	if got := off.linkCrossReferences(off.numberSections("### 4.2 Pinout\n\nSee Section 4.2")); strings.Contains(got, "<a id=") || strings.Contains(got, "](#") {
+		t.Errorf("numbering off should leave Markdown untouched, got:\n%s", got)
+	}
+	if off.looksLikeHeader("7.3.2 Timing requirements") {
+		t.Error("numbered heading detection should be disabled when numbering is off")
+	}
+}
+
+func TestHeadingDepthLimit(t *testing.T) {
+	pages := []PDFPage{{Number: 1, Text: "7.3.2 Timing Requirements\nSetup time is 5 ns.\nSee Section 7.3.2."}}
+
+	// Without a limit BASE_HEADER_LEVEL=5 would place section headings at h7
+	clamp, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 5, CrossReferenceLinks: true}, logger.NewLogger("error"))
+	md := clamp.generateMarkdown(pages)
+	for _, want := range []string{"###### Page 1", "<a id=\"section-7-3-2\"></a>\n\n###### 7.3.2 Timing Requirements", "[Section 7.3.2](#section-7-3-2)"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "#######") {
+		t.Errorf("expected no heading deeper than h6, got:\n%s", md)
+	}
+
+	bold, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, MaxHeaderDepth: 2, HeaderOverflow: "bold", CrossReferenceLinks: true}, logger.NewLogger("error"))
+	md = bold.generateMarkdown(pages)
+	for _, want := range []string{"## Page 1", "<a id=\"section-7-3-2\"></a>\n\n**7.3.2 Timing Requirements**", "[Section 7.3.2](#section-7-3-2)"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "### ") {
+		t.Errorf("expected headings below MAX_HEADER_DEPTH as bold paragraphs, got:\n%s", md)
+	}
+}
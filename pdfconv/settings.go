@@ -0,0 +1,243 @@
+// Package pdfconv - Per-document settings files.
+// This file reads the optional settings file next to an input document, named after it with
+// a .yaml suffix (LM317.pdf.yaml for LM317.pdf), which overrides conversion settings for
+// that document alone: the pages to convert, the OCR language and patterns of lines to
+// remove. Archives that mix datasheets of many vendors can so be converted in one batch.
+// The file is YAML restricted to top-level keys with scalar or list values:
+//
+//	# Skip the ordering information at the end
+//	pages: 1-24
+//	ocr_language: eng+jpn
+//	strip_patterns:
+//	  - '^Copyright .* Vendor Inc\.'
+//	  - 'www\.vendor\.com'
+package pdfconv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"datasheet-to-md-mcp/config"
+)
+
+// documentSettingsSuffix is appended to the file name of a document to name its settings file.
+const documentSettingsSuffix = ".yaml"
+
+// documentSettings are the overrides of a document settings file. Unset fields keep the
+// configured behavior.
+type documentSettings struct {
+	Pages         PageSelection    // Pages to convert, nil for all
+	OCRLanguage   string           // Tesseract language(s) overriding OCR_LANGUAGE, "" for the configured one
+	StripPatterns []*regexp.Regexp // Lines matching any of these are removed from the text
+}
+
+// loadDocumentSettings reads the settings file of the document at docPath. It returns nil
+// settings and no error when the document has none.
+func loadDocumentSettings(docPath string) (*documentSettings, error) {
+	path := docPath + documentSettingsSuffix
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document settings: %v", err)
+	}
+	settings, err := parseDocumentSettings(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid document settings %s: %v", path, err)
+	}
+	return settings, nil
+}
+
+// parseDocumentSettings parses the content of a document settings file.
+func parseDocumentSettings(content string) (*documentSettings, error) {
+	values, err := parseSettingsYAML(content)
+	if err != nil {
+		return nil, err
+	}
+	settings := &documentSettings{}
+	for key, value := range values {
+		switch key {
+		case "pages":
+			if settings.Pages, err = ParsePageSelection(strings.Join(value, ",")); err != nil {
+				return nil, fmt.Errorf("pages: %v", err)
+			}
+		case "ocr_language":
+			if len(value) != 1 || !config.IsOCRLanguage(value[0]) {
+				return nil, fmt.Errorf("ocr_language must be Tesseract language codes joined by '+', got %q", strings.Join(value, ", "))
+			}
+			settings.OCRLanguage = value[0]
+		case "strip_patterns":
+			for _, pattern := range value {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("strip_patterns: %v", err)
+				}
+				settings.StripPatterns = append(settings.StripPatterns, re)
+			}
+		default:
+			return nil, fmt.Errorf("unknown setting %q (supported: pages, ocr_language, strip_patterns)", key)
+		}
+	}
+	return settings, nil
+}
+
+// parseSettingsYAML parses YAML made of top-level "key: value" lines, where the value is a
+// scalar, a flow list such as [a, b] or, when empty, a block list of "- item" lines below
+// the key. Scalars may be single or double quoted. Each key maps to its list of values.
+func parseSettingsYAML(content string) (map[string][]string, error) {
+	values := map[string][]string{}
+	var listKey string // Key whose block list is being read
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimRight(yamlComment(scanner.Text()), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "-"); ok && listKey != "" && (item == "" || item[0] == ' ') {
+			value, err := yamlScalar(strings.TrimSpace(item))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			values[listKey] = append(values[listKey], value)
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("line %d: unexpected indentation", lineNum)
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNum)
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNum, key)
+		}
+		value = strings.TrimSpace(value)
+		listKey = ""
+		switch {
+		case value == "":
+			listKey = key
+			values[key] = []string{}
+		case strings.HasPrefix(value, "["):
+			if !strings.HasSuffix(value, "]") {
+				return nil, fmt.Errorf("line %d: unterminated list", lineNum)
+			}
+			values[key] = []string{}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				scalar, err := yamlScalar(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", lineNum, err)
+				}
+				values[key] = append(values[key], scalar)
+			}
+		default:
+			scalar, err := yamlScalar(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			values[key] = []string{scalar}
+		}
+	}
+	return values, scanner.Err()
+}
+
+// yamlComment removes a comment from a line: a '#' at the start or after whitespace that is
+// not inside quotes.
+func yamlComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// yamlScalar returns the value of a plain, single quoted or double quoted scalar.
+func yamlScalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, `"`):
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", s)
+		}
+		return value, nil
+	}
+	return s, nil
+}
+
+// withDocumentSettings applies the settings file of the document at docPath, if any, to a
+// converter and the conversion options for that document.
+func (c *PDFConverter) withDocumentSettings(docPath string, opts ConversionOptions) (*PDFConverter, ConversionOptions, error) {
+	settings, err := loadDocumentSettings(docPath)
+	if err != nil || settings == nil {
+		return c, opts, err
+	}
+	c.logger.Info("Applying document settings from %s", docPath+documentSettingsSuffix)
+	if settings.Pages != nil && opts.Pages == nil {
+		opts.Pages = settings.Pages
+	}
+	if settings.OCRLanguage == "" && settings.StripPatterns == nil {
+		return c, opts, nil
+	}
+	cfg := *c.config
+	if settings.OCRLanguage != "" {
+		cfg.OCRLanguage = settings.OCRLanguage
+	}
+	conv := c.withConfig(&cfg)
+	conv.stripPatterns = settings.StripPatterns
+	return conv, opts, nil
+}
+
+// stripLines empties the lines of the pages that match a strip pattern. Table rows are left
+// alone.
+func (c *PDFConverter) stripLines(pages []PDFPage) {
+	if len(c.stripPatterns) == 0 {
+		return
+	}
+	removed := 0
+	for i := range pages {
+		if pages[i].Failure != "" {
+			continue
+		}
+		for v, view := range lineViews(&pages[i]) {
+			for j, text := range view.texts {
+				if view.fixed[j] || strings.TrimSpace(text) == "" {
+					continue
+				}
+				for _, re := range c.stripPatterns {
+					if re.MatchString(text) {
+						view.texts[j] = ""
+						if v == 0 {
+							removed++ // Positioned lines repeat the text lines
+						}
+						break
+					}
+				}
+			}
+			view.save()
+		}
+	}
+	c.logger.Debug("Removed %d line(s) matching the strip patterns", removed)
+}
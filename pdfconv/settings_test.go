@@ -0,0 +1,120 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestParseDocumentSettings(t *testing.T) {
+	settings, err := parseDocumentSettings(`---
+# Per-document overrides
+pages: 1-3,5   # trailing comment
+ocr_language: "eng+deu"
+strip_patterns:
+  - '^Copyright .* Vendor''s Inc\.'
+  - "www\\.vendor\\.com"
+  - Rev # plain scalar
+`)
+	if err != nil {
+		t.Fatalf("parseDocumentSettings() error = %v", err)
+	}
+	if settings.Pages.String() != "1-3,5" || settings.OCRLanguage != "eng+deu" || len(settings.StripPatterns) != 3 {
+		t.Fatalf("unexpected settings: pages %s, ocr_language %q, %d patterns", settings.Pages, settings.OCRLanguage, len(settings.StripPatterns))
+	}
+	want := []string{`^Copyright .* Vendor's Inc\.`, `www\.vendor\.com`, "Rev"}
+	for i, re := range settings.StripPatterns {
+		if re.String() != want[i] {
+			t.Errorf("strip pattern %d = %q; want %q", i, re, want[i])
+		}
+	}
+
+	settings, err = parseDocumentSettings("pages: [2, 4-]\nstrip_patterns: ['#\\d+']\n")
+	if err != nil || settings.Pages.String() != "2,4-" || len(settings.StripPatterns) != 1 || settings.StripPatterns[0].String() != `#\d+` {
+		t.Errorf("expected flow lists, got %+v, %v", settings, err)
+	}
+
+	for _, content := range []string{
+		"page: 1-3",
+		"pages: 0",
+		"ocr_language: eng deu",
+		"strip_patterns:\n  - '(unclosed'",
+		"pages: 1\npages: 2",
+		"  pages: 1",
+		"pages",
+		"strip_patterns: [a, b",
+		"ocr_language: 'eng",
+	} {
+		if _, err := parseDocumentSettings(content); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
+
+func TestConvertDocument_DocumentSettings(t *testing.T) {
+	dir := t.TempDir()
+	pdfPath := filepath.Join(dir, "datasheet.pdf")
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	for _, text := range []string{"Electrical characteristics", "Pin configuration", "Ordering information"} {
+		pdf.AddPage()
+		pdf.Cell(40, 10, text)
+		pdf.Ln(10)
+		pdf.Cell(40, 10, "www.vendor.com confidential")
+	}
+	if err := pdf.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create pdf: %v", err)
+	}
+	settings := "pages: 1-2\nocr_language: jpn\nstrip_patterns:\n  - 'www\\.vendor\\.com'\n"
+	if err := os.WriteFile(pdfPath+documentSettingsSuffix, []byte(settings), 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{OCRLanguage: "eng"}, logger.NewLogger("error"))
+	batch, err := conv.ConvertPDFsInDirectory(dir, t.TempDir())
+	if err != nil || batch.SuccessCount != 1 {
+		t.Fatalf("ConvertPDFsInDirectory() = %+v, %v", batch, err)
+	}
+	result := batch.Results[0]
+	data, err := os.ReadFile(result.MarkdownFile)
+	if err != nil {
+		t.Fatalf("failed to read markdown: %v", err)
+	}
+	markdown := string(data)
+	if result.PageCount != 2 || !strings.Contains(markdown, "Pin configuration") || strings.Contains(markdown, "Ordering information") {
+		t.Errorf("expected pages 1-2 only, got %d pages:\n%s", result.PageCount, markdown)
+	}
+	if strings.Contains(markdown, "vendor.com") {
+		t.Errorf("expected the strip pattern lines removed:\n%s", markdown)
+	}
+	if conv.config.OCRLanguage != "eng" {
+		t.Errorf("expected the settings file to leave the converter's configuration alone, got %q", conv.config.OCRLanguage)
+	}
+	withSettings, _, err := conv.withDocumentSettings(pdfPath, ConversionOptions{})
+	if err != nil || withSettings.config.OCRLanguage != "jpn" {
+		t.Errorf("expected ocr_language to override OCR_LANGUAGE, got %v", err)
+	}
+
+	if err := os.WriteFile(pdfPath+documentSettingsSuffix, []byte("pages: 7-9\n"), 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	if _, err := conv.ConvertDocument(pdfPath, t.TempDir(), ConversionOptions{}); err == nil || !strings.Contains(err.Error(), "matches no page") {
+		t.Errorf("expected an error for a selection outside the document, got %v", err)
+	}
+	// Pages selected by the caller take precedence over the settings file
+	if result, err := conv.ConvertDocument(pdfPath, t.TempDir(), ConversionOptions{Pages: PageSelection{{Start: 3, End: 3}}}); err != nil || result.PageCount != 1 {
+		t.Errorf("expected the selected page converted, got %+v, %v", result, err)
+	}
+	if err := os.WriteFile(pdfPath+documentSettingsSuffix, []byte("dpi: 300\n"), 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	if _, err := conv.ConvertDocument(pdfPath, t.TempDir(), ConversionOptions{}); err == nil || !strings.Contains(err.Error(), "datasheet.pdf.yaml") {
+		t.Errorf("expected an error naming the settings file, got %v", err)
+	}
+}
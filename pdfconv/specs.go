@@ -0,0 +1,106 @@
+// Package pdfconv - Specification table formatting.
+// This file recognizes datasheet parameter tables with min/typ/max columns and normalizes
+// their numeric values and units so converted tables read consistently.
+package pdfconv
+
+import (
+	"regexp"
+	"strings"
+)
+
+// specColumnKinds maps normalized header labels to their spec column kind.
+var specColumnKinds = map[string]string{
+	"MIN": "min", "MINIMUM": "min",
+	"TYP": "typ", "TYPICAL": "typ", "NOM": "typ", "NOMINAL": "typ",
+	"MAX": "max", "MAXIMUM": "max",
+	"UNIT": "unit", "UNITS": "unit",
+}
+
+// specUnitReplacements rewrites common ASCII spellings of units to their conventional form.
+var specUnitReplacements = map[string]string{
+	"uA": "µA", "uV": "µV", "uF": "µF", "uH": "µH", "uW": "µW", "us": "µs", "uS": "µs", "uJ": "µJ",
+	"ohm": "Ω", "Ohm": "Ω", "ohms": "Ω", "Ohms": "Ω", "OHM": "Ω",
+	"kohm": "kΩ", "kOhm": "kΩ", "Kohm": "kΩ", "KOhm": "kΩ", "Mohm": "MΩ", "MOhm": "MΩ",
+	"degC": "°C", "deg C": "°C", "oC": "°C", "ºC": "°C",
+	"KHz": "kHz", "khz": "kHz", "MHZ": "MHz", "Mhz": "MHz", "mhz": "MHz", "GHZ": "GHz", "Ghz": "GHz", "HZ": "Hz", "hz": "Hz",
+	"mv": "mV", "ma": "mA", "mw": "mW",
+}
+
+var (
+	// specValuePattern matches a numeric spec value with an optional sign and trailing unit.
+	specValuePattern = regexp.MustCompile(`^([±+-]?)\s*(\d+(?:\.\d+)?)\s*([^\d\s].*)?$`)
+	// specMinusSigns are the dash characters PDFs use for negative numbers.
+	specMinusSigns = strings.NewReplacer("−", "-", "–", "-", "‒", "-", "—", "-")
+)
+
+// specColumns returns the spec column kind for each header cell, or nil when the table
+// has no min/typ/max column.
+func specColumns(header []string) []string {
+	kinds := make([]string, len(header))
+	found := false
+	for i, cell := range header {
+		label := strings.ToUpper(strings.Trim(strings.TrimSpace(cell), ".()[]"))
+		kinds[i] = specColumnKinds[label]
+		if kinds[i] == "min" || kinds[i] == "typ" || kinds[i] == "max" {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return kinds
+}
+
+// formatSpecTable normalizes the values of a min/typ/max table in place: minus signs and
+// spacing of numbers, units in value and unit columns, and optionally bold typical values.
+// Tables without spec columns are left unchanged.
+func (c *PDFConverter) formatSpecTable(table *PDFTable) {
+	kinds := specColumns(table.Header)
+	if kinds == nil {
+		return
+	}
+	for _, row := range table.Rows {
+		for i, kind := range kinds {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			switch kind {
+			case "min", "typ", "max":
+				row[i] = normalizeSpecValue(row[i])
+				if kind == "typ" && c.config.BoldTypValues {
+					row[i] = "**" + row[i] + "**"
+				}
+			case "unit":
+				row[i] = normalizeSpecUnit(row[i])
+			}
+		}
+	}
+}
+
+// normalizeSpecValue formats a spec value as "<sign><number> <unit>", e.g. "– 40 degC" as "-40 °C".
+// Values that are not numeric (such as "See Note 3") are only trimmed.
+func normalizeSpecValue(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.ContainsAny(value, "0123456789") {
+		return value // dashes alone mark an unspecified limit
+	}
+	value = specMinusSigns.Replace(value)
+	m := specValuePattern.FindStringSubmatch(value)
+	if m == nil {
+		return value
+	}
+	result := m[1] + m[2]
+	if unit := strings.TrimSpace(m[3]); unit != "" {
+		result += " " + normalizeSpecUnit(unit)
+	}
+	return result
+}
+
+// normalizeSpecUnit rewrites ASCII unit spellings ("uA", "kohm", "degC") to their usual symbols.
+func normalizeSpecUnit(unit string) string {
+	unit = strings.TrimSpace(unit)
+	if replacement, ok := specUnitReplacements[unit]; ok {
+		return replacement
+	}
+	return unit
+}
@@ -0,0 +1,52 @@
+package pdfconv
+
+import (
+	"reflect"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestNormalizeSpecValue(t *testing.T) {
+	cases := map[string]string{
+		"– 40":       "-40",
+		"−0.3V":      "-0.3 V",
+		"3.3 V":      "3.3 V",
+		"125degC":    "125 °C",
+		"10 uA":      "10 µA",
+		"± 2":        "±2",
+		"—":          "—",
+		"See Note 3": "See Note 3",
+	}
+	for in, want := range cases {
+		if got := normalizeSpecValue(in); got != want {
+			t.Errorf("normalizeSpecValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatSpecTable(t *testing.T) {
+	table := PDFTable{
+		Header: []string{"Parameter", "Min", "Typ", "Max", "Unit"},
+		Rows: [][]string{
+			{"Supply current", "", "12", "20", "uA"},
+			{"Operating temperature", "– 40", "", "125", "degC"},
+		},
+	}
+	conv, _ := NewPDFConverter(&config.Config{BoldTypValues: true}, logger.NewLogger("error"))
+	conv.formatSpecTable(&table)
+	want := [][]string{
+		{"Supply current", "", "**12**", "20", "µA"},
+		{"Operating temperature", "-40", "", "125", "°C"},
+	}
+	if !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("formatSpecTable() rows = %v, want %v", table.Rows, want)
+	}
+
+	plain := PDFTable{Header: []string{"Pin", "Name"}, Rows: [][]string{{"1", "uA"}}}
+	conv.formatSpecTable(&plain)
+	if plain.Rows[0][1] != "uA" {
+		t.Errorf("tables without min/typ/max columns must be left unchanged, got %v", plain.Rows)
+	}
+}
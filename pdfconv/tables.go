@@ -0,0 +1,346 @@
+// Package pdfconv - Table reconstruction.
+// This file detects tables in the positioned text lines of a page, renders them as Markdown
+// tables, and merges tables that continue across page breaks into a single table.
+package pdfconv
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Table detection limits
+const (
+	MinTableRows        = 2  // Minimum number of rows (including the header) for a table
+	MaxTableCellAverage = 40 // Maximum average cell length; longer cells are multi-column prose
+	TableColumnSnap     = 8  // Distance in points within which cell X positions share a column
+)
+
+var (
+	// tableCaptionPattern matches a table caption line and captures the table number.
+	tableCaptionPattern = regexp.MustCompile(`^Table\s+(\d{1,4}(?:[-.]\d{1,4})?)\b`)
+	// tableContinuedPattern matches the markers used on continuation pages of a table.
+	tableContinuedPattern = regexp.MustCompile(`(?i)\((?:continued|cont'?d\.?|cont\.)\)|\b(?:continued)\b\s*$`)
+)
+
+// PDFTable is a table reconstructed from the positioned text lines of a page.
+type PDFTable struct {
+	Caption     string     // Caption line directly above the table, if any
+	CaptionLine int        // Index of the caption in PDFPage.Lines, -1 when there is none
+	FirstLine   int        // Index of the header row in PDFPage.Lines
+	LastLine    int        // Index of the last row in PDFPage.Lines
+	Header      []string   // Header row cells
+	Rows        [][]string // Body rows, each with len(Header) cells
+	Raw         []string   // Original row text with cells separated by wide spaces
+	Top         float64    // Top Y coordinate of the header row in points
+	Bottom      float64    // Bottom Y coordinate of the last row in points
+	Confidence  float64    // Reconstruction confidence between 0.0 and 1.0
+	Fallback    bool       // Whether confidence is too low to trust the reconstructed cells
+	Image       string     // Filename of the cropped table image used as fallback, if rendered
+	Merged      bool       // Whether the rows were merged into a table on the previous page
+}
+
+// detectTables finds runs of consecutive multi-cell lines and reconstructs them as tables.
+func detectTables(lines []TextLine) []PDFTable {
+	var tables []PDFTable
+	for i := 0; i < len(lines); {
+		if len(lines[i].Cells) < 2 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(lines) && len(lines[j].Cells) >= 2 {
+			j++
+		}
+		if table, ok := buildTable(lines, i, j-1); ok {
+			tables = append(tables, table)
+		}
+		i = j
+	}
+	return tables
+}
+
+// buildTable reconstructs the table spanning lines[first..last] and reports whether the
+// block looks like tabular data rather than multi-column prose.
+func buildTable(lines []TextLine, first, last int) (PDFTable, bool) {
+	if last-first+1 < MinTableRows {
+		return PDFTable{}, false
+	}
+	cells, totalLen := 0, 0
+	var xs []float64
+	for _, line := range lines[first : last+1] {
+		for _, cell := range line.Cells {
+			cells++
+			totalLen += len(cell.Text)
+			xs = append(xs, cell.X)
+		}
+	}
+	if totalLen/cells > MaxTableCellAverage {
+		return PDFTable{}, false
+	}
+
+	// Cluster cell start positions into column boundaries
+	sort.Float64s(xs)
+	var columns []float64
+	for _, x := range xs {
+		if len(columns) == 0 || x-columns[len(columns)-1] > TableColumnSnap {
+			columns = append(columns, x)
+		}
+	}
+
+	rows := make([][]string, 0, last-first+1)
+	raw := make([]string, 0, last-first+1)
+	collisions, filled := 0, 0
+	for _, line := range lines[first : last+1] {
+		row := make([]string, len(columns))
+		texts := make([]string, 0, len(line.Cells))
+		for _, cell := range line.Cells {
+			col := columnIndex(columns, cell.X)
+			if row[col] != "" {
+				collisions++
+			} else {
+				filled++
+			}
+			row[col] = strings.TrimSpace(row[col] + " " + cell.Text)
+			texts = append(texts, cell.Text)
+		}
+		rows = append(rows, row)
+		raw = append(raw, strings.Join(texts, "    "))
+	}
+
+	table := PDFTable{CaptionLine: -1, FirstLine: first, LastLine: last, Header: rows[0], Rows: rows[1:], Raw: raw}
+
+	// Cells drifting between columns leave sparsely used columns and cells sharing a column
+	// with a neighbour; legitimately empty cells (blank Min/Max) are common, so sparsity weighs less
+	fill := float64(filled) / float64(len(rows)*len(columns))
+	table.Confidence = (1 - float64(collisions)/float64(cells)) * (0.25 + 0.75*fill)
+
+	top, bottom := lines[first], lines[last]
+	table.Top = top.Y + math.Max(top.Size, 4)
+	table.Bottom = bottom.Y - math.Max(bottom.Size, 4)*0.4
+	if first > 0 && tableCaptionPattern.MatchString(lines[first-1].Text) {
+		table.Caption = lines[first-1].Text
+		table.CaptionLine = first - 1
+	}
+	return table, true
+}
+
+// columnIndex returns the column whose start is closest to x.
+func columnIndex(columns []float64, x float64) int {
+	best := 0
+	for i, start := range columns {
+		if math.Abs(start-x) < math.Abs(columns[best]-x) {
+			best = i
+		}
+	}
+	return best
+}
+
+// markdown renders the table as a GitHub-flavored Markdown table.
+func (t PDFTable) markdown() string {
+	var md strings.Builder
+	writeRow := func(cells []string) {
+		md.WriteString("|")
+		for _, cell := range cells {
+			md.WriteString(" " + strings.ReplaceAll(cell, "|", "\\|") + " |")
+		}
+		md.WriteString("\n")
+	}
+	writeRow(t.Header)
+	md.WriteString("|" + strings.Repeat(" --- |", len(t.Header)) + "\n")
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	return md.String()
+}
+
+// continues reports whether next is the continuation of t on the following page: either
+// its caption carries a "(continued)" marker or the same table number, or it repeats the header.
+func (t PDFTable) continues(next PDFTable) bool {
+	if t.Fallback || next.Fallback || len(t.Header) != len(next.Header) {
+		return false
+	}
+	if next.Caption != "" {
+		if tableContinuedPattern.MatchString(next.Caption) {
+			return true
+		}
+		if t.Caption != "" && tableCaptionPattern.FindStringSubmatch(t.Caption)[1] == tableCaptionPattern.FindStringSubmatch(next.Caption)[1] {
+			return true
+		}
+		return false
+	}
+	return equalRows(t.Header, next.Header)
+}
+
+// equalRows reports whether two rows have the same cell texts.
+func equalRows(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeContinuedTables folds a table that starts a page into the last table of the
+// previous page when it is a continuation, so the pair renders as one Markdown table
+// with a single header row. Only tables with no body text between them are merged.
+func (c *PDFConverter) mergeContinuedTables(pages []PDFPage) {
+	for i := 1; i < len(pages); i++ {
+		prev, next := &pages[i-1], &pages[i]
+		if len(prev.Tables) == 0 || len(next.Tables) == 0 {
+			continue
+		}
+		last := &prev.Tables[len(prev.Tables)-1]
+		if !tableEndsPage(prev.Lines, *last) {
+			continue
+		}
+		if last.Merged {
+			// The previous page's table was itself merged upwards; extend the original
+			for j := i - 2; j >= 0; j-- {
+				if n := len(pages[j].Tables); n > 0 && !pages[j].Tables[n-1].Merged {
+					last = &pages[j].Tables[n-1]
+					break
+				}
+			}
+		}
+		first := &next.Tables[0]
+		if !tableStartsPage(next.Lines, *first) || !last.continues(*first) {
+			continue
+		}
+		if !equalRows(last.Header, first.Header) {
+			last.Rows = append(last.Rows, first.Header)
+		}
+		last.Rows = append(last.Rows, first.Rows...)
+		first.Merged = true
+		c.logger.Debug("Merged continued table on page %d into the table on page %d", next.Number, prev.Number)
+	}
+}
+
+// finishTables merges tables continued across the extracted pages and normalizes the
+// values of the remaining spec tables.
+func (c *PDFConverter) finishTables(pages []PDFPage) {
+	if !c.config.ExtractTables {
+		return
+	}
+	c.mergeContinuedTables(pages)
+	if !c.config.NormalizeSpecTables {
+		return
+	}
+	for i := range pages {
+		for j := range pages[i].Tables {
+			if table := &pages[i].Tables[j]; !table.Fallback && !table.Merged {
+				c.formatSpecTable(table)
+			}
+		}
+	}
+}
+
+// tableStartsPage reports whether at most a few short lines, such as running page headers,
+// precede the table (and its caption) on its page.
+func tableStartsPage(lines []TextLine, table PDFTable) bool {
+	start := table.FirstLine
+	if table.CaptionLine >= 0 {
+		start = table.CaptionLine
+	}
+	if start > 3 {
+		return false
+	}
+	for _, line := range lines[:start] {
+		if len(line.Text) > ShortHeaderLength {
+			return false
+		}
+	}
+	return true
+}
+
+// tableEndsPage reports whether at most a few short lines, such as running page footers,
+// follow the table on its page.
+func tableEndsPage(lines []TextLine, table PDFTable) bool {
+	trailing := lines[table.LastLine+1:]
+	if len(trailing) > 3 {
+		return false
+	}
+	for _, line := range trailing {
+		if len(line.Text) > ShortHeaderLength {
+			return false
+		}
+	}
+	return true
+}
+
+// tableAt returns the table whose rows or caption include the line index.
+func tableAt(tables []PDFTable, line int) (PDFTable, bool) {
+	for _, t := range tables {
+		if line >= t.FirstLine && line <= t.LastLine || line == t.CaptionLine {
+			return t, true
+		}
+	}
+	return PDFTable{}, false
+}
+
+// applyTableFallback marks tables below TABLE_MIN_CONFIDENCE as fallbacks and, when the
+// page can be rendered, saves a cropped image of each such table region.
+func (c *PDFConverter) applyTableFallback(pdfPath string, page pdf.Page, pageNum int, outputDir string, tables []PDFTable) {
+	var pageImg image.Image
+	var renderErr error
+	for i := range tables {
+		table := &tables[i]
+		if table.Confidence >= c.config.TableMinConfidence {
+			continue
+		}
+		table.Fallback = true
+		c.logger.Warn("Table on page %d has low reconstruction confidence (%.2f), using fallback", pageNum, table.Confidence)
+
+		// Render the page once, on the first low-confidence table
+		if pageImg == nil && renderErr == nil {
+			pageImg, renderErr = c.renderPage(pdfPath, pageNum, c.config.ImageMaxDPI)
+			if renderErr != nil {
+				c.logger.Debug("Cannot render page %d for table fallback: %v", pageNum, renderErr)
+			}
+		}
+		if pageImg == nil {
+			continue
+		}
+		height := pageHeight(page)
+		if height <= 0 {
+			continue
+		}
+		filename, err := c.saveHashedImage(cropPageBand(pageImg, height, table.Top, table.Bottom), outputDir, TableImagePrefix)
+		if err != nil {
+			c.logger.Warn("Failed to save image of table %d on page %d: %v", i+1, pageNum, err)
+			continue
+		}
+		table.Image = filename
+	}
+}
+
+// writeTableMarkdown writes a table, or nothing when it was merged into a previous page.
+// Low-confidence tables are written as an image, or as the raw row text when no image
+// could be rendered, behind a warning comment rather than as possibly wrong cells.
+func (c *PDFConverter) writeTableMarkdown(md *strings.Builder, table PDFTable) {
+	if table.Merged {
+		return
+	}
+	if !table.Fallback {
+		md.WriteString(table.markdown())
+		md.WriteString("\n")
+		return
+	}
+	md.WriteString(fmt.Sprintf("<!-- WARNING: table reconstruction confidence %.2f is below %.2f; verify values against the source PDF -->\n\n",
+		table.Confidence, c.config.TableMinConfidence))
+	if table.Image != "" {
+		md.WriteString(fmt.Sprintf("![%s](./%s)\n\n", c.tableImageAltText(table), table.Image))
+		return
+	}
+	md.WriteString(formatVerbatimText(strings.Join(table.Raw, "\n")))
+	md.WriteString("\n\n")
+}
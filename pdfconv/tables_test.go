@@ -0,0 +1,175 @@
+package pdfconv
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func tableLine(y float64, cells ...string) TextLine {
+	line := TextLine{Y: y, Text: strings.Join(cells, " ")}
+	for i, text := range cells {
+		line.Cells = append(line.Cells, TextCell{X: 50 + float64(i)*100, Text: text})
+	}
+	return line
+}
+
+func TestDetectTables(t *testing.T) {
+	lines := []TextLine{
+		tableLine(700, "Some introductory text"),
+		tableLine(680, "Table 3. Recommended Operating Conditions"),
+		tableLine(660, "Parameter", "Min", "Max"),
+		tableLine(640, "VDD", "1.8", "3.6"),
+		tableLine(620, "TA", "-40", "85"),
+		tableLine(600, "Closing paragraph"),
+		tableLine(580, "This column of prose is long enough to be body text", "and so is this second column of running prose text"),
+		tableLine(560, "which continues on the next line of the left column", "while the right column also keeps going for a while"),
+	}
+	tables := detectTables(lines)
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got %d: %+v", len(tables), tables)
+	}
+	table := tables[0]
+	if table.CaptionLine != 1 || table.FirstLine != 2 || table.LastLine != 4 {
+		t.Errorf("unexpected table bounds: %+v", table)
+	}
+	want := "| Parameter | Min | Max |\n| --- | --- | --- |\n| VDD | 1.8 | 3.6 |\n| TA | -40 | 85 |\n"
+	if got := table.markdown(); got != want {
+		t.Errorf("markdown() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeContinuedTables(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1}, logger.NewLogger("error"))
+	page1 := []TextLine{
+		tableLine(700, "Table 7. Pin Functions"),
+		tableLine(680, "Pin", "Name", "Type"),
+		tableLine(660, "1", "VDD", "P"),
+		tableLine(40, "Page 4"),
+	}
+	page2 := []TextLine{
+		tableLine(760, "Datasheet Rev. B"),
+		tableLine(700, "Table 7. Pin Functions (continued)"),
+		tableLine(680, "Pin", "Name", "Type"),
+		tableLine(660, "2", "GND", "G"),
+		tableLine(600, "Detailed description of the device follows here."),
+	}
+	pages := []PDFPage{
+		{Number: 4, Lines: page1, Tables: detectTables(page1)},
+		{Number: 5, Lines: page2, Tables: detectTables(page2)},
+	}
+	conv.mergeContinuedTables(pages)
+
+	if !pages[1].Tables[0].Merged {
+		t.Fatalf("expected continuation table to be merged")
+	}
+	if got := len(pages[0].Tables[0].Rows); got != 2 {
+		t.Errorf("expected 2 body rows after merge (repeated header dropped), got %d", got)
+	}
+
+	md := conv.generateMarkdown(pages)
+	if strings.Count(md, "| Pin | Name | Type |") != 1 {
+		t.Errorf("expected a single header row, got:\n%s", md)
+	}
+	if strings.Contains(md, "(continued)") {
+		t.Errorf("continuation caption should be dropped, got:\n%s", md)
+	}
+	if !strings.Contains(md, "| 1 | VDD | P |\n| 2 | GND | G |") {
+		t.Errorf("expected merged rows in one table, got:\n%s", md)
+	}
+}
+
+func TestConvertPDF_ExtractTables(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "table.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Helvetica", "", 10)
+	for page, rows := range [][][]string{{{"VDD", "1.8", "3.6"}}, {{"VIO", "1.2", "3.6"}}} {
+		doc.AddPage()
+		doc.SetXY(20, 20)
+		caption := "Table 2. Supply Voltages"
+		if page > 0 {
+			caption += " (continued)"
+		}
+		doc.CellFormat(100, 6, caption, "", 1, "L", false, 0, "")
+		for _, row := range append([][]string{{"Parameter", "Min", "Max"}}, rows...) {
+			doc.SetX(20)
+			doc.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+			doc.CellFormat(30, 6, row[1], "", 0, "L", false, 0, "")
+			doc.CellFormat(30, 6, row[2], "", 1, "L", false, 0, "")
+		}
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create table pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractTables: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	data, err := os.ReadFile(res.MarkdownFile)
+	if err != nil {
+		t.Fatalf("failed to read markdown: %v", err)
+	}
+	md := string(data)
+	if !strings.Contains(md, "| Parameter | Min | Max |\n| --- | --- | --- |\n| VDD | 1.8 | 3.6 |\n| VIO | 1.2 | 3.6 |") {
+		t.Errorf("expected merged Markdown table, got:\n%s", md)
+	}
+}
+
+func TestTableConfidenceFallback(t *testing.T) {
+	clean := detectTables([]TextLine{
+		tableLine(700, "Parameter", "Min", "Max"),
+		tableLine(680, "VDD", "1.8", "3.6"),
+	})
+	if len(clean) != 1 || clean[0].Confidence < 0.9 {
+		t.Fatalf("expected a high-confidence table, got %+v", clean)
+	}
+
+	// Cells drifting between columns indicate a misread layout
+	garbled := detectTables([]TextLine{
+		{Y: 700, Cells: []TextCell{{X: 50, Text: "Parameter"}, {X: 150, Text: "Min"}, {X: 250, Text: "Max"}}},
+		{Y: 680, Cells: []TextCell{{X: 50, Text: "VDD"}, {X: 56, Text: "1.8"}, {X: 205, Text: "3.6"}}},
+		{Y: 660, Cells: []TextCell{{X: 100, Text: "IDD"}, {X: 205, Text: "2"}, {X: 212, Text: "5"}}},
+	})
+	if len(garbled) != 1 || garbled[0].Confidence >= 0.5 {
+		t.Fatalf("expected a low-confidence table, got %+v", garbled)
+	}
+
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, TableMinConfidence: 0.5}, logger.NewLogger("error"))
+	table := garbled[0]
+	table.Fallback = true
+	var md strings.Builder
+	conv.writeTableMarkdown(&md, table)
+	got := md.String()
+	if !strings.HasPrefix(got, "<!-- WARNING: table reconstruction confidence") {
+		t.Errorf("expected warning comment, got:\n%s", got)
+	}
+	if strings.Contains(got, "| --- |") || !strings.Contains(got, "```text\nParameter    Min    Max\n") {
+		t.Errorf("expected raw text fallback instead of a Markdown table, got:\n%s", got)
+	}
+
+	table.Image = "page_1_table_1.png"
+	md.Reset()
+	conv.writeTableMarkdown(&md, table)
+	if !strings.Contains(md.String(), "![Table](./page_1_table_1.png)") {
+		t.Errorf("expected table image fallback, got:\n%s", md.String())
+	}
+}
+
+func TestCropPageBand(t *testing.T) {
+	// A 200 DPI rendering of a 72x144 point page is 200x400 pixels
+	page := image.NewRGBA(image.Rect(0, 0, 200, 400))
+	band := cropPageBand(page, 144, 108, 72)
+	if band.Bounds().Dx() != 200 || band.Bounds().Dy() != 100 {
+		t.Errorf("expected a 200x100 band, got %v", band.Bounds())
+	}
+}
@@ -0,0 +1,180 @@
+// Package pdfconv - Text layer plausibility.
+// This file scores how plausible the extracted text of a page is, so pages whose text layer
+// decodes to mojibake (broken font encodings, misread character sets) are re-read with OCR
+// or flagged as unreliable instead of being written into the Markdown silently.
+package pdfconv
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text plausibility parameters
+const (
+	textBadCharWeight  = 5   // A page with 1/textBadCharWeight bad characters scores 0
+	textMinWords       = 3   // Pages with fewer words are judged by their characters only
+	textMaxWordLength  = 25  // Longer letter runs are not words
+	textConsonantRun   = 6   // Runs of this many consonants do not occur in words
+	textAcronymLength  = 8   // All-caps words up to this length are accepted as acronyms
+	textOCRRenderDPI   = 300 // Resolution of pages rendered for OCR
+	textLatinVowels    = "aeiouyàáâäåæèéêëìíîïòóôöøœùúûüý"
+	textMojibakeWeight = 2 // Mojibake sequences count as this many bad characters
+)
+
+// mojibakePattern matches UTF-8 text that was decoded as Latin-1 or Windows-1252, such as
+// "Ã©" for "é" or "â€™" for "’".
+var mojibakePattern = regexp.MustCompile(`[ÃÂ][\x{80}-\x{BF}©®°±²³µ¶·¹º»¼½¾¿]|â€`)
+
+// knownWords are common words and unit symbols that the word shape checks would reject.
+var knownWords = map[string]bool{
+	"hz": true, "khz": true, "mhz": true, "ghz": true, "db": true, "dbm": true, "dbc": true,
+	"mv": true, "kv": true, "mw": true, "kw": true, "pf": true, "nf": true, "ns": true,
+	"ms": true, "ps": true, "rms": true, "pwm": true, "gnd": true, "vdd": true, "vss": true,
+	"vcc": true, "clk": true, "nc": true, "tx": true, "rx": true, "st": true, "nd": true,
+	"rd": true, "th": true, "mr": true, "mrs": true, "dr": true, "pp": true, "vs": true,
+}
+
+// textConfidence scores the plausibility of extracted text between 0.0 (garbage) and 1.0.
+// Two signals are combined: the density of characters that never appear in real text
+// (control characters, replacement characters, private use code points and mojibake
+// sequences), and the share of letter runs that look like words. Latin words must contain
+// a vowel, avoid long consonant runs and odd capitalization, unless they are acronyms or
+// known unit symbols; words in other scripts are accepted as they are.
+func textConfidence(text string) float64 {
+	runes, bad := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		runes++
+		if r == utf8.RuneError || unicode.IsControl(r) || unicode.Is(unicode.Co, r) || (!unicode.IsPrint(r) && !unicode.Is(unicode.Cf, r)) {
+			bad++
+		}
+	}
+	if runes == 0 {
+		return 1
+	}
+	bad += len(mojibakePattern.FindAllStringIndex(text, -1)) * textMojibakeWeight
+	charScore := 1 - float64(bad)*textBadCharWeight/float64(runes)
+	if charScore < 0 {
+		charScore = 0
+	}
+
+	words, plausible := 0, 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if utf8.RuneCountInString(word) < 2 {
+			continue
+		}
+		words++
+		if plausibleWord(word) {
+			plausible++
+		}
+	}
+	if words < textMinWords {
+		return charScore
+	}
+	return charScore * float64(plausible) / float64(words)
+}
+
+// plausibleWord reports whether a run of letters looks like a word.
+func plausibleWord(word string) bool {
+	length := utf8.RuneCountInString(word)
+	if length > textMaxWordLength {
+		return false
+	}
+	lower := strings.ToLower(word)
+	if knownWords[lower] {
+		return true
+	}
+	upper, caseChanges, consonants := 0, 0, 0
+	prevUpper := false
+	for i, r := range word {
+		if !unicode.In(r, unicode.Latin) {
+			return true // CJK, Cyrillic, Greek and other scripts are not judged
+		}
+		isUpper := unicode.IsUpper(r)
+		if isUpper {
+			upper++
+		}
+		if i > 0 && isUpper && !prevUpper {
+			caseChanges++
+		}
+		prevUpper = isUpper
+		if strings.ContainsRune(textLatinVowels, unicode.ToLower(r)) {
+			consonants = 0
+		} else if consonants++; consonants >= textConsonantRun {
+			return false
+		}
+	}
+	if upper == length {
+		return length <= textAcronymLength || strings.ContainsAny(lower, textLatinVowels)
+	}
+	// Mixed case beyond "iPhone" or "nRESET" is a sign of random letters
+	if caseChanges > 1 {
+		return false
+	}
+	return strings.ContainsAny(lower, textLatinVowels)
+}
+
+// checkTextLayer scores the text of a page against TEXT_MIN_CONFIDENCE. When the text looks
+// garbled and render can produce a page image, the page is re-read with OCR and the OCR text
+// replaces the text layer if it is more plausible; otherwise the page is flagged as
+// unreliable. Pages that already come from OCR or have no text are not checked.
+func (c *PDFConverter) checkTextLayer(page *PDFPage, render func() (image.Image, error), timings *PhaseTimings) {
+	minConfidence := c.config.TextMinConfidence
+	if minConfidence <= 0 || page.OCR || strings.TrimSpace(page.Text) == "" {
+		return
+	}
+	page.TextConfidence = textConfidence(page.Text)
+	if page.TextConfidence >= minConfidence {
+		return
+	}
+
+	if render != nil && c.HasCapability(CapabilityOCR) && ocrAvailable() {
+		ocrStart := time.Now()
+		text, confidence, err := c.ocrPageImage(render)
+		timings.record(phaseOCR, ocrStart)
+		if err != nil {
+			c.logger.Debug("OCR of page %d failed: %v", page.Number, err)
+		} else if score := textConfidence(text); strings.TrimSpace(text) != "" && score > page.TextConfidence {
+			c.logger.Info("Page %d text layer looks garbled (confidence %.2f), using OCR text instead (confidence %.2f)", page.Number, page.TextConfidence, score)
+			page.Text, page.OCR, page.OCRConfidence, page.TextConfidence = text, true, confidence, score
+			// Positioned lines and tables were built from the garbled text layer
+			page.Lines, page.Tables = nil, nil
+			return
+		}
+	}
+	page.Unreliable = true
+	c.logger.Warn("Page %d text layer looks garbled (confidence %.2f); flagged as unreliable", page.Number, page.TextConfidence)
+}
+
+// ocrPageImage renders a page and recognizes its text with tesseract.
+func (c *PDFConverter) ocrPageImage(render func() (image.Image, error)) (string, float64, error) {
+	img, err := render()
+	if err != nil {
+		return "", 0, err
+	}
+	file, err := c.createTempFile("ocr", ".png")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create OCR image: %v", err)
+	}
+	defer os.Remove(file.Name())
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return "", 0, fmt.Errorf("failed to write OCR image: %v", err)
+	}
+	file.Close()
+	return c.ocrImage(file.Name())
+}
+
+// unreliableTextNote is the Markdown note written on pages whose text looks garbled.
+func unreliableTextNote(confidence float64) string {
+	return fmt.Sprintf("> **Unreliable text:** the text layer of this page looks garbled (confidence %.0f%%); check it against the source document.\n\n", confidence*100)
+}
@@ -0,0 +1,79 @@
+package pdfconv
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestTextConfidence(t *testing.T) {
+	good := []string{
+		"The supply voltage must stay between 2.7 V and 3.6 V during operation.",
+		"Die Versorgungsspannung muss während des Betriebs stabil bleiben.",
+		"電源電圧は動作中に安定している必要があります。",
+		"Clock 16 MHz, 32 kHz crystal, 10 mV ripple, PWM on GPIO pins",
+		"Table 3. Absolute Maximum Ratings",
+	}
+	for _, text := range good {
+		if got := textConfidence(text); got < 0.8 {
+			t.Errorf("textConfidence(%q) = %.2f, want >= 0.8", text, got)
+		}
+	}
+	garbled := []string{
+		"Ã©lectrique Ã  la tempÃ©rature de fonctionnement Ã©levÃ©e",
+		"\x01\x02\x03 ab\x04\x05 \x06\x07 cd\x08",
+		"xqzvbnm rtkpsdf lkjhgfd qwrtplk mnbvcxz",
+		"\ue000\ue001\ue002\ue003 \ue004\ue005", // Private use glyphs of an unmapped font
+	}
+	for _, text := range garbled {
+		if got := textConfidence(text); got >= 0.5 {
+			t.Errorf("textConfidence(%q) = %.2f, want < 0.5", text, got)
+		}
+	}
+}
+
+func TestCheckTextLayer_FlagsGarbledText(t *testing.T) {
+	cfg := &config.Config{TextMinConfidence: 0.5}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	page := PDFPage{Number: 1, Text: "xqzvbnm rtkpsdf lkjhgfd qwrtplk"}
+	conv.checkTextLayer(&page, nil, &PhaseTimings{})
+	if !page.Unreliable || page.TextConfidence >= 0.5 {
+		t.Errorf("expected garbled page to be flagged, got unreliable=%v confidence=%.2f", page.Unreliable, page.TextConfidence)
+	}
+	md := conv.generateMarkdown([]PDFPage{page})
+	if !strings.Contains(md, "**Unreliable text:**") {
+		t.Errorf("expected unreliable text note, got:\n%s", md)
+	}
+	if q := assessQuality([]PDFPage{page}); len(q.UnreliablePages) != 1 || q.TextCoverage != 0 {
+		t.Errorf("expected one unreliable page and no text coverage, got %+v", q)
+	}
+
+	cfg.TextMinConfidence = 0
+	page = PDFPage{Number: 1, Text: "xqzvbnm rtkpsdf lkjhgfd qwrtplk"}
+	conv.checkTextLayer(&page, nil, &PhaseTimings{})
+	if page.Unreliable || page.TextConfidence != 0 {
+		t.Errorf("expected no check with TEXT_MIN_CONFIDENCE=0, got %+v", page)
+	}
+}
+
+func TestCheckTextLayer_UsesOCRText(t *testing.T) {
+	installFakeTesseract(t, []string{"Absolute", "Maximum", "Ratings"}, 90)
+	conv, _ := NewPDFConverter(&config.Config{TextMinConfidence: 0.5}, logger.NewLogger("error"))
+	render := func() (image.Image, error) {
+		img := image.NewGray(image.Rect(0, 0, 8, 8))
+		img.Set(1, 1, color.White)
+		return img, nil
+	}
+	page := PDFPage{Number: 2, Text: "Ã¤Ã¶Ã¼ Ã©Ã¨ xqzvbnm", Lines: []TextLine{{Text: "Ã¤Ã¶Ã¼"}}}
+	conv.checkTextLayer(&page, render, &PhaseTimings{})
+	if page.Unreliable || !page.OCR {
+		t.Fatalf("expected OCR text to replace the garbled layer, got %+v", page)
+	}
+	if page.Text != "Absolute Maximum Ratings" || page.Lines != nil {
+		t.Errorf("unexpected page after OCR: text=%q lines=%v", page.Text, page.Lines)
+	}
+}
@@ -0,0 +1,72 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{850 * time.Millisecond, "850ms"},
+		{4200 * time.Millisecond, "4.2s"},
+		{185 * time.Second, "3m05s"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.in); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPhaseTimings(t *testing.T) {
+	var timings *PhaseTimings
+	timings.record(phaseText, time.Now()) // nil receiver is a no-op
+
+	timings = &PhaseTimings{}
+	timings.record(phaseOCR, time.Now().Add(-1500*time.Millisecond))
+	timings.record(phaseOCR, time.Now().Add(-500*time.Millisecond))
+	if timings.OCR < 2*time.Second || timings.Text != 0 {
+		t.Errorf("expected 2s of OCR only, got %+v", *timings)
+	}
+	data, _ := json.Marshal(timings)
+	var decoded map[string]int64
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded["ocr_ms"] < 2000 || len(decoded) != 5 {
+		t.Errorf("unexpected JSON %s", data)
+	}
+}
+
+func TestConvertPDF_RecordsTimings(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{BaseHeaderLevel: 1, ExtractImages: true}, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(createTempPDFWithRawImage(t), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	sum := res.Timings.Open + res.Timings.Text + res.Timings.Images + res.Timings.OCR + res.Timings.Markdown
+	if res.Duration <= 0 || sum <= 0 || sum > res.Duration || res.Throughput() <= 0 {
+		t.Errorf("expected phase timings within the duration, got %v: %s", res.Duration, res.Timings)
+	}
+
+	data, err := os.ReadFile(filepath.Join(res.OutputDir, ReportFileName))
+	if err != nil {
+		t.Fatalf("expected conversion report: %v", err)
+	}
+	var report struct {
+		DurationMS int64            `json:"duration_ms"`
+		Timings    map[string]int64 `json:"timings"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil || report.DurationMS != res.Duration.Milliseconds() {
+		t.Errorf("report duration %d does not match %v: %v", report.DurationMS, res.Duration, err)
+	}
+	if _, ok := report.Timings["markdown_ms"]; !ok {
+		t.Errorf("expected phase timings in report, got %v", report.Timings)
+	}
+}
@@ -0,0 +1,405 @@
+// Package pdfconv - Part variant tables.
+// This file recognizes the "ordering information" tables of datasheets, which list the
+// orderable variants of a device by package and temperature grade, joins them across pages
+// by part number and writes a normalized variant comparison for parts selection.
+package pdfconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VariantsFileName is the name of the part variant file written next to the Markdown.
+const VariantsFileName = "variants.json"
+
+// Temperature grades
+const (
+	GradeCommercial = "commercial" // 0 °C to 70 °C
+	GradeIndustrial = "industrial" // -40 °C to 85 °C
+	GradeExtended   = "extended"   // -40 °C to 105 °C
+	GradeAutomotive = "automotive" // -40 °C to 125 °C, or AEC-Q100 qualified
+	GradeMilitary   = "military"   // -55 °C to 125 °C
+)
+
+// Variant table column kinds
+const (
+	variantPart        = "part"
+	variantPackage     = "package"
+	variantPins        = "pins"
+	variantTemperature = "temperature"
+	variantGrade       = "grade"
+)
+
+// variantMinPartColumns is the number of part number header cells a transposed ordering
+// matrix, with one column per variant, must have.
+const variantMinPartColumns = 2
+
+// PartVariant is one orderable variant of a device, joined from all ordering tables that
+// list its part number.
+type PartVariant struct {
+	PartNumber     string            `json:"part_number"`
+	Package        string            `json:"package,omitempty"`
+	Pins           int               `json:"pins,omitempty"`
+	Temperature    string            `json:"temperature,omitempty"`     // Normalized range, e.g. "-40 °C to 125 °C"
+	TemperatureMin *float64          `json:"temperature_min,omitempty"` // Lower end of the range in °C
+	TemperatureMax *float64          `json:"temperature_max,omitempty"` // Upper end of the range in °C
+	Grade          string            `json:"grade,omitempty"`           // One of the Grade* constants
+	Attributes     map[string]string `json:"attributes,omitempty"`      // Other columns by header label
+	Pages          []int             `json:"pages"`                     // Pages the variant is listed on
+	labels         []string          // Attribute labels in column order
+}
+
+// variantsFile is the content of variants.json.
+type variantsFile struct {
+	Source   string        `json:"source"`
+	Variants []PartVariant `json:"variants"`
+}
+
+var (
+	// orderingTitlePattern matches captions and headings of ordering information tables.
+	orderingTitlePattern = regexp.MustCompile(`(?i)\b(order(ing)?\s+(information|info|guide|codes?|options)|device\s+options|part\s+number(ing)?\s+(guide|information)|package\s+options|device\s+comparison|available\s+(options|devices))\b`)
+	// partNumberPattern matches cells that look like an orderable part number.
+	partNumberPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9\-/#.+,]{3,}$`)
+	// footnoteMarkerPattern matches footnote references appended to cells, as in "ABC123(1)".
+	footnoteMarkerPattern = regexp.MustCompile(`\s*(\(\d\)|\[\d\]|[*†‡]+)$`)
+	// temperatureRangePattern matches a temperature range such as "-40 to +125°C" or "0~70 ℃".
+	temperatureRangePattern = regexp.MustCompile(`([+-]?\d+(?:\.\d+)?)\s*(?:°\s*C|℃|C)?\s*(?:to|~|\.\.|-|/|…)\s*([+-]?\d+(?:\.\d+)?)\s*(°\s*[CF]|℃|℉|[CF]\b)?`)
+	// packagePinsPattern matches the pin count in a package name such as "LQFP-48" or "SOIC 8".
+	packagePinsPattern = regexp.MustCompile(`^[A-Za-z]+[\s-]?(\d{1,4})\b`)
+	// pinCountPattern matches a pin count cell.
+	pinCountPattern = regexp.MustCompile(`\d{1,4}`)
+)
+
+// variantColumnKind classifies an ordering table header label. Labels that are not a known
+// variant property return "".
+func variantColumnKind(label string) string {
+	l := strings.ToLower(strings.TrimSpace(label))
+	switch {
+	case l == "":
+		return ""
+	case strings.Contains(l, "marking") || strings.Contains(l, "top mark"):
+		return "" // Device marking is an attribute, not the part number
+	case strings.Contains(l, "package") || l == "pkg" || strings.HasPrefix(l, "pkg ") || strings.Contains(l, "case"):
+		if strings.Contains(l, "qty") || strings.Contains(l, "quantity") || strings.Contains(l, "pins") {
+			break
+		}
+		return variantPackage
+	case strings.Contains(l, "grade") || strings.Contains(l, "qualification"):
+		return variantGrade
+	case strings.Contains(l, "temp") || strings.Contains(l, "ambient") || l == "ta" || l == "t a" || strings.Contains(l, "operating range"):
+		return variantTemperature
+	case strings.Contains(l, "part") || strings.Contains(l, "order") || strings.Contains(l, "device") ||
+		strings.Contains(l, "product") || strings.Contains(l, "model") || l == "type":
+		return variantPart
+	}
+	if strings.Contains(l, "pin") || strings.Contains(l, "lead") {
+		return variantPins
+	}
+	return ""
+}
+
+// collectVariants finds the ordering information tables of a document and joins their rows
+// by part number. Tables continued across pages have already been merged into one table.
+func (c *PDFConverter) collectVariants(pages []PDFPage) []PartVariant {
+	if !c.config.VariantTables || !c.config.ExtractTables {
+		return nil
+	}
+	var variants []PartVariant
+	index := map[string]int{}
+	for _, page := range pages {
+		for _, table := range page.Tables {
+			if table.Merged || table.Fallback {
+				continue
+			}
+			for _, variant := range tableVariants(table, orderingTitled(page, table), page.Number) {
+				key := strings.ToUpper(variant.PartNumber)
+				if i, ok := index[key]; ok {
+					variants[i].merge(variant)
+					continue
+				}
+				index[key] = len(variants)
+				variants = append(variants, variant)
+			}
+		}
+	}
+	if len(variants) > 0 {
+		c.logger.Info("Found %d part variant(s) in ordering information tables", len(variants))
+	}
+	return variants
+}
+
+// orderingTitled reports whether the caption of a table, or one of the lines just above it,
+// names it as ordering information.
+func orderingTitled(page PDFPage, table PDFTable) bool {
+	if orderingTitlePattern.MatchString(table.Caption) {
+		return true
+	}
+	start := table.FirstLine
+	if table.CaptionLine >= 0 {
+		start = table.CaptionLine
+	}
+	for i := start - 1; i >= 0 && i >= start-3; i-- {
+		if i < len(page.Lines) && orderingTitlePattern.MatchString(page.Lines[i].Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableVariants returns the variants listed in a table, or nil when it is not an ordering
+// table. A table with a part number column qualifies when it is titled as ordering
+// information or also has a package, temperature or grade column; a matrix with one column per
+// part number and one row per property is read column by column.
+func tableVariants(table PDFTable, titled bool, pageNum int) []PartVariant {
+	kinds := make([]string, len(table.Header))
+	part, properties := -1, 0
+	for i, label := range table.Header {
+		kinds[i] = variantColumnKind(label)
+		switch kinds[i] {
+		case variantPart:
+			if part < 0 {
+				part = i
+			}
+		case variantPackage, variantTemperature, variantGrade:
+			properties++
+		}
+	}
+	if part >= 0 && (titled || properties > 0) {
+		var variants []PartVariant
+		for _, row := range table.Rows {
+			variant := PartVariant{PartNumber: cleanPartNumber(row[part]), Pages: []int{pageNum}}
+			if variant.PartNumber == "" {
+				continue
+			}
+			for i, cell := range row {
+				if i != part {
+					variant.set(kinds[i], table.Header[i], cell)
+				}
+			}
+			variant.finish()
+			variants = append(variants, variant)
+		}
+		return variants
+	}
+	return matrixVariants(table, titled, pageNum)
+}
+
+// matrixVariants reads a transposed ordering table whose header lists the part numbers and
+// whose first column names the property of each row.
+func matrixVariants(table PDFTable, titled bool, pageNum int) []PartVariant {
+	var columns []int
+	for i, label := range table.Header[1:] {
+		if partNumberPattern.MatchString(cleanPartNumber(label)) && strings.ContainsAny(label, "0123456789") {
+			columns = append(columns, i+1)
+		}
+	}
+	if len(columns) < variantMinPartColumns {
+		return nil
+	}
+	properties := 0
+	for _, row := range table.Rows {
+		if kind := variantColumnKind(row[0]); kind == variantPackage || kind == variantTemperature || kind == variantGrade {
+			properties++
+		}
+	}
+	if !titled && properties == 0 {
+		return nil
+	}
+	variants := make([]PartVariant, 0, len(columns))
+	for _, col := range columns {
+		variant := PartVariant{PartNumber: cleanPartNumber(table.Header[col]), Pages: []int{pageNum}}
+		for _, row := range table.Rows {
+			variant.set(variantColumnKind(row[0]), row[0], row[col])
+		}
+		variant.finish()
+		variants = append(variants, variant)
+	}
+	return variants
+}
+
+// cleanPartNumber removes footnote markers and surrounding space from a part number cell.
+func cleanPartNumber(cell string) string {
+	cell = strings.TrimSpace(strings.Trim(strings.TrimSpace(cell), "*"))
+	return strings.TrimSpace(footnoteMarkerPattern.ReplaceAllString(cell, ""))
+}
+
+// set stores a cell of an ordering table in the variant according to its column kind.
+func (v *PartVariant) set(kind, label, cell string) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" || cell == "-" || cell == "—" {
+		return
+	}
+	switch kind {
+	case variantPackage:
+		v.Package = cell
+	case variantPins:
+		if n, err := strconv.Atoi(pinCountPattern.FindString(cell)); err == nil {
+			v.Pins = n
+		}
+	case variantTemperature:
+		v.setTemperature(cell)
+	case variantGrade:
+		v.Grade = normalizeGrade(cell)
+		if v.Grade == "" {
+			v.setAttribute(label, cell)
+		}
+	default:
+		v.setAttribute(label, cell)
+	}
+}
+
+// setAttribute stores a cell of a column that is not a known variant property.
+func (v *PartVariant) setAttribute(label, cell string) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return
+	}
+	if v.Attributes == nil {
+		v.Attributes = map[string]string{}
+	}
+	if _, ok := v.Attributes[label]; !ok {
+		v.labels = append(v.labels, label)
+	}
+	v.Attributes[label] = cell
+}
+
+// setTemperature parses a temperature range cell, which may also name the grade.
+func (v *PartVariant) setTemperature(cell string) {
+	if grade := normalizeGrade(cell); grade != "" && v.Grade == "" {
+		v.Grade = grade
+	}
+	m := temperatureRangePattern.FindStringSubmatch(specMinusSigns.Replace(cell))
+	if m == nil {
+		v.Temperature = cell
+		return
+	}
+	low, errLow := strconv.ParseFloat(m[1], 64)
+	high, errHigh := strconv.ParseFloat(m[2], 64)
+	if errLow != nil || errHigh != nil || low >= high {
+		v.Temperature = cell
+		return
+	}
+	if unit := strings.TrimSpace(m[3]); unit == "℉" || strings.HasSuffix(unit, "F") {
+		low, high = (low-32)*5/9, (high-32)*5/9
+	}
+	v.TemperatureMin, v.TemperatureMax = &low, &high
+	v.Temperature = fmt.Sprintf("%s °C to %s °C", strconv.FormatFloat(low, 'f', -1, 64), strconv.FormatFloat(high, 'f', -1, 64))
+}
+
+// normalizeGrade returns the grade named in a cell, or "".
+func normalizeGrade(cell string) string {
+	l := strings.ToLower(cell)
+	switch {
+	case strings.Contains(l, "aec") || strings.Contains(l, "automotive") || strings.Contains(l, "auto grade"):
+		return GradeAutomotive
+	case strings.Contains(l, "military") || strings.Contains(l, "mil-"):
+		return GradeMilitary
+	case strings.Contains(l, "extended"):
+		return GradeExtended
+	case strings.Contains(l, "industrial"):
+		return GradeIndustrial
+	case strings.Contains(l, "commercial"):
+		return GradeCommercial
+	}
+	return ""
+}
+
+// finish derives the pin count from the package name and the grade from the temperature
+// range when the table has no column for them.
+func (v *PartVariant) finish() {
+	if v.Pins == 0 {
+		if m := packagePinsPattern.FindStringSubmatch(v.Package); m != nil {
+			v.Pins, _ = strconv.Atoi(m[1])
+		}
+	}
+	if v.Grade == "" && v.TemperatureMin != nil {
+		low, high := *v.TemperatureMin, *v.TemperatureMax
+		switch {
+		case low <= -55 && high >= 125:
+			v.Grade = GradeMilitary
+		case low <= -40 && high >= 125:
+			v.Grade = GradeAutomotive
+		case low <= -40 && high >= 105:
+			v.Grade = GradeExtended
+		case low <= -40 && high >= 85:
+			v.Grade = GradeIndustrial
+		case low <= 0 && high >= 70:
+			v.Grade = GradeCommercial
+		}
+	}
+}
+
+// merge adds the properties of another listing of the same part number, keeping the values
+// already known.
+func (v *PartVariant) merge(other PartVariant) {
+	if v.Package == "" {
+		v.Package = other.Package
+	}
+	if v.Pins == 0 {
+		v.Pins = other.Pins
+	}
+	if v.Temperature == "" {
+		v.Temperature, v.TemperatureMin, v.TemperatureMax = other.Temperature, other.TemperatureMin, other.TemperatureMax
+	}
+	if v.Grade == "" {
+		v.Grade = other.Grade
+	}
+	for _, label := range other.labels {
+		if _, ok := v.Attributes[label]; !ok {
+			v.setAttribute(label, other.Attributes[label])
+		}
+	}
+	for _, page := range other.Pages {
+		if v.Pages[len(v.Pages)-1] != page {
+			v.Pages = append(v.Pages, page)
+		}
+	}
+}
+
+// variantMarkdown renders the variant comparison section appended to the document.
+func (c *PDFConverter) variantMarkdown(variants []PartVariant) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	header := []string{"Part Number", "Package", "Pins", "Temperature", "Grade"}
+	var labels []string
+	seen := map[string]bool{}
+	for _, v := range variants {
+		for _, label := range v.labels {
+			if !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	}
+	table := PDFTable{Header: append(header, labels...)}
+	for _, v := range variants {
+		pins := ""
+		if v.Pins > 0 {
+			pins = strconv.Itoa(v.Pins)
+		}
+		row := []string{v.PartNumber, v.Package, pins, v.Temperature, v.Grade}
+		for _, label := range labels {
+			row = append(row, v.Attributes[label])
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return fmt.Sprintf("---\n\n%s\n\n%s\n", c.heading(c.config.BaseHeaderLevel+1, "Part Variants"), table.markdown())
+}
+
+// writeVariantsFile writes variants.json for a document into dir.
+func writeVariantsFile(dir, docPath string, variants []PartVariant) error {
+	data, err := json.MarshalIndent(variantsFile{Source: docPath, Variants: variants}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode part variants: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, VariantsFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write part variants: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package pdfconv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestCollectVariants(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractTables: true, VariantTables: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	ordering := []TextLine{
+		tableLine(700, "Ordering Information"),
+		tableLine(680, "Part Number", "Package", "Temperature Range", "Packing"),
+		tableLine(660, "XC100-QFN32T(1)", "QFN-32", "–40°C to +85°C", "Tape and reel"),
+		tableLine(640, "XC100-SO8E", "SOIC 8", "-40 to 125 °C", "Tube"),
+	}
+	marking := []TextLine{
+		tableLine(700, "Device", "Top Marking", "Grade"),
+		tableLine(680, "XC100-SO8E", "X100E", "AEC-Q100"),
+		tableLine(660, "XC100-QFN32T", "X100Q", "Industrial"),
+	}
+	// A matrix lists one variant per column and one property per row
+	matrix := []TextLine{
+		tableLine(700, "Table 1. Device Comparison"),
+		tableLine(680, "Feature", "XC200A", "XC200B"),
+		tableLine(660, "Package", "TSSOP-20", "QFN-24"),
+		tableLine(640, "Operating temperature", "0 to 70", "-55 to 125"),
+	}
+	unrelated := []TextLine{
+		tableLine(700, "Parameter", "Min", "Max"),
+		tableLine(680, "VDD", "1.8", "3.6"),
+	}
+	var pages []PDFPage
+	for i, lines := range [][]TextLine{ordering, marking, matrix, unrelated} {
+		pages = append(pages, PDFPage{Number: i + 1, Lines: lines, Tables: detectTables(lines)})
+	}
+
+	variants := conv.collectVariants(pages)
+	if len(variants) != 4 {
+		t.Fatalf("expected 4 variants, got %d: %+v", len(variants), variants)
+	}
+	qfn := variants[0]
+	if qfn.PartNumber != "XC100-QFN32T" || qfn.Package != "QFN-32" || qfn.Pins != 32 {
+		t.Errorf("unexpected first variant: %+v", qfn)
+	}
+	if qfn.Temperature != "-40 °C to 85 °C" || *qfn.TemperatureMin != -40 || *qfn.TemperatureMax != 85 || qfn.Grade != GradeIndustrial {
+		t.Errorf("unexpected temperature range of %s: %q %s", qfn.PartNumber, qfn.Temperature, qfn.Grade)
+	}
+	if qfn.Attributes["Packing"] != "Tape and reel" || qfn.Attributes["Top Marking"] != "X100Q" {
+		t.Errorf("expected attributes joined across tables, got %v", qfn.Attributes)
+	}
+	if len(qfn.Pages) != 2 || qfn.Pages[1] != 2 {
+		t.Errorf("expected pages [1 2], got %v", qfn.Pages)
+	}
+	if so := variants[1]; so.Pins != 8 || so.Grade != GradeAutomotive {
+		t.Errorf("expected 8-pin automotive variant, got %+v", so)
+	}
+	if a, b := variants[2], variants[3]; a.PartNumber != "XC200A" || a.Package != "TSSOP-20" || a.Grade != GradeCommercial || b.Grade != GradeMilitary {
+		t.Errorf("unexpected matrix variants: %+v %+v", a, b)
+	}
+
+	md := conv.variantMarkdown(variants)
+	if !strings.Contains(md, "## Part Variants") || !strings.Contains(md, "| Part Number | Package | Pins | Temperature | Grade | Packing | Top Marking |") {
+		t.Errorf("unexpected variant table:\n%s", md)
+	}
+
+	cfg.VariantTables = false
+	if got := conv.collectVariants(pages); got != nil {
+		t.Errorf("expected no variants with VARIANT_TABLES off, got %+v", got)
+	}
+}
+
+func TestConvertPDF_VariantTables(t *testing.T) {
+	pdfPath := filepath.Join(t.TempDir(), "family.pdf")
+	doc := gofpdf.New("P", "mm", "A4", "")
+	doc.SetFont("Helvetica", "", 10)
+	for page, rows := range [][][]string{{{"XC100-Q32", "QFN-32", "-40 to 85"}}, {{"XC100-S8", "SOIC-8", "-40 to 125"}}} {
+		doc.AddPage()
+		doc.SetXY(20, 20)
+		caption := "Table 9. Ordering Information"
+		if page > 0 {
+			caption += " (continued)"
+		}
+		doc.CellFormat(100, 6, caption, "", 1, "L", false, 0, "")
+		for _, row := range append([][]string{{"Part Number", "Package", "Temperature"}}, rows...) {
+			doc.SetX(20)
+			doc.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+			doc.CellFormat(30, 6, row[1], "", 0, "L", false, 0, "")
+			doc.CellFormat(30, 6, row[2], "", 1, "L", false, 0, "")
+		}
+	}
+	if err := doc.OutputFileAndClose(pdfPath); err != nil {
+		t.Fatalf("failed to create ordering pdf: %v", err)
+	}
+
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractTables: true, VariantTables: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertPDF(pdfPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("ConvertPDF() error = %v", err)
+	}
+	if len(res.Variants) != 2 {
+		t.Fatalf("expected 2 variants, got %+v", res.Variants)
+	}
+	data, err := os.ReadFile(filepath.Join(res.OutputDir, VariantsFileName))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", VariantsFileName, err)
+	}
+	var file struct {
+		Source   string        `json:"source"`
+		Variants []PartVariant `json:"variants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid %s: %v", VariantsFileName, err)
+	}
+	if file.Source != pdfPath || len(file.Variants) != 2 || file.Variants[1].Grade != GradeAutomotive || file.Variants[1].Pins != 8 {
+		t.Errorf("unexpected %s content: %s", VariantsFileName, data)
+	}
+	md, _ := os.ReadFile(res.MarkdownFile)
+	if !strings.Contains(string(md), "| XC100-S8 | SOIC-8 | 8 | -40 °C to 125 °C | automotive |") {
+		t.Errorf("expected variant comparison table, got:\n%s", md)
+	}
+}
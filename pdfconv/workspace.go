@@ -0,0 +1,95 @@
+// Package pdfconv - Temporary workspace.
+// This file places the intermediate files of conversions, such as rendered pages, OCR input
+// images, dry-run samples and extracted portfolio attachments, in TMP_DIR. Every temporary
+// file and directory carries the process ID in its name, so the files of an interrupted
+// server can be removed on shutdown and orphans of a crashed one swept at the next start.
+package pdfconv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tempPrefix starts the name of every temporary file and directory created by conversions.
+const tempPrefix = "pdfconv-"
+
+// tempRoot returns the directory that holds temporary files: TMP_DIR, created on demand,
+// or the system temporary directory.
+func (c *PDFConverter) tempRoot() (string, error) {
+	if c.config.TempDir == "" {
+		return os.TempDir(), nil
+	}
+	if err := os.MkdirAll(c.config.TempDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create temporary directory %s: %v", c.config.TempDir, err)
+	}
+	return c.config.TempDir, nil
+}
+
+// tempName returns the name prefix of a temporary file of the given kind for this process.
+func tempName(kind string) string {
+	return fmt.Sprintf("%s%d-%s-", tempPrefix, os.Getpid(), kind)
+}
+
+// makeTempDir creates a temporary directory of the given kind, such as "render". The caller
+// removes it when done.
+func (c *PDFConverter) makeTempDir(kind string) (string, error) {
+	root, err := c.tempRoot()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(root, tempName(kind))
+}
+
+// createTempFile creates a temporary file of the given kind with the given extension. The
+// caller closes and removes it when done.
+func (c *PDFConverter) createTempFile(kind, ext string) (*os.File, error) {
+	root, err := c.tempRoot()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(root, tempName(kind)+"*"+ext)
+}
+
+// CleanupTempFiles removes the temporary files of this process that are still present, for
+// example because the server is shutting down while a conversion is running. It returns the
+// number of entries removed.
+func (c *PDFConverter) CleanupTempFiles() int {
+	return c.removeTempFiles(func(pid int) bool { return pid == os.Getpid() })
+}
+
+// SweepTempFiles removes the temporary files left behind by server processes that are no
+// longer running, such as after a crash or a forced kill. It is called once at startup and
+// returns the number of entries removed.
+func (c *PDFConverter) SweepTempFiles() int {
+	return c.removeTempFiles(func(pid int) bool { return pid != os.Getpid() && !processAlive(pid) })
+}
+
+// removeTempFiles removes the temporary files in the temporary directory whose process ID
+// matches.
+func (c *PDFConverter) removeTempFiles(match func(pid int) bool) int {
+	root := c.config.TempDir
+	if root == "" {
+		root = os.TempDir()
+	}
+	entries, err := filepath.Glob(filepath.Join(root, tempPrefix+"*"))
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		id, _, found := strings.Cut(strings.TrimPrefix(filepath.Base(entry), tempPrefix), "-")
+		pid, err := strconv.Atoi(id)
+		if !found || err != nil || !match(pid) {
+			continue
+		}
+		if err := os.RemoveAll(entry); err != nil {
+			c.logger.Warn("Failed to remove temporary file %s: %v", entry, err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
@@ -0,0 +1,56 @@
+package pdfconv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+func TestTempFiles(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "tmp")
+	conv, _ := NewPDFConverter(&config.Config{TempDir: root}, logger.NewLogger("error"))
+
+	dir, err := conv.makeTempDir("render")
+	if err != nil {
+		t.Fatalf("makeTempDir() error = %v", err)
+	}
+	if filepath.Dir(dir) != root || !strings.HasPrefix(filepath.Base(dir), tempName("render")) {
+		t.Errorf("expected a render directory in TMP_DIR, got %s", dir)
+	}
+	file, err := conv.createTempFile("ocr", ".png")
+	if err != nil {
+		t.Fatalf("createTempFile() error = %v", err)
+	}
+	file.Close()
+	if !strings.HasSuffix(file.Name(), ".png") {
+		t.Errorf("expected a .png file, got %s", file.Name())
+	}
+
+	orphan := filepath.Join(root, tempPrefix+"99999999-render-1")
+	running := filepath.Join(root, tempPrefix+"1-render-1")
+	other := filepath.Join(root, "unrelated")
+	for _, path := range []string{orphan, running, other} {
+		if err := os.Mkdir(path, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if removed := conv.SweepTempFiles(); removed != 1 {
+		t.Errorf("expected the orphan of a dead process swept, removed %d", removed)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("expected %s removed", orphan)
+	}
+	if removed := conv.CleanupTempFiles(); removed != 2 {
+		t.Errorf("expected the two files of this process removed, removed %d", removed)
+	}
+	for _, path := range []string{running, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s kept: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+// Package pdfconv - XPS input.
+// This file reads XPS and OpenXPS documents (ZIP packages of XAML fixed pages) directly,
+// turning Glyphs runs into positioned text lines and ImageBrush sources into page images.
+package pdfconv
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// xpsPointsPerUnit converts XPS units (1/96 inch) to PDF points (1/72 inch).
+const xpsPointsPerUnit = 72.0 / 96.0
+
+// xpsGlyphRun is a run of text from a Glyphs element, in XPS units with Y increasing downwards.
+type xpsGlyphRun struct {
+	X, Y float64
+	Size float64
+	Text string
+}
+
+// xpsImage is an image referenced by an ImageBrush on a fixed page.
+type xpsImage struct {
+	Source      string
+	Top         float64 // Top of the brush viewport in XPS units
+	HasPosition bool
+}
+
+// xpsPage is the content parsed from a single FixedPage.
+type xpsPage struct {
+	Height float64
+	Glyphs []xpsGlyphRun
+	Images []xpsImage
+}
+
+// ConvertXPS converts an XPS or OpenXPS document to Markdown with extracted images.
+func (c *PDFConverter) ConvertXPS(xpsPath, outputBaseDir string, opts ConversionOptions) (*ConversionResult, error) {
+	c.logger.Info("Starting XPS conversion: %s", xpsPath)
+	opts.started = time.Now()
+
+	xpsPath, outputBaseDir, err := cleanInputPaths(xpsPath, outputBaseDir)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.OpenReader(xpsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XPS package: %v", err)
+	}
+	defer archive.Close()
+
+	pagePaths, err := xpsPagePaths(&archive.Reader)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Info("XPS opened successfully, %d pages found", len(pagePaths))
+
+	if err := c.preflightFileSize(xpsPath, outputBaseDir); err != nil {
+		return nil, err
+	}
+
+	return c.generateOutput(xpsPath, outputBaseDir, opts, func(stagingDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+		return c.extractXPSPages(opts.context(), &archive.Reader, pagePaths, opts.Pages, stagingDir, timings)
+	})
+}
+
+// extractXPSPages converts the fixed pages into the page model shared with PDF conversion,
+// skipping the pages outside selection unless it is nil.
+func (c *PDFConverter) extractXPSPages(ctx context.Context, archive *zip.Reader, pagePaths []string, selection PageSelection, outputDir string, timings *PhaseTimings) ([]PDFPage, int, error) {
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var pages []PDFPage
+	totalImages := 0
+	for i, pagePath := range pagePaths {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		pageNum := i + 1
+		if selection != nil && !selection.Contains(pageNum) {
+			continue
+		}
+		c.logger.Debug("Processing page %d/%d", pageNum, len(pagePaths))
+		textStart := time.Now()
+		parsed, err := parseXPSPage(files[pagePath])
+		if err != nil {
+			c.logger.Warn("Failed to parse XPS page %d, skipping: %v", pageNum, err)
+			continue
+		}
+
+		page := PDFPage{Number: pageNum, Images: []PDFImage{}}
+		lines := xpsTextLines(parsed)
+		texts := make([]string, len(lines))
+		for j, line := range lines {
+			texts[j] = line.Text
+		}
+		page.Text = strings.Join(texts, "\n")
+		if c.config.ImagePlacement == "inline" || c.config.ExtractTables || c.config.ApplicationBOM {
+			page.Lines = lines
+			if c.config.ExtractTables {
+				page.Tables = c.detectLineTables(lines, pageNum)
+			}
+		}
+		timings.record(phaseText, textStart)
+		c.checkTextLayer(&page, nil, timings)
+
+		if c.config.ExtractImages {
+			imageStart := time.Now()
+			for _, ref := range parsed.Images {
+				source := xpsResolve(pagePath, ref.Source)
+				img, err := decodeZipImage(files[source])
+				if err != nil {
+					c.logger.Warn("Failed to decode XPS image %s on page %d: %v", source, pageNum, err)
+					page.ImageFailures++
+					continue
+				}
+				filename, err := c.saveHashedImage(img, outputDir, ImageFilePrefix)
+				if err != nil {
+					c.logger.Warn("Failed to save XPS image %s on page %d: %v", source, pageNum, err)
+					page.ImageFailures++
+					continue
+				}
+				imagePath := filepath.Join(outputDir, filename)
+				page.Images = append(page.Images, PDFImage{
+					Data:        img,
+					Width:       img.Bounds().Dx(),
+					Height:      img.Bounds().Dy(),
+					Filename:    filename,
+					Diagrams:    c.detectImageDiagrams(ctx, img, imagePath),
+					ObjectName:  source,
+					PositionY:   (parsed.Height - ref.Top) * xpsPointsPerUnit,
+					HasPosition: ref.HasPosition && parsed.Height > 0,
+				})
+			}
+			totalImages += len(page.Images)
+			timings.record(phaseImages, imageStart)
+		}
+		pages = append(pages, page)
+	}
+	finishStart := time.Now()
+	c.finishPages(pages)
+	timings.record(phaseText, finishStart)
+	c.logger.Info("Extracted content from %d pages, %d images total", len(pages), totalImages)
+	return pages, totalImages, nil
+}
+
+// xpsPagePaths returns the package paths of the fixed pages in reading order, following the
+// FixedDocumentSequence when present and otherwise ordering the .fpage parts by name.
+func xpsPagePaths(archive *zip.Reader) ([]string, error) {
+	files := map[string]*zip.File{}
+	var sequence string
+	var fpages []string
+	for _, f := range archive.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		files[name] = f
+		switch strings.ToLower(path.Ext(name)) {
+		case ".fdseq":
+			sequence = name
+		case ".fpage":
+			fpages = append(fpages, name)
+		}
+	}
+
+	var pages []string
+	if sequence != "" {
+		docs, err := xpsSources(files[sequence], "DocumentReference")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read XPS document sequence: %v", err)
+		}
+		for _, doc := range docs {
+			doc = xpsResolve(sequence, doc)
+			refs, err := xpsSources(files[doc], "PageContent")
+			if err != nil {
+				return nil, fmt.Errorf("failed to read XPS document %s: %v", doc, err)
+			}
+			for _, ref := range refs {
+				pages = append(pages, xpsResolve(doc, ref))
+			}
+		}
+	}
+	if len(pages) == 0 {
+		sort.Slice(fpages, func(i, j int) bool { return naturalLess(fpages[i], fpages[j]) })
+		pages = fpages
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("XPS package contains no fixed pages")
+	}
+	return pages, nil
+}
+
+// xpsSources returns the Source attributes of the named elements in a package part.
+func xpsSources(f *zip.File, element string) ([]string, error) {
+	if f == nil {
+		return nil, fmt.Errorf("part not found")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var sources []string
+	decoder := xml.NewDecoder(rc)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return sources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == element {
+			if source := xmlAttr(start, "Source"); source != "" {
+				sources = append(sources, source)
+			}
+		}
+	}
+}
+
+// parseXPSPage reads the Glyphs runs and ImageBrush references of a FixedPage part.
+// Canvas render transforms are not applied; datasheet pages rarely rely on them for text.
+func parseXPSPage(f *zip.File) (xpsPage, error) {
+	var page xpsPage
+	if f == nil {
+		return page, fmt.Errorf("part not found")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return page, err
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return page, nil
+		}
+		if err != nil {
+			return page, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "FixedPage":
+			page.Height, _ = strconv.ParseFloat(xmlAttr(start, "Height"), 64)
+		case "Glyphs":
+			text := strings.TrimPrefix(xmlAttr(start, "UnicodeString"), "{}")
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			run := xpsGlyphRun{Text: text}
+			run.X, _ = strconv.ParseFloat(xmlAttr(start, "OriginX"), 64)
+			run.Y, _ = strconv.ParseFloat(xmlAttr(start, "OriginY"), 64)
+			run.Size, _ = strconv.ParseFloat(xmlAttr(start, "FontRenderingEmSize"), 64)
+			page.Glyphs = append(page.Glyphs, run)
+		case "ImageBrush":
+			source := xmlAttr(start, "ImageSource")
+			if source == "" || strings.HasPrefix(source, "{") {
+				continue // resource dictionary references are not resolved
+			}
+			img := xpsImage{Source: source}
+			if viewport := strings.Split(xmlAttr(start, "Viewport"), ","); len(viewport) == 4 {
+				if top, err := strconv.ParseFloat(strings.TrimSpace(viewport[1]), 64); err == nil {
+					img.Top, img.HasPosition = top, true
+				}
+			}
+			page.Images = append(page.Images, img)
+		}
+	}
+}
+
+// xpsTextLines groups glyph runs sharing a baseline into text lines, top to bottom, converted
+// to PDF points with Y increasing upwards so they match lines extracted from PDF pages.
+func xpsTextLines(page xpsPage) []TextLine {
+	runs := append([]xpsGlyphRun(nil), page.Glyphs...)
+	sort.SliceStable(runs, func(i, j int) bool {
+		if math.Abs(runs[i].Y-runs[j].Y) > 1 {
+			return runs[i].Y < runs[j].Y
+		}
+		return runs[i].X < runs[j].X
+	})
+
+	var lines []TextLine
+	var baseline, end float64
+	for _, run := range runs {
+		size := math.Max(run.Size, 4)
+		y := (page.Height - run.Y) * xpsPointsPerUnit
+		x := run.X * xpsPointsPerUnit
+		n := len(lines)
+		if n == 0 || math.Abs(run.Y-baseline) > size/2 {
+			lines = append(lines, TextLine{Y: y, Size: run.Size * xpsPointsPerUnit, Cells: []TextCell{{X: x, Text: run.Text}}})
+			baseline = run.Y
+		} else {
+			line := &lines[n-1]
+			line.Size = math.Max(line.Size, run.Size*xpsPointsPerUnit)
+			// Glyph widths are not known without the font; estimate half an em per character
+			if run.X-end > size {
+				line.Cells = append(line.Cells, TextCell{X: x, Text: run.Text})
+			} else {
+				cell := &line.Cells[len(line.Cells)-1]
+				if run.X > end {
+					cell.Text += " "
+				}
+				cell.Text += run.Text
+			}
+		}
+		end = run.X + float64(utf8.RuneCountInString(run.Text))*size/2
+	}
+	for i := range lines {
+		texts := make([]string, len(lines[i].Cells))
+		for j, cell := range lines[i].Cells {
+			texts[j] = strings.TrimSpace(cell.Text)
+		}
+		lines[i].Text = strings.Join(texts, " ")
+	}
+	return lines
+}
+
+// xpsResolve resolves a part reference relative to the part that contains it.
+func xpsResolve(base, ref string) string {
+	if strings.HasPrefix(ref, "/") {
+		return strings.TrimPrefix(path.Clean(ref), "/")
+	}
+	return path.Join(path.Dir(base), ref)
+}
+
+// decodeZipImage decodes a PNG or JPEG image stored in a package part.
+func decodeZipImage(f *zip.File) (image.Image, error) {
+	if f == nil {
+		return nil, fmt.Errorf("part not found")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	img, _, err := image.Decode(rc)
+	return img, err
+}
+
+// xmlAttr returns the value of the named attribute, ignoring its namespace.
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// naturalLess orders names so that embedded numbers compare numerically ("2.fpage" < "10.fpage").
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, _ := strconv.Atoi(da)
+			nb, _ := strconv.Atoi(db)
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits returns the run of ASCII digits at the start of s.
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,114 @@
+package pdfconv
+
+import (
+	"archive/zip"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// createTempXPS writes a two-page XPS package whose second page carries a table and an image.
+func createTempXPS(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sample.xps")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	add := func(name, content string) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	glyphs := func(x, y int, text string) string {
+		return `<Glyphs OriginX="` + strconv.Itoa(x) + `" OriginY="` + strconv.Itoa(y) + `" FontRenderingEmSize="12" UnicodeString="` + text + `" Fill="#000000"/>`
+	}
+
+	add("FixedDocumentSequence.fdseq", `<FixedDocumentSequence xmlns="http://schemas.microsoft.com/xps/2005/06"><DocumentReference Source="/Documents/1/FixedDocument.fdoc"/></FixedDocumentSequence>`)
+	// Pages are listed out of name order to check the sequence is followed
+	add("Documents/1/FixedDocument.fdoc", `<FixedDocument xmlns="http://schemas.microsoft.com/xps/2005/06"><PageContent Source="Pages/2.fpage"/><PageContent Source="Pages/10.fpage"/></FixedDocument>`)
+	add("Documents/1/Pages/2.fpage", `<FixedPage xmlns="http://schemas.microsoft.com/xps/2005/06" Width="816" Height="1056">`+
+		glyphs(96, 100, "OVERVIEW")+glyphs(96, 130, "The device is a")+glyphs(200, 130, "low-power regulator.")+`</FixedPage>`)
+	add("Documents/1/Pages/10.fpage", `<FixedPage xmlns="http://schemas.microsoft.com/xps/2005/06" Width="816" Height="1056">`+
+		glyphs(96, 100, "Parameter")+glyphs(300, 100, "Min")+glyphs(400, 100, "Max")+
+		glyphs(96, 120, "Voltage")+glyphs(300, 120, "1.8")+glyphs(400, 120, "3.6")+
+		glyphs(96, 140, "Current")+glyphs(300, 140, "1")+glyphs(400, 140, "5")+
+		`<Path Data="M 96,300 L 196,300 196,400 96,400 Z"><Path.Fill><ImageBrush ImageSource="../Resources/Images/logo.png" Viewbox="0,0,4,4" ViewboxUnits="Absolute" Viewport="96,300,100,100" ViewportUnits="Absolute"/></Path.Fill></Path>`+
+		`</FixedPage>`)
+
+	w, err := zw.Create("Documents/1/Resources/Images/logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{255, 0, 0, 255})
+	if err := png.Encode(w, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConvertDocument_XPS(t *testing.T) {
+	cfg := &config.Config{BaseHeaderLevel: 1, ExtractImages: true, ExtractTables: true}
+	conv, _ := NewPDFConverter(cfg, logger.NewLogger("error"))
+	res, err := conv.ConvertDocument(createTempXPS(t), t.TempDir(), ConversionOptions{})
+	if err != nil {
+		t.Fatalf("ConvertDocument() error = %v", err)
+	}
+	if res.PageCount != 2 || res.ImageCount != 1 {
+		t.Errorf("expected 2 pages and 1 image, got %d pages and %d images", res.PageCount, res.ImageCount)
+	}
+	if filepath.Base(res.OutputDir) != "MARKDOWN_sample" {
+		t.Errorf("unexpected output directory %s", res.OutputDir)
+	}
+	data, err := os.ReadFile(res.MarkdownFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(data)
+	for _, want := range []string{"The device is a low-power regulator.", "| Parameter | Min | Max |", "| Voltage | 1.8 | 3.6 |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected Markdown to contain %q:\n%s", want, md)
+		}
+	}
+	if strings.Index(md, "OVERVIEW") > strings.Index(md, "Parameter") {
+		t.Errorf("expected pages in document sequence order")
+	}
+	m := imageLinkPattern.FindStringSubmatch(md)
+	if m == nil || strings.Index(md, m[0]) < strings.Index(md, "Parameter") {
+		t.Fatalf("expected image link on page 2:\n%s", md)
+	}
+	if _, err := os.Stat(filepath.Join(res.OutputDir, m[1])); err != nil {
+		t.Errorf("expected extracted image: %v", err)
+	}
+}
+
+func TestConvertDocument_Unsupported(t *testing.T) {
+	conv, _ := NewPDFConverter(&config.Config{}, logger.NewLogger("error"))
+	if _, err := conv.ConvertDocument("/tmp/datasheet.docx", t.TempDir(), ConversionOptions{}); err == nil || !strings.Contains(err.Error(), "unsupported document format") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	if !naturalLess("Pages/2.fpage", "Pages/10.fpage") || naturalLess("Pages/10.fpage", "Pages/2.fpage") {
+		t.Errorf("expected numeric ordering of page names")
+	}
+}
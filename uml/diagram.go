@@ -3,19 +3,30 @@
 package uml
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"text/template"
 
 	"datasheet-to-md-mcp/config"
 	"datasheet-to-md-mcp/logger"
 )
 
+// extractedImagePattern matches the content-hash names of images extracted from documents.
+var extractedImagePattern = regexp.MustCompile(`^image_[0-9a-f]+\.png$`)
+
 // DiagramDetector handles the detection and analysis of diagrams in PDF images.
 type DiagramDetector struct {
 	config *config.Config
 	logger *logger.Logger
+
+	templatesOnce sync.Once
+	templates     map[string]*template.Template // PLANTUML_TEMPLATE_DIR templates by name
+	templatesErr  error
 }
 
 // DiagramType represents the type of diagram detected
@@ -30,6 +41,7 @@ const (
 	SequenceDiagram
 	ClassDiagram
 	ERDiagram
+	PlotDiagram
 )
 
 // String returns the string representation of the diagram type
@@ -49,6 +61,8 @@ func (dt DiagramType) String() string {
 		return "class"
 	case ERDiagram:
 		return "er"
+	case PlotDiagram:
+		return "plot"
 	default:
 		return "unknown"
 	}
@@ -61,6 +75,12 @@ type DetectedDiagram struct {
 	PlantUML    string
 	ImagePath   string
 	BoundingBox image.Rectangle
+	Plot        *PlotDetails // Axes, ticks and legend of a PlotDiagram, which has no PlantUML
+
+	Labels        []string              // Text recognized in the image, in reading order
+	Relationships []DiagramRelationship // Connections between Labels
+
+	Omitted bool // The type is not selected by DETECT_DIAGRAM_TYPES; reported, but not written
 }
 
 // NewDiagramDetector creates a new DiagramDetector instance
@@ -68,22 +88,45 @@ func NewDiagramDetector(cfg *config.Config, log *logger.Logger) *DiagramDetector
 	return &DiagramDetector{config: cfg, logger: log}
 }
 
-// DetectDiagramsInImage analyzes an image for diagram content and returns detected diagrams
-func (dd *DiagramDetector) DetectDiagramsInImage(imagePath string) ([]DetectedDiagram, error) {
+// DetectDiagramsInImage analyzes an image for diagram content and returns detected diagrams.
+// It returns the context's error when ctx is done.
+func (dd *DiagramDetector) DetectDiagramsInImage(ctx context.Context, imagePath string) ([]DetectedDiagram, error) {
 	if !dd.config.DetectDiagrams {
 		return []DetectedDiagram{}, nil
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	dd.logger.Debug("Analyzing image for diagrams: %s", imagePath)
 	var detectedDiagrams []DetectedDiagram
+	// Plots are recognized from the image content, before the file name heuristics
+	if plot, confidence := dd.detectPlot(imagePath); plot != nil && confidence >= dd.config.DiagramConfidence {
+		if !dd.typeSelected(PlotDiagram) {
+			dd.logger.Debug("Plot detected in %s is not a selected diagram type, confidence=%.2f", filepath.Base(imagePath), confidence)
+			return append(detectedDiagrams, DetectedDiagram{Type: PlotDiagram, Confidence: confidence, ImagePath: imagePath, Omitted: true}), nil
+		}
+		crop, name, err := savePlotCrop(imagePath, plot)
+		if err != nil {
+			dd.logger.Warn("Failed to crop plot in %s: %v", imagePath, err)
+			return detectedDiagrams, nil
+		}
+		plot.Image = name
+		dd.logger.Info("Plot detected in %s: %d x ticks, %d y ticks, %d legend entries, confidence=%.2f", filepath.Base(imagePath), plot.X.Ticks, plot.Y.Ticks, len(plot.Legend), confidence)
+		return append(detectedDiagrams, DetectedDiagram{Type: PlotDiagram, Confidence: confidence, ImagePath: imagePath, BoundingBox: crop, Plot: plot}), nil
+	}
 	confidence, diagramType := dd.analyzeImageMetadata(imagePath)
-	if confidence >= dd.config.DiagramConfidence {
+	if confidence >= dd.config.DiagramConfidence && !dd.typeSelected(diagramType) {
+		dd.logger.Debug("Diagram detected in %s is not a selected diagram type: type=%s, confidence=%.2f", filepath.Base(imagePath), diagramType.String(), confidence)
+		detectedDiagrams = append(detectedDiagrams, DetectedDiagram{Type: diagramType, Confidence: confidence, ImagePath: imagePath, Omitted: true})
+	} else if confidence >= dd.config.DiagramConfidence {
 		dd.logger.Info("Diagram detected in %s: type=%s, confidence=%.2f", filepath.Base(imagePath), diagramType.String(), confidence)
-		plantUML, err := dd.generatePlantUML(diagramType)
+		detectedDiagram := DetectedDiagram{Type: diagramType, Confidence: confidence, ImagePath: imagePath, BoundingBox: image.Rect(0, 0, 400, 300)}
+		plantUML, err := dd.diagramPlantUML(detectedDiagram)
 		if err != nil {
 			dd.logger.Warn("Failed to generate PlantUML for %s: %v", imagePath, err)
 			return detectedDiagrams, nil
 		}
-		detectedDiagram := DetectedDiagram{Type: diagramType, Confidence: confidence, PlantUML: plantUML, ImagePath: imagePath, BoundingBox: image.Rect(0, 0, 400, 300)}
+		detectedDiagram.PlantUML = plantUML
 		detectedDiagrams = append(detectedDiagrams, detectedDiagram)
 	} else {
 		dd.logger.Debug("No diagram detected in %s (confidence: %.2f < threshold: %.2f)", filepath.Base(imagePath), confidence, dd.config.DiagramConfidence)
@@ -91,6 +134,20 @@ func (dd *DiagramDetector) DetectDiagramsInImage(imagePath string) ([]DetectedDi
 	return detectedDiagrams, nil
 }
 
+// typeSelected reports whether diagrams of a type are written, as selected by
+// DETECT_DIAGRAM_TYPES. All types are written when it is not set.
+func (dd *DiagramDetector) typeSelected(diagramType DiagramType) bool {
+	if len(dd.config.DiagramTypes) == 0 {
+		return true
+	}
+	for _, selected := range dd.config.DiagramTypes {
+		if strings.EqualFold(selected, diagramType.String()) {
+			return true
+		}
+	}
+	return false
+}
+
 // analyzeImageMetadata performs basic analysis to detect diagram-like content
 func (dd *DiagramDetector) analyzeImageMetadata(imagePath string) (float64, DiagramType) {
 	filename := strings.ToLower(filepath.Base(imagePath))
@@ -113,7 +170,7 @@ func (dd *DiagramDetector) analyzeImageMetadata(imagePath string) (float64, Diag
 		return 0.8, FlowChart
 	}
 	// For placeholder images from PDF extraction, assume they could be diagrams
-	if strings.Contains(filename, "page_") && strings.Contains(filename, "image_") {
+	if strings.Contains(filename, "page_") && strings.Contains(filename, "image_") || extractedImagePattern.MatchString(filename) {
 		return 0.6, BlockDiagram
 	}
 	return 0.2, UnknownDiagram
@@ -217,8 +274,16 @@ func (dd *DiagramDetector) generateGenericDiagramUML(plantUML *strings.Builder)
 	plantUML.WriteString("note bottom : Generic diagram converted from PDF image\n")
 }
 
-// GetPlantUMLMarkdown formats the detected diagram as markdown with PlantUML code block
+// GetPlantUMLMarkdown formats the detected diagram as markdown with PlantUML code block.
+// Plots are formatted as their cropped image with the recognized axis labels and legend.
+// Omitted diagrams are not written.
 func (dd *DiagramDetector) GetPlantUMLMarkdown(diagram DetectedDiagram) string {
+	if diagram.Omitted {
+		return ""
+	}
+	if diagram.Plot != nil {
+		return plotMarkdown(diagram)
+	}
 	var md strings.Builder
 	md.WriteString(fmt.Sprintf("### Detected %s Diagram (Confidence: %.1f%%)\n\n", strings.Title(diagram.Type.String()), diagram.Confidence*100))
 	md.WriteString("```plantuml\n")
@@ -1,6 +1,8 @@
 package uml
 
 import (
+	"context"
+	"errors"
 	"image"
 	"os"
 	"path/filepath"
@@ -31,7 +33,7 @@ func TestDiagramType_String(t *testing.T) {
 		dt       DiagramType
 		expected string
 	}{
-		{UnknownDiagram, "unknown"}, {FlowChart, "flowchart"}, {BlockDiagram, "block"}, {CircuitDiagram, "circuit"}, {NetworkDiagram, "network"}, {SequenceDiagram, "sequence"}, {ClassDiagram, "class"}, {ERDiagram, "er"}, {DiagramType(999), "unknown"},
+		{UnknownDiagram, "unknown"}, {FlowChart, "flowchart"}, {BlockDiagram, "block"}, {CircuitDiagram, "circuit"}, {NetworkDiagram, "network"}, {SequenceDiagram, "sequence"}, {ClassDiagram, "class"}, {ERDiagram, "er"}, {PlotDiagram, "plot"}, {DiagramType(999), "unknown"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.expected, func(t *testing.T) {
@@ -48,7 +50,7 @@ func TestDetectDiagramsInImage_DisabledDetection(t *testing.T) {
 	d := NewDiagramDetector(cfg, logr)
 	tempFile := createTempImageFile(t, "test_diagram.png")
 	defer os.Remove(tempFile)
-	diagrams, err := d.DetectDiagramsInImage(tempFile)
+	diagrams, err := d.DetectDiagramsInImage(context.Background(), tempFile)
 	if err != nil {
 		t.Fatalf("DetectDiagramsInImage failed: %v", err)
 	}
@@ -57,6 +59,17 @@ func TestDetectDiagramsInImage_DisabledDetection(t *testing.T) {
 	}
 }
 
+func TestDetectDiagramsInImage_Cancelled(t *testing.T) {
+	d := NewDiagramDetector(&config.Config{DetectDiagrams: true, DiagramConfidence: 0.5}, logger.NewLogger("info"))
+	tempFile := createTempImageFile(t, "test_diagram.png")
+	defer os.Remove(tempFile)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if diagrams, err := d.DetectDiagramsInImage(ctx, tempFile); !errors.Is(err, context.Canceled) || len(diagrams) != 0 {
+		t.Errorf("expected a canceled detection, got %d diagram(s), %v", len(diagrams), err)
+	}
+}
+
 func TestDetectDiagramsInImage_EnabledDetection(t *testing.T) {
 	cfg := &config.Config{DetectDiagrams: true, DiagramConfidence: 0.5, PlantUMLStyle: "default", PlantUMLColorScheme: "auto"}
 	logr := logger.NewLogger("info")
@@ -78,7 +91,7 @@ func TestDetectDiagramsInImage_EnabledDetection(t *testing.T) {
 		t.Run(tt.filename, func(t *testing.T) {
 			tempFile := createTempImageFile(t, tt.filename)
 			defer os.Remove(tempFile)
-			diagrams, err := d.DetectDiagramsInImage(tempFile)
+			diagrams, err := d.DetectDiagramsInImage(context.Background(), tempFile)
 			if err != nil {
 				t.Fatalf("DetectDiagramsInImage failed: %v", err)
 			}
@@ -126,6 +139,7 @@ func TestAnalyzeImageMetadata(t *testing.T) {
 		{"topology_view.png", 0.8, NetworkDiagram},
 		{"page_1_image_1.png", 0.6, BlockDiagram},
 		{"page_2_image_3.jpg", 0.6, BlockDiagram},
+		{"image_3f2a9c04b1d7e865.png", 0.6, BlockDiagram},
 		{"random_photo.jpg", 0.2, UnknownDiagram},
 		{"document_scan.pdf", 0.2, UnknownDiagram},
 	}
@@ -260,7 +274,7 @@ func TestDiagramDetectorWithRealPDF(t *testing.T) {
 	d := NewDiagramDetector(cfg, logr)
 	tempFile := createTempImageFile(t, "page_1_image_1.png")
 	defer os.Remove(tempFile)
-	diagrams, err := d.DetectDiagramsInImage(tempFile)
+	diagrams, err := d.DetectDiagramsInImage(context.Background(), tempFile)
 	if err != nil {
 		t.Fatalf("DetectDiagramsInImage failed: %v", err)
 	}
@@ -300,7 +314,7 @@ func TestDiagramDetectorEdgeCases(t *testing.T) {
 	logr := logger.NewLogger("info")
 	d := NewDiagramDetector(cfg, logr)
 	t.Run("non-existent file", func(t *testing.T) {
-		diagrams, err := d.DetectDiagramsInImage("/non/existent/file.png")
+		diagrams, err := d.DetectDiagramsInImage(context.Background(), "/non/existent/file.png")
 		if err != nil {
 			t.Errorf("Expected no error, got: %v", err)
 		}
@@ -312,7 +326,7 @@ func TestDiagramDetectorEdgeCases(t *testing.T) {
 		cfg.DiagramConfidence = 0.9
 		tempFile := createTempImageFile(t, "page_1_image_1.png")
 		defer os.Remove(tempFile)
-		diagrams, err := d.DetectDiagramsInImage(tempFile)
+		diagrams, err := d.DetectDiagramsInImage(context.Background(), tempFile)
 		if err != nil {
 			t.Fatalf("DetectDiagramsInImage failed: %v", err)
 		}
@@ -323,7 +337,7 @@ func TestDiagramDetectorEdgeCases(t *testing.T) {
 	t.Run("empty filename", func(t *testing.T) {
 		tempFile := createTempImageFile(t, "empty.png")
 		defer os.Remove(tempFile)
-		diagrams, err := d.DetectDiagramsInImage(tempFile)
+		diagrams, err := d.DetectDiagramsInImage(context.Background(), tempFile)
 		if err != nil {
 			t.Errorf("Expected no error for empty filename, got: %v", err)
 		}
@@ -333,3 +347,22 @@ func TestDiagramDetectorEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectDiagramsInImage_UnselectedType(t *testing.T) {
+	cfg := &config.Config{DetectDiagrams: true, DiagramConfidence: 0.7, PlantUMLStyle: "default", PlantUMLColorScheme: "auto", DiagramTypes: []string{"Flowchart", "plot"}}
+	d := NewDiagramDetector(cfg, logger.NewLogger("error"))
+	diagrams, err := d.DetectDiagramsInImage(context.Background(), createTempImageFile(t, "power_block.png"))
+	if err != nil || len(diagrams) != 1 {
+		t.Fatalf("expected the block diagram reported, got %v, %v", diagrams, err)
+	}
+	if !diagrams[0].Omitted || diagrams[0].PlantUML != "" || diagrams[0].Type != BlockDiagram {
+		t.Errorf("expected the block diagram omitted without PlantUML, got %+v", diagrams[0])
+	}
+	if md := d.GetPlantUMLMarkdown(diagrams[0]); md != "" {
+		t.Errorf("expected no Markdown for an omitted diagram, got %q", md)
+	}
+	diagrams, _ = d.DetectDiagramsInImage(context.Background(), createTempImageFile(t, "reset_flowchart.png"))
+	if len(diagrams) != 1 || diagrams[0].Omitted || diagrams[0].PlantUML == "" {
+		t.Errorf("expected the selected flowchart written, got %+v", diagrams)
+	}
+}
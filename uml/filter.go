@@ -0,0 +1,41 @@
+// Package uml - Image filters.
+// This file measures how much line detail an image has, so callers can skip diagram
+// detection for images that cannot hold a diagram, such as logos, photos of packages and
+// flat color fills, before paying for detection and OCR.
+package uml
+
+import (
+	"image"
+)
+
+// Edge density thresholds
+const (
+	edgeSampleSide = 256 // Pixels sampled along the longest side of an image
+	edgeContrast   = 48  // Luminance difference to a neighbour that makes a pixel an edge
+)
+
+// EdgeDensity returns the fraction of pixels of an image, from 0.0 to 1.0, that differ
+// sharply in luminance from their right or lower neighbour. Line drawings score well
+// above flat or smoothly shaded images. Large images are sampled on a grid.
+func EdgeDensity(img image.Image) float64 {
+	b := img.Bounds()
+	if b.Dx() < 2 || b.Dy() < 2 {
+		return 0
+	}
+	step := (max(b.Dx(), b.Dy()) + edgeSampleSide - 1) / edgeSampleSide
+	luminance := func(x, y int) int {
+		r, g, bl, _ := img.At(x, y).RGBA()
+		return int((299*r + 587*g + 114*bl) / 1000 >> 8)
+	}
+	var edges, samples int
+	for y := b.Min.Y; y < b.Max.Y-1; y += step {
+		for x := b.Min.X; x < b.Max.X-1; x += step {
+			l := luminance(x, y)
+			if abs(l-luminance(x+1, y)) >= edgeContrast || abs(l-luminance(x, y+1)) >= edgeContrast {
+				edges++
+			}
+			samples++
+		}
+	}
+	return float64(edges) / float64(samples)
+}
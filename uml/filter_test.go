@@ -0,0 +1,37 @@
+package uml
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestEdgeDensity(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 300, 200))
+	draw.Draw(blank, blank.Bounds(), image.White, image.Point{}, draw.Src)
+	if density := EdgeDensity(blank); density != 0 {
+		t.Errorf("expected no edges in a blank image, got %f", density)
+	}
+
+	// A grid of boxes outlined like a block diagram
+	drawing := image.NewGray(image.Rect(0, 0, 300, 200))
+	draw.Draw(drawing, drawing.Bounds(), image.White, image.Point{}, draw.Src)
+	for x := 0; x < 300; x += 20 {
+		for y := 0; y < 200; y++ {
+			drawing.SetGray(x, y, color.Gray{})
+		}
+	}
+	if density := EdgeDensity(drawing); density < 0.05 {
+		t.Errorf("expected the line drawing to have edges, got %f", density)
+	}
+
+	// Large images are sampled rather than read pixel by pixel
+	large := image.NewGray(image.Rect(0, 0, 4000, 3000))
+	if density := EdgeDensity(large); density != 0 {
+		t.Errorf("expected no edges in a blank large image, got %f", density)
+	}
+	if density := EdgeDensity(image.NewGray(image.Rect(0, 0, 1, 1))); density != 0 {
+		t.Errorf("expected no edges in a single pixel, got %f", density)
+	}
+}
@@ -0,0 +1,554 @@
+// Package uml - Plot detection.
+// This file recognizes graphs and plots in extracted images from their geometry: an x and
+// a y axis meeting at the origin, regularly spaced tick marks along them and legend line
+// samples inside the plot area. Plots have no PlantUML form, so they are written as the
+// image cropped to the plot together with the axis labels and legend text recognized in it.
+package uml
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // Extracted images may be saved as JPEG
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Plot detection thresholds, in pixels unless noted
+const (
+	plotInkLevel        = 128  // Luminance below which a pixel is ink
+	plotAxisMinShare    = 0.4  // Shortest axis as a share of the image width or height
+	plotOriginTolerance = 4    // Distance allowed between the axis ends at the origin
+	plotTickMin         = 2    // Shortest tick mark
+	plotTickMax         = 12   // Longest tick mark; longer marks count only as grid lines
+	plotGridShare       = 0.8  // Shortest grid line as a share of the other axis
+	plotMinTicks        = 3    // Tick marks an axis needs to count as ticked
+	plotTickSpread      = 0.25 // Largest deviation of tick spacing from its mean, relative to it
+	plotSwatchMin       = 8    // Shortest legend line sample
+	plotSwatchMax       = 60   // Longest legend line sample
+	plotSwatchAlign     = 2    // Distance allowed between the left ends of legend samples
+	plotCropMargin      = 0.05 // Margin kept above and right of the plot, as a share of the image
+)
+
+// Plot detection confidence contributions
+const (
+	plotAxesConfidence   = 0.5  // An x and a y axis meeting at the origin
+	plotTicksConfidence  = 0.15 // Regular tick marks, per axis
+	plotLegendConfidence = 0.1  // Legend line samples
+	plotNameConfidence   = 0.1  // A file name naming a plot, graph, curve or chart
+	plotMaxConfidence    = 0.95
+)
+
+// plotNameHints are file name words that suggest a plot.
+var plotNameHints = []string{"plot", "graph", "curve", "chart"}
+
+// PlotDetails describes a detected plot.
+type PlotDetails struct {
+	Area   image.Rectangle   // Plot area bounded by the axes, in image pixels
+	Image  string            // File name of the image cropped to the plot, next to the original
+	X      PlotAxis          // Horizontal axis
+	Y      PlotAxis          // Vertical axis
+	Legend []PlotLegendEntry // Legend entries, top to bottom
+
+	swatches []image.Rectangle // Legend line samples, in the order of Legend
+}
+
+// PlotAxis is an axis of a detected plot.
+type PlotAxis struct {
+	Ticks      int      // Tick marks found along the axis
+	TickLabels []string // Labels of the ticks, left to right or bottom to top
+	Title      string   // Axis title, e.g. "Temperature (°C)"
+}
+
+// PlotLegendEntry is a legend entry of a detected plot.
+type PlotLegendEntry struct {
+	Color string // Color of the line sample as #rrggbb
+	Text  string // Text next to the sample, empty until labeled
+}
+
+// PlotWord is a word recognized in a plot image, such as an OCR result.
+type PlotWord struct {
+	Text string
+	Box  image.Rectangle // Bounds of the word in image pixels
+}
+
+// plotImage is a decoded image with its ink pixels.
+type plotImage struct {
+	img    image.Image
+	bounds image.Rectangle
+	ink    []bool // Row-major, relative to bounds.Min
+}
+
+// newPlotImage marks the ink pixels of img.
+func newPlotImage(img image.Image) *plotImage {
+	b := img.Bounds()
+	p := &plotImage{img: img, bounds: b, ink: make([]bool, b.Dx()*b.Dy())}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			if a == 0 {
+				continue
+			}
+			luminance := (299*r + 587*g + 114*bl) / 1000 >> 8
+			p.ink[(y-b.Min.Y)*b.Dx()+x-b.Min.X] = luminance < plotInkLevel
+		}
+	}
+	return p
+}
+
+// at reports whether the pixel at x, y, relative to the image origin, is ink.
+func (p *plotImage) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= p.bounds.Dx() || y >= p.bounds.Dy() {
+		return false
+	}
+	return p.ink[y*p.bounds.Dx()+x]
+}
+
+// rowRun returns the longest run of ink in row y as [x0, x1).
+func (p *plotImage) rowRun(y int) (int, int) {
+	best0, best1, start := 0, 0, -1
+	for x := 0; x <= p.bounds.Dx(); x++ {
+		if x < p.bounds.Dx() && p.at(x, y) {
+			if start < 0 {
+				start = x
+			}
+			continue
+		}
+		if start >= 0 && x-start > best1-best0 {
+			best0, best1 = start, x
+		}
+		start = -1
+	}
+	return best0, best1
+}
+
+// columnRun returns the longest run of ink in column x as [y0, y1).
+func (p *plotImage) columnRun(x int) (int, int) {
+	best0, best1, start := 0, 0, -1
+	for y := 0; y <= p.bounds.Dy(); y++ {
+		if y < p.bounds.Dy() && p.at(x, y) {
+			if start < 0 {
+				start = y
+			}
+			continue
+		}
+		if start >= 0 && y-start > best1-best0 {
+			best0, best1 = start, y
+		}
+		start = -1
+	}
+	return best0, best1
+}
+
+// inkRun counts the ink pixels from x, y in the direction dx, dy up to limit.
+func (p *plotImage) inkRun(x, y, dx, dy, limit int) int {
+	n := 0
+	for n < limit && p.at(x+n*dx, y+n*dy) {
+		n++
+	}
+	return n
+}
+
+// plotAxes are the axes of a plot: the bands of rows and columns they are drawn with and
+// their extent, relative to the image origin.
+type plotAxes struct {
+	xTop, xBottom int // Rows of the x axis
+	xStart, xEnd  int // Extent of the x axis
+	yLeft, yRight int // Columns of the y axis
+	yStart, yEnd  int // Extent of the y axis, top to bottom
+}
+
+// detectPlot analyzes an image for a plot. It returns nil when the image has no axes.
+func (dd *DiagramDetector) detectPlot(imagePath string) (*PlotDetails, float64) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, 0
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return nil, 0
+	}
+	p := newPlotImage(img)
+	axes, ok := p.axes()
+	if !ok {
+		return nil, 0
+	}
+
+	plot := &PlotDetails{Area: image.Rect(axes.yRight+1, axes.yStart, axes.xEnd, axes.xTop)}
+	confidence := plotAxesConfidence
+	if plot.X.Ticks = p.xTicks(axes); plot.X.Ticks > 0 {
+		confidence += plotTicksConfidence
+	}
+	if plot.Y.Ticks = p.yTicks(axes); plot.Y.Ticks > 0 {
+		confidence += plotTicksConfidence
+	}
+	for _, swatch := range p.legendSwatches(plot.Area) {
+		plot.swatches = append(plot.swatches, swatch)
+		plot.Legend = append(plot.Legend, PlotLegendEntry{Color: p.color(swatch)})
+	}
+	if len(plot.Legend) > 0 {
+		confidence += plotLegendConfidence
+	}
+	name := strings.ToLower(filepath.Base(imagePath))
+	for _, hint := range plotNameHints {
+		if strings.Contains(name, hint) {
+			confidence += plotNameConfidence
+			break
+		}
+	}
+	return plot, math.Min(confidence, plotMaxConfidence)
+}
+
+// axes finds the x axis, the lowest long horizontal line, and the y axis, the leftmost long
+// vertical line, and checks that they meet at the origin.
+func (p *plotImage) axes() (plotAxes, bool) {
+	w, h := p.bounds.Dx(), p.bounds.Dy()
+	var a plotAxes
+	a.xBottom = -1
+	for y := h - 1; y >= 0; y-- {
+		if x0, x1 := p.rowRun(y); float64(x1-x0) >= plotAxisMinShare*float64(w) {
+			a.xBottom, a.xStart, a.xEnd = y, x0, x1
+			break
+		}
+	}
+	a.yLeft = -1
+	for x := 0; x < w; x++ {
+		if y0, y1 := p.columnRun(x); float64(y1-y0) >= plotAxisMinShare*float64(h) {
+			a.yLeft, a.yStart, a.yEnd = x, y0, y1
+			break
+		}
+	}
+	if a.xBottom < 0 || a.yLeft < 0 {
+		return a, false
+	}
+	a.xTop = a.xBottom
+	for a.xTop > 0 {
+		if x0, x1 := p.rowRun(a.xTop - 1); x1-x0 < (a.xEnd-a.xStart)/2 {
+			break
+		}
+		a.xTop--
+	}
+	a.yRight = a.yLeft
+	for a.yRight < w-1 {
+		if y0, y1 := p.columnRun(a.yRight + 1); y1-y0 < (a.yEnd-a.yStart)/2 {
+			break
+		}
+		a.yRight++
+	}
+
+	// The origin is at the left end of the x axis and the bottom end of the y axis
+	xSlack := (a.xEnd - a.xStart) / 10
+	ySlack := (a.yEnd - a.yStart) / 10
+	if a.yLeft < a.xStart-plotOriginTolerance || a.yLeft > a.xStart+xSlack {
+		return a, false
+	}
+	if a.xBottom > a.yEnd+plotOriginTolerance || a.xBottom < a.yEnd-ySlack {
+		return a, false
+	}
+	return a, true
+}
+
+// xTicks counts the tick marks along the x axis: short marks below or above it, or grid
+// lines rising from it. It returns 0 unless enough marks are regularly spaced.
+func (p *plotImage) xTicks(a plotAxes) int {
+	var marks []bool
+	gridLength := int(plotGridShare * float64(a.xTop-a.yStart))
+	for x := a.yRight + 1; x < a.xEnd; x++ {
+		below := p.inkRun(x, a.xBottom+1, 0, 1, plotTickMax+1)
+		above := p.inkRun(x, a.xTop-1, 0, -1, a.xTop+1)
+		marks = append(marks, isTick(below) || isTick(above) || above >= gridLength)
+	}
+	return regularTicks(marks)
+}
+
+// yTicks counts the tick marks along the y axis, like xTicks.
+func (p *plotImage) yTicks(a plotAxes) int {
+	var marks []bool
+	gridLength := int(plotGridShare * float64(a.xEnd-a.yRight))
+	for y := a.yStart; y < a.xTop; y++ {
+		left := p.inkRun(a.yLeft-1, y, -1, 0, plotTickMax+1)
+		right := p.inkRun(a.yRight+1, y, 1, 0, p.bounds.Dx())
+		marks = append(marks, isTick(left) || isTick(right) || right >= gridLength)
+	}
+	return regularTicks(marks)
+}
+
+// isTick reports whether a mark of length n is a tick mark.
+func isTick(n int) bool {
+	return n >= plotTickMin && n <= plotTickMax
+}
+
+// regularTicks groups adjacent marked positions into tick marks and returns their number
+// when there are at least plotMinTicks with regular spacing, 0 otherwise.
+func regularTicks(marks []bool) int {
+	var centers []float64
+	start := -1
+	for i := 0; i <= len(marks); i++ {
+		if i < len(marks) && marks[i] {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			centers = append(centers, float64(start+i-1)/2)
+		}
+		start = -1
+	}
+	if len(centers) < plotMinTicks {
+		return 0
+	}
+	mean := (centers[len(centers)-1] - centers[0]) / float64(len(centers)-1)
+	for i := 1; i < len(centers); i++ {
+		if math.Abs(centers[i]-centers[i-1]-mean) > plotTickSpread*mean {
+			return 0
+		}
+	}
+	return len(centers)
+}
+
+// legendSwatches finds the line samples of a legend inside the plot area: isolated
+// horizontal strokes of similar length that start in the same column on several rows.
+func (p *plotImage) legendSwatches(area image.Rectangle) []image.Rectangle {
+	var strokes []image.Rectangle
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		start := -1
+		for x := area.Min.X; x <= area.Max.X; x++ {
+			if x < area.Max.X && p.at(x, y) {
+				if start < 0 {
+					start = x
+				}
+				continue
+			}
+			if start >= 0 && x-start >= plotSwatchMin && x-start <= plotSwatchMax && p.isolated(start, x, y) {
+				strokes = append(strokes, image.Rect(start, y, x, y+1))
+			}
+			start = -1
+		}
+	}
+
+	// Thick strokes span several rows; keep the top row of each
+	var samples []image.Rectangle
+	for _, s := range strokes {
+		merged := false
+		for i := range samples {
+			if samples[i].Max.Y == s.Min.Y && abs(samples[i].Min.X-s.Min.X) <= plotSwatchAlign {
+				samples[i].Max.Y, merged = s.Max.Y, true
+				break
+			}
+		}
+		if !merged {
+			samples = append(samples, s)
+		}
+	}
+
+	var best []image.Rectangle
+	for _, s := range samples {
+		var group []image.Rectangle
+		for _, other := range samples {
+			if abs(other.Min.X-s.Min.X) <= plotSwatchAlign && abs(other.Dx()-s.Dx()) <= plotSwatchAlign*2 {
+				group = append(group, other)
+			}
+		}
+		if len(group) > len(best) {
+			best = group
+		}
+	}
+	if len(best) < 2 {
+		return nil
+	}
+	return best
+}
+
+// isolated reports whether the stroke [x0, x1) in row y has no ink above and below it,
+// apart from the rows of a thick stroke, which distinguishes line samples from text and
+// from curves.
+func (p *plotImage) isolated(x0, x1, y int) bool {
+	top, bottom := y, y
+	for top > 0 && p.inkRun(x0, top-1, 1, 0, x1-x0) == x1-x0 {
+		top--
+	}
+	for bottom < p.bounds.Dy()-1 && p.inkRun(x0, bottom+1, 1, 0, x1-x0) == x1-x0 {
+		bottom++
+	}
+	for x := x0 - 1; x <= x1; x++ {
+		if p.at(x, top-2) || p.at(x, bottom+2) {
+			return false
+		}
+	}
+	return !p.at(x0-1, y) && !p.at(x1, y)
+}
+
+// color returns the mean color of the pixels of r as #rrggbb.
+func (p *plotImage) color(r image.Rectangle) string {
+	var sr, sg, sb, n uint32
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			cr, cg, cb, _ := p.img.At(p.bounds.Min.X+x, p.bounds.Min.Y+y).RGBA()
+			sr, sg, sb, n = sr+cr>>8, sg+cg>>8, sb+cb>>8, n+1
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", sr/n, sg/n, sb/n)
+}
+
+// savePlotCrop writes the image cropped to the plot and its labels next to the original and
+// returns the crop rectangle and file name. The axis labels are left of and below the axes,
+// so the crop keeps everything there.
+func savePlotCrop(imagePath string, plot *PlotDetails) (image.Rectangle, string, error) {
+	img, err := imaging.Open(imagePath)
+	if err != nil {
+		return image.Rectangle{}, "", err
+	}
+	b := img.Bounds()
+	marginX, marginY := int(plotCropMargin*float64(b.Dx())), int(plotCropMargin*float64(b.Dy()))
+	crop := image.Rect(0, plot.Area.Min.Y-marginY, plot.Area.Max.X+marginX, b.Dy()).Intersect(image.Rect(0, 0, b.Dx(), b.Dy()))
+	name := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath)) + "_plot.png"
+	if err := imaging.Save(imaging.Crop(img, crop.Add(b.Min)), filepath.Join(filepath.Dir(imagePath), name)); err != nil {
+		return image.Rectangle{}, "", err
+	}
+	return crop, name, nil
+}
+
+// LabelPlot assigns the words recognized in the image of a plot diagram to its axes and
+// legend: tick labels are the first line below the x axis and the column right next to
+// the y axis, farther words are the axis titles, and words to the right of a legend line
+// sample on its row are the sample's text. Words inside the plot area elsewhere, such as
+// curve annotations, are left out.
+func (dd *DiagramDetector) LabelPlot(diagram *DetectedDiagram, words []PlotWord) {
+	plot := diagram.Plot
+	if plot == nil {
+		return
+	}
+	area := plot.Area
+	var below, left []PlotWord
+	legend := make([][]PlotWord, len(plot.Legend))
+	for _, word := range words {
+		center := image.Pt((word.Box.Min.X+word.Box.Max.X)/2, (word.Box.Min.Y+word.Box.Max.Y)/2)
+		switch {
+		case word.Box.Min.Y >= area.Max.Y && center.X >= area.Min.X-word.Box.Dx():
+			below = append(below, word)
+		case word.Box.Max.X <= area.Min.X:
+			left = append(left, word)
+		case center.In(area):
+			for i, swatch := range plot.swatches {
+				if word.Box.Min.X >= swatch.Max.X && abs(center.Y-swatch.Min.Y) <= word.Box.Dy() {
+					legend[i] = append(legend[i], word)
+					break
+				}
+			}
+		}
+	}
+
+	// The first line below the x axis holds the tick labels, the lines below it the title
+	sort.Slice(below, func(i, j int) bool { return below[i].Box.Min.Y < below[j].Box.Min.Y })
+	var ticks, title []PlotWord
+	for _, word := range below {
+		if word.Box.Min.Y < below[0].Box.Max.Y {
+			ticks = append(ticks, word)
+		} else {
+			title = append(title, word)
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Box.Min.X < ticks[j].Box.Min.X })
+	for _, word := range ticks {
+		plot.X.TickLabels = append(plot.X.TickLabels, word.Text)
+	}
+	plot.X.Title = joinWords(title)
+
+	// Tick labels end next to the y axis, bottom to top; the title is farther left
+	rightmost := 0
+	for _, word := range left {
+		rightmost = max(rightmost, word.Box.Max.X)
+	}
+	ticks, title = nil, nil
+	for _, word := range left {
+		// Rotated titles have tall word boxes, so the tolerance is the narrower side
+		if rightmost-word.Box.Max.X <= min(word.Box.Dx(), word.Box.Dy()) {
+			ticks = append(ticks, word)
+		} else {
+			title = append(title, word)
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Box.Min.Y > ticks[j].Box.Min.Y })
+	for _, word := range ticks {
+		plot.Y.TickLabels = append(plot.Y.TickLabels, word.Text)
+	}
+	plot.Y.Title = joinWords(title)
+
+	for i, entry := range legend {
+		sort.Slice(entry, func(a, b int) bool { return entry[a].Box.Min.X < entry[b].Box.Min.X })
+		plot.Legend[i].Text = joinWords(entry)
+	}
+}
+
+// joinWords joins words in reading order: top to bottom, then left to right on a line.
+func joinWords(words []PlotWord) string {
+	sorted := append([]PlotWord(nil), words...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if abs(sorted[i].Box.Min.Y-sorted[j].Box.Min.Y) > sorted[i].Box.Dy()/2 {
+			return sorted[i].Box.Min.Y < sorted[j].Box.Min.Y
+		}
+		return sorted[i].Box.Min.X < sorted[j].Box.Min.X
+	})
+	texts := make([]string, len(sorted))
+	for i, word := range sorted {
+		texts[i] = word.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// plotMarkdown formats a detected plot as its cropped image followed by the recognized
+// axis labels and legend.
+func plotMarkdown(diagram DetectedDiagram) string {
+	plot := diagram.Plot
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("### Detected Plot (Confidence: %.1f%%)\n\n", diagram.Confidence*100))
+	md.WriteString(fmt.Sprintf("![Plot](./%s)\n\n", plot.Image))
+	for _, axis := range []struct {
+		name string
+		axis PlotAxis
+	}{{"X axis", plot.X}, {"Y axis", plot.Y}} {
+		var parts []string
+		if axis.axis.Title != "" {
+			parts = append(parts, axis.axis.Title)
+		}
+		if len(axis.axis.TickLabels) > 0 {
+			parts = append(parts, "ticks "+strings.Join(axis.axis.TickLabels, ", "))
+		} else if axis.axis.Ticks > 0 {
+			parts = append(parts, fmt.Sprintf("%d ticks", axis.axis.Ticks))
+		}
+		if len(parts) > 0 {
+			md.WriteString(fmt.Sprintf("- %s: %s\n", axis.name, strings.Join(parts, "; ")))
+		}
+	}
+	if len(plot.Legend) > 0 {
+		var entries []string
+		for _, entry := range plot.Legend {
+			text := entry.Text
+			if text == "" {
+				text = "(unlabeled)"
+			}
+			entries = append(entries, fmt.Sprintf("%s %s", text, entry.Color))
+		}
+		md.WriteString(fmt.Sprintf("- Legend: %s\n", strings.Join(entries, ", ")))
+	}
+	md.WriteString(fmt.Sprintf("\n*Original image: %s*\n\n", filepath.Base(diagram.ImagePath)))
+	return md.String()
+}
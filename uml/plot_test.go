@@ -0,0 +1,134 @@
+package uml
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+// createPlotImage draws a plot with ticked axes, a curve and a two-entry legend.
+func createPlotImage(t *testing.T) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	fill := func(x0, y0, x1, y1 int, c color.Color) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	fill(0, 0, 400, 300, color.White)
+	fill(50, 249, 380, 251, color.Black) // x axis
+	fill(50, 20, 52, 251, color.Black)   // y axis
+	for x := 100; x <= 350; x += 50 {
+		fill(x, 251, x+1, 256, color.Black)
+	}
+	for y := 50; y <= 200; y += 50 {
+		fill(45, y, 50, y+1, color.Black)
+	}
+	for x := 60; x < 370; x++ {
+		img.Set(x, 240-(x-60)*2/3, color.Black)
+	}
+	fill(280, 40, 310, 42, color.RGBA{255, 0, 0, 255})
+	fill(280, 60, 310, 62, color.RGBA{0, 0, 255, 255})
+
+	path := filepath.Join(t.TempDir(), "image_0123abcd.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectDiagramsInImage_Plot(t *testing.T) {
+	d := NewDiagramDetector(&config.Config{DetectDiagrams: true, DiagramConfidence: 0.7}, logger.NewLogger("error"))
+	imagePath := createPlotImage(t)
+	diagrams, err := d.DetectDiagramsInImage(context.Background(), imagePath)
+	if err != nil {
+		t.Fatalf("DetectDiagramsInImage failed: %v", err)
+	}
+	if len(diagrams) != 1 || diagrams[0].Type != PlotDiagram || diagrams[0].Plot == nil {
+		t.Fatalf("expected one plot, got %+v", diagrams)
+	}
+	diagram := diagrams[0]
+	plot := diagram.Plot
+	if plot.X.Ticks != 6 || plot.Y.Ticks != 4 || diagram.PlantUML != "" {
+		t.Errorf("expected 6 x and 4 y ticks without PlantUML, got %+v", plot)
+	}
+	if len(plot.Legend) != 2 || plot.Legend[0].Color != "#ff0000" || plot.Legend[1].Color != "#0000ff" {
+		t.Errorf("expected a red and a blue legend entry, got %+v", plot.Legend)
+	}
+	crop, err := os.Open(filepath.Join(filepath.Dir(imagePath), plot.Image))
+	if err != nil {
+		t.Fatalf("expected the cropped plot image: %v", err)
+	}
+	defer crop.Close()
+	if config, err := png.DecodeConfig(crop); err != nil || config.Width != diagram.BoundingBox.Dx() || config.Height != diagram.BoundingBox.Dy() {
+		t.Errorf("expected a crop of %v, got %+v %v", diagram.BoundingBox, config, err)
+	}
+
+	d.LabelPlot(&diagram, []PlotWord{
+		{"0", image.Rect(45, 260, 55, 270)},
+		{"100", image.Rect(142, 260, 158, 270)},
+		{"50", image.Rect(95, 260, 105, 270)},
+		{"Temperature", image.Rect(150, 280, 230, 292)},
+		{"(°C)", image.Rect(235, 280, 260, 292)},
+		{"10", image.Rect(30, 195, 42, 205)},
+		{"20", image.Rect(30, 145, 42, 155)},
+		{"Current", image.Rect(5, 100, 15, 160)},
+		{"VDD=3.3V", image.Rect(315, 35, 370, 47)},
+		{"VDD=5V", image.Rect(315, 55, 360, 67)},
+		{"Typ", image.Rect(200, 200, 220, 210)},
+	})
+	if strings.Join(plot.X.TickLabels, ",") != "0,50,100" || plot.X.Title != "Temperature (°C)" {
+		t.Errorf("unexpected x axis labels: %+v", plot.X)
+	}
+	if strings.Join(plot.Y.TickLabels, ",") != "10,20" || plot.Y.Title != "Current" {
+		t.Errorf("unexpected y axis labels: %+v", plot.Y)
+	}
+	if plot.Legend[0].Text != "VDD=3.3V" || plot.Legend[1].Text != "VDD=5V" {
+		t.Errorf("unexpected legend text: %+v", plot.Legend)
+	}
+
+	md := d.GetPlantUMLMarkdown(diagram)
+	for _, want := range []string{"### Detected Plot", "![Plot](./image_0123abcd_plot.png)", "- X axis: Temperature (°C); ticks 0, 50, 100", "- Legend: VDD=3.3V #ff0000, VDD=5V #0000ff", "*Original image: image_0123abcd.png*"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in plot markdown:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "plantuml") {
+		t.Errorf("expected no PlantUML for a plot:\n%s", md)
+	}
+}
+
+func TestDetectPlot_NoAxes(t *testing.T) {
+	d := NewDiagramDetector(&config.Config{DetectDiagrams: true, DiagramConfidence: 0.7}, logger.NewLogger("error"))
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	path := filepath.Join(t.TempDir(), "graph.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if plot, confidence := d.detectPlot(path); plot != nil || confidence != 0 {
+		t.Errorf("expected no plot in a blank image, got %+v %.2f", plot, confidence)
+	}
+}
@@ -0,0 +1,224 @@
+// Package uml - PlantUML templates.
+// This file renders detected diagrams with templates from PLANTUML_TEMPLATE_DIR, one per
+// diagram type, so generated diagrams follow in-house styling standards instead of the
+// built-in snippets. Templates use Go text/template syntax and receive the labels
+// recognized in the image and the relationships between them.
+package uml
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	// templateExtension is the file extension of PlantUML templates.
+	templateExtension = ".puml"
+	// genericTemplate is the template name of diagram types without a template of their own.
+	genericTemplate = "generic"
+)
+
+// aliasPattern matches the characters that cannot appear in a PlantUML alias.
+var aliasPattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// DiagramRelationship is a connection between two labels of a diagram.
+type DiagramRelationship struct {
+	From  string
+	To    string
+	Label string
+}
+
+// TemplateData are the variables available to PlantUML templates.
+type TemplateData struct {
+	Type          string  // Diagram type, e.g. "block"
+	Confidence    float64 // Detection confidence from 0.0 to 1.0
+	Image         string  // File name of the diagram image
+	Detected      bool    // Whether Labels were recognized in the image rather than placeholders
+	Labels        []string
+	Relationships []DiagramRelationship
+}
+
+// templateFuncs are the functions available to PlantUML templates besides the built-in ones.
+var templateFuncs = template.FuncMap{
+	// alias turns a label into a PlantUML alias, e.g. "ADC 1" into "ADC_1"
+	"alias": func(label string) string {
+		alias := strings.Trim(aliasPattern.ReplaceAllString(label, "_"), "_")
+		if alias == "" || alias[0] >= '0' && alias[0] <= '9' {
+			alias = "n" + alias
+		}
+		return alias
+	},
+	// quote quotes a label as a PlantUML string
+	"quote": func(label string) string {
+		return `"` + strings.ReplaceAll(label, `"`, `'`) + `"`
+	},
+	"join": strings.Join,
+}
+
+// placeholderDiagrams are the labels and relationships drawn by the built-in snippets, given
+// to templates when no labels were recognized in the image.
+var placeholderDiagrams = map[DiagramType][]DiagramRelationship{
+	FlowChart:      {{"Process Input", "Action A", "yes"}, {"Process Input", "Action B", "no"}, {"Action A", "Generate Output", ""}, {"Action B", "Generate Output", ""}},
+	BlockDiagram:   {{"Input Signal", "Processing Unit", ""}, {"Processing Unit", "Output Signal", ""}},
+	CircuitDiagram: {{"VCC", "R1", ""}, {"R1", "C1", ""}, {"C1", "GND", ""}},
+	NetworkDiagram: {{"User", "Server", "Connects to"}, {"Server", "Database", "Reads/Writes"}},
+	UnknownDiagram: {{"Component A", "Component B", ""}, {"Component B", "Component C", ""}},
+}
+
+// templateName returns the template file name, without extension, of a diagram type.
+func templateName(diagramType DiagramType) string {
+	switch diagramType {
+	case FlowChart, BlockDiagram, CircuitDiagram, NetworkDiagram:
+		return diagramType.String()
+	default:
+		return genericTemplate
+	}
+}
+
+// loadTemplates parses the templates of PLANTUML_TEMPLATE_DIR once. Diagram types without
+// a template file use the built-in snippets.
+func (dd *DiagramDetector) loadTemplates() (map[string]*template.Template, error) {
+	dd.templatesOnce.Do(func() {
+		dir := dd.config.PlantUMLTemplateDir
+		if dir == "" {
+			return
+		}
+		dd.templates = map[string]*template.Template{}
+		for _, name := range []string{"flowchart", "block", "circuit", "network", genericTemplate} {
+			path := filepath.Join(dir, name+templateExtension)
+			data, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				dd.templatesErr = fmt.Errorf("failed to read PlantUML template: %v", err)
+				return
+			}
+			tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Option("missingkey=error").Parse(string(data))
+			if err != nil {
+				dd.templatesErr = fmt.Errorf("invalid PlantUML template: %v", err)
+				return
+			}
+			dd.templates[name] = tmpl
+		}
+		dd.logger.Debug("Loaded %d PlantUML template(s) from %s", len(dd.templates), dir)
+	})
+	return dd.templates, dd.templatesErr
+}
+
+// diagramPlantUML returns the PlantUML of a detected diagram, rendered with the template of
+// its type when PLANTUML_TEMPLATE_DIR has one and from the built-in snippet otherwise.
+func (dd *DiagramDetector) diagramPlantUML(diagram DetectedDiagram) (string, error) {
+	templates, err := dd.loadTemplates()
+	if err != nil {
+		return "", err
+	}
+	tmpl := templates[templateName(diagram.Type)]
+	if tmpl == nil {
+		return dd.generatePlantUML(diagram.Type)
+	}
+	data := TemplateData{
+		Type:          diagram.Type.String(),
+		Confidence:    diagram.Confidence,
+		Image:         filepath.Base(diagram.ImagePath),
+		Detected:      len(diagram.Labels) > 0,
+		Labels:        diagram.Labels,
+		Relationships: diagram.Relationships,
+	}
+	if !data.Detected {
+		placeholders, ok := placeholderDiagrams[diagram.Type]
+		if !ok {
+			placeholders = placeholderDiagrams[UnknownDiagram]
+		}
+		data.Relationships = placeholders
+		data.Labels = relationshipLabels(placeholders)
+	}
+	var plantUML strings.Builder
+	plantUML.WriteString("@startuml\n")
+	dd.applyPlantUMLStyle(&plantUML)
+	if err := tmpl.Execute(&plantUML, data); err != nil {
+		return "", fmt.Errorf("failed to render PlantUML template: %v", err)
+	}
+	if !strings.HasSuffix(plantUML.String(), "\n") {
+		plantUML.WriteString("\n")
+	}
+	plantUML.WriteString("@enduml\n")
+	return plantUML.String(), nil
+}
+
+// relationshipLabels returns the labels of relationships in order of first appearance.
+func relationshipLabels(relationships []DiagramRelationship) []string {
+	var labels []string
+	seen := map[string]bool{}
+	for _, r := range relationships {
+		for _, label := range []string{r.From, r.To} {
+			if !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
+
+// LabelDiagram assigns the words recognized in the image of a diagram to it as labels and
+// renders its PlantUML again with the template of its type. Words on a line that are close
+// together form one label, and consecutive labels in reading order are related, since the
+// connector lines themselves are not traced. Diagrams without a template keep their
+// built-in snippet.
+func (dd *DiagramDetector) LabelDiagram(diagram *DetectedDiagram, words []PlotWord) error {
+	if diagram.Plot != nil || diagram.Omitted {
+		return nil
+	}
+	diagram.Labels = diagramLabels(words)
+	diagram.Relationships = nil
+	for i := 1; i < len(diagram.Labels); i++ {
+		diagram.Relationships = append(diagram.Relationships, DiagramRelationship{From: diagram.Labels[i-1], To: diagram.Labels[i]})
+	}
+	plantUML, err := dd.diagramPlantUML(*diagram)
+	if err != nil {
+		return err
+	}
+	diagram.PlantUML = plantUML
+	return nil
+}
+
+// diagramLabels groups words into labels in reading order: words whose boxes share a line
+// and are less than twice the word height apart form one label. Repeated labels are kept once.
+func diagramLabels(words []PlotWord) []string {
+	sorted := append([]PlotWord(nil), words...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if abs(sorted[i].Box.Min.Y-sorted[j].Box.Min.Y) > sorted[i].Box.Dy()/2 {
+			return sorted[i].Box.Min.Y < sorted[j].Box.Min.Y
+		}
+		return sorted[i].Box.Min.X < sorted[j].Box.Min.X
+	})
+	var labels []string
+	seen := map[string]bool{}
+	var phrase []string
+	var last image.Rectangle
+	flush := func() {
+		label := strings.Join(phrase, " ")
+		if label != "" && !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+		phrase = nil
+	}
+	for _, word := range sorted {
+		sameLine := len(phrase) > 0 && abs(word.Box.Min.Y-last.Min.Y) <= last.Dy()/2
+		if !sameLine || word.Box.Min.X-last.Max.X > 2*max(last.Dy(), word.Box.Dy()) {
+			flush()
+		}
+		phrase = append(phrase, word.Text)
+		last = word.Box
+	}
+	flush()
+	return labels
+}
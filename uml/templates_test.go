@@ -0,0 +1,81 @@
+package uml
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"datasheet-to-md-mcp/config"
+	"datasheet-to-md-mcp/logger"
+)
+
+const blockTemplate = `' In-house block diagram
+skinparam rectangleBorderColor #005A9C
+{{range .Labels}}rectangle {{quote .}} as {{alias .}}
+{{end}}{{range .Relationships}}{{alias .From}} --> {{alias .To}}{{if .Label}} : {{.Label}}{{end}}
+{{end}}`
+
+func TestDetectDiagramsInImage_Template(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "block.puml"), []byte(blockTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{DetectDiagrams: true, DiagramConfidence: 0.7, PlantUMLStyle: "blueprint", PlantUMLColorScheme: "auto", PlantUMLTemplateDir: dir}
+	d := NewDiagramDetector(cfg, logger.NewLogger("error"))
+
+	diagrams, err := d.DetectDiagramsInImage(context.Background(), createTempImageFile(t, "power_block.png"))
+	if err != nil || len(diagrams) != 1 {
+		t.Fatalf("expected one block diagram, got %v, %v", diagrams, err)
+	}
+	want := "@startuml\n!theme blueprint\n\n' In-house block diagram\nskinparam rectangleBorderColor #005A9C\n" +
+		"rectangle \"Input Signal\" as Input_Signal\nrectangle \"Processing Unit\" as Processing_Unit\nrectangle \"Output Signal\" as Output_Signal\n" +
+		"Input_Signal --> Processing_Unit\nProcessing_Unit --> Output_Signal\n@enduml\n"
+	if diagrams[0].PlantUML != want {
+		t.Errorf("expected the template with placeholder labels:\n%s\nwant:\n%s", diagrams[0].PlantUML, want)
+	}
+
+	// Recognized words replace the placeholders, one label per phrase
+	words := []PlotWord{
+		{Text: "ADC", Box: image.Rect(10, 10, 40, 22)},
+		{Text: "1", Box: image.Rect(44, 10, 52, 22)},
+		{Text: "DMA", Box: image.Rect(200, 10, 240, 22)},
+		{Text: "SRAM", Box: image.Rect(10, 100, 60, 112)},
+	}
+	if err := d.LabelDiagram(&diagrams[0], words); err != nil {
+		t.Fatalf("LabelDiagram() error = %v", err)
+	}
+	if want := []string{"ADC 1", "DMA", "SRAM"}; !reflect.DeepEqual(diagrams[0].Labels, want) {
+		t.Errorf("expected labels %v, got %v", want, diagrams[0].Labels)
+	}
+	for _, expected := range []string{"rectangle \"ADC 1\" as ADC_1\n", "ADC_1 --> DMA\nDMA --> SRAM\n"} {
+		if !strings.Contains(diagrams[0].PlantUML, expected) {
+			t.Errorf("expected %q in:\n%s", expected, diagrams[0].PlantUML)
+		}
+	}
+
+	// Types without a template keep the built-in snippet
+	diagrams, _ = d.DetectDiagramsInImage(context.Background(), createTempImageFile(t, "pmic_circuit.png"))
+	if len(diagrams) != 1 || !strings.Contains(diagrams[0].PlantUML, "COMPONENT(R1)") {
+		t.Errorf("expected the built-in circuit snippet, got %+v", diagrams)
+	}
+}
+
+func TestDetectDiagramsInImage_InvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "generic.puml"), []byte("{{range .Labels}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{DetectDiagrams: true, DiagramConfidence: 0.7, PlantUMLStyle: "default", PlantUMLColorScheme: "auto", PlantUMLTemplateDir: dir}
+	d := NewDiagramDetector(cfg, logger.NewLogger("error"))
+	if _, err := d.diagramPlantUML(DetectedDiagram{Type: UnknownDiagram}); err == nil || !strings.Contains(err.Error(), "invalid PlantUML template") {
+		t.Errorf("expected an invalid template error, got %v", err)
+	}
+	diagrams, err := d.DetectDiagramsInImage(context.Background(), createTempImageFile(t, "system_diagram.png"))
+	if err != nil || len(diagrams) != 0 {
+		t.Errorf("expected the diagram skipped with a warning, got %v, %v", diagrams, err)
+	}
+}